@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/sso"
+)
+
+// accountTarget is what --account resolved to: a configured profile, or a role from the
+// SSO access portal signed in to with the settings of a profile of its SSO session.
+type accountTarget struct {
+	profile string
+	role    *awslib.SSORole
+}
+
+// name is the profile name the target is opened and cached as. Constructed profiles are
+// named account/role, which no profile in the shared AWS config can be.
+func (t accountTarget) name() string {
+	if t.role == nil {
+		return t.profile
+	}
+	return t.role.AccountID + "/" + t.role.RoleName
+}
+
+// findAccount resolves an account ID, and optionally a role name, to a configured profile
+// that signs in to that account as that role. When no profile does, the access portals of
+// the SSO sessions signed in to are searched for the account, by ID or by name.
+func findAccount(ctx context.Context, account, role string, deps runDeps) (accountTarget, error) {
+	if deps.profiles == nil {
+		return accountTarget{}, i18n.Errorf("no AWS config to search for the account")
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return accountTarget{}, i18n.Errorf("failed to list profiles: %w", err)
+	}
+
+	switch names := accountProfiles(profiles, account, role); len(names) {
+	case 0:
+	case 1:
+		return accountTarget{profile: names[0]}, nil
+	default:
+		if role == "" {
+			return accountTarget{}, i18n.Errorf("profiles %s sign in to account %s; pass --role, or open one of them", strings.Join(names, ", "), account)
+		}
+		return accountTarget{}, i18n.Errorf("profiles %s sign in to account %s as %s; open one of them", strings.Join(names, ", "), account, role)
+	}
+
+	return findPortalAccount(ctx, profiles, account, role, deps)
+}
+
+// accountProfiles returns the names of the profiles that sign in to accountID, as role
+// when it is given.
+func accountProfiles(profiles []awslib.Profile, accountID, role string) []string {
+	var names []string
+	for _, p := range profiles {
+		if p.AccountID == accountID && (role == "" || strings.EqualFold(p.RoleName, role)) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// portalSession is the access portal of an SSO session with a valid cached token.
+type portalSession struct {
+	// profile is the first profile of the session; roles opened through the portal use
+	// its settings.
+	profile awslib.Profile
+	key     string
+	config  sso.ClientConfig
+	token   ssocache.Token
+	portal  *sso.Portal
+}
+
+// target returns the configured profile that signs in to accountID as role, or the
+// role itself from the session's portal when none does.
+func (s portalSession) target(profiles []awslib.Profile, accountID, role string, deps runDeps) accountTarget {
+	if names := accountProfiles(profiles, accountID, role); len(names) > 0 {
+		return accountTarget{profile: names[0]}
+	}
+	return accountTarget{profile: s.profile.Name, role: &awslib.SSORole{
+		AccountID: accountID,
+		RoleName:  role,
+		StartURL:  s.config.StartURL,
+		Region:    s.config.Region,
+		TokenFile: deps.ssoTokens.Path(s.key),
+	}}
+}
+
+// portalSessions returns the access portal of every SSO session of profiles that is
+// signed in to, and the first profile of each session that is not.
+func portalSessions(profiles []awslib.Profile, deps runDeps) ([]portalSession, []string, error) {
+	if deps.ssoTokens == nil || deps.ssoPortal == nil {
+		return nil, nil, nil
+	}
+	var sessions []portalSession
+	var signedOut []string
+	for _, p := range ssoSessionProfiles(profiles) {
+		key := ssocache.Key(p.SSOSession, p.SSOStartURL)
+		if key == "" {
+			continue
+		}
+		token, err := deps.ssoTokens.Token(key)
+		if err != nil || token.Expired(deps.now()) {
+			verbosef(deps, "Skipping the access portal of %s, which is not signed in", key)
+			signedOut = append(signedOut, p.Name)
+			continue
+		}
+		cfg, err := ssoClientConfig(p, deps)
+		if err != nil {
+			return nil, nil, err
+		}
+		sessions = append(sessions, portalSession{
+			profile: p,
+			key:     key,
+			config:  cfg,
+			token:   token,
+			portal:  sso.NewPortal(deps.ssoPortal(cfg.Region)),
+		})
+	}
+	return sessions, signedOut, nil
+}
+
+// findPortalAccount searches the access portal of every SSO session signed in to for
+// account, and returns the role to sign in to it with.
+func findPortalAccount(ctx context.Context, profiles []awslib.Profile, account, role string, deps runDeps) (accountTarget, error) {
+	sessions, signedOut, err := portalSessions(profiles, deps)
+	if err != nil {
+		return accountTarget{}, err
+	}
+	for _, s := range sessions {
+		verbosef(deps, "Searching the access portal of %s for account %s", s.key, account)
+		accounts, err := s.portal.Accounts(ctx, s.token.AccessToken)
+		if err != nil {
+			return accountTarget{}, err
+		}
+		i := slices.IndexFunc(accounts, func(a sso.Account) bool {
+			return a.ID == account || strings.EqualFold(a.Name, account)
+		})
+		if i < 0 {
+			continue
+		}
+		accountID := accounts[i].ID
+		roles, err := s.portal.AccountRoles(ctx, s.token.AccessToken, accountID)
+		if err != nil {
+			return accountTarget{}, err
+		}
+		roleName, err := chooseAccountRole(accountID, role, roles)
+		if err != nil {
+			return accountTarget{}, err
+		}
+		// A profile found by the account's ID is preferred over one constructed for it.
+		return s.target(profiles, accountID, roleName, deps), nil
+	}
+
+	err = i18n.Errorf("no profile or SSO access portal gives access to account %s", account)
+	if role != "" {
+		err = i18n.Errorf("no profile or SSO access portal gives access to account %s as %s", account, role)
+	}
+	if len(signedOut) > 0 {
+		return accountTarget{}, i18n.Errorf("%w; sign in with 'aws-console -p %s' to search its access portal too", err, signedOut[0])
+	}
+	return accountTarget{}, err
+}
+
+// chooseAccountRole returns the assigned role named role, or the only role assigned when
+// none is named.
+func chooseAccountRole(accountID, role string, roles []string) (string, error) {
+	if len(roles) == 0 {
+		return "", i18n.Errorf("no roles are assigned to you in account %s", accountID)
+	}
+	if role == "" {
+		if len(roles) > 1 {
+			return "", i18n.Errorf("account %s has roles %s; pass --role", accountID, strings.Join(roles, ", "))
+		}
+		return roles[0], nil
+	}
+	if i := slices.IndexFunc(roles, func(r string) bool { return strings.EqualFold(r, role) }); i >= 0 {
+		return roles[i], nil
+	}
+	return "", i18n.Errorf("role %s is not assigned to you in account %s; assigned: %s", role, accountID, strings.Join(roles, ", "))
+}
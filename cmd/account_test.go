@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ssoportal "github.com/aws/aws-sdk-go-v2/service/sso"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/sso"
+)
+
+// fakePortal is an access portal that assigns roles by account ID.
+type fakePortal struct {
+	accounts map[string]string
+	roles    map[string][]string
+}
+
+func (f fakePortal) ListAccounts(ctx context.Context, params *ssoportal.ListAccountsInput, optFns ...func(*ssoportal.Options)) (*ssoportal.ListAccountsOutput, error) {
+	out := &ssoportal.ListAccountsOutput{}
+	for id, name := range f.accounts {
+		out.AccountList = append(out.AccountList, ssotypes.AccountInfo{AccountId: aws.String(id), AccountName: aws.String(name)})
+	}
+	return out, nil
+}
+
+func (f fakePortal) ListAccountRoles(ctx context.Context, params *ssoportal.ListAccountRolesInput, optFns ...func(*ssoportal.Options)) (*ssoportal.ListAccountRolesOutput, error) {
+	out := &ssoportal.ListAccountRolesOutput{}
+	for _, role := range f.roles[aws.ToString(params.AccountId)] {
+		out.RoleList = append(out.RoleList, ssotypes.RoleInfo{AccountId: params.AccountId, RoleName: aws.String(role)})
+	}
+	return out, nil
+}
+
+// portalTestDeps returns deps with SSO profiles of two sessions: corp, signed in to an
+// access portal of three accounts, and a legacy session that is signed out.
+func portalTestDeps(t *testing.T) runDeps {
+	t.Helper()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	profiles := []awslib.Profile{
+		{Name: "dev", Source: awslib.ProfileSourceSSO, SSOSession: "corp", AccountID: "111111111111", RoleName: "Admin"},
+		{Name: "dev-ro", Source: awslib.ProfileSourceSSO, SSOSession: "corp", AccountID: "111111111111", RoleName: "ReadOnly"},
+		{Name: "prod", Source: awslib.ProfileSourceAssumeRole, AccountID: "222222222222", RoleName: "Deploy"},
+		{Name: "legacy", Source: awslib.ProfileSourceSSO, SSOStartURL: "https://legacy.awsapps.com/start", SSORegion: "us-west-2", AccountID: "333333333333", RoleName: "Admin"},
+	}
+	portal := fakePortal{
+		accounts: map[string]string{"111111111111": "dev", "444444444444": "sandbox", "555555555555": "audit"},
+		roles: map[string][]string{
+			"111111111111": {"ReadOnly", "Admin"},
+			"444444444444": {"Admin", "ReadOnly"},
+			"555555555555": {"Auditor"},
+		},
+	}
+	tokens := ssocache.NewCacheAt(t.TempDir())
+	if err := tokens.Put("corp", ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("failed to cache token: %v", err)
+	}
+	return runDeps{
+		profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) { return profiles, nil }},
+		ssoSessions: &mocks.SSOSessionReader{SSOSessionFunc: func(name string) (awslib.SSOSession, error) {
+			return awslib.SSOSession{Name: name, StartURL: "https://corp.awsapps.com/start", Region: "eu-west-1"}, nil
+		}},
+		ssoTokens: tokens,
+		ssoPortal: func(region string) sso.PortalAPI { return portal },
+		now:       func() time.Time { return now },
+		stdout:    &bytes.Buffer{},
+		stderr:    &bytes.Buffer{},
+	}
+}
+
+func TestOpenAccount(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		wantProfile   string
+		wantRole      *awslib.SSORole
+		wantErrSubstr string
+	}{
+		{
+			name:        "configured profile",
+			args:        []string{"--account", "222222222222"},
+			wantProfile: "prod",
+		},
+		{
+			name:          "several configured profiles",
+			args:          []string{"--account", "111111111111"},
+			wantErrSubstr: "profiles dev, dev-ro sign in to account 111111111111; pass --role",
+		},
+		{
+			name:        "configured profile with role",
+			args:        []string{"--account", "111111111111", "--role", "readonly"},
+			wantProfile: "dev-ro",
+		},
+		{
+			name:        "account name from the portal with a configured profile",
+			args:        []string{"--account", "dev", "--role", "Admin"},
+			wantProfile: "dev",
+		},
+		{
+			name:        "only role in the portal",
+			args:        []string{"--account", "555555555555"},
+			wantProfile: "555555555555/Auditor",
+			wantRole:    &awslib.SSORole{AccountID: "555555555555", RoleName: "Auditor", StartURL: "https://corp.awsapps.com/start", Region: "eu-west-1"},
+		},
+		{
+			name:        "account name and role from the portal",
+			args:        []string{"--account", "sandbox", "--role", "admin"},
+			wantProfile: "444444444444/Admin",
+			wantRole:    &awslib.SSORole{AccountID: "444444444444", RoleName: "Admin", StartURL: "https://corp.awsapps.com/start", Region: "eu-west-1"},
+		},
+		{
+			name:          "several roles in the portal",
+			args:          []string{"--account", "sandbox"},
+			wantErrSubstr: "account 444444444444 has roles Admin, ReadOnly; pass --role",
+		},
+		{
+			name:          "role not assigned",
+			args:          []string{"--account", "sandbox", "--role", "Billing"},
+			wantErrSubstr: "role Billing is not assigned to you in account 444444444444; assigned: Admin, ReadOnly",
+		},
+		{
+			name:          "unknown account",
+			args:          []string{"--account", "999999999999"},
+			wantErrSubstr: "no profile or SSO access portal gives access to account 999999999999; sign in with 'aws-console -p legacy'",
+		},
+		{
+			name:          "with profiles",
+			args:          []string{"--account", "222222222222", "dev"},
+			wantErrSubstr: "--account cannot be combined with profiles",
+		},
+		{
+			name:          "role without account",
+			args:          []string{"--role", "Admin"},
+			wantErrSubstr: "--role requires --account",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var logins []string
+			deps := portalTestDeps(t)
+			deps.login = func(ctx context.Context, profile string) error {
+				logins = append(logins, profile)
+				return nil
+			}
+
+			var opened string
+			var role awslib.SSORole
+			var hasRole bool
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				opened = opts.profile
+				role, hasRole = awslib.SSORoleFromContext(ctx)
+				return deps.login(ctx, opts.profile)
+			})
+			root.SetArgs(append([]string{"open"}, tc.args...))
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if opened != tc.wantProfile {
+				t.Fatalf("expected %s to be opened, got %q", tc.wantProfile, opened)
+			}
+			if tc.wantRole == nil {
+				if hasRole {
+					t.Fatalf("expected a configured profile, got role %+v", role)
+				}
+				if len(logins) != 1 || logins[0] != tc.wantProfile {
+					t.Fatalf("expected a login to %s, got %q", tc.wantProfile, logins)
+				}
+				return
+			}
+			want := *tc.wantRole
+			want.TokenFile = deps.ssoTokens.Path("corp")
+			if !hasRole || role != want {
+				t.Fatalf("expected role %+v, got %+v", want, role)
+			}
+			// Logging in again refreshes the token of the session the role came from.
+			if len(logins) != 1 || logins[0] != "dev" {
+				t.Fatalf("expected a login to dev, got %q", logins)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/eculver/aws-console/pkg/sso"
+	"github.com/spf13/cobra"
+)
+
+func newAccountsCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var open bool
+	var flags workflowFlags
+
+	accountsCmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "List the accounts and roles your SSO sessions can sign in to",
+		Long: `Lists every account and role assigned to you in the access portal of each SSO
+session you are signed in to, with the profile that signs in to it where one is
+configured. Sessions are found through the SSO profiles of the shared AWS config, and
+their cached tokens are used, so nothing is listed for a session until you sign in
+to one of its profiles.
+
+With --open, pick an account and role to open its console, even when no profile
+exists for it yet.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			deps.messages = g.printer()
+			if open && (deps.picker == nil || !deps.term.Interactive()) {
+				return i18n.Errorf("--open needs an interactive terminal; use 'aws-console open --account' instead")
+			}
+
+			assignments, err := listAssignments(context.Background(), deps)
+			if err != nil {
+				return err
+			}
+			if !open {
+				return renderAssignments(g.output, assignments, deps)
+			}
+			if len(assignments) == 0 {
+				return i18n.Errorf("no accounts to open")
+			}
+
+			items := make([]string, 0, len(assignments))
+			for _, a := range assignments {
+				items = append(items, a.label())
+			}
+			i, err := deps.picker.Pick("account", items)
+			if errors.Is(err, prompt.ErrCanceled) {
+				return i18n.Errorf("no account selected")
+			}
+			if err != nil {
+				return i18n.Errorf("failed to select an account: %w", err)
+			}
+			return openAccountTarget(cmd, assignments[i].target, "", "", flags, deps, runner)
+		},
+	}
+
+	accountsCmd.Flags().BoolVar(&open, "open", false, "Pick an account and role to open in the console")
+	addWorkflowFlags(accountsCmd, &flags)
+	return accountsCmd
+}
+
+// assignment is a role assigned to the user in an account of an SSO access portal.
+type assignment struct {
+	session     string
+	accountID   string
+	accountName string
+	role        string
+	target      accountTarget
+}
+
+// label describes an assignment in the picker, so searches match its account and role.
+func (a assignment) label() string {
+	label := fmt.Sprintf("%s (%s) %s", a.accountName, a.accountID, a.role)
+	if a.target.role == nil {
+		label += ", profile " + a.target.profile
+	}
+	return label
+}
+
+// listAssignments returns the roles assigned in the access portal of every SSO session
+// signed in to, ordered by session, account name, and role.
+func listAssignments(ctx context.Context, deps runDeps) ([]assignment, error) {
+	if deps.profiles == nil {
+		return nil, i18n.Errorf("no AWS config to find SSO sessions in")
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return nil, i18n.Errorf("failed to list profiles: %w", err)
+	}
+	sessions, signedOut, err := portalSessions(profiles, deps)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range signedOut {
+		deps.messages.Fprintf(deps.stderr, "Warning: the SSO session of %s is not signed in; sign in with 'aws-console -p %s' to list its accounts\n", describeProfile(name), name)
+	}
+	if len(sessions) == 0 && len(signedOut) == 0 {
+		return nil, i18n.Errorf("no SSO profiles configured (add one with 'aws configure sso')")
+	}
+
+	var assignments []assignment
+	for _, s := range sessions {
+		accounts, err := s.portal.Accounts(ctx, s.token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		slices.SortFunc(accounts, func(a, b sso.Account) int {
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+		})
+		for _, account := range accounts {
+			roles, err := s.portal.AccountRoles(ctx, s.token.AccessToken, account.ID)
+			if err != nil {
+				return nil, err
+			}
+			slices.Sort(roles)
+			for _, role := range roles {
+				assignments = append(assignments, assignment{
+					session:     s.key,
+					accountID:   account.ID,
+					accountName: account.Name,
+					role:        role,
+					target:      s.target(profiles, account.ID, role, deps),
+				})
+			}
+		}
+	}
+	return assignments, nil
+}
+
+func renderAssignments(format output.Format, assignments []assignment, deps runDeps) error {
+	table := output.Table{
+		Columns: []output.Column{
+			{Header: "SESSION", Key: "session"},
+			{Header: "ACCOUNT", Key: "account"},
+			{Header: "NAME", Key: "name"},
+			{Header: "ROLE", Key: "role"},
+			{Header: "PROFILE", Key: "profile"},
+		},
+	}
+	for _, a := range assignments {
+		profile := ""
+		if a.target.role == nil {
+			profile = a.target.profile
+		}
+		table.Rows = append(table.Rows, []string{a.session, a.accountID, a.accountName, a.role, profile})
+	}
+	return output.Render(deps.stdout, format, table)
+}
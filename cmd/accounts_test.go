@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestAccountsCmd(t *testing.T) {
+	t.Parallel()
+
+	deps := portalTestDeps(t)
+	stderr := &bytes.Buffer{}
+	deps.stderr = stderr
+
+	out, err := executeSubcommand(t, deps, "accounts", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := strings.Join([]string{
+		"session,account,name,role,profile",
+		"corp,555555555555,audit,Auditor,",
+		"corp,111111111111,dev,Admin,dev",
+		"corp,111111111111,dev,ReadOnly,dev-ro",
+		"corp,444444444444,sandbox,Admin,",
+		"corp,444444444444,sandbox,ReadOnly,",
+	}, "\n") + "\n"
+	if out != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, out)
+	}
+	if !strings.Contains(stderr.String(), `Warning: the SSO session of profile "legacy" is not signed in`) {
+		t.Fatalf("expected a warning about the signed-out session, got %q", stderr.String())
+	}
+}
+
+func TestAccountsCmdNoSSOProfiles(t *testing.T) {
+	t.Parallel()
+
+	deps := portalTestDeps(t)
+	deps.profiles = &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) { return testProfiles()[1:], nil }}
+	if _, err := executeSubcommand(t, deps, "accounts"); err == nil || !strings.Contains(err.Error(), "no SSO profiles configured") {
+		t.Fatalf("expected an error about missing SSO profiles, got %v", err)
+	}
+}
+
+func TestAccountsCmdOpen(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		interactive   bool
+		choice        int
+		wantProfile   string
+		wantRole      bool
+		wantErrSubstr string
+	}{
+		{name: "constructed profile", interactive: true, choice: 3, wantProfile: "444444444444/Admin", wantRole: true},
+		{name: "configured profile", interactive: true, choice: 2, wantProfile: "dev-ro"},
+		{name: "not interactive", wantErrSubstr: "--open needs an interactive terminal"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := portalTestDeps(t)
+			picker := &fakePicker{choice: tc.choice}
+			deps.picker = picker
+			if tc.interactive {
+				deps.term = interactiveTerminal
+			}
+
+			var opened string
+			var hasRole bool
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				opened = opts.profile
+				_, hasRole = awslib.SSORoleFromContext(ctx)
+				return nil
+			})
+			root.SetArgs([]string{"accounts", "--open"})
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opened != tc.wantProfile || hasRole != tc.wantRole {
+				t.Fatalf("expected %s to be opened (role %v), got %q (role %v)", tc.wantProfile, tc.wantRole, opened, hasRole)
+			}
+			if len(picker.items) != 5 || picker.items[2] != "dev (111111111111) ReadOnly, profile dev-ro" {
+				t.Fatalf("unexpected picker items %q", picker.items)
+			}
+		})
+	}
+}
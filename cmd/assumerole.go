@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	roleSessionNamePattern = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+	mfaTokenPattern        = regexp.MustCompile(`^[0-9]{6}$`)
+)
+
+func addAssumeRoleFlags(cmd *cobra.Command, input *awslib.AssumeRoleInput) {
+	cmd.Flags().StringVar(&input.RoleARN, "role-arn", "", "Assume this IAM role before opening the console")
+	cmd.Flags().StringVar(&input.ExternalID, "external-id", "", "External ID required by the role's trust policy")
+	cmd.Flags().StringVar(&input.SessionName, "session-name", "", "Role session name shown in CloudTrail (defaults to the session-name-template setting)")
+	cmd.Flags().StringVar(&input.MFASerial, "mfa-serial", "", "ARN or serial number of the MFA device required by the role or for session tokens")
+	cmd.Flags().StringVar(&input.MFAToken, "mfa-token", "", "Current MFA code (prompted for when omitted in a terminal)")
+	cmd.Flags().Var(&sessionTagFlag{tags: &input.Tags}, "session-tag", "Tag the role session with key=value, for IAM policies and CloudTrail; repeat for several")
+	cmd.Flags().StringSliceVar(&input.TransitiveTagKeys, "transitive-tag-key", nil, "Pass the session tag with this key on to roles assumed from the session; repeat for several")
+	cmd.Flags().StringVar(&input.SourceIdentity, "source-identity", "", "Source identity recorded in CloudTrail for the role session and every role assumed from it")
+	cmd.Flags().StringVar(&input.Reason, "reason", "", "Why the console is opened, such as a ticket ID; tagged on the role session and available to the session name template")
+}
+
+// sessionTagFlag is the --session-tag flag, which can be repeated to tag the role session
+// with several key=value pairs.
+type sessionTagFlag struct {
+	tags *[]awslib.SessionTag
+}
+
+func (f *sessionTagFlag) String() string {
+	if f.tags == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*f.tags))
+	for _, tag := range *f.tags {
+		pairs = append(pairs, tag.String())
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f *sessionTagFlag) Set(value string) error {
+	tag, err := awslib.ParseSessionTag(value)
+	if err != nil {
+		return err
+	}
+	*f.tags = append(*f.tags, tag)
+	return nil
+}
+
+func (f *sessionTagFlag) Type() string {
+	return "key=value"
+}
+
+// validateAssumeRole checks the assume-role flags before any AWS call is made. The MFA
+// flags also apply to the session token requested for IAM user keys.
+func validateAssumeRole(input awslib.AssumeRoleInput) error {
+	if input.MFAToken != "" && !mfaTokenPattern.MatchString(input.MFAToken) {
+		return i18n.Errorf("invalid --mfa-token: expected a 6-digit code")
+	}
+	if input.RoleARN == "" {
+		if input.ExternalID != "" || input.SessionName != "" {
+			return i18n.Errorf("--external-id and --session-name require --role-arn")
+		}
+		if len(input.Tags) > 0 || len(input.TransitiveTagKeys) > 0 || input.SourceIdentity != "" || input.Reason != "" {
+			return i18n.Errorf("--session-tag, --transitive-tag-key, --source-identity, and --reason require --role-arn")
+		}
+		return nil
+	}
+
+	if _, _, _, err := awslib.ParseRoleARN(input.RoleARN); err != nil {
+		return i18n.Errorf("invalid --role-arn: %w", err)
+	}
+	if input.SessionName != "" && !roleSessionNamePattern.MatchString(input.SessionName) {
+		return i18n.Errorf("invalid --session-name %q (2-64 letters, digits, or +=,.@_- characters)", input.SessionName)
+	}
+	if input.MFAToken != "" && input.MFASerial == "" {
+		return i18n.Errorf("--mfa-token requires --mfa-serial")
+	}
+	if input.Reason != "" {
+		if err := (awslib.SessionTag{Key: awslib.ReasonTagKey, Value: input.Reason}).Validate(); err != nil {
+			return i18n.Errorf("invalid --reason: %w", err)
+		}
+	}
+	if err := awslib.ValidateSessionTags(input.SessionTags(), input.TransitiveTagKeys); err != nil {
+		return err
+	}
+	if input.SourceIdentity != "" {
+		if err := awslib.ValidateSourceIdentity(input.SourceIdentity); err != nil {
+			return i18n.Errorf("invalid --source-identity: %w", err)
+		}
+	}
+	return nil
+}
+
+// promptMFAToken asks for the current code of the MFA device serial on the terminal.
+func promptMFAToken(serial string, deps runDeps) (string, error) {
+	if !deps.term.Interactive() {
+		return "", i18n.Errorf("--mfa-token is required for MFA device %s when not running in a terminal", serial)
+	}
+
+	deps.messages.Promptf(deps.stderr, "MFA code for %s: ", serial)
+	line, err := bufio.NewReader(deps.stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", i18n.Errorf("failed to read MFA code: %w", err)
+	}
+	token := strings.TrimSpace(line)
+	if !mfaTokenPattern.MatchString(token) {
+		return "", i18n.Errorf("invalid MFA code: expected 6 digits")
+	}
+	return token, nil
+}
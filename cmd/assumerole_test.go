@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+const testRoleARN = "arn:aws:iam::210987654321:role/Admin"
+
+func TestValidateAssumeRole(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		input         awslib.AssumeRoleInput
+		wantErrSubstr string
+	}{
+		{name: "no role"},
+		{name: "role only", input: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+		{
+			name: "all options",
+			input: awslib.AssumeRoleInput{
+				RoleARN: testRoleARN, ExternalID: "ext", SessionName: "alice@example.com",
+				MFASerial: "arn:aws:iam::123456789012:mfa/alice", MFAToken: "123456",
+			},
+		},
+		{
+			name:          "options without role",
+			input:         awslib.AssumeRoleInput{ExternalID: "ext"},
+			wantErrSubstr: "require --role-arn",
+		},
+		{
+			name:  "MFA for a session token",
+			input: awslib.AssumeRoleInput{MFASerial: "arn:aws:iam::123456789012:mfa/alice", MFAToken: "123456"},
+		},
+		{
+			name:          "malformed token without role",
+			input:         awslib.AssumeRoleInput{MFAToken: "abc"},
+			wantErrSubstr: "expected a 6-digit code",
+		},
+		{
+			name:          "not a role ARN",
+			input:         awslib.AssumeRoleInput{RoleARN: "arn:aws:iam::210987654321:user/alice"},
+			wantErrSubstr: "invalid --role-arn",
+		},
+		{
+			name:          "bad session name",
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, SessionName: "has space"},
+			wantErrSubstr: `invalid --session-name "has space"`,
+		},
+		{
+			name:          "token without serial",
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, MFAToken: "123456"},
+			wantErrSubstr: "--mfa-token requires --mfa-serial",
+		},
+		{
+			name: "session tags",
+			input: awslib.AssumeRoleInput{
+				RoleARN: testRoleARN, Tags: []awslib.SessionTag{{Key: "team", Value: "payments"}},
+				TransitiveTagKeys: []string{"team"}, SourceIdentity: "alice",
+			},
+		},
+		{
+			name:          "session tags without role",
+			input:         awslib.AssumeRoleInput{Tags: []awslib.SessionTag{{Key: "team", Value: "payments"}}},
+			wantErrSubstr: "--reason require --role-arn",
+		},
+		{
+			name:          "reason without role",
+			input:         awslib.AssumeRoleInput{Reason: "INC-1234"},
+			wantErrSubstr: "--reason require --role-arn",
+		},
+		{
+			name:  "reason",
+			input: awslib.AssumeRoleInput{RoleARN: testRoleARN, Reason: "INC-1234 rotate keys"},
+		},
+		{
+			name:          "bad reason",
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, Reason: "rotate keys; restart"},
+			wantErrSubstr: "invalid --reason: invalid value for session tag Reason",
+		},
+		{
+			name:          "reason and reason tag",
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, Reason: "INC-1234", Tags: []awslib.SessionTag{{Key: "reason", Value: "other"}}},
+			wantErrSubstr: "session tag Reason is given more than once",
+		},
+		{
+			name:          "transitive key without tag",
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, TransitiveTagKeys: []string{"team"}},
+			wantErrSubstr: "transitive tag key team is not one of the session tags",
+		},
+		{
+			name:          "bad source identity",
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, SourceIdentity: "alice smith"},
+			wantErrSubstr: `invalid --source-identity: invalid source identity "alice smith"`,
+		},
+		{
+			name:          "malformed token",
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, MFASerial: "serial", MFAToken: "12345"},
+			wantErrSubstr: "expected a 6-digit code",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateAssumeRole(tc.input)
+			if tc.wantErrSubstr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
+
+func TestRoleSessionName(t *testing.T) {
+	t.Parallel()
+
+	now := func() time.Time { return time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC) }
+
+	testCases := []struct {
+		name     string
+		template string
+		reason   string
+		want     string
+	}{
+		{name: "ticket", template: "{ticket}-{time}", reason: "INC-1234 rotate keys", want: "INC-1234-20261016T093000Z"},
+		{name: "reason", template: "ops-{reason}", reason: "rotate keys", want: "ops-rotate-keys"},
+		{name: "no ticket", template: "ops-{ticket}-{time}", reason: "rotate keys", want: "ops-20261016T093000Z"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := roleSessionName(tc.reason, runDeps{now: now, sessionName: tc.template})
+			if got != tc.want {
+				t.Fatalf("expected session name %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	got := roleSessionName("", runDeps{now: now})
+	if !strings.HasPrefix(got, "aws-console-") || !strings.HasSuffix(got, "-20261016T093000Z") {
+		t.Fatalf("unexpected default session name %q", got)
+	}
+}
+
+func TestRunWorkflowAssumesRole(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		base          awslib.Credentials
+		input         awslib.AssumeRoleInput
+		term          term.Info
+		stdin         string
+		duration      int32
+		durationSet   bool
+		wantDuration  int32
+		wantToken     string
+		wantErrSubstr string
+	}{
+		{
+			name:         "temporary base credentials are limited to an hour",
+			base:         awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"},
+			input:        awslib.AssumeRoleInput{RoleARN: testRoleARN, ExternalID: "ext"},
+			term:         interactiveTerminal,
+			wantDuration: 3600,
+		},
+		{
+			name:          "explicit duration too long for role chaining",
+			base:          awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"},
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN},
+			duration:      7200,
+			durationSet:   true,
+			wantErrSubstr: "invalid duration 2h0m0s: sessions of a role assumed with role credentials (role chaining) last at most 1h0m0s",
+		},
+		{
+			name:         "explicit duration within the role chaining limit",
+			base:         awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"},
+			input:        awslib.AssumeRoleInput{RoleARN: testRoleARN},
+			duration:     1800,
+			durationSet:  true,
+			wantDuration: 1800,
+		},
+		{
+			name:         "long-lived keys keep the full duration",
+			base:         awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			input:        awslib.AssumeRoleInput{RoleARN: testRoleARN},
+			term:         interactiveTerminal,
+			wantDuration: sessionDuration,
+		},
+		{
+			name:         "prompts for the MFA code",
+			base:         awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			input:        awslib.AssumeRoleInput{RoleARN: testRoleARN, MFASerial: "arn:aws:iam::123456789012:mfa/alice"},
+			term:         interactiveTerminal,
+			stdin:        "654321\n",
+			wantDuration: sessionDuration,
+			wantToken:    "654321",
+		},
+		{
+			name:          "no MFA prompt without a terminal",
+			base:          awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			input:         awslib.AssumeRoleInput{RoleARN: testRoleARN, MFASerial: "arn:aws:iam::123456789012:mfa/alice"},
+			wantErrSubstr: "--mfa-token is required for MFA device arn:aws:iam::123456789012:mfa/alice",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got awslib.AssumeRoleInput
+			var federated awslib.Credentials
+			var federatedDuration int32
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/alice", Account: "123456789012"}, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return tc.base, nil
+				},
+				AssumeRoleFunc: func(ctx context.Context, profile string, input awslib.AssumeRoleInput) (awslib.Credentials, error) {
+					got = input
+					return awslib.Credentials{AccessKeyID: "ASIAROLE", SecretAccessKey: "secret", SessionToken: "role-token"}, nil
+				},
+			}
+			deps := runDeps{
+				awsService: service,
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						federated = creds
+						federatedDuration = durationSeconds
+						return "https://example.com/console-login", nil
+					},
+				},
+				open:            func(targetURL string, opts browserOptions) error { return nil },
+				term:            tc.term,
+				stdin:           strings.NewReader(tc.stdin),
+				stdout:          &bytes.Buffer{},
+				stderr:          &bytes.Buffer{},
+				sessionDuration: sessionDuration,
+				durationSet:     tc.durationSet,
+			}
+			if tc.duration != 0 {
+				deps.sessionDuration = tc.duration
+			}
+
+			err := runWorkflow(context.Background(), workflowOptions{profile: "dev", assumeRole: tc.input}, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if service.AssumeRoleCalls.Load() != 0 {
+					t.Fatal("expected the error before AssumeRole is called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.RoleARN != testRoleARN || got.ExternalID != tc.input.ExternalID || got.DurationSeconds != tc.wantDuration || got.MFAToken != tc.wantToken {
+				t.Fatalf("unexpected AssumeRole input: %+v", got)
+			}
+			if federated.AccessKeyID != "ASIAROLE" {
+				t.Fatalf("expected the role's credentials to be federated, got %+v", federated)
+			}
+			if federatedDuration != tc.wantDuration {
+				t.Fatalf("expected a %ds console session, got %d", tc.wantDuration, federatedDuration)
+			}
+			if service.GetSessionTokenCalls.Load() != 0 {
+				t.Fatal("expected GetSessionToken to be skipped when assuming a role")
+			}
+		})
+	}
+}
+
+func TestWorkflowFlagsAssumeRole(t *testing.T) {
+	t.Parallel()
+
+	var captured *workflowOptions
+	deps := runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		captured = &opts
+		return nil
+	})
+	root.SetArgs([]string{
+		"-p", "dev", "--role-arn", testRoleARN, "--session-name", "alice", "--external-id", "ext",
+		"--session-tag", "team=payments", "--session-tag", "env=prod", "--transitive-tag-key", "team", "--source-identity", "alice",
+	})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := awslib.AssumeRoleInput{
+		RoleARN: testRoleARN, SessionName: "alice", ExternalID: "ext",
+		Tags:              []awslib.SessionTag{{Key: "team", Value: "payments"}, {Key: "env", Value: "prod"}},
+		TransitiveTagKeys: []string{"team"},
+		SourceIdentity:    "alice",
+	}
+	if captured == nil || !reflect.DeepEqual(captured.assumeRole, want) {
+		t.Fatalf("unexpected workflow options: %+v", captured)
+	}
+}
+
+func TestRunWorkflowReadOnly(t *testing.T) {
+	t.Parallel()
+
+	var policies []*awslib.SessionPolicy
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/alice", Account: "123456789012", Partition: "aws"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+		},
+		GetRoleARNFunc: func(ctx context.Context, profile string, sessionARN string) (string, error) {
+			return "arn:aws:iam::123456789012:role/Admin", nil
+		},
+		AssumeRoleFunc: func(ctx context.Context, profile string, input awslib.AssumeRoleInput) (awslib.Credentials, error) {
+			policies = append(policies, input.SessionPolicy)
+			return awslib.Credentials{AccessKeyID: "ASIAREADONLY", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	federation := &mocks.FederationBuilder{
+		BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+			if creds.AccessKeyID != "ASIAREADONLY" {
+				t.Fatalf("expected the read-only credentials to be federated, got %+v", creds)
+			}
+			return "https://example.com/console-login", nil
+		},
+	}
+	cache := credcache.NewCacheAt(t.TempDir())
+
+	// Read-only credentials are neither taken from nor added to the cache.
+	for i := 0; i < 2; i++ {
+		stdout := &bytes.Buffer{}
+		deps := runDeps{
+			awsService:      service,
+			federation:      federation,
+			credentials:     cache,
+			open:            func(targetURL string, opts browserOptions) error { return nil },
+			term:            interactiveTerminal,
+			stdout:          stdout,
+			stderr:          &bytes.Buffer{},
+			now:             time.Now,
+			sessionDuration: sessionDuration,
+		}
+		if err := runWorkflow(context.Background(), workflowOptions{profile: "dev", readOnly: true}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(stdout.String(), "Access: read-only (ReadOnlyAccess session policy)") {
+			t.Fatalf("expected read-only access to be reported, got:\n%s", stdout.String())
+		}
+	}
+	if len(policies) != 2 {
+		t.Fatalf("expected the role to be assumed on each run, got %d", len(policies))
+	}
+	for _, p := range policies {
+		if p == nil || len(p.PolicyARNs) != 1 || p.PolicyARNs[0] != "arn:aws:iam::aws:policy/ReadOnlyAccess" {
+			t.Fatalf("unexpected session policy %+v", p)
+		}
+	}
+	if _, _, ok := cache.Credentials("dev", ""); ok {
+		t.Fatal("expected read-only credentials not to be cached")
+	}
+}
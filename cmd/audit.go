@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/audit"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/config"
+)
+
+// newAuditEmitter returns an emitter for the audit settings of the config file, or nil
+// when auditing is off. Header values are expanded from the environment, so secrets can
+// stay out of the file. Records are sent through transport, when the proxy or CA bundle
+// settings need one.
+func newAuditEmitter(settings config.Audit, transport *http.Transport, deps runDeps) *audit.Emitter {
+	if !settings.Enabled() {
+		return nil
+	}
+	var client *http.Client
+	if transport != nil {
+		client = &http.Client{Transport: transport}
+	}
+	var sinks []audit.Sink
+	if settings.Webhook != "" {
+		headers := make(map[string]string, len(settings.Headers))
+		for k, v := range settings.Headers {
+			headers[k] = os.ExpandEnv(v)
+		}
+		sinks = append(sinks, &audit.Webhook{URL: settings.Webhook, Headers: headers, Client: client})
+	}
+	if settings.EventBus != "" {
+		bus := audit.NewEventBridge(settings.EventBus, settings.Profile, settings.Region)
+		bus.HTTPClient = client
+		sinks = append(sinks, bus)
+	}
+	queue := ""
+	if deps.stateDir != "" {
+		queue = filepath.Join(deps.stateDir, audit.QueueFileName)
+	}
+	return audit.NewEmitter(queue, sinks...)
+}
+
+// startAudit starts sending a record of the console session opened for profile, so
+// that the browser need not wait for it, and returns the function that waits for it to
+// be sent. Failures are reported but never fatal: the record stays queued for the next
+// console opened.
+func startAudit(ctx context.Context, profile string, identity awslib.Identity, requested time.Duration, opts workflowOptions, deps runDeps) func() {
+	if deps.audit == nil {
+		return func() {}
+	}
+	e := audit.Event{
+		Time:            deps.now().UTC(),
+		Profile:         profile,
+		Account:         identity.Account,
+		Partition:       identity.Partition,
+		ARN:             identity.Arn,
+		Role:            identity.RoleName(),
+		Session:         identity.SessionName(),
+		Regions:         opts.regions,
+		Destination:     opts.destination,
+		DurationSeconds: int64(requested / time.Second),
+		Guest:           opts.guest,
+		FallbackFor:     opts.fallbackFor,
+		User:            localUser(),
+		Version:         Version,
+	}
+	e.Host, _ = os.Hostname()
+
+	sent := make(chan error, 1)
+	go func() {
+		done := deps.timings.start("audit")
+		sent <- deps.audit.Emit(ctx, e)
+		done()
+	}()
+	return sync.OnceFunc(func() {
+		// Messages are printed here rather than as the record is sent, so they never
+		// interleave with those of the browser being opened.
+		if err := <-sent; err != nil {
+			deps.messages.Fprintf(deps.stderr, "Warning: failed to send the audit record: %v\n", err)
+			return
+		}
+		verbosef(deps, "Sent the audit record")
+	})
+}
+
+// localUser names the user running aws-console, or "" when it cannot be told.
+func localUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/audit"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/output"
+)
+
+func TestNewAuditEmitter(t *testing.T) {
+	t.Setenv("AUDIT_TEST_TOKEN", "s3cr3t")
+
+	if m := newAuditEmitter(config.Audit{}, nil, runDeps{}); m != nil {
+		t.Fatalf("expected no emitter without a webhook or event bus, got %+v", m)
+	}
+
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	stateDir := t.TempDir()
+	m := newAuditEmitter(config.Audit{
+		Webhook: server.URL,
+		Headers: map[string]string{"Authorization": "Bearer ${AUDIT_TEST_TOKEN}"},
+	}, nil, runDeps{stateDir: stateDir})
+	if m == nil || !strings.HasPrefix(m.QueuePath(), stateDir) {
+		t.Fatalf("expected an emitter queuing in the state directory, got %+v", m)
+	}
+	if err := m.Emit(context.Background(), audit.Event{Profile: "dev"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != "Bearer s3cr3t" {
+		t.Fatalf("expected the header to be expanded from the environment, got %q", auth)
+	}
+}
+
+func TestNewAuditEmitterUsesTransport(t *testing.T) {
+	t.Parallel()
+
+	// The webhook is only reachable through the proxy of the transport.
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &http.Transport{Proxy: http.ProxyURL(proxyURL)}
+	m := newAuditEmitter(config.Audit{Webhook: "http://audit.invalid/records"}, transport, runDeps{})
+	if err := m.Emit(context.Background(), audit.Event{Profile: "dev"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if proxied != "http://audit.invalid/records" {
+		t.Fatalf("expected the record to go through the proxy, got %q", proxied)
+	}
+}
+
+func TestRunWorkflowSendsAuditRecord(t *testing.T) {
+	t.Parallel()
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	openedAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", Account: "123456789012", Partition: "aws"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://signin.aws.amazon.com/federation?Action=login&SigninToken=tok", nil
+			},
+		},
+		audit:           audit.NewEmitter("", &audit.Webhook{URL: server.URL}),
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		term:            interactiveTerminal,
+		now:             func() time.Time { return openedAt },
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: 3600,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "prod", destination: "s3", guest: "alice"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got audit.Event
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("failed to decode the audit record %q: %v", body, err)
+	}
+	if got.Profile != "prod" || got.Account != "123456789012" || got.Role != "Admin" || got.Session != "me" || got.Partition != "aws" || got.Destination != "s3" ||
+		got.DurationSeconds != 3600 || got.Guest != "alice" || !got.Time.Equal(openedAt) || got.Version != Version {
+		t.Fatalf("unexpected audit record: %+v", got)
+	}
+	for _, secret := range []string{"ASIAEXAMPLE", "secret", "token", "tok"} {
+		if strings.Contains(string(body), `"`+secret) {
+			t.Fatalf("expected no credentials in the audit record, got %s", body)
+		}
+	}
+}
+
+func TestRunURLSendsAuditRecord(t *testing.T) {
+	t.Parallel()
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", Account: "123456789012", Partition: "aws"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(time.Hour)}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://signin.aws.amazon.com/federation?Action=login&SigninToken=tok", nil
+			},
+		},
+		audit:  audit.NewEmitter("", &audit.Webhook{URL: server.URL}),
+		term:   interactiveTerminal,
+		now:    func() time.Time { return now },
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
+	}
+
+	if err := runURL(context.Background(), workflowOptions{profile: "prod", destination: "s3"}, 30*time.Minute, output.FormatTable, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got audit.Event
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("failed to decode the audit record %q: %v", body, err)
+	}
+	if got.Profile != "prod" || got.Role != "Admin" || got.Destination != "s3" || got.DurationSeconds != 1800 {
+		t.Fatalf("unexpected audit record: %+v", got)
+	}
+	if strings.Contains(string(body), "tok") {
+		t.Fatalf("expected no sign-in URL in the audit record, got %s", body)
+	}
+}
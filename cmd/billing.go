@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/spf13/cobra"
+)
+
+const billingDestination = "billing/home"
+
+// billingActions are IAM actions the Billing and Cost Management console relies on.
+// Being allowed any of them is taken as a sign the console will be usable.
+var billingActions = []string{
+	"aws-portal:ViewBilling",
+	"billing:GetBillingData",
+	"ce:GetCostAndUsage",
+}
+
+func newBillingCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var flags workflowFlags
+	billingCmd := &cobra.Command{
+		Use:   "billing",
+		Short: "Open the Billing and Cost Management console",
+		Long: `Opens the Billing and Cost Management console. Before opening it, the caller's
+IAM policies are simulated to warn when billing access is unlikely to work. Opening it
+as the root user or an administrator role needs --yes, or confirmation in a terminal.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveWorkflowGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			opts, err := flags.options(g.profile, billingDestination)
+			if err != nil {
+				return err
+			}
+			opts.preflight = billingPreflight
+			return runner(ctx, opts, deps)
+		},
+	}
+
+	addWorkflowFlags(billingCmd, &flags)
+	return billingCmd
+}
+
+// billingPreflight warns when the caller is unlikely to be able to use the billing
+// console. It never blocks opening the console, since the simulation is a heuristic.
+func billingPreflight(ctx context.Context, profile string, identity awslib.Identity, deps runDeps) error {
+	if identity.IsRoot() {
+		return nil
+	}
+
+	decisions, err := deps.awsService.SimulatePrincipalPolicy(ctx, profile, identity.Arn, billingActions)
+	switch {
+	case err != nil:
+		deps.messages.Fprintf(deps.stderr, "Warning: could not verify billing permissions: %v\n", err)
+	case !anyAllowed(decisions):
+		deps.messages.Fprintf(deps.stderr, "Warning: %s is not allowed any of %s; the billing console will likely deny access.\n",
+			identity.Arn, strings.Join(billingActions, ", "))
+	}
+
+	deps.messages.Fprintln(deps.stderr, `Note: IAM users and roles can only use the billing console once the root user has activated "IAM user and role access to Billing information" in the account settings.`)
+	return nil
+}
+
+func anyAllowed(decisions map[string]bool) bool {
+	for _, allowed := range decisions {
+		if allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestBillingCmd(t *testing.T) {
+	t.Parallel()
+
+	var captured workflowOptions
+	deps := runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		captured = opts
+		return nil
+	})
+	root.SetArgs([]string{"billing", "-p", "payer"})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if captured.profile != "payer" || captured.destination != billingDestination {
+		t.Fatalf("unexpected workflow options: %+v", captured)
+	}
+	if captured.preflight == nil {
+		t.Fatal("expected billing preflight to be set")
+	}
+}
+
+func TestBillingPreflight(t *testing.T) {
+	t.Parallel()
+
+	const activationNote = "IAM user and role access to Billing information"
+
+	testCases := []struct {
+		name         string
+		arn          string
+		decisions    map[string]bool
+		simulateErr  error
+		wantCalls    int64
+		wantContains []string
+		wantNoStderr bool
+	}{
+		{
+			name:         "root user skips simulation",
+			arn:          "arn:aws:iam::123456789012:root",
+			wantNoStderr: true,
+		},
+		{
+			name:         "allowed principal only gets activation note",
+			arn:          "arn:aws:sts::123456789012:assumed-role/Billing/me",
+			decisions:    map[string]bool{"aws-portal:ViewBilling": false, "ce:GetCostAndUsage": true},
+			wantCalls:    1,
+			wantContains: []string{activationNote},
+		},
+		{
+			name:      "denied principal is warned",
+			arn:       "arn:aws:sts::123456789012:assumed-role/ReadOnly/me",
+			decisions: map[string]bool{"aws-portal:ViewBilling": false},
+			wantCalls: 1,
+			wantContains: []string{
+				"Warning: arn:aws:sts::123456789012:assumed-role/ReadOnly/me is not allowed any of aws-portal:ViewBilling",
+				activationNote,
+			},
+		},
+		{
+			name:        "simulation failure is reported",
+			arn:         "arn:aws:iam::123456789012:user/alice",
+			simulateErr: errors.New("AccessDenied"),
+			wantCalls:   1,
+			wantContains: []string{
+				"Warning: could not verify billing permissions: AccessDenied",
+				activationNote,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &mocks.Service{
+				SimulatePrincipalPolicyFunc: func(ctx context.Context, profile string, principalARN string, actions []string) (map[string]bool, error) {
+					if principalARN != tc.arn {
+						t.Fatalf("unexpected principal: %q", principalARN)
+					}
+					return tc.decisions, tc.simulateErr
+				},
+			}
+			stderr := &bytes.Buffer{}
+			deps := runDeps{awsService: svc, stderr: stderr}
+
+			if err := billingPreflight(context.Background(), "payer", awslib.Identity{Arn: tc.arn}, deps); err != nil {
+				t.Fatalf("preflight should never fail, got %v", err)
+			}
+			if svc.SimulatePrincipalPolicyCalls.Load() != tc.wantCalls {
+				t.Fatalf("expected %d simulation calls, got %d", tc.wantCalls, svc.SimulatePrincipalPolicyCalls.Load())
+			}
+			if tc.wantNoStderr && stderr.Len() != 0 {
+				t.Fatalf("expected no output, got %q", stderr.String())
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(stderr.String(), want) {
+					t.Fatalf("expected stderr to contain %q, got %q", want, stderr.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRunWorkflowPreflightAborts(t *testing.T) {
+	t.Parallel()
+
+	federation := &mocks.FederationBuilder{}
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+			},
+		},
+		federation: federation,
+		stdout:     &bytes.Buffer{},
+		stderr:     &bytes.Buffer{},
+	}
+
+	err := runWorkflow(context.Background(), workflowOptions{
+		profile: "dev",
+		preflight: func(ctx context.Context, profile string, identity awslib.Identity, deps runDeps) error {
+			return errors.New("refusing")
+		},
+	}, deps)
+	if err == nil || err.Error() != "refusing" {
+		t.Fatalf("expected preflight error, got %v", err)
+	}
+	if federation.BuildConsoleURLCalls.Load() != 0 {
+		t.Fatal("expected federation to be skipped after preflight failure")
+	}
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/destination"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func newBookmarksCmd(deps runDeps) *cobra.Command {
+	bookmarksCmd := &cobra.Command{
+		Use:   "bookmarks",
+		Short: "Manage named console pages opened with 'aws-console open <bookmark>'",
+		Long: `Bookmarks name a console page of a profile, stored in the bookmarks section of
+the aws-console config file. 'aws-console open <bookmark>' signs in to the
+bookmark's profile and opens its page; --destination and --service still win.`,
+	}
+
+	bookmarksCmd.AddCommand(
+		newBookmarksListCmd(deps),
+		newBookmarksAddCmd(deps),
+		newBookmarksRmCmd(deps),
+	)
+	return bookmarksCmd
+}
+
+func newBookmarksListCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List bookmarks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			deps.messages = g.printer()
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "NAME", Key: "name"},
+					{Header: "PROFILE", Key: "profile"},
+					{Header: "DESTINATION", Key: "destination"},
+				},
+			}
+			for _, name := range bookmarkNames(file) {
+				b := file.Bookmarks[name]
+				table.Rows = append(table.Rows, []string{name, b.Profile, b.Destination})
+			}
+
+			if len(table.Rows) == 0 && g.output == output.FormatTable {
+				deps.messages.Fprintln(deps.stdout, "No bookmarks. Add one with 'aws-console bookmarks add <name> <profile> [destination]'.")
+				return nil
+			}
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+}
+
+func newBookmarksAddCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name> <profile> [destination]",
+		Short: "Add or replace a bookmark",
+		Long: `Stores a bookmark that opens destination, in any form --destination accepts,
+as profile, which may be an alias. Without a destination the bookmark opens the
+console home page.`,
+		Args:              cobra.RangeArgs(2, 3),
+		ValidArgsFunction: completeBookmarkAddArgs(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, profile := args[0], args[1]
+			b := config.Bookmark{Profile: profile}
+			if len(args) == 3 {
+				b.Destination = args[2]
+			}
+
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+			if deps.configFile == "" {
+				return i18n.Errorf("cannot determine the config file location (set AWS_CONSOLE_CONFIG)")
+			}
+			if err := validateBookmark(name, b, file, deps); err != nil {
+				return err
+			}
+
+			if file.Bookmarks == nil {
+				file.Bookmarks = map[string]config.Bookmark{}
+			}
+			file.Bookmarks[name] = b
+			return file.Save(deps.configFile)
+		},
+	}
+}
+
+func newBookmarksRmCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm <name>",
+		Aliases:           []string{"remove"},
+		Short:             "Remove a bookmark",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeBookmarks(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+			if _, ok := file.Bookmarks[args[0]]; !ok {
+				return i18n.Errorf("no bookmark named %q (run 'aws-console bookmarks list' to see them)", args[0])
+			}
+			delete(file.Bookmarks, args[0])
+			return file.Save(deps.configFile)
+		},
+	}
+}
+
+// validateBookmark checks a bookmark before it is stored. Its name must not be taken by
+// a profile or an alias, which 'aws-console open' would otherwise have to choose between.
+func validateBookmark(name string, b config.Bookmark, file *config.File, deps runDeps) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return i18n.Errorf("invalid bookmark name %q", name)
+	}
+	if _, ok := file.Aliases[name]; ok {
+		return i18n.Errorf("%q is already an alias", name)
+	}
+	if deps.profiles != nil {
+		profiles, err := deps.profiles.ListProfiles()
+		if err != nil {
+			return i18n.Errorf("failed to list profiles: %w", err)
+		}
+		if _, ok := profileByName(profiles)[name]; ok {
+			return i18n.Errorf("%q is already a profile", name)
+		}
+	}
+	if err := checkProfileExists(file.Alias(b.Profile), deps); err != nil {
+		return err
+	}
+	_, err := destination.Normalize(b.Destination)
+	return err
+}
+
+// bookmarkNames returns the names of the bookmarks in file, sorted.
+func bookmarkNames(file *config.File) []string {
+	names := make([]string, 0, len(file.Bookmarks))
+	for name := range file.Bookmarks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeBookmarks completes bookmark names, described by their profile and page.
+func completeBookmarks(deps runDeps) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		file, err := config.LoadFile(deps.configFile)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, name := range bookmarkNames(file) {
+			if strings.HasPrefix(name, toComplete) && !slices.Contains(args, name) {
+				b := file.Bookmarks[name]
+				names = append(names, name+"\tbookmark: "+strings.TrimSpace(b.Profile+" "+b.Destination))
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeBookmarkAddArgs completes the profile argument of 'bookmarks add'.
+func completeBookmarkAddArgs(deps runDeps) cobra.CompletionFunc {
+	complete := completeProfiles(deps)
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 1 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, nil, toComplete)
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestBookmarksAddListRm(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+
+	path := filepath.Join(t.TempDir(), "aws-console", "config.yaml")
+	deps := runDeps{
+		configFile: path,
+		profiles: &mocks.ProfileLister{
+			ListProfilesFunc: func() ([]awslib.Profile, error) {
+				return testProfiles(), nil
+			},
+		},
+	}
+
+	out, err := executeSubcommand(t, deps, "bookmarks", "list")
+	if err != nil || !strings.Contains(out, "No bookmarks.") {
+		t.Fatalf("expected no bookmarks, got %q (%v)", out, err)
+	}
+
+	steps := [][]string{
+		{"config", "set", "alias.d", "dev"},
+		{"bookmarks", "add", "dev-logs", "d", "cloudwatch/home#logsV2:log-groups"},
+		{"bookmarks", "add", "keys-home", "keys"},
+	}
+	for _, args := range steps {
+		if _, err := executeSubcommand(t, deps, args...); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+	}
+
+	out, err = executeSubcommand(t, deps, "bookmarks", "list", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "name,profile,destination\ndev-logs,d,cloudwatch/home#logsV2:log-groups\nkeys-home,keys,\n"
+	if out != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, out)
+	}
+
+	if _, err := executeSubcommand(t, deps, "bookmarks", "rm", "keys-home"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := executeSubcommand(t, deps, "bookmarks", "rm", "keys-home"); err == nil || !strings.Contains(err.Error(), `no bookmark named "keys-home"`) {
+		t.Fatalf("expected an error for a missing bookmark, got %v", err)
+	}
+	out, err = executeSubcommand(t, deps, "bookmarks", "list", "-o", "csv")
+	if err != nil || strings.Contains(out, "keys-home") {
+		t.Fatalf("expected the bookmark to be removed, got %q (%v)", out, err)
+	}
+}
+
+func TestBookmarksAddRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		wantErrSubstr string
+	}{
+		{name: "name of a profile", args: []string{"dev", "keys"}, wantErrSubstr: `"dev" is already a profile`},
+		{name: "name of an alias", args: []string{"k", "keys"}, wantErrSubstr: `"k" is already an alias`},
+		{name: "unknown profile", args: []string{"qa-home", "qa"}, wantErrSubstr: `profile "qa" not found`},
+		{name: "invalid name", args: []string{"dev logs", "dev"}, wantErrSubstr: "invalid bookmark name"},
+		{name: "invalid destination", args: []string{"dev-home", "dev", "https://example.com/"}, wantErrSubstr: "example.com"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte("aliases:\n  k: keys\n"), 0o600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+			deps := runDeps{
+				configFile: path,
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return testProfiles(), nil
+					},
+				},
+			}
+
+			_, err := executeSubcommand(t, deps, append([]string{"bookmarks", "add"}, tc.args...)...)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil || strings.Contains(string(data), "bookmarks") {
+				t.Fatalf("expected no bookmark to be stored, got %q (%v)", data, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/clipboard"
+	"github.com/spf13/cobra"
+)
+
+// browserOptions control how the console is opened in a browser.
+type browserOptions struct {
+	// newWindow isolates the session in its own browser window.
+	newWindow bool
+	// private opens the console in a private (incognito) window.
+	private bool
+	// container is the Firefox container to open the console in, if any.
+	container string
+	// browser and profile choose the browser and browser profile. They come from the
+	// resolved settings through withSettings, since deps.open is bound before those are
+	// resolved.
+	browser string
+	profile string
+	// dataDir is the account's own browser profile directory, when accounts are isolated.
+	dataDir string
+}
+
+// withSettings returns opts with the browser and browser profile resolved into deps.
+func (opts browserOptions) withSettings(deps runDeps) browserOptions {
+	opts.browser, opts.profile = deps.browser, deps.browserProfile
+	return opts
+}
+
+// addBrowserFlags registers the flags of commands that open the console in a browser.
+// --browser and --browser-profile are settings, resolved with their environment and
+// profile fallbacks by resolveGlobals.
+func addBrowserFlags(cmd *cobra.Command, opts *browserOptions) {
+	cmd.Flags().BoolVar(&opts.newWindow, "new-window", false, "Open the console in a new browser window instead of a tab")
+	cmd.Flags().BoolVar(&opts.private, "incognito", false, "Open the console in a private (incognito) window, apart from the browser's other cookies")
+	cmd.Flags().String("browser", "", `Browser to open the console in (chrome, firefox, ...), optionally with a profile as in "chrome:Profile 2", or a command containing {url}`)
+	cmd.Flags().String("browser-profile", "", "Browser profile to open the console in")
+	cmd.Flags().Bool("isolate-accounts", false, "Open the console in a browser profile of the account's own, created on first use")
+	cmd.Flags().String("container", "", "Firefox Multi-Account Container to open the console in; {profile} and {account} are replaced")
+	cmd.Flags().Bool("local-redirect", false, "Open a one-time local URL that redirects to the sign-in URL, which then stays off the browser's command line")
+	cmd.Flags().Bool("headless", false, "Print the sign-in URL, show it as a QR code, and copy it instead of opening a browser, as when no display is found")
+}
+
+// openBrowser opens the given URL in the configured browser, or the user's default one.
+func openBrowser(targetURL string, opts browserOptions, deps runDeps) error {
+	platform := deps.goos
+	if deps.wsl {
+		platform = browser.WSL
+	}
+	err := browser.New(platform, deps.executor, os.Getenv, deps.stderr).Open(targetURL, browser.Options{
+		Browser:   opts.browser,
+		Profile:   opts.profile,
+		NewWindow: opts.newWindow,
+		Container: opts.container,
+		Private:   opts.private,
+		DataDir:   opts.dataDir,
+	})
+	if errors.Is(err, browser.ErrUnsupportedPlatform) {
+		return awslib.MarkError(err, awslib.ErrUnsupportedPlatform)
+	}
+	return err
+}
+
+// copyToClipboard places text on the system clipboard. Without a clipboard command, a
+// terminal on stderr is asked to set it, which also works over SSH.
+func copyToClipboard(text string, deps runDeps) error {
+	var terminal io.Writer
+	if deps.term.StderrTTY {
+		terminal = deps.stderr
+	}
+	return clipboard.New(deps.goos, deps.executor, os.Getenv, terminal).Copy(text)
+}
+
+// containerName expands the {profile} and {account} placeholders of a container setting,
+// so one setting can give every profile or account its own container.
+func containerName(template, profile, account string) string {
+	return strings.NewReplacer("{profile}", profile, "{account}", account).Replace(template)
+}
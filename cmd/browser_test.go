@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestOpenBrowser(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		goos          string
+		wsl           bool
+		opts          browserOptions
+		browser       string
+		profile       string
+		runOutput     string
+		runErr        error
+		startErr      error
+		wantName      string
+		wantArgs      []string
+		wantCalls     int
+		wantWarning   bool
+		wantErrSubstr string
+	}{
+		{
+			name:      "darwin",
+			goos:      "darwin",
+			wantName:  "open",
+			wantArgs:  []string{"https://example.com"},
+			wantCalls: 1,
+		},
+		{
+			name:      "linux",
+			goos:      "linux",
+			wantName:  "xdg-open",
+			wantArgs:  []string{"https://example.com"},
+			wantCalls: 1,
+		},
+		{
+			name:      "windows",
+			goos:      "windows",
+			wantName:  "cmd",
+			wantArgs:  []string{"/c", "start", "", "https://example.com"},
+			wantCalls: 1,
+		},
+		{
+			name:      "wsl",
+			goos:      "linux",
+			wsl:       true,
+			wantName:  "wslview",
+			wantArgs:  []string{"https://example.com"},
+			wantCalls: 1,
+		},
+		{
+			name:          "unsupported",
+			goos:          "plan9",
+			wantErrSubstr: "unsupported platform: plan9",
+		},
+		{
+			name:          "start error",
+			goos:          "linux",
+			startErr:      errors.New("start failed"),
+			wantName:      "xdg-open",
+			wantArgs:      []string{"https://example.com"},
+			wantCalls:     1,
+			wantErrSubstr: "start failed",
+		},
+		{
+			name:      "incognito",
+			goos:      "linux",
+			opts:      browserOptions{private: true},
+			browser:   "chrome",
+			wantName:  "google-chrome",
+			wantArgs:  []string{"--incognito", "https://example.com"},
+			wantCalls: 1,
+		},
+		{
+			name:      "darwin new window",
+			goos:      "darwin",
+			opts:      browserOptions{newWindow: true},
+			wantName:  "open",
+			wantArgs:  []string{"-n", "https://example.com"},
+			wantCalls: 1,
+		},
+		{
+			name:      "linux new window in chrome",
+			goos:      "linux",
+			opts:      browserOptions{newWindow: true},
+			runOutput: "google-chrome.desktop\n",
+			wantName:  "google-chrome",
+			wantArgs:  []string{"--new-window", "https://example.com"},
+			wantCalls: 2,
+		},
+		{
+			name:      "linux new window in firefox",
+			goos:      "linux",
+			opts:      browserOptions{newWindow: true},
+			runOutput: "firefox.desktop\n",
+			wantName:  "firefox",
+			wantArgs:  []string{"-new-window", "https://example.com"},
+			wantCalls: 2,
+		},
+		{
+			name:        "linux new window in unknown browser",
+			goos:        "linux",
+			opts:        browserOptions{newWindow: true},
+			runOutput:   "org.gnome.Epiphany.desktop\n",
+			wantName:    "xdg-open",
+			wantArgs:    []string{"https://example.com"},
+			wantCalls:   2,
+			wantWarning: true,
+		},
+		{
+			name:        "linux new window without xdg-settings",
+			goos:        "linux",
+			opts:        browserOptions{newWindow: true},
+			runErr:      errors.New("not found"),
+			wantName:    "xdg-open",
+			wantArgs:    []string{"https://example.com"},
+			wantCalls:   2,
+			wantWarning: true,
+		},
+		{
+			name:      "configured browser and profile",
+			goos:      "linux",
+			opts:      browserOptions{newWindow: true},
+			browser:   "chromium",
+			profile:   "Profile 2",
+			wantName:  "chromium",
+			wantArgs:  []string{"--profile-directory=Profile 2", "--new-window", "https://example.com"},
+			wantCalls: 1,
+		},
+		{
+			name:        "windows new window",
+			goos:        "windows",
+			opts:        browserOptions{newWindow: true},
+			wantName:    "cmd",
+			wantArgs:    []string{"/c", "start", "", "https://example.com"},
+			wantCalls:   1,
+			wantWarning: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stderr := &bytes.Buffer{}
+			executor := &fakeExecutor{runOutput: tc.runOutput, runErr: tc.runErr, startErr: tc.startErr}
+			deps := runDeps{
+				executor:       executor,
+				goos:           tc.goos,
+				wsl:            tc.wsl,
+				stderr:         stderr,
+				browser:        tc.browser,
+				browserProfile: tc.profile,
+			}
+
+			err := openBrowser("https://example.com", tc.opts.withSettings(deps), deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if tc.goos == "plan9" && ExitCode(err) != ExitUnsupportedPlatform {
+					t.Fatalf("expected %v to exit with %d, got %d", err, ExitUnsupportedPlatform, ExitCode(err))
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := strings.Contains(stderr.String(), "Warning:"); got != tc.wantWarning {
+				t.Fatalf("expected warning %v, got stderr %q", tc.wantWarning, stderr.String())
+			}
+
+			if len(executor.calls) != tc.wantCalls {
+				t.Fatalf("expected %d executor calls, got %d", tc.wantCalls, len(executor.calls))
+			}
+			if tc.wantCalls == 0 {
+				return
+			}
+			call := executor.calls[len(executor.calls)-1]
+			if call.method != "start" || call.name != tc.wantName {
+				t.Fatalf("unexpected executor call: %+v", call)
+			}
+			if strings.Join(call.args, "|") != strings.Join(tc.wantArgs, "|") {
+				t.Fatalf("unexpected args: got %v want %v", call.args, tc.wantArgs)
+			}
+		})
+	}
+}
+
+func TestWorkflowCmdsPassBrowserOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{{"--new-window"}, {"health", "--new-window"}, {"billing", "--new-window"}} {
+		var captured workflowOptions
+		root := newRootCmd(runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+			captured = opts
+			return nil
+		})
+		root.SetArgs(args)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		if !captured.browser.newWindow {
+			t.Fatalf("%v: expected new window to be requested", args)
+		}
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"work":                  "work",
+		"aws-{profile}":         "aws-prod",
+		"{account} ({profile})": "123456789012 (prod)",
+	}
+	for template, want := range testCases {
+		if got := containerName(template, "prod", "123456789012"); got != want {
+			t.Fatalf("containerName(%q) = %q, want %q", template, got, want)
+		}
+	}
+}
+
+func TestRunWorkflowOpensInContainer(t *testing.T) {
+	t.Parallel()
+
+	var opened browserOptions
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://example.com/console-login", nil
+			},
+		},
+		open: func(targetURL string, opts browserOptions) error {
+			opened = opts
+			return nil
+		},
+		container:       "aws-{account}",
+		browser:         "firefox",
+		browserProfile:  "work",
+		term:            interactiveTerminal,
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened.container != "aws-123456789012" {
+		t.Fatalf("expected the account's container, got %q", opened.container)
+	}
+	if opened.browser != "firefox" || opened.profile != "work" {
+		t.Fatalf("expected the resolved browser settings, got %+v", opened)
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// accountBrowserProfile returns the browser profile directory of account, or of profile
+// when the account is unknown, creating it on first use.
+func accountBrowserProfile(profile, account string, deps runDeps) (string, error) {
+	if deps.browserProfiles == nil {
+		return "", i18n.Errorf("browser profiles are unavailable: no state directory")
+	}
+	name := cmp.Or(account, profile)
+	path, created, err := deps.browserProfiles.Ensure(deps.browser, name)
+	if err != nil {
+		return "", err
+	}
+	if created {
+		deps.messages.Fprintf(statusWriter(deps), "Created a %s profile for %s in %s\n", deps.browser, name, path)
+	} else {
+		verbosef(deps, "Using the %s profile in %s", deps.browser, path)
+	}
+	return path, nil
+}
+
+func newBrowserProfilesCmd(deps runDeps) *cobra.Command {
+	browserProfilesCmd := &cobra.Command{
+		Use:   "browser-profiles",
+		Short: "Manage the browser profiles that keep each account's console apart",
+		Long: `With isolate-accounts set, aws-console opens the console in a browser profile of
+the account's own, created on first use, so each account keeps its own cookies,
+bookmarks, and extensions and stays signed in beside the others. The profiles are
+directories under browser-profiles in the aws-console state directory, one per
+browser and account.`,
+		Args: cobra.NoArgs,
+	}
+
+	browserProfilesCmd.AddCommand(
+		newBrowserProfilesListCmd(deps),
+		newBrowserProfilesCreateCmd(deps),
+		newBrowserProfilesPurgeCmd(deps),
+	)
+	return browserProfilesCmd
+}
+
+func newBrowserProfilesListCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the browser profiles created for accounts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			deps.messages = g.printer()
+			if deps.browserProfiles == nil {
+				return i18n.Errorf("browser profiles are unavailable: no state directory")
+			}
+			profiles, err := deps.browserProfiles.List()
+			if err != nil {
+				return err
+			}
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "BROWSER", Key: "browser"},
+					{Header: "ACCOUNT", Key: "account"},
+					{Header: "LAST USED", Key: "last_used"},
+					{Header: "PATH", Key: "path"},
+				},
+			}
+			for _, p := range profiles {
+				table.Rows = append(table.Rows, []string{p.Browser, p.Account, formatTimestamp(p.LastUsed), p.Path})
+			}
+
+			if len(table.Rows) == 0 && g.output == output.FormatTable {
+				deps.messages.Fprintln(deps.stdout, "No browser profiles created.")
+				return nil
+			}
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+}
+
+func newBrowserProfilesCreateCmd(deps runDeps) *cobra.Command {
+	createCmd := &cobra.Command{
+		Use:   "create <account>...",
+		Short: "Create browser profiles for accounts ahead of their first use",
+		Long: `Creates a profile for each account in the browser chosen with --browser, so it
+can be set up, with extensions or bookmarks, before the console is first opened
+in it. Existing profiles are kept.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			if deps.browserProfiles == nil {
+				return i18n.Errorf("browser profiles are unavailable: no state directory")
+			}
+			if err := browser.Validate(browser.Options{Browser: g.browser, DataDir: deps.browserProfiles.Dir()}); err != nil {
+				return err
+			}
+
+			for _, account := range args {
+				path, created, err := deps.browserProfiles.Ensure(g.browser, account)
+				if err != nil {
+					return err
+				}
+				if created {
+					fmt.Fprintf(deps.stdout, "Created a %s profile for %s in %s\n", g.browser, account, path)
+				} else {
+					fmt.Fprintf(deps.stdout, "A %s profile for %s already exists in %s\n", g.browser, account, path)
+				}
+			}
+			return nil
+		},
+	}
+	createCmd.Flags().String("browser", "", "Browser to create the profiles for, e.g. chrome or firefox")
+	return createCmd
+}
+
+func newBrowserProfilesPurgeCmd(deps runDeps) *cobra.Command {
+	var all bool
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge [account...]",
+		Short: "Delete the browser profiles of accounts, with their cookies and bookmarks",
+		Long: `Deletes the profiles of the accounts given, in every browser, or of every
+account with --all. Close the browser first: a running browser keeps writing to
+its profile.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := resolveGlobals(cmd, deps); err != nil {
+				return err
+			}
+			if len(args) == 0 && !all {
+				return i18n.Errorf("give the accounts whose browser profiles to delete, or --all")
+			}
+			if len(args) > 0 && all {
+				return i18n.Errorf("--all cannot be combined with accounts")
+			}
+			if deps.browserProfiles == nil {
+				return i18n.Errorf("browser profiles are unavailable: no state directory")
+			}
+
+			purged, err := deps.browserProfiles.Purge(args...)
+			for _, p := range purged {
+				fmt.Fprintf(deps.stdout, "Deleted the %s profile for %s\n", p.Browser, p.Account)
+			}
+			if err != nil {
+				return err
+			}
+			if len(purged) == 0 {
+				fmt.Fprintln(deps.stdout, "No browser profiles to delete.")
+			}
+			return nil
+		},
+	}
+	purgeCmd.Flags().BoolVar(&all, "all", false, "Delete the browser profiles of every account")
+	return purgeCmd
+}
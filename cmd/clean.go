@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// Names of the local state kept under the cache and state directories.
+const (
+	credentialCacheDirName  = credcache.CredentialsDirName
+	signinTokenCacheDirName = credcache.SigninTokensDirName
+	ssoCacheDirName         = "sso"
+)
+
+// cleanTarget is a piece of local state that the clean command can remove.
+type cleanTarget struct {
+	name        string
+	description string
+	path        string
+	// purge, when set, removes what path indexes elsewhere, such as keychain entries,
+	// before path is removed.
+	purge func() error
+}
+
+func cleanTargets(deps runDeps) []cleanTarget {
+	var historyPath, usagePath, accountsPath, sessionsPath string
+	if deps.history != nil {
+		historyPath = deps.history.Path()
+	}
+	if deps.usage != nil {
+		usagePath = deps.usage.Path()
+	}
+	if deps.accounts != nil {
+		accountsPath = deps.accounts.Path()
+	}
+	if deps.sessions != nil {
+		sessionsPath = deps.sessions.Path()
+	}
+
+	return []cleanTarget{
+		credentialCacheTarget(deps),
+		signinTokenCacheTarget(deps),
+		{name: "history", description: "console open history", path: historyPath},
+		{name: "frecency", description: "profile usage data used for sorting", path: usagePath},
+		{name: "sessions", description: "console sessions listed by the sessions command", path: sessionsPath},
+		{name: "accounts", description: "cached account aliases and names", path: accountsPath},
+		{name: "crash-reports", description: "saved crash reports", path: joinIfSet(deps.stateDir, crashDirName)},
+	}
+}
+
+// credentialCacheTarget and signinTokenCacheTarget are the credential cache, whose
+// entries may be kept in the keychain.
+func credentialCacheTarget(deps runDeps) cleanTarget {
+	return cleanTarget{
+		name:        "credentials",
+		description: "cached session credentials",
+		path:        joinIfSet(deps.cacheDir, credentialCacheDirName),
+		purge:       purgeCache(deps, credentialCacheDirName),
+	}
+}
+
+func signinTokenCacheTarget(deps runDeps) cleanTarget {
+	return cleanTarget{
+		name:        "signin-tokens",
+		description: "cached console sign-in tokens",
+		path:        joinIfSet(deps.cacheDir, signinTokenCacheDirName),
+		purge:       purgeCache(deps, signinTokenCacheDirName),
+	}
+}
+
+func purgeCache(deps runDeps, kind string) func() error {
+	if deps.credentials == nil {
+		return nil
+	}
+	return func() error { return deps.credentials.Purge(kind) }
+}
+
+func joinIfSet(dir string, name string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+func newCleanCmd(deps runDeps) *cobra.Command {
+	var dryRun bool
+	selected := map[string]*bool{}
+
+	cleanCmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove locally cached credentials, tokens, history, and usage data",
+		Long: `Removes the local state aws-console keeps on this machine. Without selection
+flags every category is removed; pass one or more of the category flags to
+remove only those. Use --dry-run to see what would be removed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Settings only decide where cached credentials are kept; a broken config
+			// must not stop clean from removing local state.
+			if g, err := resolveGlobals(cmd, deps); err == nil {
+				_, deps = g.apply(context.Background(), deps)
+			}
+			targets := cleanTargets(deps)
+
+			anySelected := false
+			for _, t := range targets {
+				anySelected = anySelected || *selected[t.name]
+			}
+
+			var errs []error
+			for _, t := range targets {
+				if anySelected && !*selected[t.name] {
+					continue
+				}
+				if err := cleanPath(t, dryRun, deps); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+
+	cleanCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be removed without removing anything")
+	for _, t := range cleanTargets(deps) {
+		selected[t.name] = cleanCmd.Flags().Bool(t.name, false, "Remove "+t.description)
+	}
+
+	return cleanCmd
+}
+
+func cleanPath(t cleanTarget, dryRun bool, deps runDeps) error {
+	if t.path == "" {
+		return nil
+	}
+
+	if _, err := os.Lstat(t.path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(deps.stdout, "Nothing to remove for %s (%s)\n", t.description, t.path)
+			return nil
+		}
+		return i18n.Errorf("failed to inspect %s: %w", t.path, err)
+	}
+
+	if dryRun {
+		fmt.Fprintf(deps.stdout, "Would remove %s: %s\n", t.description, t.path)
+		return nil
+	}
+
+	if t.purge != nil {
+		if err := t.purge(); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(t.path); err != nil {
+		return i18n.Errorf("failed to remove %s: %w", t.path, err)
+	}
+	fmt.Fprintf(deps.stdout, "Removed %s: %s\n", t.description, t.path)
+	return nil
+}
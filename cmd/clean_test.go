@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/history"
+	"github.com/eculver/aws-console/pkg/usage"
+)
+
+func setupCleanDirs(t *testing.T) runDeps {
+	t.Helper()
+
+	root := t.TempDir()
+	cacheDir := filepath.Join(root, "cache")
+	stateDir := filepath.Join(root, "state")
+
+	for _, dir := range []string{
+		filepath.Join(cacheDir, credentialCacheDirName),
+		filepath.Join(cacheDir, signinTokenCacheDirName),
+		stateDir,
+	} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatalf("failed to create %s: %v", dir, err)
+		}
+	}
+	for _, file := range []string{
+		filepath.Join(cacheDir, credentialCacheDirName, "dev.json"),
+		filepath.Join(stateDir, history.FileName),
+		filepath.Join(stateDir, usage.FileName),
+	} {
+		if err := os.WriteFile(file, []byte("{}"), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	return runDeps{
+		cacheDir: cacheDir,
+		stateDir: stateDir,
+		history:  history.NewStoreAt(filepath.Join(stateDir, history.FileName)),
+		usage:    usage.NewStoreAt(filepath.Join(stateDir, usage.FileName)),
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestCleanCmd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		args         []string
+		wantRemoved  []string
+		wantKept     []string
+		wantContains []string
+	}{
+		{
+			name:        "removes everything by default",
+			args:        []string{"clean"},
+			wantRemoved: []string{"cache/credentials", "cache/signin-tokens", "state/history.jsonl", "state/usage.json"},
+			wantContains: []string{
+				"Removed cached session credentials:",
+				"Removed profile usage data used for sorting:",
+			},
+		},
+		{
+			name:     "dry run keeps files",
+			args:     []string{"clean", "--dry-run"},
+			wantKept: []string{"cache/credentials", "cache/signin-tokens", "state/history.jsonl", "state/usage.json"},
+			wantContains: []string{
+				"Would remove cached session credentials:",
+				"Would remove console open history:",
+			},
+		},
+		{
+			name:         "selective removal",
+			args:         []string{"clean", "--history", "--frecency"},
+			wantRemoved:  []string{"state/history.jsonl", "state/usage.json"},
+			wantKept:     []string{"cache/credentials", "cache/signin-tokens"},
+			wantContains: []string{"Removed console open history:"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := setupCleanDirs(t)
+			base := filepath.Dir(deps.cacheDir)
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, rel := range tc.wantRemoved {
+				if exists(filepath.Join(base, rel)) {
+					t.Fatalf("expected %s to be removed", rel)
+				}
+			}
+			for _, rel := range tc.wantKept {
+				if !exists(filepath.Join(base, rel)) {
+					t.Fatalf("expected %s to be kept", rel)
+				}
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanCmdNothingToRemove(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	deps := runDeps{
+		cacheDir: filepath.Join(root, "cache"),
+		stateDir: filepath.Join(root, "state"),
+	}
+
+	out, err := executeSubcommand(t, deps, "clean")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Nothing to remove for cached session credentials") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+// secretStore is a credcache.CacheStore in memory.
+type secretStore map[string][]byte
+
+func (s secretStore) Get(key string) ([]byte, error) {
+	secret, ok := s[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return secret, nil
+}
+
+func (s secretStore) Set(key string, secret []byte) error {
+	s[key] = secret
+	return nil
+}
+
+func (s secretStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestCleanCmdRemovesKeychainEntries(t *testing.T) {
+	t.Parallel()
+
+	cacheDir := t.TempDir()
+	store := secretStore{}
+	cache := credcache.NewStoreCache(cacheDir, store)
+	creds := awslib.Credentials{AccessKeyID: "ASIA", SessionToken: "token", Expires: time.Now().Add(time.Hour)}
+	if err := cache.PutCredentials("dev", "", awslib.Identity{}, creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cache.PutSigninToken("key", "token", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deps := runDeps{cacheDir: cacheDir, credentials: cache}
+	if _, err := executeSubcommand(t, deps, "clean", "--credentials"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store) != 1 {
+		t.Fatalf("keychain holds %d entries after cleaning credentials, want only the sign-in token", len(store))
+	}
+	if exists(filepath.Join(cacheDir, credentialCacheDirName)) {
+		t.Fatal("expected the credentials directory to be removed")
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// newCompletionCmd creates the completion command, which prints a shell completion script.
+func newCompletionCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "Print a shell completion script",
+		Long: `Prints a completion script for the given shell. Besides subcommands and flags, it
+completes profile names from the shared AWS config for --profile and profile arguments.
+
+  bash:        source <(aws-console completion bash)
+  zsh:         aws-console completion zsh > "${fpath[1]}/_aws-console"
+  fish:        aws-console completion fish > ~/.config/fish/completions/aws-console.fish
+  powershell:  aws-console completion powershell | Out-String | Invoke-Expression`,
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			var err error
+			switch args[0] {
+			case "bash":
+				err = root.GenBashCompletionV2(deps.stdout, true)
+			case "zsh":
+				err = root.GenZshCompletion(deps.stdout)
+			case "fish":
+				err = root.GenFishCompletion(deps.stdout, true)
+			case "powershell":
+				err = root.GenPowerShellCompletionWithDesc(deps.stdout)
+			}
+			if err != nil {
+				return i18n.Errorf("failed to write %s completion: %w", args[0], err)
+			}
+			return nil
+		},
+	}
+}
+
+// completeProfiles completes profile names from the shared config, described by their
+// type, account, role, and region, leaving out profiles already given as arguments.
+// Errors reading the config complete nothing.
+func completeProfiles(deps runDeps) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if deps.profiles == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		profiles, err := deps.profiles.ListProfiles()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var names []string
+		for _, p := range profiles {
+			if strings.HasPrefix(p.Name, toComplete) && !slices.Contains(args, p.Name) {
+				names = append(names, p.Name+"\t"+profileDetails(p))
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeProfileArg completes the single profile argument of the root command.
+func completeProfileArg(deps runDeps) cobra.CompletionFunc {
+	complete := completeProfiles(deps)
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
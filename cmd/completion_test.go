@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		shell         string
+		wantContains  string
+		wantErrSubstr string
+	}{
+		{shell: "bash", wantContains: "complete -o default -F __start_aws-console aws-console"},
+		{shell: "zsh", wantContains: "#compdef aws-console"},
+		{shell: "fish", wantContains: "complete -c aws-console"},
+		{shell: "powershell", wantContains: "Register-ArgumentCompleter"},
+		{shell: "tcsh", wantErrSubstr: `invalid argument "tcsh"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.shell, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+			root := newRootCmd(runDeps{stdout: stdout, stderr: &bytes.Buffer{}}, nil)
+			root.SetArgs([]string{"completion", tc.shell})
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout.String(), tc.wantContains) {
+				t.Fatalf("expected the %s script to contain %q", tc.shell, tc.wantContains)
+			}
+		})
+	}
+}
+
+func TestProfileCompletion(t *testing.T) {
+	t.Parallel()
+
+	profiles := []awslib.Profile{
+		{Name: "dev", Source: awslib.ProfileSourceStatic, Region: "us-east-1"},
+		{Name: "prod-admin", Source: awslib.ProfileSourceSSO, AccountID: "123456789012", RoleName: "Admin"},
+		{Name: "prod-read", Source: awslib.ProfileSourceSSO, AccountID: "123456789012", RoleName: "ReadOnly"},
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("bookmarks:\n  prod-billing:\n    profile: prod-admin\n    destination: billing/home\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		profErr  error
+		want     []string
+		wantNone bool
+	}{
+		{
+			name: "profile flag",
+			args: []string{"--profile", "prod"},
+			want: []string{"prod-admin\tsso, 123456789012, Admin", "prod-read\tsso, 123456789012, ReadOnly"},
+		},
+		{
+			name: "profile flag on a subcommand",
+			args: []string{"whoami", "--profile", "d"},
+			want: []string{"dev\t" + awslib.ProfileSourceStatic + ", us-east-1"},
+		},
+		{
+			name: "positional profile",
+			args: []string{"prod-a"},
+			want: []string{"prod-admin\tsso, 123456789012, Admin"},
+		},
+		{
+			name:     "only one positional profile",
+			args:     []string{"dev", ""},
+			wantNone: true,
+		},
+		{
+			name: "open skips profiles already given",
+			args: []string{"open", "prod-admin", "prod"},
+			want: []string{"prod-read\tsso, 123456789012, ReadOnly", "prod-billing\tbookmark: prod-admin billing/home"},
+		},
+		{
+			name: "open completes bookmarks",
+			args: []string{"open", "prod-"},
+			want: []string{"prod-admin\tsso, 123456789012, Admin", "prod-read\tsso, 123456789012, ReadOnly", "prod-billing\tbookmark: prod-admin billing/home"},
+		},
+		{
+			name: "bookmarks rm",
+			args: []string{"bookmarks", "rm", ""},
+			want: []string{"prod-billing\tbookmark: prod-admin billing/home"},
+		},
+		{
+			name:     "unreadable config",
+			args:     []string{"--profile", ""},
+			profErr:  errors.New("bad config"),
+			wantNone: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{
+				stdout:     &bytes.Buffer{},
+				stderr:     &bytes.Buffer{},
+				configFile: configFile,
+				profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) {
+					return profiles, tc.profErr
+				}},
+			}
+			out := &bytes.Buffer{}
+			root := newRootCmd(deps, nil)
+			root.SetArgs(append([]string{"__complete"}, tc.args...))
+			root.SetOut(out)
+			root.SetErr(&bytes.Buffer{})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if strings.HasPrefix(line, ":") {
+					if line != ":4" {
+						t.Fatalf("expected file completion to be disabled, got directive %q", line)
+					}
+					break
+				}
+				got = append(got, line)
+			}
+			if tc.wantNone {
+				if len(got) != 0 {
+					t.Fatalf("expected no profiles, got %q", got)
+				}
+				return
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/paths"
+	"github.com/spf13/cobra"
+)
+
+// aliasPrefix marks config keys that name a profile alias, as in alias.prod.
+const aliasPrefix = "alias."
+
+// defaultConfigFile returns the aws-console config file path: AWS_CONSOLE_CONFIG, or
+// config.yaml in the config directory. It is empty when neither can be determined.
+func defaultConfigFile() string {
+	if path := os.Getenv("AWS_CONSOLE_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+func newConfigCmd(deps runDeps) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and change aws-console settings",
+	}
+
+	configCmd.AddCommand(newConfigDiffCmd(deps))
+	configCmd.AddCommand(newConfigViewCmd(deps))
+	configCmd.AddCommand(newConfigGetCmd(deps))
+	configCmd.AddCommand(newConfigSetCmd(deps))
+	configCmd.AddCommand(newConfigUnsetCmd(deps))
+	configCmd.AddCommand(newConfigExportCmd(deps))
+	configCmd.AddCommand(newConfigImportCmd(deps))
+	return configCmd
+}
+
+func newConfigDiffCmd(deps runDeps) *cobra.Command {
+	var showAll bool
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show settings that differ from the built-in defaults",
+		Long: `Shows each effective setting that deviates from its built-in default, along
+with the layer it came from (flag, file, env, or profile) and the specific flag,
+config file, environment variable, or profile that supplied it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			deps.messages = g.printer()
+			if g.profileErr != nil {
+				deps.messages.Fprintf(deps.stderr, "Warning: %v\n", g.profileErr)
+			}
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "SETTING", Key: "setting"},
+					{Header: "VALUE", Key: "value"},
+					{Header: "DEFAULT", Key: "default"},
+					{Header: "SOURCE", Key: "source"},
+					{Header: "ORIGIN", Key: "origin"},
+				},
+			}
+			for _, v := range g.values {
+				if !showAll && !v.Overridden() {
+					continue
+				}
+				table.Rows = append(table.Rows, []string{v.Setting.Key, v.Value, v.Setting.Default, string(v.Source), v.Origin})
+			}
+
+			if len(table.Rows) == 0 && g.output == output.FormatTable {
+				fmt.Fprintln(deps.stdout, "All settings match the built-in defaults.")
+				return nil
+			}
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+
+	diffCmd.Flags().BoolVar(&showAll, "all", false, "Show every setting, including those left at their defaults")
+
+	return diffCmd
+}
+
+func newConfigViewCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "view",
+		Short: "Print the aws-console config file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.configFile == "" {
+				return i18n.Errorf("cannot determine the config file location (set AWS_CONSOLE_CONFIG)")
+			}
+			data, err := os.ReadFile(deps.configFile)
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(deps.stdout, "No config file at %s.\n", deps.configFile)
+				return nil
+			}
+			if err != nil {
+				return i18n.Errorf("failed to read config file: %w", err)
+			}
+
+			fmt.Fprintf(deps.stdout, "# %s\n%s", deps.configFile, data)
+			return nil
+		},
+	}
+}
+
+func newConfigGetCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <setting>",
+		Short: "Print the effective value of a setting",
+		Long: `Prints the value a setting resolves to for this invocation, after flags, the
+config file, environment variables, and the selected profile are applied. Run
+'aws-console config diff --all' to list every setting.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			v, ok := config.Lookup(g.values, args[0])
+			if !ok {
+				return i18n.Errorf("unknown setting %q (run 'aws-console config diff --all' to list settings)", args[0])
+			}
+			fmt.Fprintln(deps.stdout, v.Value)
+			return nil
+		},
+	}
+}
+
+func newConfigSetCmd(deps runDeps) *cobra.Command {
+	var forProfile string
+
+	setCmd := &cobra.Command{
+		Use:   "set <setting> <value>",
+		Short: "Store a setting in the aws-console config file",
+		Long: `Stores a default in the aws-console config file. With --for-profile the value
+only applies when that profile is selected. Use alias.<name> to make <name> a
+shorthand for a profile, as in 'aws-console config set alias.prod prod-admin'.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+			if deps.configFile == "" {
+				return i18n.Errorf("cannot determine the config file location (set AWS_CONSOLE_CONFIG)")
+			}
+
+			if name, ok := strings.CutPrefix(key, aliasPrefix); ok {
+				if name == "" || forProfile != "" {
+					return i18n.Errorf("invalid alias %q: use alias.<name> without --for-profile", key)
+				}
+				if file.Aliases == nil {
+					file.Aliases = map[string]string{}
+				}
+				file.Aliases[name] = value
+			} else {
+				if _, ok := fileSetting(key); !ok {
+					return i18n.Errorf("setting %q cannot be stored in the config file", key)
+				}
+				if err := validateSetting(key, value); err != nil {
+					return err
+				}
+				file.Set(forProfile, key, value)
+			}
+
+			return file.Save(deps.configFile)
+		},
+	}
+
+	setCmd.Flags().StringVar(&forProfile, "for-profile", "", "Store the value for this AWS profile only")
+	return setCmd
+}
+
+func newConfigUnsetCmd(deps runDeps) *cobra.Command {
+	var forProfile string
+
+	unsetCmd := &cobra.Command{
+		Use:   "unset <setting>",
+		Short: "Remove a setting from the aws-console config file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+
+			var removed bool
+			if name, ok := strings.CutPrefix(key, aliasPrefix); ok {
+				_, removed = file.Aliases[name]
+				delete(file.Aliases, name)
+			} else {
+				removed = file.Unset(forProfile, key)
+			}
+			if !removed {
+				fmt.Fprintf(deps.stderr, "%s is not set in %s\n", key, deps.configFile)
+				return nil
+			}
+
+			return file.Save(deps.configFile)
+		},
+	}
+
+	unsetCmd.Flags().StringVar(&forProfile, "for-profile", "", "Remove the value stored for this AWS profile")
+	return unsetCmd
+}
+
+// validateSetting checks a value before it is stored, so a bad value is reported by
+// 'config set' instead of by every later invocation.
+func validateSetting(key, value string) error {
+	switch key {
+	case settingOutput:
+		_, err := output.ParseFormat(value)
+		return err
+	case settingVerbose, settingDebugHTTP, settingTimings, settingInsecure, settingHistory, settingLocalRedirect, settingHeadless, settingNotify, settingIsolateAccounts, settingStrict:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return i18n.Errorf("invalid %s setting: %w", key, err)
+		}
+	case settingDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return i18n.Errorf("invalid duration %q: %w", value, err)
+		}
+		return awslib.ValidateSessionDuration("", d)
+	case settingDeadline:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return i18n.Errorf("invalid deadline %q: %w", value, err)
+		}
+		if d < 0 {
+			return i18n.Errorf("invalid deadline %q: must not be negative", value)
+		}
+	case settingSTSEndpoint:
+		return awslib.ValidateSTSEndpoint(value)
+	case settingFederationEndpoint:
+		return awslib.ValidateFederationEndpoint(value)
+	case settingReauthURL:
+		return validateReauthURL(value)
+	case settingPartition:
+		return awslib.ValidatePartition(value)
+	case settingColor:
+		return awslib.ValidateSwitchRoleColor(value)
+	case settingProxy:
+		return awslib.ValidateProxy(value)
+	case settingCredentialStore:
+		return validateCredentialStore(value)
+	case settingPolicyARNs:
+		if arns := parsePolicyARNs(value); len(arns) > 0 {
+			return (&awslib.SessionPolicy{PolicyARNs: arns}).Validate()
+		}
+	case settingForbiddenSources:
+		_, err := parseForbiddenSources(value)
+		return err
+	case settingBrowser:
+		name, profile := browser.ParseSpec(value)
+		return browser.Validate(browser.Options{Browser: name, Profile: profile})
+	}
+	return nil
+}
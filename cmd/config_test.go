@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestConfigDiffCmd(t *testing.T) {
+	testCases := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		wantContains []string
+		wantMissing  []string
+	}{
+		{
+			name: "reports env and profile overrides",
+			env:  map[string]string{"AWS_PROFILE": "dev"},
+			args: []string{"config", "diff", "-o", "csv"},
+			wantContains: []string{
+				"setting,value,default,source,origin",
+				"profile,dev,,env,AWS_PROFILE",
+				"region,us-west-2,,profile,profile dev",
+			},
+			wantMissing: []string{"aws-config-file"},
+		},
+		{
+			name: "env beats profile region",
+			env:  map[string]string{"AWS_PROFILE": "dev", "AWS_REGION": "eu-central-1"},
+			args: []string{"config", "diff", "-o", "csv"},
+			wantContains: []string{
+				"region,eu-central-1,,env,AWS_REGION",
+			},
+		},
+		{
+			name:         "no overrides",
+			args:         []string{"config", "diff"},
+			wantContains: []string{"All settings match the built-in defaults."},
+		},
+		{
+			name:         "all settings",
+			args:         []string{"config", "diff", "--all", "-o", "csv"},
+			wantContains: []string{"aws-config-file,~/.aws/config,~/.aws/config,default,"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_CONFIG_FILE", "AWS_CA_BUNDLE"} {
+				t.Setenv(name, tc.env[name])
+			}
+
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return testProfiles(), nil
+					},
+				},
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+			for _, missing := range tc.wantMissing {
+				if strings.Contains(out, missing) {
+					t.Fatalf("expected output not to contain %q, got:\n%s", missing, out)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigSetGetUnset(t *testing.T) {
+	for _, name := range []string{"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_CONSOLE_BROWSER"} {
+		t.Setenv(name, "")
+	}
+
+	path := filepath.Join(t.TempDir(), "aws-console", "config.yaml")
+	deps := runDeps{
+		configFile: path,
+		profiles: &mocks.ProfileLister{
+			ListProfilesFunc: func() ([]awslib.Profile, error) {
+				return testProfiles(), nil
+			},
+		},
+	}
+
+	out, err := executeSubcommand(t, deps, "config", "view")
+	if err != nil || !strings.Contains(out, "No config file at "+path) {
+		t.Fatalf("expected missing file note, got %q (%v)", out, err)
+	}
+
+	steps := [][]string{
+		{"config", "set", "browser", "firefox"},
+		{"config", "set", "duration", "1h", "--for-profile", "dev"},
+		{"config", "set", "alias.d", "dev"},
+	}
+	for _, args := range steps {
+		if _, err := executeSubcommand(t, deps, args...); err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+	}
+
+	out, err = executeSubcommand(t, deps, "config", "view")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"# " + path, "browser: firefox", "dev:", "duration: 1h", "d: dev"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected config view to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	out, err = executeSubcommand(t, deps, "config", "get", "duration", "--profile", "d")
+	if err != nil || out != "1h\n" {
+		t.Fatalf("expected the profile's duration, got %q (%v)", out, err)
+	}
+	out, err = executeSubcommand(t, deps, "config", "get", "duration")
+	if err != nil || out != "12h0m0s\n" {
+		t.Fatalf("expected the default duration, got %q (%v)", out, err)
+	}
+
+	if _, err := executeSubcommand(t, deps, "config", "unset", "duration", "--for-profile", "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err = executeSubcommand(t, deps, "config", "get", "duration", "--profile", "dev")
+	if err != nil || out != "12h0m0s\n" {
+		t.Fatalf("expected the unset duration to fall back, got %q (%v)", out, err)
+	}
+}
+
+func TestConfigSetRejectsInvalidValues(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		wantErrSubstr string
+	}{
+		{name: "unknown setting", args: []string{"browsr", "firefox"}, wantErrSubstr: `setting "browsr" cannot be stored in the config file`},
+		{name: "file location", args: []string{"aws-config-file", "/tmp/config"}, wantErrSubstr: "cannot be stored in the config file"},
+		{name: "duration", args: []string{"duration", "13h"}, wantErrSubstr: "must be between 15m0s and 12h0m0s"},
+		{name: "bool", args: []string{"verbose", "sometimes"}, wantErrSubstr: "invalid verbose setting"},
+		{name: "strict", args: []string{"strict", "always"}, wantErrSubstr: "invalid strict setting"},
+		{name: "credential sources", args: []string{"forbid-credential-sources", "keys,static"}, wantErrSubstr: `unknown credential source "static"`},
+		{name: "browser", args: []string{"browser", "netscape"}, wantErrSubstr: `unknown browser "netscape"`},
+		{name: "browser command", args: []string{"browser", "open -a Arc {{url}} {{account}}"}, wantErrSubstr: "unknown placeholder {{account}}"},
+		{name: "alias for profile", args: []string{"alias.p", "prod", "--for-profile", "dev"}, wantErrSubstr: "without --for-profile"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			_, err := executeSubcommand(t, runDeps{configFile: path}, append([]string{"config", "set"}, tc.args...)...)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+			if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+				t.Fatalf("expected no config file to be written, got %v", statErr)
+			}
+		})
+	}
+}
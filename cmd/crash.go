@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+const crashDirName = "crashes"
+
+// sensitiveFlagPattern matches flag names whose values must never be written to disk.
+var sensitiveFlagPattern = regexp.MustCompile(`(?i)(token|secret|password|passphrase|key|credential|external-id)`)
+
+// sensitiveValuePatterns match secrets that can appear anywhere in arguments or panic values.
+var sensitiveValuePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`),
+	regexp.MustCompile(`(?i)(SigninToken=)[^&\s]+`),
+	regexp.MustCompile(`(?i)(sessionToken|sessionKey|SecretAccessKey|SessionToken)("?\s*[:=]\s*"?)[^"&\s,}]+`),
+}
+
+const redacted = "[REDACTED]"
+
+// writeCrashReport records a panic as a redacted report under stateDir and returns
+// the error presented to the user in place of the raw panic output.
+func writeCrashReport(recovered any, stack []byte, args []string, stateDir string, now time.Time) error {
+	panicValue := redactText(fmt.Sprint(recovered))
+
+	if stateDir == "" {
+		return i18n.Errorf("aws-console crashed unexpectedly: %s (no state directory available for a crash report)", panicValue)
+	}
+
+	dir := filepath.Join(stateDir, crashDirName)
+	path := filepath.Join(dir, "crash-"+now.UTC().Format("20060102T150405Z")+".txt")
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "aws-console crash report\n\n")
+	fmt.Fprintf(&report, "time:     %s\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&report, "version:  %s\n", Version)
+	fmt.Fprintf(&report, "go:       %s\n", runtime.Version())
+	fmt.Fprintf(&report, "platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&report, "args:     %s\n", strings.Join(redactArgs(args), " "))
+	fmt.Fprintf(&report, "panic:    %s\n\n", panicValue)
+	fmt.Fprintf(&report, "%s", redactText(string(stack)))
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return i18n.Errorf("aws-console crashed unexpectedly: %s (failed to write crash report: %v)", panicValue, err)
+	}
+	if err := os.WriteFile(path, []byte(report.String()), 0o600); err != nil {
+		return i18n.Errorf("aws-console crashed unexpectedly: %s (failed to write crash report: %v)", panicValue, err)
+	}
+
+	return i18n.Errorf("aws-console crashed unexpectedly: %s\nA crash report was saved to %s; please attach it when reporting the issue", panicValue, path)
+}
+
+// redactArgs replaces the values of sensitive flags, in both "--flag value" and
+// "--flag=value" form, along with any recognizable secrets in other arguments.
+func redactArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	redactNext := false
+	for _, arg := range args {
+		if redactNext {
+			out = append(out, redacted)
+			redactNext = false
+			continue
+		}
+
+		if strings.HasPrefix(arg, "-") {
+			name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if sensitiveFlagPattern.MatchString(name) {
+				if hasValue {
+					out = append(out, arg[:strings.Index(arg, "=")+1]+redacted)
+				} else {
+					out = append(out, arg)
+					redactNext = true
+				}
+				continue
+			}
+		}
+
+		out = append(out, redactText(arg))
+	}
+	return out
+}
+
+func redactText(text string) string {
+	for _, pattern := range sensitiveValuePatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			if len(groups) > 2 {
+				return groups[1] + groups[2] + redacted
+			}
+			if len(groups) == 2 && strings.HasSuffix(groups[1], "=") {
+				return groups[1] + redacted
+			}
+			return redacted
+		})
+	}
+	return text
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// credentialProcessEnv is set while credential-process resolves credentials, so a
+// profile whose credential_process runs aws-console for itself fails instead of looping.
+const credentialProcessEnv = "AWS_CONSOLE_CREDENTIAL_PROCESS"
+
+// credentialProcessOutput is the JSON document the AWS SDKs and CLI expect from a
+// credential_process command.
+type credentialProcessOutput struct {
+	Version         int    `json:"Version"`
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken,omitempty"`
+	Expiration      string `json:"Expiration,omitempty"`
+}
+
+func newCredentialProcessCmd(deps runDeps) *cobra.Command {
+	var noCache bool
+	var assumeRole awslib.AssumeRoleInput
+
+	processCmd := &cobra.Command{
+		Use:   "credential-process",
+		Short: "Print credentials in the format of the AWS credential_process setting",
+		Long: `Prints temporary credentials for the profile as the JSON document expected from
+a credential_process command, so another profile can source its credentials
+from aws-console:
+
+  [profile console-dev]
+  credential_process = aws-console credential-process --profile dev
+
+The profile given with --profile must not be the one using it as its
+credential_process.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if os.Getenv(credentialProcessEnv) != "" {
+				return i18n.Errorf("credential-process called itself: --profile must not name a profile whose credential_process runs aws-console")
+			}
+			if err := validateAssumeRole(assumeRole); err != nil {
+				return err
+			}
+
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			deps.printOnly = true
+
+			if err := os.Setenv(credentialProcessEnv, "1"); err != nil {
+				return err
+			}
+			defer os.Unsetenv(credentialProcessEnv)
+
+			opts := workflowOptions{profile: g.profile, noCache: noCache, assumeRole: assumeRole}
+			creds, err := sessionCredentials(ctx, opts, deps)
+			if err != nil {
+				return err
+			}
+
+			out := credentialProcessOutput{
+				Version:         1,
+				AccessKeyID:     creds.AccessKeyID,
+				SecretAccessKey: creds.SecretAccessKey,
+				SessionToken:    creds.SessionToken,
+			}
+			if !creds.Expires.IsZero() {
+				out.Expiration = creds.Expires.UTC().Format(time.RFC3339)
+			}
+			return json.NewEncoder(deps.stdout).Encode(out)
+		},
+	}
+
+	processCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or update the credential cache")
+	addAssumeRoleFlags(processCmd, &assumeRole)
+
+	return processCmd
+}
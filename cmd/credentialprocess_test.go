@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestCredentialProcessCmd(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.FixedZone("PST", -8*3600))
+
+	testCases := []struct {
+		name          string
+		env           string
+		creds         awslib.Credentials
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name:  "temporary credentials",
+			creds: awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: expires},
+			want:  `{"Version":1,"AccessKeyId":"ASIA","SecretAccessKey":"secret","SessionToken":"token","Expiration":"2030-01-02T11:04:05Z"}` + "\n",
+		},
+		{
+			name:          "recursive call",
+			env:           "1",
+			wantErrSubstr: "credential-process called itself",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(credentialProcessEnv, tc.env)
+
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return tc.creds, nil
+					},
+				},
+				sessionDuration: sessionDuration,
+			}
+
+			out, err := executeSubcommand(t, deps, "credential-process", "--profile", "dev")
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tc.want {
+				t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, tc.want)
+			}
+		})
+	}
+}
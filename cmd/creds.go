@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// credentialFormats render credentials for a shell or tool, keyed by --format value.
+var credentialFormats = map[string]func(w io.Writer, profile string, creds awslib.Credentials) error{
+	"env":             writeEnvCredentials("export %s=%s\n", posixQuote),
+	"fish":            writeEnvCredentials("set -gx %s %s\n", posixQuote),
+	"powershell":      writeEnvCredentials("$Env:%s = %s\n", powershellQuote),
+	"cmd":             writeEnvCredentials("set \"%s=%s\"\n", noQuote),
+	"env-file":        writeEnvCredentials("%s=%s\n", noQuote),
+	"json":            writeJSONCredentials,
+	"credential-file": writeCredentialFile,
+}
+
+func credentialFormatNames() []string {
+	names := make([]string, 0, len(credentialFormats))
+	for name := range credentialFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newCredsCmd(deps runDeps) *cobra.Command {
+	var format string
+	var noCache bool
+	var assumeRole awslib.AssumeRoleInput
+
+	credsCmd := &cobra.Command{
+		Use:   "creds",
+		Short: "Print temporary credentials for the profile as environment variables",
+		Long: `Prints temporary credentials for the profile, resolved the same way as when
+opening the console: from the credential cache, or after an SSO login when the
+profile's credentials are not valid. Long-lived keys are exchanged for a session
+token, and --role-arn assumes a role first.
+
+  eval "$(aws-console creds -p dev)"
+
+--format selects env (POSIX shells), fish, powershell, cmd (set commands for a
+Windows batch file or 'for /f'), env-file (KEY=value lines for docker --env-file
+and .env loaders), json, or credential-file (a section for ~/.aws/credentials named
+after the profile).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			write, ok := credentialFormats[format]
+			if !ok {
+				return i18n.Errorf("unsupported credentials format %q (expected one of: %s)", format, strings.Join(credentialFormatNames(), ", "))
+			}
+			if err := validateAssumeRole(assumeRole); err != nil {
+				return err
+			}
+
+			g, err := resolveWorkflowGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			// Progress messages must not end up in the evaluated output.
+			deps.printOnly = true
+
+			opts := workflowOptions{profile: g.profile, noCache: noCache, assumeRole: assumeRole}
+			creds, err := sessionCredentials(ctx, opts, deps)
+			if err != nil {
+				return err
+			}
+			return write(deps.stdout, g.profile, creds)
+		},
+	}
+
+	credsCmd.Flags().StringVar(&format, "format", "env", "Output format: "+strings.Join(credentialFormatNames(), ", "))
+	credsCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or update the credential cache")
+	addAssumeRoleFlags(credsCmd, &assumeRole)
+
+	return credsCmd
+}
+
+// sessionCredentials returns temporary credentials for opts.profile as the console
+// workflow would federate with them: from the cache when fresh ones are there, or
+// resolved after an SSO login when needed.
+func sessionCredentials(ctx context.Context, opts workflowOptions, deps runDeps) (awslib.Credentials, error) {
+	if creds, ok := daemonCredentials(ctx, opts, deps); ok {
+		return creds, nil
+	}
+
+	cache := deps.credentials
+	if opts.noCache || limitedSession(opts, deps) {
+		cache = nil
+	}
+	if cache != nil {
+		if _, creds, ok := cache.Credentials(opts.profile, opts.assumeRole.RoleARN); ok {
+			verbosef(deps, "Using cached credentials for %s until %s", describeProfile(opts.profile), creds.Expires.Format(time.RFC3339))
+			return creds, nil
+		}
+	}
+
+	identity, err := authenticate(ctx, opts.profile, deps)
+	if err != nil {
+		return awslib.Credentials{}, err
+	}
+	creds, err := federationCredentials(ctx, opts.profile, &identity, opts, deps)
+	if err != nil {
+		return awslib.Credentials{}, err
+	}
+	if cache != nil {
+		if err := cache.PutCredentials(opts.profile, opts.assumeRole.RoleARN, identity, creds); err != nil {
+			deps.messages.Fprintf(deps.stderr, "Warning: %v\n", err)
+		}
+	}
+	return creds, nil
+}
+
+// credentialVariables returns the environment variables for creds, in order.
+func credentialVariables(creds awslib.Credentials) [][2]string {
+	vars := [][2]string{
+		{"AWS_ACCESS_KEY_ID", creds.AccessKeyID},
+		{"AWS_SECRET_ACCESS_KEY", creds.SecretAccessKey},
+	}
+	if creds.SessionToken != "" {
+		vars = append(vars, [2]string{"AWS_SESSION_TOKEN", creds.SessionToken})
+	}
+	if !creds.Expires.IsZero() {
+		vars = append(vars, [2]string{"AWS_CREDENTIAL_EXPIRATION", formatTimestamp(creds.Expires.UTC())})
+	}
+	return vars
+}
+
+func writeEnvCredentials(line string, quote func(string) string) func(io.Writer, string, awslib.Credentials) error {
+	return func(w io.Writer, profile string, creds awslib.Credentials) error {
+		for _, v := range credentialVariables(creds) {
+			if _, err := fmt.Fprintf(w, line, v[0], quote(v[1])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// posixQuote single-quotes s for POSIX shells and fish.
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// noQuote leaves s as it is, for formats without quoting, such as cmd's set "KEY=value"
+// and env files. Credentials hold no double quotes, percent signs, or line breaks.
+func noQuote(s string) string {
+	return s
+}
+
+// powershellQuote single-quotes s for PowerShell, where a quote is escaped by doubling it.
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func writeJSONCredentials(w io.Writer, profile string, creds awslib.Credentials) error {
+	record := make(map[string]string)
+	for _, v := range credentialVariables(creds) {
+		record[v[0]] = v[1]
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(record)
+}
+
+// writeCredentialFile writes a ~/.aws/credentials section named after profile.
+func writeCredentialFile(w io.Writer, profile string, creds awslib.Credentials) error {
+	if profile == "" {
+		profile = "default"
+	}
+	if _, err := fmt.Fprintf(w, "[%s]\naws_access_key_id = %s\naws_secret_access_key = %s\n", profile, creds.AccessKeyID, creds.SecretAccessKey); err != nil {
+		return err
+	}
+	if creds.SessionToken != "" {
+		if _, err := fmt.Fprintf(w, "aws_session_token = %s\n", creds.SessionToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/credcache"
+)
+
+func TestCredsCmdFormats(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		args          []string
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name: "env",
+			args: []string{"creds", "-p", "dev"},
+			want: "export AWS_ACCESS_KEY_ID='ASIA'\nexport AWS_SECRET_ACCESS_KEY='se'\\''cret'\nexport AWS_SESSION_TOKEN='token'\nexport AWS_CREDENTIAL_EXPIRATION='2030-01-02T03:04:05Z'\n",
+		},
+		{
+			name: "fish",
+			args: []string{"creds", "-p", "dev", "--format", "fish"},
+			want: "set -gx AWS_ACCESS_KEY_ID 'ASIA'\nset -gx AWS_SECRET_ACCESS_KEY 'se'\\''cret'\nset -gx AWS_SESSION_TOKEN 'token'\nset -gx AWS_CREDENTIAL_EXPIRATION '2030-01-02T03:04:05Z'\n",
+		},
+		{
+			name: "powershell",
+			args: []string{"creds", "-p", "dev", "--format", "powershell"},
+			want: "$Env:AWS_ACCESS_KEY_ID = 'ASIA'\n$Env:AWS_SECRET_ACCESS_KEY = 'se''cret'\n$Env:AWS_SESSION_TOKEN = 'token'\n$Env:AWS_CREDENTIAL_EXPIRATION = '2030-01-02T03:04:05Z'\n",
+		},
+		{
+			name: "cmd",
+			args: []string{"creds", "-p", "dev", "--format", "cmd"},
+			want: "set \"AWS_ACCESS_KEY_ID=ASIA\"\nset \"AWS_SECRET_ACCESS_KEY=se'cret\"\nset \"AWS_SESSION_TOKEN=token\"\nset \"AWS_CREDENTIAL_EXPIRATION=2030-01-02T03:04:05Z\"\n",
+		},
+		{
+			name: "env file",
+			args: []string{"creds", "-p", "dev", "--format", "env-file"},
+			want: "AWS_ACCESS_KEY_ID=ASIA\nAWS_SECRET_ACCESS_KEY=se'cret\nAWS_SESSION_TOKEN=token\nAWS_CREDENTIAL_EXPIRATION=2030-01-02T03:04:05Z\n",
+		},
+		{
+			name: "json",
+			args: []string{"creds", "-p", "dev", "--format", "json"},
+			want: "{\n  \"AWS_ACCESS_KEY_ID\": \"ASIA\",\n  \"AWS_CREDENTIAL_EXPIRATION\": \"2030-01-02T03:04:05Z\",\n  \"AWS_SECRET_ACCESS_KEY\": \"se'cret\",\n  \"AWS_SESSION_TOKEN\": \"token\"\n}\n",
+		},
+		{
+			name: "credential file",
+			args: []string{"creds", "-p", "dev", "--format", "credential-file"},
+			want: "[dev]\naws_access_key_id = ASIA\naws_secret_access_key = se'cret\naws_session_token = token\n",
+		},
+		{
+			name:          "unknown format",
+			args:          []string{"creds", "-p", "dev", "--format", "tcsh"},
+			wantErrSubstr: `unsupported credentials format "tcsh" (expected one of: cmd, credential-file, env, env-file, fish, json, powershell)`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "se'cret", SessionToken: "token", Expires: expires}, nil
+					},
+				},
+				sessionDuration: sessionDuration,
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tc.want {
+				t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, tc.want)
+			}
+		})
+	}
+}
+
+func TestCredsCmdWorkflow(t *testing.T) {
+	t.Parallel()
+
+	loggedIn := false
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			if !loggedIn {
+				return awslib.Identity{}, errors.New("expired token")
+			}
+			return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/alice", Account: "123456789012"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}, nil
+		},
+		GetSessionTokenFunc: func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	stderr := &bytes.Buffer{}
+	deps := runDeps{
+		awsService:  service,
+		credentials: credcache.NewCacheAt(t.TempDir()),
+		login: func(ctx context.Context, profile string) error {
+			loggedIn = true
+			return nil
+		},
+		term:            interactiveTerminal,
+		stderr:          stderr,
+		sessionDuration: sessionDuration,
+	}
+
+	for i := 0; i < 2; i++ {
+		out, err := executeSubcommand(t, deps, "creds", "-p", "keys")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(out, "export AWS_ACCESS_KEY_ID='ASIA'") || strings.Contains(out, "requesting temporary credentials") {
+			t.Fatalf("expected only the session token's variables on stdout, got:\n%s", out)
+		}
+	}
+
+	if !strings.Contains(stderr.String(), "attempting SSO login") {
+		t.Fatalf("expected the SSO fallback to run, got %q", stderr.String())
+	}
+	if service.GetSessionTokenCalls.Load() != 1 {
+		t.Fatalf("expected the second run to use the cache, got %d GetSessionToken calls", service.GetSessionTokenCalls.Load())
+	}
+}
@@ -0,0 +1,394 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/console"
+	"github.com/eculver/aws-console/pkg/daemon"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// defaultDaemonRefresh is how often the daemon prepares its profiles again.
+	defaultDaemonRefresh = 5 * time.Minute
+	// daemonShutdownTimeout bounds how long a stopping daemon waits for requests in flight.
+	daemonShutdownTimeout = 5 * time.Second
+)
+
+// daemonEnv is set in the daemon's environment, so aws-console processes it starts,
+// such as a credential_process, resolve credentials themselves instead of asking it.
+const daemonEnv = "AWS_CONSOLE_DAEMON"
+
+func newDaemonCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var refresh time.Duration
+
+	daemonCmd := &cobra.Command{
+		Use:   "daemon [profile...]",
+		Short: "Keep credentials ready and serve them and sign-in URLs over a local socket",
+		Long: `Runs in the foreground, keeping credentials and sign-in tokens warm for the
+profiles given, or those listed under warm in the aws-console config file, and
+serving credentials and console sign-in URLs to local clients over a Unix socket
+in the aws-console state directory. Only the current user can connect to it.
+
+While the daemon runs, 'aws-console creds', 'exec', and 'credential-process' get
+their credentials from it, and 'aws-console daemon open' opens the console with a
+sign-in URL from it. Scripts and editor plugins can call the socket directly:
+
+  curl --unix-socket ~/.local/state/aws-console/daemon.sock \
+    -d '{"profile": "dev", "destination": "s3"}' http://daemon/v1/open
+
+The endpoints are GET /v1/status, POST /v1/creds and POST /v1/open. The daemon
+never prompts: profiles whose SSO login has expired or that need an MFA code fail
+until signed in from a terminal. Requests for one profile are served one at a
+time, so concurrent clients share a single refresh.`,
+		ValidArgsFunction: completeProfiles(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if refresh <= 0 {
+				return i18n.Errorf("invalid --refresh %s: must be positive", refresh)
+			}
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			profiles, err := warmProfiles(args, deps)
+			if err != nil && !errors.Is(err, errNoWarmProfiles) {
+				return err
+			}
+			if err := os.Setenv(daemonEnv, "1"); err != nil {
+				return err
+			}
+			return serveDaemon(ctx, profiles, refresh, deps, runner)
+		},
+	}
+
+	daemonCmd.Flags().DurationVar(&refresh, "refresh", defaultDaemonRefresh, "How often to prepare the profiles again")
+	daemonCmd.AddCommand(newDaemonStatusCmd(deps), newDaemonOpenCmd(deps))
+	return daemonCmd
+}
+
+// serveDaemon serves the daemon API until ctx is done, preparing profiles every refresh.
+func serveDaemon(ctx context.Context, profiles []string, refresh time.Duration, deps runDeps, runner workflowRunner) error {
+	if deps.daemon == nil {
+		return i18n.Errorf("the daemon is unavailable: no state directory")
+	}
+	backend := newDaemonBackend(deps, runner)
+	server, err := daemon.Listen(deps.daemon.Path(), backend)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := server.Close(daemonShutdownTimeout); err != nil {
+			deps.messages.Fprintf(deps.stderr, "Warning: %v\n", err)
+		}
+	}()
+
+	deps.messages.Fprintf(deps.stderr, "Serving credentials on %s (Ctrl-C to stop)\n", server.Path())
+	if len(profiles) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	for {
+		backend.warm(ctx, profiles)
+		if err := deps.sleep(ctx, refresh); err != nil {
+			return nil
+		}
+	}
+}
+
+// daemonBackend resolves what the daemon serves with the same workflow as the CLI.
+type daemonBackend struct {
+	deps    runDeps
+	runner  workflowRunner
+	started time.Time
+	locks   daemon.Locks
+
+	mu       sync.Mutex
+	profiles map[string]daemon.ProfileStatus
+}
+
+func newDaemonBackend(deps runDeps, runner workflowRunner) *daemonBackend {
+	// The daemon has no terminal to prompt on, and must not ask itself for credentials.
+	deps.stdin = strings.NewReader("")
+	deps.term.StdinTTY = false
+	deps.daemon = nil
+	deps.login = func(ctx context.Context, profile string) error {
+		return awslib.MarkError(i18n.Errorf("%s needs an SSO login; run 'aws-console %s' to sign in", describeProfile(profile), profile), awslib.ErrSSOLoginRequired)
+	}
+	return &daemonBackend{deps: deps, runner: runner, started: deps.now(), profiles: make(map[string]daemon.ProfileStatus)}
+}
+
+// requestDeps returns the dependencies of a single request. Requests share the daemon's
+// settings and caches, but each records its own timings, which are reported and exported
+// once per run.
+func (b *daemonBackend) requestDeps() runDeps {
+	deps := b.deps
+	deps.timings = b.deps.timings.fork()
+	return deps
+}
+
+// check accepts only configured profiles that can be used without signing in.
+func (b *daemonBackend) check(profile string) error {
+	if err := checkReauthProfile(profile, b.deps); err != nil {
+		return err
+	}
+	if reason := ssoLoginReason(profile, b.deps); reason != "" {
+		return awslib.MarkError(i18n.Errorf("%s; run 'aws-console %s' to sign in", reason, profile), awslib.ErrSSOLoginRequired)
+	}
+	return nil
+}
+
+func (b *daemonBackend) Credentials(ctx context.Context, profile string) (awslib.Credentials, error) {
+	if err := b.check(profile); err != nil {
+		return awslib.Credentials{}, err
+	}
+	unlock := b.locks.Lock(profile)
+	defer unlock()
+
+	creds, err := sessionCredentials(ctx, workflowOptions{profile: profile}, b.requestDeps())
+	b.record(profile, creds, err)
+	return creds, err
+}
+
+func (b *daemonBackend) Open(ctx context.Context, req daemon.OpenRequest) (daemon.OpenResponse, error) {
+	if err := b.check(req.Profile); err != nil {
+		return daemon.OpenResponse{}, err
+	}
+	path, err := rootDestination(req.Destination, "", "", "")
+	if err != nil {
+		return daemon.OpenResponse{}, err
+	}
+	unlock := b.locks.Lock(req.Profile)
+	defer unlock()
+
+	// The client opens the browser, so the workflow only captures the URL.
+	var loginURL string
+	requestDeps := b.requestDeps()
+	requestDeps.term.StdoutTTY = true
+	requestDeps.term.Term = "dumb"
+	requestDeps.localRedirect = false
+	requestDeps.stdout = b.deps.stderr
+	requestDeps.open = func(targetURL string, opts browserOptions) error {
+		loginURL = targetURL
+		return nil
+	}
+
+	err = b.runner(ctx, workflowOptions{profile: req.Profile, destination: path}, requestDeps)
+	if err == nil && loginURL == "" {
+		err = i18n.Errorf("sign-in did not produce a console URL")
+	}
+	var creds awslib.Credentials
+	if b.deps.credentials != nil {
+		_, creds, _ = b.deps.credentials.Credentials(req.Profile, "")
+	}
+	b.record(req.Profile, creds, err)
+	if err != nil {
+		return daemon.OpenResponse{}, err
+	}
+	return daemon.OpenResponse{URL: loginURL}, nil
+}
+
+func (b *daemonBackend) Status(ctx context.Context) daemon.Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := daemon.Status{PID: os.Getpid(), Started: b.started}
+	for _, p := range b.profiles {
+		status.Profiles = append(status.Profiles, p)
+	}
+	slices.SortFunc(status.Profiles, func(a, b daemon.ProfileStatus) int { return strings.Compare(a.Profile, b.Profile) })
+	return status
+}
+
+// warm prepares profiles like 'aws-console warm', holding them until it is done so
+// requests for them wait for its credentials instead of refreshing them again.
+func (b *daemonBackend) warm(ctx context.Context, profiles []string) {
+	for _, profile := range profiles {
+		unlock := b.locks.Lock(profile)
+		defer unlock()
+	}
+
+	for _, r := range warmResults(ctx, profiles, console.DefaultWarmWorkers, b.requestDeps()) {
+		if r.Err != nil {
+			b.deps.messages.Fprintf(b.deps.stderr, "Failed to prepare %s: %v\n", describeProfile(r.Profile), r.Err)
+		}
+		b.recordStatus(daemon.ProfileStatus{
+			Profile: r.Profile,
+			Account: r.URL.Identity.Account,
+			Expires: r.URL.Credentials.Expires,
+		}, r.Err)
+	}
+}
+
+// record notes the credentials of profile, or the error that replaced them.
+func (b *daemonBackend) record(profile string, creds awslib.Credentials, err error) {
+	status := daemon.ProfileStatus{Profile: profile, Expires: creds.Expires}
+	if b.deps.credentials != nil {
+		if identity, _, ok := b.deps.credentials.Credentials(profile, ""); ok {
+			status.Account = identity.Account
+		}
+	}
+	b.recordStatus(status, err)
+}
+
+func (b *daemonBackend) recordStatus(status daemon.ProfileStatus, err error) {
+	status.Refreshed = b.deps.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if status.Account == "" {
+		status.Account = b.profiles[status.Profile].Account
+	}
+	if err != nil {
+		status.Expires = time.Time{}
+		status.Error = err.Error()
+	}
+	b.profiles[status.Profile] = status
+}
+
+// daemonCredentials returns credentials for opts.profile from a running daemon. It
+// reports false, and the caller resolves them itself, when no daemon is running, when it
+// fails, or when opts ask for something the daemon does not serve.
+func daemonCredentials(ctx context.Context, opts workflowOptions, deps runDeps) (awslib.Credentials, bool) {
+	if deps.daemon == nil || opts.profile == "" || opts.noCache || opts.assumeRole.RoleARN != "" || limitedSession(opts, deps) || os.Getenv(daemonEnv) != "" {
+		return awslib.Credentials{}, false
+	}
+	creds, err := deps.daemon.Credentials(ctx, opts.profile)
+	if err != nil {
+		if !errors.Is(err, daemon.ErrNotRunning) {
+			verbosef(deps, "The daemon could not provide credentials for %s: %v", describeProfile(opts.profile), err)
+		}
+		return awslib.Credentials{}, false
+	}
+	verbosef(deps, "Using credentials for %s from the daemon on %s", describeProfile(opts.profile), deps.daemon.Path())
+	return creds, true
+}
+
+// daemonClient returns the client of the running daemon, or an error explaining how to
+// start it.
+func daemonClient(deps runDeps) (*daemon.Client, error) {
+	if deps.daemon == nil {
+		return nil, i18n.Errorf("the daemon is unavailable: no state directory")
+	}
+	return deps.daemon, nil
+}
+
+// notRunningHint adds how to start the daemon to an error saying it is not running.
+func notRunningHint(err error) error {
+	if errors.Is(err, daemon.ErrNotRunning) {
+		return i18n.Errorf("%w; start it with 'aws-console daemon'", err)
+	}
+	return err
+}
+
+func newDaemonStatusCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon is running and the profiles it has prepared",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			deps.messages = g.printer()
+			client, err := daemonClient(deps)
+			if err != nil {
+				return err
+			}
+			status, err := client.Status(context.Background())
+			if err != nil {
+				return notRunningHint(err)
+			}
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "PROFILE", Key: "profile"},
+					{Header: "ACCOUNT", Key: "account"},
+					{Header: "EXPIRY", Key: "expiry"},
+					{Header: "REFRESHED", Key: "refreshed"},
+					{Header: "ERROR", Key: "error"},
+				},
+			}
+			for _, p := range status.Profiles {
+				table.Rows = append(table.Rows, []string{p.Profile, p.Account, formatTimestamp(p.Expires), formatTimestamp(p.Refreshed), p.Error})
+			}
+
+			if g.output == output.FormatTable {
+				fmt.Fprintf(deps.stdout, "Daemon running on %s since %s (pid %d)\n", client.Path(), formatTimestamp(status.Started), status.PID)
+				if len(table.Rows) == 0 {
+					deps.messages.Fprintln(deps.stdout, "No profiles prepared yet.")
+					return nil
+				}
+				fmt.Fprintln(deps.stdout)
+			}
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+}
+
+func newDaemonOpenCmd(deps runDeps) *cobra.Command {
+	var dest string
+	var print bool
+
+	openCmd := &cobra.Command{
+		Use:   "open [profile]",
+		Short: "Open the console with a sign-in URL from the daemon",
+		Long: `Asks the running daemon for a console sign-in URL for the profile and opens it
+in the browser, or prints it with --print. The daemon resolves the profile with
+its own settings, so only the profile and destination are taken from here.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProfileArg(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if err := setPositionalProfile(cmd, args[0], deps); err != nil {
+					return err
+				}
+			}
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			_, deps := g.apply(context.Background(), deps)
+			if g.profile == "" {
+				return i18n.Errorf("no profile given: name one as an argument or with --profile")
+			}
+			if dest == "" {
+				dest = g.destination
+			}
+			client, err := daemonClient(deps)
+			if err != nil {
+				return err
+			}
+
+			resp, err := client.Open(context.Background(), daemon.OpenRequest{Profile: g.profile, Destination: dest})
+			if err != nil {
+				return notRunningHint(err)
+			}
+			if print || printOnly(deps) {
+				fmt.Fprintln(deps.stdout, resp.URL)
+				return nil
+			}
+			deps.messages.Fprintln(deps.stderr, "Opening AWS Console in your browser...")
+			return deps.open(resp.URL, browserOptions{}.withSettings(deps))
+		},
+	}
+
+	openCmd.Flags().StringVarP(&dest, "destination", "d", "", "Console page to open: a service (ec2, s3, cw, cloudshell, quicksight), a page such as dashboard:<name>, a console path, or a console URL")
+	openCmd.Flags().BoolVar(&print, "print", false, "Print the sign-in URL instead of opening it")
+	return openCmd
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/daemon"
+)
+
+func TestDaemon(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	// The credential cache judges expiry by the wall clock.
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	var mu sync.Mutex
+	retrieved := 0
+	dir := t.TempDir()
+	client := daemon.NewClient(filepath.Join(dir, daemon.SocketName))
+	profiles := &mocks.ProfileLister{
+		ListProfilesFunc: func() ([]awslib.Profile, error) { return testProfiles(), nil },
+	}
+
+	daemonDeps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				mu.Lock()
+				retrieved++
+				mu.Unlock()
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: expires}, nil
+			},
+		},
+		credentials: credcache.NewCacheAt(filepath.Join(dir, "cache")),
+		profiles:    profiles,
+		daemon:      client,
+		now:         func() time.Time { return now },
+		stderr:      &bytes.Buffer{},
+	}
+	var openedDest string
+	runner := func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		openedDest = opts.destination
+		return deps.open("https://signin.aws.amazon.com/federation?Action=login", opts.browser)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serveDaemon(ctx, nil, time.Minute, daemonDeps, runner) }()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if _, err := client.Status(context.Background()); err == nil {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("the daemon did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The CLI resolves nothing itself while the daemon serves the credentials.
+	deps := runDeps{
+		awsService: &mocks.Service{
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{}, errors.New("credentials resolved outside the daemon")
+			},
+		},
+		profiles: profiles,
+		daemon:   client,
+	}
+
+	var wg sync.WaitGroup
+	outs := make([]string, 4)
+	errs := make([]error, len(outs))
+	for i := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outs[i], errs[i] = executeSubcommand(t, deps, "creds", "-p", "dev")
+		}()
+	}
+	wg.Wait()
+	for i := range outs {
+		if errs[i] != nil || !strings.Contains(outs[i], "export AWS_SESSION_TOKEN='token'") {
+			t.Fatalf("unexpected credentials output %q (%v)", outs[i], errs[i])
+		}
+	}
+	if retrieved != 1 {
+		t.Fatalf("expected concurrent requests to share one refresh, got %d", retrieved)
+	}
+
+	out, err := executeSubcommand(t, deps, "daemon", "open", "dev", "-d", "s3")
+	if err != nil || out != "https://signin.aws.amazon.com/federation?Action=login\n" {
+		t.Fatalf("unexpected open output %q (%v)", out, err)
+	}
+	if openedDest != "s3/home" {
+		t.Fatalf("expected the s3 destination, got %q", openedDest)
+	}
+
+	if _, err := executeSubcommand(t, deps, "daemon", "open", "nope"); err == nil || !strings.Contains(err.Error(), `"nope"`) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+
+	out, err = executeSubcommand(t, deps, "daemon", "status", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "profile,account,expiry,refreshed,error\ndev,123456789012," + formatTimestamp(expires) + ",2025-06-01T12:00:00Z,\n"; out != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, out)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := executeSubcommand(t, deps, "daemon", "status"); err == nil || !strings.Contains(err.Error(), "start it with 'aws-console daemon'") {
+		t.Fatalf("expected a not running error, got %v", err)
+	}
+}
+
+func TestDaemonRequestDeps(t *testing.T) {
+	t.Parallel()
+
+	deps := runDeps{now: time.Now, timings: newTimings(time.Now)}
+	deps.timings.silent = true
+	backend := newDaemonBackend(deps, nil)
+
+	first := backend.requestDeps()
+	first.timings.record(timing{step: "sts", duration: time.Second})
+	first.timings.report(&bytes.Buffer{})
+
+	second := backend.requestDeps()
+	if second.timings == first.timings || second.timings == deps.timings {
+		t.Fatal("expected each request to record its own timings")
+	}
+	if steps := second.timings.recorded(); len(steps) != 0 {
+		t.Fatalf("expected no timings from an earlier request, got %v", steps)
+	}
+	if !second.timings.silent {
+		t.Fatal("expected the timings settings of the daemon")
+	}
+	if first := newDaemonBackend(runDeps{now: time.Now}, nil).requestDeps(); first.timings != nil {
+		t.Fatal("expected no timings when they are off")
+	}
+}
+
+func TestDaemonCredentials(t *testing.T) {
+	t.Parallel()
+
+	client := daemon.NewClient(filepath.Join(t.TempDir(), daemon.SocketName))
+	testCases := []struct {
+		name string
+		opts workflowOptions
+		deps runDeps
+	}{
+		{name: "no daemon", opts: workflowOptions{profile: "dev"}, deps: runDeps{}},
+		{name: "not running", opts: workflowOptions{profile: "dev"}, deps: runDeps{daemon: client}},
+		{name: "default profile", opts: workflowOptions{}, deps: runDeps{daemon: client}},
+		{name: "no cache", opts: workflowOptions{profile: "dev", noCache: true}, deps: runDeps{daemon: client}},
+		{name: "assume role", opts: workflowOptions{profile: "dev", assumeRole: awslib.AssumeRoleInput{RoleARN: "arn:aws:iam::123456789012:role/Admin"}}, deps: runDeps{daemon: client}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, ok := daemonCredentials(context.Background(), tc.opts, tc.deps); ok {
+				t.Fatal("expected the credentials to be resolved without the daemon")
+			}
+		})
+	}
+}
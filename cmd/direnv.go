@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// Lines around the block 'direnv hook' writes to .envrc, which it replaces when run again.
+const (
+	direnvBlockStart = "# >>> aws-console >>>"
+	direnvBlockEnd   = "# <<< aws-console <<<"
+)
+
+func newDirenvCmd(deps runDeps) *cobra.Command {
+	direnvCmd := &cobra.Command{
+		Use:   "direnv",
+		Short: "Load temporary credentials in project directories with direnv",
+		Long: `Loads a profile's temporary credentials into the environment whenever you enter
+a project directory, with direnv (https://direnv.net). 'aws-console direnv hook'
+adds a block to the directory's .envrc that runs 'aws-console direnv export', which
+prints the credentials, refreshed once they near expiry, and has direnv watch their
+credential cache entry, so the environment is reloaded at the next prompt whenever
+they are refreshed, such as by 'aws-console daemon'.`,
+		Args: cobra.NoArgs,
+	}
+
+	direnvCmd.AddCommand(
+		newDirenvHookCmd(deps),
+		newDirenvExportCmd(deps),
+	)
+	return direnvCmd
+}
+
+func newDirenvHookCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "hook [dir]",
+		Short: "Add or refresh the aws-console block of a directory's .envrc",
+		Long: `Writes the aws-console block for the profile, from --profile or AWS_PROFILE, to
+the .envrc of dir, or of the current directory, creating the file if needed. A block
+written before is replaced, and the rest of the file is kept. Run 'direnv allow'
+afterwards, as direnv asks after every change.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile := cmd.Flags().Lookup("profile").Value.String()
+			if profile == "" {
+				profile = os.Getenv("AWS_PROFILE")
+			}
+			if profile == "" {
+				return i18n.Errorf("direnv hook needs a profile: pass --profile or set AWS_PROFILE")
+			}
+
+			dir := "."
+			if len(args) == 1 {
+				dir = args[0]
+			}
+			path, err := filepath.Abs(filepath.Join(dir, ".envrc"))
+			if err != nil {
+				return err
+			}
+			existing, err := os.ReadFile(path)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return i18n.Errorf("failed to read %s: %w", path, err)
+			}
+
+			updated, err := replaceDirenvBlock(existing, direnvBlock(profile))
+			if err != nil {
+				return i18n.Errorf("invalid %s: %w", path, err)
+			}
+			if bytes.Equal(updated, existing) {
+				fmt.Fprintf(deps.stderr, "%s already loads %s\n", path, profile)
+				return nil
+			}
+			if err := os.WriteFile(path, updated, 0o644); err != nil {
+				return i18n.Errorf("failed to write %s: %w", path, err)
+			}
+			fmt.Fprintf(deps.stderr, "Wrote the aws-console block for %s to %s; run 'direnv allow' to load it\n", profile, path)
+			return nil
+		},
+	}
+}
+
+// direnvBlock returns the .envrc block that loads the credentials of profile.
+func direnvBlock(profile string) string {
+	return direnvBlockStart + "\n" +
+		"# Written by 'aws-console direnv hook'; run it again instead of editing this block.\n" +
+		"eval \"$(aws-console direnv export --profile " + posixQuote(profile) + ")\"\n" +
+		direnvBlockEnd + "\n"
+}
+
+// replaceDirenvBlock returns envrc with its aws-console block replaced by block, or
+// block appended when it has none.
+func replaceDirenvBlock(envrc []byte, block string) ([]byte, error) {
+	text := string(envrc)
+	start := strings.Index(text, direnvBlockStart)
+	if start < 0 {
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		return []byte(text + block), nil
+	}
+	n := strings.Index(text[start:], direnvBlockEnd)
+	if n < 0 {
+		return nil, i18n.Errorf("the aws-console block has no %q line", direnvBlockEnd)
+	}
+	end := start + n + len(direnvBlockEnd)
+	if strings.HasPrefix(text[end:], "\n") {
+		end++
+	}
+	return []byte(text[:start] + block + text[end:]), nil
+}
+
+func newDirenvExportCmd(deps runDeps) *cobra.Command {
+	var noCache bool
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the profile's credentials for an .envrc",
+		Long: `Prints temporary credentials for the profile as export lines, resolved the same
+way as by 'aws-console creds', after a watch_file line for their credential cache
+entry. It is meant to be evaluated by .envrc, as the block of 'aws-console direnv
+hook' does.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveWorkflowGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			// Progress messages must not end up in the evaluated output.
+			deps.printOnly = true
+
+			opts := workflowOptions{profile: g.profile, noCache: noCache}
+			creds, err := sessionCredentials(ctx, opts, deps)
+			if err != nil {
+				return err
+			}
+			if deps.credentials != nil && !noCache {
+				if file := deps.credentials.CredentialsFile(g.profile, ""); file != "" {
+					fmt.Fprintf(deps.stdout, "watch_file %s\n", posixQuote(file))
+				}
+			}
+			return credentialFormats["env"](deps.stdout, g.profile, creds)
+		},
+	}
+
+	exportCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or update the credential cache")
+	return exportCmd
+}
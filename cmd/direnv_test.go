@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/credcache"
+)
+
+func TestReplaceDirenvBlock(t *testing.T) {
+	t.Parallel()
+
+	block := direnvBlock("dev")
+
+	testCases := []struct {
+		name          string
+		envrc         string
+		want          string
+		wantErrSubstr string
+	}{
+		{name: "new file", want: block},
+		{name: "appended", envrc: "dotenv\nPATH_add bin", want: "dotenv\nPATH_add bin\n" + block},
+		{
+			name:  "replaced",
+			envrc: "dotenv\n" + direnvBlock("prod") + "PATH_add bin\n",
+			want:  "dotenv\n" + block + "PATH_add bin\n",
+		},
+		{name: "unterminated", envrc: direnvBlockStart + "\neval x\n", wantErrSubstr: "the aws-console block has no"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := replaceDirenvBlock([]byte(tc.envrc), block)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDirenvHookCmd(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".envrc")
+	if err := os.WriteFile(path, []byte("dotenv\n"), 0o600); err != nil {
+		t.Fatalf("failed to write .envrc: %v", err)
+	}
+
+	stderr := &bytes.Buffer{}
+	deps := runDeps{stderr: stderr}
+	if _, err := executeSubcommand(t, deps, "direnv", "hook", "-p", "it's-dev", dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read .envrc: %v", err)
+	}
+	want := "dotenv\n" + direnvBlockStart + "\n" +
+		"# Written by 'aws-console direnv hook'; run it again instead of editing this block.\n" +
+		"eval \"$(aws-console direnv export --profile 'it'\\''s-dev')\"\n" +
+		direnvBlockEnd + "\n"
+	if string(data) != want {
+		t.Fatalf(".envrc =\n%s\nwant:\n%s", data, want)
+	}
+	if !strings.Contains(stderr.String(), "run 'direnv allow' to load it") {
+		t.Fatalf("unexpected stderr %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if _, err := executeSubcommand(t, deps, "direnv", "hook", "-p", "it's-dev", dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "already loads it's-dev") {
+		t.Fatalf("unexpected stderr %q", stderr.String())
+	}
+}
+
+func TestDirenvHookCmdWithoutProfile(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+
+	_, err := executeSubcommand(t, runDeps{}, "direnv", "hook", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "direnv hook needs a profile") {
+		t.Fatalf("expected an error without a profile, got %v", err)
+	}
+}
+
+func TestDirenvExportCmd(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	cache := credcache.NewCacheAt(t.TempDir())
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: expires}, nil
+			},
+		},
+		credentials:     cache,
+		sessionDuration: sessionDuration,
+	}
+
+	out, err := executeSubcommand(t, deps, "direnv", "export", "-p", "dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "watch_file " + posixQuote(cache.CredentialsFile("dev", "")) + "\n" +
+		"export AWS_ACCESS_KEY_ID='ASIA'\nexport AWS_SECRET_ACCESS_KEY='secret'\nexport AWS_SESSION_TOKEN='token'\n" +
+		"export AWS_CREDENTIAL_EXPIRATION='" + expires.Format(time.RFC3339) + "'\n"
+	if out != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+	if _, err := os.Stat(cache.CredentialsFile("dev", "")); err != nil {
+		t.Fatalf("expected the credentials to be cached: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocsCmd(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1767225600")
+
+	testCases := []struct {
+		format string
+		files  map[string]string
+	}{
+		{format: "man", files: map[string]string{
+			"aws-console.1":            `.TH "AWS-CONSOLE" "1" "Jan 2026"`,
+			"aws-console-org.1":        `\fB--role-name\fP`,
+			"aws-console-config-set.1": "aws-console-config-set",
+		}},
+		{format: "markdown", files: map[string]string{
+			"aws-console.md":            "* [aws-console org](aws-console_org.md)",
+			"aws-console_org.md":        "--role-name",
+			"aws-console_config_set.md": "## aws-console config set",
+		}},
+	}
+
+	for _, tc := range testCases {
+		dir := filepath.Join(t.TempDir(), "out")
+		root := newRootCmd(runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, now: time.Now}, nil)
+		root.SetArgs([]string{"docs", tc.format, dir})
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.format, err)
+		}
+
+		for name, want := range tc.files {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatalf("%s: %v", tc.format, err)
+			}
+			if !strings.Contains(string(data), want) {
+				t.Fatalf("%s: expected %s to contain %q, got:\n%s", tc.format, name, want, data)
+			}
+			if strings.Contains(string(data), "Auto generated") {
+				t.Fatalf("%s: expected %s to have no generation timestamp", tc.format, name)
+			}
+		}
+	}
+}
+
+func TestDocsDateRejectsInvalidEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+
+	if _, err := docsDate(runDeps{}); err == nil || !strings.Contains(err.Error(), "SOURCE_DATE_EPOCH") {
+		t.Fatalf("expected an invalid SOURCE_DATE_EPOCH error, got %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/doctor"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment aws-console depends on and suggest fixes",
+		Long: `Checks the AWS CLI, the shared AWS config and the aws-console settings, the
+cached SSO tokens, that the STS and federation endpoints can be reached, and that
+a browser can be opened, and suggests a fix for each problem found. Use -o json
+for machine-readable output. The command fails when a check fails; warnings only
+point at something worth a look.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Broken settings are reported like any other problem, and the remaining
+			// checks run with what could be resolved.
+			g, settingsErr := resolveGlobals(cmd, deps)
+
+			reports := doctor.Run(context.Background(), doctorChecks(g, settingsErr, deps))
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "CHECK", Key: "check"},
+					{Header: "STATUS", Key: "status"},
+					{Header: "DETAIL", Key: "detail"},
+					{Header: "FIX", Key: "fix"},
+				},
+			}
+			for _, r := range reports {
+				table.Rows = append(table.Rows, []string{r.Check, string(r.Status), r.Detail, r.Fix})
+			}
+			if err := output.Render(deps.stdout, g.output, table); err != nil {
+				return err
+			}
+
+			if n := doctor.Failed(reports); n > 0 {
+				return i18n.Errorf("%d of %d checks failed", n, len(reports))
+			}
+			return nil
+		},
+	}
+}
+
+// doctorChecks returns the checks 'aws-console doctor' runs, in display order.
+func doctorChecks(g globalOptions, settingsErr error, deps runDeps) []doctor.Check {
+	client := &http.Client{Timeout: cmp.Or(g.timeout, awslib.DefaultHTTPTimeout)}
+	federationClient := &http.Client{Timeout: client.Timeout}
+	if g.transport != nil {
+		federationClient.Transport = g.transport
+	}
+
+	checks := []doctor.Check{
+		{Name: "aws-cli", Run: func(ctx context.Context) doctor.Result { return checkAWSCLI(deps) }},
+		{Name: "shared-config", Run: func(ctx context.Context) doctor.Result { return checkSharedConfig(deps) }},
+		{Name: "settings", Run: func(ctx context.Context) doctor.Result { return checkSettings(settingsErr, deps) }},
+	}
+	checks = append(checks, ssoCacheChecks(deps)...)
+	checks = append(checks,
+		doctor.Check{Name: "sts-endpoint", Run: func(ctx context.Context) doctor.Result {
+			endpoint, err := doctorSTSEndpoint(g)
+			if err != nil {
+				return doctor.Fail(err.Error(), "set a region, or fix --sts-endpoint")
+			}
+			return checkReachable(ctx, client, endpoint, "check network access to AWS, HTTPS_PROXY, or point --sts-endpoint at a VPC interface endpoint")
+		}},
+		doctor.Check{Name: "federation-endpoint", Run: func(ctx context.Context) doctor.Result {
+			e, _ := awslib.PartitionEndpoints(cmp.Or(g.partition, awslib.PartitionAWS))
+			endpoint := cmp.Or(g.federationEndpoint, e.FederationURL)
+			return checkReachable(ctx, federationClient, endpoint, "check network access to AWS, or send federation requests through a proxy with --proxy")
+		}},
+		doctor.Check{Name: "browser", Run: func(ctx context.Context) doctor.Result { return checkBrowserOpener(g, deps) }},
+	)
+	return checks
+}
+
+// checkAWSCLI reports the installed AWS CLI. It is only needed to sign in to profiles
+// that are not SSO profiles, so a missing or old CLI is a warning.
+func checkAWSCLI(deps runDeps) doctor.Result {
+	var out bytes.Buffer
+	err := deps.executor.Run("aws", []string{"--version"}, nil, &out, &out)
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return doctor.Warn("aws is not installed", "install AWS CLI v2 if a profile needs 'aws sso login'; SSO profiles sign in without it")
+	case err != nil:
+		return doctor.Warn(fmt.Sprintf("aws --version failed: %v", err), "reinstall AWS CLI v2")
+	}
+
+	version, _, _ := strings.Cut(strings.TrimSpace(out.String()), " ")
+	if strings.HasPrefix(version, "aws-cli/1.") {
+		return doctor.Warn(version, "upgrade to AWS CLI v2, which supports sso-session sections")
+	}
+	return doctor.OK(version)
+}
+
+func checkSharedConfig(deps runDeps) doctor.Result {
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return doctor.Fail(err.Error(), "fix the shared AWS config at ~/.aws/config, or the file named by AWS_CONFIG_FILE")
+	}
+	if len(profiles) == 0 {
+		return doctor.Warn("no profiles configured", "add one with 'aws configure sso' or 'aws configure'")
+	}
+	return doctor.OK(fmt.Sprintf("%d profiles", len(profiles)))
+}
+
+func checkSettings(settingsErr error, deps runDeps) doctor.Result {
+	if settingsErr != nil {
+		return doctor.Fail(settingsErr.Error(), "fix the setting with 'aws-console config set', or in its flag, environment variable, or config file")
+	}
+	if deps.configFile == "" {
+		return doctor.OK("no config file")
+	}
+	return doctor.OK(deps.configFile)
+}
+
+// ssoCacheChecks returns a check of the cached token of each SSO session used by a
+// profile.
+func ssoCacheChecks(deps runDeps) []doctor.Check {
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return []doctor.Check{{Name: "sso-cache", Run: func(ctx context.Context) doctor.Result {
+			return doctor.Skip("the shared AWS config could not be read")
+		}}}
+	}
+
+	// Each session is checked once, with the first profile that uses it to sign in.
+	sessions := ssoSessionProfiles(profiles)
+	if len(sessions) == 0 {
+		return []doctor.Check{{Name: "sso-cache", Run: func(ctx context.Context) doctor.Result {
+			return doctor.Skip("no SSO profiles")
+		}}}
+	}
+
+	checks := make([]doctor.Check, 0, len(sessions))
+	for _, p := range sessions {
+		key := ssocache.Key(p.SSOSession, p.SSOStartURL)
+		name := "sso-cache"
+		if key != "" {
+			name += " " + key
+		}
+		checks = append(checks, doctor.Check{Name: name, Run: func(ctx context.Context) doctor.Result {
+			return checkSSOToken(key, p, deps)
+		}})
+	}
+	return checks
+}
+
+func checkSSOToken(key string, p awslib.Profile, deps runDeps) doctor.Result {
+	if key == "" {
+		return doctor.Fail(fmt.Sprintf("%s has neither sso_session nor sso_start_url", describeProfile(p.Name)), "add an sso_session to the profile with 'aws configure sso'")
+	}
+	if p.SSOSession != "" && deps.ssoSessions != nil {
+		if _, err := deps.ssoSessions.SSOSession(p.SSOSession); err != nil {
+			return doctor.Fail(err.Error(), fmt.Sprintf("add an [sso-session %s] section with 'aws configure sso-session'", p.SSOSession))
+		}
+	}
+	if deps.ssoTokens == nil {
+		return doctor.Skip("no SSO token cache")
+	}
+
+	signIn := fmt.Sprintf("sign in with 'aws-console -p %s'", p.Name)
+	token, err := deps.ssoTokens.Token(key)
+	switch {
+	case errors.Is(err, ssocache.ErrNoToken):
+		return doctor.Warn("not signed in", signIn)
+	case err != nil:
+		return doctor.Fail(err.Error(), fmt.Sprintf("remove %s and %s", deps.ssoTokens.Path(key), signIn))
+	}
+
+	now := deps.now()
+	switch {
+	case !token.Expired(now):
+		return doctor.OK(fmt.Sprintf("signed in until %s", formatTimestamp(token.ExpiresAt)))
+	case token.Refreshable(now):
+		return doctor.OK(fmt.Sprintf("expired at %s; refreshed on next use", formatTimestamp(token.ExpiresAt)))
+	}
+	return doctor.Warn(fmt.Sprintf("expired at %s", formatTimestamp(token.ExpiresAt)), signIn)
+}
+
+// doctorSTSEndpoint returns the STS endpoint of the resolved region, defaulting to
+// us-east-1.
+func doctorSTSEndpoint(g globalOptions) (string, error) {
+	region := cmp.Or(g.region, "us-east-1")
+	if g.stsEndpoint != "" {
+		return awslib.ResolveSTSEndpoint(g.stsEndpoint, region)
+	}
+	host := "sts." + region + ".amazonaws.com"
+	if strings.HasPrefix(region, "cn-") {
+		host += ".cn"
+	}
+	return "https://" + host + "/", nil
+}
+
+// checkReachable reports whether endpoint answers HTTPS requests. Any response counts,
+// since the request carries no credentials.
+func checkReachable(ctx context.Context, client *http.Client, endpoint, fix string) doctor.Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return doctor.Fail(fmt.Sprintf("invalid endpoint %s: %v", endpoint, err), fix)
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return doctor.Fail(fmt.Sprintf("%s is unreachable: %v", endpoint, err), fix)
+	}
+	resp.Body.Close()
+	return doctor.OK(fmt.Sprintf("%s answered in %s", endpoint, time.Since(start).Round(time.Millisecond)))
+}
+
+// checkBrowserOpener reports the executable that opens the console, without opening it.
+func checkBrowserOpener(g globalOptions, deps runDeps) doctor.Result {
+	platform := deps.goos
+	if deps.wsl {
+		platform = browser.WSL
+	}
+	fix := "set --browser to an installed browser or a command containing {url}, or print sign-in URLs with --print"
+	executables, err := browser.New(platform, deps.executor, os.Getenv, deps.stderr).Executables(browser.Options{
+		Browser:   g.browser,
+		Profile:   g.browserProfile,
+		Container: g.container,
+	})
+	if err != nil {
+		return doctor.Fail(err.Error(), fix)
+	}
+	for _, name := range executables {
+		if path, err := deps.lookPath(name); err == nil {
+			return doctor.OK(path)
+		}
+	}
+	return doctor.Fail(fmt.Sprintf("none of %s is installed", strings.Join(executables, ", ")), fix)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+)
+
+func TestDoctorCmd(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	ssoProfiles := []awslib.Profile{
+		{Name: "dev", Source: awslib.ProfileSourceSSO, SSOSession: "corp"},
+		{Name: "prod", Source: awslib.ProfileSourceSSO, SSOSession: "corp"},
+		{Name: "legacy", Source: awslib.ProfileSourceSSO, SSOStartURL: "https://legacy.awsapps.com/start"},
+		{Name: "keys", Source: awslib.ProfileSourceStatic},
+	}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		executor      *fakeExecutor
+		profiles      []awslib.Profile
+		listErr       error
+		installed     bool
+		want          map[string]string
+		wantErrSubstr string
+	}{
+		{
+			name:      "healthy",
+			args:      []string{"--sts-endpoint", server.URL, "--federation-endpoint", server.URL + "/federation"},
+			executor:  &fakeExecutor{runOutput: "aws-cli/2.15.0 Python/3.11.6 Linux/6.5.0 exe/x86_64\n"},
+			profiles:  ssoProfiles,
+			installed: true,
+			want: map[string]string{
+				"aws-cli":        "ok",
+				"shared-config":  "ok",
+				"settings":       "ok",
+				"sso-cache corp": "ok",
+				"sso-cache https://legacy.awsapps.com/start": "warn",
+				"sts-endpoint":        "ok",
+				"federation-endpoint": "ok",
+				"browser":             "ok",
+			},
+		},
+		{
+			name:     "broken",
+			args:     []string{"--sts-endpoint", closed.URL, "--federation-endpoint", closed.URL},
+			executor: &fakeExecutor{runErr: &exec.Error{Name: "aws", Err: exec.ErrNotFound}},
+			listErr:  errors.New("failed to parse config /home/me/.aws/config: line 3: expected key = value"),
+			want: map[string]string{
+				"aws-cli":             "warn",
+				"shared-config":       "fail",
+				"settings":            "ok",
+				"sso-cache":           "skip",
+				"sts-endpoint":        "fail",
+				"federation-endpoint": "fail",
+				"browser":             "fail",
+			},
+			wantErrSubstr: "4 of 7 checks failed",
+		},
+		{
+			name:     "old CLI and invalid settings",
+			args:     []string{"--partition", "aws-iso", "--sts-endpoint", server.URL, "--federation-endpoint", server.URL},
+			executor: &fakeExecutor{runOutput: "aws-cli/1.29.0 Python/3.8 Linux/5.4 botocore/1.31.0\n"},
+			want: map[string]string{
+				"aws-cli":  "warn",
+				"settings": "fail",
+			},
+			wantErrSubstr: "checks failed",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens := ssocache.NewCacheAt(t.TempDir())
+			if err := tokens.Put("corp", ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(time.Hour)}); err != nil {
+				t.Fatalf("failed to cache token: %v", err)
+			}
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) { return tc.profiles, tc.listErr },
+				},
+				ssoSessions: &mocks.SSOSessionReader{SSOSessionFunc: func(name string) (awslib.SSOSession, error) {
+					return awslib.SSOSession{Name: name, StartURL: "https://corp.awsapps.com/start", Region: "us-east-1"}, nil
+				}},
+				ssoTokens: tokens,
+				executor:  tc.executor,
+				lookPath: func(name string) (string, error) {
+					if !tc.installed {
+						return "", exec.ErrNotFound
+					}
+					return filepath.Join("/usr/bin", name), nil
+				},
+				goos: "linux",
+				now:  func() time.Time { return now },
+			}
+
+			out, err := executeSubcommand(t, deps, append([]string{"doctor", "-o", "json"}, tc.args...)...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var reports []map[string]string
+			if err := json.Unmarshal([]byte(out), &reports); err != nil {
+				t.Fatalf("failed to decode %q: %v", out, err)
+			}
+			got := make(map[string]string, len(reports))
+			for _, r := range reports {
+				got[r["check"]] = r["status"]
+				if (r["status"] == "warn" || r["status"] == "fail") && r["fix"] == "" {
+					t.Fatalf("expected a fix for %+v", r)
+				}
+			}
+			for check, status := range tc.want {
+				if got[check] != status {
+					t.Fatalf("expected %s to be %q, got %q in %s", check, status, got[check], out)
+				}
+			}
+		})
+	}
+}
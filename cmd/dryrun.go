@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eculver/aws-console/pkg/accounts"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/console"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// runDryRun reports what runWorkflow would do with opts: the credentials and identity
+// it would use, how they would be federated, for how long, and where the console would
+// open. It never logs in, requests temporary credentials or a sign-in token, or opens
+// anything. Problems that would stop the real run are returned as errors.
+func runDryRun(ctx context.Context, opts workflowOptions, deps runDeps) error {
+	profile := opts.profile
+	w := deps.stdout
+	fmt.Fprintf(w, "Profile: %s\n", strings.TrimPrefix(describeProfile(profile), "profile "))
+
+	if reason := ssoLoginReason(profile, deps); reason != "" {
+		return awslib.MarkError(i18n.Errorf("%s; an SSO login would be needed first", reason), awslib.ErrSSOLoginRequired)
+	}
+	if !opts.noCache && !limitedSession(opts, deps) && deps.credentials != nil {
+		if _, creds, ok := deps.credentials.Credentials(profile, opts.assumeRole.RoleARN); ok {
+			fmt.Fprintf(w, "Cached credentials: expire %s\n", formatTimestamp(creds.Expires))
+		}
+	}
+
+	creds, err := deps.awsService.RetrieveCredentials(ctx, profile)
+	if err != nil {
+		return i18n.Errorf("failed to retrieve credentials: %w", err)
+	}
+	source := ""
+	if creds.Source != "" {
+		source = " from " + creds.Source
+	}
+	fmt.Fprintf(w, "Credentials: %s%s\n", describeCredentials(creds), source)
+
+	identity, err := deps.awsService.GetCallerIdentity(ctx, profile)
+	if err != nil {
+		return i18n.Errorf("credentials for %s failed the identity check: %w", describeProfile(profile), err)
+	}
+	fmt.Fprintf(w, "Authenticated as: %s\n", identity.Arn)
+	if identity.Account != "" {
+		fmt.Fprintf(w, "Account: %s\n", accounts.Label(describeAccount(ctx, profile, identity.Account, deps)))
+	}
+
+	partition := awslib.PartitionFromContext(ctx)
+	if partition == "" {
+		partition = identity.Partition
+	}
+	if partition == "" {
+		partition = awslib.PartitionAWS
+	}
+	endpoints, ok := awslib.PartitionEndpoints(partition)
+	if !ok {
+		return i18n.Errorf("console federation is not available in partition %q", partition)
+	}
+	fmt.Fprintf(w, "Federation endpoint: %s\n", endpoints.FederationURL)
+
+	if opts.preflight != nil {
+		if err := opts.preflight(ctx, profile, identity, deps); err != nil {
+			return err
+		}
+	}
+
+	copts := consoleOptions(opts, deps)
+	plan, err := copts.Plan(ctx, creds, identity)
+	if err != nil {
+		return err
+	}
+	limit := ""
+	switch {
+	case copts.ReadOnly:
+		limit = fmt.Sprintf(" with the %s session policy", awslib.ReadOnlyAccessPolicy)
+	case plan.SessionPolicy != nil:
+		limit = " with a session policy"
+	}
+	switch plan.Strategy {
+	case console.StrategyAssumeRole:
+		fmt.Fprintf(w, "Federation: assume role %s%s, then federate its %s credentials\n", copts.AssumeRole.RoleARN, limit, plan.Kind)
+	case console.StrategyAssumeOwnRole:
+		fmt.Fprintf(w, "Federation: assume the role of the session again%s, then federate its %s credentials\n", limit, plan.Kind)
+	case console.StrategyFederationToken:
+		fmt.Fprintf(w, "Federation: request temporary credentials with sts:GetFederationToken%s\n", limit)
+	case console.StrategySessionToken:
+		action := "request temporary credentials with sts:GetSessionToken"
+		if serial := cmp.Or(copts.AssumeRole.MFASerial, copts.MFASerial); serial != "" {
+			action += ", with MFA device " + serial
+		} else {
+			// Credentials falls back to the first MFA device listed for the user.
+			action += ", with the IAM user's MFA device if it has one"
+		}
+		fmt.Fprintf(w, "Federation: %s\n", action)
+	default:
+		fmt.Fprintf(w, "Federation: use the %s credentials directly\n", plan.Kind)
+	}
+
+	duration, err := copts.SessionDurationFor(plan.Kind)
+	if err != nil {
+		return err
+	}
+	if plan.Strategy == console.StrategyDirect {
+		creds.Kind = plan.Kind
+		duration = console.LimitToCredentials(creds, duration, deps.now())
+	}
+	fmt.Fprintf(w, "Session duration: %s\n", duration)
+
+	if reason := privilegedPrincipal(plan.Identity, opts.destination); reason != "" {
+		fmt.Fprintf(w, "Guard rail: %s; opening the console needs --yes or confirmation\n", reason)
+	}
+
+	dest := opts.destination
+	if dest == "" {
+		dest = "console home"
+	}
+	fmt.Fprintf(w, "Destination: %s\n", dest)
+	if len(opts.regions) > 0 {
+		fmt.Fprintf(w, "Regions: %s\n", strings.Join(opts.regions, ", "))
+	} else if region := awslib.RegionFromContext(ctx); region != "" {
+		fmt.Fprintf(w, "Region: %s\n", region)
+	}
+	fmt.Fprintf(w, "Action: %s\n", dryRunAction(opts, deps))
+
+	fmt.Fprintln(w, "Dry run: no sign-in token was requested and nothing was opened.")
+	return nil
+}
+
+// dryRunAction describes what would be done with the sign-in URL.
+func dryRunAction(opts workflowOptions, deps runDeps) string {
+	var actions []string
+	if opts.copy {
+		actions = append(actions, "copy the sign-in URL to the clipboard")
+	}
+	if opts.qr {
+		actions = append(actions, "show the sign-in URL as a QR code")
+	}
+	switch {
+	case (opts.copy || opts.qr) && !opts.print:
+	case printOnly(deps) || opts.print:
+		actions = append(actions, "print the sign-in URL")
+	default:
+		action := "open the console in " + describeBrowser(opts.browser.withSettings(deps))
+		if opts.forceLogout {
+			action = "sign the browser out of the console, then " + action
+		}
+		if deps.isolateAccounts {
+			action += ", in the account's own browser profile"
+		}
+		actions = append(actions, action)
+	}
+	if opts.wait {
+		actions = append(actions, "wait for the session to expire")
+	}
+	return strings.Join(actions, ", then ")
+}
+
+// describeBrowser names the browser the console would open in.
+func describeBrowser(opts browserOptions) string {
+	name := "the default browser"
+	if opts.browser != "" {
+		name = opts.browser
+	}
+	if opts.profile != "" {
+		name += fmt.Sprintf(" (profile %q)", opts.profile)
+	}
+	if opts.private {
+		name += ", in a private window"
+	}
+	return name
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+)
+
+func TestRunWorkflowDryRun(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	roleCreds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(time.Hour), Source: "SSOProvider"}
+	roleIdentity := awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/alice", Account: "123456789012", Partition: "aws"}
+	userIdentity := awslib.Identity{Arn: "arn:aws:iam::123456789012:user/alice", Account: "123456789012", Partition: "aws"}
+
+	testCases := []struct {
+		name          string
+		opts          workflowOptions
+		creds         awslib.Credentials
+		identity      awslib.Identity
+		duration      int32
+		durationSet   bool
+		sessionPolicy *awslib.SessionPolicy
+		ssoToken      *ssocache.Token
+		profiles      []awslib.Profile
+		wantOut       []string
+		wantErrSubstr string
+	}{
+		{
+			name:     "role credentials",
+			opts:     workflowOptions{profile: "dev", destination: "/s3/home", print: true},
+			creds:    roleCreds,
+			identity: roleIdentity,
+			wantOut: []string{
+				"Profile: \"dev\"",
+				"Credentials: temporary credentials, expire 2024-01-02T04:04:05Z from SSOProvider",
+				"Authenticated as: " + roleIdentity.Arn,
+				"Federation endpoint: https://signin.aws.amazon.com/federation",
+				"Federation: use the role credentials directly",
+				// The role session expires in an hour.
+				"Session duration: 1h0m0s",
+				"Destination: /s3/home",
+				"Action: print the sign-in URL",
+			},
+		},
+		{
+			name:     "long-lived keys",
+			opts:     workflowOptions{profile: "dev", assumeRole: awslib.AssumeRoleInput{MFASerial: "arn:aws:iam::123456789012:mfa/alice"}},
+			creds:    awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			identity: userIdentity,
+			wantOut: []string{
+				"Credentials: long-lived keys",
+				"Federation: request temporary credentials with sts:GetSessionToken, with MFA device arn:aws:iam::123456789012:mfa/alice",
+				"Session duration: 12h0m0s",
+				"Destination: console home",
+				"Action: open the console in the default browser",
+			},
+		},
+		{
+			name:     "long-lived keys without an MFA device",
+			opts:     workflowOptions{profile: "dev"},
+			creds:    awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			identity: userIdentity,
+			wantOut: []string{
+				"Federation: request temporary credentials with sts:GetSessionToken, with the IAM user's MFA device if it has one",
+			},
+		},
+		{
+			name:     "assume role",
+			opts:     workflowOptions{profile: "dev", regions: []string{"us-east-1", "eu-west-1"}, copy: true, assumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+			creds:    roleCreds,
+			identity: roleIdentity,
+			wantOut: []string{
+				"Federation: assume role " + testRoleARN + ", then federate its role-chained credentials",
+				"Session duration: 1h0m0s",
+				"Regions: us-east-1, eu-west-1",
+				"Action: copy the sign-in URL to the clipboard",
+			},
+		},
+		{
+			name:     "read-only role session",
+			opts:     workflowOptions{profile: "dev", readOnly: true},
+			creds:    roleCreds,
+			identity: roleIdentity,
+			wantOut: []string{
+				"Federation: assume the role of the session again with the ReadOnlyAccess session policy, then federate its role-chained credentials",
+				"Session duration: 1h0m0s",
+			},
+		},
+		{
+			name:     "read-only long-lived keys",
+			opts:     workflowOptions{profile: "dev", readOnly: true},
+			creds:    awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			identity: userIdentity,
+			wantOut: []string{
+				"Federation: request temporary credentials with sts:GetFederationToken with the ReadOnlyAccess session policy",
+				"Session duration: 12h0m0s",
+			},
+		},
+		{
+			name:          "session policy",
+			opts:          workflowOptions{profile: "dev"},
+			creds:         awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+			identity:      userIdentity,
+			sessionPolicy: &awslib.SessionPolicy{PolicyARNs: []string{"arn:aws:iam::123456789012:policy/Deploy"}},
+			wantOut: []string{
+				"Federation: request temporary credentials with sts:GetFederationToken with a session policy",
+			},
+		},
+		{
+			name:          "read-only temporary IAM user credentials",
+			opts:          workflowOptions{profile: "dev", readOnly: true},
+			creds:         awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"},
+			identity:      userIdentity,
+			wantErrSubstr: "cannot limit the temporary credentials of " + userIdentity.Arn,
+		},
+		{
+			name:          "explicit duration too long",
+			opts:          workflowOptions{profile: "dev", assumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+			creds:         roleCreds,
+			identity:      roleIdentity,
+			durationSet:   true,
+			wantErrSubstr: "role chaining",
+		},
+		{
+			name:     "role profile sourced from another role",
+			opts:     workflowOptions{profile: "deploy"},
+			creds:    awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(2 * time.Hour), Source: "AssumeRoleProvider"},
+			identity: roleIdentity,
+			profiles: []awslib.Profile{
+				{Name: "admin", Source: awslib.ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/Admin", SourceProfile: "keys"},
+				{Name: "deploy", Source: awslib.ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/Deploy", SourceProfile: "admin"},
+			},
+			wantOut: []string{
+				"Federation: use the role-chained credentials directly",
+				"Session duration: 1h0m0s",
+			},
+		},
+		{
+			name:          "expired SSO token",
+			opts:          workflowOptions{profile: "dev"},
+			ssoToken:      &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(-time.Hour)},
+			wantErrSubstr: "an SSO login would be needed first",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return tc.identity, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return tc.creds, nil
+				},
+			}
+			federation := &mocks.FederationBuilder{}
+			loggedIn, opened := false, false
+			deps := runDeps{
+				awsService: service,
+				federation: federation,
+				open: func(targetURL string, opts browserOptions) error {
+					opened = true
+					return nil
+				},
+				login: func(ctx context.Context, profile string) error {
+					loggedIn = true
+					return nil
+				},
+				now:             func() time.Time { return now },
+				term:            interactiveTerminal,
+				stdout:          &bytes.Buffer{},
+				stderr:          &bytes.Buffer{},
+				sessionDuration: sessionDuration,
+				durationSet:     tc.durationSet,
+				sessionPolicy:   tc.sessionPolicy,
+			}
+			if tc.profiles != nil {
+				deps.profiles = &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) { return tc.profiles, nil },
+				}
+			}
+			if tc.ssoToken != nil {
+				tokens := ssocache.NewCacheAt(t.TempDir())
+				if err := tokens.Put("my-sso", *tc.ssoToken); err != nil {
+					t.Fatalf("failed to cache token: %v", err)
+				}
+				deps.ssoTokens = tokens
+				deps.profiles = &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return []awslib.Profile{{Name: "dev", Source: awslib.ProfileSourceSSO, SSOSession: "my-sso"}}, nil
+					},
+				}
+			}
+
+			tc.opts.dryRun = true
+			err := runWorkflow(context.Background(), tc.opts, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			out := deps.stdout.(*bytes.Buffer).String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+			if loggedIn || opened {
+				t.Fatalf("expected no login or browser, got login %v and open %v", loggedIn, opened)
+			}
+			if federation.BuildConsoleURLCalls.Load() != 0 || federation.BuildConsoleURLsCalls.Load() != 0 {
+				t.Fatal("expected no sign-in token to be requested")
+			}
+			if service.GetSessionTokenCalls.Load() != 0 || service.AssumeRoleCalls.Load() != 0 {
+				t.Fatal("expected no temporary credentials to be requested")
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"cmp"
+	"os"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// envCredentials reports whether the console opens with credentials exported in
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (or their older AWS_ACCESS_KEY and
+// AWS_SECRET_KEY names) rather than a profile's. The AWS SDK prefers them over the
+// default profile, but not over one that is named.
+func envCredentials(profile string) bool {
+	return profile == "" &&
+		cmp.Or(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_ACCESS_KEY")) != "" &&
+		cmp.Or(os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SECRET_KEY")) != ""
+}
+
+// envCredentialsError explains that the exported credentials were rejected. An SSO
+// login cannot renew them, so none is attempted.
+func envCredentialsError(err error) error {
+	err = i18n.Errorf("the credentials in AWS_ACCESS_KEY_ID were rejected; export valid ones, or unset them to use a profile: %w", err)
+	if awslib.ClassifyError(err) == awslib.ErrorKindExpired {
+		err = awslib.MarkError(err, awslib.ErrCredentialsExpired)
+	}
+	return err
+}
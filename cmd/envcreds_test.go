@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/credcache"
+)
+
+func setEnvCredentials(t *testing.T, keyID, secret string) {
+	t.Helper()
+	for _, name := range []string{"AWS_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("AWS_ACCESS_KEY_ID", keyID)
+	t.Setenv("AWS_SECRET_ACCESS_KEY", secret)
+}
+
+func TestEnvCredentials(t *testing.T) {
+	testCases := []struct {
+		name    string
+		profile string
+		keyID   string
+		secret  string
+		want    bool
+	}{
+		{name: "exported keys", keyID: "AKIAEXAMPLE", secret: "secret", want: true},
+		{name: "named profile", profile: "dev", keyID: "AKIAEXAMPLE", secret: "secret"},
+		{name: "no secret", keyID: "AKIAEXAMPLE"},
+		{name: "nothing exported"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setEnvCredentials(t, tc.keyID, tc.secret)
+			if got := envCredentials(tc.profile); got != tc.want {
+				t.Fatalf("envCredentials(%q) = %v, want %v", tc.profile, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunWorkflowEnvCredentials(t *testing.T) {
+	setEnvCredentials(t, "AKIAEXAMPLE", "secret")
+
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/ci", Account: "123456789012"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret", Source: "EnvConfigCredentials"}, nil
+		},
+		GetSessionTokenFunc: func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	cache := credcache.NewCacheAt(t.TempDir())
+	for range 2 {
+		stdout := &bytes.Buffer{}
+		deps := runDeps{
+			awsService: service,
+			federation: &mocks.FederationBuilder{
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+					if creds.SessionToken != "token" {
+						t.Fatalf("expected a session token to be federated, got %+v", creds)
+					}
+					return "https://example.com/console-login", nil
+				},
+			},
+			credentials:     cache,
+			login:           func(ctx context.Context, profile string) error { return errors.New("unexpected SSO login") },
+			open:            func(targetURL string, opts browserOptions) error { return nil },
+			term:            interactiveTerminal,
+			stdout:          stdout,
+			stderr:          &bytes.Buffer{},
+			now:             time.Now,
+			sessionDuration: sessionDuration,
+		}
+		if err := runWorkflow(context.Background(), workflowOptions{}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(stdout.String(), "Authenticated as: arn:aws:iam::123456789012:user/ci") {
+			t.Fatalf("unexpected output:\n%s", stdout)
+		}
+	}
+	// Each run exchanges the exported keys again rather than reusing cached credentials.
+	if service.GetSessionTokenCalls.Load() != 2 {
+		t.Fatalf("expected a session token per run, got %d", service.GetSessionTokenCalls.Load())
+	}
+}
+
+func TestAuthenticateEnvCredentialsSkipsSSOLogin(t *testing.T) {
+	setEnvCredentials(t, "ASIAEXAMPLE", "secret")
+
+	loggedIn := false
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{}, &smithy.GenericAPIError{Code: "ExpiredToken", Message: "The security token included in the request is expired"}
+			},
+		},
+		login: func(ctx context.Context, profile string) error {
+			loggedIn = true
+			return nil
+		},
+		stderr: &bytes.Buffer{},
+	}
+	_, err := authenticate(context.Background(), "", deps)
+	if err == nil || !strings.Contains(err.Error(), "the credentials in AWS_ACCESS_KEY_ID were rejected") {
+		t.Fatalf("expected the exported credentials to be rejected, got %v", err)
+	}
+	if !errors.Is(err, awslib.ErrCredentialsExpired) {
+		t.Fatalf("expected expired credentials, got %v", err)
+	}
+	if loggedIn {
+		t.Fatal("expected no SSO login for credentials from the environment")
+	}
+}
+
+func TestRootCmdEnvCredentialsSkipsPicker(t *testing.T) {
+	setEnvCredentials(t, "AKIAEXAMPLE", "secret")
+	t.Setenv("AWS_PROFILE", "")
+
+	picker := &fakePicker{}
+	var captured *workflowOptions
+	deps := runDeps{
+		profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) {
+			return testProfiles(), nil
+		}},
+		picker: picker,
+		term:   interactiveTerminal,
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
+	}
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		captured = &opts
+		return nil
+	})
+	root.SetArgs(nil)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if picker.items != nil {
+		t.Fatal("expected no profile picker with credentials in the environment")
+	}
+	if captured == nil || captured.profile != "" {
+		t.Fatalf("unexpected workflow options: %+v", captured)
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// ExitError carries the exit code of a command run by exec, for the process to exit with.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// profileVariables select credentials in the SDKs; they are removed from the environment
+// of a command run by exec so that the injected credentials are the ones it uses.
+var profileVariables = []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE"}
+
+func newExecCmd(deps runDeps) *cobra.Command {
+	var noCache bool
+	var assumeRole awslib.AssumeRoleInput
+
+	execCmd := &cobra.Command{
+		Use:   "exec [profile] -- <command> [args...]",
+		Short: "Run a command with the profile's temporary credentials in its environment",
+		Long: `Runs a command with temporary credentials for the profile in its environment,
+resolved the same way as when opening the console: from the credential cache, or
+after an SSO login when the profile's credentials are not valid.
+
+  aws-console exec dev -- terraform plan
+
+The command gets AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, and
+AWS_CREDENTIAL_EXPIRATION, and AWS_REGION when a region is set; AWS_PROFILE is
+removed. aws-console exits with the command's exit code.`,
+		ValidArgsFunction: completeProfileArg(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 || dash == len(args) {
+				return i18n.Errorf("the command to run must follow --, as in: aws-console exec dev -- aws s3 ls")
+			}
+			if dash > 1 {
+				return i18n.Errorf("expected at most one profile before --, got %q", args[:dash])
+			}
+			if dash == 1 {
+				if err := setPositionalProfile(cmd, args[0], deps); err != nil {
+					return err
+				}
+			}
+			if err := validateAssumeRole(assumeRole); err != nil {
+				return err
+			}
+
+			g, err := resolveWorkflowGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			// The command owns stdout.
+			deps.printOnly = true
+
+			opts := workflowOptions{profile: g.profile, noCache: noCache, assumeRole: assumeRole}
+			creds, err := sessionCredentials(ctx, opts, deps)
+			if err != nil {
+				return err
+			}
+
+			env := execEnvironment(os.Environ(), creds, awslib.RegionFromContext(ctx))
+			code, err := deps.executor.Exec(args[dash], args[dash+1:], env, deps.stdin, deps.stdout, deps.stderr)
+			if err != nil {
+				return i18n.Errorf("failed to run %s: %w", args[dash], err)
+			}
+			if code != 0 {
+				// The command has reported its own failure.
+				cmd.SilenceErrors = true
+				return &ExitError{Code: code}
+			}
+			return nil
+		},
+	}
+
+	execCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or update the credential cache")
+	addAssumeRoleFlags(execCmd, &assumeRole)
+	return execCmd
+}
+
+// execEnvironment returns environ with the credential and profile variables replaced
+// by creds, and the region, when set.
+func execEnvironment(environ []string, creds awslib.Credentials, region string) []string {
+	vars := credentialVariables(creds)
+	if region != "" {
+		vars = append(vars, [2]string{"AWS_REGION", region}, [2]string{"AWS_DEFAULT_REGION", region})
+	}
+
+	replaced := append([]string{"AWS_SESSION_TOKEN", "AWS_SECURITY_TOKEN", "AWS_CREDENTIAL_EXPIRATION"}, profileVariables...)
+	for _, v := range vars {
+		replaced = append(replaced, v[0])
+	}
+	env := make([]string, 0, len(environ)+len(vars))
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if !slices.Contains(replaced, name) {
+			env = append(env, kv)
+		}
+	}
+	for _, v := range vars {
+		env = append(env, v[0]+"="+v[1])
+	}
+	return env
+}
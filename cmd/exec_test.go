@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestExecCmd(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		args          []string
+		execCode      int
+		wantName      string
+		wantArgs      []string
+		wantEnv       []string
+		wantExitCode  int
+		wantErrSubstr string
+	}{
+		{
+			name:     "runs the command with credentials",
+			args:     []string{"exec", "dev", "--region", "eu-west-1", "--", "terraform", "plan", "-out", "plan.tfplan"},
+			wantName: "terraform",
+			wantArgs: []string{"plan", "-out", "plan.tfplan"},
+			wantEnv: []string{
+				"AWS_ACCESS_KEY_ID=ASIA",
+				"AWS_SECRET_ACCESS_KEY=secret",
+				"AWS_SESSION_TOKEN=token",
+				"AWS_CREDENTIAL_EXPIRATION=2030-01-02T03:04:05Z",
+				"AWS_REGION=eu-west-1",
+			},
+		},
+		{
+			name:         "passes on the exit code",
+			args:         []string{"exec", "-p", "dev", "--", "false"},
+			execCode:     3,
+			wantName:     "false",
+			wantExitCode: 3,
+		},
+		{
+			name:          "without a command",
+			args:          []string{"exec", "dev"},
+			wantErrSubstr: "the command to run must follow --",
+		},
+		{
+			name:          "several profiles",
+			args:          []string{"exec", "dev", "prod", "--", "aws", "s3", "ls"},
+			wantErrSubstr: `expected at most one profile before --, got ["dev" "prod"]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &fakeExecutor{execCode: tc.execCode}
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: expires}, nil
+					},
+				},
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) { return testProfiles(), nil },
+				},
+				executor:        executor,
+				sessionDuration: sessionDuration,
+			}
+
+			_, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			var exitErr *ExitError
+			if tc.wantExitCode != 0 {
+				if !errors.As(err, &exitErr) || exitErr.Code != tc.wantExitCode {
+					t.Fatalf("expected exit code %d, got %v", tc.wantExitCode, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(executor.calls) != 1 || executor.calls[0].method != "exec" {
+				t.Fatalf("expected one command to run, got %+v", executor.calls)
+			}
+			call := executor.calls[0]
+			if call.name != tc.wantName || strings.Join(call.args, "|") != strings.Join(tc.wantArgs, "|") {
+				t.Fatalf("ran %s %q, want %s %q", call.name, call.args, tc.wantName, tc.wantArgs)
+			}
+			for _, want := range tc.wantEnv {
+				if !slices.Contains(call.env, want) {
+					t.Fatalf("expected %s in the environment, got %q", want, call.env)
+				}
+			}
+		})
+	}
+}
+
+func TestExecEnvironment(t *testing.T) {
+	t.Parallel()
+
+	environ := []string{
+		"HOME=/home/alice",
+		"AWS_PROFILE=dev",
+		"AWS_ACCESS_KEY_ID=AKIAOLD",
+		"AWS_SESSION_TOKEN=old",
+		"AWS_DEFAULT_REGION=us-east-1",
+	}
+	creds := awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}
+
+	got := execEnvironment(environ, creds, "")
+	want := []string{
+		"HOME=/home/alice",
+		"AWS_DEFAULT_REGION=us-east-1",
+		"AWS_ACCESS_KEY_ID=AKIA",
+		"AWS_SECRET_ACCESS_KEY=secret",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected environment:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestOSExecutorExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+	code, err := osExecutor{}.Exec("sh", []string{"-c", `echo "$GREETING"; exit 4`}, []string{"GREETING=hello"}, nil, stdout, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 4 || stdout.String() != "hello\n" {
+		t.Fatalf("got exit code %d and output %q", code, stdout.String())
+	}
+}
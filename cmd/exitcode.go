@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"errors"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of aws-console, so scripts can tell failures apart without reading stderr.
+// Usage errors exit with 2, as shells use it. exec exits with the code of its command.
+const (
+	ExitFailure             = 1
+	ExitUsage               = 2
+	ExitSSOLoginRequired    = 3
+	ExitCredentialsExpired  = 4
+	ExitThrottled           = 5
+	ExitUnsupportedPlatform = 6
+)
+
+// ExitCode returns the code the process exits with after err.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	var usage *usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, awslib.ErrSSOLoginRequired):
+		return ExitSSOLoginRequired
+	case awslib.ClassifyError(err) == awslib.ErrorKindExpired:
+		return ExitCredentialsExpired
+	case errors.Is(err, awslib.ErrThrottled), errors.Is(err, awslib.ErrFederationThrottled):
+		return ExitThrottled
+	case errors.Is(err, awslib.ErrUnsupportedPlatform):
+		return ExitUnsupportedPlatform
+	default:
+		return ExitFailure
+	}
+}
+
+// usageError is a command line that cobra rejected: an unknown flag, a flag value that
+// does not parse, or the wrong arguments.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *usageError) Unwrap() error {
+	return e.err
+}
+
+// markUsageErrors makes the flag and argument errors of cmd and its subcommands
+// usageErrors, for ExitCode.
+func markUsageErrors(cmd *cobra.Command) {
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+	for _, c := range append([]*cobra.Command{cmd}, allSubcommands(cmd)...) {
+		if args := c.Args; args != nil {
+			c.Args = func(cmd *cobra.Command, a []string) error {
+				if err := args(cmd, a); err != nil {
+					return &usageError{err: err}
+				}
+				return nil
+			}
+		}
+	}
+}
+
+// allSubcommands returns the subcommands of cmd, theirs, and so on.
+func allSubcommands(cmd *cobra.Command) []*cobra.Command {
+	var all []*cobra.Command
+	for _, c := range cmd.Commands() {
+		all = append(append(all, c), allSubcommands(c)...)
+	}
+	return all
+}
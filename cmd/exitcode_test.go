@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", want: 0},
+		{name: "other failure", err: errors.New("boom"), want: ExitFailure},
+		{name: "exec", err: fmt.Errorf("wrapped: %w", &ExitError{Code: 42}), want: 42},
+		{name: "usage", err: &usageError{err: errors.New("unknown flag: --bogus")}, want: ExitUsage},
+		{name: "sso login", err: awslib.MarkError(errors.New("SSO login failed: denied"), awslib.ErrSSOLoginRequired), want: ExitSSOLoginRequired},
+		{name: "marked expired", err: awslib.MarkError(errors.New("credentials still invalid"), awslib.ErrCredentialsExpired), want: ExitCredentialsExpired},
+		{name: "expired API error", err: fmt.Errorf("failed to get a federation token: %w", &smithy.GenericAPIError{Code: "ExpiredToken"}), want: ExitCredentialsExpired},
+		{name: "throttled", err: fmt.Errorf("failed to open the console: %w", awslib.MarkError(errors.New("federation endpoint returned HTTP 429"), awslib.ErrFederationThrottled)), want: ExitThrottled},
+		{name: "throttled sts", err: fmt.Errorf("failed to check credentials: %w", &awslib.ThrottledError{Service: "AWS STS", Err: errors.New("Throttling")}), want: ExitThrottled},
+		{name: "unsupported platform", err: awslib.MarkError(errors.New("unsupported platform: plan9"), awslib.ErrUnsupportedPlatform), want: ExitUnsupportedPlatform},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ExitCode(tc.err); got != tc.want {
+				t.Fatalf("ExitCode(%v) = %d, want %d", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExitCodeUsageErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "unknown flag", args: []string{"--bogus"}, want: ExitUsage},
+		{name: "unknown subcommand flag", args: []string{"list", "--bogus"}, want: ExitUsage},
+		{name: "invalid flag value", args: []string{"list", "--valid-only=maybe"}, want: ExitUsage},
+		{name: "extra argument", args: []string{"version", "extra"}, want: ExitUsage},
+		{name: "failure", args: []string{"list", "-o", "yaml"}, want: ExitFailure},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{profiles: &mocks.ProfileLister{
+				ListProfilesFunc: func() ([]awslib.Profile, error) { return nil, nil },
+			}}
+			_, err := executeSubcommand(t, deps, tc.args...)
+			if got := ExitCode(err); got != tc.want {
+				t.Fatalf("ExitCode(%v) = %d, want %d", err, got, tc.want)
+			}
+		})
+	}
+}
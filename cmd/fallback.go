@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/smithy-go"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// fallbackProfiles returns the profiles to sign in with, in order, when IAM Identity
+// Center fails for profile: those of its aws_console_fallback key that are configured.
+func fallbackProfiles(profile string, deps runDeps) []string {
+	if profile == "" || deps.profiles == nil {
+		return nil
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return nil
+	}
+	byName := profileByName(profiles)
+	var fallbacks []string
+	for _, name := range byName[profile].FallbackProfiles() {
+		switch _, ok := byName[name]; {
+		case name == profile:
+		case !ok:
+			deps.messages.Fprintf(deps.stderr, "Warning: the fallback profile %s of %s is not in the AWS config\n", name, profile)
+		default:
+			fallbacks = append(fallbacks, name)
+		}
+	}
+	return fallbacks
+}
+
+// identityCenterServices are the service IDs of the IAM Identity Center clients: the
+// OIDC endpoint of SSO logins, and the portal that issues the credentials of SSO
+// profiles.
+var identityCenterServices = map[string]bool{"SSO OIDC": true, "SSO": true}
+
+// identityCenterFailed reports whether err, from authenticating profile, means IAM
+// Identity Center is down: a call to its OIDC or portal endpoints could not reach it,
+// or failed on its side with a 5xx status. Sign-ins that were denied or abandoned, and
+// credentials that are rejected or not allowed, are left alone, as another profile
+// would hide a problem with the profile itself.
+func identityCenterFailed(profile string, err error, deps runDeps) bool {
+	if _, ok := ssoProfile(profile, deps); !ok {
+		return false
+	}
+	var opErr *smithy.OperationError
+	if !errors.As(err, &opErr) || !identityCenterServices[opErr.Service()] {
+		return false
+	}
+	var respErr interface{ HTTPStatusCode() int }
+	return awslib.ClassifyError(err) == awslib.ErrorKindNetwork || errors.As(err, &respErr) && respErr.HTTPStatusCode() >= 500
+}
+
+// authenticateWithFallback authenticates profile and, when IAM Identity Center fails
+// for it, each of its fallback profiles in turn, once the user confirms. It returns
+// the profile that signed in with its caller identity.
+func authenticateWithFallback(ctx context.Context, profile string, opts workflowOptions, deps runDeps) (string, awslib.Identity, error) {
+	identity, err := authenticate(ctx, profile, deps)
+	if err == nil || opts.noFallback || ctx.Err() != nil || !identityCenterFailed(profile, err, deps) {
+		return profile, identity, err
+	}
+
+	primaryErr := err
+	for _, fallback := range fallbackProfiles(profile, deps) {
+		if err := confirmFallback(profile, fallback, primaryErr, opts, deps); err != nil {
+			return profile, awslib.Identity{}, err
+		}
+		identity, err := authenticate(ctx, fallback, deps)
+		if err == nil {
+			return fallback, identity, nil
+		}
+		deps.messages.Fprintf(deps.stderr, "Fallback profile %s failed too: %v\n", fallback, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return profile, awslib.Identity{}, primaryErr
+}
+
+// confirmFallback explains why IAM Identity Center is being bypassed for profile and,
+// unless --fallback was passed, asks the user to confirm signing in with fallback
+// instead. --yes does not confirm it: scripts pass that for other prompts.
+func confirmFallback(profile, fallback string, cause error, opts workflowOptions, deps runDeps) error {
+	const warning = "Warning: IAM Identity Center failed for %s (%v); the fallback profile %s can sign in instead.\n"
+	if opts.fallback {
+		deps.messages.Fprintf(deps.stderr, warning, profile, cause, fallback)
+		return nil
+	}
+	if !deps.term.Interactive() {
+		deps.messages.Fprintf(deps.stderr, warning, profile, cause, fallback)
+		return i18n.Errorf("not signing in with the fallback profile %s without confirmation; pass --fallback to use it: %w", fallback, cause)
+	}
+
+	// The warning is what the question is about, so it is shown even with --quiet.
+	deps.messages.Promptf(deps.stderr, warning, profile, cause, fallback)
+	deps.messages.Promptf(deps.stderr, "Sign in with %s? [y/N] ", fallback)
+	line, _ := bufio.NewReader(deps.stdin).ReadString('\n')
+	if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+		return i18n.Errorf("not signing in with the fallback profile %s: %w", fallback, cause)
+	}
+	return nil
+}
+
+// useFallback points opts at profile when it signed in as a fallback for opts.profile,
+// and says so.
+func useFallback(opts *workflowOptions, profile string, deps runDeps) {
+	if profile == opts.profile {
+		return
+	}
+	opts.profile, opts.fallbackFor = profile, opts.profile
+	// Shown even with --quiet: a break-glass session must not pass for a usual one.
+	deps.messages.Promptf(deps.stderr, "Signed in with the fallback profile %s instead of %s; this console session bypasses IAM Identity Center.\n", profile, opts.fallbackFor)
+}
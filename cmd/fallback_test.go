@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/sessions"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+func TestRunWorkflowFallback(t *testing.T) {
+	t.Parallel()
+
+	profiles := []awslib.Profile{
+		{Name: "dev", Source: awslib.ProfileSourceSSO, SSOSession: "corp", Fallback: "missing, breakglass"},
+		{Name: "keys", Source: awslib.ProfileSourceStatic, Fallback: "breakglass"},
+		{Name: "breakglass", Source: awslib.ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/BreakGlass"},
+	}
+
+	testCases := []struct {
+		name          string
+		profile       string
+		opts          workflowOptions
+		term          term.Info
+		stdin         string
+		breakglassErr error
+		// loginErr is how the SSO login fails; by default, IAM Identity Center is down.
+		loginErr      error
+		wantProfile   string
+		wantErrSubstr string
+		wantStderr    string
+	}{
+		{name: "confirmed", profile: "dev", term: interactiveTerminal, stdin: "y\n", wantProfile: "breakglass", wantStderr: "Signed in with the fallback profile breakglass instead of dev"},
+		{name: "missing fallback", profile: "dev", opts: workflowOptions{fallback: true}, wantProfile: "breakglass", wantStderr: "the fallback profile missing of dev is not in the AWS config"},
+		{name: "fallback flag", profile: "dev", opts: workflowOptions{fallback: true}, wantProfile: "breakglass", wantStderr: "IAM Identity Center failed for dev"},
+		{name: "unreachable", profile: "dev", opts: workflowOptions{fallback: true}, loginErr: identityCenterError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), wantProfile: "breakglass"},
+		{name: "declined", profile: "dev", term: interactiveTerminal, stdin: "n\n", wantErrSubstr: "not signing in with the fallback profile breakglass"},
+		{name: "not confirmed", profile: "dev", wantErrSubstr: "pass --fallback to use it"},
+		{name: "yes does not confirm", profile: "dev", opts: workflowOptions{yes: true}, wantErrSubstr: "pass --fallback to use it"},
+		{name: "no fallback", profile: "dev", opts: workflowOptions{fallback: true, noFallback: true}, wantErrSubstr: "SSO login failed"},
+		{name: "not SSO", profile: "keys", opts: workflowOptions{fallback: true}, wantErrSubstr: "SSO login failed"},
+		{
+			name:          "sign-in denied",
+			profile:       "dev",
+			opts:          workflowOptions{fallback: true},
+			loginErr:      &smithy.OperationError{ServiceID: "SSO OIDC", OperationName: "CreateToken", Err: &smithy.GenericAPIError{Code: "AccessDeniedException"}},
+			wantErrSubstr: "SSO login failed",
+		},
+		{
+			name:          "not Identity Center",
+			profile:       "dev",
+			opts:          workflowOptions{fallback: true},
+			loginErr:      &smithy.OperationError{ServiceID: "STS", OperationName: "GetCallerIdentity", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+			wantErrSubstr: "SSO login failed",
+		},
+		{
+			name:          "fallbacks fail",
+			profile:       "dev",
+			opts:          workflowOptions{fallback: true},
+			breakglassErr: &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized to perform sts:AssumeRole"},
+			wantErrSubstr: "SSO login failed",
+			wantStderr:    "Fallback profile breakglass failed too",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stderr := &bytes.Buffer{}
+			store := sessions.NewStoreAt(filepath.Join(t.TempDir(), "sessions.json"))
+			opened := false
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						switch {
+						case profile == "breakglass" && tc.breakglassErr == nil:
+							return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/BreakGlass/alice", Account: "123456789012"}, nil
+						case profile == "breakglass":
+							return awslib.Identity{}, tc.breakglassErr
+						}
+						return awslib.Identity{}, errors.New("the SSO session has expired")
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) { return profiles, nil }},
+				login: func(ctx context.Context, profile string) error {
+					if profile != "dev" && profile != "keys" {
+						t.Fatalf("unexpected login for %s", profile)
+					}
+					if tc.loginErr != nil {
+						return tc.loginErr
+					}
+					return identityCenterError(&smithyhttp.ResponseError{
+						Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+						Err:      errors.New("the IAM Identity Center portal is unavailable"),
+					})
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = true
+					return nil
+				},
+				sessions:        store,
+				term:            tc.term,
+				stdin:           strings.NewReader(tc.stdin),
+				stdout:          &bytes.Buffer{},
+				stderr:          stderr,
+				now:             time.Now,
+				sessionDuration: sessionDuration,
+			}
+
+			opts := tc.opts
+			opts.profile = tc.profile
+			err := runWorkflow(context.Background(), opts, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if opened {
+					t.Fatal("expected the console not to open")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stderr.String(), tc.wantStderr) {
+				t.Fatalf("expected stderr to contain %q, got %q", tc.wantStderr, stderr.String())
+			}
+			if tc.wantProfile == "" {
+				return
+			}
+
+			active, err := store.Active(time.Now())
+			if err != nil {
+				t.Fatalf("failed to read sessions: %v", err)
+			}
+			if len(active) != 1 || active[0].Profile != tc.wantProfile || active[0].FallbackFor != tc.profile {
+				t.Fatalf("expected a session of %s labeled as a fallback for %s, got %+v", tc.wantProfile, tc.profile, active)
+			}
+		})
+	}
+}
+
+// identityCenterError is err as returned by the OIDC client of an SSO login.
+func identityCenterError(err error) error {
+	return fmt.Errorf("failed to start device authorization: %w", &smithy.OperationError{ServiceID: "SSO OIDC", OperationName: "StartDeviceAuthorization", Err: err})
+}
+
+func TestFallbackFlagsConflict(t *testing.T) {
+	t.Parallel()
+
+	if _, err := (workflowFlags{fallback: true, noFallback: true}).options("dev", ""); err == nil || !strings.Contains(err.Error(), "--fallback cannot be combined with --no-fallback") {
+		t.Fatalf("expected --fallback and --no-fallback to conflict, got %v", err)
+	}
+}
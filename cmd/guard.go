@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// isAdminRole reports whether role is named like roles that usually carry administrator
+// access, such as AdministratorAccess, AWSReservedSSO_AdministratorAccess_0123abcd,
+// prod-admin, or the role AWS Organizations creates in member accounts. A word of the
+// name must start with admin, and names that deny it, such as NotAdminReadOnly, or are
+// read-only do not count.
+func isAdminRole(role string) bool {
+	if role == "OrganizationAccountAccessRole" {
+		return true
+	}
+	words := roleNameWords(role)
+	admin := false
+	for i, word := range words {
+		switch {
+		case word == "readonly" || word == "read" && i+1 < len(words) && words[i+1] == "only":
+			return false
+		case strings.HasPrefix(word, "admin") && (i == 0 || !slices.Contains([]string{"no", "non", "not"}, words[i-1])):
+			admin = true
+		}
+	}
+	return admin
+}
+
+// roleNameWords splits a role name into lowercase words at separators and changes of
+// case: AWSReservedSSO_AdministratorAccess is aws, reserved, sso, administrator, access.
+func roleNameWords(role string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(role)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, strings.ToLower(string(word)))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, strings.ToLower(string(word)))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, strings.ToLower(string(word)))
+	}
+	return words
+}
+
+// privilegedPrincipal explains why opening destination as identity breaks the usual
+// compliance rules: the root user never federates into the console, and administrator
+// roles are kept out of billing. It returns "" when nothing is wrong.
+func privilegedPrincipal(identity awslib.Identity, destination string) string {
+	if identity.IsRoot() {
+		return fmt.Sprintf("%s is the root user of account %s", identity.Arn, identity.Account)
+	}
+	if role := identity.RoleName(); role != "" && isAdminRole(role) && isBillingDestination(destination) {
+		return fmt.Sprintf("%s is an administrator role, not a billing role", role)
+	}
+	return ""
+}
+
+// isBillingDestination reports whether destination, a console path or URL, is in the
+// Billing and Cost Management console.
+func isBillingDestination(destination string) bool {
+	path := destination
+	if u, err := url.Parse(destination); err == nil && u.Host != "" {
+		path = u.Path
+	}
+	path = strings.TrimPrefix(path, "/")
+	return path == "billing" || strings.HasPrefix(path, "billing/") || strings.HasPrefix(path, "costmanagement/")
+}
+
+// guardPrincipal stops the console from opening as a privileged principal unless
+// --yes was passed or, in a terminal, the user confirms.
+func guardPrincipal(identity awslib.Identity, opts workflowOptions, deps runDeps) error {
+	reason := privilegedPrincipal(identity, opts.destination)
+	if reason == "" {
+		return nil
+	}
+	const warning = "Warning: %s; opening the console as it is usually a compliance violation.\n"
+	if opts.yes {
+		deps.messages.Fprintf(deps.stderr, warning, reason)
+		return nil
+	}
+	if !deps.term.Interactive() {
+		deps.messages.Fprintf(deps.stderr, warning, reason)
+		return i18n.Errorf("refusing to open the console because %s; pass --yes to open it anyway", reason)
+	}
+
+	// The warning is what the question is about, so it is shown even with --quiet.
+	deps.messages.Promptf(deps.stderr, warning, reason)
+	deps.messages.Promptf(deps.stderr, "Open the console anyway? [y/N] ")
+	line, _ := bufio.NewReader(deps.stdin).ReadString('\n')
+	if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+		return i18n.Errorf("not opening the console because %s", reason)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+func TestPrivilegedPrincipal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		arn         string
+		destination string
+		want        string
+	}{
+		{name: "root", arn: "arn:aws:iam::123456789012:root", destination: "s3/home", want: "arn:aws:iam::123456789012:root is the root user of account 123456789012"},
+		{name: "admin in billing", arn: "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_AdministratorAccess_0123abcd/me", destination: billingDestination, want: "AWSReservedSSO_AdministratorAccess_0123abcd is an administrator role"},
+		{name: "organizations role in cost explorer", arn: "arn:aws:sts::123456789012:assumed-role/OrganizationAccountAccessRole/me", destination: "costmanagement/home#/cost-explorer", want: "OrganizationAccountAccessRole is an administrator role"},
+		{name: "admin in billing URL", arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", destination: "https://console.aws.amazon.com/billing/home#/bills", want: "Admin is an administrator role"},
+		{name: "admin elsewhere", arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", destination: "ec2/home"},
+		{name: "billing role", arn: "arn:aws:sts::123456789012:assumed-role/Billing/me", destination: billingDestination},
+		{name: "iam user", arn: "arn:aws:iam::123456789012:user/admin", destination: billingDestination},
+		{name: "admin after a separator", arn: "arn:aws:sts::123456789012:assumed-role/prod-admin/me", destination: billingDestination, want: "prod-admin is an administrator role"},
+		{name: "admin in camel case", arn: "arn:aws:sts::123456789012:assumed-role/DevAdmin/me", destination: billingDestination, want: "DevAdmin is an administrator role"},
+		{name: "not admin", arn: "arn:aws:sts::123456789012:assumed-role/NotAdminReadOnly/me", destination: billingDestination},
+		{name: "read-only admin", arn: "arn:aws:sts::123456789012:assumed-role/AdminReadOnly/me", destination: billingDestination},
+		{name: "admin inside a word", arn: "arn:aws:sts::123456789012:assumed-role/Badminton/me", destination: billingDestination},
+	}
+
+	for _, tc := range testCases {
+		got := privilegedPrincipal(awslib.Identity{Arn: tc.arn, Account: "123456789012"}, tc.destination)
+		if tc.want == "" && got != "" || !strings.HasPrefix(got, tc.want) {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGuardPrincipal(t *testing.T) {
+	t.Parallel()
+
+	root := awslib.Identity{Arn: "arn:aws:iam::123456789012:root", Account: "123456789012"}
+	testCases := []struct {
+		name          string
+		identity      awslib.Identity
+		yes           bool
+		term          term.Info
+		stdin         string
+		quiet         bool
+		wantErrSubstr string
+		wantWarning   bool
+	}{
+		{name: "ordinary role", identity: awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Dev/me"}},
+		{name: "root with --yes", identity: root, yes: true, wantWarning: true},
+		{name: "root in a script", identity: root, wantErrSubstr: "refusing to open the console because arn:aws:iam::123456789012:root is the root user of account 123456789012; pass --yes", wantWarning: true},
+		{name: "root confirmed", identity: root, term: interactiveTerminal, stdin: "y\n", wantWarning: true},
+		{name: "root declined", identity: root, term: interactiveTerminal, stdin: "\n", wantErrSubstr: "not opening the console", wantWarning: true},
+		{name: "root with --yes and --quiet", identity: root, yes: true, quiet: true},
+		{name: "root confirmed with --quiet", identity: root, term: interactiveTerminal, stdin: "y\n", quiet: true, wantWarning: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stderr := &bytes.Buffer{}
+			deps := runDeps{term: tc.term, stdin: strings.NewReader(tc.stdin), stderr: stderr}
+			if tc.quiet {
+				deps.messages = output.NewPrinter(i18n.English, output.LevelQuiet)
+			}
+			err := guardPrincipal(tc.identity, workflowOptions{destination: "s3/home", yes: tc.yes}, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.Contains(stderr.String(), "usually a compliance violation"); got != tc.wantWarning {
+				t.Fatalf("warning = %v, want %v: %q", got, tc.wantWarning, stderr)
+			}
+		})
+	}
+}
+
+func TestRunWorkflowRefusesRootWithoutYes(t *testing.T) {
+	t.Parallel()
+
+	federated := false
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:root", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				federated = true
+				return "https://signin.aws.amazon.com/federation?Action=login", nil
+			},
+		},
+		open:   func(targetURL string, opts browserOptions) error { return nil },
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
+	}
+
+	err := runWorkflow(context.Background(), workflowOptions{profile: "root-keys", destination: billingDestination}, deps)
+	if err == nil || !strings.Contains(err.Error(), "pass --yes to open it anyway") {
+		t.Fatalf("expected the root user to be refused, got %v", err)
+	}
+	if federated {
+		t.Fatal("expected no sign-in token to be requested")
+	}
+}
+
+func TestRunWorkflowGuardsAssumedRole(t *testing.T) {
+	t.Parallel()
+
+	federated := false
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Billing/me", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}, nil
+			},
+			AssumeRoleFunc: func(ctx context.Context, profile string, input awslib.AssumeRoleInput) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				federated = true
+				return "https://signin.aws.amazon.com/federation?Action=login", nil
+			},
+		},
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+	}
+
+	opts := workflowOptions{
+		profile:     "billing",
+		destination: billingDestination,
+		assumeRole:  awslib.AssumeRoleInput{RoleARN: "arn:aws:iam::210987654321:role/ops/Admin"},
+	}
+	err := runWorkflow(context.Background(), opts, deps)
+	if err == nil || !strings.Contains(err.Error(), "Admin is an administrator role") {
+		t.Fatalf("expected the assumed administrator role to be refused, got %v", err)
+	}
+	if federated {
+		t.Fatal("expected no sign-in token to be requested")
+	}
+}
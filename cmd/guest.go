@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// guestDuration is how long a guest session lasts unless --duration is given.
+const guestDuration = time.Hour
+
+// reusableGuestURL warns that a sign-in URL shared without a shortener is not single-use.
+const reusableGuestURL = "The sign-in URL is not single-use: for 15 minutes, anyone who sees it can sign in as this session\n"
+
+// guestNamePattern matches the names guests are given, which become part of a role
+// session name.
+var guestNamePattern = regexp.MustCompile(`^[\w+=,.@-]{1,40}$`)
+
+func newGuestCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var name, dest, service string
+	var assumeRole awslib.AssumeRoleInput
+	var printURL, copyURL, showQR, yes bool
+
+	guestCmd := &cobra.Command{
+		Use:   "guest --name <teammate> --role-arn <arn> [profile]",
+		Short: "Share a short, limited console session as a role with a teammate",
+		Long: `Signs in as --role-arn, assumed with the profile's credentials, and prints a
+sign-in URL to hand to a teammate, instead of opening a browser. The session is
+named after the teammate, so CloudTrail shows who used it:
+
+  aws-console guest --name alice --role-arn arn:aws:iam::123456789012:role/Support --duration 1h --print
+
+Guest sessions are limited on every side:
+
+  - They last --duration, one hour by default, after which the role's credentials
+    and the console session both expire.
+  - They are read-only unless --session-policy or --policy-arns gives another
+    session policy.
+  - Their credentials are never cached, and the sign-in URL can be used for 15
+    minutes. Without a shortener in the config file, anyone who sees it in that
+    time can sign in with it, as often as they like; with one, it is shared as a
+    single-use link.
+  - Each is recorded in the history and, when configured, the audit log, with the
+    teammate's name.
+
+Before the URL is requested you are asked to confirm; --yes skips the question,
+and outside a terminal it is required.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProfileArg(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if err := setPositionalProfile(cmd, args[0], deps); err != nil {
+					return err
+				}
+			}
+			if name == "" {
+				return i18n.Errorf("--name is required: it names the teammate in the role session and the audit record")
+			}
+			if !guestNamePattern.MatchString(name) {
+				return i18n.Errorf("invalid --name %q (up to 40 letters, digits, or +=,.@_- characters)", name)
+			}
+			if assumeRole.RoleARN == "" {
+				return i18n.Errorf("--role-arn is required: guest sessions are always a role assumed for the guest")
+			}
+			if err := validateAssumeRole(assumeRole); err != nil {
+				return err
+			}
+			if !printURL && !copyURL && !showQR {
+				printURL = true
+			}
+
+			g, err := resolveWorkflowGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			if dest == "" && service == "" {
+				dest = g.destination
+			}
+			path, err := rootDestination(dest, service, g.partition, g.region)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			if !deps.durationSet {
+				deps.sessionDuration = int32(guestDuration / time.Second)
+				deps.durationSet = true
+			}
+			if assumeRole.SessionName == "" {
+				assumeRole.SessionName = guestSessionName(name)
+			}
+			// Only a session policy given for this session replaces read-only access; one
+			// from the config file limits the user's own sessions, not what a guest sees.
+			readOnly := !cmd.Flags().Changed("session-policy") && !cmd.Flags().Changed("policy-arns")
+			if readOnly {
+				deps.sessionPolicy = nil
+			}
+
+			opts := workflowOptions{
+				profile:     g.profile,
+				destination: path,
+				print:       printURL,
+				copy:        copyURL,
+				qr:          showQR,
+				noCache:     true,
+				shorten:     deps.shortener != nil,
+				assumeRole:  assumeRole,
+				readOnly:    readOnly,
+				yes:         yes,
+				guest:       name,
+			}
+			if err := confirmGuest(opts, deps); err != nil {
+				return err
+			}
+			return runner(ctx, opts, deps)
+		},
+	}
+
+	guestCmd.Flags().StringVar(&name, "name", "", "Name of the teammate the session is shared with, recorded in the role session name and audit log")
+	guestCmd.Flags().StringVarP(&dest, "destination", "d", "", "Console page to open: a service (ec2, s3, cw, cloudshell, quicksight), a page such as dashboard:<name>, a console path, or a console URL")
+	guestCmd.Flags().StringVar(&service, "service", "", "Console service to open, e.g. ec2 or cloudwatch (same as --destination)")
+	guestCmd.Flags().BoolVar(&printURL, "print", false, "Print the sign-in URL to stdout (the default)")
+	guestCmd.Flags().BoolVar(&copyURL, "copy", false, "Copy the sign-in URL to the clipboard")
+	guestCmd.Flags().BoolVar(&showQR, "qr", false, "Show the sign-in URL as a QR code")
+	guestCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Share the session without asking for confirmation")
+	addAssumeRoleFlags(guestCmd, &assumeRole)
+	return guestCmd
+}
+
+// guestSessionName is the role session name of a guest session, which CloudTrail
+// records against everything the guest does.
+func guestSessionName(name string) string {
+	session := "aws-console-guest-" + name
+	return session[:min(len(session), 64)]
+}
+
+// confirmGuest describes the guest session about to be shared and, unless --yes was
+// given, asks the user to confirm it.
+func confirmGuest(opts workflowOptions, deps runDeps) error {
+	summary := "Sharing a read-only %s console session of %s with %s\n"
+	if !opts.readOnly {
+		summary = "Sharing a %s console session of %s with %s, limited by a session policy\n"
+	}
+	duration := formatDuration(time.Duration(deps.sessionDuration) * time.Second)
+	if opts.yes {
+		deps.messages.Fprintf(deps.stderr, summary, duration, opts.assumeRole.RoleARN, opts.guest)
+		if !opts.shorten {
+			deps.messages.Fprintf(deps.stderr, reusableGuestURL)
+		}
+		return nil
+	}
+	if !deps.term.Interactive() {
+		return i18n.Errorf("refusing to share a guest session without confirmation; pass --yes to share it")
+	}
+
+	// The summary is what the question is about, so it is shown even with --quiet.
+	deps.messages.Promptf(deps.stderr, summary, duration, opts.assumeRole.RoleARN, opts.guest)
+	if !opts.shorten {
+		deps.messages.Promptf(deps.stderr, reusableGuestURL)
+	}
+	deps.messages.Promptf(deps.stderr, "Share it? [y/N] ")
+	line, _ := bufio.NewReader(deps.stdin).ReadString('\n')
+	if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+		return i18n.Errorf("not sharing the guest session")
+	}
+	return nil
+}
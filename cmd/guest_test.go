@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestGuestCmd(t *testing.T) {
+	t.Parallel()
+
+	role := "arn:aws:iam::123456789012:role/Support"
+	testCases := []struct {
+		name          string
+		args          []string
+		interactive   bool
+		stdin         string
+		config        string
+		wantOpts      workflowOptions
+		wantDuration  int32
+		wantStderr    string
+		wantErrSubstr string
+	}{
+		{
+			name:         "read-only for an hour by default",
+			args:         []string{"guest", "--name", "alice", "--role-arn", role, "--yes"},
+			wantOpts:     workflowOptions{print: true, readOnly: true},
+			wantDuration: 3600,
+			wantStderr:   "Sharing a read-only 1h0m console session of " + role + " with alice\nThe sign-in URL is not single-use",
+		},
+		{
+			name:         "read-only despite a session policy in the config file",
+			args:         []string{"guest", "--name", "alice", "--role-arn", role, "--yes"},
+			config:       "policy-arns: arn:aws:iam::aws:policy/AmazonS3FullAccess\n",
+			wantOpts:     workflowOptions{print: true, readOnly: true},
+			wantDuration: 3600,
+			wantStderr:   "Sharing a read-only 1h0m console session",
+		},
+		{
+			name:         "explicit duration and session policy",
+			args:         []string{"guest", "dev", "--name", "alice", "--role-arn", role, "--duration", "30m", "--policy-arns", "arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess", "--copy", "--yes"},
+			wantOpts:     workflowOptions{profile: "dev", copy: true},
+			wantDuration: 1800,
+			wantStderr:   "limited by a session policy",
+		},
+		{
+			name:         "confirmed in a terminal",
+			args:         []string{"guest", "-p", "dev", "--name", "alice", "--role-arn", role, "-d", "s3"},
+			interactive:  true,
+			stdin:        "y\n",
+			wantOpts:     workflowOptions{profile: "dev", destination: "s3/home", print: true, readOnly: true},
+			wantDuration: 3600,
+			wantStderr:   "Share it? [y/N] ",
+		},
+		{name: "declined in a terminal", args: []string{"guest", "-p", "dev", "--name", "alice", "--role-arn", role}, interactive: true, stdin: "n\n", wantErrSubstr: "not sharing the guest session"},
+		{name: "no confirmation outside a terminal", args: []string{"guest", "--name", "alice", "--role-arn", role}, wantErrSubstr: "pass --yes to share it"},
+		{name: "no name", args: []string{"guest", "--role-arn", role, "--yes"}, wantErrSubstr: "--name is required"},
+		{name: "invalid name", args: []string{"guest", "--name", "alice smith", "--role-arn", role, "--yes"}, wantErrSubstr: `invalid --name "alice smith"`},
+		{name: "no role", args: []string{"guest", "--name", "alice", "--yes"}, wantErrSubstr: "--role-arn is required"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stderr := &bytes.Buffer{}
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return testProfiles(), nil
+					},
+				},
+				stdin:  strings.NewReader(tc.stdin),
+				stdout: &bytes.Buffer{},
+				stderr: stderr,
+			}
+			if tc.interactive {
+				deps.term = interactiveTerminal
+			}
+			if tc.config != "" {
+				deps.configFile = filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(deps.configFile, []byte(tc.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got workflowOptions
+			var gotPolicy *awslib.SessionPolicy
+			var gotDuration int32
+			ran := false
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				got, gotDuration, gotPolicy, ran = opts, deps.sessionDuration, deps.sessionPolicy, true
+				return nil
+			})
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if ran {
+					t.Fatal("expected no guest session to be shared")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := tc.wantOpts
+			if got.profile != want.profile || got.destination != want.destination || got.print != want.print || got.copy != want.copy || got.readOnly != want.readOnly {
+				t.Fatalf("unexpected options %+v", got)
+			}
+			if got.guest != "alice" || !got.noCache || got.assumeRole.RoleARN != role || got.assumeRole.SessionName != "aws-console-guest-alice" {
+				t.Fatalf("expected an uncached guest session of the role, got %+v", got)
+			}
+			if got.readOnly && gotPolicy != nil {
+				t.Fatalf("expected a read-only session without another session policy, got %+v", gotPolicy)
+			}
+			if gotDuration != tc.wantDuration {
+				t.Fatalf("expected a %ds session, got %ds", tc.wantDuration, gotDuration)
+			}
+			if !strings.Contains(stderr.String(), tc.wantStderr) {
+				t.Fatalf("expected stderr to contain %q, got %q", tc.wantStderr, stderr)
+			}
+		})
+	}
+}
+
+func TestGuestSessionName(t *testing.T) {
+	t.Parallel()
+
+	if got := guestSessionName("alice@example.com"); got != "aws-console-guest-alice@example.com" {
+		t.Fatalf("unexpected session name %q", got)
+	}
+	if got := guestSessionName(strings.Repeat("a", 60)); len(got) != 64 {
+		t.Fatalf("expected the session name to be cut to 64 characters, got %q", got)
+	}
+}
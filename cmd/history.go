@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/history"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd(deps runDeps) *cobra.Command {
+	var accounts []string
+	var since string
+	var limit int
+
+	historyCmd := &cobra.Command{
+		Use:   "history [profile...]",
+		Short: "List the consoles opened recently, newest first",
+		Long: `Lists the console sign-ins aws-console has recorded, newest first, with the
+profile, account, role, region, and page of each. Give profiles to see only theirs.
+
+The history is kept only on this machine, in history.jsonl under the aws-console
+state directory. Set history to false in the config file, or AWS_CONSOLE_HISTORY=false,
+to stop recording, and run 'aws-console clean --history' to delete it.`,
+		ValidArgsFunction: completeProfiles(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			deps.messages = g.printer()
+			if deps.history == nil {
+				return i18n.Errorf("history is unavailable: no state directory")
+			}
+			if limit < 0 {
+				return i18n.Errorf("invalid --limit %d: must not be negative", limit)
+			}
+
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+			filter := history.Filter{Accounts: accounts, Limit: limit}
+			for _, name := range args {
+				filter.Profiles = append(filter.Profiles, file.Alias(name))
+			}
+			if since != "" {
+				age, err := parseAge(since)
+				if err != nil {
+					return err
+				}
+				filter.Since = deps.now().Add(-age)
+			}
+
+			entries, err := deps.history.Query(filter)
+			if err != nil {
+				return err
+			}
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "TIME", Key: "time"},
+					{Header: "PROFILE", Key: "profile"},
+					{Header: "ACCOUNT", Key: "account"},
+					{Header: "ROLE", Key: "role"},
+					{Header: "REGION", Key: "region"},
+					{Header: "DESTINATION", Key: "destination"},
+				},
+			}
+			for _, e := range entries {
+				table.Rows = append(table.Rows, []string{formatTimestamp(e.Time), e.Profile, e.Account, e.Role, e.Region, e.Destination})
+			}
+
+			if len(table.Rows) == 0 && g.output == output.FormatTable {
+				deps.messages.Fprintln(deps.stdout, "No console sign-ins recorded.")
+				return nil
+			}
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+
+	historyCmd.Flags().StringSliceVar(&accounts, "account", nil, "Only list sign-ins to these account IDs")
+	historyCmd.Flags().StringVar(&since, "since", "", "Only list sign-ins within this long ago, e.g. 12h or 7d")
+	historyCmd.Flags().IntVar(&limit, "limit", 20, "List at most this many sign-ins; 0 lists all")
+	return historyCmd
+}
+
+// parseAge parses a duration such as 90m or 12h, also accepting whole days such as 7d.
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, i18n.Errorf("invalid --since %q (expected a duration such as 12h or 7d)", value)
+	}
+	return d, nil
+}
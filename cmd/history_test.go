@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/history"
+	"github.com/spf13/cobra"
+)
+
+func TestHistoryCmd(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	store := history.NewStoreAt(filepath.Join(dir, history.FileName))
+	for _, e := range []history.Entry{
+		{Time: now.Add(-72 * time.Hour), Profile: "dev", Account: "123456789012", Role: "AdministratorAccess"},
+		{Time: now.Add(-3 * time.Hour), Profile: "prod", Account: "210987654321", Role: "Admin", Region: "eu-west-1", Destination: "ec2/home"},
+		{Time: now.Add(-time.Hour), Profile: "dev", Account: "123456789012", Role: "AdministratorAccess", Destination: "s3"},
+	} {
+		if err := store.Append(e); err != nil {
+			t.Fatalf("failed to record history: %v", err)
+		}
+	}
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("aliases:\n  p: prod\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		empty         bool
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name: "newest first",
+			args: []string{"history", "-o", "csv"},
+			want: "time,profile,account,role,region,destination\n" +
+				"2025-06-10T11:00:00Z,dev,123456789012,AdministratorAccess,,s3\n" +
+				"2025-06-10T09:00:00Z,prod,210987654321,Admin,eu-west-1,ec2/home\n" +
+				"2025-06-07T12:00:00Z,dev,123456789012,AdministratorAccess,,\n",
+		},
+		{
+			name: "by profile alias",
+			args: []string{"history", "p", "-o", "csv"},
+			want: "time,profile,account,role,region,destination\n" +
+				"2025-06-10T09:00:00Z,prod,210987654321,Admin,eu-west-1,ec2/home\n",
+		},
+		{
+			name: "by account, since and limit",
+			args: []string{"history", "--account", "123456789012", "--since", "7d", "--limit", "1", "-o", "csv"},
+			want: "time,profile,account,role,region,destination\n" +
+				"2025-06-10T11:00:00Z,dev,123456789012,AdministratorAccess,,s3\n",
+		},
+		{
+			name: "since in hours",
+			args: []string{"history", "--since", "2h", "-o", "csv"},
+			want: "time,profile,account,role,region,destination\n" +
+				"2025-06-10T11:00:00Z,dev,123456789012,AdministratorAccess,,s3\n",
+		},
+		{
+			name:  "nothing recorded",
+			args:  []string{"history"},
+			empty: true,
+			want:  "No console sign-ins recorded.\n",
+		},
+		{
+			name:          "invalid since",
+			args:          []string{"history", "--since", "last week"},
+			wantErrSubstr: `invalid --since "last week"`,
+		},
+		{
+			name:          "invalid limit",
+			args:          []string{"history", "--limit", "-1"},
+			wantErrSubstr: "invalid --limit -1",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			deps := runDeps{history: store, configFile: configFile, now: func() time.Time { return now }}
+			if tc.empty {
+				deps.history = history.NewStoreAt(filepath.Join(t.TempDir(), history.FileName))
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tc.want {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tc.want, out)
+			}
+		})
+	}
+}
+
+func TestHistorySetting(t *testing.T) {
+	testCases := []struct {
+		value       string
+		wantHistory bool
+	}{
+		{value: "", wantHistory: true},
+		{value: "false", wantHistory: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run("AWS_CONSOLE_HISTORY="+tc.value, func(t *testing.T) {
+			t.Setenv("AWS_CONSOLE_HISTORY", tc.value)
+
+			deps := runDeps{history: history.NewStoreAt(filepath.Join(t.TempDir(), history.FileName)), stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+			var gotDeps runDeps
+			root := newRootCmd(deps, nil)
+			historyCmd, _, err := root.Find([]string{"history"})
+			if err != nil {
+				t.Fatalf("failed to find history command: %v", err)
+			}
+			historyCmd.RunE = func(cmd *cobra.Command, args []string) error {
+				g, err := resolveGlobals(cmd, deps)
+				if err != nil {
+					return err
+				}
+				_, gotDeps = g.apply(context.Background(), deps)
+				return nil
+			}
+			root.SetArgs([]string{"history"})
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotHistory := gotDeps.history != nil; gotHistory != tc.wantHistory {
+				t.Fatalf("history recorded = %v, want %v", gotHistory, tc.wantHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/logging"
+)
+
+// Points of the workflow hooks run at, as named in the config file.
+const (
+	hookPreOpen  = "pre-open"
+	hookPostOpen = "post-open"
+)
+
+// hookSession is what hooks are told about the console session being opened.
+type hookSession struct {
+	profile     string
+	identity    awslib.Identity
+	region      string
+	destination string
+	urls        []string
+}
+
+// environment returns the environment of aws-console with the session described in
+// AWS_CONSOLE_* variables. Sign-in tokens are redacted from the URLs, so a hook can
+// log them but not sign in with them.
+func (s hookSession) environment(event string) []string {
+	urls := make([]string, 0, len(s.urls))
+	for _, u := range s.urls {
+		urls = append(urls, logging.Mask("", u))
+	}
+	return append(os.Environ(),
+		"AWS_CONSOLE_HOOK="+event,
+		"AWS_CONSOLE_PROFILE="+s.profile,
+		"AWS_CONSOLE_ACCOUNT="+s.identity.Account,
+		"AWS_CONSOLE_ARN="+s.identity.Arn,
+		"AWS_CONSOLE_REGION="+s.region,
+		"AWS_CONSOLE_DESTINATION="+s.destination,
+		"AWS_CONSOLE_URL="+strings.Join(urls, "\n"),
+	)
+}
+
+// runHooks runs the hooks of event in order through the platform shell, each bounded
+// by its timeout. Their output goes to stderr, keeping stdout for the URL or record
+// aws-console prints. A failing hook stops the workflow only when its on-failure is fail.
+func runHooks(ctx context.Context, event string, hooks []config.Hook, session hookSession, deps runDeps) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	env := session.environment(event)
+	for _, h := range hooks {
+		verbosef(deps, "Running %s hook: %s", event, h.Command)
+		hookCtx, cancel := context.WithTimeout(ctx, h.TimeoutDuration())
+		name, args := shellCommand(deps.goos, h.Command)
+		err := deps.executor.RunContext(hookCtx, name, args, env, nil, deps.stderr, deps.stderr)
+		cancel()
+		if err == nil {
+			continue
+		}
+		switch h.OnFailure {
+		case config.HookFail:
+			return i18n.Errorf("%s hook %q failed: %w", event, h.Command, err)
+		case config.HookIgnore:
+			verbosef(deps, "Ignoring the failure of %s hook %q: %v", event, h.Command, err)
+		default:
+			deps.messages.Fprintf(deps.stderr, "Warning: %s hook %q failed: %v\n", event, h.Command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/config"
+)
+
+func TestRunHooks(t *testing.T) {
+	t.Parallel()
+
+	session := hookSession{
+		profile:     "dev",
+		identity:    awslib.Identity{Account: "123456789012", Arn: "arn:aws:sts::123456789012:assumed-role/Dev/me"},
+		region:      "us-west-2",
+		destination: "s3",
+		urls:        []string{"https://signin.aws.amazon.com/federation?Action=login&SigninToken=secret&Destination=x"},
+	}
+
+	testCases := []struct {
+		name          string
+		hooks         []config.Hook
+		runErr        error
+		wantCalls     int
+		wantStderr    string
+		wantErrSubstr string
+	}{
+		{
+			name: "no hooks",
+		},
+		{
+			name:      "runs every hook",
+			hooks:     []config.Hook{{Command: "one"}, {Command: "two"}},
+			wantCalls: 2,
+		},
+		{
+			name:       "warns on failure by default",
+			hooks:      []config.Hook{{Command: "one"}, {Command: "two"}},
+			runErr:     errors.New("exit status 1"),
+			wantCalls:  2,
+			wantStderr: `Warning: pre-open hook "one" failed: exit status 1`,
+		},
+		{
+			name:      "ignores failure",
+			hooks:     []config.Hook{{Command: "one", OnFailure: config.HookIgnore}},
+			runErr:    errors.New("exit status 1"),
+			wantCalls: 1,
+		},
+		{
+			name:          "stops on failure",
+			hooks:         []config.Hook{{Command: "one", OnFailure: config.HookFail}, {Command: "two"}},
+			runErr:        errors.New("exit status 1"),
+			wantCalls:     1,
+			wantErrSubstr: `pre-open hook "one" failed: exit status 1`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stderr := &bytes.Buffer{}
+			executor := &fakeExecutor{runErr: tc.runErr}
+			deps := runDeps{executor: executor, goos: "linux", stderr: stderr}
+
+			err := runHooks(context.Background(), hookPreOpen, tc.hooks, session, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(executor.calls) != tc.wantCalls {
+				t.Fatalf("expected %d hooks to run, got %+v", tc.wantCalls, executor.calls)
+			}
+			if tc.wantStderr == "" && stderr.Len() != 0 {
+				t.Fatalf("expected no output, got %q", stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tc.wantStderr) {
+				t.Fatalf("expected stderr to contain %q, got %q", tc.wantStderr, stderr.String())
+			}
+			for i, call := range executor.calls {
+				if got := append([]string{call.name}, call.args...); strings.Join(got, "|") != "sh|-c|"+tc.hooks[i].Command {
+					t.Fatalf("unexpected hook command %v", got)
+				}
+				for _, want := range []string{
+					"AWS_CONSOLE_HOOK=pre-open",
+					"AWS_CONSOLE_PROFILE=dev",
+					"AWS_CONSOLE_ACCOUNT=123456789012",
+					"AWS_CONSOLE_ARN=arn:aws:sts::123456789012:assumed-role/Dev/me",
+					"AWS_CONSOLE_REGION=us-west-2",
+					"AWS_CONSOLE_DESTINATION=s3",
+				} {
+					if !slices.Contains(call.env, want) {
+						t.Fatalf("expected the environment to contain %q, got %q", want, call.env)
+					}
+				}
+				u := slices.IndexFunc(call.env, func(v string) bool { return strings.HasPrefix(v, "AWS_CONSOLE_URL=") })
+				if u < 0 || strings.Contains(call.env[u], "secret") || !strings.Contains(call.env[u], "Destination=x") {
+					t.Fatalf("expected a redacted URL, got %q", call.env)
+				}
+			}
+		})
+	}
+}
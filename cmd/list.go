@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/usage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	sortByName     = "name"
+	sortByExpiry   = "expiry"
+	sortByLastUsed = "last-used"
+)
+
+// profileColumns are shared by the list and status commands so their output lines up.
+var profileColumns = []output.Column{
+	{Header: "PROFILE", Key: "profile"},
+	{Header: "ACCOUNT", Key: "account"},
+	{Header: "ROLE", Key: "role"},
+	{Header: "SOURCE", Key: "source"},
+	{Header: "EXPIRY", Key: "expiry"},
+}
+
+type listOptions struct {
+	output    output.Format
+	filter    string
+	sortBy    string
+	validOnly bool
+}
+
+func newListCmd(deps runDeps) *cobra.Command {
+	var opts listOptions
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List profiles from the shared AWS config",
+		Long: `Lists the profiles defined in the shared AWS config and credentials files, with
+their type (SOURCE), region, and account where the config names one.
+
+Credentials are only checked when --valid-only or --sort expiry is given, since
+that requires calling STS for every listed profile.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			opts.output = g.output
+			return runList(ctx, opts, deps)
+		},
+	}
+
+	listCmd.Flags().StringVar(&opts.filter, "filter", "", "Only show profiles whose name, account, or role contains this substring")
+	listCmd.Flags().StringVar(&opts.sortBy, "sort", sortByName, "Sort order: name, expiry, or last-used")
+	listCmd.Flags().BoolVar(&opts.validOnly, "valid-only", false, "Only show profiles with valid credentials (checks each profile)")
+
+	return listCmd
+}
+
+func runList(ctx context.Context, opts listOptions, deps runDeps) error {
+	switch opts.sortBy {
+	case sortByName, sortByExpiry, sortByLastUsed:
+	default:
+		return i18n.Errorf("unsupported sort order %q (expected one of: %s, %s, %s)", opts.sortBy, sortByName, sortByExpiry, sortByLastUsed)
+	}
+
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return i18n.Errorf("failed to list profiles: %w", err)
+	}
+	profiles = filterProfiles(profiles, opts.filter)
+
+	lastUsed := map[string]usage.Entry{}
+	if deps.usage != nil {
+		if lastUsed, err = deps.usage.Load(); err != nil {
+			return err
+		}
+	}
+
+	statuses := make([]profileStatus, 0, len(profiles))
+	checkCredentials := opts.validOnly || opts.sortBy == sortByExpiry
+	for _, p := range profiles {
+		status := profileStatus{profile: p, account: p.AccountID, role: p.RoleName}
+		if checkCredentials {
+			status = checkProfile(ctx, p, deps)
+		}
+		if opts.validOnly && !status.valid() {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+
+	sortProfileStatuses(statuses, opts.sortBy, lastUsed)
+
+	table := output.Table{
+		Columns: append(append([]output.Column(nil), profileColumns...),
+			output.Column{Header: "REGION", Key: "region"},
+			output.Column{Header: "LAST USED", Key: "last_used"},
+		),
+	}
+	for _, s := range statuses {
+		table.Rows = append(table.Rows, []string{
+			s.profile.Name,
+			s.account,
+			s.role,
+			s.profile.Source,
+			formatTimestamp(s.expires),
+			s.profile.Region,
+			formatTimestamp(lastUsed[s.profile.Name].LastUsed),
+		})
+	}
+
+	return output.Render(deps.stdout, opts.output, table)
+}
+
+// filterProfiles keeps profiles whose name, account ID, or role name contains the
+// substring, ignoring case.
+func filterProfiles(profiles []awslib.Profile, substring string) []awslib.Profile {
+	substring = strings.ToLower(strings.TrimSpace(substring))
+	if substring == "" {
+		return profiles
+	}
+
+	var filtered []awslib.Profile
+	for _, p := range profiles {
+		for _, field := range []string{p.Name, p.AccountID, p.RoleName} {
+			if strings.Contains(strings.ToLower(field), substring) {
+				filtered = append(filtered, p)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// sortProfileStatuses orders statuses in place. Expiry sorts soonest first with
+// non-expiring and unchecked credentials last; last-used sorts most recent first.
+func sortProfileStatuses(statuses []profileStatus, sortBy string, lastUsed map[string]usage.Entry) {
+	byName := func(i, j int) bool {
+		return statuses[i].profile.Name < statuses[j].profile.Name
+	}
+
+	switch sortBy {
+	case sortByExpiry:
+		sort.SliceStable(statuses, func(i, j int) bool {
+			a, b := statuses[i].expires, statuses[j].expires
+			if a.IsZero() != b.IsZero() {
+				return !a.IsZero()
+			}
+			if !a.Equal(b) {
+				return a.Before(b)
+			}
+			return byName(i, j)
+		})
+	case sortByLastUsed:
+		sort.SliceStable(statuses, func(i, j int) bool {
+			a, b := lastUsed[statuses[i].profile.Name].LastUsed, lastUsed[statuses[j].profile.Name].LastUsed
+			if !a.Equal(b) {
+				return a.After(b)
+			}
+			return byName(i, j)
+		})
+	default:
+		sort.SliceStable(statuses, byName)
+	}
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatDuration formats d to the minute, e.g. 7h59m, for status messages.
+func formatDuration(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	if d < time.Minute {
+		return "less than a minute"
+	}
+	s := d.String()
+	return strings.TrimSuffix(s, "0s")
+}
+
+// profileByName indexes profiles for lookups by name.
+func profileByName(profiles []awslib.Profile) map[string]awslib.Profile {
+	byName := make(map[string]awslib.Profile, len(profiles))
+	for _, p := range profiles {
+		byName[p.Name] = p
+	}
+	return byName
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/usage"
+)
+
+func testProfiles() []awslib.Profile {
+	return []awslib.Profile{
+		{Name: "dev", Source: awslib.ProfileSourceSSO, Region: "us-west-2", AccountID: "123456789012", RoleName: "AdministratorAccess"},
+		{Name: "keys", Source: awslib.ProfileSourceStatic},
+	}
+}
+
+func executeSubcommand(t *testing.T, deps runDeps, args ...string) (string, error) {
+	t.Helper()
+
+	stdout := &bytes.Buffer{}
+	deps.stdout = stdout
+	if deps.stderr == nil {
+		deps.stderr = &bytes.Buffer{}
+	}
+
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		t.Fatal("unexpected workflow run")
+		return nil
+	})
+	root.SetArgs(args)
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+
+	err := root.Execute()
+	return stdout.String(), err
+}
+
+func TestListCmd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		listErr       error
+		wantContains  []string
+		wantErrSubstr string
+	}{
+		{
+			name: "table output",
+			args: []string{"list"},
+			wantContains: []string{
+				"PROFILE  ACCOUNT       ROLE                 SOURCE  EXPIRY  REGION",
+				"dev      123456789012  AdministratorAccess  sso     -       us-west-2",
+				"keys     -             -                    static  -       -",
+			},
+		},
+		{
+			name: "csv output",
+			args: []string{"list", "--output", "csv"},
+			wantContains: []string{
+				"profile,account,role,source,expiry,region",
+				"dev,123456789012,AdministratorAccess,sso,,us-west-2",
+			},
+		},
+		{
+			name:         "json output",
+			args:         []string{"list", "-o", "json"},
+			wantContains: []string{`"profile": "dev"`, `"source": "static"`},
+		},
+		{
+			name:          "invalid output",
+			args:          []string{"list", "-o", "xml"},
+			wantErrSubstr: `unsupported output format "xml"`,
+		},
+		{
+			name:          "lister error",
+			args:          []string{"list"},
+			listErr:       errors.New("bad config"),
+			wantErrSubstr: "failed to list profiles: bad config",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return testProfiles(), tc.listErr
+					},
+				},
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestListCmdFilterSortAndValidity(t *testing.T) {
+	t.Parallel()
+
+	profiles := []awslib.Profile{
+		{Name: "alpha", Source: awslib.ProfileSourceSSO, AccountID: "111111111111", RoleName: "ReadOnly"},
+		{Name: "bravo", Source: awslib.ProfileSourceSSO, AccountID: "222222222222", RoleName: "Admin"},
+		{Name: "charlie", Source: awslib.ProfileSourceStatic},
+	}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		args          []string
+		wantOrder     []string
+		wantChecks    int64
+		wantErrSubstr string
+	}{
+		{
+			name:      "sorted by name without credential checks",
+			args:      []string{"list", "-o", "csv"},
+			wantOrder: []string{"alpha", "bravo", "charlie"},
+		},
+		{
+			name:      "filter matches role case-insensitively",
+			args:      []string{"list", "-o", "csv", "--filter", "ADMIN"},
+			wantOrder: []string{"bravo"},
+		},
+		{
+			name:      "filter matches account",
+			args:      []string{"list", "-o", "csv", "--filter", "1111"},
+			wantOrder: []string{"alpha"},
+		},
+		{
+			name:       "valid only drops failing profiles",
+			args:       []string{"list", "-o", "csv", "--valid-only"},
+			wantOrder:  []string{"alpha", "bravo"},
+			wantChecks: 3,
+		},
+		{
+			name:       "expiry sorts soonest first and unknown last",
+			args:       []string{"list", "-o", "csv", "--sort", "expiry"},
+			wantOrder:  []string{"bravo", "alpha", "charlie"},
+			wantChecks: 3,
+		},
+		{
+			name:      "last used sorts most recent first",
+			args:      []string{"list", "-o", "csv", "--sort", "last-used"},
+			wantOrder: []string{"charlie", "alpha", "bravo"},
+		},
+		{
+			name:          "invalid sort",
+			args:          []string{"list", "--sort", "size"},
+			wantErrSubstr: `unsupported sort order "size"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := usage.NewStoreAt(filepath.Join(t.TempDir(), "usage.json"))
+			if err := store.Record("alpha", now.Add(-time.Hour)); err != nil {
+				t.Fatalf("failed to seed usage: %v", err)
+			}
+			if err := store.Record("charlie", now); err != nil {
+				t.Fatalf("failed to seed usage: %v", err)
+			}
+
+			svc := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					if profile == "charlie" {
+						return awslib.Identity{}, errors.New("invalid keys")
+					}
+					return awslib.Identity{Arn: "arn:aws:sts::111111111111:assumed-role/ReadOnly/me"}, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					expires := now.Add(2 * time.Hour)
+					if profile == "bravo" {
+						expires = now.Add(time.Hour)
+					}
+					return awslib.Credentials{Expires: expires}, nil
+				},
+			}
+
+			deps := runDeps{
+				awsService: svc,
+				usage:      store,
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return profiles, nil
+					},
+				},
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimSpace(out), "\n")[1:]
+			var gotOrder []string
+			for _, line := range lines {
+				gotOrder = append(gotOrder, strings.SplitN(line, ",", 2)[0])
+			}
+			if strings.Join(gotOrder, ",") != strings.Join(tc.wantOrder, ",") {
+				t.Fatalf("unexpected order: got %v want %v\n%s", gotOrder, tc.wantOrder, out)
+			}
+			if svc.GetCallerIdentityCalls.Load() != tc.wantChecks {
+				t.Fatalf("expected %d credential checks, got %d", tc.wantChecks, svc.GetCallerIdentityCalls.Load())
+			}
+		})
+	}
+}
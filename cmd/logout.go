@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+type logoutOptions struct {
+	sso       bool
+	noBrowser bool
+	dryRun    bool
+}
+
+func newLogoutCmd(deps runDeps) *cobra.Command {
+	var opts logoutOptions
+
+	logoutCmd := &cobra.Command{
+		Use:   "logout",
+		Short: "End console sessions and remove cached credentials and tokens",
+		Long: `Ends what aws-console signed in on this machine: removes cached session
+credentials, console sign-in tokens, SSO client registrations, and tracked console
+sessions, then opens the console sign-out page so the browser's session ends too.
+The page is printed instead when stdout is piped.
+
+With --sso, 'aws sso logout' is also run to sign out of IAM Identity Center and
+clear the AWS CLI's SSO token cache. History and usage data are kept; use
+'aws-console clean' to remove those.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			return runLogout(ctx, opts, deps)
+		},
+	}
+
+	logoutCmd.Flags().BoolVar(&opts.sso, "sso", false, "Also run 'aws sso logout' to sign out of IAM Identity Center")
+	logoutCmd.Flags().BoolVar(&opts.noBrowser, "no-browser", false, "Do not open the console sign-out page")
+	logoutCmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Show what would be removed without removing anything or signing out")
+
+	return logoutCmd
+}
+
+// logoutTargets are the cached credentials and sessions that logout removes.
+func logoutTargets(deps runDeps) []cleanTarget {
+	var sessionsPath string
+	if deps.sessions != nil {
+		sessionsPath = deps.sessions.Path()
+	}
+	return []cleanTarget{
+		credentialCacheTarget(deps),
+		signinTokenCacheTarget(deps),
+		{name: "sso-registrations", description: "cached SSO client registrations", path: joinIfSet(deps.cacheDir, ssoCacheDirName)},
+		{name: "sessions", description: "console sessions listed by the sessions command", path: sessionsPath},
+	}
+}
+
+func runLogout(ctx context.Context, opts logoutOptions, deps runDeps) error {
+	var errs []error
+	for _, t := range logoutTargets(deps) {
+		if err := cleanPath(t, opts.dryRun, deps); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if opts.dryRun {
+		return errors.Join(errs...)
+	}
+
+	if opts.sso {
+		deps.messages.Fprintln(deps.stderr, "Signing out of IAM Identity Center...")
+		if err := deps.executor.Run("aws", []string{"sso", "logout"}, deps.stdin, deps.messages.Writer(deps.stderr), deps.stderr); err != nil {
+			errs = append(errs, i18n.Errorf("failed to run 'aws sso logout': %w", err))
+		}
+	}
+
+	if !opts.noBrowser {
+		if err := openLogoutPage(ctx, deps); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// logoutSettle is how long the browser is given to follow the console sign-out page
+// before the sign-in URL is opened, since it cannot report when a page has loaded.
+const logoutSettle = 3 * time.Second
+
+// signOutFirst opens the console sign-out page of the partition in ctx where the
+// console is about to open, and waits for the browser to follow it. The console allows
+// one federated session per browser, and otherwise asks to sign out of the old one.
+func signOutFirst(ctx context.Context, opts browserOptions, deps runDeps) error {
+	logoutURL, err := awslib.LogoutURL(awslib.PartitionFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	deps.messages.Fprintln(statusWriter(deps), "Signing out of the AWS Console first...")
+	done := deps.timings.start("browser")
+	err = deps.open(logoutURL, opts)
+	done()
+	if err != nil {
+		return i18n.Errorf("failed to open the console sign-out page: %w", err)
+	}
+	return deps.sleep(ctx, logoutSettle)
+}
+
+// openLogoutPage opens the console sign-out page of the partition in ctx, or prints it
+// when stdout is piped.
+func openLogoutPage(ctx context.Context, deps runDeps) error {
+	logoutURL, err := awslib.LogoutURL(awslib.PartitionFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if deps.term.Piped() {
+		fmt.Fprintln(deps.stdout, logoutURL)
+		return nil
+	}
+	deps.messages.Fprintln(deps.stderr, "Signing out of the AWS Console...")
+	return deps.open(logoutURL, browserOptions{}.withSettings(deps))
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/history"
+	"github.com/eculver/aws-console/pkg/sessions"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+func TestLogoutCmd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		args         []string
+		piped        bool
+		runErr       error
+		wantOpened   string
+		wantExec     bool
+		wantRemoved  bool
+		wantContains []string
+		wantStderr   []string
+		wantErr      string
+	}{
+		{
+			name:         "removes caches and opens the sign-out page",
+			args:         []string{"logout"},
+			wantOpened:   "https://signin.aws.amazon.com/oauth?Action=logout",
+			wantRemoved:  true,
+			wantContains: []string{"Removed cached session credentials:", "Removed cached SSO client registrations:"},
+			wantStderr:   []string{"Signing out of the AWS Console..."},
+		},
+		{
+			name:         "partition",
+			args:         []string{"logout", "--partition", "aws-cn"},
+			wantOpened:   "https://signin.amazonaws.cn/oauth?Action=logout",
+			wantRemoved:  true,
+			wantContains: []string{"Removed cached console sign-in tokens:"},
+		},
+		{
+			name:         "piped prints the sign-out page",
+			args:         []string{"logout"},
+			piped:        true,
+			wantRemoved:  true,
+			wantContains: []string{"https://signin.aws.amazon.com/oauth?Action=logout\n"},
+		},
+		{
+			name:        "sso",
+			args:        []string{"logout", "--sso", "--no-browser"},
+			wantExec:    true,
+			wantRemoved: true,
+			wantStderr:  []string{"Signing out of IAM Identity Center...", "Successfully signed out"},
+		},
+		{
+			name:        "sso quiet",
+			args:        []string{"logout", "--sso", "--no-browser", "--quiet"},
+			wantExec:    true,
+			wantRemoved: true,
+		},
+		{
+			name:        "sso failure is reported after cleaning up",
+			args:        []string{"logout", "--sso", "--no-browser"},
+			runErr:      errors.New("executable file not found"),
+			wantExec:    true,
+			wantRemoved: true,
+			wantErr:     "failed to run 'aws sso logout': executable file not found",
+		},
+		{
+			name:         "dry run",
+			args:         []string{"logout", "--dry-run", "--sso"},
+			wantContains: []string{"Would remove cached session credentials:"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := setupCleanDirs(t)
+			ssoDir := filepath.Join(deps.cacheDir, ssoCacheDirName)
+			if err := os.MkdirAll(ssoDir, 0o700); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			deps.sessions = sessions.NewStoreAt(filepath.Join(deps.stateDir, sessions.FileName))
+			executor := &fakeExecutor{runErr: tc.runErr, runOutput: "Successfully signed out"}
+			stderr := &bytes.Buffer{}
+			deps.stderr = stderr
+			deps.executor = executor
+			deps.term = interactiveTerminal
+			if tc.piped {
+				deps.term = term.Info{StdoutTTY: false, StdinTTY: true, StderrTTY: true}
+			}
+			var opened string
+			deps.open = func(targetURL string, opts browserOptions) error {
+				opened = targetURL
+				return nil
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if opened != tc.wantOpened {
+				t.Fatalf("expected %q to be opened, got %q", tc.wantOpened, opened)
+			}
+			if gotExec := len(executor.calls) == 1 && strings.Join(executor.calls[0].args, " ") == "sso logout"; gotExec != tc.wantExec {
+				t.Fatalf("unexpected executor calls: %+v", executor.calls)
+			}
+			for _, path := range []string{filepath.Join(deps.cacheDir, credentialCacheDirName), ssoDir} {
+				if exists(path) == tc.wantRemoved {
+					t.Fatalf("expected %s removed=%v", path, tc.wantRemoved)
+				}
+			}
+			if !exists(filepath.Join(deps.stateDir, history.FileName)) {
+				t.Fatal("expected history to be kept")
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+			for _, want := range tc.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Fatalf("expected stderr to contain %q, got:\n%s", want, stderr.String())
+				}
+			}
+			if strings.Contains(out, "Signing out") || strings.Contains(out, "Successfully signed out") {
+				t.Fatalf("expected progress on stderr only, got:\n%s", out)
+			}
+			if slices.Contains(tc.args, "--quiet") && stderr.Len() > 0 {
+				t.Fatalf("expected no progress, got:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunWorkflowForceLogout(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		print      bool
+		openErr    error
+		wantOpened []string
+		wantSlept  time.Duration
+		wantErr    string
+	}{
+		{
+			name:       "signs out first",
+			wantOpened: []string{"https://signin.aws.amazon.com/oauth?Action=logout", "https://example.com/console-login"},
+			wantSlept:  logoutSettle,
+		},
+		{name: "printed", print: true},
+		{
+			name:       "sign-out page fails to open",
+			openErr:    errors.New("no browser"),
+			wantOpened: []string{"https://signin.aws.amazon.com/oauth?Action=logout"},
+			wantErr:    "failed to open the console sign-out page: no browser",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opened []string
+			var slept time.Duration
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Dev/me", Account: "123456789012", Partition: awslib.PartitionAWS}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = append(opened, targetURL)
+					return tc.openErr
+				},
+				sleep: func(ctx context.Context, d time.Duration) error {
+					slept += d
+					return nil
+				},
+				term:            interactiveTerminal,
+				stdout:          &bytes.Buffer{},
+				stderr:          &bytes.Buffer{},
+				now:             time.Now,
+				sessionDuration: sessionDuration,
+			}
+
+			err := runWorkflow(context.Background(), workflowOptions{profile: "dev", forceLogout: true, print: tc.print}, deps)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(opened, " ") != strings.Join(tc.wantOpened, " ") {
+				t.Fatalf("opened %v, want %v", opened, tc.wantOpened)
+			}
+			if slept != tc.wantSlept {
+				t.Fatalf("waited %s, want %s", slept, tc.wantSlept)
+			}
+		})
+	}
+}
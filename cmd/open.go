@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// newOpenCmd creates the open command, which opens the console for several profiles at once.
+func newOpenCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var dest, service, account, role string
+	var flags workflowFlags
+
+	openCmd := &cobra.Command{
+		Use:   "open [profile|@group|bookmark...]",
+		Short: "Open the AWS Console for one or more profiles",
+		Long: `Opens the AWS Console for each profile given as an argument or with a repeated
+--profile. The profiles are signed in concurrently, each with its own settings, and
+every console opens in a new browser window. Profiles that share an SSO session
+log in once. A bookmark opens its page as its profile (see 'aws-console bookmarks'),
+and @group opens every profile of a group defined under groups in the aws-console
+config file.
+
+The console keeps one session per browser profile unless multi-session support is
+enabled, so give each profile its own container (--container '{profile}') or
+browser profile to keep them signed in side by side. A profile that fails does not
+stop the others; every failure is reported at the end.
+
+--account opens an account by its ID instead of a profile name, as the configured
+profile that signs in to it, with --role choosing between several. When no profile
+does, the access portals of the SSO sessions you are signed in to are searched for the
+account, by ID or name, and its role is signed in to with the settings of a profile
+of that session, without adding a profile to the AWS config.`,
+		ValidArgsFunction: completeOpenArgs(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if account != "" {
+				if len(args) > 0 || len(profileFlagValues(cmd.Flags())) > 0 {
+					return i18n.Errorf("--account cannot be combined with profiles")
+				}
+				return openAccount(cmd, account, role, dest, service, flags, deps, runner)
+			}
+			if role != "" {
+				return i18n.Errorf("--role requires --account")
+			}
+
+			file, err := loadConfigFile(deps)
+			if err != nil {
+				return err
+			}
+
+			var pages []profilePage
+			for _, name := range profileFlagValues(cmd.Flags()) {
+				pages = append(pages, profilePage{profile: name})
+			}
+			for _, arg := range args {
+				if b, ok := file.Bookmark(arg); ok {
+					if err := checkProfileExists(b.Profile, deps); err != nil {
+						return err
+					}
+					pages = append(pages, profilePage{profile: b.Profile, destination: b.Destination})
+					continue
+				}
+				names, err := expandProfiles(file, arg, deps)
+				if err != nil {
+					return err
+				}
+				for _, name := range names {
+					pages = append(pages, profilePage{profile: name})
+				}
+			}
+			return openProfiles(cmd, pages, dest, service, flags, deps, runner)
+		},
+	}
+
+	addWorkflowFlags(openCmd, &flags)
+	openCmd.Flags().StringVarP(&dest, "destination", "d", "", "Console page to open: a service (ec2, s3, cw, cloudshell, quicksight), a page such as dashboard:<name>, a console path, or a console URL")
+	openCmd.Flags().StringVar(&service, "service", "", "Console service to open, e.g. ec2 or cloudwatch (same as --destination)")
+	openCmd.Flags().StringVar(&account, "account", "", "Open this account ID, or SSO account name, instead of a profile")
+	openCmd.Flags().StringVar(&role, "role", "", "Role to sign in to the --account as, e.g. AdministratorAccess")
+	return openCmd
+}
+
+// profilePage is a profile to open the console for and, for a bookmark, the page to
+// open when no --destination or --service is given.
+type profilePage struct {
+	profile     string
+	destination string
+}
+
+// destinationOr returns the page to open: dest or service when either is given,
+// otherwise the bookmarked page, if any.
+func (p profilePage) destinationOr(dest, service string) string {
+	if dest != "" || service != "" {
+		return dest
+	}
+	return p.destination
+}
+
+// completeOpenArgs completes profile, group, and bookmark names.
+func completeOpenArgs(deps runDeps) cobra.CompletionFunc {
+	profiles, groups, bookmarks := completeProfiles(deps), completeGroups(deps), completeBookmarks(deps)
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names, directive := profiles(cmd, args, toComplete)
+		more, _ := groups(cmd, args, toComplete)
+		names = append(names, more...)
+		more, _ = bookmarks(cmd, args, toComplete)
+		return append(names, more...), directive
+	}
+}
+
+// completeGroups completes the names of groups in the config file, with their prefix.
+func completeGroups(deps runDeps) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		file, err := config.LoadFile(deps.configFile)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, group := range slices.Sorted(maps.Keys(file.Groups)) {
+			name := config.GroupPrefix + group
+			if strings.HasPrefix(name, toComplete) && !slices.Contains(args, name) {
+				names = append(names, name+"\tgroup: "+strings.Join(file.Groups[group], " "))
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// expandProfiles returns the configured profiles that name, a profile, an alias, or
+// an @group, stands for.
+func expandProfiles(file *config.File, name string, deps runDeps) ([]string, error) {
+	profiles, err := file.Expand(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, i18n.Errorf("group %q has no profiles", name)
+	}
+	for _, profile := range profiles {
+		if err := checkProfileExists(profile, deps); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// openTarget is the request to open one profile's console, with the context and
+// dependencies resolved for that profile.
+type openTarget struct {
+	ctx  context.Context
+	opts workflowOptions
+	deps runDeps
+}
+
+// resolveOpenTarget resolves the settings of the profile currently selected on cmd into a
+// request to open the console.
+func resolveOpenTarget(cmd *cobra.Command, dest, service string, flags workflowFlags, deps runDeps) (openTarget, error) {
+	g, err := resolveWorkflowGlobals(cmd, deps)
+	if err != nil {
+		return openTarget{}, err
+	}
+	if dest == "" && service == "" {
+		dest = g.destination
+	}
+	// With --regions, the destination is pinned to each region as it opens.
+	region := g.region
+	if len(flags.regions) > 0 {
+		region = ""
+	}
+	path, err := rootDestination(dest, service, g.partition, region)
+	if err != nil {
+		return openTarget{}, err
+	}
+	opts, err := flags.options(g.profile, path)
+	if err != nil {
+		return openTarget{}, err
+	}
+	if opts.browser.private {
+		// Checked before signing in, since the browser settings are only known now.
+		if err := browser.Validate(browser.Options{Browser: g.browser, Profile: g.browserProfile, Container: g.container, Private: true}); err != nil {
+			return openTarget{}, err
+		}
+	}
+	ctx, deps := g.apply(context.Background(), deps)
+	return openTarget{ctx: ctx, opts: opts, deps: deps}, nil
+}
+
+// openProfiles opens the console for each of pages, or for the resolved profile when
+// none is given. Several profiles are opened concurrently, each in a new window.
+func openProfiles(cmd *cobra.Command, pages []profilePage, dest, service string, flags workflowFlags, deps runDeps, runner workflowRunner) error {
+	if len(pages) <= 1 {
+		pageDest := dest
+		if len(pages) == 1 {
+			if err := setProfileFlag(cmd.Flags(), pages[0].profile); err != nil {
+				return err
+			}
+			pageDest = pages[0].destinationOr(dest, service)
+		}
+		t, err := resolveOpenTarget(cmd, pageDest, service, flags, deps)
+		if err != nil {
+			return err
+		}
+		return runner(t.ctx, t.opts, t.deps)
+	}
+
+	if deps.login != nil {
+		deps.login = sharedLogin(deps)
+	}
+
+	var targets []openTarget
+	seen := map[profilePage]bool{}
+	for _, page := range pages {
+		if err := setProfileFlag(cmd.Flags(), page.profile); err != nil {
+			return err
+		}
+		t, err := resolveOpenTarget(cmd, page.destinationOr(dest, service), service, flags, deps)
+		if err != nil {
+			return i18n.Errorf("%s: %w", page.profile, err)
+		}
+		// Aliases can name the same profile twice, while bookmarks can open several
+		// pages of one profile.
+		key := profilePage{profile: t.opts.profile, destination: t.opts.destination}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		t.opts.browser.newWindow = true
+		targets = append(targets, t)
+	}
+	return runConcurrently(targets, runner, deps)
+}
+
+// openAccount opens the console for the profile or SSO role that gives access to account.
+func openAccount(cmd *cobra.Command, account, role, dest, service string, flags workflowFlags, deps runDeps, runner workflowRunner) error {
+	target, err := findAccount(context.Background(), account, role, deps)
+	if err != nil {
+		return err
+	}
+	return openAccountTarget(cmd, target, dest, service, flags, deps, runner)
+}
+
+// openAccountTarget opens the console for target, a configured profile or an SSO role.
+func openAccountTarget(cmd *cobra.Command, target accountTarget, dest, service string, flags workflowFlags, deps runDeps, runner workflowRunner) error {
+	if err := setProfileFlag(cmd.Flags(), target.profile); err != nil {
+		return err
+	}
+	t, err := resolveOpenTarget(cmd, dest, service, flags, deps)
+	if err != nil {
+		return err
+	}
+	if target.role == nil {
+		verbosef(deps, "Opening %s", describeProfile(target.profile))
+		return runner(t.ctx, t.opts, t.deps)
+	}
+
+	verbosef(deps, "Opening %s from the SSO access portal with the settings of %s", target.name(), describeProfile(target.profile))
+	t.ctx = awslib.WithSSORole(t.ctx, *target.role)
+	t.opts.profile = target.name()
+	// Signing in again refreshes the token of the SSO session the role belongs to.
+	if login := t.deps.login; login != nil {
+		t.deps.login = func(ctx context.Context, _ string) error { return login(ctx, target.profile) }
+	}
+	return runner(t.ctx, t.opts, t.deps)
+}
+
+// runConcurrently runs the workflow of every target at once. Output lines are prefixed
+// with the profile, except sign-in URLs printed for another program, and the failures of
+// all targets are returned together.
+func runConcurrently(targets []openTarget, runner workflowRunner, deps runDeps) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make([]error, len(targets))
+
+	for i, t := range targets {
+		prefix := fmt.Sprintf("[%s] ", t.opts.profile)
+		stdout := &lineWriter{mu: &mu, w: deps.stdout, prefix: prefix}
+		if t.opts.print || printOnly(t.deps) {
+			stdout.prefix = ""
+		}
+		stderr := &lineWriter{mu: &mu, w: deps.stderr, prefix: prefix}
+		t.deps.stdout, t.deps.stderr = stdout, stderr
+
+		wg.Add(1)
+		go func(i int, t openTarget) {
+			defer wg.Done()
+			err := runner(t.ctx, t.opts, t.deps)
+			stdout.flush()
+			stderr.flush()
+			if err != nil {
+				errs[i] = i18n.Errorf("%s: %w", describeProfile(t.opts.profile), err)
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return i18n.Errorf("failed to open %d of %d profiles:\n%w", failed, len(targets), errors.Join(errs...))
+}
+
+// sharedLogin wraps deps.login so concurrent workflows log in one at a time and at most
+// once per SSO session: profiles sharing a session reuse the first login's result.
+func sharedLogin(deps runDeps) func(context.Context, string) error {
+	var mu sync.Mutex
+	results := map[string]error{}
+	login := deps.login
+
+	return func(ctx context.Context, profile string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		key := profile
+		if p, ok := ssoProfile(profile, deps); ok {
+			key = ssocache.Key(p.SSOSession, p.SSOStartURL)
+		}
+		if err, ok := results[key]; ok {
+			return err
+		}
+		err := login(ctx, profile)
+		results[key] = err
+		return err
+	}
+}
+
+// lineWriter writes whole lines to w with prefix, holding mu so lines written by
+// concurrent workflows do not interleave.
+type lineWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := l.writeLine(l.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		l.buf = l.buf[i+1:]
+	}
+}
+
+// flush writes a final line that did not end in a newline.
+func (l *lineWriter) flush() {
+	if len(l.buf) > 0 {
+		l.writeLine(append(l.buf, '\n'))
+		l.buf = nil
+	}
+}
+
+func (l *lineWriter) writeLine(line []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := fmt.Fprintf(l.w, "%s%s", l.prefix, line)
+	return err
+}
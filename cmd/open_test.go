@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestOpenProfiles(t *testing.T) {
+	t.Parallel()
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	contents := `
+profiles:
+  staging:
+    destination: cloudwatch
+aliases:
+  stg: staging
+groups:
+  pre-prod: [stg, dev]
+  all: ["@pre-prod", prod]
+bookmarks:
+  prod-billing:
+    profile: prod
+    destination: billing/home
+  stg-logs:
+    profile: stg
+    destination: cloudwatch/home#logsV2:log-groups
+`
+	if err := os.WriteFile(configFile, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	profiles := []awslib.Profile{{Name: "prod"}, {Name: "staging"}, {Name: "dev"}}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		failProfile   string
+		wantOpened    map[string]string
+		wantErrSubstr string
+		wantStderr    string
+	}{
+		{
+			name:       "repeated profile flag",
+			args:       []string{"--profile", "prod", "--profile", "staging"},
+			wantOpened: map[string]string{"prod": "", "staging": "cloudwatch/home"},
+			wantStderr: "[prod] running\n",
+		},
+		{
+			name:       "open with aliases and duplicates",
+			args:       []string{"open", "prod", "stg", "staging", "dev"},
+			wantOpened: map[string]string{"prod": "", "staging": "cloudwatch/home", "dev": ""},
+			wantStderr: "[dev] running\n",
+		},
+		{
+			name:       "destination applies to every profile",
+			args:       []string{"open", "prod", "dev", "--destination", "ec2"},
+			wantOpened: map[string]string{"prod": "ec2/home", "dev": "ec2/home"},
+		},
+		{
+			name:          "failures are aggregated",
+			args:          []string{"open", "prod", "staging", "dev"},
+			failProfile:   "staging",
+			wantOpened:    map[string]string{"prod": "", "staging": "cloudwatch/home", "dev": ""},
+			wantErrSubstr: "failed to open 1 of 3 profiles:\nprofile \"staging\": boom",
+		},
+		{
+			name:       "groups",
+			args:       []string{"open", "@all", "dev"},
+			wantOpened: map[string]string{"prod": "", "staging": "cloudwatch/home", "dev": ""},
+		},
+		{
+			name:       "group as the root argument",
+			args:       []string{"@pre-prod", "-d", "s3"},
+			wantOpened: map[string]string{"staging": "s3/home", "dev": "s3/home"},
+		},
+		{
+			name:          "unknown group",
+			args:          []string{"open", "@qa"},
+			wantErrSubstr: `unknown group "@qa"`,
+		},
+		{
+			name:          "group where one profile is expected",
+			args:          []string{"url", "@all"},
+			wantErrSubstr: "@all is a group of profiles",
+		},
+		{
+			name:          "unknown profile",
+			args:          []string{"open", "prod", "qa"},
+			wantErrSubstr: `profile "qa" not found in AWS config`,
+		},
+		{
+			name:          "incognito is checked before signing in",
+			args:          []string{"open", "dev", "--incognito", "--container", "work"},
+			wantErrSubstr: "containers are not available in private windows",
+		},
+		{
+			name:       "bookmarks",
+			args:       []string{"open", "prod-billing", "stg-logs", "dev"},
+			wantOpened: map[string]string{"prod": "billing/home", "staging": "cloudwatch/home#logsV2:log-groups", "dev": ""},
+		},
+		{
+			name:       "destination overrides a bookmark",
+			args:       []string{"open", "prod-billing", "--service", "s3"},
+			wantOpened: map[string]string{"prod": "s3/home"},
+		},
+		{
+			name:       "single profile",
+			args:       []string{"open", "dev"},
+			wantOpened: map[string]string{"dev": ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			opened := map[string]string{}
+			newWindows := 0
+			stderr := &bytes.Buffer{}
+			deps := runDeps{
+				profiles:   &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) { return profiles, nil }},
+				configFile: configFile,
+				stdout:     &bytes.Buffer{},
+				stderr:     stderr,
+			}
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				mu.Lock()
+				opened[opts.profile] = opts.destination
+				if opts.browser.newWindow {
+					newWindows++
+				}
+				mu.Unlock()
+				fmt.Fprintln(deps.stderr, "running")
+				if opts.profile == tc.failProfile {
+					return errors.New("boom")
+				}
+				return nil
+			})
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(opened) != len(tc.wantOpened) {
+				t.Fatalf("expected %v to be opened, got %v", tc.wantOpened, opened)
+			}
+			for profile, dest := range tc.wantOpened {
+				if got, ok := opened[profile]; !ok || got != dest {
+					t.Fatalf("expected %v to be opened, got %v", tc.wantOpened, opened)
+				}
+			}
+			if len(opened) > 1 && newWindows != len(opened) {
+				t.Fatalf("expected every profile to open in a new window, got %d of %d", newWindows, len(opened))
+			}
+			if !strings.Contains(stderr.String(), tc.wantStderr) {
+				t.Fatalf("expected stderr to contain %q, got %q", tc.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestOpenProfilesTimings(t *testing.T) {
+	t.Parallel()
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("telemetry:\n  endpoint: http://127.0.0.1:1\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	profiles := []awslib.Profile{{Name: "prod"}, {Name: "dev"}}
+
+	var mu sync.Mutex
+	seen := map[*timings]string{}
+	deps := runDeps{
+		profiles:   &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) { return profiles, nil }},
+		configFile: configFile,
+		stdout:     &bytes.Buffer{},
+		stderr:     &bytes.Buffer{},
+	}
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		deps.timings.start("sts")()
+		mu.Lock()
+		defer mu.Unlock()
+		seen[deps.timings] = opts.profile
+		return nil
+	})
+	root.SetArgs([]string{"open", "prod", "dev"})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(seen) != 2 || seen[nil] != "" {
+		t.Fatalf("expected each profile to record its own timings, got %v", seen)
+	}
+}
+
+func TestRepeatedProfileRejectedOutsideOpen(t *testing.T) {
+	t.Parallel()
+
+	root := newRootCmd(runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}, nil)
+	root.SetArgs([]string{"whoami", "--profile", "prod", "--profile", "dev"})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--profile can only be repeated when opening the console") {
+		t.Fatalf("expected repeated --profile to be rejected, got %v", err)
+	}
+}
+
+func TestSharedLogin(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var logins []string
+	deps := runDeps{
+		profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) {
+			return []awslib.Profile{
+				{Name: "prod", Source: awslib.ProfileSourceSSO, SSOSession: "corp"},
+				{Name: "staging", Source: awslib.ProfileSourceSSO, SSOSession: "corp"},
+				{Name: "sandbox", Source: awslib.ProfileSourceSSO, SSOSession: "lab"},
+			}, nil
+		}},
+		login: func(ctx context.Context, profile string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			logins = append(logins, profile)
+			return nil
+		},
+	}
+
+	login := sharedLogin(deps)
+	var wg sync.WaitGroup
+	for _, profile := range []string{"prod", "staging", "sandbox", "prod"} {
+		wg.Add(1)
+		go func(profile string) {
+			defer wg.Done()
+			if err := login(context.Background(), profile); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(profile)
+	}
+	wg.Wait()
+
+	sessions := map[string]bool{}
+	for _, profile := range logins {
+		if profile == "sandbox" {
+			sessions["lab"] = true
+		} else {
+			sessions["corp"] = true
+		}
+	}
+	if len(logins) != 2 || len(sessions) != 2 {
+		t.Fatalf("expected one login per SSO session, got %v", logins)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	out := &bytes.Buffer{}
+	w := &lineWriter{mu: &mu, w: out, prefix: "[dev] "}
+	fmt.Fprint(w, "Opening ")
+	fmt.Fprint(w, "console...\nDone\npartial")
+	if got := out.String(); got != "[dev] Opening console...\n[dev] Done\n" {
+		t.Fatalf("expected only complete lines to be written, got %q", got)
+	}
+	w.flush()
+	if got := out.String(); !strings.HasSuffix(got, "[dev] partial\n") {
+		t.Fatalf("expected flush to write the partial line, got %q", got)
+	}
+}
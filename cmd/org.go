@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/spf13/cobra"
+)
+
+func newOrgCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var list bool
+	var flags workflowFlags
+
+	orgCmd := &cobra.Command{
+		Use:   "org [account]",
+		Short: "Open the console of an account of your AWS organization",
+		Long: `Lists the member accounts of the organization through AWS Organizations, with the
+credentials of the selected profile, which must sign in to the management account or
+a delegated administrator. Pick an account, or name one by ID or name, to assume its
+OrganizationAccountAccessRole and open its console.
+
+Accounts created by the organization trust the management account to assume
+OrganizationAccountAccessRole. Pass --role-name, or set org-role, for accounts that
+trust another role.`,
+		Example: `  aws-console org -p management
+  aws-console org -p management Production --role-name ReadOnly
+  aws-console org -p management --list -o csv`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.assumeRole.RoleARN != "" {
+				return i18n.Errorf("--role-arn cannot be combined with org; pass --role-name to choose the role assumed in the account")
+			}
+			if list && len(args) > 0 {
+				return i18n.Errorf("--list takes no account")
+			}
+			if !list && len(args) == 0 && (deps.picker == nil || !deps.term.Interactive()) {
+				return i18n.Errorf("pick an account in an interactive terminal, or name one: aws-console org <account>")
+			}
+
+			if list {
+				g, err := resolveWorkflowGlobals(cmd, deps)
+				if err != nil {
+					return err
+				}
+				ctx, deps := g.apply(context.Background(), deps)
+				accounts, _, err := listOrgAccounts(ctx, g.profile, deps)
+				if err != nil {
+					return err
+				}
+				return renderOrgAccounts(g.output, accounts, deps)
+			}
+
+			t, err := resolveOpenTarget(cmd, "", "", flags, deps)
+			if err != nil {
+				return err
+			}
+			accounts, identity, err := listOrgAccounts(t.ctx, t.opts.profile, t.deps)
+			if err != nil {
+				return err
+			}
+
+			var account awslib.OrganizationAccount
+			if len(args) == 1 {
+				account, err = findOrgAccount(accounts, args[0])
+			} else {
+				account, err = pickOrgAccount(accounts, t.deps)
+			}
+			if err != nil {
+				return err
+			}
+
+			// The profile already signs in to its own account; there is no role to assume.
+			if account.ID == identity.Account {
+				verbosef(t.deps, "Opening account %s as %s", account.ID, describeProfile(t.opts.profile))
+				return runner(t.ctx, t.opts, t.deps)
+			}
+			partition := cmp.Or(awslib.PartitionFromContext(t.ctx), identity.Partition, "aws")
+			roleARN, err := awslib.RoleARN(partition, account.ID, t.deps.orgRole)
+			if err != nil {
+				return i18n.Errorf("invalid org-role: %w", err)
+			}
+			verbosef(t.deps, "Opening account %s by assuming %s", account.ID, roleARN)
+			t.opts.assumeRole.RoleARN = roleARN
+			return runner(t.ctx, t.opts, t.deps)
+		},
+	}
+
+	orgCmd.Flags().BoolVar(&list, "list", false, "List the accounts of the organization instead of opening one")
+	orgCmd.Flags().String("role-name", "", "Role to assume in the account (default "+awslib.DefaultOrganizationRole+")")
+	addWorkflowFlags(orgCmd, &flags)
+	return orgCmd
+}
+
+// listOrgAccounts signs in with profile and returns the accounts of its organization,
+// ordered by name, with the identity signed in as.
+func listOrgAccounts(ctx context.Context, profile string, deps runDeps) ([]awslib.OrganizationAccount, awslib.Identity, error) {
+	identity, err := authenticate(ctx, profile, deps)
+	if err != nil {
+		return nil, awslib.Identity{}, err
+	}
+	verbosef(deps, "Listing the accounts of the organization of %s", identity.Account)
+	accounts, err := deps.awsService.ListAccounts(ctx, profile)
+	if awslib.ClassifyError(err) == awslib.ErrorKindAccessDenied {
+		return nil, awslib.Identity{}, i18n.Errorf("%s may not list the accounts of its organization; use a profile of the management account or a delegated administrator: %w", describeProfile(profile), err)
+	}
+	if err != nil {
+		return nil, awslib.Identity{}, err
+	}
+	slices.SortFunc(accounts, func(a, b awslib.OrganizationAccount) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return accounts, identity, nil
+}
+
+// findOrgAccount returns the account of accounts whose ID or name is account.
+func findOrgAccount(accounts []awslib.OrganizationAccount, account string) (awslib.OrganizationAccount, error) {
+	i := slices.IndexFunc(accounts, func(a awslib.OrganizationAccount) bool {
+		return a.ID == account || strings.EqualFold(a.Name, account)
+	})
+	if i < 0 {
+		return awslib.OrganizationAccount{}, i18n.Errorf("no account %s in the organization; list them with 'aws-console org --list'", account)
+	}
+	if !accounts[i].Active() {
+		return awslib.OrganizationAccount{}, i18n.Errorf("account %s (%s) is %s and cannot be signed in to", accounts[i].Name, accounts[i].ID, strings.ToLower(accounts[i].State))
+	}
+	return accounts[i], nil
+}
+
+// pickOrgAccount asks which of the active accounts to open.
+func pickOrgAccount(accounts []awslib.OrganizationAccount, deps runDeps) (awslib.OrganizationAccount, error) {
+	var active []awslib.OrganizationAccount
+	var items []string
+	for _, a := range accounts {
+		if a.Active() {
+			active = append(active, a)
+			items = append(items, fmt.Sprintf("%s (%s) %s", a.Name, a.ID, a.Email))
+		}
+	}
+	if len(active) == 0 {
+		return awslib.OrganizationAccount{}, i18n.Errorf("no active accounts in the organization")
+	}
+	i, err := deps.picker.Pick("account", items)
+	if errors.Is(err, prompt.ErrCanceled) {
+		return awslib.OrganizationAccount{}, i18n.Errorf("no account selected")
+	}
+	if err != nil {
+		return awslib.OrganizationAccount{}, i18n.Errorf("failed to select an account: %w", err)
+	}
+	return active[i], nil
+}
+
+func renderOrgAccounts(format output.Format, accounts []awslib.OrganizationAccount, deps runDeps) error {
+	table := output.Table{
+		Columns: []output.Column{
+			{Header: "ACCOUNT", Key: "account"},
+			{Header: "NAME", Key: "name"},
+			{Header: "EMAIL", Key: "email"},
+			{Header: "STATE", Key: "state"},
+		},
+	}
+	for _, a := range accounts {
+		table.Rows = append(table.Rows, []string{a.ID, a.Name, a.Email, a.State})
+	}
+	return output.Render(deps.stdout, format, table)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func orgTestService() *mocks.Service {
+	return &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return awslib.Identity{Arn: "arn:aws:sts::111111111111:assumed-role/Admin/me", Account: "111111111111", Partition: "aws"}, nil
+		},
+		ListAccountsFunc: func(ctx context.Context, profile string) ([]awslib.OrganizationAccount, error) {
+			return []awslib.OrganizationAccount{
+				{ID: "333333333333", Name: "Staging", Email: "staging@example.com", State: "ACTIVE"},
+				{ID: "111111111111", Name: "Management", Email: "root@example.com", State: "ACTIVE"},
+				{ID: "444444444444", Name: "Legacy", Email: "legacy@example.com", State: "SUSPENDED"},
+				{ID: "222222222222", Name: "Production", Email: "prod@example.com", State: "ACTIVE"},
+			}, nil
+		},
+	}
+}
+
+func TestOrgCmdList(t *testing.T) {
+	t.Parallel()
+
+	deps := runDeps{awsService: orgTestService(), term: interactiveTerminal}
+	out, err := executeSubcommand(t, deps, "org", "-p", "management", "--list", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `account,name,email,state
+444444444444,Legacy,legacy@example.com,SUSPENDED
+111111111111,Management,root@example.com,ACTIVE
+222222222222,Production,prod@example.com,ACTIVE
+333333333333,Staging,staging@example.com,ACTIVE
+`
+	if out != want {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestOrgCmdOpen(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		interactive   bool
+		choice        int
+		wantRoleARN   string
+		wantErrSubstr string
+	}{
+		{name: "picked", interactive: true, choice: 1, wantRoleARN: "arn:aws:iam::222222222222:role/OrganizationAccountAccessRole"},
+		{name: "named", args: []string{"staging"}, wantRoleARN: "arn:aws:iam::333333333333:role/OrganizationAccountAccessRole"},
+		{name: "role name", args: []string{"222222222222", "--role-name", "ops/ReadOnly"}, wantRoleARN: "arn:aws:iam::222222222222:role/ops/ReadOnly"},
+		{name: "own account", args: []string{"Management"}},
+		{name: "suspended", args: []string{"Legacy"}, wantErrSubstr: "account Legacy (444444444444) is suspended"},
+		{name: "unknown", args: []string{"Sandbox"}, wantErrSubstr: "no account Sandbox in the organization"},
+		{name: "invalid role name", args: []string{"Staging", "--role-name", "Read Only"}, wantErrSubstr: `invalid org-role: invalid role name "Read Only"`},
+		{name: "role ARN", args: []string{"Staging", "--role-arn", "arn:aws:iam::333333333333:role/Admin"}, wantErrSubstr: "--role-arn cannot be combined with org"},
+		{name: "not interactive", wantErrSubstr: "pick an account in an interactive terminal"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			picker := &fakePicker{choice: tc.choice}
+			deps := runDeps{awsService: orgTestService(), picker: picker, stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+			if tc.interactive {
+				deps.term = interactiveTerminal
+			}
+
+			var opened *workflowOptions
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				opened = &opts
+				return nil
+			})
+			root.SetArgs(append([]string{"org", "-p", "management"}, tc.args...))
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opened == nil || opened.profile != "management" || opened.assumeRole.RoleARN != tc.wantRoleARN {
+				t.Fatalf("expected management to open with role %q, got %+v", tc.wantRoleARN, opened)
+			}
+			if tc.interactive && (len(picker.items) != 3 || picker.items[1] != "Production (222222222222) prod@example.com") {
+				t.Fatalf("expected only the active accounts to be offered, got %q", picker.items)
+			}
+		})
+	}
+}
+
+func TestOrgCmdNotManagementAccount(t *testing.T) {
+	t.Parallel()
+
+	service := orgTestService()
+	service.ListAccountsFunc = func(ctx context.Context, profile string) ([]awslib.OrganizationAccount, error) {
+		return nil, &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "You don't have permissions to access this resource."}
+	}
+	deps := runDeps{awsService: service, term: interactiveTerminal}
+	_, err := executeSubcommand(t, deps, "org", "-p", "member", "--list")
+	if err == nil || !strings.Contains(err.Error(), "use a profile of the management account or a delegated administrator") {
+		t.Fatalf("expected a hint to use the management account, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/eculver/aws-console/pkg/destination"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/spf13/cobra"
+)
+
+// maxPageChoices bounds the console pages offered when a query is ambiguous.
+const maxPageChoices = 10
+
+// setPositionalArgs reads the arguments of 'aws-console [profile] [service [page]]':
+// a first argument that is a configured profile selects it, as setPositionalProfile
+// does, and the words after it name a console page. A single word that is neither a
+// profile nor a page is reported as an unknown profile, and one that is only a page
+// needs a profile from elsewhere.
+func setPositionalArgs(cmd *cobra.Command, args []string, deps runDeps) ([]string, error) {
+	profile, err := isProfileArg(args[0], deps)
+	if err != nil {
+		return nil, err
+	}
+	if profile || (len(args) == 1 && len(destination.Search(args)) == 0) {
+		return args[1:], setPositionalProfile(cmd, args[0], deps)
+	}
+	if len(args) == 1 {
+		// A mistyped profile can match a page too; it must not open that page with the
+		// default credentials unless the user can pick a profile for it.
+		chosen, err := profileChosen(cmd, deps)
+		if err != nil {
+			return nil, err
+		}
+		if !chosen && (deps.picker == nil || !deps.term.Interactive()) {
+			return nil, i18n.Errorf("%q is not a profile; to open it as a console page, pass --profile or set AWS_PROFILE", args[0])
+		}
+	}
+	return args, nil
+}
+
+// profileChosen reports whether a profile is given by --profile, AWS_PROFILE, or the
+// config file, or credentials are exported in the environment instead.
+func profileChosen(cmd *cobra.Command, deps runDeps) (bool, error) {
+	file, err := loadConfigFile(deps)
+	if err != nil {
+		return false, err
+	}
+	values, _ := resolveSettings(cmd.Flags(), file, deps)
+	profile := settingValue(values, settingProfile)
+	return profile != "" || envCredentials(profile), nil
+}
+
+// isProfileArg reports whether name, or the profile it is an alias for, is a
+// configured profile. Without a shared config reader every name is a profile.
+func isProfileArg(name string, deps runDeps) (bool, error) {
+	if deps.profiles == nil {
+		return true, nil
+	}
+	file, err := loadConfigFile(deps)
+	if err != nil {
+		return false, err
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return false, i18n.Errorf("failed to list profiles: %w", err)
+	}
+	_, ok := profileByName(profiles)[file.Alias(name)]
+	return ok, nil
+}
+
+// pagePath finds the console page named by the words of query in the catalog. The
+// closest match is used when it is closer than any other; otherwise the user picks
+// one of those tied for closest.
+func pagePath(query []string, deps runDeps) (string, error) {
+	matches := destination.Search(query)
+	words := strings.Join(query, " ")
+	if len(matches) == 0 {
+		return "", i18n.Errorf("no console page matches %q (give a service such as s3 or ec2, optionally followed by a page such as buckets or instances)", words)
+	}
+	tied := 1
+	for tied < len(matches) && tied < maxPageChoices && matches[tied].Score == matches[0].Score {
+		tied++
+	}
+	if tied == 1 {
+		verbosef(deps, "Opening the %s page for %q", matches[0].Page.Title(), words)
+		return matches[0].Page.Destination, nil
+	}
+
+	items := make([]string, 0, tied)
+	for _, m := range matches[:tied] {
+		items = append(items, m.Page.Title())
+	}
+	if deps.picker == nil || !deps.term.Interactive() {
+		return "", i18n.Errorf("%q matches several console pages: %s; give more of the name", words, strings.Join(items, ", "))
+	}
+	i, err := deps.picker.Pick("console page", items)
+	if errors.Is(err, prompt.ErrCanceled) {
+		return "", i18n.Errorf("no console page selected")
+	}
+	if err != nil {
+		return "", i18n.Errorf("failed to select a console page: %w", err)
+	}
+	return matches[i].Page.Destination, nil
+}
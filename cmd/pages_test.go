@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/prompt"
+)
+
+func TestNewRootCmdPositionalPage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		args            []string
+		interactive     bool
+		picker          *fakePicker
+		wantProfile     string
+		wantDestination string
+		wantChoices     []string
+		wantErrSubstr   string
+	}{
+		{name: "service", args: []string{"-p", "dev", "s3"}, wantProfile: "dev", wantDestination: "s3/home"},
+		{name: "service without a profile", args: []string{"s3"}, wantErrSubstr: `"s3" is not a profile; to open it as a console page, pass --profile or set AWS_PROFILE`},
+		{name: "service with a profile to pick", args: []string{"s3"}, interactive: true, picker: &fakePicker{choice: 0}, wantProfile: "dev", wantDestination: "s3/home"},
+		{name: "service and page", args: []string{"dynamodb", "tables"}, wantDestination: "dynamodbv2/home#tables"},
+		{name: "profile and page", args: []string{"dev", "ec2", "inst"}, wantProfile: "dev", wantDestination: "ec2/home#Instances:"},
+		{name: "profile flag and typo", args: []string{"--profile", "keys", "lamda"}, wantProfile: "keys", wantDestination: "lambda/home"},
+		{name: "group and page", args: []string{"@dev", "s3"}, wantProfile: "dev", wantDestination: "s3/home"},
+		{
+			name:            "ambiguous page picked",
+			args:            []string{"-p", "dev", "ec"},
+			interactive:     true,
+			picker:          &fakePicker{choice: 2},
+			wantProfile:     "dev",
+			wantDestination: "ecs/v2/home",
+			wantChoices:     []string{"ec2", "ecr", "ecs"},
+		},
+		{name: "ambiguous page picking canceled", args: []string{"-p", "dev", "ec"}, interactive: true, picker: &fakePicker{err: prompt.ErrCanceled}, wantErrSubstr: "no console page selected"},
+		{name: "ambiguous page outside a terminal", args: []string{"-p", "dev", "cloud"}, wantErrSubstr: `"cloud" matches several console pages: cloudformation, cloudfront, cloudshell, cloudtrail, cloudwatch`},
+		{name: "unknown page", args: []string{"dev", "nothing", "here"}, wantErrSubstr: `no console page matches "nothing here"`},
+		{name: "page and destination", args: []string{"-p", "dev", "s3", "--service", "ec2"}, wantErrSubstr: "cannot be given both as arguments and with --destination or --service"},
+		{name: "self-test", args: []string{"-p", "dev", "s3", "--self-test"}, wantErrSubstr: `--self-test opens no console page; "s3" is not a profile`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configFile, []byte("groups:\n  dev: [dev]\n"), 0o600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+			var opts workflowOptions
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return testProfiles(), nil
+					},
+				},
+				configFile: configFile,
+				stdout:     &bytes.Buffer{},
+				stderr:     &bytes.Buffer{},
+			}
+			if tc.interactive {
+				deps.term = interactiveTerminal
+			}
+			if tc.picker != nil {
+				deps.picker = tc.picker
+			}
+
+			root := newRootCmd(deps, func(ctx context.Context, o workflowOptions, deps runDeps) error {
+				opts = o
+				return nil
+			})
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opts.profile != tc.wantProfile || opts.destination != tc.wantDestination {
+				t.Fatalf("expected %s %s, got %s %s", tc.wantProfile, tc.wantDestination, opts.profile, opts.destination)
+			}
+			if tc.wantChoices != nil && strings.Join(tc.picker.items, ",") != strings.Join(tc.wantChoices, ",") {
+				t.Fatalf("expected choices %v, got %v", tc.wantChoices, tc.picker.items)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/spf13/cobra"
+)
+
+// resolveWorkflowGlobals resolves the persistent flags for a command that opens the
+// console. When no profile is given and the terminal is interactive, the user picks one
+// from the shared config instead of silently falling back to the default credential chain.
+// Credentials exported in the environment are used as they are.
+func resolveWorkflowGlobals(cmd *cobra.Command, deps runDeps) (globalOptions, error) {
+	g, err := resolveGlobals(cmd, deps)
+	if err != nil || g.profile != "" || deps.picker == nil || deps.profiles == nil || !deps.term.Interactive() || envCredentials(g.profile) {
+		return g, err
+	}
+
+	profile, err := pickProfile(deps)
+	if err != nil || profile == "" {
+		return g, err
+	}
+	if err := cmd.Flags().Set("profile", profile); err != nil {
+		return g, err
+	}
+	// Re-resolve so the picked profile's region and other settings apply.
+	return resolveGlobals(cmd, deps)
+}
+
+// pickProfile asks the user to choose a configured profile. With no profiles configured
+// it returns "" so the default credential chain is used.
+func pickProfile(deps runDeps) (string, error) {
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return "", i18n.Errorf("failed to list profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return "", nil
+	}
+
+	items := make([]string, 0, len(profiles))
+	for _, p := range profiles {
+		items = append(items, profileLabel(p))
+	}
+	i, err := deps.picker.Pick("profile", items)
+	if errors.Is(err, prompt.ErrCanceled) {
+		return "", i18n.Errorf("no profile selected (use --profile or AWS_PROFILE)")
+	}
+	if err != nil {
+		return "", i18n.Errorf("failed to select a profile: %w", err)
+	}
+	return profiles[i].Name, nil
+}
+
+// profileLabel describes a profile in the picker, so searches can match its account
+// and role as well as its name.
+func profileLabel(p awslib.Profile) string {
+	return fmt.Sprintf("%s (%s)", p.Name, profileDetails(p))
+}
+
+// profileDetails summarizes a profile's type, account, role, and region.
+func profileDetails(p awslib.Profile) string {
+	details := []string{p.Source}
+	for _, detail := range []string{p.AccountID, p.RoleName, p.Region} {
+		if detail != "" {
+			details = append(details, detail)
+		}
+	}
+	return strings.Join(details, ", ")
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+// fakePicker answers every prompt with a fixed choice and records what it was shown.
+type fakePicker struct {
+	choice int
+	err    error
+	items  []string
+}
+
+func (p *fakePicker) Pick(title string, items []string) (int, error) {
+	p.items = items
+	return p.choice, p.err
+}
+
+func TestRootCmdPicksProfile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		term          term.Info
+		picker        *fakePicker
+		wantProfile   string
+		wantRegion    string
+		wantPrompted  bool
+		wantErrSubstr string
+	}{
+		{
+			name:         "picks on an interactive terminal",
+			term:         interactiveTerminal,
+			picker:       &fakePicker{choice: 0},
+			wantProfile:  "dev",
+			wantRegion:   "us-west-2",
+			wantPrompted: true,
+		},
+		{
+			name:        "profile flag skips the picker",
+			args:        []string{"-p", "keys"},
+			term:        interactiveTerminal,
+			picker:      &fakePicker{choice: 0},
+			wantProfile: "keys",
+		},
+		{
+			name:   "piped output skips the picker",
+			term:   term.Info{StdinTTY: true},
+			picker: &fakePicker{choice: 0},
+		},
+		{
+			name:          "canceled",
+			term:          interactiveTerminal,
+			picker:        &fakePicker{err: prompt.ErrCanceled},
+			wantPrompted:  true,
+			wantErrSubstr: "no profile selected",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured *workflowOptions
+			var region string
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) {
+					return testProfiles(), nil
+				}},
+				picker: tc.picker,
+				term:   tc.term,
+				stdout: &bytes.Buffer{},
+				stderr: &bytes.Buffer{},
+			}
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				captured = &opts
+				region = awslib.RegionFromContext(ctx)
+				return nil
+			})
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if prompted := tc.picker.items != nil; prompted != tc.wantPrompted {
+				t.Fatalf("expected prompted=%v, got %v", tc.wantPrompted, prompted)
+			}
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if captured == nil || captured.profile != tc.wantProfile {
+				t.Fatalf("unexpected workflow options: %+v", captured)
+			}
+			if tc.wantRegion != "" && region != tc.wantRegion {
+				t.Fatalf("expected the picked profile's region %q, got %q", tc.wantRegion, region)
+			}
+		})
+	}
+}
+
+func TestProfileLabel(t *testing.T) {
+	t.Parallel()
+
+	profiles := testProfiles()
+	want := []string{
+		"dev (sso, 123456789012, AdministratorAccess, us-west-2)",
+		"keys (static)",
+	}
+	for i, p := range profiles {
+		if got := profileLabel(p); got != want[i] {
+			t.Fatalf("profileLabel(%s) = %q, want %q", p.Name, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/console"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// credentialPolicyTimeout bounds a credential-policy command.
+const credentialPolicyTimeout = 30 * time.Second
+
+// credentialPolicy decides which credentials may be federated, for teams that do not
+// allow some kinds of credentials, such as long-lived access keys, to be used.
+type credentialPolicy struct {
+	// strict refuses long-lived IAM user access keys, unless they assume a role.
+	strict bool
+	// forbidden are the credential sources that are refused.
+	forbidden []awslib.CredentialSource
+	// command, when set, is run through the platform shell for each set of
+	// credentials, and refuses them by exiting non-zero.
+	command string
+}
+
+// parseForbiddenSources reads the forbid-credential-sources setting, a comma-separated
+// list of credential sources.
+func parseForbiddenSources(value string) ([]awslib.CredentialSource, error) {
+	var sources []awslib.CredentialSource
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		source, err := awslib.ParseCredentialSource(name)
+		if err != nil {
+			return nil, i18n.Errorf("invalid forbid-credential-sources: %w", err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// check refuses the credentials described by c when the policy does not allow them.
+func (p credentialPolicy) check(ctx context.Context, c console.PolicyCheck, deps runDeps) error {
+	// Keys that only assume a role are allowed: the console session is the role's, and
+	// temporary.
+	if p.strict && c.LongLived && c.Strategy != console.StrategyAssumeRole {
+		return i18n.Errorf("strict mode refuses to federate the long-lived IAM user access keys of %s: "+
+			"static keys never expire, so a leaked key keeps working until someone notices and rotates it. "+
+			"Sign in with IAM Identity Center (aws configure sso), use a profile that assumes a role, or pass --role-arn instead",
+			describeProfile(c.Profile))
+	}
+	if slices.Contains(p.forbidden, c.Source) {
+		return i18n.Errorf("credentials of %s come from %s, which the forbid-credential-sources setting does not allow", describeProfile(c.Profile), c.Source)
+	}
+	if p.command == "" {
+		return nil
+	}
+
+	verbosef(deps, "Running credential-policy: %s", p.command)
+	env := append(os.Environ(),
+		"AWS_CONSOLE_PROFILE="+c.Profile,
+		"AWS_CONSOLE_ACCOUNT="+c.Identity.Account,
+		"AWS_CONSOLE_ARN="+c.Identity.Arn,
+		"AWS_CONSOLE_CREDENTIAL_SOURCE="+string(c.Source),
+		"AWS_CONSOLE_CREDENTIAL_PROVIDER="+c.Provider,
+		"AWS_CONSOLE_LONG_LIVED="+strconv.FormatBool(c.LongLived),
+		"AWS_CONSOLE_STRATEGY="+string(c.Strategy),
+	)
+	policyCtx, cancel := context.WithTimeout(ctx, credentialPolicyTimeout)
+	defer cancel()
+	var reason bytes.Buffer
+	name, args := shellCommand(deps.goos, p.command)
+	if err := deps.executor.RunContext(policyCtx, name, args, env, nil, &reason, deps.stderr); err != nil {
+		if msg := strings.TrimSpace(reason.String()); msg != "" {
+			return i18n.Errorf("credential-policy refused the credentials of %s: %s", describeProfile(c.Profile), msg)
+		}
+		return i18n.Errorf("credential-policy refused the credentials of %s: %w", describeProfile(c.Profile), err)
+	}
+	return nil
+}
+
+// allowsCached reports whether cached credentials can be used without checking them
+// again. Their source is not cached, so only strict mode can tell them apart: it
+// refuses those that were federated from IAM user access keys.
+func (p credentialPolicy) allowsCached(creds awslib.Credentials) bool {
+	if len(p.forbidden) > 0 || p.command != "" {
+		return false
+	}
+	if p.strict {
+		return creds.Kind != awslib.CredentialKindSessionToken && creds.Kind != awslib.CredentialKindFederationToken
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/console"
+)
+
+func TestParseForbiddenSources(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseForbiddenSources(" keys, Environment,,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got, []awslib.CredentialSource{awslib.CredentialSourceKeys, awslib.CredentialSourceEnvironment}) {
+		t.Fatalf("unexpected sources %v", got)
+	}
+	if got, err := parseForbiddenSources(""); err != nil || got != nil {
+		t.Fatalf("expected no sources, got %v, %v", got, err)
+	}
+	if _, err := parseForbiddenSources("keys,static"); err == nil || !strings.Contains(err.Error(), `invalid forbid-credential-sources: unknown credential source "static"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCredentialPolicyCheck(t *testing.T) {
+	t.Parallel()
+
+	keys := console.PolicyCheck{
+		Profile:   "dev",
+		Identity:  awslib.Identity{Arn: "arn:aws:iam::123456789012:user/alice", Account: "123456789012"},
+		Provider:  "SharedConfigCredentials: /home/alice/.aws/credentials",
+		Source:    awslib.CredentialSourceKeys,
+		LongLived: true,
+		Strategy:  console.StrategySessionToken,
+	}
+	assumeRole := keys
+	assumeRole.Strategy = console.StrategyAssumeRole
+	sso := console.PolicyCheck{
+		Profile:  "dev",
+		Identity: awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Dev/alice", Account: "123456789012"},
+		Provider: "SSOProvider",
+		Source:   awslib.CredentialSourceSSO,
+		Strategy: console.StrategyDirect,
+	}
+
+	testCases := []struct {
+		name          string
+		policy        credentialPolicy
+		check         console.PolicyCheck
+		runOutput     string
+		runErr        error
+		wantCommand   bool
+		wantErrSubstr string
+	}{
+		{name: "no policy", check: keys},
+		{name: "strict refuses keys", policy: credentialPolicy{strict: true}, check: keys, wantErrSubstr: "strict mode refuses to federate the long-lived IAM user access keys of profile \"dev\": static keys never expire"},
+		{name: "strict allows sso", policy: credentialPolicy{strict: true}, check: sso},
+		{name: "strict allows keys that assume a role", policy: credentialPolicy{strict: true}, check: assumeRole},
+		{name: "forbidden source", policy: credentialPolicy{forbidden: []awslib.CredentialSource{awslib.CredentialSourceKeys}}, check: keys, wantErrSubstr: "come from keys, which the forbid-credential-sources setting does not allow"},
+		{name: "other source", policy: credentialPolicy{forbidden: []awslib.CredentialSource{awslib.CredentialSourceKeys}}, check: sso},
+		{name: "command allows", policy: credentialPolicy{command: "check-creds"}, check: sso, wantCommand: true},
+		{name: "command refuses with a reason", policy: credentialPolicy{command: "check-creds"}, check: keys, runOutput: "access keys are not allowed in production\n", runErr: errors.New("exit status 1"), wantCommand: true, wantErrSubstr: "credential-policy refused the credentials of profile \"dev\": access keys are not allowed in production"},
+		{name: "command refuses silently", policy: credentialPolicy{command: "check-creds"}, check: keys, runErr: errors.New("exit status 1"), wantCommand: true, wantErrSubstr: "credential-policy refused the credentials of profile \"dev\": exit status 1"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			executor := &fakeExecutor{runOutput: tc.runOutput, runErr: tc.runErr}
+			deps := runDeps{executor: executor, goos: "linux", stderr: &bytes.Buffer{}}
+			err := tc.policy.check(context.Background(), tc.check, deps)
+			if (len(executor.calls) == 1) != tc.wantCommand {
+				t.Fatalf("unexpected commands %+v", executor.calls)
+			}
+			if tc.wantCommand {
+				call := executor.calls[0]
+				if !slices.Equal(call.args, []string{"-c", "check-creds"}) {
+					t.Fatalf("unexpected command %v", call.args)
+				}
+				for _, v := range []string{"AWS_CONSOLE_PROFILE=dev", "AWS_CONSOLE_ACCOUNT=123456789012", "AWS_CONSOLE_CREDENTIAL_SOURCE=" + string(tc.check.Source), "AWS_CONSOLE_STRATEGY=" + string(tc.check.Strategy)} {
+					if !slices.Contains(call.env, v) {
+						t.Fatalf("expected %s in the environment of the command", v)
+					}
+				}
+			}
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCredentialPolicyAllowsCached(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		policy credentialPolicy
+		kind   awslib.CredentialKind
+		want   bool
+	}{
+		{name: "no policy", kind: awslib.CredentialKindSessionToken, want: true},
+		{name: "strict with a role", policy: credentialPolicy{strict: true}, kind: awslib.CredentialKindRole, want: true},
+		{name: "strict with a session token", policy: credentialPolicy{strict: true}, kind: awslib.CredentialKindSessionToken},
+		{name: "strict with a federation token", policy: credentialPolicy{strict: true}, kind: awslib.CredentialKindFederationToken},
+		{name: "forbidden sources", policy: credentialPolicy{forbidden: []awslib.CredentialSource{awslib.CredentialSourceSSO}}, kind: awslib.CredentialKindRole},
+		{name: "command", policy: credentialPolicy{command: "check-creds"}, kind: awslib.CredentialKindRole},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.policy.allowsCached(awslib.Credentials{Kind: tc.kind}); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRunWorkflowStrict(t *testing.T) {
+	t.Parallel()
+
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/alice", Account: "123456789012"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret", Source: "SharedConfigCredentials: /home/alice/.aws/credentials"}, nil
+		},
+	}
+	deps := runDeps{
+		awsService:      service,
+		federation:      &mocks.FederationBuilder{},
+		executor:        &fakeExecutor{},
+		goos:            "linux",
+		stdin:           strings.NewReader(""),
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		now:             time.Now,
+		sessionDuration: sessionDuration,
+		policy:          credentialPolicy{strict: true},
+	}
+
+	err := runWorkflow(context.Background(), workflowOptions{profile: "dev", print: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "strict mode refuses") {
+		t.Fatalf("expected strict mode to refuse the keys, got %v", err)
+	}
+	if service.GetSessionTokenCalls.Load() != 0 {
+		t.Fatal("expected no session token for refused keys")
+	}
+}
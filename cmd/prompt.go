@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// Formats of the prompt command.
+const (
+	promptPlain         = "plain"
+	promptStarship      = "starship"
+	promptPowerlevel10k = "powerlevel10k"
+)
+
+// States of a prompt segment, which the powerlevel10k format leads with so that the
+// segment can be colored by them.
+const (
+	promptOK      = "OK"
+	promptWarn    = "WARN"
+	promptExpired = "EXPIRED"
+)
+
+func newPromptCmd(deps runDeps) *cobra.Command {
+	var format string
+
+	promptCmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print the active profile and its credential expiry for a shell prompt",
+		Long: `Prints the active profile, from --profile or AWS_PROFILE with the aliases of the
+config file resolved, and how long its cached credentials have left, such as
+"prod 42m", for a shell prompt segment. Nothing is printed when no profile is active.
+
+Only the config files and the credential cache are read: no SSO token cache and no
+STS calls, so it takes a few milliseconds and can run on every prompt. With
+credential-store keychain or server, the cache is read from that store, which takes
+longer. A profile without cached credentials is printed on its own.
+
+  plain          prod 42m, or prod expired
+  starship       prod 42m, with ⚠ under 15 minutes and ✗ once expired
+  powerlevel10k  OK, WARN, or EXPIRED, then the plain text, for p10k segment -s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch format {
+			case promptPlain, promptStarship, promptPowerlevel10k:
+			default:
+				return i18n.Errorf("invalid --format %q: expected %s, %s, or %s", format, promptPlain, promptStarship, promptPowerlevel10k)
+			}
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			if g.profile == "" {
+				return nil
+			}
+			_, deps := g.apply(context.Background(), deps)
+
+			var expires time.Time
+			if deps.credentials != nil {
+				expires, _ = deps.credentials.Expires(g.profile, "")
+			}
+			fmt.Fprintln(deps.stdout, formatPrompt(g.profile, expires, deps.now(), format))
+			return nil
+		},
+	}
+
+	promptCmd.Flags().StringVar(&format, "format", promptPlain, "Output format: plain, starship, or powerlevel10k")
+	return promptCmd
+}
+
+// formatPrompt describes profile, whose cached credentials expire at expires (zero when
+// none are cached), at now.
+func formatPrompt(profile string, expires, now time.Time, format string) string {
+	state, remaining := promptOK, ""
+	if !expires.IsZero() {
+		left := expires.Sub(now)
+		switch {
+		case left <= 0:
+			state, remaining = promptExpired, "expired"
+		case left < time.Minute:
+			state, remaining = promptWarn, "<1m"
+		default:
+			remaining = strings.TrimSuffix(left.Truncate(time.Minute).String(), "0s")
+			if left < remainingWarn {
+				state = promptWarn
+			}
+		}
+	}
+	text := strings.TrimSpace(profile + " " + remaining)
+
+	switch format {
+	case promptStarship:
+		switch state {
+		case promptWarn:
+			return profile + " ⚠ " + remaining
+		case promptExpired:
+			return profile + " ✗"
+		}
+	case promptPowerlevel10k:
+		return state + " " + text
+	}
+	return text
+}
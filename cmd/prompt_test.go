@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/credcache"
+)
+
+func TestPromptCmd(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := credcache.NewCacheAt(t.TempDir())
+	for profile, left := range map[string]time.Duration{"prod": 42 * time.Minute, "dev": 10 * time.Minute, "old": -time.Minute} {
+		creds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(left)}
+		if err := cache.PutCredentials(profile, "", awslib.Identity{}, creds); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		want          string
+		wantErrSubstr string
+	}{
+		{name: "plain", args: []string{"-p", "prod"}, want: "prod 42m\n"},
+		{name: "expired", args: []string{"-p", "old"}, want: "old expired\n"},
+		{name: "not cached", args: []string{"-p", "staging"}, want: "staging\n"},
+		{name: "starship", args: []string{"-p", "prod", "--format", "starship"}, want: "prod 42m\n"},
+		{name: "starship warning", args: []string{"-p", "dev", "--format", "starship"}, want: "dev ⚠ 10m\n"},
+		{name: "starship expired", args: []string{"-p", "old", "--format", "starship"}, want: "old ✗\n"},
+		{name: "powerlevel10k", args: []string{"-p", "prod", "--format", "powerlevel10k"}, want: "OK prod 42m\n"},
+		{name: "powerlevel10k warning", args: []string{"-p", "dev", "--format", "powerlevel10k"}, want: "WARN dev 10m\n"},
+		{name: "powerlevel10k expired", args: []string{"-p", "old", "--format", "powerlevel10k"}, want: "EXPIRED old expired\n"},
+		{name: "invalid format", args: []string{"-p", "prod", "--format", "zsh"}, wantErrSubstr: `invalid --format "zsh"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{credentials: cache, now: func() time.Time { return now }}
+			out, err := executeSubcommand(t, deps, append([]string{"prompt"}, tc.args...)...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, out)
+			}
+		})
+	}
+}
+
+func TestPromptCmdConfigFile(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cacheDir := t.TempDir()
+	store := secretStore{}
+	creds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(42 * time.Minute)}
+	if err := credcache.NewStoreCache(cacheDir, store).PutCredentials("prod-admin", "", awslib.Identity{}, creds); err != nil {
+		t.Fatal(err)
+	}
+	var secret []byte
+	for _, v := range store {
+		secret = v
+	}
+
+	// The alias and the keychain holding the credentials of its profile both come from
+	// the config file.
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("aliases:\n  prod: prod-admin\nprofiles:\n  prod-admin:\n    credential-store: keychain\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	executor := &fakeExecutor{runOutput: base64.StdEncoding.EncodeToString(secret)}
+	deps := runDeps{
+		configFile:  configFile,
+		cacheDir:    cacheDir,
+		credentials: credcache.NewCacheAt(cacheDir),
+		executor:    executor,
+		goos:        "darwin",
+		now:         func() time.Time { return now },
+	}
+	out, err := executeSubcommand(t, deps, "prompt", "-p", "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "prod-admin 42m\n" {
+		t.Fatalf("expected %q, got %q", "prod-admin 42m\n", out)
+	}
+	if len(executor.calls) != 1 || executor.calls[0].name != "security" {
+		t.Fatalf("expected the credentials read from the keychain, got %+v", executor.calls)
+	}
+}
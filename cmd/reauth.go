@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+const (
+	defaultReauthListen = "127.0.0.1:17345"
+	reauthPath          = "/reauth"
+	// reauthTokenFile, in the state directory, holds the token of the running
+	// reauth-server, which login URLs include in their Issuer.
+	reauthTokenFile = "reauth-token"
+)
+
+func newReauthServerCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var listen string
+
+	reauthCmd := &cobra.Command{
+		Use:   "reauth-server",
+		Short: "Serve the link the console follows when a session expires",
+		Long: `Runs a local server that signs in again when the console's "log back in" link
+is followed after a session expires. Point aws-console at it with --reauth-url
+(or AWS_CONSOLE_REAUTH_URL), e.g. http://127.0.0.1:17345/reauth; login URLs then
+name it as the Issuer, and the console links there with the profile and page the
+session was opened with. The server runs the usual sign-in for that profile and
+redirects the browser to the new console session.
+
+The server only listens on loopback addresses and only serves profiles from the
+shared AWS config. Each run picks a random token that requests must carry, so other
+web pages cannot make it sign in; it is kept in the state directory, where sessions
+opened with --reauth-url find it, and printed with the URL.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			host, _, err := net.SplitHostPort(listen)
+			if err != nil {
+				return i18n.Errorf("invalid --listen address %q: %w", listen, err)
+			}
+			if !loopbackHost(host) {
+				return i18n.Errorf("invalid --listen address %q: must be a loopback address", listen)
+			}
+
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+
+			token, err := newReauthToken(deps)
+			if err != nil {
+				return err
+			}
+			defer removeReauthToken(deps)
+
+			listener, err := net.Listen("tcp", listen)
+			if err != nil {
+				return i18n.Errorf("failed to listen on %s: %w", listen, err)
+			}
+			server := &http.Server{
+				Handler:           reauthHandler(ctx, token, deps, runner),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			go func() {
+				<-ctx.Done()
+				server.Close()
+			}()
+
+			deps.messages.Fprintf(deps.stderr, "Serving console re-authentication on http://%s%s?token=%s (Ctrl-C to stop)\n", listener.Addr(), reauthPath, token)
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return i18n.Errorf("re-authentication server failed: %w", err)
+			}
+			return nil
+		},
+	}
+
+	reauthCmd.Flags().StringVar(&listen, "listen", defaultReauthListen, "Loopback address to listen on")
+	return reauthCmd
+}
+
+// reauthHandler signs in again for the profile named in the request and redirects the
+// browser to the new login URL instead of opening another window. Requests without
+// token are refused.
+func reauthHandler(ctx context.Context, token string, deps runDeps, runner workflowRunner) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(reauthPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// Only loopback hostnames are accepted so a page on another site cannot reach
+		// the server through DNS rebinding.
+		if host, _, err := net.SplitHostPort(r.Host); err != nil || !loopbackHost(host) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		// Any web page can send the browser here; only the Issuer of a session opened by
+		// aws-console knows the token.
+		if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(token)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		profile := r.URL.Query().Get("profile")
+		if err := checkReauthProfile(profile, deps); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var loginURL string
+		requestDeps := deps
+		requestDeps.term.StdoutTTY = true
+		// The browser is redirected by this response, which a local redirect would wait on.
+		requestDeps.localRedirect = false
+		requestDeps.stdout = deps.stderr
+		requestDeps.open = func(targetURL string, opts browserOptions) error {
+			loginURL = targetURL
+			return nil
+		}
+
+		opts := workflowOptions{profile: profile, destination: r.URL.Query().Get("destination")}
+		deps.messages.Fprintf(deps.stderr, "Re-authenticating %s\n", describeProfile(profile))
+		if err := runner(ctx, opts, requestDeps); err != nil {
+			fmt.Fprintf(deps.stderr, "Error: %v\n", err)
+			http.Error(w, fmt.Sprintf("sign-in failed: %v", err), http.StatusBadGateway)
+			return
+		}
+		if loginURL == "" {
+			http.Error(w, "sign-in did not produce a console URL", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, loginURL, http.StatusFound)
+	})
+	return mux
+}
+
+// checkReauthProfile accepts only configured profiles, so requests cannot name
+// arbitrary profiles or fall back to AWS_PROFILE.
+func checkReauthProfile(profile string, deps runDeps) error {
+	if profile == "" {
+		return i18n.Errorf("missing profile")
+	}
+	if deps.profiles == nil {
+		return i18n.Errorf("profile %q not found in AWS config", profile)
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return i18n.Errorf("failed to list profiles: %w", err)
+	}
+	if _, ok := profileByName(profiles)[profile]; !ok {
+		return i18n.Errorf("profile %q not found in AWS config", profile)
+	}
+	return nil
+}
+
+func loopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newReauthToken picks the token of a reauth-server run and, when there is a state
+// directory, saves it there for the sessions the server signs in again.
+func newReauthToken(deps runDeps) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", i18n.Errorf("failed to generate a re-authentication token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	if deps.stateDir == "" {
+		return token, nil
+	}
+	if err := os.MkdirAll(deps.stateDir, 0o700); err != nil {
+		return "", i18n.Errorf("failed to save the re-authentication token: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(deps.stateDir, reauthTokenFile), []byte(token+"\n"), 0o600); err != nil {
+		return "", i18n.Errorf("failed to save the re-authentication token: %w", err)
+	}
+	return token, nil
+}
+
+// removeReauthToken forgets the token of a reauth-server that stopped.
+func removeReauthToken(deps runDeps) {
+	if deps.stateDir != "" {
+		_ = os.Remove(filepath.Join(deps.stateDir, reauthTokenFile))
+	}
+}
+
+// reauthToken returns the token of the running reauth-server, or "" when none saved one.
+func reauthToken(deps runDeps) string {
+	if deps.stateDir == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(deps.stateDir, reauthTokenFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// reauthIssuer is the Issuer for a session of profile on destination: the reauth URL
+// with both added to its query, and token, when the server saved one, in place of any
+// the URL carries.
+func reauthIssuer(reauthURL, token, profile, destination string) string {
+	u, err := url.Parse(reauthURL)
+	if err != nil {
+		return reauthURL
+	}
+	query := u.Query()
+	if token != "" {
+		query.Set("token", token)
+	}
+	query.Set("profile", profile)
+	if destination != "" {
+		query.Set("destination", destination)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// validateReauthURL checks that reauthURL points at a loopback re-authentication server.
+func validateReauthURL(reauthURL string) error {
+	u, err := url.Parse(reauthURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !loopbackHost(u.Hostname()) {
+		return i18n.Errorf("invalid reauth URL %q (expected a loopback URL such as http://%s%s)", reauthURL, defaultReauthListen, reauthPath)
+	}
+	return nil
+}
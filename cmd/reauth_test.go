@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestReauthHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		method       string
+		target       string
+		host         string
+		runErr       error
+		wantStatus   int
+		wantLocation string
+		wantOpts     *workflowOptions
+	}{
+		{
+			name:         "redirects to a new login URL",
+			target:       "/reauth?token=secret&profile=dev&destination=ec2%2Fhome",
+			host:         "127.0.0.1:17345",
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://signin.aws.amazon.com/federation?Action=login",
+			wantOpts:     &workflowOptions{profile: "dev", destination: "ec2/home"},
+		},
+		{
+			name:       "unknown profile",
+			target:     "/reauth?token=secret&profile=nope",
+			host:       "localhost:17345",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing profile",
+			target:     "/reauth?token=secret",
+			host:       "localhost:17345",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing token",
+			target:     "/reauth?profile=dev",
+			host:       "127.0.0.1:17345",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "wrong token",
+			target:     "/reauth?token=guess&profile=dev",
+			host:       "127.0.0.1:17345",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "non-loopback host",
+			target:     "/reauth?token=secret&profile=dev",
+			host:       "attacker.example.com:17345",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "post",
+			method:     http.MethodPost,
+			target:     "/reauth?token=secret&profile=dev",
+			host:       "127.0.0.1:17345",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "sign-in failure",
+			target:     "/reauth?token=secret&profile=dev",
+			host:       "127.0.0.1:17345",
+			runErr:     errors.New("SSO login failed"),
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured *workflowOptions
+			runner := func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				captured = &opts
+				if tc.runErr != nil {
+					return tc.runErr
+				}
+				return deps.open("https://signin.aws.amazon.com/federation?Action=login", opts.browser)
+			}
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return testProfiles(), nil
+					},
+				},
+				open: func(string, browserOptions) error {
+					t.Fatal("unexpected browser launch")
+					return nil
+				},
+				stdout: &bytes.Buffer{},
+				stderr: &bytes.Buffer{},
+			}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.target, nil)
+			req.Host = tc.host
+			rec := httptest.NewRecorder()
+			reauthHandler(context.Background(), "secret", deps, runner).ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Location"); got != tc.wantLocation {
+				t.Fatalf("expected Location %q, got %q", tc.wantLocation, got)
+			}
+			if tc.wantOpts != nil && (captured == nil || captured.profile != tc.wantOpts.profile || captured.destination != tc.wantOpts.destination) {
+				t.Fatalf("unexpected workflow options: %+v", captured)
+			}
+		})
+	}
+}
+
+func TestReauthIssuer(t *testing.T) {
+	t.Parallel()
+
+	got := reauthIssuer("http://127.0.0.1:17345/reauth", "", "prod-admin", "ec2/home?region=us-east-1")
+	want := "http://127.0.0.1:17345/reauth?destination=ec2%2Fhome%3Fregion%3Dus-east-1&profile=prod-admin"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	got = reauthIssuer("http://127.0.0.1:17345/reauth?token=stale", "secret", "dev", "")
+	if want := "http://127.0.0.1:17345/reauth?profile=dev&token=secret"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestValidateReauthURL(t *testing.T) {
+	t.Parallel()
+
+	for _, valid := range []string{"http://127.0.0.1:17345/reauth", "http://localhost:9000/reauth", "http://[::1]:17345/reauth"} {
+		if err := validateReauthURL(valid); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"https://reauth.example.com/reauth", "127.0.0.1:17345", "ftp://localhost/reauth"} {
+		if err := validateReauthURL(invalid); err == nil || !strings.Contains(err.Error(), "invalid reauth URL") {
+			t.Fatalf("expected %q to be rejected, got %v", invalid, err)
+		}
+	}
+}
+
+func TestRunWorkflowUsesReauthIssuer(t *testing.T) {
+	t.Parallel()
+
+	var issuer string
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				issuer = awslib.IssuerFromContext(ctx)
+				return "https://example.com/console-login", nil
+			},
+		},
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		term:            interactiveTerminal,
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+		reauthURL:       "http://127.0.0.1:17345/reauth",
+		stateDir:        t.TempDir(),
+	}
+	token, err := newReauthToken(deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "dev", destination: "s3/home"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "http://127.0.0.1:17345/reauth?destination=s3%2Fhome&profile=dev&token=" + token; issuer != want {
+		t.Fatalf("expected issuer %q, got %q", want, issuer)
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// recordingPath returns the fixture file federation exchanges are recorded to when
+// AWS_CONSOLE_RECORD is set: a timestamped file under the state directory for "1", or
+// the path it names. Empty, or a false value such as "0", records nothing.
+func recordingPath(deps runDeps) (string, error) {
+	value := os.Getenv(awslib.RecordEnv)
+	if value == "" {
+		return "", nil
+	}
+	record, err := strconv.ParseBool(value)
+	if err != nil {
+		return paths.ExpandHome(value)
+	}
+	if !record {
+		return "", nil
+	}
+	name := "federation-" + deps.now().UTC().Format("20060102T150405Z") + ".json"
+	return filepath.Join(deps.stateDir, "fixtures", name), nil
+}
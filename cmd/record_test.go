@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/spf13/cobra"
+)
+
+func TestRecordingPath(t *testing.T) {
+	stateDir := t.TempDir()
+	deps := runDeps{stateDir: stateDir, now: func() time.Time { return time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC) }}
+
+	for value, want := range map[string]string{
+		"":                     "",
+		"0":                    "",
+		"false":                "",
+		"1":                    filepath.Join(stateDir, "fixtures", "federation-20260301T093000Z.json"),
+		"testdata/signin.json": "testdata/signin.json",
+	} {
+		t.Setenv(awslib.RecordEnv, value)
+		if got, err := recordingPath(deps); err != nil || got != want {
+			t.Fatalf("%s=%q: got %q, %v; want %q", awslib.RecordEnv, value, got, err, want)
+		}
+	}
+}
+
+func TestRecordFederationExchanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"SigninToken":"live-token"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "signin.json")
+	t.Setenv(awslib.RecordEnv, path)
+	stderr := &bytes.Buffer{}
+	deps := runDeps{federation: awslib.NewFederationClient(), stdout: &bytes.Buffer{}, stderr: stderr}
+
+	var gotErr error
+	root := newRootCmd(deps, nil)
+	billing, _, err := root.Find([]string{"billing"})
+	if err != nil {
+		t.Fatalf("failed to find billing command: %v", err)
+	}
+	billing.RunE = func(cmd *cobra.Command, args []string) error {
+		g, err := resolveGlobals(cmd, deps)
+		if err != nil {
+			return err
+		}
+		ctx, deps := g.apply(context.Background(), deps)
+		creds := awslib.Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}
+		_, gotErr = deps.federation.BuildConsoleURL(awslib.WithFederationEndpoint(ctx, server.URL), creds, 3600, "")
+		return nil
+	}
+	root.SetArgs([]string{"billing"})
+	if err := root.Execute(); err != nil || gotErr != nil {
+		t.Fatalf("unexpected error: %v, %v", err, gotErr)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"SigninToken\":\"REDACTED\"`) || strings.Contains(string(data), "secret") {
+		t.Fatalf("expected a sanitized recording, got %s, %v", data, err)
+	}
+	if !strings.Contains(stderr.String(), "Recording sanitized federation exchanges to "+path) {
+		t.Fatalf("expected the recording to be reported, got %q", stderr)
+	}
+}
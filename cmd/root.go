@@ -1,23 +1,75 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/eculver/aws-console/pkg/accounts"
+	"github.com/eculver/aws-console/pkg/audit"
 	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/browserprofiles"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/console"
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/daemon"
+	"github.com/eculver/aws-console/pkg/destination"
+	"github.com/eculver/aws-console/pkg/history"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/logging"
+	"github.com/eculver/aws-console/pkg/notify"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/paths"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/eculver/aws-console/pkg/qr"
+	"github.com/eculver/aws-console/pkg/redirect"
+	"github.com/eculver/aws-console/pkg/secrets"
+	"github.com/eculver/aws-console/pkg/sessions"
+	"github.com/eculver/aws-console/pkg/shortlink"
+	"github.com/eculver/aws-console/pkg/sso"
+	"github.com/eculver/aws-console/pkg/term"
+	"github.com/eculver/aws-console/pkg/usage"
 	"github.com/spf13/cobra"
 )
 
-const sessionDuration = 43200 // 12 hours (max for federation)
+const sessionDuration = int32(awslib.MaxSessionDuration / time.Second)
+
+// localRedirectTimeout bounds how long a one-time sign-in redirect waits for the browser.
+const localRedirectTimeout = 2 * time.Minute
+
+// commandWaitDelay is how long a command killed by RunContext may keep its output open.
+const commandWaitDelay = 5 * time.Second
 
 // Executor abstracts command execution for easier testing.
 type Executor interface {
 	Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+	// RunContext is Run, but the command is killed when ctx is done. A non-nil env is
+	// the command's whole environment; otherwise it inherits that of aws-console.
+	RunContext(ctx context.Context, name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
 	Start(name string, args []string) error
+	// Exec runs name in the foreground with env as its whole environment and returns
+	// its exit code.
+	Exec(name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error)
+}
+
+// Notifier shows desktop notifications.
+type Notifier interface {
+	Notify(title, message string) error
 }
 
 type osExecutor struct{}
@@ -30,24 +82,360 @@ func (osExecutor) Run(name string, args []string, stdin io.Reader, stdout io.Wri
 	return cliCmd.Run()
 }
 
+func (osExecutor) RunContext(ctx context.Context, name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	cliCmd := exec.CommandContext(ctx, name, args...)
+	cliCmd.Env = env
+	cliCmd.Stdin = stdin
+	cliCmd.Stdout = stdout
+	cliCmd.Stderr = stderr
+	// A killed command may leave children holding its output open.
+	cliCmd.WaitDelay = commandWaitDelay
+	err := cliCmd.Run()
+	if err != nil && ctx.Err() != nil {
+		return i18n.Errorf("%s was stopped: %w", name, ctx.Err())
+	}
+	return err
+}
+
 func (osExecutor) Start(name string, args []string) error {
 	return exec.Command(name, args...).Start()
 }
 
+func (osExecutor) Exec(name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	child := exec.Command(name, args...)
+	child.Env = env
+	child.Stdin = stdin
+	child.Stdout = stdout
+	child.Stderr = stderr
+	if err := child.Start(); err != nil {
+		return 0, err
+	}
+
+	// An interrupt from the terminal reaches the child too, so it is only caught, to
+	// outlive the child and report its exit code. Signals sent to aws-console alone are
+	// forwarded.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig != os.Interrupt {
+					_ = child.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := child.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// A child killed by a signal has no exit code of its own.
+		return max(exitErr.ExitCode(), 1), nil
+	}
+	return 0, err
+}
+
 type runDeps struct {
-	awsService      awslib.Service
-	federation      awslib.FederationURLBuilder
-	login           func(string) error
-	open            func(string) error
-	executor        Executor
-	goos            string
+	awsService  awslib.Service
+	federation  awslib.FederationURLBuilder
+	profiles    awslib.ProfileLister
+	usage       *usage.Store
+	history     *history.Store
+	accounts    *accounts.Cache
+	sessions    *sessions.Store
+	credentials *credcache.Cache
+	// ssoTokens is the SSO token cache shared with the AWS CLI.
+	ssoTokens   *ssocache.Cache
+	ssoSessions awslib.SSOSessionReader
+	// deviceLogin runs the SSO device authorization flow for an OIDC client.
+	deviceLogin func(context.Context, sso.ClientConfig, func(sso.Authorization)) (ssocache.Token, error)
+	// ssoPortal returns the SSO access portal of the sso_region it is given.
+	ssoPortal func(string) sso.PortalAPI
+	cacheDir  string
+	stateDir  string
+	// daemon reaches a running aws-console daemon for credentials; nil never tries.
+	daemon *daemon.Client
+	// configFile is the aws-console config file; empty reads none.
+	configFile string
+	now        func() time.Time
+	login      func(context.Context, string) error
+	open       func(string, browserOptions) error
+	copy       func(string) error
+	// notifier shows desktop notifications; nil shows none.
+	notifier Notifier
+	// sessionPolicy, when set, limits the permissions of console sessions.
+	sessionPolicy *awslib.SessionPolicy
+	// hooks are shell commands run before and after the console is opened.
+	hooks config.Hooks
+	// audit sends a record of each console opened; nil sends none.
+	audit *audit.Emitter
+	// mfaSource is the password manager item or YubiKey account MFA codes are read
+	// from; nil asks for them.
+	mfaSource *secrets.Item
+	// validate is when credentials are checked before federating: validateAlways,
+	// validateRecent, or validateSkip. Empty always checks them.
+	validate string
+	// orgRole is the role 'aws-console org' assumes in member accounts.
+	orgRole string
+	// policy decides which credentials may be federated.
+	policy credentialPolicy
+	// samlProviders are the SAML identity providers of the config file, signed in to
+	// through samlTransport.
+	samlProviders map[string]config.SAMLProvider
+	samlTransport http.RoundTripper
+	// shortener, when set, exchanges sign-in URLs for short links with --shorten.
+	shortener *shortlink.Client
+	// sessionName is the template names of assumed role sessions are expanded from;
+	// empty uses awslib.DefaultSessionNameTemplate.
+	sessionName string
+	// deadline, when positive, bounds each run of the workflow up to opening the console.
+	deadline time.Duration
+	sleep    func(context.Context, time.Duration) error
+	executor Executor
+	// lookPath finds an executable on PATH, like exec.LookPath.
+	lookPath func(string) (string, error)
+	// picker chooses a profile when none is given on an interactive terminal.
+	picker prompt.Picker
+	// messages translates prompts and progress messages; nil shows them in English.
+	messages *output.Printer
+	goos     string
+	// wsl is set on Linux under the Windows Subsystem for Linux, whose browser is on
+	// the Windows side.
+	wsl             bool
+	term            term.Info
 	stdin           io.Reader
 	stdout          io.Writer
 	stderr          io.Writer
+	verbose         bool
+	debug           bool
+	timings         *timings
 	sessionDuration int32
+	// durationSet makes a sessionDuration too long for the credentials an error instead
+	// of being shortened to their limit.
+	durationSet bool
+	// reauthURL, when set, is where the console sends users whose session expired.
+	reauthURL string
+	// browser and browserProfile choose where the console opens; empty uses the default browser.
+	browser        string
+	browserProfile string
+	// container is the Firefox container setting, before placeholders are expanded.
+	container string
+	// localRedirect opens sign-in URLs through a one-time loopback redirect, so they
+	// never appear on a browser's command line.
+	localRedirect bool
+	// isolateAccounts opens the console in the account's own profile directory from
+	// browserProfiles.
+	isolateAccounts bool
+	browserProfiles *browserprofiles.Manager
+	// headless shares sign-in URLs instead of opening a browser. Unless headlessSet, it
+	// was not given, and term.Headless decides.
+	headless    bool
+	headlessSet bool
+	// printOnly prints sign-in URLs to stdout instead of opening them, as when stdout is piped.
+	printOnly bool
+}
+
+// workflowOptions describes a single request to open the console.
+type workflowOptions struct {
+	profile string
+	// destination is a console path relative to the console root; empty opens the home page.
+	destination string
+	// browser controls how the console is opened when it is not printed.
+	browser browserOptions
+	// print writes the sign-in URL to stdout instead of opening a browser.
+	print bool
+	// copy places the sign-in URL on the clipboard instead of opening a browser.
+	copy bool
+	// qr renders the sign-in URL as a QR code instead of opening a browser.
+	qr bool
+	// noCache skips the credential and sign-in token caches.
+	noCache bool
+	// stdinCreds federates a credentials document read from stdin instead of the
+	// profile's credentials.
+	stdinCreds bool
+	// saml, when set, names the SAML provider whose role credentials are federated
+	// instead of the profile's.
+	saml string
+	// webIdentity, when its role is set, federates that role, assumed with an OIDC
+	// token, instead of the profile's credentials.
+	webIdentity webIdentityOptions
+	// shorten prints, copies, or shows short, single-use links from the configured
+	// shortener instead of the sign-in URLs.
+	shorten bool
+	// skipValidate federates without checking credentials first whenever the caller
+	// identity of the profile is remembered, as with the validate setting skip.
+	skipValidate bool
+	// wait keeps the process running until the console session expires, then runs
+	// onExpiry through the shell when it is set.
+	wait     bool
+	onExpiry string
+	// keepAlive keeps the process running and opens the console again with a fresh
+	// sign-in URL shortly before each session expires.
+	keepAlive bool
+	// minValidity refreshes cached credentials that expire within it.
+	minValidity time.Duration
+	// opened, when set, is told when the console session opened by the workflow expires.
+	opened func(expires time.Time)
+	// regions, when set, opens the destination once per region with one sign-in token.
+	regions []string
+	// assumeRole, when its RoleARN is set, federates as that role instead of the profile.
+	assumeRole awslib.AssumeRoleInput
+	// readOnly limits the console session to read-only access with a session policy.
+	readOnly bool
+	// dryRun reports what would happen without requesting a sign-in token or opening anything.
+	dryRun bool
+	// forceLogout signs the browser out of the console before opening the new session.
+	forceLogout bool
+	// yes opens the console as the root user, or billing as an administrator, without
+	// asking.
+	yes bool
+	// fallback signs in with the profile's fallback profiles without asking when IAM
+	// Identity Center is down; noFallback fails instead.
+	fallback   bool
+	noFallback bool
+	// fallbackFor, set by the workflow, names the profile whose IAM Identity Center
+	// sign-in failed when opts.profile is one of its fallback profiles.
+	fallbackFor string
+	// guest, when set, names the teammate the console session is shared with.
+	guest string
+	// preflight, when set, runs once the caller identity is known and before federating.
+	// Returning an error aborts the workflow.
+	preflight func(ctx context.Context, profile string, identity awslib.Identity, deps runDeps) error
+}
+
+// workflowFlags are the flags shared by every command that opens the console.
+type workflowFlags struct {
+	browser      browserOptions
+	print        bool
+	copy         bool
+	qr           bool
+	noCache      bool
+	stdinCreds   bool
+	saml         string
+	webIdentity  webIdentityOptions
+	shorten      bool
+	skipValidate bool
+	wait         bool
+	onExpiry     string
+	keepAlive    bool
+	regions      []string
+	assumeRole   awslib.AssumeRoleInput
+	readOnly     bool
+	dryRun       bool
+	forceLogout  bool
+	yes          bool
+	fallback     bool
+	noFallback   bool
+}
+
+func addWorkflowFlags(cmd *cobra.Command, f *workflowFlags) {
+	addBrowserFlags(cmd, &f.browser)
+	cmd.Flags().BoolVar(&f.print, "print", false, "Print the sign-in URL to stdout instead of opening a browser")
+	cmd.Flags().BoolVar(&f.print, "no-open", false, "Alias for --print")
+	cmd.Flags().BoolVar(&f.copy, "copy", false, "Copy the sign-in URL to the clipboard instead of opening a browser")
+	cmd.Flags().BoolVar(&f.qr, "qr", false, "Show the sign-in URL as a QR code to scan with a phone instead of opening a browser")
+	cmd.Flags().BoolVar(&f.noCache, "no-cache", false, "Do not use or update cached credentials and sign-in tokens")
+	cmd.Flags().BoolVar(&f.stdinCreds, "stdin-creds", false, "Open the console with a credentials document read from stdin: credential_process output, or the output of aws sts assume-role")
+	cmd.Flags().StringVar(&f.saml, "saml", "", "Open the console as a role assumed with a SAML assertion from this provider in the config file")
+	addWebIdentityFlags(cmd, &f.webIdentity)
+	cmd.Flags().BoolVar(&f.shorten, "shorten", false, "Print, copy, or show a short single-use link from the shortener in the config file instead of the sign-in URL")
+	cmd.Flags().BoolVar(&f.skipValidate, "skip-validate", false, "Federate without checking credentials first when the profile was checked before, checking them only if federation fails")
+	cmd.Flags().BoolVar(&f.wait, "wait", false, "Keep running until the console session expires, then exit")
+	cmd.Flags().StringVar(&f.onExpiry, "on-expiry", "", "Shell command to run when the console session expires (implies --wait)")
+	cmd.Flags().BoolVar(&f.keepAlive, "keep-alive", false, "Keep running and open the console again with a fresh sign-in URL shortly before each session expires")
+	cmd.Flags().StringSliceVar(&f.regions, "regions", nil, "Open the console once per region, e.g. us-east-1,eu-west-1")
+	cmd.Flags().BoolVar(&f.readOnly, "read-only", false, "Limit the console session to read-only access with the ReadOnlyAccess session policy")
+	cmd.Flags().BoolVar(&f.dryRun, "dry-run", false, "Check credentials and report what would happen without requesting a sign-in token or opening anything")
+	cmd.Flags().BoolVar(&f.forceLogout, "force-logout", false, `Sign the browser out of the console first, so the new session does not meet the "You must sign out" page`)
+	cmd.Flags().BoolVarP(&f.yes, "yes", "y", false, "Open the console as the root user, or billing as an administrator role, without asking")
+	cmd.Flags().BoolVar(&f.fallback, "fallback", false, "Sign in with the profile's aws_console_fallback profiles without asking when IAM Identity Center is down")
+	cmd.Flags().BoolVar(&f.noFallback, "no-fallback", false, "Fail instead of signing in with the profile's aws_console_fallback profiles when IAM Identity Center is down")
+	addAssumeRoleFlags(cmd, &f.assumeRole)
+}
+
+// options builds the request to open path for profile.
+func (f workflowFlags) options(profile, path string) (workflowOptions, error) {
+	for _, region := range f.regions {
+		if err := destination.ValidateRegion(region); err != nil {
+			return workflowOptions{}, err
+		}
+	}
+	if err := validateAssumeRole(f.assumeRole); err != nil {
+		return workflowOptions{}, err
+	}
+	if f.keepAlive && (f.wait || f.onExpiry != "") {
+		return workflowOptions{}, i18n.Errorf("--keep-alive cannot be combined with --wait or --on-expiry")
+	}
+	if f.keepAlive && f.dryRun {
+		return workflowOptions{}, i18n.Errorf("--keep-alive cannot be combined with --dry-run")
+	}
+	if f.stdinCreds && f.keepAlive {
+		return workflowOptions{}, i18n.Errorf("--stdin-creds cannot be combined with --keep-alive, since piped credentials cannot be refreshed")
+	}
+	if f.saml != "" && (f.stdinCreds || f.keepAlive) {
+		return workflowOptions{}, i18n.Errorf("--saml cannot be combined with --stdin-creds or --keep-alive")
+	}
+	if err := f.webIdentity.validate(); err != nil {
+		return workflowOptions{}, err
+	}
+	if f.webIdentity.roleARN != "" && (f.stdinCreds || f.saml != "" || f.keepAlive) {
+		return workflowOptions{}, i18n.Errorf("--web-identity-role-arn cannot be combined with --stdin-creds, --saml, or --keep-alive")
+	}
+	if f.shorten && !f.print && !f.copy && !f.qr {
+		return workflowOptions{}, i18n.Errorf("--shorten requires --print, --copy, or --qr")
+	}
+	if f.fallback && f.noFallback {
+		return workflowOptions{}, i18n.Errorf("--fallback cannot be combined with --no-fallback")
+	}
+
+	return workflowOptions{
+		profile:      profile,
+		destination:  path,
+		browser:      f.browser,
+		print:        f.print,
+		copy:         f.copy,
+		qr:           f.qr,
+		noCache:      f.noCache,
+		stdinCreds:   f.stdinCreds,
+		saml:         f.saml,
+		webIdentity:  f.webIdentity,
+		shorten:      f.shorten,
+		skipValidate: f.skipValidate,
+		wait:         f.wait || f.onExpiry != "",
+		onExpiry:     f.onExpiry,
+		keepAlive:    f.keepAlive,
+		regions:      f.regions,
+		assumeRole:   f.assumeRole,
+		readOnly:     f.readOnly,
+		dryRun:       f.dryRun,
+		forceLogout:  f.forceLogout,
+		yes:          f.yes,
+		fallback:     f.fallback,
+		noFallback:   f.noFallback,
+	}, nil
+}
+
+// suppliedCredentials describes the credentials federated instead of the profile's,
+// or returns "" when the profile's are.
+func (o workflowOptions) suppliedCredentials() string {
+	switch {
+	case o.stdinCreds:
+		return "the credentials on stdin"
+	case o.saml != "":
+		return "the credentials of SAML provider " + o.saml
+	case o.webIdentity.roleARN != "":
+		return "the web identity credentials of " + o.webIdentity.roleARN
+	}
+	return ""
 }
 
-type workflowRunner func(ctx context.Context, profile string, deps runDeps) error
+type workflowRunner func(ctx context.Context, opts workflowOptions, deps runDeps) error
 
 // NewRootCmd creates the root CLI command.
 func NewRootCmd() *cobra.Command {
@@ -55,130 +443,1060 @@ func NewRootCmd() *cobra.Command {
 }
 
 func newRootCmd(deps runDeps, runner workflowRunner) *cobra.Command {
-	var profile string
 	var showVersion bool
+	var selfTest bool
+	var dest, service string
+	var flags workflowFlags
 
 	rootCmd := &cobra.Command{
-		Use:   "aws-console",
+		Use:   "aws-console [profile] [service [page]]",
 		Short: "Open the AWS Console in your browser using current credentials",
 		Long: `Authenticates using your AWS credentials and opens the AWS Management Console
 in your default web browser. If credentials are expired or missing, it will
-attempt to run 'aws sso login' to refresh them.`,
-		SilenceUsage: true,
+sign in to IAM Identity Center (SSO) to refresh them.
+
+The profile can be given as the first argument, as in 'aws-console prod-admin'.
+Profiles named like a subcommand must be selected with --profile instead. Repeat
+--profile, give a group from the config file as in 'aws-console @all-prod', or use
+'aws-console open', to open several profiles at once.
+
+Words after the profile, or in place of it, name the console page to open, as in
+'aws-console s3' or 'aws-console prod dynamodb tables'. They are matched against a
+catalog of services and their pages, allowing prefixes and typos; when several
+pages match equally well, you are asked to pick one.`,
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completeProfileArg(deps),
+		SilenceUsage:      true,
+		// main prints the error, once, as the last line of stderr.
+		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if showVersion {
 				fmt.Fprintln(deps.stdout, Version)
 				return nil
 			}
 
-			resolvedProfile := profile
-			if resolvedProfile == "" {
-				resolvedProfile = os.Getenv("AWS_PROFILE")
+			var pages []profilePage
+			query := args
+			if len(args) > 0 && strings.HasPrefix(args[0], config.GroupPrefix) && !selfTest {
+				file, err := loadConfigFile(deps)
+				if err != nil {
+					return err
+				}
+				names, err := expandProfiles(file, args[0], deps)
+				if err != nil {
+					return err
+				}
+				for _, name := range names {
+					pages = append(pages, profilePage{profile: name})
+				}
+				query = args[1:]
+			} else if len(args) > 0 {
+				var err error
+				if query, err = setPositionalArgs(cmd, args, deps); err != nil {
+					return err
+				}
+			}
+
+			if len(query) > 0 {
+				if selfTest {
+					return i18n.Errorf("--self-test opens no console page; %q is not a profile", strings.Join(query, " "))
+				}
+				if dest != "" || service != "" {
+					return i18n.Errorf("a console page cannot be given both as arguments and with --destination or --service")
+				}
+				var err error
+				if dest, err = pagePath(query, deps); err != nil {
+					return err
+				}
 			}
-			return runner(context.Background(), resolvedProfile, deps)
+
+			if selfTest {
+				g, err := resolveWorkflowGlobals(cmd, deps)
+				if err != nil {
+					return err
+				}
+				ctx, deps := g.apply(context.Background(), deps)
+				return runSelfTest(ctx, g.profile, g.output, deps)
+			}
+			for _, name := range profileFlagValues(cmd.Flags()) {
+				pages = append(pages, profilePage{profile: name})
+			}
+			return openProfiles(cmd, pages, dest, service, flags, deps, runner)
 		},
 	}
 
-	rootCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile to use (defaults to AWS_PROFILE env var)")
+	addGlobalFlags(rootCmd)
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles(deps))
+	addWorkflowFlags(rootCmd, &flags)
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Print the current version")
+	rootCmd.Flags().BoolVar(&selfTest, "self-test", false, "Check each step of signing in to the console without opening a browser")
+	rootCmd.Flags().StringVarP(&dest, "destination", "d", "", "Console page to open: a service (ec2, s3, cw, cloudshell, quicksight), a page such as dashboard:<name>, a console path, or a console URL")
+	rootCmd.Flags().StringVar(&service, "service", "", "Console service to open, e.g. ec2 or cloudwatch (same as --destination)")
+
+	rootCmd.AddCommand(
+		newOpenCmd(deps, runner),
+		newURLCmd(deps),
+		newListCmd(deps),
+		newAccountsCmd(deps, runner),
+		newOrgCmd(deps, runner),
+		newStatusCmd(deps),
+		newWhoamiCmd(deps),
+		newCredsCmd(deps),
+		newDirenvCmd(deps),
+		newExecCmd(deps),
+		newCredentialProcessCmd(deps),
+		newConfigCmd(deps),
+		newBookmarksCmd(deps),
+		newTuiCmd(deps, runner),
+		newWarmCmd(deps),
+		newDaemonCmd(deps, runner),
+		newBillingCmd(deps, runner),
+		newCleanCmd(deps),
+		newDoctorCmd(deps),
+		newLogoutCmd(deps),
+		newSwitchRoleCmd(deps),
+		newGuestCmd(deps, runner),
+		newSessionsCmd(deps, runner),
+		newPromptCmd(deps),
+		newBrowserProfilesCmd(deps),
+		newHistoryCmd(deps),
+		newReauthServerCmd(deps, runner),
+		newCompletionCmd(deps),
+		newDocsCmd(deps),
+		newVersionCmd(deps),
+	)
+	for _, shortcut := range destination.Shortcuts() {
+		rootCmd.AddCommand(newShortcutCmd(shortcut, deps, runner))
+	}
+	markUsageErrors(rootCmd)
 
 	return rootCmd
 }
 
-// Execute runs the root command.
-func Execute() error {
-	return NewRootCmd().Execute()
+// rootDestination resolves --destination or its --service alias to a console path or
+// URL in partition and region, either of which may be empty.
+func rootDestination(dest, service, partition, region string) (string, error) {
+	if dest != "" && service != "" {
+		return "", i18n.Errorf("--destination and --service cannot be used together")
+	}
+	if service != "" {
+		dest = service
+	}
+	return destination.Resolve(dest, partition, region)
+}
+
+// setPositionalProfile treats 'aws-console <name>' as 'aws-console --profile <name>'
+// once the name, or the profile it is an alias for, is confirmed to be a configured
+// profile.
+func setPositionalProfile(cmd *cobra.Command, name string, deps runDeps) error {
+	file, err := loadConfigFile(deps)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(name, config.GroupPrefix) {
+		return i18n.Errorf("%s is a group of profiles; give one of its profiles instead", name)
+	}
+	name = file.Alias(name)
+
+	flag := cmd.Flags().Lookup("profile")
+	if flag.Changed && file.Alias(flag.Value.String()) != name {
+		return i18n.Errorf("conflicting profiles: %q given as an argument but --profile is %q", name, flag.Value.String())
+	}
+
+	if err := checkProfileExists(name, deps); err != nil {
+		return err
+	}
+	return cmd.Flags().Set("profile", name)
+}
+
+// checkProfileExists returns an error when name is not a configured profile. Without a
+// shared config reader every name is accepted.
+func checkProfileExists(name string, deps runDeps) error {
+	if deps.profiles == nil {
+		return nil
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return i18n.Errorf("failed to list profiles: %w", err)
+	}
+	if _, ok := profileByName(profiles)[name]; !ok {
+		return i18n.Errorf("profile %q not found in AWS config (run 'aws-console list' to see configured profiles)", name)
+	}
+	return nil
+}
+
+// Execute runs the root command. A panic is converted into an error that points at a
+// redacted crash report instead of dumping a raw stack trace on the user. Other errors
+// remember the language of the command that returned them, for ErrorMessage.
+func Execute() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stateDir, _ := paths.StateDir()
+			err = writeCrashReport(r, debug.Stack(), os.Args[1:], stateDir, time.Now())
+		}
+	}()
+
+	deps := defaultRunDeps()
+	executed, err := newRootCmd(deps, runWorkflow).ExecuteC()
+	return localizeError(executed, err, deps)
+}
+
+// localizeError attaches the Printer of the language of cmd to err, for ErrorMessage.
+func localizeError(cmd *cobra.Command, err error, deps runDeps) error {
+	if err == nil {
+		return nil
+	}
+	return &localizedError{err: err, printer: output.NewPrinter(errorLanguage(cmd, deps), output.LevelNormal)}
+}
+
+// ErrorMessage returns the line reporting err, translated into the language of the
+// command when Execute returned it.
+func ErrorMessage(err error) string {
+	var localized *localizedError
+	if errors.As(err, &localized) {
+		return localized.printer.Sprintf("Error: %v", localized.err)
+	}
+	return "Error: " + err.Error()
+}
+
+// localizedError is an error returned by a command, with the Printer of its language.
+type localizedError struct {
+	err     error
+	printer *output.Printer
+}
+
+func (e *localizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *localizedError) Unwrap() error {
+	return e.err
+}
+
+// errorLanguage returns the language of the lang setting of cmd, or of the locale when
+// its settings cannot be read, as when reading them is what failed.
+func errorLanguage(cmd *cobra.Command, deps runDeps) i18n.Language {
+	locale := i18n.Detect(os.LookupEnv)
+	if cmd == nil {
+		return locale
+	}
+	file, err := loadConfigFile(deps)
+	if err != nil {
+		return locale
+	}
+	values, err := resolveSettings(cmd.Flags(), file, deps)
+	if err != nil {
+		return locale
+	}
+	lang, err := i18n.Parse(settingValue(values, settingLang))
+	if err != nil {
+		return locale
+	}
+	return lang
 }
 
 func defaultRunDeps() runDeps {
+	sharedConfig := awslib.NewSharedConfig()
 	deps := runDeps{
 		awsService:      awslib.NewService(),
 		federation:      awslib.NewFederationClient(),
+		profiles:        sharedConfig,
+		ssoSessions:     sharedConfig,
+		deviceLogin:     deviceLogin,
+		ssoPortal:       func(region string) sso.PortalAPI { return sso.NewPortalClient(region) },
+		usage:           usage.NewStore(),
+		history:         history.NewStore(),
+		accounts:        accounts.NewCache(),
+		sessions:        sessions.NewStore(),
+		now:             time.Now,
+		sleep:           sleepContext,
 		executor:        osExecutor{},
+		lookPath:        exec.LookPath,
 		goos:            runtime.GOOS,
+		wsl:             runtime.GOOS == "linux" && browser.IsWSL(),
+		term:            term.Detect(os.Stdin, os.Stdout, os.Stderr),
 		stdin:           os.Stdin,
 		stdout:          os.Stdout,
 		stderr:          os.Stderr,
 		sessionDuration: sessionDuration,
 	}
+	deps.picker = prompt.NewLinePicker(deps.stdin, deps.stderr)
 
-	deps.login = func(profile string) error {
-		return ssoLogin(profile, deps)
+	// An unresolvable home directory leaves these empty, which disables local state.
+	deps.cacheDir, _ = paths.CacheDir()
+	deps.stateDir, _ = paths.StateDir()
+	deps.credentials = credcache.NewCacheAt(deps.cacheDir)
+	if deps.stateDir != "" {
+		deps.daemon = daemon.NewClient(filepath.Join(deps.stateDir, daemon.SocketName))
+		deps.browserProfiles = browserprofiles.NewManagerAt(filepath.Join(deps.stateDir, browserprofiles.DirName))
+	}
+	deps.ssoTokens = ssocache.NewCache()
+	deps.configFile = defaultConfigFile()
+
+	deps.login = func(ctx context.Context, profile string) error {
+		return ssoLogin(ctx, profile, deps)
+	}
+	deps.open = func(targetURL string, opts browserOptions) error {
+		return openBrowser(targetURL, opts, deps)
 	}
-	deps.open = func(targetURL string) error {
-		return openBrowser(targetURL, deps)
+	deps.copy = func(text string) error {
+		return copyToClipboard(text, deps)
 	}
+	notifyPlatform := deps.goos
+	if deps.wsl {
+		notifyPlatform = notify.WSL
+	}
+	deps.notifier = notify.New(notifyPlatform, deps.executor)
 
 	return deps
 }
 
-func runWorkflow(ctx context.Context, profile string, deps runDeps) error {
-	identity, err := deps.awsService.GetCallerIdentity(ctx, profile)
+// runWorkflow opens the console for opts.profile, then waits when asked to. Opening
+// gives up after deps.deadline, and an interrupt cancels it, stopping any aws sso login
+// it started.
+func runWorkflow(ctx context.Context, opts workflowOptions, deps runDeps) error {
+	if opts.keepAlive {
+		return keepAlive(ctx, opts, deps)
+	}
+	ctx, stop := interruptContext(ctx)
+	defer stop()
+
+	openCtx, cancel := ctx, context.CancelFunc(func() {})
+	if deps.deadline > 0 {
+		openCtx, cancel = context.WithTimeout(ctx, deps.deadline)
+	}
+	defer cancel()
+
+	if opts.stdinCreds {
+		var err error
+		if openCtx, err = withStdinCredentials(openCtx, deps); err != nil {
+			return err
+		}
+	}
+	if opts.saml != "" {
+		var err error
+		if openCtx, err = withSAMLCredentials(openCtx, opts.saml, deps); err != nil {
+			return stoppedError(openCtx, deps, err)
+		}
+	}
+	if opts.webIdentity.roleARN != "" {
+		var err error
+		if openCtx, err = withWebIdentityCredentials(openCtx, opts.webIdentity, deps); err != nil {
+			return stoppedError(openCtx, deps, err)
+		}
+	}
+	if opts.dryRun {
+		return stoppedError(openCtx, deps, runDryRun(openCtx, opts, deps))
+	}
+	deps, err := openConsole(openCtx, opts, deps)
 	if err != nil {
-		fmt.Fprintln(deps.stderr, "Credentials are not valid, attempting SSO login...")
-		if loginErr := deps.login(profile); loginErr != nil {
-			return fmt.Errorf("SSO login failed: %w", loginErr)
+		return stoppedError(openCtx, deps, err)
+	}
+	cancel()
+	return waitIfRequested(ctx, opts, deps)
+}
+
+// stoppedError explains err when ctx ended before the console opened.
+func stoppedError(ctx context.Context, deps runDeps, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return i18n.Errorf("gave up after the %s deadline: %w", deps.deadline, err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return i18n.Errorf("interrupted: %w", err)
+	}
+	return err
+}
+
+// interruptContext returns a context canceled by an interrupt or SIGTERM. The first
+// signal restores the default handling, so a second one exits even a step that does
+// not watch ctx, such as a prompt.
+func interruptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// openConsole signs in to opts.profile and opens the console, returning deps with the
+// session duration granted.
+func openConsole(ctx context.Context, opts workflowOptions, deps runDeps) (_ runDeps, err error) {
+	profile := opts.profile
+	if opts.qr && deps.term.Accessible {
+		// A screen reader cannot read a QR code out; the URL it holds can be.
+		deps.messages.Fprintln(deps.stderr, "QR codes are not shown in accessible mode; printing the sign-in URL instead.")
+		opts.qr, opts.print = false, true
+	}
+	if opts.print {
+		deps.printOnly = true
+	}
+	headless, detected := useHeadless(opts, deps)
+	if headless {
+		// Without a browser to open, the URL is shared in every way that may reach one.
+		opts.print, opts.qr = true, deps.term.StderrTTY && !deps.term.Accessible
+		deps.printOnly = true
+		if detected {
+			deps.messages.Fprintln(deps.stderr, "No display found; printing the sign-in URL instead of opening a browser (pass --headless=false to open one anyway).")
 		}
+	}
+	// Reported here only when the workflow fails; on success it is reported before waiting.
+	defer func() {
+		deps.timings.report(deps.stderr)
+		deps.timings.export(ctx, err, deps)
+	}()
+
+	cache := deps.credentials
+	// Credentials limited by a session policy are not cached, so they never stand in for
+	// the profile's own or the other way around. Neither are those federated from the
+	// environment, stdin, or a SAML provider, which have no profile to key them by.
+	if opts.noCache || limitedSession(opts, deps) || envCredentials(profile) || opts.suppliedCredentials() != "" {
+		cache = nil
+	}
+	if cache != nil {
+		ctx = awslib.WithSigninTokenScope(awslib.WithSigninTokenCache(ctx, cache), signinTokenScope(opts, deps))
+	}
 
+	var identity awslib.Identity
+	var creds awslib.Credentials
+	cached := false
+	if cache != nil {
+		identity, creds, cached = cache.Credentials(profile, opts.assumeRole.RoleARN)
+	}
+	// Cached credentials that would expire before an explicit --duration ends are refreshed.
+	if cached && deps.durationSet && !creds.ValidAt(deps.now().Add(time.Duration(deps.sessionDuration)*time.Second)) {
+		verbosef(deps, "Cached credentials for %s expire at %s, before the requested session ends; refreshing", describeProfile(profile), formatTimestamp(creds.Expires))
+		cached = false
+	} else if cached && opts.minValidity > 0 && !creds.ValidAt(deps.now().Add(opts.minValidity)) {
+		verbosef(deps, "Cached credentials for %s expire at %s; refreshing", describeProfile(profile), formatTimestamp(creds.Expires))
+		cached = false
+	} else if cached && !deps.policy.allowsCached(creds) {
+		verbosef(deps, "Checking the credentials of %s against the credential policy instead of using cached ones", describeProfile(profile))
+		cached = false
+	}
+
+	var done func()
+	checked := false
+	switch {
+	case cached:
+		verbosef(deps, "Using cached credentials for %s until %s", describeProfile(profile), creds.Expires.Format(time.RFC3339))
+	case rememberedIdentity(cache, profile, opts, deps, &identity):
+	case opts.suppliedCredentials() != "":
+		done = deps.timings.start("sts")
 		identity, err = deps.awsService.GetCallerIdentity(ctx, profile)
+		done()
 		if err != nil {
-			return fmt.Errorf("credentials still invalid after SSO login: %w", err)
+			return deps, i18n.Errorf("failed to check %s: %w", opts.suppliedCredentials(), err)
+		}
+	default:
+		if profile, identity, err = authenticateWithFallback(ctx, profile, opts, deps); err != nil {
+			return deps, err
 		}
+		checked = true
+		putValidated(cache, profile, identity, deps)
+	}
+
+	useFallback(&opts, profile, deps)
+	status := statusWriter(deps)
+	deps.messages.Fprintf(status, "Authenticated as: %s\n", identity.Arn)
+	if identity.Account != "" {
+		deps.messages.Fprintf(status, "Account: %s\n", accounts.Label(describeAccount(ctx, profile, identity.Account, deps)))
 	}
 
-	fmt.Fprintf(deps.stdout, "Authenticated as: %s\n", identity.Arn)
+	// GovCloud and China identities must federate through their own partition's endpoints.
+	if partition := awslib.PartitionFromContext(ctx); partition != "" {
+		if identity.Partition != "" && identity.Partition != partition {
+			deps.messages.Fprintf(deps.stderr, "Warning: --partition %s differs from the partition of %s\n", partition, identity.Arn)
+		}
+		verbosef(deps, "Using %s partition endpoints", partition)
+	} else if identity.Partition != "" {
+		verbosef(deps, "Using %s partition endpoints", identity.Partition)
+		ctx = awslib.WithPartition(ctx, identity.Partition)
+	}
+
+	if deps.reauthURL != "" {
+		ctx = awslib.WithIssuer(ctx, reauthIssuer(deps.reauthURL, reauthToken(deps), profile, opts.destination))
+	}
+
+	if opts.preflight != nil {
+		done = deps.timings.start("preflight")
+		err := opts.preflight(ctx, profile, identity, deps)
+		done()
+		if err != nil {
+			return deps, err
+		}
+	}
 
-	creds, err := deps.awsService.RetrieveCredentials(ctx, profile)
+	if !cached {
+		creds, err = federationCredentials(ctx, profile, &identity, opts, deps)
+		if err != nil && !checked && awslib.ClassifyError(err) == awslib.ErrorKindExpired {
+			// The remembered identity may be stale: check the credentials, logging in
+			// again if needed, and federate once more. Other failures, such as a
+			// declined MFA prompt or a denied AssumeRole, would only fail again.
+			verbosef(deps, "Federation without a credential check failed: %v", err)
+			if profile, identity, err = authenticateWithFallback(ctx, profile, opts, deps); err != nil {
+				return deps, err
+			}
+			useFallback(&opts, profile, deps)
+			putValidated(cache, profile, identity, deps)
+			creds, err = federationCredentials(ctx, profile, &identity, opts, deps)
+		}
+		if err != nil {
+			return deps, err
+		}
+		if cache != nil {
+			if err := cache.PutCredentials(profile, opts.assumeRole.RoleARN, identity, creds); err != nil {
+				deps.messages.Fprintf(deps.stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+
+	// The guard looks at the principal the console opens as, such as the role assumed
+	// with --role-arn, rather than the profile's.
+	if err := guardPrincipal(identity, opts, deps); err != nil {
+		return deps, err
+	}
+
+	copts := consoleOptions(opts, deps)
+	copts.Identity, copts.Credentials = &identity, &creds
+	consoleURL, err := consoleClient(deps).OpenConsole(awslib.WithLogger(ctx, logger(deps)), copts)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve credentials: %w", err)
+		return deps, err
+	}
+	requested := time.Duration(deps.sessionDuration) * time.Second
+	if deps.durationSet && consoleURL.SessionDuration < requested {
+		deps.messages.Fprintf(deps.stderr, "Warning: the console session is limited to %s, when the role credentials of %s expire\n", formatDuration(consoleURL.SessionDuration), describeProfile(profile))
+	}
+	deps.sessionDuration = int32(consoleURL.SessionDuration / time.Second)
+	loginURLs := consoleURL.SignInURLs
+	valid := consoleURL.SessionDuration
+	if !creds.Expires.IsZero() {
+		valid = min(valid, creds.Remaining(deps.now()))
+	}
+	deps.messages.Fprintf(status, "Console session valid for %s\n", formatDuration(valid))
+	switch {
+	case opts.readOnly:
+		deps.messages.Fprintf(status, "Access: read-only (%s session policy)\n", awslib.ReadOnlyAccessPolicy)
+	case deps.sessionPolicy != nil:
+		deps.messages.Fprintln(status, "Access: limited by a session policy")
+	}
+
+	if deps.container != "" {
+		opts.browser.container = containerName(deps.container, profile, identity.Account)
+	}
+	if deps.isolateAccounts && !printOnly(deps) && !opts.copy && !opts.qr {
+		if opts.browser.dataDir, err = accountBrowserProfile(profile, identity.Account, deps); err != nil {
+			return deps, err
+		}
+	}
+
+	hooks := hookSession{
+		profile:     profile,
+		identity:    identity,
+		region:      strings.Join(opts.regions, ","),
+		destination: opts.destination,
+		urls:        loginURLs,
+	}
+	if hooks.region == "" {
+		hooks.region = awslib.RegionFromContext(ctx)
+	}
+	if err := runHooks(ctx, hookPreOpen, deps.hooks.PreOpen, hooks, deps); err != nil {
+		return deps, err
+	}
+	if opts.shorten {
+		if loginURLs, err = shortenURLs(ctx, loginURLs, deps); err != nil {
+			return deps, err
+		}
+	}
+
+	if opts.copy || headless {
+		err := deps.copy(strings.Join(loginURLs, "\n"))
+		switch {
+		case err == nil:
+			deps.messages.Fprintln(status, "Copied the sign-in URL to the clipboard.")
+		case opts.copy:
+			return deps, i18n.Errorf("failed to copy the sign-in URL to the clipboard: %w", err)
+		default:
+			// Headless hosts often have no clipboard; the URL is printed anyway.
+			verbosef(deps, "Not copying the sign-in URL to the clipboard: %v", err)
+		}
 	}
 
-	// If no session token (e.g. long-lived IAM user keys), request temporary credentials
-	if creds.SessionToken == "" {
-		fmt.Fprintln(deps.stdout, "No session token found, requesting temporary credentials...")
-		creds, err = deps.awsService.GetSessionToken(ctx, profile, deps.sessionDuration)
+	waitAudit := startAudit(ctx, profile, identity, requested, opts, deps)
+	defer waitAudit()
+
+	var redirects *redirect.Server
+	for i, loginURL := range loginURLs {
+		region, dest := "", opts.destination
+		if len(opts.regions) > 0 {
+			region = opts.regions[i]
+			dest = destination.WithRegion(opts.destination, region)
+		}
+		recordSession(profile, opts.fallbackFor, identity, dest, deps)
+
+		if opts.qr {
+			if err := showQRCode(status, loginURL, region, statusColor(deps)); err != nil {
+				return deps, err
+			}
+		}
+		if (opts.copy || opts.qr) && !opts.print {
+			recordHistory(profile, identity, region, dest, deps)
+			continue
+		}
+		if printOnly(deps) {
+			// With --print or a piped stdout the caller wants the URL, not a browser window.
+			fmt.Fprintln(deps.stdout, loginURL)
+			recordHistory(profile, identity, region, dest, deps)
+			continue
+		}
+
+		if region != "" {
+			deps.messages.Fprintf(status, "Opening AWS Console in %s in your browser...\n", region)
+		} else {
+			deps.messages.Fprintln(status, "Opening AWS Console in your browser...")
+		}
+		openURL := loginURL
+		if deps.localRedirect {
+			if redirects == nil {
+				if redirects, err = redirect.Start(); err != nil {
+					return deps, err
+				}
+				defer redirects.Close()
+			}
+			if openURL, err = redirects.Add(loginURL); err != nil {
+				return deps, err
+			}
+		}
+		if opts.forceLogout && i == 0 {
+			if err := signOutFirst(ctx, opts.browser.withSettings(deps), deps); err != nil {
+				return deps, err
+			}
+		}
+		done = deps.timings.start("browser")
+		err := deps.open(openURL, opts.browser.withSettings(deps))
+		done()
 		if err != nil {
-			return fmt.Errorf("failed to get temporary credentials: %w", err)
+			return deps, err
+		}
+		recordHistory(profile, identity, region, dest, deps)
+		// A short clickable label is a handy fallback if the browser opened the wrong
+		// window; a one-time URL has no second use.
+		if deps.term.Hyperlinks() && redirects == nil {
+			deps.messages.Fprintln(deps.stdout, consoleLink(loginURL, profile, region))
+		}
+	}
+	if redirects != nil {
+		verbosef(deps, "Waiting for the browser to open the sign-in link")
+		if err := redirects.Wait(ctx, localRedirectTimeout); err != nil {
+			return deps, err
 		}
 	}
 
-	// Build the federated console sign-in URL
-	loginURL, err := deps.federation.BuildConsoleURL(ctx, creds, deps.sessionDuration)
+	if err := runHooks(ctx, hookPostOpen, deps.hooks.PostOpen, hooks, deps); err != nil {
+		return deps, err
+	}
+	waitAudit()
+
+	recordUsage(profile, deps)
+	deps.timings.report(deps.stderr)
+	deps.timings.export(ctx, nil, deps)
+	if opts.opened != nil {
+		opts.opened(deps.now().Add(valid))
+	}
+	return deps, nil
+}
+
+// authenticate returns the caller identity of profile, running an SSO login first
+// when its cached SSO token has expired or its credentials are rejected as expired.
+// Network, permission, and throttling errors are returned without a login, which would
+// not help.
+func authenticate(ctx context.Context, profile string, deps runDeps) (awslib.Identity, error) {
+	if reason := ssoLoginReason(profile, deps); reason != "" {
+		deps.messages.Fprintf(deps.stderr, "%s, attempting SSO login...\n", reason)
+		return loginAndIdentify(ctx, profile, deps)
+	}
+
+	verbosef(deps, "Checking credentials for %s", describeProfile(profile))
+	done := deps.timings.start("sts")
+	identity, err := deps.awsService.GetCallerIdentity(ctx, profile)
+	done()
+	if err == nil {
+		return identity, nil
+	}
+
+	switch awslib.ClassifyError(err) {
+	case awslib.ErrorKindNetwork:
+		return awslib.Identity{}, i18n.Errorf("failed to reach AWS to check credentials for %s: %w", describeProfile(profile), err)
+	case awslib.ErrorKindAccessDenied:
+		return awslib.Identity{}, i18n.Errorf("credentials for %s are not allowed to call sts:GetCallerIdentity: %w", describeProfile(profile), err)
+	case awslib.ErrorKindThrottled:
+		return awslib.Identity{}, i18n.Errorf("failed to check credentials for %s: %w", describeProfile(profile), err)
+	}
+
+	// Keys from a password manager or the environment are not refreshed by an SSO login.
+	if _, ok := awslib.KeySourceFor(ctx, profile); ok {
+		return awslib.Identity{}, i18n.Errorf("the access keys of %s from its credential-source were rejected: %w", describeProfile(profile), err)
+	}
+	if envCredentials(profile) {
+		return awslib.Identity{}, envCredentialsError(err)
+	}
+
+	verbosef(deps, "Credential check failed: %v", err)
+	deps.messages.Fprintln(deps.stderr, "Credentials are not valid, attempting SSO login...")
+	return loginAndIdentify(ctx, profile, deps)
+}
+
+// validatedMaxAge is how long a credential check stands in for the next with the
+// validate setting recent.
+const validatedMaxAge = 15 * time.Minute
+
+// rememberedIdentity sets identity to the caller identity of profile remembered in
+// cache and reports true when profile can federate without checking its credentials
+// first: the validate setting or --skip-validate allows it, the last check is recent
+// enough, and no SSO login is known to be needed.
+func rememberedIdentity(cache *credcache.Cache, profile string, opts workflowOptions, deps runDeps, identity *awslib.Identity) bool {
+	if cache == nil {
+		return false
+	}
+	var maxAge time.Duration
+	switch {
+	case opts.skipValidate || deps.validate == validateSkip:
+	case deps.validate == validateRecent:
+		maxAge = validatedMaxAge
+	default:
+		return false
+	}
+	remembered, validatedAt, ok := cache.Validated(profile, maxAge)
+	if !ok || ssoLoginReason(profile, deps) != "" {
+		return false
+	}
+	verbosef(deps, "Skipping the credential check of %s, last checked at %s", describeProfile(profile), formatTimestamp(validatedAt))
+	*identity = remembered
+	return true
+}
+
+// putValidated remembers that the credentials of profile were checked and belong to
+// identity, for rememberedIdentity.
+func putValidated(cache *credcache.Cache, profile string, identity awslib.Identity, deps runDeps) {
+	if cache == nil {
+		return
+	}
+	if err := cache.PutValidated(profile, identity); err != nil {
+		deps.messages.Fprintf(deps.stderr, "Warning: %v\n", err)
+	}
+}
+
+// ssoLoginReason inspects the SSO token cache for profile and explains why a login is
+// needed before calling AWS: the token is missing, or expired and cannot be refreshed.
+// It returns "" when no login is known to be needed, including for non-SSO profiles.
+func ssoLoginReason(profile string, deps runDeps) string {
+	if deps.ssoTokens == nil {
+		return ""
+	}
+	p, ok := ssoProfile(profile, deps)
+	if !ok {
+		return ""
+	}
+
+	token, err := deps.ssoTokens.Token(ssocache.Key(p.SSOSession, p.SSOStartURL))
+	switch {
+	case errors.Is(err, ssocache.ErrNoToken):
+		return deps.messages.Sprintf("No cached SSO token for %s", describeProfile(profile))
+	case err != nil:
+		verbosef(deps, "Ignoring the SSO token cache: %v", err)
+		return ""
+	}
+
+	now := deps.now()
+	if token.Expired(now) && !token.Refreshable(now) {
+		return deps.messages.Sprintf("The SSO token for %s expired at %s", describeProfile(profile), token.ExpiresAt.Local().Format(time.Kitchen))
+	}
+	verbosef(deps, "SSO token for %s is valid until %s", describeProfile(profile), token.ExpiresAt.Format(time.RFC3339))
+	return ""
+}
+
+// loginAndIdentify runs an SSO login for profile and returns its caller identity.
+func loginAndIdentify(ctx context.Context, profile string, deps runDeps) (awslib.Identity, error) {
+	done := deps.timings.start("sso-login")
+	loginErr := deps.login(ctx, profile)
+	done()
+	if loginErr != nil {
+		return awslib.Identity{}, awslib.MarkError(i18n.Errorf("SSO login failed: %w", loginErr), awslib.ErrSSOLoginRequired)
+	}
+
+	done = deps.timings.start("sts")
+	identity, err := deps.awsService.GetCallerIdentity(ctx, profile)
+	done()
+	if err != nil {
+		err = i18n.Errorf("credentials still invalid after SSO login: %w", err)
+		if awslib.ClassifyError(err) == awslib.ErrorKindExpired {
+			err = awslib.MarkError(err, awslib.ErrCredentialsExpired)
+		}
+		return awslib.Identity{}, err
+	}
+	return identity, nil
+}
+
+// federationCredentials returns temporary credentials for the console session: the
+// profile's own, those of opts.assumeRole, or a session token for long-lived keys.
+// Assuming a role moves identity to the role's account.
+func federationCredentials(ctx context.Context, profile string, identity *awslib.Identity, opts workflowOptions, deps runDeps) (awslib.Credentials, error) {
+	copts := consoleOptions(opts, deps)
+	copts.Profile, copts.Identity = profile, identity
+	session, err := consoleClient(deps).Credentials(awslib.WithLogger(ctx, logger(deps)), copts)
+	if err != nil {
+		return awslib.Credentials{}, err
+	}
+	*identity = session.Identity
+	return session.Credentials, nil
+}
+
+// consoleClient returns a console.Client over the services in deps that prompts for MFA
+// codes and reports progress and timings like the rest of the CLI.
+func consoleClient(deps runDeps) *console.Client {
+	return &console.Client{
+		Service:    deps.awsService,
+		Federation: deps.federation,
+		MFAToken: func(ctx context.Context, serial string, optional bool) (string, error) {
+			// A device found on the user's behalf is optional outside a terminal, so
+			// scripts that never needed MFA keep working.
+			if optional && !deps.term.Interactive() && deps.mfaSource == nil {
+				return "", nil
+			}
+			return mfaCode(serial, deps)
+		},
+		Progress: func(msg string) { deps.messages.Fprintln(statusWriter(deps), msg) },
+		Step:     deps.timings.start,
+		Now:      deps.now,
+		Policy: func(ctx context.Context, check console.PolicyCheck) error {
+			return deps.policy.check(ctx, check, deps)
+		},
+	}
+}
+
+// consoleOptions translates opts and the resolved settings in deps for console.Client.
+func consoleOptions(opts workflowOptions, deps runDeps) console.Options {
+	copts := console.Options{
+		Profile:         opts.profile,
+		Destination:     opts.destination,
+		Regions:         opts.regions,
+		SessionDuration: time.Duration(deps.sessionDuration) * time.Second,
+		// A duration left at its default is shortened to the limit for the credentials;
+		// one given explicitly is rejected before any request is made.
+		LimitDuration: !deps.durationSet,
+		AssumeRole:    opts.assumeRole,
+		ReadOnly:      opts.readOnly,
+		SessionPolicy: deps.sessionPolicy,
+	}
+	if copts.AssumeRole.MFASerial == "" {
+		copts.MFASerial = profileMFASerial(opts.profile, deps)
+	}
+	copts.ChainedRole = chainedRoleProfile(opts.profile, deps)
+	if copts.AssumeRole.SessionName == "" {
+		copts.AssumeRole.SessionName = roleSessionName(opts.assumeRole.Reason, deps)
+	}
+	return copts
+}
+
+// roleSessionName names a role session opened for reason after the session name
+// template, so CloudTrail shows who opened it, from where, and why.
+func roleSessionName(reason string, deps runDeps) string {
+	now := time.Now()
+	if deps.now != nil {
+		now = deps.now()
+	}
+	host, _ := os.Hostname()
+	return awslib.ExpandSessionName(cmp.Or(deps.sessionName, awslib.DefaultSessionNameTemplate), awslib.SessionNameValues{
+		User:   localUser(),
+		Host:   host,
+		Reason: reason,
+		Time:   now,
+	})
+}
+
+// limitedSession reports whether the credentials of opts are limited by a session
+// policy, or carry session tags or a source identity, and so differ from the ones
+// cached for the profile and role.
+func limitedSession(opts workflowOptions, deps runDeps) bool {
+	tagged := len(opts.assumeRole.SessionTags()) > 0 || opts.assumeRole.SourceIdentity != ""
+	return opts.readOnly || deps.sessionPolicy != nil || tagged
+}
+
+// signinTokenScope describes the session policy and read-only setting of a console
+// session, so cached sign-in tokens are only reused for sessions limited the same way.
+func signinTokenScope(opts workflowOptions, deps runDeps) string {
+	scope := fmt.Sprintf("read-only=%t", opts.readOnly)
+	if p := deps.sessionPolicy; p != nil {
+		scope += fmt.Sprintf("|policy-arns=%s|policy=%s", strings.Join(p.PolicyARNs, ","), p.Policy)
+	}
+	return scope
+}
+
+// recordSession remembers a console session for the sessions command, labeled with
+// the profile it stands in for when profile is a fallback.
+func recordSession(profile, fallbackFor string, identity awslib.Identity, dest string, deps runDeps) {
+	if deps.sessions == nil {
+		return
+	}
+	now := deps.now()
+	_, err := deps.sessions.Add(sessions.Session{
+		Profile:     profile,
+		FallbackFor: fallbackFor,
+		Account:     identity.Account,
+		Role:        identity.RoleName(),
+		Partition:   identity.Partition,
+		Destination: dest,
+		OpenedAt:    now.UTC(),
+		ExpiresAt:   now.Add(time.Duration(deps.sessionDuration) * time.Second).UTC(),
+	}, now)
 	if err != nil {
-		return fmt.Errorf("failed to build console URL: %w", err)
+		deps.messages.Fprintf(deps.stderr, "Warning: %v\n", err)
 	}
+}
 
-	fmt.Fprintln(deps.stdout, "Opening AWS Console in your browser...")
-	return deps.open(loginURL)
+// recordHistory appends a console sign-in to the local history, once its sign-in URL
+// has been opened or handed to the user.
+func recordHistory(profile string, identity awslib.Identity, region, dest string, deps runDeps) {
+	if deps.history == nil {
+		return
+	}
+	err := deps.history.Append(history.Entry{
+		Time:        deps.now().UTC(),
+		Profile:     profile,
+		Account:     identity.Account,
+		Role:        identity.RoleName(),
+		Region:      region,
+		Destination: dest,
+	})
+	if err != nil {
+		deps.messages.Fprintf(deps.stderr, "Warning: %v\n", err)
+	}
 }
 
-// ssoLogin shells out to the AWS CLI to perform an SSO login.
-func ssoLogin(profile string, deps runDeps) error {
-	args := []string{"sso", "login"}
+// consoleLink renders the sign-in URL as an OSC 8 hyperlink labeled with the profile
+// and region, since the raw URL wraps across many lines.
+func consoleLink(loginURL, profile, region string) string {
+	label := "Open AWS Console"
 	if profile != "" {
-		args = append(args, "--profile", profile)
+		label += " – " + profile
 	}
+	if region != "" {
+		label += " (" + region + ")"
+	}
+	return term.Hyperlink(loginURL, label)
+}
 
-	return deps.executor.Run("aws", args, deps.stdin, deps.stdout, deps.stderr)
+// showQRCode renders the sign-in URL as a QR code, since the URL is too long to type
+// on a phone; without color, in the terminal's own colors.
+func showQRCode(w io.Writer, loginURL, region string, color bool) error {
+	code, err := qr.Encode(loginURL)
+	if err != nil {
+		return i18n.Errorf("failed to render the sign-in URL as a QR code: %w", err)
+	}
+	label := "Scan to open the AWS Console"
+	if region != "" {
+		label += " in " + region
+	}
+	drawing := code.Monochrome()
+	if color {
+		drawing = code.Terminal()
+	}
+	fmt.Fprintf(w, "%s (the link is valid for up to 15 minutes):\n%s", label, drawing)
+	return nil
 }
 
-// openBrowser opens the given URL in the user's default browser.
-func openBrowser(targetURL string, deps runDeps) error {
-	var command string
-	var args []string
+// statusWriter returns where progress messages go: stdout on a terminal, or stderr
+// when stdout is piped so that it only carries the command's actual output.
+func statusWriter(deps runDeps) io.Writer {
+	if printOnly(deps) {
+		return deps.stderr
+	}
+	return deps.stdout
+}
 
-	switch deps.goos {
-	case "darwin":
-		command = "open"
-	case "linux":
-		command = "xdg-open"
-	case "windows":
-		command = "rundll32"
-		args = []string{"url.dll,FileProtocolHandler"}
-	default:
-		return fmt.Errorf("unsupported platform: %s", deps.goos)
+// statusColor reports whether colors may be written to statusWriter(deps).
+func statusColor(deps runDeps) bool {
+	if printOnly(deps) {
+		return deps.term.StderrColor()
 	}
+	return deps.term.Color()
+}
+
+// useHeadless reports whether sign-in URLs are shared instead of opened because there
+// is no display to open a browser on: --headless was given, or, when it was not, none
+// was found, in which case detected is true. URLs already printed, copied, or shown as
+// a QR code are left as asked.
+func useHeadless(opts workflowOptions, deps runDeps) (headless, detected bool) {
+	if opts.print || opts.copy || opts.qr || printOnly(deps) {
+		return false, false
+	}
+	if deps.headlessSet {
+		return deps.headless, false
+	}
+	return deps.term.Headless, deps.term.Headless
+}
 
-	args = append(args, targetURL)
-	return deps.executor.Start(command, args)
+// printOnly reports whether sign-in URLs are printed rather than opened in a browser.
+func printOnly(deps runDeps) bool {
+	return deps.printOnly || deps.term.Piped()
+}
+
+// describeAccount looks up cached account metadata, or only the account alias when
+// there is no cache. It is informational only, so failures are reported with --verbose
+// and otherwise ignored.
+func describeAccount(ctx context.Context, profile, accountID string, deps runDeps) awslib.AccountInfo {
+	if accountID == "" || deps.awsService == nil {
+		return awslib.AccountInfo{ID: accountID}
+	}
+	if deps.accounts == nil {
+		done := deps.timings.start("account")
+		alias, err := deps.awsService.GetAccountAlias(ctx, profile)
+		done()
+		if err != nil {
+			verbosef(deps, "Could not look up the account alias: %v", err)
+		}
+		return awslib.AccountInfo{ID: accountID, Alias: alias}
+	}
+	done := deps.timings.start("account")
+	info, err := deps.accounts.Lookup(ctx, deps.awsService, profile, accountID, deps.now())
+	done()
+	if err != nil {
+		verbosef(deps, "Could not look up account details: %v", err)
+	}
+	return info
+}
+
+// verbosef prints a progress detail to stderr when --verbose is set.
+func verbosef(deps runDeps, format string, args ...any) {
+	if deps.verbose {
+		logger(deps).Info(fmt.Sprintf(format, args...))
+	}
+}
+
+// logger returns the logger for --verbose and --debug, writing to stderr.
+func logger(deps runDeps) *slog.Logger {
+	return logging.New(deps.stderr, logging.Level(deps.verbose, deps.debug))
+}
+
+// describeProfile names a profile for messages, including the unnamed default chain.
+func describeProfile(profile string) string {
+	if envCredentials(profile) {
+		return "the credentials in AWS_ACCESS_KEY_ID"
+	}
+	if profile == "" {
+		return "the default credential chain"
+	}
+	return fmt.Sprintf("profile %q", profile)
+}
+
+// recordUsage notes that the profile was opened. Failures are reported but never fatal.
+func recordUsage(profile string, deps runDeps) {
+	if deps.usage == nil {
+		return
+	}
+	if err := deps.usage.Record(profile, deps.now()); err != nil {
+		deps.messages.Fprintf(deps.stderr, "Warning: %v\n", err)
+	}
 }
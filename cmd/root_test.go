@@ -6,13 +6,31 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/smithy-go"
+	"github.com/eculver/aws-console/pkg/accounts"
 	awslib "github.com/eculver/aws-console/pkg/aws"
 	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/history"
+	"github.com/eculver/aws-console/pkg/qr"
+	"github.com/eculver/aws-console/pkg/sessions"
+	"github.com/eculver/aws-console/pkg/term"
+	"github.com/eculver/aws-console/pkg/usage"
 )
 
+// interactiveTerminal simulates running attached to a terminal.
+var interactiveTerminal = term.Info{StdinTTY: true, StdoutTTY: true, StderrTTY: true, Term: "xterm-256color"}
+
 type workflowState struct {
 	stdout               bytes.Buffer
 	stderr               bytes.Buffer
@@ -28,12 +46,16 @@ type execCall struct {
 	method string
 	name   string
 	args   []string
+	env    []string
+	stdin  io.Reader
 }
 
 type fakeExecutor struct {
-	runErr   error
-	startErr error
-	calls    []execCall
+	runErr    error
+	runOutput string
+	startErr  error
+	execCode  int
+	calls     []execCall
 }
 
 func (f *fakeExecutor) Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
@@ -41,10 +63,23 @@ func (f *fakeExecutor) Run(name string, args []string, stdin io.Reader, stdout i
 		method: "run",
 		name:   name,
 		args:   append([]string(nil), args...),
+		stdin:  stdin,
 	})
+	if stdout != nil {
+		io.WriteString(stdout, f.runOutput)
+	}
 	return f.runErr
 }
 
+func (f *fakeExecutor) RunContext(ctx context.Context, name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := f.Run(name, args, stdin, stdout, stderr)
+	f.calls[len(f.calls)-1].env = append([]string(nil), env...)
+	return err
+}
+
 func (f *fakeExecutor) Start(name string, args []string) error {
 	f.calls = append(f.calls, execCall{
 		method: "start",
@@ -54,6 +89,36 @@ func (f *fakeExecutor) Start(name string, args []string) error {
 	return f.startErr
 }
 
+func (f *fakeExecutor) Exec(name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	f.calls = append(f.calls, execCall{
+		method: "exec",
+		name:   name,
+		args:   append([]string(nil), args...),
+		env:    append([]string(nil), env...),
+	})
+	return f.execCode, f.runErr
+}
+
+// fakeNotifier records the messages of desktop notifications.
+type fakeNotifier struct {
+	err      error
+	messages []string
+}
+
+func (f *fakeNotifier) Notify(title, message string) error {
+	f.messages = append(f.messages, message)
+	return f.err
+}
+
+// TestMain runs the tests in English and with the platform's own browser launcher,
+// whatever the locale and BROWSER of the machine running them.
+func TestMain(m *testing.M) {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG", "AWS_CONSOLE_LANG", "BROWSER"} {
+		os.Unsetenv(name)
+	}
+	os.Exit(m.Run())
+}
+
 func TestRunWorkflow(t *testing.T) {
 	t.Parallel()
 
@@ -81,7 +146,7 @@ func TestRunWorkflow(t *testing.T) {
 						SessionToken:    "token",
 					}, nil
 				}
-				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32) (string, error) {
+				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
 					return "https://example.com/console-login", nil
 				}
 			},
@@ -90,14 +155,14 @@ func TestRunWorkflow(t *testing.T) {
 				if state.openedURL != "https://example.com/console-login" {
 					t.Fatalf("unexpected opened URL: %q", state.openedURL)
 				}
-				if svc.GetCallerIdentityCalls != 1 {
-					t.Fatalf("expected 1 GetCallerIdentity call, got %d", svc.GetCallerIdentityCalls)
+				if svc.GetCallerIdentityCalls.Load() != 1 {
+					t.Fatalf("expected 1 GetCallerIdentity call, got %d", svc.GetCallerIdentityCalls.Load())
 				}
-				if svc.GetSessionTokenCalls != 0 {
-					t.Fatalf("expected 0 GetSessionToken calls, got %d", svc.GetSessionTokenCalls)
+				if svc.GetSessionTokenCalls.Load() != 0 {
+					t.Fatalf("expected 0 GetSessionToken calls, got %d", svc.GetSessionTokenCalls.Load())
 				}
-				if federation.BuildConsoleURLCalls != 1 {
-					t.Fatalf("expected 1 BuildConsoleURL call, got %d", federation.BuildConsoleURLCalls)
+				if federation.BuildConsoleURLCalls.Load() != 1 {
+					t.Fatalf("expected 1 BuildConsoleURL call, got %d", federation.BuildConsoleURLCalls.Load())
 				}
 				if !strings.Contains(state.stdout.String(), "Authenticated as: arn:aws:iam::123456789012:user/test") {
 					t.Fatalf("expected authenticated output, got: %q", state.stdout.String())
@@ -126,14 +191,14 @@ func TestRunWorkflow(t *testing.T) {
 						SessionToken:    "",
 					}, nil
 				}
-				svc.GetSessionTokenFunc = func(ctx context.Context, profile string, durationSeconds int32) (awslib.Credentials, error) {
+				svc.GetSessionTokenFunc = func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
 					return awslib.Credentials{
 						AccessKeyID:     "AKIA_TEMP",
 						SecretAccessKey: "temp-secret",
 						SessionToken:    "temp-token",
 					}, nil
 				}
-				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32) (string, error) {
+				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
 					return "https://example.com/federated", nil
 				}
 			},
@@ -142,11 +207,11 @@ func TestRunWorkflow(t *testing.T) {
 				if state.loginCalls != 1 {
 					t.Fatalf("expected login to be called once, got %d", state.loginCalls)
 				}
-				if svc.GetCallerIdentityCalls != 2 {
-					t.Fatalf("expected 2 GetCallerIdentity calls, got %d", svc.GetCallerIdentityCalls)
+				if svc.GetCallerIdentityCalls.Load() != 2 {
+					t.Fatalf("expected 2 GetCallerIdentity calls, got %d", svc.GetCallerIdentityCalls.Load())
 				}
-				if svc.GetSessionTokenCalls != 1 {
-					t.Fatalf("expected 1 GetSessionToken call, got %d", svc.GetSessionTokenCalls)
+				if svc.GetSessionTokenCalls.Load() != 1 {
+					t.Fatalf("expected 1 GetSessionToken call, got %d", svc.GetSessionTokenCalls.Load())
 				}
 				if federation.LastCredentials.AccessKeyID != "AKIA_TEMP" {
 					t.Fatalf("expected temporary credentials to be used, got %+v", federation.LastCredentials)
@@ -212,7 +277,7 @@ func TestRunWorkflow(t *testing.T) {
 						SessionToken:    "",
 					}, nil
 				}
-				svc.GetSessionTokenFunc = func(ctx context.Context, profile string, durationSeconds int32) (awslib.Credentials, error) {
+				svc.GetSessionTokenFunc = func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
 					return awslib.Credentials{}, errors.New("token request failed")
 				}
 			},
@@ -233,7 +298,7 @@ func TestRunWorkflow(t *testing.T) {
 						SessionToken:    "token",
 					}, nil
 				}
-				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32) (string, error) {
+				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
 					return "", errors.New("federation failed")
 				}
 			},
@@ -255,7 +320,7 @@ func TestRunWorkflow(t *testing.T) {
 						SessionToken:    "token",
 					}, nil
 				}
-				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32) (string, error) {
+				federation.BuildConsoleURLFunc = func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
 					return "https://example.com/console-login", nil
 				}
 			},
@@ -275,13 +340,13 @@ func TestRunWorkflow(t *testing.T) {
 				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
 					return awslib.Credentials{}, fmt.Errorf("unexpected RetrieveCredentials call")
 				},
-				GetSessionTokenFunc: func(ctx context.Context, profile string, durationSeconds int32) (awslib.Credentials, error) {
+				GetSessionTokenFunc: func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
 					return awslib.Credentials{}, fmt.Errorf("unexpected GetSessionToken call")
 				},
 			}
 
 			federation := &mocks.FederationBuilder{
-				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32) (string, error) {
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
 					return "", fmt.Errorf("unexpected BuildConsoleURL call")
 				},
 			}
@@ -292,7 +357,7 @@ func TestRunWorkflow(t *testing.T) {
 			deps := runDeps{
 				awsService: svc,
 				federation: federation,
-				login: func(profile string) error {
+				login: func(ctx context.Context, profile string) error {
 					state.loginCalls++
 					state.lastLoginProfile = profile
 					if state.expectedLoginProfile != "" && profile != state.expectedLoginProfile {
@@ -300,16 +365,17 @@ func TestRunWorkflow(t *testing.T) {
 					}
 					return state.loginErr
 				},
-				open: func(targetURL string) error {
+				open: func(targetURL string, opts browserOptions) error {
 					state.openedURL = targetURL
 					return state.openErr
 				},
+				term:            interactiveTerminal,
 				stdout:          &state.stdout,
 				stderr:          &state.stderr,
 				sessionDuration: sessionDuration,
 			}
 
-			err := runWorkflow(context.Background(), tc.profile, deps)
+			err := runWorkflow(context.Background(), workflowOptions{profile: tc.profile}, deps)
 			if tc.wantErr != "" {
 				if err == nil {
 					t.Fatalf("expected error containing %q but got nil", tc.wantErr)
@@ -367,15 +433,15 @@ func TestNewRootCmdProfileResolution(t *testing.T) {
 			deps := runDeps{
 				awsService:      &mocks.Service{},
 				federation:      &mocks.FederationBuilder{},
-				login:           func(profile string) error { return nil },
-				open:            func(targetURL string) error { return nil },
+				login:           func(ctx context.Context, profile string) error { return nil },
+				open:            func(targetURL string, opts browserOptions) error { return nil },
 				stdout:          &bytes.Buffer{},
 				stderr:          &bytes.Buffer{},
 				sessionDuration: sessionDuration,
 			}
 
-			root := newRootCmd(deps, func(ctx context.Context, profile string, deps runDeps) error {
-				capturedProfile = profile
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				capturedProfile = opts.profile
 				return nil
 			})
 			root.SetArgs(tc.args)
@@ -395,9 +461,9 @@ func TestNewRootCmdProfileFlagConfigured(t *testing.T) {
 	t.Parallel()
 
 	root := NewRootCmd()
-	flag := root.Flags().Lookup("profile")
+	flag := root.PersistentFlags().Lookup("profile")
 	if flag == nil {
-		t.Fatal("expected profile flag to be registered")
+		t.Fatal("expected profile flag to be registered as a persistent flag")
 	}
 	if flag.Shorthand != "p" {
 		t.Fatalf("expected shorthand 'p', got %q", flag.Shorthand)
@@ -432,14 +498,14 @@ func TestNewRootCmdPrintsVersionAndSkipsWorkflow(t *testing.T) {
 	deps := runDeps{
 		awsService:      &mocks.Service{},
 		federation:      &mocks.FederationBuilder{},
-		login:           func(profile string) error { return nil },
-		open:            func(targetURL string) error { return nil },
+		login:           func(ctx context.Context, profile string) error { return nil },
+		open:            func(targetURL string, opts browserOptions) error { return nil },
 		stdout:          stdout,
 		stderr:          &bytes.Buffer{},
 		sessionDuration: sessionDuration,
 	}
 
-	root := newRootCmd(deps, func(ctx context.Context, profile string, deps runDeps) error {
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
 		runnerCalls++
 		return nil
 	})
@@ -499,7 +565,7 @@ func TestSSOLogin(t *testing.T) {
 				stderr:   &bytes.Buffer{},
 			}
 
-			err := ssoLogin(tc.profile, deps)
+			err := ssoLogin(context.Background(), tc.profile, deps)
 			if tc.wantErrSubstr != "" {
 				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
 					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
@@ -522,48 +588,286 @@ func TestSSOLogin(t *testing.T) {
 	}
 }
 
-func TestOpenBrowser(t *testing.T) {
+func TestSSOLoginStopsWithContext(t *testing.T) {
 	t.Parallel()
 
-	testCases := []struct {
-		name          string
-		goos          string
-		startErr      error
-		wantName      string
-		wantArgs      []string
-		wantErrSubstr string
-	}{
-		{
-			name:     "darwin",
-			goos:     "darwin",
-			wantName: "open",
-			wantArgs: []string{"https://example.com"},
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deps := runDeps{
+		executor: &fakeExecutor{},
+		stdin:    strings.NewReader(""),
+		stdout:   &bytes.Buffer{},
+		stderr:   &bytes.Buffer{},
+	}
+	if err := ssoLogin(ctx, "dev", deps); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the canceled login to fail, got %v", err)
+	}
+}
+
+func TestOSExecutorRunContextKillsCommand(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not installed")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := osExecutor{}.RunContext(ctx, "sleep", []string{"10"}, nil, nil, io.Discard, io.Discard)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "sleep was stopped") {
+		t.Fatalf("expected sleep to be stopped, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected sleep to be killed, it ran for %s", elapsed)
+	}
+}
+
+func TestRunWorkflowDeadline(t *testing.T) {
+	t.Parallel()
+
+	opened := false
+	deps := runDeps{
+		awsService: &mocks.Service{
+			// A stuck STS call only returns when its context ends.
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				<-ctx.Done()
+				return awslib.Identity{}, fmt.Errorf("failed to get caller identity: %w", ctx.Err())
+			},
 		},
-		{
-			name:     "linux",
-			goos:     "linux",
-			wantName: "xdg-open",
-			wantArgs: []string{"https://example.com"},
+		open: func(targetURL string, opts browserOptions) error {
+			opened = true
+			return nil
 		},
-		{
-			name:     "windows",
-			goos:     "windows",
-			wantName: "rundll32",
-			wantArgs: []string{"url.dll,FileProtocolHandler", "https://example.com"},
+		term:            interactiveTerminal,
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+		deadline:        10 * time.Millisecond,
+	}
+
+	err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "gave up after the 10ms deadline") {
+		t.Fatalf("expected the deadline to stop the workflow, got %v", err)
+	}
+	if opened {
+		t.Fatal("expected the console not to open")
+	}
+}
+
+func TestRunWorkflowRecordsUsage(t *testing.T) {
+	t.Parallel()
+
+	openedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	store := usage.NewStoreAt(filepath.Join(t.TempDir(), "usage.json"))
+	sessionStore := sessions.NewStoreAt(filepath.Join(t.TempDir(), sessions.FileName))
+	historyStore := history.NewStoreAt(filepath.Join(t.TempDir(), history.FileName))
+
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
 		},
-		{
-			name:          "unsupported",
-			goos:          "plan9",
-			wantErrSubstr: "unsupported platform: plan9",
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://example.com/console-login", nil
+			},
 		},
-		{
-			name:          "start error",
-			goos:          "linux",
-			startErr:      errors.New("start failed"),
-			wantName:      "xdg-open",
-			wantArgs:      []string{"https://example.com"},
-			wantErrSubstr: "start failed",
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		term:            interactiveTerminal,
+		usage:           store,
+		sessions:        sessionStore,
+		history:         historyStore,
+		now:             func() time.Time { return openedAt },
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "dev-profile", destination: "ec2/home"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error loading usage: %v", err)
+	}
+	if got := entries["dev-profile"]; got.Count != 1 || !got.LastUsed.Equal(openedAt) {
+		t.Fatalf("unexpected usage entry: %+v", got)
+	}
+
+	active, err := sessionStore.Active(openedAt)
+	if err != nil {
+		t.Fatalf("unexpected error loading sessions: %v", err)
+	}
+	if len(active) != 1 || active[0].Profile != "dev-profile" || active[0].Account != "123456789012" ||
+		active[0].Destination != "ec2/home" || !active[0].ExpiresAt.Equal(openedAt.Add(12*time.Hour)) {
+		t.Fatalf("unexpected recorded sessions: %+v", active)
+	}
+
+	recorded, err := historyStore.Query(history.Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error loading history: %v", err)
+	}
+	want := history.Entry{Time: openedAt, Profile: "dev-profile", Account: "123456789012", Destination: "ec2/home"}
+	if len(recorded) != 1 || recorded[0] != want {
+		t.Fatalf("unexpected history: %+v", recorded)
+	}
+}
+
+func TestRunWorkflowSkipsHistoryWhenOpenFails(t *testing.T) {
+	t.Parallel()
+
+	historyStore := history.NewStoreAt(filepath.Join(t.TempDir(), history.FileName))
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://example.com/console-login", nil
+			},
+		},
+		open:            func(targetURL string, opts browserOptions) error { return errors.New("no browser") },
+		term:            interactiveTerminal,
+		history:         historyStore,
+		now:             time.Now,
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "dev-profile"}, deps); err == nil || !strings.Contains(err.Error(), "no browser") {
+		t.Fatalf("expected the browser error, got %v", err)
+	}
+	if recorded, err := historyStore.Query(history.Filter{}); err != nil || len(recorded) != 0 {
+		t.Fatalf("expected no history, got %+v (%v)", recorded, err)
+	}
+}
+
+func TestRunWorkflowPipedStdout(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	opened := false
+
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://example.com/console-login", nil
+			},
+		},
+		open: func(targetURL string, opts browserOptions) error {
+			opened = true
+			return nil
 		},
+		term:            term.Info{StdinTTY: true, StderrTTY: true},
+		stdout:          stdout,
+		stderr:          stderr,
+		sessionDuration: sessionDuration,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened {
+		t.Fatal("expected browser not to be opened when stdout is piped")
+	}
+	if got := stdout.String(); got != "https://example.com/console-login\n" {
+		t.Fatalf("expected stdout to contain only the URL, got %q", got)
+	}
+	if !strings.Contains(stderr.String(), "Authenticated as: arn:aws:iam::123456789012:user/test") {
+		t.Fatalf("expected status output on stderr, got %q", stderr.String())
+	}
+}
+
+func TestRunWorkflowPrintOnTerminal(t *testing.T) {
+	t.Parallel()
+
+	for _, flag := range []string{"--print", "--no-open"} {
+		flag := flag
+		t.Run(flag, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			opened := false
+
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = true
+					return nil
+				},
+				term:            interactiveTerminal,
+				stdout:          stdout,
+				stderr:          stderr,
+				sessionDuration: sessionDuration,
+			}
+			root := newRootCmd(deps, runWorkflow)
+			root.SetArgs([]string{"-p", "dev", flag})
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opened {
+				t.Fatalf("expected browser not to be opened with %s", flag)
+			}
+			if got := stdout.String(); got != "https://example.com/console-login\n" {
+				t.Fatalf("expected stdout to contain only the URL, got %q", got)
+			}
+			if !strings.Contains(stderr.String(), "Authenticated as:") {
+				t.Fatalf("expected status output on stderr, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestNewRootCmdQuiet(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		wantStdout    string
+		wantOpened    bool
+		wantErrSubstr string
+	}{
+		{name: "print", args: []string{"-p", "dev", "--quiet", "--print"}, wantStdout: "https://example.com/console-login\n"},
+		{name: "open", args: []string{"-p", "dev", "-q"}, wantOpened: true},
+		{name: "with verbose", args: []string{"-p", "dev", "-q", "--verbose"}, wantErrSubstr: "--quiet cannot be used with --verbose"},
 	}
 
 	for _, tc := range testCases {
@@ -571,38 +875,1302 @@ func TestOpenBrowser(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			executor := &fakeExecutor{startErr: tc.startErr}
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			opened := false
 			deps := runDeps{
-				executor: executor,
-				goos:     tc.goos,
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test", Account: "123456789012"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = true
+					return nil
+				},
+				term:            interactiveTerminal,
+				stdout:          stdout,
+				stderr:          stderr,
+				sessionDuration: sessionDuration,
 			}
+			root := newRootCmd(deps, runWorkflow)
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
 
-			err := openBrowser("https://example.com", deps)
+			err := root.Execute()
 			if tc.wantErrSubstr != "" {
 				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
 					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
 				}
-			} else if err != nil {
+				return
+			}
+			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+			if opened != tc.wantOpened {
+				t.Fatalf("opened = %v, want %v", opened, tc.wantOpened)
+			}
+			if got := stdout.String(); got != tc.wantStdout {
+				t.Fatalf("expected stdout %q, got %q", tc.wantStdout, got)
+			}
+			if stderr.Len() != 0 {
+				t.Fatalf("expected nothing on stderr, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunWorkflowCopy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		copyErr       error
+		wantPrinted   bool
+		wantErrSubstr string
+	}{
+		{name: "copy instead of opening", args: []string{"--copy"}},
+		{name: "copy and print", args: []string{"--copy", "--print"}, wantPrinted: true},
+		{
+			name:          "clipboard failure",
+			args:          []string{"--copy"},
+			copyErr:       errors.New("no clipboard command found"),
+			wantErrSubstr: "failed to copy the sign-in URL to the clipboard: no clipboard command found",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			opened := false
+			var copied []string
+
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = true
+					return nil
+				},
+				copy: func(text string) error {
+					copied = append(copied, text)
+					return tc.copyErr
+				},
+				term:            interactiveTerminal,
+				stdout:          stdout,
+				stderr:          stderr,
+				sessionDuration: sessionDuration,
+			}
+			root := newRootCmd(deps, runWorkflow)
+			root.SetArgs(append([]string{"-p", "dev"}, tc.args...))
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
 
-			if tc.goos == "plan9" {
-				if len(executor.calls) != 0 {
-					t.Fatalf("expected no executor calls for unsupported platform, got %d", len(executor.calls))
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
 				}
 				return
 			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if opened {
+				t.Fatal("expected browser not to be opened with --copy")
+			}
+			if len(copied) != 1 || copied[0] != "https://example.com/console-login" {
+				t.Fatalf("expected the sign-in URL to be copied, got %v", copied)
+			}
+			if printed := strings.Contains(stdout.String(), "https://example.com/console-login"); printed != tc.wantPrinted {
+				t.Fatalf("expected the URL to be printed: %v, got stdout %q", tc.wantPrinted, stdout.String())
+			}
+			if !strings.Contains(stdout.String()+stderr.String(), "Copied the sign-in URL to the clipboard.") {
+				t.Fatalf("expected a confirmation, got stdout %q and stderr %q", stdout.String(), stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunWorkflowHeadless(t *testing.T) {
+	t.Parallel()
+
+	headlessTerminal := interactiveTerminal
+	headlessTerminal.Headless = true
+
+	testCases := []struct {
+		name         string
+		args         []string
+		term         term.Info
+		copyErr      error
+		wantOpened   bool
+		wantCopied   bool
+		wantPrinted  bool
+		wantQR       bool
+		wantDetected bool
+	}{
+		{name: "display", term: interactiveTerminal, wantOpened: true},
+		{name: "no display", term: headlessTerminal, wantCopied: true, wantPrinted: true, wantQR: true, wantDetected: true},
+		{name: "no clipboard", term: headlessTerminal, copyErr: errors.New("no clipboard command found"), wantPrinted: true, wantQR: true, wantDetected: true},
+		{name: "forced", args: []string{"--headless"}, term: interactiveTerminal, wantCopied: true, wantPrinted: true, wantQR: true},
+		{name: "forced off", args: []string{"--headless=false"}, term: headlessTerminal, wantOpened: true},
+		{name: "copy only", args: []string{"--copy"}, term: headlessTerminal, wantCopied: true},
+		{name: "accessible", args: []string{"--accessible"}, term: headlessTerminal, wantCopied: true, wantPrinted: true, wantDetected: true},
+		{name: "accessible qr", args: []string{"--qr", "--accessible"}, term: interactiveTerminal, wantPrinted: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			opened, copied := false, false
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = true
+					return nil
+				},
+				copy: func(text string) error {
+					copied = tc.copyErr == nil
+					return tc.copyErr
+				},
+				term:            tc.term,
+				stdout:          stdout,
+				stderr:          stderr,
+				sessionDuration: sessionDuration,
+			}
+			root := newRootCmd(deps, runWorkflow)
+			root.SetArgs(append([]string{"-p", "dev"}, tc.args...))
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
 
-			if len(executor.calls) != 1 {
-				t.Fatalf("expected 1 executor call, got %d", len(executor.calls))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
 			}
-			call := executor.calls[0]
-			if call.method != "start" || call.name != tc.wantName {
-				t.Fatalf("unexpected executor call: %+v", call)
+			if opened != tc.wantOpened || copied != tc.wantCopied {
+				t.Fatalf("expected opened %v and copied %v, got %v and %v", tc.wantOpened, tc.wantCopied, opened, copied)
 			}
-			if strings.Join(call.args, "|") != strings.Join(tc.wantArgs, "|") {
-				t.Fatalf("unexpected args: got %v want %v", call.args, tc.wantArgs)
+			if printed := strings.TrimSpace(stdout.String()) == "https://example.com/console-login"; printed != tc.wantPrinted {
+				t.Fatalf("expected the URL to be printed: %v, got stdout %q", tc.wantPrinted, stdout.String())
+			}
+			if qr := strings.Contains(stderr.String(), "Scan to open the AWS Console"); qr != tc.wantQR {
+				t.Fatalf("expected a QR code: %v, got stderr %q", tc.wantQR, stderr.String())
+			}
+			if detected := strings.Contains(stderr.String(), "No display found"); detected != tc.wantDetected {
+				t.Fatalf("expected the missing display to be reported: %v, got stderr %q", tc.wantDetected, stderr.String())
 			}
 		})
 	}
 }
+
+func TestRunWorkflowQRCode(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+	opened := false
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLsFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destinations []string) ([]string, error) {
+				urls := make([]string, len(destinations))
+				for i := range destinations {
+					urls[i] = fmt.Sprintf("https://example.com/console-login?region=%d", i)
+				}
+				return urls, nil
+			},
+		},
+		open: func(targetURL string, opts browserOptions) error {
+			opened = true
+			return nil
+		},
+		term:            interactiveTerminal,
+		stdout:          stdout,
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+	}
+
+	opts := workflowOptions{profile: "dev", qr: true, regions: []string{"us-east-1", "eu-west-1"}}
+	if err := runWorkflow(context.Background(), opts, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened {
+		t.Fatal("expected browser not to be opened with --qr")
+	}
+
+	code, err := qr.Encode("https://example.com/console-login?region=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "Scan to open the AWS Console in us-east-1") || !strings.Contains(out, "Scan to open the AWS Console in eu-west-1") {
+		t.Fatalf("expected a QR code per region, got %q", out)
+	}
+	if !strings.Contains(out, code.Terminal()) {
+		t.Fatal("expected the QR code of the eu-west-1 sign-in URL")
+	}
+	if strings.Contains(out, "https://example.com/console-login") {
+		t.Fatalf("expected the URL not to be printed without --print, got %q", out)
+	}
+}
+
+func TestStatusColor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		term      term.Info
+		printOnly bool
+		want      bool
+	}{
+		{name: "terminal", term: interactiveTerminal, want: true},
+		{name: "no color", term: term.Info{StdinTTY: true, StdoutTTY: true, StderrTTY: true, Term: "xterm-256color", NoColor: true}},
+		{name: "accessible", term: term.Info{StdinTTY: true, StdoutTTY: true, StderrTTY: true, Term: "xterm-256color", Accessible: true}},
+		{name: "stdout piped", term: term.Info{StdinTTY: true, StderrTTY: true, Term: "xterm-256color"}, printOnly: true, want: true},
+		{name: "stderr redirected", term: term.Info{StdinTTY: true, StdoutTTY: true, Term: "xterm-256color"}, printOnly: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{term: tc.term, printOnly: tc.printOnly}
+			if got := statusColor(deps); got != tc.want {
+				t.Fatalf("expected color %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSSOLoginPipedStdoutUsesStderr(t *testing.T) {
+	t.Parallel()
+
+	var gotStdout io.Writer
+	stderr := &bytes.Buffer{}
+	executor := &recordingExecutor{run: func(stdout io.Writer) { gotStdout = stdout }}
+
+	deps := runDeps{executor: executor, stdout: &bytes.Buffer{}, stderr: stderr}
+	if err := ssoLogin(context.Background(), "dev", deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotStdout != stderr {
+		t.Fatal("expected aws sso login output to be routed to stderr when stdout is piped")
+	}
+}
+
+type recordingExecutor struct {
+	run func(stdout io.Writer)
+}
+
+func (r *recordingExecutor) Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	r.run(stdout)
+	return nil
+}
+
+func (r *recordingExecutor) RunContext(ctx context.Context, name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	return r.Run(name, args, stdin, stdout, stderr)
+}
+
+func (r *recordingExecutor) Start(name string, args []string) error {
+	return nil
+}
+
+func (r *recordingExecutor) Exec(name string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (int, error) {
+	return 0, nil
+}
+
+func TestRunWorkflowHyperlink(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		term     term.Info
+		wantLink bool
+	}{
+		{
+			name:     "capable terminal",
+			term:     term.Info{StdinTTY: true, StdoutTTY: true, StderrTTY: true, Term: "xterm-256color", LinkCapable: true},
+			wantLink: true,
+		},
+		{
+			name: "unknown terminal",
+			term: interactiveTerminal,
+		},
+		{
+			name: "dumb terminal",
+			term: term.Info{StdinTTY: true, StdoutTTY: true, StderrTTY: true, Term: "dumb", LinkCapable: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				open:            func(targetURL string, opts browserOptions) error { return nil },
+				term:            tc.term,
+				stdout:          stdout,
+				stderr:          &bytes.Buffer{},
+				sessionDuration: sessionDuration,
+			}
+
+			if err := runWorkflow(context.Background(), workflowOptions{profile: "prod"}, deps); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			link := "\x1b]8;;https://example.com/console-login\x1b\\Open AWS Console – prod\x1b]8;;\x1b\\"
+			if got := strings.Contains(stdout.String(), link); got != tc.wantLink {
+				t.Fatalf("expected link presence %v, got output %q", tc.wantLink, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunWorkflowLocalRedirect(t *testing.T) {
+	t.Parallel()
+
+	const loginURL = "https://signin.aws.amazon.com/federation?Action=login&SigninToken=secret"
+	var opened, location string
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return loginURL, nil
+			},
+		},
+		// The browser follows the one-time URL in the background, as a real one would.
+		open: func(targetURL string, opts browserOptions) error {
+			opened = targetURL
+			client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+			resp, err := client.Get(targetURL)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			location = resp.Header.Get("Location")
+			return nil
+		},
+		term:            term.Info{StdinTTY: true, StdoutTTY: true, StderrTTY: true, Term: "xterm-256color", LinkCapable: true},
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+		localRedirect:   true,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "prod"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(opened, "http://127.0.0.1:") || strings.Contains(opened, "secret") {
+		t.Fatalf("expected the browser to open a loopback URL, got %q", opened)
+	}
+	if location != loginURL {
+		t.Fatalf("expected a redirect to the sign-in URL, got %q", location)
+	}
+	if strings.Contains(deps.stdout.(*bytes.Buffer).String(), "secret") {
+		t.Fatal("expected the sign-in URL to stay out of the output")
+	}
+}
+
+func TestConsoleLinkWithoutProfile(t *testing.T) {
+	t.Parallel()
+
+	if got := consoleLink("https://example.com", "", ""); !strings.Contains(got, "\x1b\\Open AWS Console\x1b]8;;") {
+		t.Fatalf("unexpected link %q", got)
+	}
+}
+
+func TestRunWorkflowVerbose(t *testing.T) {
+	t.Parallel()
+
+	stderr := &bytes.Buffer{}
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://example.com/console-login", nil
+			},
+		},
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		term:            interactiveTerminal,
+		stdout:          &bytes.Buffer{},
+		stderr:          stderr,
+		verbose:         true,
+		sessionDuration: 3600,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{`Checking credentials for profile "dev"`, "sign-in token for a 1h0m0s session"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Fatalf("expected stderr to contain %q, got %q", want, stderr.String())
+		}
+	}
+}
+
+func TestNewRootCmdPositionalProfile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		listErr       error
+		wantProfile   string
+		wantErrSubstr string
+	}{
+		{
+			name:        "configured profile",
+			args:        []string{"dev"},
+			wantProfile: "dev",
+		},
+		{
+			name:        "matches profile flag",
+			args:        []string{"dev", "--profile", "dev"},
+			wantProfile: "dev",
+		},
+		{
+			name:          "conflicts with profile flag",
+			args:          []string{"dev", "--profile", "keys"},
+			wantErrSubstr: `conflicting profiles: "dev" given as an argument but --profile is "keys"`,
+		},
+		{
+			name:          "unknown profile",
+			args:          []string{"prod-admin"},
+			wantErrSubstr: `profile "prod-admin" not found in AWS config`,
+		},
+		{
+			name:          "config unreadable",
+			args:          []string{"dev"},
+			listErr:       errors.New("boom"),
+			wantErrSubstr: "failed to list profiles: boom",
+		},
+		{
+			name:          "unknown page after a profile",
+			args:          []string{"dev", "keys"},
+			wantErrSubstr: `no console page matches "keys"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			capturedProfile := "__unset__"
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return testProfiles(), tc.listErr
+					},
+				},
+				stdout: &bytes.Buffer{},
+				stderr: &bytes.Buffer{},
+			}
+
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				capturedProfile = opts.profile
+				return nil
+			})
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if capturedProfile != tc.wantProfile {
+				t.Fatalf("expected profile %q, got %q", tc.wantProfile, capturedProfile)
+			}
+		})
+	}
+}
+
+func TestRunWorkflowUsesIdentityPartition(t *testing.T) {
+	t.Parallel()
+
+	var gotPartition string
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws-us-gov:iam::123456789012:user/test", Partition: awslib.PartitionUSGov}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				gotPartition = awslib.PartitionFromContext(ctx)
+				return "https://signin.amazonaws-us-gov.com/federation", nil
+			},
+		},
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		term:            interactiveTerminal,
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{profile: "gov"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPartition != awslib.PartitionUSGov {
+		t.Fatalf("expected federation to use partition %q, got %q", awslib.PartitionUSGov, gotPartition)
+	}
+}
+
+func TestRunWorkflowPartitionOverride(t *testing.T) {
+	t.Parallel()
+
+	var gotPartition string
+	stderr := &bytes.Buffer{}
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test", Partition: awslib.PartitionAWS}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				gotPartition = awslib.PartitionFromContext(ctx)
+				return "https://signin.amazonaws.cn/federation", nil
+			},
+		},
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		term:            interactiveTerminal,
+		stdout:          &bytes.Buffer{},
+		stderr:          stderr,
+		sessionDuration: sessionDuration,
+	}
+
+	ctx := awslib.WithPartition(context.Background(), awslib.PartitionChina)
+	if err := runWorkflow(ctx, workflowOptions{profile: "cn"}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPartition != awslib.PartitionChina {
+		t.Fatalf("expected federation to use partition %q, got %q", awslib.PartitionChina, gotPartition)
+	}
+	if !strings.Contains(stderr.String(), "Warning: --partition aws-cn differs from the partition of arn:aws:iam::123456789012:user/test") {
+		t.Fatalf("expected a partition mismatch warning, got %q", stderr.String())
+	}
+}
+
+func TestRunWorkflowMultipleRegions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		term       term.Info
+		wantOpened []string
+		wantStdout string
+	}{
+		{
+			name:       "opens each region",
+			term:       interactiveTerminal,
+			wantOpened: []string{"login:ec2/home?region=us-east-1", "login:ec2/home?region=eu-west-1"},
+		},
+		{
+			name:       "prints each region when piped",
+			wantStdout: "login:ec2/home?region=us-east-1\nlogin:ec2/home?region=eu-west-1\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opened []string
+			stdout := &bytes.Buffer{}
+			federation := &mocks.FederationBuilder{
+				BuildConsoleURLsFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destinations []string) ([]string, error) {
+					urls := make([]string, 0, len(destinations))
+					for _, d := range destinations {
+						urls = append(urls, "login:"+d)
+					}
+					return urls, nil
+				},
+			}
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: federation,
+				open: func(targetURL string, opts browserOptions) error {
+					opened = append(opened, targetURL)
+					return nil
+				},
+				term:            tc.term,
+				stdout:          stdout,
+				stderr:          &bytes.Buffer{},
+				sessionDuration: sessionDuration,
+			}
+
+			opts := workflowOptions{profile: "dev", destination: "ec2/home", regions: []string{"us-east-1", "eu-west-1"}}
+			if err := runWorkflow(context.Background(), opts, deps); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if federation.BuildConsoleURLsCalls.Load() != 1 || federation.BuildConsoleURLCalls.Load() != 0 {
+				t.Fatalf("expected one multi-destination federation call, got %d/%d", federation.BuildConsoleURLsCalls.Load(), federation.BuildConsoleURLCalls.Load())
+			}
+			if strings.Join(opened, ",") != strings.Join(tc.wantOpened, ",") {
+				t.Fatalf("expected opened %v, got %v", tc.wantOpened, opened)
+			}
+			if tc.wantStdout != "" && stdout.String() != tc.wantStdout {
+				t.Fatalf("expected stdout %q, got %q", tc.wantStdout, stdout.String())
+			}
+		})
+	}
+}
+
+func TestWorkflowFlagsRejectInvalidRegions(t *testing.T) {
+	t.Parallel()
+
+	root := newRootCmd(runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		t.Fatal("workflow should not run")
+		return nil
+	})
+	root.SetArgs([]string{"--regions", "us-east-1,mars-1"})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), `invalid region "mars-1"`) {
+		t.Fatalf("expected invalid region error, got %v", err)
+	}
+}
+
+func TestRunWorkflowShowsCachedAccountAlias(t *testing.T) {
+	t.Parallel()
+
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test", Account: "123456789012"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+		},
+		DescribeAccountFunc: func(ctx context.Context, profile string, accountID string) (awslib.AccountInfo, error) {
+			return awslib.AccountInfo{ID: accountID, Alias: "acme-dev"}, nil
+		},
+	}
+	cache := accounts.NewCacheAt(filepath.Join(t.TempDir(), accounts.FileName), accounts.DefaultTTL)
+
+	for i := 0; i < 2; i++ {
+		stdout := &bytes.Buffer{}
+		deps := runDeps{
+			awsService: service,
+			federation: &mocks.FederationBuilder{
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+					return "https://example.com/console-login", nil
+				},
+			},
+			accounts:        cache,
+			open:            func(targetURL string, opts browserOptions) error { return nil },
+			term:            interactiveTerminal,
+			now:             time.Now,
+			stdout:          stdout,
+			stderr:          &bytes.Buffer{},
+			sessionDuration: sessionDuration,
+		}
+
+		if err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(stdout.String(), "Account: acme-dev (123456789012)") {
+			t.Fatalf("expected account alias in output, got:\n%s", stdout.String())
+		}
+	}
+	if service.DescribeAccountCalls.Load() != 1 {
+		t.Fatalf("expected the second run to use the cache, got %d lookups", service.DescribeAccountCalls.Load())
+	}
+}
+
+func TestRunWorkflowShowsAccount(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		alias    string
+		aliasErr error
+		want     string
+	}{
+		{name: "alias", alias: "acme-dev", want: "Account: acme-dev (123456789012)\n"},
+		{name: "no alias", want: "Account: 123456789012\n"},
+		{name: "lookup failure", aliasErr: errors.New("connection reset"), want: "Account: 123456789012\n"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test", Account: "123456789012"}, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+				},
+				GetAccountAliasFunc: func(ctx context.Context, profile string) (string, error) {
+					return tc.alias, tc.aliasErr
+				},
+			}
+			stdout := &bytes.Buffer{}
+			deps := runDeps{
+				awsService: service,
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				open:            func(targetURL string, opts browserOptions) error { return nil },
+				term:            interactiveTerminal,
+				now:             time.Now,
+				stdout:          stdout,
+				stderr:          &bytes.Buffer{},
+				sessionDuration: sessionDuration,
+			}
+
+			if err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout.String(), "Authenticated as: arn:aws:iam::123456789012:user/test\n"+tc.want) {
+				t.Fatalf("expected %q after the identity, got:\n%s", tc.want, stdout.String())
+			}
+			if service.GetAccountAliasCalls.Load() != 1 {
+				t.Fatalf("expected one alias lookup, got %d", service.GetAccountAliasCalls.Load())
+			}
+		})
+	}
+}
+
+func TestSigninTokenScope(t *testing.T) {
+	t.Parallel()
+
+	full := signinTokenScope(workflowOptions{}, runDeps{})
+	scopes := map[string]string{
+		"read-only":   signinTokenScope(workflowOptions{readOnly: true}, runDeps{}),
+		"policy":      signinTokenScope(workflowOptions{}, runDeps{sessionPolicy: &awslib.SessionPolicy{Policy: `{"Version":"2012-10-17"}`}}),
+		"policy ARNs": signinTokenScope(workflowOptions{}, runDeps{sessionPolicy: &awslib.SessionPolicy{PolicyARNs: []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}}}),
+	}
+	for name, scope := range scopes {
+		if scope == full {
+			t.Fatalf("expected a %s session to have its own scope, got %q", name, scope)
+		}
+	}
+	if scopes["policy"] == scopes["policy ARNs"] {
+		t.Fatalf("expected different policies to have different scopes, got %q", scopes["policy"])
+	}
+}
+
+func TestRunWorkflowCachesCredentials(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		noCache       bool
+		duration      int32
+		wantSTSCalls  int64
+		wantCredCalls int64
+	}{
+		{name: "second run uses the cache", wantSTSCalls: 1, wantCredCalls: 1},
+		{name: "--no-cache", noCache: true, wantSTSCalls: 2, wantCredCalls: 2},
+		{name: "cache expires before --duration ends", duration: 7200, wantSTSCalls: 2, wantCredCalls: 2},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012"}, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+				},
+			}
+			federation := &mocks.FederationBuilder{
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+					if creds.AccessKeyID != "ASIA" {
+						t.Fatalf("unexpected credentials: %+v", creds)
+					}
+					return "https://example.com/console-login", nil
+				},
+			}
+			cache := credcache.NewCacheAt(t.TempDir())
+
+			for i := 0; i < 2; i++ {
+				stdout := &bytes.Buffer{}
+				deps := runDeps{
+					awsService:      service,
+					federation:      federation,
+					credentials:     cache,
+					open:            func(targetURL string, opts browserOptions) error { return nil },
+					term:            interactiveTerminal,
+					stdout:          stdout,
+					stderr:          &bytes.Buffer{},
+					now:             time.Now,
+					sessionDuration: sessionDuration,
+				}
+				if tc.duration != 0 {
+					deps.sessionDuration, deps.durationSet = tc.duration, true
+				}
+				if err := runWorkflow(context.Background(), workflowOptions{profile: "dev", noCache: tc.noCache}, deps); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.Contains(stdout.String(), "Console session valid for ") {
+					t.Fatalf("expected the session validity to be reported, got:\n%s", stdout.String())
+				}
+			}
+			if service.GetCallerIdentityCalls.Load() != tc.wantSTSCalls {
+				t.Fatalf("expected %d GetCallerIdentity calls, got %d", tc.wantSTSCalls, service.GetCallerIdentityCalls.Load())
+			}
+			if service.RetrieveCredentialsCalls.Load() != tc.wantCredCalls {
+				t.Fatalf("expected %d RetrieveCredentials calls, got %d", tc.wantCredCalls, service.RetrieveCredentialsCalls.Load())
+			}
+			if federation.BuildConsoleURLCalls.Load() != 2 {
+				t.Fatalf("expected a console URL per run, got %d", federation.BuildConsoleURLCalls.Load())
+			}
+		})
+	}
+}
+
+func TestRunWorkflowSkipsValidation(t *testing.T) {
+	t.Parallel()
+
+	identity := awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012"}
+	testCases := []struct {
+		name          string
+		validate      string
+		skipValidate  bool
+		remembered    bool
+		failFirst     error
+		wantSTSCalls  int64
+		wantCredCalls int64
+		wantErrSubstr string
+	}{
+		{name: "always", validate: validateAlways, remembered: true, wantSTSCalls: 1, wantCredCalls: 1},
+		{name: "recent", validate: validateRecent, remembered: true, wantSTSCalls: 0, wantCredCalls: 1},
+		{name: "--skip-validate", skipValidate: true, remembered: true, wantSTSCalls: 0, wantCredCalls: 1},
+		{name: "nothing remembered", validate: validateSkip, wantSTSCalls: 1, wantCredCalls: 1},
+		{name: "federation fails", validate: validateSkip, remembered: true, failFirst: &smithy.GenericAPIError{Code: "ExpiredToken", Message: "the SSO session has expired"}, wantSTSCalls: 1, wantCredCalls: 2},
+		{name: "federation denied", validate: validateSkip, remembered: true, failFirst: errors.New("MFA code required"), wantSTSCalls: 0, wantCredCalls: 1, wantErrSubstr: "MFA code required"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return identity, nil
+				},
+			}
+			service.RetrieveCredentialsFunc = func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				if tc.failFirst != nil && service.RetrieveCredentialsCalls.Load() == 1 {
+					return awslib.Credentials{}, tc.failFirst
+				}
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+			}
+			federation := &mocks.FederationBuilder{
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+					return "https://example.com/console-login", nil
+				},
+			}
+			cache := credcache.NewCacheAt(t.TempDir())
+			if tc.remembered {
+				if err := cache.PutValidated("dev", identity); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			stdout := &bytes.Buffer{}
+			deps := runDeps{
+				awsService:      service,
+				federation:      federation,
+				credentials:     cache,
+				validate:        tc.validate,
+				open:            func(targetURL string, opts browserOptions) error { return nil },
+				term:            interactiveTerminal,
+				stdout:          stdout,
+				stderr:          &bytes.Buffer{},
+				now:             time.Now,
+				sessionDuration: sessionDuration,
+			}
+
+			err := runWorkflow(context.Background(), workflowOptions{profile: "dev", skipValidate: tc.skipValidate}, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout.String(), "Authenticated as: "+identity.Arn) {
+				t.Fatalf("expected the remembered identity to be reported, got:\n%s", stdout.String())
+			}
+			if service.GetCallerIdentityCalls.Load() != tc.wantSTSCalls {
+				t.Fatalf("expected %d GetCallerIdentity calls, got %d", tc.wantSTSCalls, service.GetCallerIdentityCalls.Load())
+			}
+			if service.RetrieveCredentialsCalls.Load() != tc.wantCredCalls {
+				t.Fatalf("expected %d RetrieveCredentials calls, got %d", tc.wantCredCalls, service.RetrieveCredentialsCalls.Load())
+			}
+			if got, _, ok := cache.Validated("dev", 0); !ok || got != identity {
+				t.Fatalf("expected the identity to be remembered, got %+v (%v)", got, ok)
+			}
+		})
+	}
+}
+
+func TestNewRootCmdDestination(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		args            []string
+		wantDestination string
+		wantErrSubstr   string
+	}{
+		{name: "home page", args: []string{"-p", "dev"}, wantDestination: ""},
+		{name: "service shorthand", args: []string{"-p", "dev", "--destination", "cw"}, wantDestination: "cloudwatch/home"},
+		{name: "short flag path", args: []string{"-p", "dev", "-d", "s3/buckets/my-bucket"}, wantDestination: "s3/buckets/my-bucket"},
+		{name: "service flag", args: []string{"-p", "dev", "--service", "ec2"}, wantDestination: "ec2/home"},
+		{name: "cloudshell pinned to the region", args: []string{"-p", "dev", "-d", "cloudshell", "--region", "eu-west-1"}, wantDestination: "cloudshell/home?region=eu-west-1"},
+		{name: "cloudshell with regions", args: []string{"-p", "dev", "-d", "cloudshell", "--region", "eu-west-1", "--regions", "us-east-1,us-west-2"}, wantDestination: "cloudshell/home"},
+		{name: "quicksight host", args: []string{"-p", "dev", "--service", "quicksight", "--region", "us-east-1"}, wantDestination: "https://us-east-1.quicksight.aws.amazon.com/sn/start"},
+		{
+			name:          "quicksight outside the aws partition",
+			args:          []string{"-p", "dev", "-d", "quicksight", "--partition", "aws-cn"},
+			wantErrSubstr: `the quicksight destination is not available in partition "aws-cn"`,
+		},
+		{name: "cloudwatch dashboard by name", args: []string{"-p", "dev", "-d", "dashboard:Prod-API"}, wantDestination: "cloudwatch/home#dashboards/dashboard/Prod-API"},
+		{
+			name:          "invalid deep link",
+			args:          []string{"-p", "dev", "-d", "grafana:prod"},
+			wantErrSubstr: `invalid Grafana workspace ID "prod"`,
+		},
+		{
+			name:          "both flags",
+			args:          []string{"-p", "dev", "--service", "ec2", "--destination", "s3"},
+			wantErrSubstr: "--destination and --service cannot be used together",
+		},
+		{
+			name:          "foreign URL",
+			args:          []string{"-p", "dev", "-d", "https://example.com/"},
+			wantErrSubstr: "must point at the AWS console",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured *workflowOptions
+			deps := runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				captured = &opts
+				return nil
+			})
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if captured == nil || captured.destination != tc.wantDestination {
+				t.Fatalf("unexpected workflow options: %+v", captured)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	identity := awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/AdministratorAccess/dev", Account: "123456789012"}
+
+	testCases := []struct {
+		name          string
+		profile       string
+		token         *ssocache.Token
+		identityErr   error
+		wantLogin     bool
+		wantSTSCalls  int64
+		wantStderr    string
+		wantErrSubstr string
+	}{
+		{
+			name:         "valid token",
+			token:        &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(time.Hour)},
+			wantSTSCalls: 1,
+		},
+		{
+			name:         "expired token logs in before checking",
+			token:        &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(-time.Minute)},
+			wantLogin:    true,
+			wantSTSCalls: 1,
+			wantStderr:   `The SSO token for profile "dev" expired at`,
+		},
+		{
+			name:         "missing token logs in",
+			wantLogin:    true,
+			wantSTSCalls: 1,
+			wantStderr:   `No cached SSO token for profile "dev", attempting SSO login...`,
+		},
+		{
+			name:         "role chain checks the token of its root SSO profile",
+			profile:      "prod",
+			token:        &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(-time.Minute)},
+			wantLogin:    true,
+			wantSTSCalls: 1,
+			wantStderr:   `The SSO token for profile "prod" expired at`,
+		},
+		{
+			name: "refreshable token is left to the SDK",
+			token: &ssocache.Token{
+				AccessToken: "token", ExpiresAt: now.Add(-time.Minute),
+				RefreshToken: "refresh", ClientID: "client", RegistrationExpiresAt: now.Add(24 * time.Hour),
+			},
+			wantSTSCalls: 1,
+		},
+		{
+			name:         "rejected credentials log in",
+			token:        &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(time.Hour)},
+			identityErr:  &smithy.GenericAPIError{Code: "ExpiredToken"},
+			wantLogin:    true,
+			wantSTSCalls: 2,
+			wantStderr:   "Credentials are not valid, attempting SSO login...",
+		},
+		{
+			name:          "network errors do not log in",
+			token:         &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(time.Hour)},
+			identityErr:   &net.DNSError{Err: "no such host", Name: "sts.amazonaws.com"},
+			wantSTSCalls:  1,
+			wantErrSubstr: `failed to reach AWS to check credentials for profile "dev"`,
+		},
+		{
+			name:          "permission errors do not log in",
+			token:         &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(time.Hour)},
+			identityErr:   &smithy.GenericAPIError{Code: "AccessDenied"},
+			wantSTSCalls:  1,
+			wantErrSubstr: "not allowed to call sts:GetCallerIdentity",
+		},
+		{
+			name:          "throttling does not log in",
+			token:         &ssocache.Token{AccessToken: "token", ExpiresAt: now.Add(time.Hour)},
+			identityErr:   &awslib.ThrottledError{Service: "AWS STS", RetryAfter: 20 * time.Second, Err: &smithy.GenericAPIError{Code: "Throttling"}},
+			wantSTSCalls:  1,
+			wantErrSubstr: `failed to check credentials for profile "dev": AWS STS is throttling requests; try again in about 20s`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tokens := ssocache.NewCacheAt(t.TempDir())
+			if tc.token != nil {
+				if err := tokens.Put("my-sso", *tc.token); err != nil {
+					t.Fatalf("failed to cache token: %v", err)
+				}
+			}
+
+			loggedIn := false
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					if tc.identityErr != nil && !loggedIn {
+						return awslib.Identity{}, tc.identityErr
+					}
+					return identity, nil
+				},
+			}
+			stderr := &bytes.Buffer{}
+			deps := runDeps{
+				awsService: service,
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return []awslib.Profile{
+							{Name: "dev", Source: awslib.ProfileSourceSSO, SSOSession: "my-sso"},
+							{Name: "ops", Source: awslib.ProfileSourceAssumeRole, SourceProfile: "dev"},
+							{Name: "prod", Source: awslib.ProfileSourceAssumeRole, SourceProfile: "ops"},
+						}, nil
+					},
+				},
+				ssoTokens: tokens,
+				now:       func() time.Time { return now },
+				login: func(ctx context.Context, profile string) error {
+					loggedIn = true
+					return nil
+				},
+				stderr: stderr,
+			}
+
+			profile := tc.profile
+			if profile == "" {
+				profile = "dev"
+			}
+			got, err := authenticate(context.Background(), profile, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil || got != identity {
+				t.Fatalf("unexpected result %+v (%v)", got, err)
+			}
+			if loggedIn != tc.wantLogin {
+				t.Fatalf("expected login %v, got %v", tc.wantLogin, loggedIn)
+			}
+			if service.GetCallerIdentityCalls.Load() != tc.wantSTSCalls {
+				t.Fatalf("expected %d GetCallerIdentity calls, got %d", tc.wantSTSCalls, service.GetCallerIdentityCalls.Load())
+			}
+			if !strings.Contains(stderr.String(), tc.wantStderr) {
+				t.Fatalf("expected stderr to contain %q, got %q", tc.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	t.Setenv("AWS_CONSOLE_LANG", "")
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+
+	testCases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "--lang", args: []string{"status", "nope", "--lang", "ja"}, want: `エラー: プロファイル "nope" が AWS の設定に見つかりません`},
+		{name: "locale", args: []string{"status", "nope"}, want: `Fehler: Profil "nope" nicht in der AWS-Konfiguration gefunden`},
+		{name: "invalid --lang", args: []string{"status", "nope", "--lang", "fr"}, want: `Fehler: Nicht unterstützte Sprache "fr" (erwartet: en, ja oder de)`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) { return testProfiles(), nil },
+				},
+				stdout: &bytes.Buffer{},
+				stderr: &bytes.Buffer{},
+			}
+			root := newRootCmd(deps, runWorkflow)
+			root.SetArgs(tc.args)
+			executed, err := root.ExecuteC()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := ErrorMessage(localizeError(executed, err, deps)); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+
+	if got := ErrorMessage(errors.New("crashed")); got != "Error: crashed" {
+		t.Fatalf("expected other errors in English, got %q", got)
+	}
+}
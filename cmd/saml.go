@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	xterm "github.com/charmbracelet/x/term"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/eculver/aws-console/pkg/saml"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+// samlPasswordEnv holds the identity provider password when there is no terminal to
+// ask for it in.
+const samlPasswordEnv = "AWS_CONSOLE_SAML_PASSWORD"
+
+// withSAMLCredentials signs in to the SAML provider called name, assumes a role the
+// assertion grants with sts:AssumeRoleWithSAML, and returns a context whose AWS calls
+// sign with the role's credentials instead of the profile's.
+func withSAMLCredentials(ctx context.Context, name string, deps runDeps) (context.Context, error) {
+	p, ok := deps.samlProviders[name]
+	if !ok {
+		return ctx, i18n.Errorf("unknown SAML provider %q; add it under saml-providers in the config file", name)
+	}
+	provider, err := saml.New(p.Driver, saml.Options{
+		URL:       p.URL,
+		Transport: deps.samlTransport,
+		Run: func(ctx context.Context) ([]byte, error) {
+			var out bytes.Buffer
+			name, args := shellCommand(deps.goos, p.Command)
+			err := deps.executor.RunContext(ctx, name, args, nil, nil, &out, deps.stderr)
+			return out.Bytes(), err
+		},
+	})
+	if err != nil {
+		return ctx, i18n.Errorf("SAML provider %q: %w", name, err)
+	}
+
+	deps.messages.Fprintf(statusWriter(deps), "Signing in to SAML provider %s...\n", name)
+	done := deps.timings.start("saml")
+	assertion, err := provider.Assertion(ctx, func() (saml.Login, error) {
+		return samlLogin(name, p, deps)
+	})
+	done()
+	if err != nil {
+		return ctx, i18n.Errorf("failed to sign in to SAML provider %s: %w", name, err)
+	}
+	response, err := saml.ParseResponse(assertion)
+	if err != nil {
+		return ctx, err
+	}
+	role, err := pickSAMLRole(response, p.RoleARN, deps)
+	if err != nil {
+		return ctx, err
+	}
+
+	input := awslib.SAMLRoleInput{
+		RoleARN:         role.RoleARN,
+		PrincipalARN:    role.PrincipalARN,
+		Assertion:       response.Assertion,
+		DurationSeconds: int32(response.SessionDuration / time.Second),
+	}
+	// An explicit --duration asks for role credentials that last as long as the console
+	// session; otherwise the identity provider's SessionDuration applies.
+	if deps.durationSet {
+		input.DurationSeconds = deps.sessionDuration
+	}
+	verbosef(deps, "Assuming role %s with the SAML assertion", role.RoleARN)
+	done = deps.timings.start("sts")
+	creds, err := deps.awsService.AssumeRoleWithSAML(ctx, input)
+	done()
+	if err != nil {
+		return ctx, i18n.Errorf("failed to assume role %s with SAML: %w", role.RoleARN, err)
+	}
+	return awslib.WithKeySource(ctx, func(context.Context) (awslib.Credentials, error) {
+		return creds, nil
+	}), nil
+}
+
+// samlLogin asks for the username, unless the provider sets one, and the password to
+// sign in to the SAML provider called name with.
+func samlLogin(name string, p config.SAMLProvider, deps runDeps) (saml.Login, error) {
+	login := saml.Login{Username: p.Username, Password: os.Getenv(samlPasswordEnv)}
+	if login.Username != "" && login.Password != "" {
+		return login, nil
+	}
+	if !deps.term.Interactive() {
+		return saml.Login{}, i18n.Errorf("SAML provider %s needs a username and password: set its username in the config file and %s when not running in a terminal", name, samlPasswordEnv)
+	}
+
+	in := bufio.NewReader(deps.stdin)
+	if login.Username == "" {
+		deps.messages.Promptf(deps.stderr, "Username for %s: ", name)
+		line, err := in.ReadString('\n')
+		if err != nil && line == "" {
+			return saml.Login{}, i18n.Errorf("failed to read the username: %w", err)
+		}
+		if login.Username = strings.TrimSpace(line); login.Username == "" {
+			return saml.Login{}, i18n.Errorf("no username given")
+		}
+	}
+	if login.Password == "" {
+		deps.messages.Promptf(deps.stderr, "Password for %s: ", name)
+		password, err := readPassword(in, deps)
+		fmt.Fprintln(deps.stderr)
+		if err != nil {
+			return saml.Login{}, i18n.Errorf("failed to read the password: %w", err)
+		}
+		login.Password = password
+	}
+	return login, nil
+}
+
+// readPassword reads a line from the terminal without echoing it, or from in when
+// stdin is not a terminal.
+func readPassword(in *bufio.Reader, deps runDeps) (string, error) {
+	if f, ok := deps.stdin.(*os.File); ok && term.IsTerminal(f) {
+		password, err := xterm.ReadPassword(f.Fd())
+		return string(password), err
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// pickSAMLRole returns the role of response named by roleARN, the only one it grants,
+// or the one picked in a terminal.
+func pickSAMLRole(response saml.Response, roleARN string, deps runDeps) (saml.Role, error) {
+	if roleARN != "" || len(response.Roles) == 1 || !deps.term.Interactive() || deps.picker == nil {
+		return response.Role(roleARN)
+	}
+	roles := slices.Clone(response.Roles)
+	slices.SortFunc(roles, func(a, b saml.Role) int { return strings.Compare(a.RoleARN, b.RoleARN) })
+	items := make([]string, len(roles))
+	for i, role := range roles {
+		items[i] = role.RoleARN
+	}
+	i, err := deps.picker.Pick("role", items)
+	if errors.Is(err, prompt.ErrCanceled) {
+		return saml.Role{}, i18n.Errorf("no role selected")
+	}
+	if err != nil {
+		return saml.Role{}, i18n.Errorf("failed to select a role: %w", err)
+	}
+	return roles[i], nil
+}
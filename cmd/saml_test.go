@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+const testSAMLProviderARN = "arn:aws:iam::123456789012:saml-provider/ADFS"
+
+// samlResponse returns a base64 encoded SAML response granting roles.
+func samlResponse(roles ...string) string {
+	var b strings.Builder
+	b.WriteString(`<Response><Assertion><AttributeStatement>`)
+	b.WriteString(`<Attribute Name="https://aws.amazon.com/SAML/Attributes/Role">`)
+	for _, role := range roles {
+		b.WriteString(`<AttributeValue>` + role + `,` + testSAMLProviderARN + `</AttributeValue>`)
+	}
+	b.WriteString(`</Attribute><Attribute Name="https://aws.amazon.com/SAML/Attributes/SessionDuration"><AttributeValue>7200</AttributeValue></Attribute>`)
+	b.WriteString(`</AttributeStatement></Assertion></Response>`)
+	return base64.StdEncoding.EncodeToString([]byte(b.String()))
+}
+
+// assumedRoleCredentials are the credentials of a role assumed outside the profile.
+func assumedRoleCredentials(accessKeyID string) awslib.Credentials {
+	return awslib.Credentials{AccessKeyID: accessKeyID, SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}
+}
+
+// assumedRoleDeps returns the dependencies of a workflow that federates a role assumed
+// outside the profile, as with --saml and --web-identity-role-arn, whose credentials
+// have accessKeyID and whose caller identity is identityARN. The caller gives the
+// service the call that assumes the role. Like the SDK service, it signs with the key
+// source of the context, and the test fails when other credentials are checked or
+// federated.
+func assumedRoleDeps(t *testing.T, accessKeyID, identityARN string) (runDeps, *mocks.Service) {
+	fromKeySource := func(ctx context.Context) awslib.Credentials {
+		source, ok := awslib.KeySourceFromContext(ctx)
+		if !ok {
+			t.Fatal("expected the assumed role's credentials to be used")
+		}
+		creds, _ := source(ctx)
+		return creds
+	}
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			if fromKeySource(ctx).AccessKeyID != accessKeyID {
+				t.Fatal("expected the identity of the assumed role's credentials")
+			}
+			return awslib.Identity{Arn: identityARN, Account: "123456789012"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return fromKeySource(ctx), nil
+		},
+	}
+	deps := runDeps{
+		awsService: service,
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				if creds.AccessKeyID != accessKeyID {
+					t.Fatalf("expected the assumed role's credentials to be federated, got %+v", creds)
+				}
+				return "https://example.com/console-login", nil
+			},
+		},
+		goos:            "linux",
+		stdin:           strings.NewReader(""),
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		now:             time.Now,
+		sessionDuration: sessionDuration,
+	}
+	return deps, service
+}
+
+func TestRunWorkflowSAML(t *testing.T) {
+	t.Parallel()
+
+	admin := "arn:aws:iam::123456789012:role/Admin"
+	readOnly := "arn:aws:iam::123456789012:role/ReadOnly"
+	response := samlResponse(readOnly, admin)
+
+	testCases := []struct {
+		name          string
+		provider      config.SAMLProvider
+		durationSet   bool
+		wantRole      string
+		wantDuration  int32
+		wantErrSubstr string
+	}{
+		{name: "picks a role", provider: config.SAMLProvider{Driver: "command", Command: "kinit-saml"}, wantRole: readOnly, wantDuration: 7200},
+		{name: "configured role", provider: config.SAMLProvider{Driver: "command", Command: "kinit-saml", RoleARN: admin}, wantRole: admin, wantDuration: 7200},
+		{name: "explicit duration", provider: config.SAMLProvider{Driver: "command", Command: "kinit-saml", RoleARN: admin}, durationSet: true, wantRole: admin, wantDuration: sessionDuration},
+		{name: "role not granted", provider: config.SAMLProvider{Driver: "command", Command: "kinit-saml", RoleARN: "arn:aws:iam::123456789012:role/Other"}, wantErrSubstr: "does not grant role arn:aws:iam::123456789012:role/Other"},
+		{name: "unknown driver", provider: config.SAMLProvider{Driver: "okta", URL: "https://example.okta.com"}, wantErrSubstr: `SAML provider "corp": unknown SAML driver "okta"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got awslib.SAMLRoleInput
+			deps, service := assumedRoleDeps(t, "ASIASAML", "arn:aws:sts::123456789012:assumed-role/ReadOnly/alice")
+			service.AssumeRoleWithSAMLFunc = func(ctx context.Context, input awslib.SAMLRoleInput) (awslib.Credentials, error) {
+				got = input
+				return assumedRoleCredentials("ASIASAML"), nil
+			}
+			executor := &fakeExecutor{runOutput: response}
+			deps.samlProviders = map[string]config.SAMLProvider{"corp": tc.provider}
+			deps.executor = executor
+			deps.picker = &fakePicker{choice: 1}
+			deps.login = func(ctx context.Context, profile string) error { return errors.New("unexpected SSO login") }
+			deps.term = interactiveTerminal
+			deps.durationSet = tc.durationSet
+
+			err := runWorkflow(context.Background(), workflowOptions{saml: "corp", print: true}, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.RoleARN != tc.wantRole || got.PrincipalARN != testSAMLProviderARN || got.Assertion != response || got.DurationSeconds != tc.wantDuration {
+				t.Fatalf("unexpected AssumeRoleWithSAML input %+v", got)
+			}
+			if len(executor.calls) != 1 || strings.Join(executor.calls[0].args, " ") != "-c kinit-saml" {
+				t.Fatalf("expected the SAML command to run, got %+v", executor.calls)
+			}
+		})
+	}
+}
+
+func TestSAMLLogin(t *testing.T) {
+	t.Parallel()
+
+	stderr := &bytes.Buffer{}
+	deps := runDeps{term: interactiveTerminal, stdin: strings.NewReader("alice\ns3cret\n"), stderr: stderr}
+	login, err := samlLogin("corp", config.SAMLProvider{}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if login.Username != "alice" || login.Password != "s3cret" {
+		t.Fatalf("unexpected login %+v", login)
+	}
+	if !strings.Contains(stderr.String(), "Username for corp: ") || !strings.Contains(stderr.String(), "Password for corp: ") {
+		t.Fatalf("expected prompts for the username and password, got %q", stderr)
+	}
+
+	deps = runDeps{term: term.Info{}, stdin: strings.NewReader(""), stderr: &bytes.Buffer{}}
+	_, err = samlLogin("corp", config.SAMLProvider{Username: "alice"}, deps)
+	if err == nil || !strings.Contains(err.Error(), samlPasswordEnv) {
+		t.Fatalf("expected the password to be required outside a terminal, got %v", err)
+	}
+}
+
+func TestRunWorkflowUnknownSAMLProvider(t *testing.T) {
+	t.Parallel()
+
+	deps := runDeps{stdin: strings.NewReader(""), stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, now: time.Now}
+	err := runWorkflow(context.Background(), workflowOptions{saml: "corp"}, deps)
+	if err == nil || !strings.Contains(err.Error(), `unknown SAML provider "corp"`) {
+		t.Fatalf("expected an unknown provider error, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/secrets"
+)
+
+// mfaSourcePrompt asks for MFA codes on the terminal. It is the default mfa-source.
+const mfaSourcePrompt = "prompt"
+
+// parseCredentialSource reads the credential-source setting; empty leaves the profile's
+// credentials to the shared AWS config.
+func parseCredentialSource(value string) (*secrets.Item, error) {
+	if value == "" {
+		return nil, nil
+	}
+	item, err := secrets.Parse(value)
+	if err != nil {
+		return nil, i18n.Errorf("invalid credential-source: %w", err)
+	}
+	if item.Manager == secrets.YubiKey {
+		return nil, i18n.Errorf("invalid credential-source: %s holds one-time passwords, not access keys; use it as the mfa-source", item)
+	}
+	return &item, nil
+}
+
+// parseMFASource reads the mfa-source setting: prompt, or a password manager item or
+// YubiKey OATH account whose one-time password is the MFA code.
+func parseMFASource(value string) (*secrets.Item, error) {
+	if value == "" || value == mfaSourcePrompt {
+		return nil, nil
+	}
+	if kind, _, _ := strings.Cut(strings.ToLower(value), ":"); kind == "fido2" || kind == "webauthn" {
+		// STS takes only a TOTP code as TokenCode on GetSessionToken and AssumeRole;
+		// FIDO2 security keys work for console sign-in but not for API calls.
+		return nil, i18n.Errorf("invalid mfa-source: STS accepts only one-time codes for MFA, so FIDO2 security keys cannot be used; register the key's OATH application as a virtual MFA device and use yubikey:<account>")
+	}
+	item, err := secrets.Parse(value)
+	if err != nil {
+		return nil, i18n.Errorf("invalid mfa-source: expected %s or <manager>:<item>: %w", mfaSourcePrompt, err)
+	}
+	return &item, nil
+}
+
+// secretKeySource returns a key source that reads the access keys of item when an AWS
+// call first needs them, and only once, since each read may prompt to unlock the vault.
+func secretKeySource(item secrets.Item, deps runDeps) awslib.KeySource {
+	var once sync.Once
+	var creds awslib.Credentials
+	var err error
+	return func(ctx context.Context) (awslib.Credentials, error) {
+		once.Do(func() {
+			verbosef(deps, "Reading access keys from %s", item)
+			var keys secrets.Keys
+			if keys, err = item.Keys(deps.executor, secretStdin(deps)); err == nil {
+				creds = awslib.Credentials{AccessKeyID: keys.AccessKeyID, SecretAccessKey: keys.SecretAccessKey, Source: awslib.KeySourceProvider}
+			}
+		})
+		return creds, err
+	}
+}
+
+// mfaCode returns the current code of the MFA device serial from the mfa-source, or
+// asks for it on the terminal.
+func mfaCode(serial string, deps runDeps) (string, error) {
+	if deps.mfaSource == nil {
+		return promptMFAToken(serial, deps)
+	}
+	verbosef(deps, "Reading the MFA code for %s from %s", serial, deps.mfaSource)
+	if deps.mfaSource.Manager == secrets.YubiKey {
+		// ykman waits silently for accounts that require touch.
+		deps.messages.Promptf(deps.stderr, "Reading the MFA code for %s from your YubiKey; touch it if it flashes\n", serial)
+	}
+	return deps.mfaSource.TOTP(deps.executor, secretStdin(deps))
+}
+
+// secretStdin is the input of password manager commands: the terminal, where they may
+// ask for a master password, or none when stdin is not one.
+func secretStdin(deps runDeps) io.Reader {
+	if !deps.term.Interactive() {
+		return nil
+	}
+	return deps.stdin
+}
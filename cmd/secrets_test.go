@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/secrets"
+	"github.com/eculver/aws-console/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+func TestResolveGlobalsSecretSources(t *testing.T) {
+	testCases := []struct {
+		name             string
+		credentialSource string
+		mfaSource        string
+		args             []string
+		wantKeySource    bool
+		wantMFASource    string
+		wantErrSubstr    string
+	}{
+		{name: "none"},
+		{name: "prompt", mfaSource: "prompt"},
+		{
+			name:             "password manager items",
+			credentialSource: "1password:Private/AWS",
+			mfaSource:        "bw:AWS MFA",
+			wantKeySource:    true,
+			wantMFASource:    "bitwarden:AWS MFA",
+		},
+		{name: "yubikey", mfaSource: "ykman:Amazon Web Services:me@prod", wantMFASource: "yubikey:Amazon Web Services:me@prod"},
+		{name: "flag", mfaSource: "bw:AWS MFA", args: []string{"--mfa-source", "yubikey:aws"}, wantMFASource: "yubikey:aws"},
+		{name: "fido2", mfaSource: "fido2:yubikey", wantErrSubstr: "STS accepts only one-time codes for MFA"},
+		{name: "yubikey credential source", credentialSource: "yubikey:aws", wantErrSubstr: "yubikey:aws holds one-time passwords, not access keys"},
+		{name: "invalid credential source", credentialSource: "vault:aws", wantErrSubstr: `invalid credential-source: invalid secret reference "vault:aws"`},
+		{name: "invalid mfa source", mfaSource: "yubikey", wantErrSubstr: "invalid mfa-source: expected prompt or <manager>:<item>"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("AWS_CONSOLE_CREDENTIAL_SOURCE", tc.credentialSource)
+			t.Setenv("AWS_CONSOLE_MFA_SOURCE", tc.mfaSource)
+
+			deps := runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+			var gotErr error
+			var gotCtx context.Context
+			var gotDeps runDeps
+			root := newRootCmd(deps, nil)
+			billing, _, err := root.Find([]string{"billing"})
+			if err != nil {
+				t.Fatalf("failed to find billing command: %v", err)
+			}
+			billing.RunE = func(cmd *cobra.Command, args []string) error {
+				var g globalOptions
+				if g, gotErr = resolveGlobals(cmd, deps); gotErr == nil {
+					gotCtx, gotDeps = g.apply(context.Background(), deps)
+				}
+				return nil
+			}
+			root.SetArgs(append([]string{"billing"}, tc.args...))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("unexpected execute error: %v", err)
+			}
+
+			if tc.wantErrSubstr != "" {
+				if gotErr == nil || !strings.Contains(gotErr.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, gotErr)
+				}
+				return
+			}
+			if gotErr != nil {
+				t.Fatalf("unexpected error: %v", gotErr)
+			}
+			if _, ok := awslib.KeySourceFromContext(gotCtx); ok != tc.wantKeySource {
+				t.Fatalf("key source = %v, want %v", ok, tc.wantKeySource)
+			}
+			if _, ok := awslib.KeySourceFor(gotCtx, "breakglass"); ok {
+				t.Fatal("expected the key source to sign only for the selected profile")
+			}
+			if got := gotDeps.mfaSource; (got == nil) != (tc.wantMFASource == "") || got != nil && got.String() != tc.wantMFASource {
+				t.Fatalf("unexpected mfa source %v, want %q", got, tc.wantMFASource)
+			}
+		})
+	}
+}
+
+func TestSecretKeySourceReadsOnce(t *testing.T) {
+	t.Parallel()
+
+	executor := &fakeExecutor{runOutput: "secret\nusername: AKIAEXAMPLE\n"}
+	source := secretKeySource(secrets.Item{Manager: secrets.Pass, Name: "aws/prod"}, runDeps{executor: executor, stderr: &bytes.Buffer{}})
+	for range 2 {
+		creds, err := source(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if creds.AccessKeyID != "AKIAEXAMPLE" || creds.SecretAccessKey != "secret" || creds.Source != awslib.KeySourceProvider {
+			t.Fatalf("unexpected credentials: %+v", creds)
+		}
+	}
+	if len(executor.calls) != 1 || strings.Join(append([]string{executor.calls[0].name}, executor.calls[0].args...), " ") != "pass show aws/prod" {
+		t.Fatalf("expected the item to be read once, got %+v", executor.calls)
+	}
+}
+
+func TestSecretStdin(t *testing.T) {
+	t.Parallel()
+
+	stdin := strings.NewReader("master password\n")
+	for _, tc := range []struct {
+		term term.Info
+		want io.Reader
+	}{
+		{term: interactiveTerminal, want: stdin},
+		{want: nil},
+	} {
+		executor := &fakeExecutor{runOutput: "secret\nusername: AKIAEXAMPLE\n"}
+		deps := runDeps{executor: executor, term: tc.term, stdin: stdin, stderr: &bytes.Buffer{}}
+		if _, err := secretKeySource(secrets.Item{Manager: secrets.Pass, Name: "aws/prod"}, deps)(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if executor.calls[0].stdin != tc.want {
+			t.Fatalf("interactive = %v: pass ran with stdin %v, want %v", tc.term.Interactive(), executor.calls[0].stdin, tc.want)
+		}
+	}
+}
+
+func TestMFACodeFromSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source      secrets.Item
+		wantCommand string
+		wantTouch   bool
+	}{
+		{source: secrets.Item{Manager: secrets.Bitwarden, Name: "AWS"}, wantCommand: "bw get totp AWS"},
+		{source: secrets.Item{Manager: secrets.YubiKey, Name: "AWS:me"}, wantCommand: "ykman oath accounts code --single AWS:me", wantTouch: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.source.Manager, func(t *testing.T) {
+			t.Parallel()
+
+			executor := &fakeExecutor{runOutput: "123456\n"}
+			stderr := &bytes.Buffer{}
+			deps := runDeps{executor: executor, mfaSource: &tc.source, stderr: stderr}
+			code, err := consoleClient(deps).MFAToken(context.Background(), "arn:aws:iam::123456789012:mfa/me", true)
+			if err != nil || code != "123456" {
+				t.Fatalf("expected the code from %s, got %q, %v", tc.source, code, err)
+			}
+			if len(executor.calls) != 1 || strings.Join(append([]string{executor.calls[0].name}, executor.calls[0].args...), " ") != tc.wantCommand {
+				t.Fatalf("unexpected commands: %+v", executor.calls)
+			}
+			if got := strings.Contains(stderr.String(), "touch it if it flashes"); got != tc.wantTouch {
+				t.Fatalf("touch prompt = %v, want %v: %q", got, tc.wantTouch, stderr)
+			}
+		})
+	}
+}
+
+func TestAuthenticateKeySourceSkipsSSOLogin(t *testing.T) {
+	t.Parallel()
+
+	loggedIn := false
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{}, &smithy.GenericAPIError{Code: "InvalidClientTokenId"}
+			},
+		},
+		login: func(ctx context.Context, profile string) error {
+			loggedIn = true
+			return nil
+		},
+		stderr: &bytes.Buffer{},
+	}
+	ctx := awslib.WithKeySource(context.Background(), func(ctx context.Context) (awslib.Credentials, error) {
+		return awslib.Credentials{}, nil
+	})
+	_, err := authenticate(ctx, "prod", deps)
+	if err == nil || !strings.Contains(err.Error(), `the access keys of profile "prod" from its credential-source were rejected`) {
+		t.Fatalf("expected the keys to be rejected, got %v", err)
+	}
+	if loggedIn {
+		t.Fatal("expected no SSO login for keys from a password manager")
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+)
+
+const (
+	selfTestPass = "pass"
+	selfTestFail = "fail"
+	selfTestSkip = "skip"
+)
+
+// selfTestStep is the outcome of one stage of the sign-in chain.
+type selfTestStep struct {
+	name     string
+	result   string
+	duration time.Duration
+	detail   string
+}
+
+// runSelfTest exercises every stage of opening the console for profile — resolving
+// credentials, calling STS, and fetching a federation sign-in token — without logging
+// in or opening a browser. Stages after a failure are skipped. The sign-in URL is never
+// printed since it grants console access.
+func runSelfTest(ctx context.Context, profile string, format output.Format, deps runDeps) error {
+	var steps []selfTestStep
+	failed := false
+
+	skip := func(name, reason string) {
+		steps = append(steps, selfTestStep{name: name, result: selfTestSkip, detail: reason})
+	}
+	run := func(name string, fn func() (string, error)) {
+		if failed {
+			skip(name, "previous step failed")
+			return
+		}
+		start := deps.now()
+		detail, err := fn()
+		step := selfTestStep{name: name, result: selfTestPass, duration: deps.now().Sub(start), detail: detail}
+		if err != nil {
+			failed = true
+			step.result = selfTestFail
+			step.detail = err.Error()
+		}
+		steps = append(steps, step)
+	}
+
+	var creds awslib.Credentials
+	run("credentials", func() (string, error) {
+		var err error
+		creds, err = deps.awsService.RetrieveCredentials(ctx, profile)
+		if err != nil {
+			return "", i18n.Errorf("failed to retrieve credentials: %w", err)
+		}
+		return describeCredentials(creds), nil
+	})
+
+	run("sts", func() (string, error) {
+		identity, err := deps.awsService.GetCallerIdentity(ctx, profile)
+		if err != nil {
+			return "", i18n.Errorf("GetCallerIdentity failed: %w", err)
+		}
+		ctx = awslib.WithPartition(ctx, identity.Partition)
+		return identity.Arn, nil
+	})
+
+	if failed || creds.SessionToken == "" {
+		run("session-token", func() (string, error) {
+			var err error
+			creds, err = deps.awsService.GetSessionToken(ctx, profile, awslib.SessionTokenInput{
+				DurationSeconds: awslib.CredentialKindSessionToken.DurationSeconds(time.Duration(deps.sessionDuration) * time.Second),
+			})
+			if err != nil {
+				return "", i18n.Errorf("failed to get temporary credentials: %w", err)
+			}
+			return describeCredentials(creds), nil
+		})
+	} else {
+		skip("session-token", "credentials already include a session token")
+	}
+
+	run("federation", func() (string, error) {
+		if _, err := deps.federation.BuildConsoleURL(ctx, creds, deps.sessionDuration, ""); err != nil {
+			return "", i18n.Errorf("failed to build console URL: %w", err)
+		}
+		return "sign-in token issued", nil
+	})
+
+	table := output.Table{
+		Columns: []output.Column{
+			{Header: "STEP", Key: "step"},
+			{Header: "RESULT", Key: "result"},
+			{Header: "DURATION", Key: "duration"},
+			{Header: "DETAIL", Key: "detail"},
+		},
+	}
+	for _, s := range steps {
+		duration := ""
+		if s.result != selfTestSkip {
+			duration = s.duration.Round(time.Millisecond).String()
+		}
+		table.Rows = append(table.Rows, []string{s.name, s.result, duration, s.detail})
+	}
+	if err := output.Render(deps.stdout, format, table); err != nil {
+		return err
+	}
+
+	if failed {
+		return i18n.Errorf("self-test failed")
+	}
+	return nil
+}
+
+// describeCredentials summarizes credentials without revealing them.
+func describeCredentials(creds awslib.Credentials) string {
+	kind := "long-lived keys"
+	if creds.SessionToken != "" {
+		kind = "temporary credentials"
+	}
+	if creds.Expires.IsZero() {
+		return kind
+	}
+	return fmt.Sprintf("%s, expire %s", kind, formatTimestamp(creds.Expires))
+}
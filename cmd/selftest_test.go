@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/output"
+)
+
+// steppingClock returns a clock that advances by step on every call.
+func steppingClock(step time.Duration) func() time.Time {
+	current := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return func() time.Time {
+		current = current.Add(step)
+		return current
+	}
+}
+
+func TestRunSelfTest(t *testing.T) {
+	t.Parallel()
+
+	sessionCreds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}
+	staticCreds := awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}
+
+	testCases := []struct {
+		name          string
+		creds         awslib.Credentials
+		credsErr      error
+		identityErr   error
+		federationErr error
+		wantRows      []string
+		wantErr       bool
+		wantSTSCalls  int64
+	}{
+		{
+			name:  "session credentials",
+			creds: sessionCreds,
+			wantRows: []string{
+				"credentials,pass,10ms,temporary credentials",
+				"sts,pass,10ms,arn:aws:iam::123456789012:user/test",
+				"session-token,skip,,credentials already include a session token",
+				"federation,pass,10ms,sign-in token issued",
+			},
+			wantSTSCalls: 1,
+		},
+		{
+			name:  "long-lived keys",
+			creds: staticCreds,
+			wantRows: []string{
+				"credentials,pass,10ms,long-lived keys",
+				"session-token,pass,10ms,temporary credentials",
+			},
+			wantSTSCalls: 1,
+		},
+		{
+			name:     "credentials fail",
+			credsErr: errors.New("no credentials"),
+			wantRows: []string{
+				"credentials,fail,10ms,failed to retrieve credentials: no credentials",
+				"sts,skip,,previous step failed",
+				"session-token,skip,,previous step failed",
+				"federation,skip,,previous step failed",
+			},
+			wantErr: true,
+		},
+		{
+			name:          "federation fails",
+			creds:         sessionCreds,
+			federationErr: errors.New("403"),
+			wantRows: []string{
+				"federation,fail,10ms,failed to build console URL: 403",
+			},
+			wantErr:      true,
+			wantSTSCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+			service := &mocks.Service{
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return tc.creds, tc.credsErr
+				},
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, tc.identityErr
+				},
+				GetSessionTokenFunc: func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
+					return sessionCreds, nil
+				},
+			}
+			federation := &mocks.FederationBuilder{
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+					return "https://signin.aws.amazon.com/federation?SigninToken=secret", tc.federationErr
+				},
+			}
+			opened := false
+			deps := runDeps{
+				awsService:      service,
+				federation:      federation,
+				now:             steppingClock(10 * time.Millisecond),
+				open:            func(string, browserOptions) error { opened = true; return nil },
+				stdout:          stdout,
+				stderr:          &bytes.Buffer{},
+				sessionDuration: sessionDuration,
+			}
+
+			err := runSelfTest(context.Background(), "dev", output.FormatCSV, deps)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			for _, want := range tc.wantRows {
+				if !strings.Contains(stdout.String(), want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, stdout.String())
+				}
+			}
+			if strings.Contains(stdout.String(), "SigninToken") {
+				t.Fatalf("expected sign-in URL not to be printed, got:\n%s", stdout.String())
+			}
+			if opened {
+				t.Fatal("expected self-test not to open a browser")
+			}
+			if service.GetCallerIdentityCalls.Load() != tc.wantSTSCalls {
+				t.Fatalf("expected %d GetCallerIdentity calls, got %d", tc.wantSTSCalls, service.GetCallerIdentityCalls.Load())
+			}
+		})
+	}
+}
+
+func TestNewRootCmdSelfTestSkipsWorkflow(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+	deps := runDeps{
+		awsService: &mocks.Service{
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{SessionToken: "token"}, nil
+			},
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn"}, nil
+			},
+		},
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				return "https://example.com", nil
+			},
+		},
+		now:    steppingClock(time.Millisecond),
+		stdout: stdout,
+		stderr: &bytes.Buffer{},
+	}
+
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		t.Fatal("workflow should not run in self-test mode")
+		return nil
+	})
+	root.SetArgs([]string{"--self-test"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "federation") {
+		t.Fatalf("expected a summary, got:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// resolveSessionPolicy returns the session policy of the session-policy and policy-arns
+// settings, or nil when neither is set. name is a template in the session-policies
+// section of file, or else the path of a JSON policy document; arns are added to the
+// managed policies of either.
+func resolveSessionPolicy(name, arns string, file *config.File, configFile string) (*awslib.SessionPolicy, error) {
+	policy := &awslib.SessionPolicy{PolicyARNs: parsePolicyARNs(arns)}
+	if name != "" {
+		var err error
+		if template, ok := file.SessionPolicies[name]; ok {
+			err = applySessionPolicyTemplate(policy, template, configFile)
+		} else {
+			if policy.Policy, err = readPolicyFile(name); errors.Is(err, os.ErrNotExist) {
+				err = i18n.Errorf("no such template in session-policies, and no file %s", name)
+			}
+		}
+		if err != nil {
+			return nil, i18n.Errorf("invalid session policy %q: %w", name, err)
+		}
+	}
+	if len(policy.PolicyARNs) == 0 && policy.Policy == "" {
+		return nil, nil
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// applySessionPolicyTemplate adds the policies of template to policy. A relative file is
+// read from the directory of the config file.
+func applySessionPolicyTemplate(policy *awslib.SessionPolicy, template config.SessionPolicy, configFile string) error {
+	policy.PolicyARNs = append(slices.Clone(template.PolicyARNs), policy.PolicyARNs...)
+	switch {
+	case template.Policy != "":
+		doc, err := awslib.ParsePolicyDocument([]byte(template.Policy))
+		if err != nil {
+			return err
+		}
+		policy.Policy = doc
+	case template.File != "":
+		path, err := paths.ExpandHome(template.File)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(path) && configFile != "" {
+			path = filepath.Join(filepath.Dir(configFile), path)
+		}
+		if policy.Policy, err = readPolicyFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPolicyFile reads the JSON policy document at path.
+func readPolicyFile(path string) (string, error) {
+	expanded, err := paths.ExpandHome(path)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(expanded)
+	if err != nil {
+		return "", i18n.Errorf("failed to read policy file: %w", err)
+	}
+	doc, err := awslib.ParsePolicyDocument(data)
+	if err != nil {
+		return "", i18n.Errorf("%s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// parsePolicyARNs splits a comma-separated list of policy ARNs, dropping empty entries.
+func parsePolicyARNs(value string) []string {
+	var arns []string
+	for _, arn := range strings.Split(value, ",") {
+		if arn = strings.TrimSpace(arn); arn != "" {
+			arns = append(arns, arn)
+		}
+	}
+	return arns
+}
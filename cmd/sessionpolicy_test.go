@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eculver/aws-console/pkg/config"
+)
+
+func TestResolveSessionPolicy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	s3Only := filepath.Join(dir, "s3-only.json")
+	if err := os.MkdirAll(filepath.Join(dir, "policies"), 0o700); err != nil {
+		t.Fatalf("failed to create policies directory: %v", err)
+	}
+	for path, doc := range map[string]string{
+		s3Only: "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": [{\"Effect\": \"Allow\", \"Action\": \"s3:*\", \"Resource\": \"*\"}]\n}\n",
+		filepath.Join(dir, "policies", "logs.json"): `{"Statement": [{"Effect": "Allow", "Action": "logs:*", "Resource": "*"}]}`,
+		filepath.Join(dir, "broken.json"):           `{"Statement": [`,
+	} {
+		if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	file := &config.File{SessionPolicies: map[string]config.SessionPolicy{
+		"deploy": {PolicyARNs: []string{"arn:aws:iam::123456789012:policy/Deploy"}},
+		"logs":   {File: "policies/logs.json"},
+		"ec2":    {Policy: `{"Statement": [{"Effect": "Allow", "Action": "ec2:Describe*", "Resource": "*"}]}`},
+		"bad":    {Policy: "ec2:*"},
+	}}
+
+	testCases := []struct {
+		name          string
+		policy        string
+		arns          string
+		wantARNs      string
+		wantPolicy    string
+		wantErrSubstr string
+	}{
+		{name: "unset"},
+		{name: "empty ARNs", arns: " , "},
+		{
+			name:       "policy file",
+			policy:     s3Only,
+			wantPolicy: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`,
+		},
+		{
+			name:     "policy ARNs",
+			arns:     "arn:aws:iam::aws:policy/ReadOnlyAccess, arn:aws:iam::123456789012:policy/Deploy",
+			wantARNs: "arn:aws:iam::aws:policy/ReadOnlyAccess,arn:aws:iam::123456789012:policy/Deploy",
+		},
+		{
+			name:     "template with extra ARNs",
+			policy:   "deploy",
+			arns:     "arn:aws:iam::aws:policy/ReadOnlyAccess",
+			wantARNs: "arn:aws:iam::123456789012:policy/Deploy,arn:aws:iam::aws:policy/ReadOnlyAccess",
+		},
+		{
+			name:       "template file relative to the config file",
+			policy:     "logs",
+			wantPolicy: `{"Statement":[{"Effect":"Allow","Action":"logs:*","Resource":"*"}]}`,
+		},
+		{
+			name:       "inline template",
+			policy:     "ec2",
+			wantPolicy: `{"Statement":[{"Effect":"Allow","Action":"ec2:Describe*","Resource":"*"}]}`,
+		},
+		{name: "invalid template", policy: "bad", wantErrSubstr: `invalid session policy "bad": invalid policy document`},
+		{name: "invalid file", policy: filepath.Join(dir, "broken.json"), wantErrSubstr: "broken.json: invalid policy document"},
+		{name: "unknown", policy: "s3-only", wantErrSubstr: "no such template in session-policies, and no file s3-only"},
+		{name: "invalid ARN", arns: "arn:aws:iam::123456789012:role/Admin", wantErrSubstr: "invalid policy ARN"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := resolveSessionPolicy(tc.policy, tc.arns, file, configFile)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantARNs == "" && tc.wantPolicy == "" {
+				if got != nil {
+					t.Fatalf("expected no session policy, got %+v", got)
+				}
+				return
+			}
+			if got == nil || strings.Join(got.PolicyARNs, ",") != tc.wantARNs || got.Policy != tc.wantPolicy {
+				t.Fatalf("unexpected session policy %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/sessions"
+	"github.com/spf13/cobra"
+)
+
+func newSessionsCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	list := func(cmd *cobra.Command, args []string) error {
+		g, err := resolveGlobals(cmd, deps)
+		if err != nil {
+			return err
+		}
+		deps.messages = g.printer()
+		if deps.sessions == nil {
+			return i18n.Errorf("session tracking is unavailable: no state directory")
+		}
+
+		active, err := deps.sessions.Active(deps.now())
+		if err != nil {
+			return err
+		}
+
+		table := output.Table{
+			Columns: []output.Column{
+				{Header: "ID", Key: "id"},
+				{Header: "PROFILE", Key: "profile"},
+				{Header: "ACCOUNT", Key: "account"},
+				{Header: "ROLE", Key: "role"},
+				{Header: "DESTINATION", Key: "destination"},
+				{Header: "OPENED", Key: "opened"},
+				{Header: "EXPIRES", Key: "expires"},
+				{Header: "REMAINING", Key: "remaining"},
+			},
+		}
+		for _, s := range active {
+			profile := s.Profile
+			if s.FallbackFor != "" {
+				profile += " (fallback for " + s.FallbackFor + ")"
+			}
+			table.Rows = append(table.Rows, []string{
+				s.ID,
+				profile,
+				s.Account,
+				s.Role,
+				s.Destination,
+				formatTimestamp(s.OpenedAt),
+				formatTimestamp(s.ExpiresAt),
+				s.ExpiresAt.Sub(deps.now()).Truncate(time.Minute).String(),
+			})
+		}
+
+		if len(table.Rows) == 0 && g.output == output.FormatTable {
+			deps.messages.Fprintln(deps.stdout, "No active console sessions.")
+			return nil
+		}
+		return output.Render(deps.stdout, g.output, table)
+	}
+
+	sessionsCmd := &cobra.Command{
+		Use:     "sessions",
+		Aliases: []string{"session"},
+		Short:   "List console sessions opened by aws-console that have not expired",
+		Long: `Lists the console sessions aws-console has opened whose session duration has
+not yet run out, with the profile, account, role, and page each one was opened on.
+Use 'sessions open <id>' to sign in again on the same page,
+'sessions logout <id>' to sign the browser out of the console, or
+'sessions remaining' to show the time left in a shell prompt or tmux status bar.`,
+		Args: cobra.NoArgs,
+		RunE: list,
+	}
+
+	sessionsCmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List console sessions that have not expired (the default)",
+			Args:  cobra.NoArgs,
+			RunE:  list,
+		},
+		newSessionsRemainingCmd(deps),
+		newSessionsOpenCmd(deps, runner),
+		newSessionsLogoutCmd(deps),
+	)
+	return sessionsCmd
+}
+
+// Formats of 'sessions remaining'.
+const (
+	remainingPlain = "plain"
+	remainingTmux  = "tmux"
+)
+
+// Below these, 'sessions remaining --format tmux' turns yellow, then red.
+const (
+	remainingWarn     = 15 * time.Minute
+	remainingCritical = 5 * time.Minute
+)
+
+func newSessionsRemainingCmd(deps runDeps) *cobra.Command {
+	var format string
+
+	remainingCmd := &cobra.Command{
+		Use:   "remaining [id|profile]",
+		Short: "Print the profile and time left of the latest console session, for prompts and status bars",
+		Long: `Prints the profile and time left of the most recently opened console session
+that has not expired, or of the session with the given ID or profile, such as
+"prod 42m". Nothing is printed when there is none, so a prompt segment or status
+bar showing it disappears.
+
+It only reads the local sessions file: no config, no credentials, and no network,
+so it is fast enough to run on every prompt. --format tmux colors the text for a
+tmux status bar: green, yellow under 15 minutes, and red under 5.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != remainingPlain && format != remainingTmux {
+				return i18n.Errorf("invalid --format %q: expected %s or %s", format, remainingPlain, remainingTmux)
+			}
+			if deps.sessions == nil {
+				return i18n.Errorf("session tracking is unavailable: no state directory")
+			}
+			now := deps.now()
+			active, err := deps.sessions.Active(now)
+			if err != nil {
+				return err
+			}
+
+			var latest *sessions.Session
+			for i, s := range active {
+				if len(args) == 1 && s.ID != args[0] && s.Profile != args[0] {
+					continue
+				}
+				if latest == nil || !s.OpenedAt.Before(latest.OpenedAt) {
+					latest = &active[i]
+				}
+			}
+			if latest == nil {
+				return nil
+			}
+			fmt.Fprintln(deps.stdout, formatRemaining(*latest, now, format))
+			return nil
+		},
+	}
+
+	remainingCmd.Flags().StringVar(&format, "format", remainingPlain, "Output format: plain, or tmux for a status bar with colors")
+	return remainingCmd
+}
+
+// formatRemaining describes the time left in session at now.
+func formatRemaining(session sessions.Session, now time.Time, format string) string {
+	left := session.ExpiresAt.Sub(now)
+	text := session.Profile + " " + strings.TrimSuffix(left.Truncate(time.Minute).String(), "0s")
+	if left < time.Minute {
+		text = session.Profile + " <1m"
+	}
+	if format != remainingTmux {
+		return text
+	}
+	color := "green"
+	switch {
+	case left < remainingCritical:
+		color = "red"
+	case left < remainingWarn:
+		color = "yellow"
+	}
+	return "#[fg=" + color + "]" + text + "#[default]"
+}
+
+func newSessionsOpenCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	var flags workflowFlags
+
+	openCmd := &cobra.Command{
+		Use:   "open <id>",
+		Short: "Sign in again with the session's profile and open the same page",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.sessions == nil {
+				return i18n.Errorf("session tracking is unavailable: no state directory")
+			}
+			session, err := deps.sessions.Find(args[0], deps.now())
+			if err != nil {
+				return err
+			}
+
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			// The session's profile wins over AWS_PROFILE, which is usually set for
+			// something else entirely.
+			opts, err := flags.options(session.Profile, session.Destination)
+			if err != nil {
+				return err
+			}
+
+			ctx, deps := g.apply(context.Background(), deps)
+			if err := runner(ctx, opts, deps); err != nil {
+				return err
+			}
+			// The new sign-in replaces the old session in the browser.
+			return deps.sessions.Remove(session.ID)
+		},
+	}
+
+	addWorkflowFlags(openCmd, &flags)
+	return openCmd
+}
+
+func newSessionsLogoutCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout <id>",
+		Short: "Sign the browser out of the console and forget the session",
+		Long: `Opens the console sign-out page for the session's partition and forgets the
+session. The console keeps its session in browser cookies, so this signs that
+browser out of every console session, not only the one given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.sessions == nil {
+				return i18n.Errorf("session tracking is unavailable: no state directory")
+			}
+			session, err := deps.sessions.Find(args[0], deps.now())
+			if err != nil {
+				return err
+			}
+
+			logoutURL, err := awslib.LogoutURL(session.Partition)
+			if err != nil {
+				return err
+			}
+			if deps.term.Piped() {
+				fmt.Fprintln(deps.stdout, logoutURL)
+			} else {
+				deps.messages.Fprintf(deps.stdout, "Signing out of the AWS Console (%s)...\n", session.Profile)
+				if err := deps.open(logoutURL, browserOptions{}.withSettings(deps)); err != nil {
+					return err
+				}
+			}
+			return deps.sessions.Remove(session.ID)
+		},
+	}
+}
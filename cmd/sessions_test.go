@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/sessions"
+)
+
+func TestSessionsCmd(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := sessions.NewStoreAt(filepath.Join(t.TempDir(), sessions.FileName))
+	active, err := store.Add(sessions.Session{
+		Profile: "dev", Account: "123456789012", Role: "Admin", Destination: "ec2/home",
+		OpenedAt: now.Add(-time.Hour), ExpiresAt: now.Add(90 * time.Minute),
+	}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Add(sessions.Session{Profile: "old", OpenedAt: now, ExpiresAt: now.Add(time.Minute)}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fallback, err := store.Add(sessions.Session{
+		Profile: "breakglass", FallbackFor: "dev", Account: "123456789012", Role: "BreakGlass",
+		OpenedAt: now, ExpiresAt: now.Add(time.Hour),
+	}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deps := runDeps{sessions: store, now: func() time.Time { return now.Add(2 * time.Minute) }}
+	out, err := executeSubcommand(t, deps, "sessions", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "id,profile,account,role,destination,opened,expires,remaining\n" +
+		active.ID + ",dev,123456789012,Admin,ec2/home,2025-01-01T11:00:00Z,2025-01-01T13:30:00Z,1h28m0s\n" +
+		fallback.ID + ",breakglass (fallback for dev),123456789012,BreakGlass,,2025-01-01T12:00:00Z,2025-01-01T13:00:00Z,58m0s\n"
+	if out != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+
+	empty := runDeps{sessions: sessions.NewStoreAt(filepath.Join(t.TempDir(), sessions.FileName)), now: time.Now}
+	out, err = executeSubcommand(t, empty, "sessions")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "No active console sessions.") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestSessionsRemainingCmd(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := sessions.NewStoreAt(filepath.Join(t.TempDir(), sessions.FileName))
+	dev, err := store.Add(sessions.Session{Profile: "dev", OpenedAt: now.Add(-time.Hour), ExpiresAt: now.Add(92 * time.Minute)}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Add(sessions.Session{Profile: "prod", OpenedAt: now.Add(-10 * time.Minute), ExpiresAt: now.Add(4 * time.Minute)}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		store         *sessions.Store
+		want          string
+		wantErrSubstr string
+	}{
+		{name: "latest session", args: []string{"session", "remaining"}, want: "prod 4m\n"},
+		{name: "by profile", args: []string{"session", "remaining", "dev"}, want: "dev 1h32m\n"},
+		{name: "by ID", args: []string{"sessions", "remaining", dev.ID}, want: "dev 1h32m\n"},
+		{name: "tmux", args: []string{"sessions", "remaining", "--format", "tmux"}, want: "#[fg=red]prod 4m#[default]\n"},
+		{name: "tmux with time left", args: []string{"sessions", "remaining", "dev", "--format", "tmux"}, want: "#[fg=green]dev 1h32m#[default]\n"},
+		{name: "no match", args: []string{"sessions", "remaining", "staging"}, want: ""},
+		{name: "no sessions", args: []string{"sessions", "remaining"}, store: sessions.NewStoreAt(filepath.Join(t.TempDir(), sessions.FileName)), want: ""},
+		{name: "invalid format", args: []string{"sessions", "remaining", "--format", "starship"}, wantErrSubstr: `invalid --format "starship"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{sessions: store, now: func() time.Time { return now }}
+			if tc.store != nil {
+				deps.sessions = tc.store
+			}
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, out)
+			}
+		})
+	}
+}
+
+func TestSessionsOpenCmd(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "unrelated")
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := sessions.NewStoreAt(filepath.Join(t.TempDir(), sessions.FileName))
+	session, err := store.Add(sessions.Session{Profile: "dev", Destination: "cloudshell/home?region=eu-west-1", ExpiresAt: now.Add(time.Hour)}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var captured *workflowOptions
+	deps := runDeps{sessions: store, now: func() time.Time { return now }, stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+	root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		captured = &opts
+		return nil
+	})
+	root.SetArgs([]string{"sessions", "open", session.ID, "--new-window"})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if captured == nil || captured.profile != "dev" || captured.destination != "cloudshell/home?region=eu-west-1" || !captured.browser.newWindow {
+		t.Fatalf("unexpected workflow options: %+v", captured)
+	}
+	if _, err := store.Find(session.ID, now); err == nil {
+		t.Fatal("expected the re-opened session to be replaced")
+	}
+
+	root.SetArgs([]string{"sessions", "open", "deadbeef"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), `no active session with ID "deadbeef"`) {
+		t.Fatalf("expected unknown session error, got %v", err)
+	}
+}
+
+func TestSessionsLogoutCmd(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := sessions.NewStoreAt(filepath.Join(t.TempDir(), sessions.FileName))
+	session, err := store.Add(sessions.Session{Profile: "gov", Partition: awslib.PartitionUSGov, ExpiresAt: now.Add(time.Hour)}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var opened string
+	deps := runDeps{
+		sessions: store,
+		now:      func() time.Time { return now },
+		term:     interactiveTerminal,
+		open: func(targetURL string, opts browserOptions) error {
+			opened = targetURL
+			return nil
+		},
+	}
+	if _, err := executeSubcommand(t, deps, "sessions", "logout", session.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened != "https://signin.amazonaws-us-gov.com/oauth?Action=logout" {
+		t.Fatalf("unexpected logout URL: %q", opened)
+	}
+	if active, _ := store.Active(now); len(active) != 0 {
+		t.Fatalf("expected the session to be forgotten, got %+v", active)
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import awslib "github.com/eculver/aws-console/pkg/aws"
+
+// profileMFASerial returns the mfa_serial of profile, if any.
+func profileMFASerial(profile string, deps runDeps) string {
+	if profile == "" || deps.profiles == nil {
+		return ""
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return ""
+	}
+	return profileByName(profiles)[profile].MFASerial
+}
+
+// chainedRoleProfile reports whether profile assumes its role with the credentials of
+// another role session, so its own credentials are role-chained.
+func chainedRoleProfile(profile string, deps runDeps) bool {
+	if profile == "" || deps.profiles == nil {
+		return false
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return false
+	}
+	return awslib.ChainedRole(profiles, profile)
+}
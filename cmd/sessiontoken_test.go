@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+func TestSessionTokenMFA(t *testing.T) {
+	t.Parallel()
+
+	const (
+		profileSerial    = "arn:aws:iam::123456789012:mfa/alice"
+		discoveredSerial = "arn:aws:iam::123456789012:mfa/alice-phone"
+	)
+
+	testCases := []struct {
+		name          string
+		profile       string
+		input         awslib.AssumeRoleInput
+		devices       []string
+		devicesErr    error
+		term          term.Info
+		stdin         string
+		wantInput     awslib.SessionTokenInput
+		wantListCalls int64
+		wantErrSubstr string
+	}{
+		{
+			name:      "flags",
+			profile:   "keys",
+			input:     awslib.AssumeRoleInput{MFASerial: "arn:aws:iam::123456789012:mfa/bob", MFAToken: "111111"},
+			wantInput: awslib.SessionTokenInput{MFASerial: "arn:aws:iam::123456789012:mfa/bob", MFAToken: "111111"},
+		},
+		{
+			name:      "profile mfa_serial prompts for the code",
+			profile:   "mfa",
+			term:      interactiveTerminal,
+			stdin:     "222222\n",
+			wantInput: awslib.SessionTokenInput{MFASerial: profileSerial, MFAToken: "222222"},
+		},
+		{
+			name:          "profile mfa_serial requires a code outside a terminal",
+			profile:       "mfa",
+			wantErrSubstr: "--mfa-token is required for MFA device " + profileSerial,
+		},
+		{
+			name:          "discovered device prompts for the code",
+			profile:       "keys",
+			devices:       []string{discoveredSerial, "arn:aws:iam::123456789012:mfa/alice-backup"},
+			term:          interactiveTerminal,
+			stdin:         "333333\n",
+			wantInput:     awslib.SessionTokenInput{MFASerial: discoveredSerial, MFAToken: "333333"},
+			wantListCalls: 1,
+		},
+		{
+			name:          "discovered device with --mfa-token",
+			profile:       "keys",
+			input:         awslib.AssumeRoleInput{MFAToken: "444444"},
+			devices:       []string{discoveredSerial},
+			wantInput:     awslib.SessionTokenInput{MFASerial: discoveredSerial, MFAToken: "444444"},
+			wantListCalls: 1,
+		},
+		{
+			name:          "discovered device is skipped outside a terminal",
+			profile:       "keys",
+			devices:       []string{discoveredSerial},
+			wantListCalls: 1,
+		},
+		{
+			name:          "no devices",
+			profile:       "keys",
+			term:          interactiveTerminal,
+			wantListCalls: 1,
+		},
+		{
+			name:          "discovery denied",
+			profile:       "keys",
+			devicesErr:    errors.New("AccessDenied"),
+			term:          interactiveTerminal,
+			wantListCalls: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got awslib.SessionTokenInput
+			service := &mocks.Service{
+				ListMFADevicesFunc: func(ctx context.Context, profile string) ([]string, error) {
+					return tc.devices, tc.devicesErr
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}, nil
+				},
+				GetSessionTokenFunc: func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
+					got = input
+					return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+				},
+			}
+			deps := runDeps{
+				awsService: service,
+				profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) {
+					return []awslib.Profile{
+						{Name: "keys", Source: awslib.ProfileSourceStatic},
+						{Name: "mfa", Source: awslib.ProfileSourceStatic, MFASerial: profileSerial},
+					}, nil
+				}},
+				term:            tc.term,
+				stdin:           strings.NewReader(tc.stdin),
+				stdout:          &bytes.Buffer{},
+				stderr:          &bytes.Buffer{},
+				sessionDuration: 3600,
+			}
+
+			identity := awslib.Identity{Arn: "arn:aws:iam::123456789012:user/alice"}
+			creds, err := federationCredentials(context.Background(), tc.profile, &identity, workflowOptions{profile: tc.profile, assumeRole: tc.input}, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if service.GetSessionTokenCalls.Load() != 0 {
+					t.Fatal("expected the error before GetSessionToken is called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tc.wantInput.DurationSeconds = 3600
+			if got != tc.wantInput {
+				t.Fatalf("expected GetSessionToken input %+v, got %+v", tc.wantInput, got)
+			}
+			if service.ListMFADevicesCalls.Load() != tc.wantListCalls {
+				t.Fatalf("expected %d ListMFADevices calls, got %d", tc.wantListCalls, service.ListMFADevicesCalls.Load())
+			}
+			if creds.Kind != awslib.CredentialKindSessionToken {
+				t.Fatalf("expected session token credentials, got %q", creds.Kind)
+			}
+		})
+	}
+}
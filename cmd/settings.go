@@ -0,0 +1,1076 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/audit"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/browser"
+	"github.com/eculver/aws-console/pkg/browserprofiles"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/credcache"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/keychain"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/paths"
+	"github.com/eculver/aws-console/pkg/prompt"
+	"github.com/eculver/aws-console/pkg/secrets"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	settingProfile            = "profile"
+	settingRegion             = "region"
+	settingOutput             = "output"
+	settingVerbose            = "verbose"
+	settingQuiet              = "quiet"
+	settingDebug              = "debug"
+	settingDuration           = "duration"
+	settingTimeout            = "timeout"
+	settingDeadline           = "deadline"
+	settingDebugHTTP          = "debug-http"
+	settingTimings            = "timings"
+	settingLang               = "lang"
+	settingNoColor            = "no-color"
+	settingAccessible         = "accessible"
+	settingAWSConfigFile      = "aws-config-file"
+	settingSTSEndpoint        = "sts-endpoint"
+	settingFederationEndpoint = "federation-endpoint"
+	settingReauthURL          = "reauth-url"
+	settingBrowser            = "browser"
+	settingBrowserProfile     = "browser-profile"
+	settingContainer          = "container"
+	settingLocalRedirect      = "local-redirect"
+	settingIsolateAccounts    = "isolate-accounts"
+	settingHeadless           = "headless"
+	settingDestination        = "destination"
+	settingIssuer             = "issuer"
+	settingConfigFile         = "config-file"
+	settingPartition          = "partition"
+	settingCredentialStore    = "credential-store"
+	settingProxy              = "proxy"
+	settingCABundle           = "ca-bundle"
+	settingInsecure           = "insecure-skip-verify"
+	settingHistory            = "history"
+	settingNotify             = "notify"
+	settingSessionPolicy      = "session-policy"
+	settingPolicyARNs         = "policy-arns"
+	settingColor              = "color"
+	settingCredentialSource   = "credential-source"
+	settingMFASource          = "mfa-source"
+	settingValidate           = "validate"
+	settingOrgRole            = "org-role"
+	settingSessionName        = "session-name-template"
+	settingStrict             = "strict"
+	settingForbiddenSources   = "forbid-credential-sources"
+	settingCredentialPolicy   = "credential-policy"
+)
+
+// Values of the credential-store setting.
+const (
+	credentialStoreFile     = "file"
+	credentialStoreKeychain = "keychain"
+	credentialStoreServer   = "server"
+)
+
+// Values of the validate setting.
+const (
+	validateAlways = "always"
+	validateRecent = "recent"
+	validateSkip   = "skip"
+)
+
+// settingsCatalog declares every setting aws-console resolves, in display order.
+func settingsCatalog() []config.Setting {
+	return []config.Setting{
+		{
+			Key:         settingProfile,
+			Description: "AWS profile to use",
+			Flag:        "profile",
+			Env:         []string{"AWS_PROFILE"},
+		},
+		{
+			Key:         settingRegion,
+			Description: "AWS region used for STS calls",
+			Flag:        "region",
+			Env:         []string{"AWS_REGION", "AWS_DEFAULT_REGION"},
+			ProfileKey:  "region",
+			FileKey:     "region",
+		},
+		{
+			Key:         settingOutput,
+			Description: "Output format for tabular commands",
+			Default:     string(output.FormatTable),
+			Flag:        "output",
+			FileKey:     "output",
+		},
+		{
+			Key:         settingVerbose,
+			Description: "Print progress details to stderr",
+			Default:     "false",
+			Flag:        "verbose",
+			FileKey:     "verbose",
+		},
+		{
+			Key:         settingQuiet,
+			Description: "Print only results and errors, leaving out progress messages, notes, and warnings",
+			Default:     "false",
+			Flag:        "quiet",
+			Env:         []string{"AWS_CONSOLE_QUIET"},
+			FileKey:     "quiet",
+		},
+		{
+			Key:         settingDebug,
+			Description: "Log debug details, such as AWS calls, to stderr with secrets masked",
+			Default:     "false",
+			Flag:        "debug",
+			FileKey:     "debug",
+		},
+		{
+			Key:         settingDuration,
+			Description: "Console session duration",
+			Default:     awslib.MaxSessionDuration.String(),
+			Flag:        "duration",
+			FileKey:     "duration",
+		},
+		{
+			Key:         settingTimeout,
+			Description: "Timeout for each federation request",
+			Default:     awslib.DefaultHTTPTimeout.String(),
+			Flag:        "timeout",
+			FileKey:     "timeout",
+		},
+		{
+			Key:         settingDeadline,
+			Description: "Time limit for signing in and opening the console, including any SSO login; 0 for none",
+			Default:     "0s",
+			Flag:        "deadline",
+			Env:         []string{"AWS_CONSOLE_DEADLINE"},
+			FileKey:     "deadline",
+		},
+		{
+			Key:         settingDebugHTTP,
+			Description: "Log federation HTTP exchanges to stderr",
+			Default:     "false",
+			Flag:        "debug-http",
+			FileKey:     "debug-http",
+		},
+		{
+			Key:         settingTimings,
+			Description: "Report how long each step took",
+			Default:     "false",
+			Flag:        "timings",
+			FileKey:     "timings",
+		},
+		{
+			Key:         settingNoColor,
+			Description: "Leave colors out of terminal output; NO_COLOR set to any value does too. QR codes are then drawn inverted, in the terminal's own colors, and may not scan on a light theme",
+			Default:     "false",
+			Flag:        "no-color",
+			Env:         []string{"AWS_CONSOLE_NO_COLOR"},
+			FileKey:     "no-color",
+		},
+		{
+			Key:         settingAccessible,
+			Description: "Screen reader friendly output: plain lines, without colors, escape sequences, QR codes, or redrawn screens",
+			Default:     "false",
+			Flag:        "accessible",
+			Env:         []string{"AWS_CONSOLE_ACCESSIBLE"},
+			FileKey:     "accessible",
+		},
+		{
+			Key:         settingLang,
+			Description: "Language of prompts and messages: en, ja, or de (defaults to the locale in LC_ALL, LC_MESSAGES, or LANG)",
+			Flag:        "lang",
+			Env:         []string{"AWS_CONSOLE_LANG"},
+			FileKey:     "lang",
+		},
+		{
+			Key:         settingProxy,
+			Description: "Proxy URL for federation requests, instead of HTTPS_PROXY",
+			Flag:        "proxy",
+			Env:         []string{"AWS_CONSOLE_PROXY"},
+			FileKey:     "proxy",
+		},
+		{
+			Key:         settingCABundle,
+			Description: "PEM file of certificates trusted for federation requests, besides the system roots",
+			Flag:        "ca-bundle",
+			Env:         []string{"AWS_CONSOLE_CA_BUNDLE", "AWS_CA_BUNDLE"},
+			ProfileKey:  "ca_bundle",
+			FileKey:     "ca-bundle",
+		},
+		{
+			Key:         settingInsecure,
+			Description: "Skip TLS certificate verification of federation requests",
+			Default:     "false",
+			Flag:        "insecure-skip-verify",
+			FileKey:     "insecure-skip-verify",
+		},
+		{
+			Key:         settingHistory,
+			Description: "Record the consoles opened in a local history",
+			Default:     "true",
+			Env:         []string{"AWS_CONSOLE_HISTORY"},
+			FileKey:     "history",
+		},
+		{
+			Key:         settingCredentialStore,
+			Description: "Where cached credentials and sign-in tokens are kept: file, keychain, or server",
+			Default:     credentialStoreFile,
+			Env:         []string{"AWS_CONSOLE_CREDENTIAL_STORE"},
+			FileKey:     "credential-store",
+		},
+		{
+			Key:         settingCredentialSource,
+			Description: "Password manager item holding the profile's access keys, e.g. 1password:Private/AWS",
+			Env:         []string{"AWS_CONSOLE_CREDENTIAL_SOURCE"},
+			ProfileKey:  "aws_console_credential_source",
+			FileKey:     "credential-source",
+		},
+		{
+			Key:         settingMFASource,
+			Description: "Where MFA codes come from: prompt, a password manager item with a one-time password, or yubikey:<account>",
+			Default:     mfaSourcePrompt,
+			Flag:        "mfa-source",
+			Env:         []string{"AWS_CONSOLE_MFA_SOURCE"},
+			ProfileKey:  "aws_console_mfa_source",
+			FileKey:     "mfa-source",
+		},
+		{
+			Key:         settingValidate,
+			Description: "When credentials are checked before federating: always, recent to skip checks within 15 minutes of the last, or skip",
+			Default:     validateAlways,
+			Env:         []string{"AWS_CONSOLE_VALIDATE"},
+			ProfileKey:  "aws_console_validate",
+			FileKey:     "validate",
+		},
+		{
+			Key:         settingOrgRole,
+			Description: "Role 'aws-console org' assumes in member accounts of the organization",
+			Default:     awslib.DefaultOrganizationRole,
+			Flag:        "role-name",
+			Env:         []string{"AWS_CONSOLE_ORG_ROLE"},
+			ProfileKey:  "aws_console_org_role",
+			FileKey:     "org-role",
+		},
+		{
+			Key:         settingSessionName,
+			Description: "Name of assumed role sessions, shown in CloudTrail; {user}, {host}, {reason}, {ticket}, and {time} are replaced",
+			Default:     awslib.DefaultSessionNameTemplate,
+			Env:         []string{"AWS_CONSOLE_SESSION_NAME_TEMPLATE"},
+			FileKey:     "session-name-template",
+		},
+		{
+			Key:         settingStrict,
+			Description: "Refuse to federate long-lived IAM user access keys, allowing only SSO and role credentials",
+			Default:     "false",
+			Flag:        "strict",
+			Env:         []string{"AWS_CONSOLE_STRICT"},
+			FileKey:     "strict",
+		},
+		{
+			Key:         settingForbiddenSources,
+			Description: "Comma-separated credential sources that are refused, such as keys,environment",
+			Env:         []string{"AWS_CONSOLE_FORBID_CREDENTIAL_SOURCES"},
+			FileKey:     "forbid-credential-sources",
+		},
+		{
+			Key:         settingCredentialPolicy,
+			Description: "Command run before federating credentials, which refuses them by exiting non-zero",
+			FileKey:     "credential-policy",
+		},
+		{
+			Key:         settingSTSEndpoint,
+			Description: "STS endpoint override, e.g. a VPC interface endpoint",
+			Flag:        "sts-endpoint",
+			Env:         []string{"AWS_CONSOLE_STS_ENDPOINT", "AWS_ENDPOINT_URL_STS", "AWS_ENDPOINT_URL"},
+			ProfileKey:  "aws_console_sts_endpoint",
+			FileKey:     "sts-endpoint",
+		},
+		{
+			Key:         settingFederationEndpoint,
+			Description: "Federation endpoint override, e.g. a local stand-in for testing",
+			Flag:        "federation-endpoint",
+			Env:         []string{"AWS_CONSOLE_FEDERATION_ENDPOINT"},
+			ProfileKey:  "aws_console_federation_endpoint",
+			FileKey:     "federation-endpoint",
+		},
+		{
+			Key:         settingReauthURL,
+			Description: "Re-authentication URL the console links to when a session expires",
+			Flag:        "reauth-url",
+			Env:         []string{"AWS_CONSOLE_REAUTH_URL"},
+			FileKey:     "reauth-url",
+		},
+		{
+			Key:         settingBrowser,
+			Description: "Browser to open the console in, or a command template containing {url}",
+			Flag:        "browser",
+			Env:         []string{"AWS_CONSOLE_BROWSER"},
+			ProfileKey:  "aws_console_browser",
+			FileKey:     "browser",
+		},
+		{
+			Key:         settingBrowserProfile,
+			Description: "Browser profile to open the console in",
+			Flag:        "browser-profile",
+			Env:         []string{"AWS_CONSOLE_BROWSER_PROFILE"},
+			ProfileKey:  "aws_console_browser_profile",
+			FileKey:     "browser-profile",
+		},
+		{
+			Key:         settingContainer,
+			Description: "Firefox container to open the console in; {profile} and {account} are replaced",
+			Flag:        "container",
+			Env:         []string{"AWS_CONSOLE_CONTAINER"},
+			ProfileKey:  "aws_console_container",
+			FileKey:     "container",
+		},
+		{
+			Key:         settingIsolateAccounts,
+			Description: "Open the console in a browser profile of the account's own, created on first use",
+			Default:     "false",
+			Flag:        "isolate-accounts",
+			Env:         []string{"AWS_CONSOLE_ISOLATE_ACCOUNTS"},
+			ProfileKey:  "aws_console_isolate_accounts",
+			FileKey:     "isolate-accounts",
+		},
+		{
+			Key:         settingLocalRedirect,
+			Description: "Open a one-time local URL that redirects to the sign-in URL, instead of the sign-in URL itself",
+			Default:     "false",
+			Flag:        "local-redirect",
+			Env:         []string{"AWS_CONSOLE_LOCAL_REDIRECT"},
+			FileKey:     "local-redirect",
+		},
+		{
+			Key:         settingHeadless,
+			Description: "Print the sign-in URL, show it as a QR code, and copy it instead of opening a browser (detected when not set)",
+			Flag:        "headless",
+			Env:         []string{"AWS_CONSOLE_HEADLESS"},
+			FileKey:     "headless",
+		},
+		{
+			Key:         settingNotify,
+			Description: "Show desktop notifications when an SSO login is needed or a console session is about to expire",
+			Default:     "false",
+			Flag:        "notify",
+			Env:         []string{"AWS_CONSOLE_NOTIFY"},
+			FileKey:     "notify",
+		},
+		{
+			Key:         settingSessionPolicy,
+			Description: "Session policy limiting console sessions: a JSON policy file, or a template in the config file",
+			Flag:        "session-policy",
+			Env:         []string{"AWS_CONSOLE_SESSION_POLICY"},
+			FileKey:     "session-policy",
+		},
+		{
+			Key:         settingPolicyARNs,
+			Description: "Comma-separated managed policy ARNs limiting console sessions",
+			Flag:        "policy-arns",
+			Env:         []string{"AWS_CONSOLE_POLICY_ARNS"},
+			FileKey:     "policy-arns",
+		},
+		{
+			Key:         settingPartition,
+			Description: "AWS partition to federate in, instead of the one in the caller identity",
+			Flag:        "partition",
+			Env:         []string{"AWS_CONSOLE_PARTITION"},
+			ProfileKey:  "aws_console_partition",
+			FileKey:     "partition",
+		},
+		{
+			Key:         settingDestination,
+			Description: "Console page to open when none is given",
+			Flag:        "destination",
+			Env:         []string{"AWS_CONSOLE_DESTINATION"},
+			ProfileKey:  "aws_console_destination",
+			FileKey:     "destination",
+		},
+		{
+			Key:         settingColor,
+			Description: "Session color of switch-role links: red, orange, yellow, green, blue, or a hex value",
+			Env:         []string{"AWS_CONSOLE_COLOR"},
+			ProfileKey:  "aws_console_color",
+			FileKey:     "color",
+		},
+		{
+			Key:         settingIssuer,
+			Description: "Issuer name, or URL the console links to when the session expires",
+			Default:     awslib.DefaultIssuer,
+			Flag:        "issuer",
+			Env:         []string{"AWS_CONSOLE_ISSUER"},
+			ProfileKey:  "aws_console_issuer",
+			FileKey:     "issuer",
+		},
+		{
+			Key:         settingConfigFile,
+			Description: "aws-console config file",
+			Default:     "~/.config/aws-console/config.yaml",
+			Env:         []string{"AWS_CONSOLE_CONFIG"},
+		},
+		{
+			Key:         settingAWSConfigFile,
+			Description: "Shared AWS config file",
+			Default:     "~/.aws/config",
+			Env:         []string{"AWS_CONFIG_FILE"},
+		},
+	}
+}
+
+// resolveSettings computes the effective settings for an invocation. Flags take
+// precedence over the aws-console config file, which takes precedence over environment
+// variables, which take precedence over values from the selected profile in the shared
+// AWS config. Within the config file a profile's section overrides its top-level
+// defaults, and aliases are replaced by the profile they name. When the shared config
+// cannot be read the values resolved without it are returned together with the error.
+func resolveSettings(flags *pflag.FlagSet, file *config.File, deps runDeps) ([]config.Value, error) {
+	catalog := settingsCatalog()
+	flagLayer := config.FlagLayer(flags)
+	envLayer := config.EnvLayer(os.LookupEnv)
+
+	values := config.Resolve(catalog, flagLayer, config.FileLayer(file, deps.configFile, ""), envLayer)
+	resolveAlias(values, file)
+	profile := settingValue(values, settingProfile)
+	if profile == "" {
+		return values, nil
+	}
+
+	layers := []config.Layer{flagLayer, config.FileLayer(file, deps.configFile, profile), envLayer}
+	values = config.Resolve(catalog, layers...)
+	resolveAlias(values, file)
+	if deps.profiles == nil {
+		return values, nil
+	}
+
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return values, i18n.Errorf("failed to read profile settings: %w", err)
+	}
+	p, ok := profileByName(profiles)[profile]
+	if !ok {
+		return values, nil
+	}
+
+	layers = append(layers, config.ProfileLayer(p.Name, map[string]string{
+		"region":                          p.Region,
+		"aws_console_sts_endpoint":        p.STSEndpoint,
+		"aws_console_federation_endpoint": p.FederationEndpoint,
+		"aws_console_browser":             p.Browser,
+		"aws_console_browser_profile":     p.BrowserProfile,
+		"aws_console_container":           p.Container,
+		"aws_console_isolate_accounts":    p.IsolateAccounts,
+		"aws_console_partition":           p.Partition,
+		"aws_console_issuer":              p.Issuer,
+		"aws_console_destination":         p.Destination,
+		"aws_console_color":               p.Color,
+		"aws_console_credential_source":   p.CredentialSource,
+		"aws_console_mfa_source":          p.MFASource,
+		"aws_console_validate":            p.Validate,
+		"aws_console_org_role":            p.OrgRole,
+		"ca_bundle":                       p.CABundle,
+	}))
+	values = config.Resolve(catalog, layers...)
+	resolveAlias(values, file)
+	return values, nil
+}
+
+// resolveAlias replaces a profile alias from the config file with the profile it names.
+func resolveAlias(values []config.Value, file *config.File) {
+	for i, v := range values {
+		if v.Setting.Key != settingProfile || v.Value == "" {
+			continue
+		}
+		if target := file.Alias(v.Value); target != v.Value {
+			values[i].Value = target
+			values[i].Origin = fmt.Sprintf("%s (alias %s)", v.Origin, v.Value)
+		}
+	}
+}
+
+// loadConfigFile reads the aws-console config file, rejecting keys that are not settings.
+func loadConfigFile(deps runDeps) (*config.File, error) {
+	file, err := config.LoadFile(deps.configFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range file.Keys() {
+		if _, ok := fileSetting(key); !ok {
+			return nil, i18n.Errorf("unknown setting %q in config file %s", key, deps.configFile)
+		}
+	}
+	return file, nil
+}
+
+// fileSetting returns the setting read from key in the config file.
+func fileSetting(key string) (config.Setting, bool) {
+	for _, s := range settingsCatalog() {
+		if s.FileKey != "" && s.FileKey == key {
+			return s, true
+		}
+	}
+	return config.Setting{}, false
+}
+
+// settingValue returns the resolved value for key, or "" when it is unknown.
+func settingValue(values []config.Value, key string) string {
+	v, _ := config.Lookup(values, key)
+	return v.Value
+}
+
+// readOnlySessionPolicy returns the session policy of a command given --read-only,
+// which takes the place of a session policy from the config file or the environment:
+// asking for a read-only session is the more specific request. A session policy given
+// on the command line as well is an error, since the two would widen each other.
+func readOnlySessionPolicy(cmd *cobra.Command, values []config.Value, policy *awslib.SessionPolicy) (*awslib.SessionPolicy, error) {
+	flag := cmd.Flags().Lookup("read-only")
+	if policy == nil || flag == nil || flag.Value.String() != "true" {
+		return policy, nil
+	}
+	for _, key := range []string{settingSessionPolicy, settingPolicyARNs} {
+		if v, _ := config.Lookup(values, key); v.Source == config.SourceFlag && v.Value != "" {
+			return nil, i18n.Errorf("--read-only cannot be combined with --%s", key)
+		}
+	}
+	return nil, nil
+}
+
+// globalOptions are the persistent flags every subcommand inherits, after resolution
+// against the environment and shared config.
+type globalOptions struct {
+	profile string
+	region  string
+	output  output.Format
+	verbose bool
+	// quiet leaves out everything but results, errors, and prompts.
+	quiet     bool
+	debug     bool
+	debugHTTP bool
+	timings   bool
+	// noColor leaves colors out; accessible also leaves out escape sequences, drawings,
+	// and redrawn screens, for screen readers.
+	noColor    bool
+	accessible bool
+	// lang is the language prompts and messages are shown in.
+	lang     i18n.Language
+	duration time.Duration
+	// durationSet is true when the duration was given rather than left at its default.
+	durationSet bool
+	// timeout bounds each federation request.
+	timeout time.Duration
+	// deadline, when positive, bounds signing in and opening the console.
+	deadline time.Duration
+	// stsEndpoint may contain awslib.RegionPlaceholder.
+	stsEndpoint        string
+	federationEndpoint string
+	reauthURL          string
+	// browser is a browser name or command template; browserProfile overrides a profile
+	// given in a "browser:profile" value.
+	browser        string
+	browserProfile string
+	// container may contain {profile} and {account}, expanded when the console opens.
+	container string
+	// localRedirect opens the browser with a one-time loopback URL.
+	localRedirect bool
+	// isolateAccounts opens the browser in a profile directory of the account's own.
+	isolateAccounts bool
+	// headless shares sign-in URLs instead of opening a browser; when headlessSet is
+	// false, it was not given and is detected instead.
+	headless    bool
+	headlessSet bool
+	// partition, when set, overrides the partition detected from the caller identity.
+	partition string
+	// destination is the default console page; issuer names aws-console to the console.
+	destination string
+	issuer      string
+	// color is the session color of switch-role links.
+	color string
+	// credentialStore is credentialStoreFile, credentialStoreKeychain, or
+	// credentialStoreServer.
+	credentialStore string
+	// cacheStore is the store of the cache server of the config file, with
+	// credentialStoreServer; shareCache lets users reuse each other's entries on it.
+	cacheStore credcache.CacheStore
+	shareCache bool
+	// validate is validateAlways, validateRecent, or validateSkip.
+	validate string
+	// orgRole is the role assumed in member accounts opened with 'aws-console org'.
+	orgRole string
+	// sessionName is the template role session names are expanded from.
+	sessionName string
+	// policy decides which credentials may be federated.
+	policy credentialPolicy
+	// samlProviders are the SAML identity providers of the config file.
+	samlProviders map[string]config.SAMLProvider
+	// shortener is the redirector of the config file that --shorten uses.
+	shortener config.Shortener
+	// telemetry is the collector of the config file that step timings are exported to.
+	telemetry config.Telemetry
+	// configTiming is how long reading the config file and settings took.
+	configTiming timing
+	// history is false when consoles opened should not be recorded.
+	history bool
+	// notify shows desktop notifications of events that need the user's attention.
+	notify bool
+	// sessionPolicy, when set, limits the permissions of console sessions.
+	sessionPolicy *awslib.SessionPolicy
+	// hooks are run around opening the console.
+	hooks config.Hooks
+	// credentialSource, when set, is the password manager item the profile's access keys
+	// are read from, and mfaSource the one MFA codes are read from.
+	credentialSource *secrets.Item
+	mfaSource        *secrets.Item
+	// audit, when set, sends a record of each console opened.
+	audit *audit.Emitter
+	// transport, when set, carries federation requests through a proxy or with custom
+	// TLS roots.
+	transport          *http.Transport
+	insecureSkipVerify bool
+	// recordPath, when set, is the fixture file federation exchanges are recorded to.
+	recordPath string
+	// values holds every resolved setting, for commands that report on them.
+	values []config.Value
+	// profileErr is set when the shared config could not be read to resolve profile
+	// settings. It is not fatal: values resolved without the profile are still usable.
+	profileErr error
+}
+
+// addGlobalFlags registers the persistent flags shared by the whole command tree.
+func addGlobalFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.VarP(&profileFlag{}, "profile", "p", "AWS profile to use (defaults to AWS_PROFILE env var); repeat to open the console for several")
+	flags.String("region", "", "AWS region to use (defaults to AWS_REGION or the profile's region)")
+	flags.StringP("output", "o", string(output.FormatTable), "Output format: table, csv, or json")
+	flags.Bool("verbose", false, "Print progress details to stderr")
+	flags.BoolP("quiet", "q", false, "Print only results and errors, leaving out progress messages, notes, and warnings")
+	flags.Bool("debug", false, "Log debug details, such as credential sources and AWS calls, to stderr with secrets masked")
+	flags.Duration("duration", awslib.MaxSessionDuration, "Console session duration, between 15m and 12h")
+	flags.Duration("timeout", awslib.DefaultHTTPTimeout, "Timeout for each request to the federation endpoint")
+	flags.Duration("deadline", 0, "Give up on signing in and opening the console after this long, including any SSO login (0 for no limit)")
+	flags.Bool("debug-http", false, "Log federation requests and responses to stderr, with secrets redacted")
+	flags.Bool("timings", false, "Report how long each step took on stderr")
+	flags.Bool("no-color", false, "Leave colors out of terminal output (also NO_COLOR); QR codes are drawn inverted and may not scan on a light theme")
+	flags.Bool("accessible", false, "Screen reader friendly output: plain lines, without colors, escape sequences, QR codes, or redrawn screens")
+	flags.String("lang", "", "Language of prompts and messages: en, ja, or de (defaults to the locale in LANG)")
+	flags.Bool("notify", false, "Show desktop notifications when an SSO login is needed or a console session is about to expire")
+	flags.String("sts-endpoint", "", "Send STS calls to this endpoint, e.g. a VPC endpoint; {region} is replaced with the region")
+	flags.String("federation-endpoint", "", "Request sign-in tokens from this federation endpoint instead of the partition's")
+	flags.String("partition", "", "AWS partition to federate in: aws, aws-us-gov, or aws-cn (defaults to the caller identity's)")
+	flags.String("issuer", "", "Issuer for console sessions: a name, or a URL such as your SSO portal that the console links to when the session expires")
+	flags.String("reauth-url", "", "URL of a running 'aws-console reauth-server' for the console's sign-in-again link")
+	flags.String("proxy", "", "Send federation requests through this proxy URL (defaults to HTTPS_PROXY)")
+	flags.String("ca-bundle", "", "PEM file of certificates to trust for federation requests, in addition to the system roots")
+	flags.String("session-policy", "", "Limit console sessions with a session policy: a JSON policy file, or the name of a template in the config file")
+	flags.String("policy-arns", "", "Limit console sessions with these comma-separated managed policy ARNs")
+	flags.String("mfa-source", "", "Where MFA codes come from: prompt, a password manager item such as 1password:Private/AWS, or yubikey:<account> to read them with ykman")
+	flags.Bool("strict", false, "Refuse to federate long-lived IAM user access keys; sign in with SSO or a role instead")
+	flags.Bool("insecure-skip-verify", false, "Skip TLS certificate verification of federation requests; for debugging only")
+}
+
+// profileFlag is the --profile flag. It can be repeated to open the console for several
+// profiles at once; like a string flag, its value is the last profile given, even when
+// that profile was given before.
+type profileFlag struct {
+	names []string
+}
+
+func (f *profileFlag) String() string {
+	if len(f.names) == 0 {
+		return ""
+	}
+	return f.names[len(f.names)-1]
+}
+
+func (f *profileFlag) Set(name string) error {
+	f.names = append(f.names, name)
+	return nil
+}
+
+func (f *profileFlag) Type() string {
+	return "string"
+}
+
+// profileFlagValues returns every profile given with --profile, in the order they were
+// first given and without duplicates.
+func profileFlagValues(flags *pflag.FlagSet) []string {
+	f, ok := flags.Lookup("profile").Value.(*profileFlag)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, name := range f.names {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// setProfileFlag makes name the only --profile value, so settings resolve for it.
+func setProfileFlag(flags *pflag.FlagSet, name string) error {
+	if f, ok := flags.Lookup("profile").Value.(*profileFlag); ok {
+		f.names = nil
+	}
+	return flags.Set("profile", name)
+}
+
+// resolveGlobals resolves and validates the persistent flags for cmd.
+func resolveGlobals(cmd *cobra.Command, deps runDeps) (globalOptions, error) {
+	if len(profileFlagValues(cmd.Flags())) > 1 {
+		return globalOptions{}, i18n.Errorf("--profile can only be repeated when opening the console")
+	}
+
+	now := deps.now
+	if now == nil {
+		now = time.Now
+	}
+	configStart := now()
+	file, err := loadConfigFile(deps)
+	if err != nil {
+		return globalOptions{}, err
+	}
+
+	values, profileErr := resolveSettings(cmd.Flags(), file, deps)
+	configTiming := timing{step: "config", start: configStart, duration: now().Sub(configStart)}
+	g := globalOptions{
+		profile:            settingValue(values, settingProfile),
+		region:             settingValue(values, settingRegion),
+		stsEndpoint:        settingValue(values, settingSTSEndpoint),
+		federationEndpoint: settingValue(values, settingFederationEndpoint),
+		reauthURL:          settingValue(values, settingReauthURL),
+		partition:          settingValue(values, settingPartition),
+		destination:        settingValue(values, settingDestination),
+		issuer:             settingValue(values, settingIssuer),
+		color:              settingValue(values, settingColor),
+		hooks:              file.Hooks,
+		values:             values,
+		profileErr:         profileErr,
+
+		credentialStore: settingValue(values, settingCredentialStore),
+		validate:        settingValue(values, settingValidate),
+		orgRole:         settingValue(values, settingOrgRole),
+		sessionName:     settingValue(values, settingSessionName),
+		samlProviders:   file.SAMLProviders,
+		shortener:       file.Shortener,
+		telemetry:       file.Telemetry,
+		configTiming:    configTiming,
+	}
+
+	if g.output, err = output.ParseFormat(settingValue(values, settingOutput)); err != nil {
+		return g, err
+	}
+	if g.verbose, err = boolSetting(values, settingVerbose); err != nil {
+		return g, err
+	}
+	if g.debug, err = boolSetting(values, settingDebug); err != nil {
+		return g, err
+	}
+	if g.quiet, err = boolSetting(values, settingQuiet); err != nil {
+		return g, err
+	}
+	if g.quiet && (g.verbose || g.debug) {
+		return g, i18n.Errorf("--quiet cannot be used with --verbose or --debug")
+	}
+	if g.debugHTTP, err = boolSetting(values, settingDebugHTTP); err != nil {
+		return g, err
+	}
+	if g.timings, err = boolSetting(values, settingTimings); err != nil {
+		return g, err
+	}
+	if g.noColor, err = boolSetting(values, settingNoColor); err != nil {
+		return g, err
+	}
+	if g.accessible, err = boolSetting(values, settingAccessible); err != nil {
+		return g, err
+	}
+	g.lang = i18n.Detect(os.LookupEnv)
+	if lang := settingValue(values, settingLang); lang != "" {
+		if g.lang, err = i18n.Parse(lang); err != nil {
+			return g, err
+		}
+	}
+	if g.insecureSkipVerify, err = boolSetting(values, settingInsecure); err != nil {
+		return g, err
+	}
+	if g.history, err = boolSetting(values, settingHistory); err != nil {
+		return g, err
+	}
+	if g.localRedirect, err = boolSetting(values, settingLocalRedirect); err != nil {
+		return g, err
+	}
+	if g.notify, err = boolSetting(values, settingNotify); err != nil {
+		return g, err
+	}
+	if g.isolateAccounts, err = boolSetting(values, settingIsolateAccounts); err != nil {
+		return g, err
+	}
+	if settingValue(values, settingHeadless) != "" {
+		if g.headless, err = boolSetting(values, settingHeadless); err != nil {
+			return g, err
+		}
+		g.headlessSet = true
+	}
+	if g.policy.strict, err = boolSetting(values, settingStrict); err != nil {
+		return g, err
+	}
+	if g.policy.forbidden, err = parseForbiddenSources(settingValue(values, settingForbiddenSources)); err != nil {
+		return g, err
+	}
+	g.policy.command = settingValue(values, settingCredentialPolicy)
+
+	if g.stsEndpoint != "" {
+		if err := awslib.ValidateSTSEndpoint(g.stsEndpoint); err != nil {
+			return g, err
+		}
+	}
+
+	if g.federationEndpoint != "" {
+		if err := awslib.ValidateFederationEndpoint(g.federationEndpoint); err != nil {
+			return g, err
+		}
+	}
+
+	if g.partition != "" {
+		if err := awslib.ValidatePartition(g.partition); err != nil {
+			return g, err
+		}
+	}
+
+	if err := awslib.ValidateIssuer(g.issuer); err != nil {
+		return g, err
+	}
+
+	if g.color != "" {
+		if err := awslib.ValidateSwitchRoleColor(g.color); err != nil {
+			return g, err
+		}
+	}
+
+	if err := validateCredentialStore(g.credentialStore); err != nil {
+		return g, err
+	}
+	if err := validateValidate(g.validate); err != nil {
+		return g, err
+	}
+	if g.credentialStore == credentialStoreKeychain && !keychain.Supported(deps.goos) {
+		return g, awslib.MarkError(i18n.Errorf("credential-store keychain is not supported on %s", deps.goos), awslib.ErrUnsupportedPlatform)
+	}
+
+	if g.reauthURL != "" {
+		if err := validateReauthURL(g.reauthURL); err != nil {
+			return g, err
+		}
+	}
+
+	g.browser, g.browserProfile = browser.ParseSpec(settingValue(values, settingBrowser))
+	if profile := settingValue(values, settingBrowserProfile); profile != "" {
+		g.browserProfile = profile
+	}
+	g.container = settingValue(values, settingContainer)
+	bopts := browser.Options{Browser: g.browser, Profile: g.browserProfile, Container: g.container}
+	if g.isolateAccounts {
+		// Any directory validates the same; the account's is only known once signed in.
+		bopts.DataDir = browserprofiles.DirName
+	}
+	if err := browser.Validate(bopts); err != nil {
+		return g, err
+	}
+
+	if g.credentialSource, err = parseCredentialSource(settingValue(values, settingCredentialSource)); err != nil {
+		return g, err
+	}
+	if g.mfaSource, err = parseMFASource(settingValue(values, settingMFASource)); err != nil {
+		return g, err
+	}
+
+	if err := awslib.ValidateSessionNameTemplate(g.sessionName); err != nil {
+		return g, err
+	}
+
+	if g.sessionPolicy, err = resolveSessionPolicy(settingValue(values, settingSessionPolicy), settingValue(values, settingPolicyARNs), file, deps.configFile); err != nil {
+		return g, err
+	}
+	if g.sessionPolicy, err = readOnlySessionPolicy(cmd, values, g.sessionPolicy); err != nil {
+		return g, err
+	}
+
+	topts := awslib.TransportOptions{
+		Proxy:              settingValue(values, settingProxy),
+		InsecureSkipVerify: g.insecureSkipVerify,
+	}
+	if topts.CABundle, err = paths.ExpandHome(settingValue(values, settingCABundle)); err != nil {
+		return g, err
+	}
+	if topts != (awslib.TransportOptions{}) {
+		if g.transport, err = awslib.NewTransport(topts); err != nil {
+			return g, err
+		}
+	}
+	g.audit = newAuditEmitter(file.Audit, g.transport, deps)
+	if g.recordPath, err = recordingPath(deps); err != nil {
+		return g, err
+	}
+	if g.credentialStore == credentialStoreServer {
+		if g.cacheStore, err = newCacheServerStore(file.CacheServer, g.transport); err != nil {
+			return g, err
+		}
+		g.shareCache = file.CacheServer.Share
+	}
+
+	raw := settingValue(values, settingDuration)
+	if g.duration, err = time.ParseDuration(raw); err != nil {
+		return g, i18n.Errorf("invalid duration %q: %w", raw, err)
+	}
+	if err := awslib.ValidateSessionDuration("", g.duration); err != nil {
+		return g, err
+	}
+	v, _ := config.Lookup(values, settingDuration)
+	g.durationSet = v.Source != config.SourceDefault
+
+	raw = settingValue(values, settingTimeout)
+	if g.timeout, err = time.ParseDuration(raw); err != nil {
+		return g, i18n.Errorf("invalid timeout %q: %w", raw, err)
+	}
+	if g.timeout <= 0 {
+		return g, i18n.Errorf("invalid timeout %q: must be positive", raw)
+	}
+
+	raw = settingValue(values, settingDeadline)
+	if g.deadline, err = time.ParseDuration(raw); err != nil {
+		return g, i18n.Errorf("invalid deadline %q: %w", raw, err)
+	}
+	if g.deadline < 0 {
+		return g, i18n.Errorf("invalid deadline %q: must not be negative", raw)
+	}
+
+	return g, nil
+}
+
+// validateCredentialStore checks a credential-store setting.
+func validateCredentialStore(value string) error {
+	switch value {
+	case credentialStoreFile, credentialStoreKeychain, credentialStoreServer:
+		return nil
+	}
+	return i18n.Errorf("unsupported credential-store %q (expected %s, %s, or %s)", value, credentialStoreFile, credentialStoreKeychain, credentialStoreServer)
+}
+
+// validateValidate checks a validate setting.
+func validateValidate(value string) error {
+	switch value {
+	case validateAlways, validateRecent, validateSkip:
+		return nil
+	}
+	return i18n.Errorf("unsupported validate %q (expected %s, %s, or %s)", value, validateAlways, validateRecent, validateSkip)
+}
+
+func boolSetting(values []config.Value, key string) (bool, error) {
+	v, err := strconv.ParseBool(settingValue(values, key))
+	if err != nil {
+		return false, i18n.Errorf("invalid %s setting: %w", key, err)
+	}
+	return v, nil
+}
+
+// printer returns the Printer for messages in the language of g, leaving out all but
+// prompts with --quiet. Commands that do not apply g set it themselves.
+func (g globalOptions) printer() *output.Printer {
+	if g.quiet {
+		return output.NewPrinter(g.lang, output.LevelQuiet)
+	}
+	return output.NewPrinter(g.lang, output.LevelNormal)
+}
+
+// apply threads the resolved options into the context and dependencies used to run a command.
+func (g globalOptions) apply(ctx context.Context, deps runDeps) (context.Context, runDeps) {
+	deps.verbose = g.verbose || g.debug
+	deps.debug = g.debug
+	deps.sessionDuration = int32(g.duration / time.Second)
+	deps.durationSet = g.durationSet
+	deps.reauthURL = g.reauthURL
+	deps.browser = g.browser
+	deps.browserProfile = g.browserProfile
+	deps.container = g.container
+	deps.localRedirect = g.localRedirect
+	deps.isolateAccounts = g.isolateAccounts
+	deps.headless, deps.headlessSet = g.headless, g.headlessSet
+	deps.sessionPolicy = g.sessionPolicy
+	deps.hooks = g.hooks
+	deps.audit = g.audit
+	deps.mfaSource = g.mfaSource
+	deps.validate = g.validate
+	deps.orgRole = g.orgRole
+	deps.sessionName = g.sessionName
+	deps.policy = g.policy
+	deps.samlProviders = g.samlProviders
+	if g.transport != nil {
+		deps.samlTransport = g.transport
+	}
+	deps.shortener = newShortener(g.shortener, g.transport)
+	deps.messages = g.printer()
+	deps.term.NoColor = deps.term.NoColor || g.noColor || g.accessible
+	deps.term.Accessible = g.accessible
+	if picker, ok := deps.picker.(*prompt.LinePicker); ok {
+		picker = picker.Localized(deps.messages.Sprintf)
+		if g.accessible {
+			picker = picker.Accessible()
+		}
+		deps.picker = picker
+	}
+	deps.deadline = g.deadline
+	if g.debugHTTP {
+		ctx = awslib.WithHTTPDebug(ctx, deps.stderr)
+	}
+	if g.timings || g.telemetry.Endpoint != "" {
+		deps.timings = newTimings(deps.now)
+		deps.timings.silent = !g.timings
+		deps.timings.exporter = newTelemetryExporter(g.telemetry, g.transport, deps.goos)
+		deps.timings.record(g.configTiming)
+	}
+	if !g.history {
+		deps.history = nil
+	}
+	if !g.notify {
+		deps.notifier = nil
+	}
+	if fc, ok := deps.federation.(*awslib.FederationClient); ok && (g.transport != nil || g.recordPath != "") {
+		var transport http.RoundTripper = http.DefaultTransport
+		if g.transport != nil {
+			transport = g.transport
+		}
+		if g.recordPath != "" {
+			transport = awslib.NewRecordingTransport(g.recordPath, transport)
+			deps.messages.Fprintf(deps.stderr, "Recording sanitized federation exchanges to %s\n", g.recordPath)
+		}
+		deps.federation = fc.WithTransport(transport)
+		if g.insecureSkipVerify {
+			deps.messages.Fprintln(deps.stderr, "Warning: TLS certificates of the federation endpoint are not verified")
+		}
+	}
+	if g.credentialStore == credentialStoreKeychain && deps.credentials != nil {
+		deps.credentials = credcache.NewStoreCache(deps.cacheDir, keychain.New(deps.goos, deps.executor))
+	}
+	if g.cacheStore != nil && deps.credentials != nil {
+		deps.credentials = credcache.NewStoreCache(deps.cacheDir, g.cacheStore).WithScope(cacheServerScope(g.shareCache, deps))
+	}
+	if g.credentialSource != nil {
+		// The keys are the selected profile's; a fallback profile signs with its own.
+		ctx = awslib.WithProfileKeySource(ctx, g.profile, secretKeySource(*g.credentialSource, deps))
+	}
+	ctx = awslib.WithLogger(ctx, logger(deps))
+	ctx = awslib.WithHTTPTimeout(ctx, g.timeout)
+	ctx = awslib.WithSTSEndpoint(ctx, g.stsEndpoint)
+	ctx = awslib.WithFederationEndpoint(ctx, g.federationEndpoint)
+	ctx = awslib.WithIssuer(ctx, g.issuer)
+	ctx = awslib.WithPartition(ctx, g.partition)
+	return awslib.WithRegion(ctx, g.region), deps
+}
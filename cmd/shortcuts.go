@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/eculver/aws-console/pkg/destination"
+	"github.com/spf13/cobra"
+)
+
+// newShortcutCmd creates a subcommand that opens the console directly on a built-in page.
+func newShortcutCmd(shortcut destination.Shortcut, deps runDeps, runner workflowRunner) *cobra.Command {
+	use := shortcut.Name
+	if shortcut.Args != "" {
+		use += " " + shortcut.Args
+	}
+
+	var flags workflowFlags
+	shortcutCmd := &cobra.Command{
+		Use:   use,
+		Short: shortcut.Description,
+		Args:  cobra.MaximumNArgs(shortcut.MaxArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := shortcut.Path(args)
+			if err != nil {
+				return err
+			}
+
+			g, err := resolveWorkflowGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			opts, err := flags.options(g.profile, path)
+			if err != nil {
+				return err
+			}
+			// --regions sets the region per URL; otherwise regional pages use the resolved region.
+			if shortcut.Regional && len(opts.regions) == 0 {
+				opts.destination = destination.WithRegion(path, g.region)
+			}
+
+			ctx, deps := g.apply(context.Background(), deps)
+			return runner(ctx, opts, deps)
+		},
+	}
+
+	addWorkflowFlags(shortcutCmd, &flags)
+	return shortcutCmd
+}
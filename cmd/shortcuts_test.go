@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestShortcutCmds(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		args            []string
+		wantProfile     string
+		wantDestination string
+		wantErrSubstr   string
+	}{
+		{
+			name:            "health",
+			args:            []string{"health", "--profile", "ops"},
+			wantProfile:     "ops",
+			wantDestination: "health/home#/account/dashboard/open-issues",
+		},
+		{
+			name:            "trusted advisor",
+			args:            []string{"trusted-advisor", "-p", "ops"},
+			wantProfile:     "ops",
+			wantDestination: "trustedadvisor/home",
+		},
+		{
+			name:            "quotas for a service",
+			args:            []string{"quotas", "lambda", "-p", "ops"},
+			wantProfile:     "ops",
+			wantDestination: "servicequotas/home/services/lambda/quotas",
+		},
+		{
+			name:            "cloudshell in a region",
+			args:            []string{"cloudshell", "--region", "eu-west-1", "-p", "ops"},
+			wantProfile:     "ops",
+			wantDestination: "cloudshell/home?region=eu-west-1",
+		},
+		{
+			name:            "cloudshell leaves region to --regions",
+			args:            []string{"cloudshell", "--region", "eu-west-1", "--regions", "us-east-1,us-west-2", "-p", "ops"},
+			wantProfile:     "ops",
+			wantDestination: "cloudshell/home",
+		},
+		{
+			name:          "quotas rejects invalid service",
+			args:          []string{"quotas", "Lambda!", "-p", "ops"},
+			wantErrSubstr: `invalid service code "Lambda!"`,
+		},
+		{
+			name:          "quotas rejects extra args",
+			args:          []string{"quotas", "ec2", "s3", "-p", "ops"},
+			wantErrSubstr: "accepts at most 1 arg(s)",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var captured *workflowOptions
+			deps := runDeps{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}}
+			root := newRootCmd(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+				captured = &opts
+				return nil
+			})
+			root.SetArgs(tc.args)
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if captured != nil {
+					t.Fatal("expected workflow not to run")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if captured == nil {
+				t.Fatal("expected workflow to run")
+			}
+			if captured.profile != tc.wantProfile || captured.destination != tc.wantDestination {
+				t.Fatalf("unexpected workflow options: %+v", *captured)
+			}
+		})
+	}
+}
+
+func TestRunWorkflowPassesDestination(t *testing.T) {
+	t.Parallel()
+
+	federation := &mocks.FederationBuilder{
+		BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+			return "https://example.com/console-login", nil
+		},
+	}
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+			},
+		},
+		federation:      federation,
+		open:            func(targetURL string, opts browserOptions) error { return nil },
+		term:            interactiveTerminal,
+		stdout:          &bytes.Buffer{},
+		stderr:          &bytes.Buffer{},
+		sessionDuration: sessionDuration,
+	}
+
+	err := runWorkflow(context.Background(), workflowOptions{profile: "ops", destination: "trustedadvisor/home"}, deps)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if federation.LastDestination != "trustedadvisor/home" {
+		t.Fatalf("unexpected destination: %q", federation.LastDestination)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/shortlink"
+)
+
+// newShortener returns a client of the shortener of the config file, or nil when none
+// is configured. Header values are expanded from the environment, so secrets can stay
+// out of the file.
+func newShortener(settings config.Shortener, transport *http.Transport) *shortlink.Client {
+	if settings.URL == "" {
+		return nil
+	}
+	headers := make(map[string]string, len(settings.Headers))
+	for k, v := range settings.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	c := &shortlink.Client{Endpoint: settings.URL, Headers: headers}
+	if transport != nil {
+		c.HTTP = &http.Client{Transport: transport}
+	}
+	return c
+}
+
+// shortenURLs exchanges each sign-in URL for a short, single-use link that expires with
+// its sign-in token. The redirector can sign in as the session until the link is used,
+// which the user is warned about every time, even with --quiet.
+func shortenURLs(ctx context.Context, loginURLs []string, deps runDeps) ([]string, error) {
+	if deps.shortener == nil {
+		return nil, i18n.Errorf("--shorten needs a shortener url in the config file")
+	}
+	host := deps.shortener.Endpoint
+	if u, err := url.Parse(host); err == nil {
+		host = u.Host
+	}
+	deps.messages.Promptf(deps.stderr, "Warning: the sign-in URL is sent to %s; until the short link is used or expires, anyone in control of that redirector can open this console session\n", host)
+
+	expires := deps.now().Add(awslib.SigninTokenTTL)
+	done := deps.timings.start("shorten")
+	defer done()
+	links := make([]string, 0, len(loginURLs))
+	for _, loginURL := range loginURLs {
+		link, err := deps.shortener.Shorten(ctx, loginURL, expires)
+		if err != nil {
+			return nil, i18n.Errorf("failed to shorten the sign-in URL: %w", err)
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
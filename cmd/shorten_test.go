@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/eculver/aws-console/pkg/shortlink"
+)
+
+func TestRunWorkflowShorten(t *testing.T) {
+	t.Parallel()
+
+	const loginURL = "https://signin.aws.amazon.com/federation?Action=login&SigninToken=tok"
+
+	testCases := []struct {
+		name          string
+		status        int
+		noShortener   bool
+		quiet         bool
+		wantStdout    string
+		wantErrSubstr string
+	}{
+		{name: "short link", status: http.StatusOK, wantStdout: "https://go.example.com/x7Kq\n"},
+		{name: "warns with --quiet", status: http.StatusOK, quiet: true, wantStdout: "https://go.example.com/x7Kq\n"},
+		{name: "shortener fails", status: http.StatusForbidden, wantErrSubstr: "failed to shorten the sign-in URL: shortener returned 403 Forbidden"},
+		{name: "no shortener", noShortener: true, wantErrSubstr: "--shorten needs a shortener url in the config file"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(`{"url": "https://go.example.com/x7Kq"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", Account: "123456789012"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return loginURL, nil
+					},
+				},
+				shortener:       &shortlink.Client{Endpoint: server.URL},
+				term:            interactiveTerminal,
+				now:             time.Now,
+				stdout:          stdout,
+				stderr:          stderr,
+				sessionDuration: sessionDuration,
+			}
+			if tc.noShortener {
+				deps.shortener = nil
+			}
+			if tc.quiet {
+				deps.messages = output.NewPrinter(i18n.English, output.LevelQuiet)
+			}
+
+			err := runWorkflow(context.Background(), workflowOptions{profile: "dev", print: true, shorten: true}, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if strings.Contains(stdout.String(), "SigninToken") {
+					t.Fatalf("expected the sign-in URL not to be printed when shortening fails, got %q", stdout)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stdout.String() != tc.wantStdout {
+				t.Fatalf("expected stdout %q, got %q", tc.wantStdout, stdout)
+			}
+			if !strings.Contains(stderr.String(), "Warning: the sign-in URL is sent to "+strings.TrimPrefix(server.URL, "http://")) {
+				t.Fatalf("expected a warning about the redirector, got %q", stderr)
+			}
+		})
+	}
+}
+
+func TestShortenRequiresOutput(t *testing.T) {
+	t.Parallel()
+
+	if _, err := (workflowFlags{shorten: true}).options("dev", ""); err == nil || !strings.Contains(err.Error(), "--shorten requires --print, --copy, or --qr") {
+		t.Fatalf("expected --shorten to require a printed, copied, or shown URL, got %v", err)
+	}
+	if _, err := (workflowFlags{shorten: true, copy: true}).options("dev", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
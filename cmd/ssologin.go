@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/sso"
+)
+
+// ssoLogin refreshes the SSO token of profile, or of the SSO profile at the root of its
+// source_profile chain. SSO profiles sign in natively through the device authorization
+// flow and the token is written to the cache shared with the AWS CLI; other profiles
+// fall back to `aws sso login`.
+func ssoLogin(ctx context.Context, profile string, deps runDeps) error {
+	p, ok := ssoProfile(profile, deps)
+	if !ok {
+		return awsCLISSOLogin(ctx, profile, deps)
+	}
+	if p.Name != profile {
+		verbosef(deps, "%s gets its credentials from %s", describeProfile(profile), describeProfile(p.Name))
+	}
+	if deps.deviceLogin == nil || deps.ssoTokens == nil {
+		return awsCLISSOLogin(ctx, p.Name, deps)
+	}
+
+	cfg, err := ssoClientConfig(p, deps)
+	if err != nil {
+		return err
+	}
+	notifications := &backgroundNotifier{deps: deps}
+	defer notifications.wait()
+	token, err := deps.deviceLogin(ctx, cfg, func(auth sso.Authorization) {
+		promptAuthorization(auth, notifications, deps)
+	})
+	if err != nil {
+		return err
+	}
+	if err := deps.ssoTokens.Put(ssocache.Key(p.SSOSession, p.SSOStartURL), token); err != nil {
+		return i18n.Errorf("failed to cache SSO token: %w", err)
+	}
+	deps.messages.Fprintf(statusWriter(deps), "Signed in to %s\n", cfg.StartURL)
+	return nil
+}
+
+// deviceLogin runs the device authorization flow against the OIDC endpoint of cfg's region.
+func deviceLogin(ctx context.Context, cfg sso.ClientConfig, notify func(sso.Authorization)) (ssocache.Token, error) {
+	return sso.NewDeviceLogin(sso.NewOIDCClient(cfg.Region)).Login(ctx, cfg, notify)
+}
+
+// promptAuthorization asks the user to approve a device authorization, opening the
+// approval page when possible.
+func promptAuthorization(auth sso.Authorization, notifications *backgroundNotifier, deps runDeps) {
+	w := statusWriter(deps)
+	deps.messages.Promptf(w, "Approve the sign-in request in your browser. If it does not open, visit:\n\n  %s\n\nand confirm the code %s.\n", auth.VerificationURL, auth.UserCode)
+	notifications.notify(fmt.Sprintf("SSO login required — check your browser and confirm the code %s.", auth.UserCode))
+	if deps.open == nil {
+		return
+	}
+	if err := deps.open(auth.VerificationURL, browserOptions{}.withSettings(deps)); err != nil {
+		deps.messages.Fprintf(deps.stderr, "Warning: failed to open browser: %v\n", err)
+	}
+}
+
+// ssoProfile returns the shared config entry of the SSO profile that profile gets its
+// credentials from: profile itself, or the root of its chain of source_profile roles.
+func ssoProfile(profile string, deps runDeps) (awslib.Profile, bool) {
+	if profile == "" || deps.profiles == nil {
+		return awslib.Profile{}, false
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return awslib.Profile{}, false
+	}
+	byName := profileByName(profiles)
+	p, ok := byName[profile]
+	// A chain that loops back on itself never reaches an SSO profile.
+	for seen := map[string]bool{}; ok && p.Source == awslib.ProfileSourceAssumeRole && p.SourceProfile != "" && !seen[p.Name]; {
+		seen[p.Name] = true
+		p, ok = byName[p.SourceProfile]
+	}
+	if !ok || p.Source != awslib.ProfileSourceSSO {
+		return awslib.Profile{}, false
+	}
+	return p, true
+}
+
+// ssoSessionProfiles returns the first of profiles to use each SSO session, ordered by
+// the session's token cache key.
+func ssoSessionProfiles(profiles []awslib.Profile) []awslib.Profile {
+	seen := make(map[string]bool)
+	var sessions []awslib.Profile
+	for _, p := range profiles {
+		if p.Source != awslib.ProfileSourceSSO {
+			continue
+		}
+		if key := ssocache.Key(p.SSOSession, p.SSOStartURL); !seen[key] {
+			seen[key] = true
+			sessions = append(sessions, p)
+		}
+	}
+	slices.SortStableFunc(sessions, func(a, b awslib.Profile) int {
+		return strings.Compare(ssocache.Key(a.SSOSession, a.SSOStartURL), ssocache.Key(b.SSOSession, b.SSOStartURL))
+	})
+	return sessions
+}
+
+// ssoClientConfig resolves the OIDC client for p from its sso-session, or from the
+// sso_start_url and sso_region of profiles configured without one. Registrations are
+// cached under the aws-console cache directory.
+func ssoClientConfig(p awslib.Profile, deps runDeps) (sso.ClientConfig, error) {
+	session := awslib.SSOSession{StartURL: p.SSOStartURL, Region: p.SSORegion}
+	if p.SSOSession != "" {
+		if deps.ssoSessions == nil {
+			return sso.ClientConfig{}, i18n.Errorf("cannot read sso-session %q", p.SSOSession)
+		}
+		var err error
+		if session, err = deps.ssoSessions.SSOSession(p.SSOSession); err != nil {
+			return sso.ClientConfig{}, i18n.Errorf("failed to read SSO configuration of %s: %w", describeProfile(p.Name), err)
+		}
+	} else if p.SSORegion == "" {
+		return sso.ClientConfig{}, i18n.Errorf("profile %q must set sso_region", p.Name)
+	}
+
+	cacheDir := ""
+	if deps.cacheDir != "" {
+		cacheDir = filepath.Join(deps.cacheDir, ssoCacheDirName)
+	}
+	return sso.NewClientConfig(session, cacheDir)
+}
+
+// awsCLISSOLogin shells out to the AWS CLI to perform an SSO login. The AWS CLI is
+// killed when ctx is done, so an interrupted or timed-out workflow leaves none behind.
+func awsCLISSOLogin(ctx context.Context, profile string, deps runDeps) error {
+	args := []string{"sso", "login"}
+	if profile != "" {
+		args = append(args, "--profile", profile)
+	}
+
+	notifications := &backgroundNotifier{deps: deps}
+	defer notifications.wait()
+	notifications.notify("SSO login required — check your browser.")
+	return deps.executor.RunContext(ctx, "aws", args, nil, deps.stdin, statusWriter(deps), deps.stderr)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/sso"
+)
+
+func TestSSOLoginNative(t *testing.T) {
+	t.Parallel()
+
+	profiles := []awslib.Profile{
+		{Name: "dev", Source: awslib.ProfileSourceSSO, SSOSession: "corp"},
+		{Name: "legacy", Source: awslib.ProfileSourceSSO, SSOStartURL: "https://legacy.awsapps.com/start", SSORegion: "eu-west-1"},
+		{Name: "legacy-no-region", Source: awslib.ProfileSourceSSO, SSOStartURL: "https://legacy.awsapps.com/start"},
+		{Name: "keys-role", Source: awslib.ProfileSourceAssumeRole, SourceProfile: "keys"},
+		{Name: "keys", Source: awslib.ProfileSourceStatic},
+		{Name: "prod", Source: awslib.ProfileSourceAssumeRole, SourceProfile: "ops"},
+		{Name: "ops", Source: awslib.ProfileSourceAssumeRole, SourceProfile: "dev"},
+		{Name: "loop", Source: awslib.ProfileSourceAssumeRole, SourceProfile: "loop-back"},
+		{Name: "loop-back", Source: awslib.ProfileSourceAssumeRole, SourceProfile: "loop"},
+	}
+	expires := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		profile       string
+		loginErr      error
+		wantCacheKey  string
+		wantStartURL  string
+		wantRegion    string
+		wantCLI       bool
+		wantErrSubstr string
+	}{
+		{
+			name:         "sso-session profile",
+			profile:      "dev",
+			wantCacheKey: "corp",
+			wantStartURL: "https://corp.awsapps.com/start",
+			wantRegion:   "us-east-1",
+		},
+		{
+			name:         "legacy profile",
+			profile:      "legacy",
+			wantCacheKey: "https://legacy.awsapps.com/start",
+			wantStartURL: "https://legacy.awsapps.com/start",
+			wantRegion:   "eu-west-1",
+		},
+		{
+			name:          "legacy profile without region",
+			profile:       "legacy-no-region",
+			wantErrSubstr: `profile "legacy-no-region" must set sso_region`,
+		},
+		{
+			name:         "role chain signs in through its root SSO profile",
+			profile:      "prod",
+			wantCacheKey: "corp",
+			wantStartURL: "https://corp.awsapps.com/start",
+			wantRegion:   "us-east-1",
+		},
+		{
+			name:    "non-SSO profile falls back to the AWS CLI",
+			profile: "keys-role",
+			wantCLI: true,
+		},
+		{
+			name:    "looping role chain falls back to the AWS CLI",
+			profile: "loop",
+			wantCLI: true,
+		},
+		{
+			name:          "login error",
+			profile:       "dev",
+			loginErr:      errors.New("the device authorization was denied"),
+			wantErrSubstr: "the device authorization was denied",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cacheDir := t.TempDir()
+			tokens := ssocache.NewCacheAt(t.TempDir())
+			executor := &fakeExecutor{}
+			notifier := &fakeNotifier{}
+			// Status output goes to stdout or stderr depending on the terminal.
+			output := &bytes.Buffer{}
+			var gotConfig sso.ClientConfig
+			var opened []string
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) { return profiles, nil }},
+				ssoSessions: &mocks.SSOSessionReader{SSOSessionFunc: func(name string) (awslib.SSOSession, error) {
+					return awslib.SSOSession{Name: name, StartURL: "https://corp.awsapps.com/start", Region: "us-east-1"}, nil
+				}},
+				ssoTokens: tokens,
+				deviceLogin: func(ctx context.Context, cfg sso.ClientConfig, notify func(sso.Authorization)) (ssocache.Token, error) {
+					gotConfig = cfg
+					notify(sso.Authorization{VerificationURL: "https://device.example/?user_code=ABCD-EFGH", UserCode: "ABCD-EFGH"})
+					if tc.loginErr != nil {
+						return ssocache.Token{}, tc.loginErr
+					}
+					return ssocache.Token{StartURL: cfg.StartURL, Region: cfg.Region, AccessToken: "access-token", ExpiresAt: expires}, nil
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = append(opened, targetURL)
+					return nil
+				},
+				executor: executor,
+				notifier: notifier,
+				cacheDir: cacheDir,
+				stdout:   output,
+				stderr:   output,
+			}
+
+			err := ssoLogin(context.Background(), tc.profile, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.wantCLI {
+				if len(executor.calls) != 1 || executor.calls[0].name != "aws" {
+					t.Fatalf("expected aws sso login, got %+v", executor.calls)
+				}
+				if len(notifier.messages) != 1 || notifier.messages[0] != "SSO login required — check your browser." {
+					t.Fatalf("unexpected notifications %q", notifier.messages)
+				}
+				return
+			}
+			if len(executor.calls) != 0 {
+				t.Fatalf("expected no AWS CLI calls, got %+v", executor.calls)
+			}
+
+			if gotConfig.StartURL != tc.wantStartURL || gotConfig.Region != tc.wantRegion || gotConfig.CacheDir != filepath.Join(cacheDir, "sso") {
+				t.Fatalf("unexpected client config: %+v", gotConfig)
+			}
+			if len(opened) != 1 || opened[0] != "https://device.example/?user_code=ABCD-EFGH" {
+				t.Fatalf("expected the approval page to be opened, got %v", opened)
+			}
+			if !strings.Contains(output.String(), "confirm the code ABCD-EFGH") || !strings.Contains(output.String(), "Signed in to "+tc.wantStartURL) {
+				t.Fatalf("unexpected output: %q", output.String())
+			}
+			if len(notifier.messages) != 1 || !strings.Contains(notifier.messages[0], "SSO login required") || !strings.Contains(notifier.messages[0], "ABCD-EFGH") {
+				t.Fatalf("unexpected notifications %q", notifier.messages)
+			}
+
+			token, err := tokens.Token(tc.wantCacheKey)
+			if err != nil {
+				t.Fatalf("expected the token to be cached: %v", err)
+			}
+			if token.AccessToken != "access-token" || !token.ExpiresAt.Equal(expires) {
+				t.Fatalf("unexpected cached token: %+v", token)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// defaultStatusWorkers is how many profiles status checks at once.
+const defaultStatusWorkers = 8
+
+func newStatusCmd(deps runDeps) *cobra.Command {
+	var workers int
+	var asJSON bool
+
+	statusCmd := &cobra.Command{
+		Use:   "status [profile...]",
+		Short: "Check credential validity for configured profiles",
+		Long: `Checks the credentials of each configured profile (or only the profiles given
+as arguments) without attempting an SSO login, and reports the result: ok, expired
+when signing in again should fix them, or error. Profiles are checked concurrently.`,
+		ValidArgsFunction: completeProfiles(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			if asJSON {
+				if cmd.Flags().Changed("output") && g.output != output.FormatJSON {
+					return i18n.Errorf("--json cannot be combined with --output %s", g.output)
+				}
+				g.output = output.FormatJSON
+			}
+			if workers < 1 {
+				return i18n.Errorf("invalid --concurrency %d: must be at least 1", workers)
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+
+			profiles, err := deps.profiles.ListProfiles()
+			if err != nil {
+				return i18n.Errorf("failed to list profiles: %w", err)
+			}
+
+			if len(args) > 0 {
+				byName := profileByName(profiles)
+				selected := make([]awslib.Profile, 0, len(args))
+				for _, name := range args {
+					p, ok := byName[name]
+					if !ok {
+						return i18n.Errorf("profile %q not found in AWS config", name)
+					}
+					selected = append(selected, p)
+				}
+				profiles = selected
+			}
+
+			table := output.Table{
+				Columns: append(append([]output.Column(nil), profileColumns...),
+					output.Column{Header: "EXPIRES IN", Key: "expires_in"},
+					output.Column{Header: "IDENTITY", Key: "identity"},
+					output.Column{Header: "ALIAS", Key: "alias"},
+					output.Column{Header: "STATUS", Key: "status"},
+					output.Column{Header: "ERROR", Key: "error"},
+				),
+			}
+			now := deps.now()
+			for _, status := range checkProfiles(ctx, profiles, workers, deps) {
+				table.Rows = append(table.Rows, status.statusRow(now))
+			}
+
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+
+	statusCmd.Flags().IntVar(&workers, "concurrency", defaultStatusWorkers, "How many profiles to check at once")
+	statusCmd.Flags().BoolVar(&asJSON, "json", false, "Print the results as JSON (same as --output json)")
+	return statusCmd
+}
+
+// checkProfiles checks up to workers profiles at once, looking up the account alias of
+// those with valid credentials. The statuses are in the order of profiles.
+func checkProfiles(ctx context.Context, profiles []awslib.Profile, workers int, deps runDeps) []profileStatus {
+	statuses := make([]profileStatus, len(profiles))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(workers, len(profiles)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				status := checkProfile(ctx, profiles[i], deps)
+				if status.valid() {
+					status.alias = accountAlias(describeAccount(ctx, profiles[i].Name, status.account, deps))
+				}
+				statuses[i] = status
+			}
+		}()
+	}
+	for i := range profiles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return statuses
+}
+
+// profileStatus is the outcome of validating a single profile's credentials.
+type profileStatus struct {
+	profile awslib.Profile
+	account string
+	alias   string
+	role    string
+	// identity is the ARN of the principal the credentials belong to.
+	identity string
+	expires  time.Time
+	err      error
+}
+
+func (s profileStatus) valid() bool {
+	return s.err == nil
+}
+
+// statusRow renders s for the status table, with the time its credentials have left
+// at now. Errors that signing in again should fix are reported as expired.
+func (s profileStatus) statusRow(now time.Time) []string {
+	expiresIn := ""
+	if !s.expires.IsZero() {
+		expiresIn = "expired"
+		if now.Before(s.expires) {
+			expiresIn = formatDuration(s.expires.Sub(now))
+		}
+	}
+	row := []string{s.profile.Name, s.account, s.role, s.profile.Source, formatTimestamp(s.expires), expiresIn, s.identity, s.alias}
+	switch {
+	case s.err != nil && awslib.ClassifyError(s.err) == awslib.ErrorKindExpired:
+		return append(row, "expired", s.err.Error())
+	case s.err != nil:
+		return append(row, "error", s.err.Error())
+	case expiresIn == "expired":
+		return append(row, "expired", "")
+	}
+	return append(row, "ok", "")
+}
+
+// checkProfile validates a single profile without attempting an SSO login.
+func checkProfile(ctx context.Context, p awslib.Profile, deps runDeps) profileStatus {
+	status := profileStatus{
+		profile: p,
+		account: p.AccountID,
+		role:    p.RoleName,
+	}
+
+	identity, err := deps.awsService.GetCallerIdentity(ctx, p.Name)
+	if err != nil {
+		status.err = err
+		return status
+	}
+	status.identity = identity.Arn
+	if identity.Account != "" {
+		status.account = identity.Account
+	}
+	if status.role == "" {
+		status.role = identity.RoleName()
+	}
+
+	creds, err := deps.awsService.RetrieveCredentials(ctx, p.Name)
+	if err != nil {
+		status.err = err
+		return status
+	}
+	status.expires = creds.Expires
+
+	return status
+}
+
+// accountAlias prefers the IAM alias and falls back to the Organizations account name.
+func accountAlias(info awslib.AccountInfo) string {
+	if info.Alias != "" {
+		return info.Alias
+	}
+	return info.Name
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/accounts"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestStatusCmd(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newService := func() *mocks.Service {
+		return &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				switch profile {
+				case "keys":
+					return awslib.Identity{}, errors.New("expired token")
+				case "broken":
+					return awslib.Identity{}, errors.New("no EC2 IMDS role found")
+				}
+				return awslib.Identity{
+					Arn:     "arn:aws:sts::123456789012:assumed-role/AdministratorAccess/me",
+					Account: "123456789012",
+				}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", Expires: expires}, nil
+			},
+			DescribeAccountFunc: func(ctx context.Context, profile string, accountID string) (awslib.AccountInfo, error) {
+				return awslib.AccountInfo{ID: accountID, Alias: "acme-dev"}, nil
+			},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		wantContains  []string
+		wantMissing   []string
+		wantErrSubstr string
+	}{
+		{
+			name: "all profiles",
+			args: []string{"status", "-o", "csv", "--concurrency", "2"},
+			wantContains: []string{
+				"profile,account,role,source,expiry,expires_in,identity,alias,status,error\n" +
+					"dev,123456789012,AdministratorAccess,sso,2030-01-02T03:04:05Z,1h30m,arn:aws:sts::123456789012:assumed-role/AdministratorAccess/me,acme-dev,ok,\n" +
+					"keys,,,static,,,,,expired,expired token\n" +
+					"broken,,,static,,,,,error,no EC2 IMDS role found\n",
+			},
+		},
+		{
+			name:         "json",
+			args:         []string{"status", "dev", "--json"},
+			wantContains: []string{`"profile": "dev"`, `"expires_in": "1h30m"`, `"status": "ok"`},
+		},
+		{
+			name:          "json with another output format",
+			args:          []string{"status", "--json", "-o", "csv"},
+			wantErrSubstr: "--json cannot be combined with --output csv",
+		},
+		{
+			name:          "invalid concurrency",
+			args:          []string{"status", "--concurrency", "0"},
+			wantErrSubstr: "invalid --concurrency 0",
+		},
+		{
+			name:         "selected profile",
+			args:         []string{"status", "dev"},
+			wantContains: []string{"dev", "ok"},
+			wantMissing:  []string{"keys"},
+		},
+		{
+			name:          "unknown profile",
+			args:          []string{"status", "nope"},
+			wantErrSubstr: `profile "nope" not found in AWS config`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{
+				awsService: newService(),
+				accounts:   accounts.NewCacheAt(filepath.Join(t.TempDir(), accounts.FileName), accounts.DefaultTTL),
+				now:        func() time.Time { return expires.Add(-90 * time.Minute) },
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) {
+						return append(testProfiles(), awslib.Profile{Name: "broken", Source: awslib.ProfileSourceStatic}), nil
+					},
+				},
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+			for _, missing := range tc.wantMissing {
+				if strings.Contains(out, missing) {
+					t.Fatalf("expected output not to contain %q, got:\n%s", missing, out)
+				}
+			}
+		})
+	}
+}
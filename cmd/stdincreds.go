@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// maxStdinCredentials bounds how much of stdin --stdin-creds reads; credential
+// documents are a few hundred bytes.
+const maxStdinCredentials = 64 << 10
+
+// stdinCredentialsDocument is either shape of credentials --stdin-creds accepts: the
+// output of a credential_process command, or of an STS call such as
+// `aws sts assume-role`, whose credentials are nested under Credentials.
+type stdinCredentialsDocument struct {
+	credentialProcessOutput
+	Credentials *credentialProcessOutput `json:"Credentials"`
+}
+
+// parseStdinCredentials reads a credentials document. Credentials that have already
+// expired are rejected before any call to AWS.
+func parseStdinCredentials(data []byte, now time.Time) (awslib.Credentials, error) {
+	var doc stdinCredentialsDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return awslib.Credentials{}, i18n.Errorf("invalid credentials on stdin: %w", err)
+	}
+	c := doc.credentialProcessOutput
+	if doc.Credentials != nil {
+		c = *doc.Credentials
+	}
+	switch {
+	case c.Version != 0 && c.Version != 1:
+		return awslib.Credentials{}, i18n.Errorf("invalid credentials on stdin: unsupported Version %d (expected 1)", c.Version)
+	case c.AccessKeyID == "" || c.SecretAccessKey == "":
+		return awslib.Credentials{}, i18n.Errorf("invalid credentials on stdin: expected AccessKeyId and SecretAccessKey, as printed by a credential_process command or `aws sts assume-role`")
+	}
+
+	creds := awslib.Credentials{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+		Source:          awslib.KeySourceProvider,
+	}
+	if c.Expiration != "" {
+		expires, err := time.Parse(time.RFC3339, c.Expiration)
+		if err != nil {
+			return awslib.Credentials{}, i18n.Errorf("invalid credentials on stdin: invalid Expiration %q: %w", c.Expiration, err)
+		}
+		if !expires.After(now) {
+			return awslib.Credentials{}, awslib.MarkError(i18n.Errorf("the credentials on stdin expired at %s", formatTimestamp(expires)), awslib.ErrCredentialsExpired)
+		}
+		creds.Expires = expires
+	}
+	return creds, nil
+}
+
+// withStdinCredentials reads credentials from stdin and returns a context whose AWS
+// calls sign with them instead of the profile's.
+func withStdinCredentials(ctx context.Context, deps runDeps) (context.Context, error) {
+	if deps.term.StdinTTY {
+		return ctx, i18n.Errorf("--stdin-creds reads a credentials document from stdin; pipe one in, e.g. from `aws sts assume-role`")
+	}
+	data, err := io.ReadAll(io.LimitReader(deps.stdin, maxStdinCredentials))
+	if err != nil {
+		return ctx, i18n.Errorf("failed to read credentials from stdin: %w", err)
+	}
+	creds, err := parseStdinCredentials(data, deps.now())
+	if err != nil {
+		return ctx, err
+	}
+	return awslib.WithKeySource(ctx, func(context.Context) (awslib.Credentials, error) {
+		return creds, nil
+	}), nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/term"
+)
+
+func TestParseStdinCredentials(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name          string
+		input         string
+		want          awslib.Credentials
+		wantExpired   bool
+		wantErrSubstr string
+	}{
+		{
+			name:  "credential_process",
+			input: `{"Version": 1, "AccessKeyId": "ASIAPROCESS", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "2025-01-01T13:00:00Z"}`,
+			want:  awslib.Credentials{AccessKeyID: "ASIAPROCESS", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(time.Hour), Source: awslib.KeySourceProvider},
+		},
+		{
+			name: "assume-role",
+			input: `{"Credentials": {"AccessKeyId": "ASIAROLE", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "2025-01-01T12:30:00+00:00"},
+				"AssumedRoleUser": {"AssumedRoleId": "AROAEXAMPLE:me", "Arn": "arn:aws:sts::123456789012:assumed-role/Dev/me"}}`,
+			want: awslib.Credentials{AccessKeyID: "ASIAROLE", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(30 * time.Minute), Source: awslib.KeySourceProvider},
+		},
+		{
+			name:  "long-lived keys",
+			input: `{"Version": 1, "AccessKeyId": "AKIAUSER", "SecretAccessKey": "secret"}`,
+			want:  awslib.Credentials{AccessKeyID: "AKIAUSER", SecretAccessKey: "secret", Source: awslib.KeySourceProvider},
+		},
+		{
+			name:        "expired",
+			input:       `{"Version": 1, "AccessKeyId": "ASIAOLD", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "2025-01-01T11:00:00Z"}`,
+			wantExpired: true, wantErrSubstr: "the credentials on stdin expired at 2025-01-01T11:00:00Z",
+		},
+		{name: "not JSON", input: "AKIAUSER secret", wantErrSubstr: "invalid credentials on stdin"},
+		{name: "no keys", input: `{"Version": 1}`, wantErrSubstr: "expected AccessKeyId and SecretAccessKey"},
+		{name: "unknown version", input: `{"Version": 2, "AccessKeyId": "AKIAUSER", "SecretAccessKey": "secret"}`, wantErrSubstr: "unsupported Version 2"},
+		{name: "bad expiration", input: `{"AccessKeyId": "ASIA", "SecretAccessKey": "secret", "Expiration": "tomorrow"}`, wantErrSubstr: `invalid Expiration "tomorrow"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseStdinCredentials([]byte(tc.input), now)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if errors.Is(err, awslib.ErrCredentialsExpired) != tc.wantExpired {
+					t.Fatalf("expired = %v, want %v", !tc.wantExpired, tc.wantExpired)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.AccessKeyID != tc.want.AccessKeyID || got.SessionToken != tc.want.SessionToken || !got.Expires.Equal(tc.want.Expires) || got.Source != tc.want.Source {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunWorkflowStdinCredentials(t *testing.T) {
+	t.Parallel()
+
+	// The mocked service signs with the key source, as the SDK service does.
+	fromKeySource := func(ctx context.Context) awslib.Credentials {
+		source, ok := awslib.KeySourceFromContext(ctx)
+		if !ok {
+			t.Fatal("expected the piped credentials to be used")
+		}
+		creds, _ := source(ctx)
+		return creds
+	}
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			if fromKeySource(ctx).AccessKeyID != "ASIAROLE" {
+				t.Fatal("expected the identity of the piped credentials")
+			}
+			return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Dev/me", Account: "123456789012"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return fromKeySource(ctx), nil
+		},
+	}
+	stdout := &bytes.Buffer{}
+	deps := runDeps{
+		awsService: service,
+		federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				if creds.AccessKeyID != "ASIAROLE" || creds.SessionToken != "token" {
+					t.Fatalf("expected the piped credentials to be federated, got %+v", creds)
+				}
+				return "https://example.com/console-login", nil
+			},
+		},
+		login: func(ctx context.Context, profile string) error { return errors.New("unexpected SSO login") },
+		term:  term.Info{StdoutTTY: true},
+		stdin: strings.NewReader(`{"Credentials": {"AccessKeyId": "ASIAROLE", "SecretAccessKey": "secret", "SessionToken": "token",
+			"Expiration": "` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}}`),
+		stdout:          stdout,
+		stderr:          &bytes.Buffer{},
+		now:             time.Now,
+		sessionDuration: sessionDuration,
+	}
+
+	if err := runWorkflow(context.Background(), workflowOptions{stdinCreds: true, print: true}, deps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "https://example.com/console-login") {
+		t.Fatalf("expected the sign-in URL to be printed, got:\n%s", stdout)
+	}
+}
+
+func TestRunWorkflowStdinCredentialsFromTerminal(t *testing.T) {
+	t.Parallel()
+
+	deps := runDeps{term: interactiveTerminal, stdin: strings.NewReader(""), stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, now: time.Now}
+	err := runWorkflow(context.Background(), workflowOptions{stdinCreds: true}, deps)
+	if err == nil || !strings.Contains(err.Error(), "pipe one in") {
+		t.Fatalf("expected a document to be required on stdin, got %v", err)
+	}
+}
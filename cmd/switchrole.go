@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+func newSwitchRoleCmd(deps runDeps) *cobra.Command {
+	var role awslib.SwitchRole
+	var open bool
+
+	switchRoleCmd := &cobra.Command{
+		Use:   "switch-role --account <id|alias> --role <name|arn>",
+		Short: "Print the console link for switching to a role",
+		Long: `Prints the console's switch-role link for users who are already signed in to
+the console and only need to assume another role there. No credentials are used.
+
+--role also accepts a full role ARN, in which case --account may be omitted. With
+neither, the role_arn of the selected assume-role profile is used, and the profile
+name becomes the default display name.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			if role.Color == "" {
+				role.Color = g.color
+			}
+			if role.Account == "" && role.RoleName == "" && g.profile != "" {
+				if err := switchRoleFromProfile(&role, g.profile, deps); err != nil {
+					return err
+				}
+			}
+
+			partition, err := resolveSwitchRole(&role, g.partition)
+			if err != nil {
+				return err
+			}
+
+			switchURL, err := awslib.SwitchRoleURL(partition, role)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(deps.stdout, switchURL)
+			if open {
+				return deps.open(switchURL, browserOptions{}.withSettings(deps))
+			}
+			return nil
+		},
+	}
+
+	switchRoleCmd.Flags().StringVar(&role.Account, "account", "", "Account ID or alias to switch to")
+	switchRoleCmd.Flags().StringVar(&role.RoleName, "role", "", "Role name (with any path) or role ARN")
+	switchRoleCmd.Flags().StringVar(&role.DisplayName, "name", "", "Display name shown in the console navigation bar")
+	switchRoleCmd.Flags().StringVar(&role.Color, "color", "", "Session color: red, orange, yellow, green, blue, or a hex value (defaults to the color setting)")
+	switchRoleCmd.Flags().BoolVar(&open, "open", false, "Also open the link in the default browser")
+
+	return switchRoleCmd
+}
+
+// switchRoleFromProfile fills role from the role_arn of an assume-role profile.
+func switchRoleFromProfile(role *awslib.SwitchRole, profile string, deps runDeps) error {
+	if deps.profiles == nil {
+		return i18n.Errorf("--role is required")
+	}
+	profiles, err := deps.profiles.ListProfiles()
+	if err != nil {
+		return i18n.Errorf("failed to list profiles: %w", err)
+	}
+	p, ok := profileByName(profiles)[profile]
+	if !ok || p.RoleARN == "" {
+		return i18n.Errorf("profile %q does not assume a role; pass --account and --role", profile)
+	}
+	role.RoleName = p.RoleARN
+	if role.DisplayName == "" {
+		role.DisplayName = p.Name
+	}
+	return nil
+}
+
+// resolveSwitchRole expands a role ARN given as --role and returns the partition the
+// link should point at: the ARN's, or partition for a role name, defaulting to aws.
+func resolveSwitchRole(role *awslib.SwitchRole, partition string) (string, error) {
+	if role.RoleName == "" {
+		return "", i18n.Errorf("--role is required")
+	}
+	if !strings.HasPrefix(role.RoleName, "arn:") {
+		if role.Account == "" {
+			return "", i18n.Errorf("--account is required unless --role is a role ARN")
+		}
+		if partition == "" {
+			partition = awslib.PartitionAWS
+		}
+		return partition, nil
+	}
+
+	partition, account, roleName, err := awslib.ParseRoleARN(role.RoleName)
+	if err != nil {
+		return "", err
+	}
+	if role.Account != "" && role.Account != account {
+		return "", i18n.Errorf("--account %s does not match the account in --role (%s)", role.Account, account)
+	}
+	role.Account = account
+	role.RoleName = roleName
+	return partition, nil
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestSwitchRoleCmd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		args          []string
+		want          string
+		wantOpened    bool
+		wantErrSubstr string
+	}{
+		{
+			name: "account and role",
+			args: []string{"switch-role", "--account", "999988887777", "--role", "Admin", "--color", "red", "--name", "prod"},
+			want: "https://signin.aws.amazon.com/switchrole?account=999988887777&color=F2B0A9&displayName=prod&roleName=Admin\n",
+		},
+		{
+			name: "role ARN",
+			args: []string{"switch-role", "--role", "arn:aws-us-gov:iam::999988887777:role/Admin"},
+			want: "https://signin.amazonaws-us-gov.com/switchrole?account=999988887777&roleName=Admin\n",
+		},
+		{
+			name: "partition for a role name",
+			args: []string{"switch-role", "--account", "999988887777", "--role", "Admin", "--partition", "aws-cn"},
+			want: "https://signin.amazonaws.cn/switchrole?account=999988887777&roleName=Admin\n",
+		},
+		{
+			name: "assume-role profile",
+			args: []string{"switch-role", "--profile", "prod-admin"},
+			want: "https://signin.aws.amazon.com/switchrole?account=210987654321&displayName=prod-admin&roleName=ops%2FAdmin\n",
+		},
+		{
+			name: "assume-role profile with display name",
+			args: []string{"switch-role", "--profile", "prod-admin", "--name", "prod"},
+			want: "https://signin.aws.amazon.com/switchrole?account=210987654321&displayName=prod&roleName=ops%2FAdmin\n",
+		},
+		{
+			name: "color from the profile",
+			args: []string{"switch-role", "--profile", "prod-red"},
+			want: "https://signin.aws.amazon.com/switchrole?account=210987654321&color=F2B0A9&displayName=prod-red&roleName=ops%2FAdmin\n",
+		},
+		{
+			name: "color flag overrides the profile",
+			args: []string{"switch-role", "--profile", "prod-red", "--color", "blue"},
+			want: "https://signin.aws.amazon.com/switchrole?account=210987654321&color=99BCE3&displayName=prod-red&roleName=ops%2FAdmin\n",
+		},
+		{
+			name:          "profile without a role",
+			args:          []string{"switch-role", "--profile", "dev"},
+			wantErrSubstr: `profile "dev" does not assume a role; pass --account and --role`,
+		},
+		{
+			name:       "open",
+			args:       []string{"switch-role", "--account", "999988887777", "--role", "Admin", "--open"},
+			want:       "https://signin.aws.amazon.com/switchrole?account=999988887777&roleName=Admin\n",
+			wantOpened: true,
+		},
+		{
+			name:          "missing role",
+			args:          []string{"switch-role", "--account", "999988887777"},
+			wantErrSubstr: "--role is required",
+		},
+		{
+			name:          "missing account",
+			args:          []string{"switch-role", "--role", "Admin"},
+			wantErrSubstr: "--account is required unless --role is a role ARN",
+		},
+		{
+			name:          "account mismatch",
+			args:          []string{"switch-role", "--account", "111122223333", "--role", "arn:aws:iam::999988887777:role/Admin"},
+			wantErrSubstr: "--account 111122223333 does not match the account in --role (999988887777)",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var opened string
+			deps := runDeps{
+				profiles: &mocks.ProfileLister{ListProfilesFunc: func() ([]awslib.Profile, error) {
+					return []awslib.Profile{
+						{Name: "dev", Source: awslib.ProfileSourceSSO},
+						{Name: "prod-admin", Source: awslib.ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::210987654321:role/ops/Admin"},
+						{Name: "prod-red", Source: awslib.ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::210987654321:role/ops/Admin", Color: "red"},
+					}, nil
+				}},
+				open: func(targetURL string, opts browserOptions) error {
+					opened = targetURL
+					return nil
+				},
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tc.want {
+				t.Fatalf("got %q, want %q", out, tc.want)
+			}
+			if got := opened != ""; got != tc.wantOpened {
+				t.Fatalf("expected opened %v, got %q", tc.wantOpened, opened)
+			}
+		})
+	}
+}
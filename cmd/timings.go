@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/config"
+	"github.com/eculver/aws-console/pkg/telemetry"
+)
+
+// telemetryTimeout bounds exporting the spans of a command, so a collector that is down
+// never holds up the shell.
+const telemetryTimeout = 5 * time.Second
+
+// timings records how long each step of opening the console took, for --timings and
+// the telemetry collector of the config file. A nil *timings records nothing, so
+// callers never need to check whether it is enabled.
+type timings struct {
+	now func() time.Time
+	// mu guards steps, which steps run concurrently, such as the checks of status,
+	// record at once.
+	mu    sync.Mutex
+	steps []timing
+	// silent leaves the steps out of report, when they are only exported.
+	silent   bool
+	exporter *telemetry.Exporter
+	reported bool
+	exported bool
+}
+
+type timing struct {
+	step     string
+	start    time.Time
+	duration time.Duration
+}
+
+func newTimings(now func() time.Time) *timings {
+	if now == nil {
+		now = time.Now
+	}
+	return &timings{now: now}
+}
+
+// fork returns timings with the same settings and no steps recorded, for another run
+// of the workflow; nil stays nil.
+func (t *timings) fork() *timings {
+	if t == nil {
+		return nil
+	}
+	return &timings{now: t.now, silent: t.silent, exporter: t.exporter}
+}
+
+// start begins timing step and returns a function that records it when called.
+func (t *timings) start(step string) func() {
+	if t == nil {
+		return func() {}
+	}
+	begin := t.now()
+	return func() {
+		t.record(timing{step: step, start: begin, duration: t.now().Sub(begin)})
+	}
+}
+
+// record adds a step that has ended.
+func (t *timings) record(s timing) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.steps = append(t.steps, s)
+}
+
+// recorded returns the steps recorded so far.
+func (t *timings) recorded() []timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]timing(nil), t.steps...)
+}
+
+// report prints the recorded steps and their total once; later calls do nothing.
+func (t *timings) report(w io.Writer) {
+	if t == nil || t.silent || t.reported {
+		return
+	}
+	steps := t.recorded()
+	if len(steps) == 0 {
+		return
+	}
+	t.reported = true
+
+	var total time.Duration
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Timings:")
+	for _, s := range steps {
+		total += s.duration
+		fmt.Fprintf(tw, "  %s\t%s\n", s.step, formatTiming(s.duration))
+	}
+	fmt.Fprintf(tw, "  total\t%s\n", formatTiming(total))
+	tw.Flush()
+}
+
+// export sends the recorded steps to the telemetry collector once, as spans of a trace
+// of opening the console that failed with err, if it is not nil. Only the class of err
+// is sent, since its message may name the profile, role, or account. The collector
+// being unreachable is only reported with --verbose: it never fails the command.
+func (t *timings) export(ctx context.Context, err error, deps runDeps) {
+	if t == nil || t.exporter == nil || t.exported {
+		return
+	}
+	steps := t.recorded()
+	if len(steps) == 0 {
+		return
+	}
+	t.exported = true
+
+	root := telemetry.Span{Name: "open-console", Start: steps[0].start, End: t.now()}
+	if err != nil {
+		root.Error = awslib.ClassifyError(err).String()
+	}
+	children := make([]telemetry.Span, 0, len(steps))
+	for _, s := range steps {
+		root.Start = minTime(root.Start, s.start)
+		children = append(children, telemetry.Span{Name: s.step, Start: s.start, End: s.start.Add(s.duration)})
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), telemetryTimeout)
+	defer cancel()
+	if err := t.exporter.Export(ctx, root, children); err != nil {
+		verbosef(deps, "Failed to export telemetry: %v", err)
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// newTelemetryExporter returns an exporter to the collector of the config file, or nil
+// when none is configured. Header values are expanded from the environment, as the
+// shortener's are. Spans describe the steps and the platform, never the profile or
+// account.
+func newTelemetryExporter(settings config.Telemetry, transport *http.Transport, goos string) *telemetry.Exporter {
+	if settings.Endpoint == "" {
+		return nil
+	}
+	headers := make(map[string]string, len(settings.Headers))
+	for k, v := range settings.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+	e := &telemetry.Exporter{
+		Endpoint: settings.Endpoint,
+		Headers:  headers,
+		Resource: map[string]string{
+			"service.name":    "aws-console",
+			"service.version": Version,
+			"os.type":         goos,
+		},
+	}
+	if transport != nil {
+		e.HTTP = &http.Client{Transport: transport}
+	}
+	return e
+}
+
+func formatTiming(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
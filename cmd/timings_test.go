@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/config"
+)
+
+func TestTimingsReport(t *testing.T) {
+	t.Parallel()
+
+	tm := newTimings(steppingClock(25 * time.Millisecond))
+	tm.start("sts")()
+	tm.start("federation")()
+
+	out := &bytes.Buffer{}
+	tm.report(out)
+	tm.report(out)
+
+	want := "Timings:\n  sts         25ms\n  federation  25ms\n  total       50ms\n"
+	if out.String() != want {
+		t.Fatalf("unexpected report:\n%q\nwant:\n%q", out.String(), want)
+	}
+}
+
+func TestTimingsNil(t *testing.T) {
+	t.Parallel()
+
+	var tm *timings
+	tm.start("sts")()
+	out := &bytes.Buffer{}
+	tm.report(out)
+	if out.Len() != 0 {
+		t.Fatalf("expected no output, got %q", out.String())
+	}
+}
+
+func TestTimingsConcurrent(t *testing.T) {
+	t.Parallel()
+
+	tm := newTimings(time.Now)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tm.start("sts")()
+		}()
+	}
+	wg.Wait()
+
+	if got := len(tm.recorded()); got != 8 {
+		t.Fatalf("expected 8 steps, got %d", got)
+	}
+}
+
+func TestTimingsExport(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		status     int
+		wantLogged string
+	}{
+		{name: "exported", status: http.StatusOK},
+		{name: "collector fails", status: http.StatusServiceUnavailable, wantLogged: "Failed to export telemetry: failed to send spans: the collector answered 503 Service Unavailable"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests int
+			var names, statuses []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				var body struct {
+					ResourceSpans []struct {
+						ScopeSpans []struct {
+							Spans []struct {
+								Name   string `json:"name"`
+								Status struct {
+									Message string `json:"message"`
+								} `json:"status"`
+							} `json:"spans"`
+						} `json:"scopeSpans"`
+					} `json:"resourceSpans"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				for _, s := range body.ResourceSpans[0].ScopeSpans[0].Spans {
+					names = append(names, s.Name)
+					statuses = append(statuses, s.Status.Message)
+				}
+				w.WriteHeader(tc.status)
+			}))
+			t.Cleanup(server.Close)
+
+			tm := newTimings(steppingClock(25 * time.Millisecond))
+			tm.silent = true
+			tm.exporter = newTelemetryExporter(config.Telemetry{Endpoint: server.URL}, nil, "linux")
+			tm.start("sts")()
+			tm.start("federation")()
+
+			stderr := &bytes.Buffer{}
+			deps := runDeps{stderr: stderr, verbose: true}
+			tm.report(stderr)
+			denied := fmt.Errorf("failed to assume arn:aws:iam::123456789012:role/Admin: %w", &smithy.GenericAPIError{Code: "AccessDenied"})
+			tm.export(context.Background(), denied, deps)
+			tm.export(context.Background(), denied, deps)
+
+			if requests != 1 || strings.Join(names, ",") != "open-console,sts,federation" {
+				t.Fatalf("expected one trace of the steps, got %d requests with spans %v", requests, names)
+			}
+			if statuses[0] != "access-denied" {
+				t.Fatalf("expected the failure to be sent as its class, got %q", statuses[0])
+			}
+			if strings.Contains(stderr.String(), "Timings:") {
+				t.Fatalf("expected no timings report without --timings, got %q", stderr.String())
+			}
+			if tc.wantLogged != "" && !strings.Contains(stderr.String(), tc.wantLogged) {
+				t.Fatalf("expected %q to be logged, got %q", tc.wantLogged, stderr.String())
+			}
+			if tc.wantLogged == "" && stderr.Len() != 0 {
+				t.Fatalf("expected no output, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestNewTelemetryExporter(t *testing.T) {
+	t.Setenv("OTEL_TEST_TOKEN", "secret")
+
+	if e := newTelemetryExporter(config.Telemetry{}, nil, "linux"); e != nil {
+		t.Fatalf("expected no exporter without an endpoint, got %+v", e)
+	}
+	e := newTelemetryExporter(config.Telemetry{
+		Endpoint: "https://otel.example.com:4318",
+		Headers:  map[string]string{"Authorization": "Bearer ${OTEL_TEST_TOKEN}"},
+	}, nil, "darwin")
+	if e.Headers["Authorization"] != "Bearer secret" {
+		t.Fatalf("expected the header to be expanded, got %v", e.Headers)
+	}
+	if e.Resource["service.name"] != "aws-console" || e.Resource["os.type"] != "darwin" {
+		t.Fatalf("unexpected resource %v", e.Resource)
+	}
+}
+
+func TestRunWorkflowTimings(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		identityErr error
+		wantSteps   []string
+		wantErr     bool
+	}{
+		{
+			name:      "success",
+			wantSteps: []string{"sts", "credentials", "session-token", "federation", "browser", "total"},
+		},
+		{
+			name:        "sso login fails",
+			identityErr: errors.New("expired"),
+			wantSteps:   []string{"sts", "sso-login", "total"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stderr := &bytes.Buffer{}
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:iam::123456789012:user/test"}, tc.identityErr
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}, nil
+					},
+					GetSessionTokenFunc: func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						return "https://example.com/console-login", nil
+					},
+				},
+				login:           func(ctx context.Context, profile string) error { return errors.New("login failed") },
+				open:            func(targetURL string, opts browserOptions) error { return nil },
+				timings:         newTimings(steppingClock(time.Millisecond)),
+				term:            interactiveTerminal,
+				stdout:          &bytes.Buffer{},
+				stderr:          stderr,
+				sessionDuration: sessionDuration,
+			}
+
+			err := runWorkflow(context.Background(), workflowOptions{profile: "dev"}, deps)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+
+			i := strings.Index(stderr.String(), "Timings:")
+			if i < 0 {
+				t.Fatalf("expected a timings report, got:\n%s", stderr.String())
+			}
+			report := stderr.String()[i:]
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(report), "\n")[1:] {
+				got = append(got, strings.Fields(line)[0])
+			}
+			if strings.Join(got, ",") != strings.Join(tc.wantSteps, ",") {
+				t.Fatalf("expected steps %v, got %v in:\n%s", tc.wantSteps, got, report)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/tui"
+	"github.com/spf13/cobra"
+)
+
+func newTuiCmd(deps runDeps, runner workflowRunner) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Browse profiles and their credentials in an interactive dashboard",
+		Long: `Shows the configured profiles with the status and expiry of their credentials.
+Press enter to open the console of the selected profile, c to copy a sign-in URL
+for it, r to refresh its SSO login, and q to quit.
+
+With --accessible, the profiles are listed as plain lines instead, and a profile and
+what to do with it are chosen by number or search, for screen readers.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !deps.term.Interactive() {
+				return i18n.Errorf("aws-console tui needs an interactive terminal")
+			}
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+
+			profiles, err := deps.profiles.ListProfiles()
+			if err != nil {
+				return i18n.Errorf("failed to list profiles: %w", err)
+			}
+			if deps.term.Accessible {
+				return tui.RunPlain(ctx, profiles, tuiActions(deps, runner), deps.picker, deps.stdout)
+			}
+			return tui.Run(ctx, profiles, tuiActions(deps, runner), deps.stdin, deps.stdout)
+		},
+	}
+}
+
+// tuiActions opens consoles through runner like 'aws-console <profile>' does. Checks run
+// while the dashboard is drawn, so they are kept quiet.
+func tuiActions(deps runDeps, runner workflowRunner) tui.Actions {
+	quiet := deps
+	quiet.stderr, quiet.verbose, quiet.debug = io.Discard, false, false
+
+	return tui.Actions{
+		Status: func(ctx context.Context, p awslib.Profile) tui.Status {
+			if reason := ssoLoginReason(p.Name, quiet); reason != "" {
+				return tui.Status{Err: errors.New(reason)}
+			}
+			status := checkProfile(ctx, p, quiet)
+			return tui.Status{Account: status.account, Role: status.role, Expires: status.expires, Err: status.err}
+		},
+		Open: func(ctx context.Context, profile string) error {
+			return runner(ctx, workflowOptions{profile: profile}, deps)
+		},
+		Copy: func(ctx context.Context, profile string) error {
+			return runner(ctx, workflowOptions{profile: profile, copy: true}, deps)
+		},
+		Login: func(ctx context.Context, profile string) error {
+			if _, ok := ssoProfile(profile, deps); !ok {
+				return i18n.Errorf("profile %q does not use IAM Identity Center (SSO)", profile)
+			}
+			return deps.login(ctx, profile)
+		},
+	}
+}
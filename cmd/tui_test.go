@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestTuiCmdRequiresTerminal(t *testing.T) {
+	t.Parallel()
+
+	_, err := executeSubcommand(t, runDeps{}, "tui")
+	if err == nil || !strings.Contains(err.Error(), "needs an interactive terminal") {
+		t.Fatalf("tui error = %v, want an interactive terminal error", err)
+	}
+}
+
+func TestTuiActions(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	var runs []workflowOptions
+	var logins []string
+	deps := runDeps{
+		awsService: &mocks.Service{
+			GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+				if profile == "keys" {
+					return awslib.Identity{}, errors.New("expired token")
+				}
+				return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/AdministratorAccess/me", Account: "123456789012"}, nil
+			},
+			RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+				return awslib.Credentials{AccessKeyID: "ASIA", Expires: expires}, nil
+			},
+		},
+		profiles: &mocks.ProfileLister{
+			ListProfilesFunc: func() ([]awslib.Profile, error) { return testProfiles(), nil },
+		},
+		login: func(ctx context.Context, profile string) error {
+			logins = append(logins, profile)
+			return nil
+		},
+	}
+	actions := tuiActions(deps, func(ctx context.Context, opts workflowOptions, deps runDeps) error {
+		runs = append(runs, opts)
+		return nil
+	})
+	ctx := context.Background()
+
+	status := actions.Status(ctx, testProfiles()[0])
+	if status.Err != nil || status.Account != "123456789012" || status.Role != "AdministratorAccess" || !status.Expires.Equal(expires) {
+		t.Fatalf("Status(dev) = %+v", status)
+	}
+	if status := actions.Status(ctx, testProfiles()[1]); status.Err == nil || status.Err.Error() != "expired token" {
+		t.Fatalf("Status(keys) = %+v, want the expired token error", status)
+	}
+
+	if err := actions.Open(ctx, "dev"); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := actions.Copy(ctx, "dev"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].profile != "dev" || runs[0].copy || runs[1].profile != "dev" || !runs[1].copy {
+		t.Fatalf("workflow runs = %+v, want an open and a copy of dev", runs)
+	}
+
+	if err := actions.Login(ctx, "dev"); err != nil {
+		t.Fatalf("Login(dev) error = %v", err)
+	}
+	err := actions.Login(ctx, "keys")
+	if err == nil || !strings.Contains(err.Error(), `profile "keys" does not use IAM Identity Center`) {
+		t.Fatalf("Login(keys) error = %v, want a non-SSO error", err)
+	}
+	if len(logins) != 1 || logins[0] != "dev" {
+		t.Fatalf("logins = %v, want [dev]", logins)
+	}
+}
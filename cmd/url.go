@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func newURLCmd(deps runDeps) *cobra.Command {
+	var dest, service string
+	var expiresIn time.Duration
+	var assumeRole awslib.AssumeRoleInput
+	var shorten bool
+
+	urlCmd := &cobra.Command{
+		Use:   "url [profile]",
+		Short: "Print a sign-in URL to share, with a limited console session",
+		Long: `Prints a console sign-in URL for the profile and reports how long it stays valid.
+The URL can be used for 15 minutes, and the console session it starts lasts
+--expires-in, at least 15 minutes. It is refused when the profile's credentials
+expire before that session would end.
+
+Fresh credentials and a fresh sign-in token are requested every time, so the
+reported window is exact. With --output csv or json the URL and its validity are
+printed as one record.
+
+--shorten prints a short, single-use link from the shortener in the config file
+instead, for chat tools that break long URLs. The shortener can sign in with the
+URL until the link is used, so only use one you trust with the credentials.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProfileArg(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if err := setPositionalProfile(cmd, args[0], deps); err != nil {
+					return err
+				}
+			}
+			if err := validateAssumeRole(assumeRole); err != nil {
+				return err
+			}
+
+			g, err := resolveWorkflowGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			if dest == "" && service == "" {
+				dest = g.destination
+			}
+			path, err := rootDestination(dest, service, g.partition, g.region)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+
+			opts := workflowOptions{profile: g.profile, destination: path, assumeRole: assumeRole, noCache: true, shorten: shorten}
+			return runURL(ctx, opts, expiresIn, g.output, deps)
+		},
+	}
+
+	urlCmd.Flags().DurationVar(&expiresIn, "expires-in", awslib.MinSessionDuration, "How long the console session lasts once signed in, from 15m to 12h")
+	urlCmd.Flags().StringVarP(&dest, "destination", "d", "", "Console page to open: a service (ec2, s3, cw, cloudshell, quicksight), a page such as dashboard:<name>, a console path, or a console URL")
+	urlCmd.Flags().StringVar(&service, "service", "", "Console service to open, e.g. ec2 or cloudwatch (same as --destination)")
+	urlCmd.Flags().BoolVar(&shorten, "shorten", false, "Print a short single-use link from the shortener in the config file instead of the sign-in URL")
+	addAssumeRoleFlags(urlCmd, &assumeRole)
+	return urlCmd
+}
+
+// runURL prints a sign-in URL whose console session lasts expiresIn, after checking
+// that the credentials behind it outlive the session.
+func runURL(ctx context.Context, opts workflowOptions, expiresIn time.Duration, format output.Format, deps runDeps) error {
+	if expiresIn < awslib.MinSessionDuration {
+		return i18n.Errorf("invalid --expires-in %s: console sessions last at least %s", expiresIn, awslib.MinSessionDuration)
+	}
+	deps.sessionDuration = int32(expiresIn / time.Second)
+	deps.durationSet = true
+	// Only the URL goes to stdout.
+	deps.printOnly = true
+
+	identity, err := authenticate(ctx, opts.profile, deps)
+	if err != nil {
+		return err
+	}
+	creds, err := federationCredentials(ctx, opts.profile, &identity, opts, deps)
+	if err != nil {
+		return err
+	}
+
+	now := deps.now()
+	sessionEnds := now.Add(expiresIn)
+	if !creds.ValidAt(sessionEnds) {
+		return i18n.Errorf("credentials for %s expire at %s, %s from now, before a %s console session would end; use a shorter --expires-in",
+			describeProfile(opts.profile), formatTimestamp(creds.Expires), creds.Expires.Sub(now).Truncate(time.Second), expiresIn)
+	}
+
+	copts := consoleOptions(opts, deps)
+	copts.Identity, copts.Credentials = &identity, &creds
+	consoleURL, err := consoleClient(deps).OpenConsole(awslib.WithLogger(ctx, logger(deps)), copts)
+	if err != nil {
+		return err
+	}
+	waitAudit := startAudit(ctx, opts.profile, identity, consoleURL.SessionDuration, opts, deps)
+	defer waitAudit()
+
+	linkExpires := now.Add(awslib.SigninTokenTTL)
+	link := consoleURL.String()
+	if opts.shorten {
+		links, err := shortenURLs(ctx, []string{link}, deps)
+		if err != nil {
+			return err
+		}
+		link = links[0]
+	}
+	if format != output.FormatTable {
+		err := output.Render(deps.stdout, format, output.Table{
+			Columns: []output.Column{
+				{Header: "URL", Key: "url"},
+				{Header: "LINK EXPIRES", Key: "link_expires"},
+				{Header: "SESSION DURATION", Key: "session_duration"},
+				{Header: "CREDENTIALS EXPIRE", Key: "credentials_expire"},
+			},
+			Rows: [][]string{{link, formatTimestamp(linkExpires), consoleURL.SessionDuration.String(), formatTimestamp(creds.Expires)}},
+		})
+		if err == nil {
+			recordHistory(opts.profile, identity, "", opts.destination, deps)
+		}
+		return err
+	}
+
+	fmt.Fprintln(deps.stdout, link)
+	recordHistory(opts.profile, identity, "", opts.destination, deps)
+	deps.messages.Fprintf(deps.stderr, "The link can be used until %s (%s).\n", formatTimestamp(linkExpires), awslib.SigninTokenTTL)
+	deps.messages.Fprintf(deps.stderr, "The console session lasts %s once signed in.\n", consoleURL.SessionDuration)
+	if !creds.ValidAt(linkExpires.Add(consoleURL.SessionDuration)) {
+		deps.messages.Fprintf(deps.stderr, "Its credentials expire at %s, so sign in by %s for the full session.\n",
+			formatTimestamp(creds.Expires), formatTimestamp(creds.Expires.Add(-consoleURL.SessionDuration)))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestURLCmd(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		args          []string
+		expires       time.Time
+		wantDuration  int32
+		wantDest      string
+		wantOut       string
+		wantStderr    []string
+		wantErrSubstr string
+	}{
+		{
+			name:         "default session",
+			args:         []string{"url", "dev"},
+			expires:      now.Add(time.Hour),
+			wantDuration: 900,
+			wantOut:      "https://signin.example.com/login\n",
+			wantStderr: []string{
+				"The link can be used until 2025-06-01T12:15:00Z (15m0s).",
+				"The console session lasts 15m0s once signed in.",
+			},
+		},
+		{
+			name:         "credentials expire within the link window",
+			args:         []string{"url", "--profile", "dev", "--expires-in", "45m", "-d", "s3"},
+			expires:      now.Add(50 * time.Minute),
+			wantDuration: 2700,
+			wantDest:     "s3/home",
+			wantOut:      "https://signin.example.com/login\n",
+			wantStderr:   []string{"Its credentials expire at 2025-06-01T12:50:00Z, so sign in by 2025-06-01T12:05:00Z for the full session."},
+		},
+		{
+			name:         "json",
+			args:         []string{"url", "dev", "--expires-in", "30m", "-o", "json"},
+			expires:      now.Add(time.Hour),
+			wantDuration: 1800,
+			wantOut:      `"link_expires": "2025-06-01T12:15:00Z"`,
+		},
+		{
+			name:          "credentials expire before the session ends",
+			args:          []string{"url", "dev", "--expires-in", "2h"},
+			expires:       now.Add(time.Hour),
+			wantErrSubstr: `credentials for profile "dev" expire at 2025-06-01T13:00:00Z, 1h0m0s from now, before a 2h0m0s console session would end`,
+		},
+		{
+			name:          "shorter than the console allows",
+			args:          []string{"url", "dev", "--expires-in", "5m"},
+			wantErrSubstr: "invalid --expires-in 5m0s: console sessions last at least 15m0s",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var gotDuration int32
+			var gotDest string
+			stderr := &bytes.Buffer{}
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/AdministratorAccess/me", Account: "123456789012"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: tc.expires}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						gotDuration, gotDest = durationSeconds, destination
+						return "https://signin.example.com/login", nil
+					},
+				},
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) { return testProfiles(), nil },
+				},
+				now:    func() time.Time { return now },
+				stderr: stderr,
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(out, tc.wantOut) {
+				t.Fatalf("expected output to contain %q, got:\n%s", tc.wantOut, out)
+			}
+			if gotDuration != tc.wantDuration || gotDest != tc.wantDest {
+				t.Fatalf("requested a %ds session for %q, want %ds for %q", gotDuration, gotDest, tc.wantDuration, tc.wantDest)
+			}
+			for _, want := range tc.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Fatalf("expected stderr to contain %q, got:\n%s", want, stderr.String())
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,51 @@
 package cmd
 
+import (
+	"github.com/eculver/aws-console/pkg/buildinfo"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
 // Version is injected at build time via -ldflags.
 // Defaults to a local development value when not overridden.
 var Version = "dev"
+
+// Build metadata injected at build time via -ldflags, alongside Version. Commit and
+// BuildDate fall back to the version control information Go records in the binary.
+var (
+	Commit    = ""
+	BuildDate = ""
+	// Channel is the release channel self-updates are taken from.
+	Channel = "dev"
+)
+
+func newVersionCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, commit, build date, and platform",
+		Long: `Prints the version of aws-console along with the commit and date it was built
+from, the Go version, the platform, and its release channel.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+
+			info := buildinfo.Read(Version, Commit, BuildDate, Channel)
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "VERSION", Key: "version"},
+					{Header: "COMMIT", Key: "commit"},
+					{Header: "BUILT", Key: "built"},
+					{Header: "GO", Key: "go"},
+					{Header: "PLATFORM", Key: "platform"},
+					{Header: "CHANNEL", Key: "channel"},
+				},
+				Rows: [][]string{{info.Version, info.Commit, info.Date, info.GoVersion, info.Platform, info.Channel}},
+			}
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+}
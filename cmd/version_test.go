@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"testing"
+)
+
+func TestVersionCmd(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+	deps := runDeps{stdout: stdout, stderr: &bytes.Buffer{}}
+	root := newRootCmd(deps, nil)
+	root.SetArgs([]string{"version", "-o", "json"})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout, err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected one row, got %v", got)
+	}
+	want := map[string]string{
+		"go":       runtime.Version(),
+		"platform": runtime.GOOS + "/" + runtime.GOARCH,
+		"channel":  Channel,
+	}
+	for key, value := range want {
+		if got[0][key] != value {
+			t.Fatalf("expected %s %q, got %q", key, value, got[0][key])
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// keepAliveLead is how long before a console session expires --keep-alive opens it again.
+const keepAliveLead = 5 * time.Minute
+
+// expiryWarningLead is how long before a waited-on console session expires the user is
+// notified.
+const expiryWarningLead = 10 * time.Minute
+
+// waitIfRequested blocks until the console session opened by the workflow expires and
+// then runs the expiry hook, when the workflow was asked to wait.
+func waitIfRequested(ctx context.Context, opts workflowOptions, deps runDeps) error {
+	if !opts.wait {
+		return nil
+	}
+
+	status := statusWriter(deps)
+	duration := time.Duration(deps.sessionDuration) * time.Second
+	expires := deps.now().Add(duration)
+	deps.messages.Fprintf(status, "Waiting for the console session to expire at %s (Ctrl-C to stop)...\n", expires.Format(time.RFC3339))
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	notifications := &backgroundNotifier{deps: deps}
+	defer notifications.wait()
+	// With notifications on, the wait is split to warn shortly before the session ends.
+	if deps.notifier != nil && duration > expiryWarningLead {
+		if err := deps.sleep(ctx, duration-expiryWarningLead); err != nil {
+			return i18n.Errorf("stopped waiting before the console session expired: %w", err)
+		}
+		notifications.notify(fmt.Sprintf("The console session of %s expires in %d minutes.", describeProfile(opts.profile), int(expiryWarningLead.Minutes())))
+		duration = expiryWarningLead
+	}
+	if err := deps.sleep(ctx, duration); err != nil {
+		return i18n.Errorf("stopped waiting before the console session expired: %w", err)
+	}
+	deps.messages.Fprintln(status, "Console session expired.")
+	notifications.notify(fmt.Sprintf("The console session of %s has expired.", describeProfile(opts.profile)))
+
+	if opts.onExpiry == "" {
+		return nil
+	}
+	name, args := shellCommand(deps.goos, opts.onExpiry)
+	if err := deps.executor.Run(name, args, deps.stdin, deps.stdout, deps.stderr); err != nil {
+		return i18n.Errorf("expiry hook failed: %w", err)
+	}
+	return nil
+}
+
+// keepAlive opens the console, then opens it again with a fresh sign-in URL shortly
+// before each session expires, until interrupted. Reopening shows the new session the
+// same way as the first: in the browser, printed, or copied.
+func keepAlive(ctx context.Context, opts workflowOptions, deps runDeps) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	notifications := &backgroundNotifier{deps: deps}
+	defer notifications.wait()
+
+	var expires time.Time
+	next := opts
+	next.keepAlive = false
+	next.opened = func(t time.Time) { expires = t }
+	if err := runWorkflow(ctx, next, deps); err != nil {
+		return err
+	}
+	// Credentials about to expire would open a session that ends right away.
+	next.minValidity = awslib.MinSessionDuration
+
+	status := statusWriter(deps)
+	for {
+		reopen := expires.Add(-keepAliveLead)
+		deps.messages.Fprintf(status, "Keeping the console session alive; it is reopened at %s (Ctrl-C to stop)...\n", formatTimestamp(reopen))
+		if err := deps.sleep(ctx, max(reopen.Sub(deps.now()), 0)); err != nil {
+			deps.messages.Fprintln(status, "Stopped keeping the console session alive.")
+			return nil
+		}
+
+		deps.messages.Fprintf(status, "The console session expires at %s; opening a new one...\n", formatTimestamp(expires))
+		if err := runWorkflow(ctx, next, deps); err != nil {
+			if ctx.Err() != nil {
+				deps.messages.Fprintln(status, "Stopped keeping the console session alive.")
+				return nil
+			}
+			notifications.notify(fmt.Sprintf("Failed to reopen the console for %s.", describeProfile(opts.profile)))
+			return i18n.Errorf("failed to reopen the console: %w", err)
+		}
+		notifications.notify(fmt.Sprintf("Reopened the console for %s, valid until %s.", describeProfile(opts.profile), expires.Local().Format(time.Kitchen)))
+		if expires.Sub(deps.now()) <= keepAliveLead {
+			return i18n.Errorf("the new console session of %s expires at %s, too soon to keep it alive", describeProfile(opts.profile), formatTimestamp(expires))
+		}
+	}
+}
+
+// backgroundNotifier shows desktop notifications, when they are enabled, without
+// holding up the command: notification commands can take seconds, as on Windows. They
+// are shown one at a time, in the order they were sent, warning on stderr about those
+// that cannot be.
+type backgroundNotifier struct {
+	deps runDeps
+	// last is closed once the notification sent last has been shown.
+	last chan struct{}
+}
+
+func (n *backgroundNotifier) notify(message string) {
+	if n.deps.notifier == nil {
+		return
+	}
+	prev, done := n.last, make(chan struct{})
+	n.last = done
+	go func() {
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		if err := n.deps.notifier.Notify("aws-console", message); err != nil {
+			n.deps.messages.Fprintf(n.deps.stderr, "Warning: failed to show a notification: %v\n", err)
+		}
+	}()
+}
+
+// wait blocks until the notifications sent so far have been shown.
+func (n *backgroundNotifier) wait() {
+	if n.last != nil {
+		<-n.last
+	}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// shellCommand runs command through the platform shell.
+func shellCommand(goos, command string) (string, []string) {
+	if goos == "windows" {
+		return "cmd", []string{"/C", command}
+	}
+	return "sh", []string{"-c", command}
+}
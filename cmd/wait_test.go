@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestWaitIfRequested(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		opts          workflowOptions
+		goos          string
+		sleepErr      error
+		hookErr       error
+		notify        bool
+		wantSlept     time.Duration
+		wantHook      []string
+		wantOutput    []string
+		wantNotified  []string
+		wantErrSubstr string
+	}{
+		{
+			name: "not requested",
+		},
+		{
+			name:       "waits for the session duration",
+			opts:       workflowOptions{wait: true},
+			wantSlept:  time.Hour,
+			wantOutput: []string{"Waiting for the console session to expire at 2026-01-02T04:00:00Z", "Console session expired."},
+		},
+		{
+			name:      "notifies before and at expiry",
+			opts:      workflowOptions{wait: true, profile: "dev"},
+			notify:    true,
+			wantSlept: time.Hour,
+			wantNotified: []string{
+				`The console session of profile "dev" expires in 10 minutes.`,
+				`The console session of profile "dev" has expired.`,
+			},
+		},
+		{
+			name:      "runs hook",
+			opts:      workflowOptions{wait: true, onExpiry: "notify-send done"},
+			goos:      "linux",
+			wantSlept: time.Hour,
+			wantHook:  []string{"sh", "-c", "notify-send done"},
+		},
+		{
+			name:      "runs hook with windows shell",
+			opts:      workflowOptions{wait: true, onExpiry: "echo done"},
+			goos:      "windows",
+			wantSlept: time.Hour,
+			wantHook:  []string{"cmd", "/C", "echo done"},
+		},
+		{
+			name:          "hook fails",
+			opts:          workflowOptions{wait: true, onExpiry: "false"},
+			goos:          "linux",
+			hookErr:       errors.New("exit status 1"),
+			wantSlept:     time.Hour,
+			wantHook:      []string{"sh", "-c", "false"},
+			wantErrSubstr: "expiry hook failed: exit status 1",
+		},
+		{
+			name:          "interrupted",
+			opts:          workflowOptions{wait: true, onExpiry: "echo done"},
+			sleepErr:      context.Canceled,
+			wantSlept:     time.Hour,
+			wantErrSubstr: "stopped waiting before the console session expired",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var slept time.Duration
+			stdout := &bytes.Buffer{}
+			executor := &fakeExecutor{runErr: tc.hookErr}
+			notifier := &fakeNotifier{}
+			deps := runDeps{
+				now: func() time.Time { return time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC) },
+				sleep: func(ctx context.Context, d time.Duration) error {
+					slept += d
+					return tc.sleepErr
+				},
+				executor:        executor,
+				goos:            tc.goos,
+				term:            interactiveTerminal,
+				stdout:          stdout,
+				stderr:          &bytes.Buffer{},
+				sessionDuration: 3600,
+			}
+			if tc.notify {
+				deps.notifier = notifier
+			}
+
+			err := waitIfRequested(context.Background(), tc.opts, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if slept != tc.wantSlept {
+				t.Fatalf("expected to sleep %s, slept %s", tc.wantSlept, slept)
+			}
+			for _, want := range tc.wantOutput {
+				if !strings.Contains(stdout.String(), want) {
+					t.Fatalf("expected output to contain %q, got %q", want, stdout.String())
+				}
+			}
+			if strings.Join(notifier.messages, "|") != strings.Join(tc.wantNotified, "|") {
+				t.Fatalf("expected notifications %q, got %q", tc.wantNotified, notifier.messages)
+			}
+
+			if tc.wantHook == nil {
+				if len(executor.calls) != 0 {
+					t.Fatalf("expected no hook to run, got %+v", executor.calls)
+				}
+				return
+			}
+			if len(executor.calls) != 1 {
+				t.Fatalf("expected hook to run once, got %+v", executor.calls)
+			}
+			call := executor.calls[0]
+			if got := append([]string{call.name}, call.args...); strings.Join(got, "|") != strings.Join(tc.wantHook, "|") {
+				t.Fatalf("unexpected hook command %v, want %v", got, tc.wantHook)
+			}
+		})
+	}
+}
+
+// blockingNotifier shows each notification once it is released.
+type blockingNotifier struct {
+	release  chan struct{}
+	messages []string
+}
+
+func (n *blockingNotifier) Notify(title, message string) error {
+	<-n.release
+	n.messages = append(n.messages, message)
+	if message == "fails" {
+		return errors.New("notify-send failed")
+	}
+	return nil
+}
+
+func TestBackgroundNotifier(t *testing.T) {
+	t.Parallel()
+
+	notifier := &blockingNotifier{release: make(chan struct{})}
+	stderr := &bytes.Buffer{}
+	notifications := &backgroundNotifier{deps: runDeps{notifier: notifier, stderr: stderr}}
+
+	// Sending returns while the notification command is still running.
+	for _, message := range []string{"first", "fails", "last"} {
+		notifications.notify(message)
+	}
+	close(notifier.release)
+	notifications.wait()
+
+	if got := strings.Join(notifier.messages, ","); got != "first,fails,last" {
+		t.Fatalf("expected the notifications in order, got %q", got)
+	}
+	if got := stderr.String(); got != "Warning: failed to show a notification: notify-send failed\n" {
+		t.Fatalf("unexpected warnings %q", got)
+	}
+
+	// Without a notifier nothing is sent, and waiting returns at once.
+	disabled := &backgroundNotifier{deps: runDeps{stderr: stderr}}
+	disabled.notify("ignored")
+	disabled.wait()
+}
+
+func TestSleepContext(t *testing.T) {
+	t.Parallel()
+
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWorkflowFlagsOptions(t *testing.T) {
+	t.Parallel()
+
+	opts, err := workflowFlags{onExpiry: "echo done"}.options("dev", "health/home")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.wait || opts.onExpiry != "echo done" || opts.profile != "dev" || opts.destination != "health/home" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	if _, err := (workflowFlags{keepAlive: true, onExpiry: "echo done"}).options("dev", ""); err == nil || !strings.Contains(err.Error(), "--keep-alive cannot be combined with --wait") {
+		t.Fatalf("expected a conflict with --on-expiry, got %v", err)
+	}
+	if _, err := (workflowFlags{keepAlive: true, dryRun: true}).options("dev", ""); err == nil || !strings.Contains(err.Error(), "--keep-alive cannot be combined with --dry-run") {
+		t.Fatalf("expected a conflict with --dry-run, got %v", err)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name string
+		// expires returns when credentials retrieved at now expire.
+		expires       func(now time.Time) time.Time
+		buildErrAfter int
+		wantOpened    int
+		wantSlept     []time.Duration
+		wantNotified  []string
+		wantErrSubstr string
+	}{
+		{
+			name:         "reopens until interrupted",
+			expires:      func(now time.Time) time.Time { return now.Add(time.Hour) },
+			wantOpened:   3,
+			wantSlept:    []time.Duration{55 * time.Minute, 55 * time.Minute, 55 * time.Minute},
+			wantNotified: []string{`Reopened the console for profile "dev", valid until`, `Reopened the console for profile "dev", valid until`},
+		},
+		{
+			name:          "reopening fails",
+			expires:       func(now time.Time) time.Time { return now.Add(time.Hour) },
+			buildErrAfter: 1,
+			wantOpened:    1,
+			wantSlept:     []time.Duration{55 * time.Minute},
+			wantNotified:  []string{`Failed to reopen the console for profile "dev".`},
+			wantErrSubstr: "failed to reopen the console: failed to build console URL: federation unavailable",
+		},
+		{
+			name:          "credentials cannot outlast the session",
+			expires:       func(time.Time) time.Time { return start.Add(8 * time.Minute) },
+			wantOpened:    2,
+			wantSlept:     []time.Duration{3 * time.Minute},
+			wantNotified:  []string{`Reopened the console for profile "dev"`},
+			wantErrSubstr: "too soon to keep it alive",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := start
+			var slept []time.Duration
+			notifier := &fakeNotifier{}
+			builds, opened := 0, 0
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", Account: "123456789012"}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: tc.expires(now)}, nil
+					},
+				},
+				federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						builds++
+						if tc.buildErrAfter > 0 && builds > tc.buildErrAfter {
+							return "", errors.New("federation unavailable")
+						}
+						return "https://signin.aws.amazon.com/federation?Action=login", nil
+					},
+				},
+				open: func(targetURL string, opts browserOptions) error {
+					opened++
+					return nil
+				},
+				notifier: notifier,
+				now:      func() time.Time { return now },
+				sleep: func(ctx context.Context, d time.Duration) error {
+					slept = append(slept, d)
+					if len(slept) > 2 {
+						return context.Canceled
+					}
+					now = now.Add(d)
+					return nil
+				},
+				term:            interactiveTerminal,
+				stdout:          &bytes.Buffer{},
+				stderr:          &bytes.Buffer{},
+				sessionDuration: 43200,
+			}
+
+			err := runWorkflow(context.Background(), workflowOptions{profile: "dev", keepAlive: true}, deps)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if opened != tc.wantOpened {
+				t.Fatalf("expected the console to open %d times, got %d", tc.wantOpened, opened)
+			}
+			if len(slept) != len(tc.wantSlept) {
+				t.Fatalf("expected sleeps %v, got %v", tc.wantSlept, slept)
+			}
+			for i := range slept {
+				if slept[i] != tc.wantSlept[i] {
+					t.Fatalf("expected sleeps %v, got %v", tc.wantSlept, slept)
+				}
+			}
+			notified := notifier.messages
+			if len(notified) != len(tc.wantNotified) {
+				t.Fatalf("expected notifications %q, got %q", tc.wantNotified, notified)
+			}
+			for i, want := range tc.wantNotified {
+				if !strings.HasPrefix(notified[i], want) {
+					t.Fatalf("expected notifications %q, got %q", tc.wantNotified, notified)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/console"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func newWarmCmd(deps runDeps) *cobra.Command {
+	var workers int
+
+	warmCmd := &cobra.Command{
+		Use:   "warm [profile|@group...]",
+		Short: "Cache credentials and sign-in tokens so opening the console is instant",
+		Long: `Requests temporary credentials and a console sign-in token for each profile given,
+or for the profiles listed under warm in the aws-console config file, and caches
+them, so that opening the console for those profiles soon after calls neither STS
+nor the federation endpoint. Profiles are prepared concurrently.
+
+Warming never signs in or prompts: profiles whose SSO login has expired or that
+need an MFA code are reported as failed. Sign-in tokens are reused for up to 15
+minutes, and credentials until shortly before they expire.`,
+		ValidArgsFunction: completeProfiles(deps),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+
+			profiles, err := warmProfiles(args, deps)
+			if err != nil {
+				return err
+			}
+			return runWarm(ctx, profiles, workers, g.output, deps)
+		},
+	}
+
+	warmCmd.Flags().IntVar(&workers, "concurrency", console.DefaultWarmWorkers, "How many profiles to prepare at once")
+	return warmCmd
+}
+
+// errNoWarmProfiles is returned by warmProfiles when there are no profiles to warm.
+var errNoWarmProfiles = errors.New("no profiles to warm: give them as arguments or list them under warm in the aws-console config file")
+
+// warmProfiles returns the profiles to warm: those given, or those listed in the config
+// file, with aliases resolved and groups expanded.
+func warmProfiles(args []string, deps runDeps) ([]string, error) {
+	file, err := loadConfigFile(deps)
+	if err != nil {
+		return nil, err
+	}
+	names := args
+	if len(names) == 0 {
+		names = file.Warm
+	}
+	if len(names) == 0 {
+		return nil, errNoWarmProfiles
+	}
+
+	var profiles []string
+	for _, name := range names {
+		expanded, err := expandProfiles(file, name, deps)
+		if err != nil {
+			return nil, err
+		}
+		for _, profile := range expanded {
+			if !slices.Contains(profiles, profile) {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	return profiles, nil
+}
+
+func runWarm(ctx context.Context, profiles []string, workers int, format output.Format, deps runDeps) error {
+	if workers < 1 {
+		return i18n.Errorf("invalid --concurrency %d: must be at least 1", workers)
+	}
+
+	results := warmResults(ctx, profiles, workers, deps)
+
+	table := output.Table{
+		Columns: []output.Column{
+			{Header: "PROFILE", Key: "profile"},
+			{Header: "ACCOUNT", Key: "account"},
+			{Header: "CREDENTIALS", Key: "credentials"},
+			{Header: "EXPIRY", Key: "expiry"},
+			{Header: "STATUS", Key: "status"},
+			{Header: "ERROR", Key: "error"},
+		},
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			table.Rows = append(table.Rows, []string{r.Profile, "", "", "", "error", r.Err.Error()})
+			continue
+		}
+		credentials := "refreshed"
+		if r.Cached {
+			credentials = "cached"
+		}
+		table.Rows = append(table.Rows, []string{r.Profile, r.URL.Identity.Account, credentials, formatTimestamp(r.URL.Credentials.Expires), "ok", ""})
+	}
+
+	if err := output.Render(deps.stdout, format, table); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return i18n.Errorf("failed to warm %d of %d profiles", failed, len(results))
+	}
+	return nil
+}
+
+// warmResults prepares console sessions for profiles, up to workers at once, and
+// returns a result for each in order. Profiles that need an SSO login are not signed in.
+func warmResults(ctx context.Context, profiles []string, workers int, deps runDeps) []console.WarmResult {
+	var cache console.CredentialCache
+	if deps.credentials != nil {
+		cache = deps.credentials
+		ctx = awslib.WithSigninTokenScope(awslib.WithSigninTokenCache(ctx, deps.credentials), signinTokenScope(workflowOptions{}, deps))
+	}
+
+	// Profiles that need an SSO login fail up front; the rest are warmed together.
+	results := make([]console.WarmResult, len(profiles))
+	var opts []console.Options
+	var pending []int
+	for i, profile := range profiles {
+		if reason := ssoLoginReason(profile, deps); reason != "" {
+			results[i] = console.WarmResult{Profile: profile, Err: awslib.MarkError(i18n.Errorf("%s; run 'aws-console %s' to sign in", reason, profile), awslib.ErrSSOLoginRequired)}
+			continue
+		}
+		opts = append(opts, consoleOptions(workflowOptions{profile: profile}, deps))
+		pending = append(pending, i)
+	}
+	client := &console.Client{Service: deps.awsService, Federation: deps.federation}
+	for j, r := range client.Warm(ctx, opts, workers, cache) {
+		results[pending[j]] = r
+	}
+	return results
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+	"github.com/eculver/aws-console/pkg/credcache"
+)
+
+func TestWarmCmd(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/me", Account: "123456789012", Partition: "aws"}, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			return awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: expires}, nil
+		},
+	}
+	federation := &mocks.FederationBuilder{
+		BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+			return "https://signin.aws.amazon.com/federation?SigninToken=token", nil
+		},
+	}
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configFile, []byte("aliases:\n  d: dev\nwarm: [d]\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	deps := runDeps{
+		awsService:  service,
+		federation:  federation,
+		credentials: credcache.NewCacheAt(filepath.Join(dir, "cache")),
+		configFile:  configFile,
+		profiles: &mocks.ProfileLister{
+			ListProfilesFunc: func() ([]awslib.Profile, error) { return testProfiles(), nil },
+		},
+		sessionDuration: sessionDuration,
+	}
+
+	out, err := executeSubcommand(t, deps, "warm", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "profile,account,credentials,expiry,status,error\ndev,123456789012,refreshed," + expires.Format(time.RFC3339) + ",ok,\n"
+	if out != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, out)
+	}
+	if _, _, ok := deps.credentials.Credentials("dev", ""); !ok {
+		t.Fatal("expected the credentials to be cached")
+	}
+
+	// A second run finds the credentials cached and requests only a sign-in token.
+	retrieved := service.RetrieveCredentialsCalls.Load()
+	out, err = executeSubcommand(t, deps, "warm", "dev", "d", "-o", "csv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "dev,123456789012,cached,") || strings.Count(out, "\n") != 2 {
+		t.Fatalf("expected dev once with cached credentials, got:\n%s", out)
+	}
+	if service.RetrieveCredentialsCalls.Load() != retrieved {
+		t.Fatal("expected cached credentials not to be retrieved again")
+	}
+}
+
+func TestWarmCmdErrors(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "")
+
+	testCases := []struct {
+		name          string
+		args          []string
+		ssoToken      *ssocache.Token
+		wantOut       string
+		wantErrSubstr string
+	}{
+		{
+			name:          "nothing to warm",
+			args:          []string{"warm"},
+			wantErrSubstr: "no profiles to warm",
+		},
+		{
+			name:          "unknown profile",
+			args:          []string{"warm", "nope"},
+			wantErrSubstr: `profile "nope" not found in AWS config`,
+		},
+		{
+			name:          "invalid concurrency",
+			args:          []string{"warm", "dev", "--concurrency", "0"},
+			wantErrSubstr: "invalid --concurrency 0: must be at least 1",
+		},
+		{
+			name:          "expired SSO login",
+			args:          []string{"warm", "dev", "-o", "csv"},
+			ssoToken:      &ssocache.Token{AccessToken: "token", ExpiresAt: time.Now().Add(-time.Hour)},
+			wantOut:       `dev,,,,error,"The SSO token for profile ""dev"" expired at`,
+			wantErrSubstr: "failed to warm 1 of 1 profiles",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			profiles := testProfiles()
+			profiles[0].SSOSession = "my-sso"
+			deps := runDeps{
+				awsService: &mocks.Service{},
+				now:        time.Now,
+				profiles: &mocks.ProfileLister{
+					ListProfilesFunc: func() ([]awslib.Profile, error) { return profiles, nil },
+				},
+			}
+			if tc.ssoToken != nil {
+				deps.ssoTokens = ssocache.NewCacheAt(t.TempDir())
+				if err := deps.ssoTokens.Put("my-sso", *tc.ssoToken); err != nil {
+					t.Fatalf("failed to cache token: %v", err)
+				}
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+			if !strings.Contains(out, tc.wantOut) {
+				t.Fatalf("expected output to contain %q, got:\n%s", tc.wantOut, out)
+			}
+		})
+	}
+}
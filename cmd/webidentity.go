@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/arn"
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/spf13/cobra"
+)
+
+// webIdentityTokenFileEnv names the token file of the role in AWS_ROLE_ARN, as EKS and
+// the AWS SDKs set it.
+const webIdentityTokenFileEnv = "AWS_WEB_IDENTITY_TOKEN_FILE"
+
+// webIdentityOptions describe a role to federate as, assumed with an OIDC token from a
+// file or a command, such as a CI job's ID token.
+type webIdentityOptions struct {
+	roleARN   string
+	tokenFile string
+	command   string
+}
+
+func addWebIdentityFlags(cmd *cobra.Command, o *webIdentityOptions) {
+	cmd.Flags().StringVar(&o.roleARN, "web-identity-role-arn", "", "Open the console as this role, assumed with an OIDC token such as a CI job's")
+	cmd.Flags().StringVar(&o.tokenFile, "web-identity-token-file", "", "File holding the OIDC token for --web-identity-role-arn (defaults to "+webIdentityTokenFileEnv+")")
+	cmd.Flags().StringVar(&o.command, "web-identity-command", "", "Shell command that prints the OIDC token for --web-identity-role-arn, or a JSON document with it under value")
+}
+
+func (o webIdentityOptions) validate() error {
+	if o.roleARN == "" {
+		if o.tokenFile != "" || o.command != "" {
+			return i18n.Errorf("--web-identity-token-file and --web-identity-command require --web-identity-role-arn")
+		}
+		return nil
+	}
+	a, err := arn.Parse(o.roleARN)
+	if _, isRole := a.Role(); err != nil || !isRole {
+		return i18n.Errorf("invalid --web-identity-role-arn %q: expected a role ARN", o.roleARN)
+	}
+	if o.tokenFile != "" && o.command != "" {
+		return i18n.Errorf("--web-identity-token-file and --web-identity-command cannot be combined")
+	}
+	return nil
+}
+
+// tokenSource is where the OIDC token comes from: the command, the token file, or the
+// file named by AWS_WEB_IDENTITY_TOKEN_FILE.
+func (o webIdentityOptions) tokenSource(deps runDeps) (awslib.TokenSource, error) {
+	if o.command != "" {
+		return awslib.TokenCommand(func(ctx context.Context) ([]byte, error) {
+			var out bytes.Buffer
+			name, args := shellCommand(deps.goos, o.command)
+			err := deps.executor.RunContext(ctx, name, args, nil, nil, &out, deps.stderr)
+			return out.Bytes(), err
+		}), nil
+	}
+	path := o.tokenFile
+	if path == "" {
+		path = os.Getenv(webIdentityTokenFileEnv)
+	}
+	if path == "" {
+		return nil, i18n.Errorf("--web-identity-role-arn needs an OIDC token: pass --web-identity-token-file or --web-identity-command, or set %s", webIdentityTokenFileEnv)
+	}
+	return awslib.TokenFile(path), nil
+}
+
+// withWebIdentityCredentials assumes the role of o with sts:AssumeRoleWithWebIdentity
+// and returns a context whose AWS calls sign with the role's credentials instead of
+// the profile's.
+func withWebIdentityCredentials(ctx context.Context, o webIdentityOptions, deps runDeps) (context.Context, error) {
+	source, err := o.tokenSource(deps)
+	if err != nil {
+		return ctx, err
+	}
+	token, err := source(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	input := awslib.WebIdentityRoleInput{
+		RoleARN:     o.roleARN,
+		SessionName: roleSessionName("", deps),
+		Token:       token,
+	}
+	if deps.durationSet {
+		input.DurationSeconds = deps.sessionDuration
+	}
+	verbosef(deps, "Assuming role %s with the web identity token", o.roleARN)
+	done := deps.timings.start("sts")
+	creds, err := deps.awsService.AssumeRoleWithWebIdentity(ctx, input)
+	done()
+	if err != nil {
+		return ctx, i18n.Errorf("failed to assume role %s with the web identity token: %w", o.roleARN, err)
+	}
+	return awslib.WithKeySource(ctx, func(context.Context) (awslib.Credentials, error) {
+		return creds, nil
+	}), nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+	"github.com/eculver/aws-console/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+func newWhoamiCmd(deps runDeps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "whoami",
+		Short: "Print the caller identity of the resolved profile",
+		Long: `Prints the caller identity (ARN, account ID, and user ID) of the resolved
+profile along with the provider that supplied its credentials and when they
+expire. No SSO login is attempted; use -o json for machine-readable output.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := resolveGlobals(cmd, deps)
+			if err != nil {
+				return err
+			}
+			ctx, deps := g.apply(context.Background(), deps)
+
+			identity, err := deps.awsService.GetCallerIdentity(ctx, g.profile)
+			if err != nil {
+				return i18n.Errorf("failed to get caller identity for %s: %w", describeProfile(g.profile), err)
+			}
+			creds, err := deps.awsService.RetrieveCredentials(ctx, g.profile)
+			if err != nil {
+				return i18n.Errorf("failed to retrieve credentials: %w", err)
+			}
+
+			table := output.Table{
+				Columns: []output.Column{
+					{Header: "PROFILE", Key: "profile"},
+					{Header: "ARN", Key: "arn"},
+					{Header: "ACCOUNT", Key: "account"},
+					{Header: "USER ID", Key: "user_id"},
+					{Header: "SOURCE", Key: "source"},
+					{Header: "EXPIRY", Key: "expiry"},
+				},
+				Rows: [][]string{{g.profile, identity.Arn, identity.Account, identity.UserID, creds.Source, formatTimestamp(creds.Expires)}},
+			}
+			return output.Render(deps.stdout, g.output, table)
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestWhoamiCmd(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		args          []string
+		identityErr   error
+		wantContains  []string
+		wantErrSubstr string
+	}{
+		{
+			name: "csv",
+			args: []string{"whoami", "-p", "dev", "-o", "csv"},
+			wantContains: []string{
+				"profile,arn,account,user_id,source,expiry",
+				"dev,arn:aws:sts::123456789012:assumed-role/AdministratorAccess/me,123456789012,AROAEXAMPLE:me,SSOProvider,2030-01-02T03:04:05Z",
+			},
+		},
+		{
+			name:         "json",
+			args:         []string{"whoami", "-p", "dev", "-o", "json"},
+			wantContains: []string{`"user_id": "AROAEXAMPLE:me"`, `"source": "SSOProvider"`},
+		},
+		{
+			name:          "invalid credentials",
+			args:          []string{"whoami", "-p", "dev"},
+			identityErr:   errors.New("expired token"),
+			wantErrSubstr: `failed to get caller identity for profile "dev": expired token`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deps := runDeps{
+				awsService: &mocks.Service{
+					GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+						if tc.identityErr != nil {
+							return awslib.Identity{}, tc.identityErr
+						}
+						return awslib.Identity{
+							Arn:     "arn:aws:sts::123456789012:assumed-role/AdministratorAccess/me",
+							Account: "123456789012",
+							UserID:  "AROAEXAMPLE:me",
+						}, nil
+					},
+					RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+						return awslib.Credentials{AccessKeyID: "ASIA", Expires: expires, Source: "SSOProvider"}, nil
+					},
+				},
+			}
+
+			out, err := executeSubcommand(t, deps, tc.args...)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
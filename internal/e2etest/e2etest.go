@@ -0,0 +1,237 @@
+// Package e2etest runs the compiled aws-console binary end to end against fakes: a
+// FakeAWS server for STS, the federation endpoint, and IAM Identity Center, reached
+// through endpoint overrides, and stand-ins for the aws CLI and the browser on PATH,
+// which record how they were run. Nothing outside the test's temporary directory is
+// read or written, and nothing leaves the machine.
+package e2etest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runTimeout bounds a single run of the binary, so a hung workflow fails the test.
+const runTimeout = 30 * time.Second
+
+// Build compiles the aws-console binary into dir and returns its path.
+func Build(dir string) (string, error) {
+	binary := filepath.Join(dir, "aws-console")
+	cmd := exec.Command("go", "build", "-o", binary, "github.com/eculver/aws-console")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to build aws-console: %w\n%s", err, out)
+	}
+	return binary, nil
+}
+
+// Harness runs the binary in a home directory of its own, with the AWS config, the
+// fakes, and PATH set up for it.
+type Harness struct {
+	// AWS is the fake every AWS endpoint points at.
+	AWS *FakeAWS
+	// Home is the home directory of the runs, which holds the AWS config and every
+	// cache and state directory.
+	Home string
+
+	t       *testing.T
+	binary  string
+	binDir  string
+	awsLog  string
+	openLog string
+}
+
+// New returns a harness for binary, as built by Build, with an empty AWS config.
+func New(t *testing.T, binary string) *Harness {
+	t.Helper()
+	dir := t.TempDir()
+	h := &Harness{
+		t:       t,
+		binary:  binary,
+		Home:    filepath.Join(dir, "home"),
+		binDir:  filepath.Join(dir, "bin"),
+		awsLog:  filepath.Join(dir, "aws.log"),
+		openLog: filepath.Join(dir, "browser.log"),
+	}
+	loginMarker := filepath.Join(dir, "sso-login")
+	h.AWS = newFakeAWS(t, loginMarker)
+
+	for _, d := range []string{h.binDir, filepath.Join(h.Home, ".aws")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", d, err)
+		}
+	}
+	// The aws CLI records its arguments, and 'aws sso login' lets FakeAWS accept the
+	// keys it expired with ExpireUntilLogin.
+	h.writeScript("aws", fmt.Sprintf(`printf '%%s\n' "$*" >> %s
+if [ "$1 $2" = "sso login" ]; then
+	: > %s
+fi
+`, shellQuote(h.awsLog), shellQuote(loginMarker)))
+	h.writeScript("browser", fmt.Sprintf(`printf '%%s\n' "$*" >> %s
+`, shellQuote(h.openLog)))
+	h.WriteConfig("", "")
+	return h
+}
+
+// writeScript writes an executable shell script named name to the harness's PATH.
+func (h *Harness) writeScript(name, body string) {
+	h.t.Helper()
+	if err := os.WriteFile(filepath.Join(h.binDir, name), []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		h.t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+// WriteConfig replaces the AWS config and credentials files.
+func (h *Harness) WriteConfig(config, credentials string) {
+	h.t.Helper()
+	files := map[string]string{"config": config, "credentials": credentials}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(h.Home, ".aws", name), []byte(contents), 0o600); err != nil {
+			h.t.Fatalf("failed to write the AWS %s file: %v", name, err)
+		}
+	}
+}
+
+// Browser returns the path of the fake browser, for --browser command templates.
+func (h *Harness) Browser() string {
+	return filepath.Join(h.binDir, "browser")
+}
+
+// Result is the outcome of a run of the binary.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Run runs the binary with args, with its output piped and no terminal attached, as
+// in a script.
+func (h *Harness) Run(args ...string) Result {
+	h.t.Helper()
+	var stdout bytes.Buffer
+	return h.run(args, &stdout, func() string { return stdout.String() })
+}
+
+// RunInTerminal runs the binary with args and its stdout attached to a terminal, so it
+// opens the console in a browser rather than printing the sign-in URL. Stdin is still
+// not a terminal, so nothing is asked. Terminals are only emulated on Linux; elsewhere
+// the test is skipped.
+func (h *Harness) RunInTerminal(args ...string) Result {
+	h.t.Helper()
+	terminal, tty, err := openTerminal()
+	if err != nil {
+		h.t.Skipf("cannot emulate a terminal: %v", err)
+	}
+	defer terminal.Close()
+
+	var output bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		// Reading ends with an error once every copy of tty is closed.
+		io.Copy(&output, terminal)
+		close(done)
+	}()
+	return h.run(args, tty, func() string {
+		tty.Close()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			h.t.Errorf("the output of aws-console %s did not end", strings.Join(args, " "))
+		}
+		return strings.ReplaceAll(output.String(), "\r\n", "\n")
+	})
+}
+
+// run runs the binary with args and its stdout attached to stdout, then returns what
+// output returns as the result's Stdout.
+func (h *Harness) run(args []string, stdout io.Writer, output func() string) Result {
+	h.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.binary, args...)
+	cmd.Env = h.env()
+	cmd.Dir = h.Home
+	cmd.Stdout, cmd.Stderr = stdout, &stderr
+	err := cmd.Run()
+
+	result := Result{Stdout: output(), Stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		h.t.Fatalf("failed to run aws-console %s: %v", strings.Join(args, " "), err)
+	}
+	return result
+}
+
+// env is the whole environment of a run: nothing is inherited but PATH, so the
+// settings of the machine running the tests do not leak in.
+func (h *Harness) env() []string {
+	return []string{
+		"PATH=" + h.binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"HOME=" + h.Home,
+		"AWS_CONFIG_FILE=" + filepath.Join(h.Home, ".aws", "config"),
+		"AWS_SHARED_CREDENTIALS_FILE=" + filepath.Join(h.Home, ".aws", "credentials"),
+		"AWS_REGION=us-east-1",
+		"AWS_EC2_METADATA_DISABLED=true",
+		// STS, IAM, and IAM Identity Center all go to the fake.
+		"AWS_ENDPOINT_URL=" + h.AWS.URL,
+		"AWS_CONSOLE_FEDERATION_ENDPOINT=" + h.AWS.FederationURL(),
+		// BROWSER is also taken as a display being available, so the console is opened.
+		"BROWSER=" + h.Browser(),
+		// Output stays free of colors and hyperlinks on a terminal.
+		"TERM=dumb",
+	}
+}
+
+// AWSCalls returns the arguments of each run of the fake aws CLI, in order.
+func (h *Harness) AWSCalls() []string {
+	return readLines(h.t, h.awsLog)
+}
+
+// OpenedURLs waits for the fake browser to have been run n times and returns the
+// arguments of each run. The browser is started without waiting for it, so it may
+// still be running when the binary exits.
+func (h *Harness) OpenedURLs(n int) []string {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lines := readLines(h.t, h.openLog)
+		if len(lines) >= n || time.Now().After(deadline) {
+			return lines
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// readLines returns the lines of path, or none when it does not exist.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
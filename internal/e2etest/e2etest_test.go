@@ -0,0 +1,251 @@
+package e2etest
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	// The binary is built by a go build of its own, so the test cache would not see
+	// changes to the CLI without this import.
+	_ "github.com/eculver/aws-console/cmd"
+)
+
+// binary is the aws-console binary built by TestMain.
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "aws-console-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binary, err = Build(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+const (
+	testConfig = `[profile dev]
+sso_session = corp
+sso_account_id = 123456789012
+sso_role_name = AdministratorAccess
+aws_console_fallback = breakglass
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+
+[profile breakglass]
+region = us-east-1
+
+[profile keys]
+region = us-east-1
+
+[profile stale]
+region = us-east-1
+`
+	testCredentials = `[breakglass]
+aws_access_key_id = ASIABREAKGLASS
+aws_secret_access_key = breakglass-secret
+aws_session_token = breakglass-token
+
+[keys]
+aws_access_key_id = ASIAKEYS
+aws_secret_access_key = keys-secret
+aws_session_token = keys-token
+
+[stale]
+aws_access_key_id = ASIASTALE
+aws_secret_access_key = stale-secret
+aws_session_token = stale-token
+`
+)
+
+// newTestHarness returns a harness with the profiles of testConfig and the identities
+// of their keys.
+func newTestHarness(t *testing.T) *Harness {
+	t.Helper()
+	h := New(t, binary)
+	h.WriteConfig(testConfig, testCredentials)
+	h.AWS.AddIdentity("ASIAKEYS", "arn:aws:sts::123456789012:assumed-role/Developer/alice")
+	h.AWS.AddIdentity("ASIABREAKGLASS", "arn:aws:sts::123456789012:assumed-role/BreakGlass/alice")
+	return h
+}
+
+func TestWorkflow(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		args     []string
+		terminal bool
+		setup    func(h *Harness)
+		// wantCode is the exit code; wantStderr is part of stderr.
+		wantCode   int
+		wantStderr string
+		// wantSessions are the access key IDs sign-in tokens are issued for.
+		wantSessions []string
+		// wantOpened are the destinations opened in the browser; wantPrinted those printed.
+		wantOpened  []string
+		wantPrinted []string
+		wantAWS     []string
+		// wantActions are the STS and IAM calls, each followed by the key that signed it.
+		wantActions []string
+	}{
+		{
+			name:         "opens the console",
+			args:         []string{"-p", "keys"},
+			terminal:     true,
+			wantSessions: []string{"ASIAKEYS"},
+			wantOpened:   []string{"https://us-east-1.console.aws.amazon.com/"},
+			wantActions:  []string{"GetCallerIdentity ASIAKEYS", "ListAccountAliases ASIAKEYS"},
+		},
+		{
+			name:         "prints when piped",
+			args:         []string{"-p", "keys"},
+			wantSessions: []string{"ASIAKEYS"},
+			wantPrinted:  []string{"https://us-east-1.console.aws.amazon.com/"},
+		},
+		{
+			name:         "print",
+			args:         []string{"-p", "keys", "--print", "-d", "s3"},
+			terminal:     true,
+			wantSessions: []string{"ASIAKEYS"},
+			wantPrinted:  []string{"https://us-east-1.console.aws.amazon.com/s3/home"},
+		},
+		{
+			name:         "regions",
+			args:         []string{"-p", "keys", "--regions", "us-east-1,eu-west-1"},
+			terminal:     true,
+			wantSessions: []string{"ASIAKEYS"},
+			wantOpened:   []string{"https://us-east-1.console.aws.amazon.com/?region=us-east-1", "https://eu-west-1.console.aws.amazon.com/?region=eu-west-1"},
+		},
+		{
+			name:         "SSO login",
+			args:         []string{"-p", "keys"},
+			terminal:     true,
+			setup:        func(h *Harness) { h.AWS.ExpireUntilLogin("ASIAKEYS") },
+			wantStderr:   "Credentials are not valid, attempting SSO login...",
+			wantSessions: []string{"ASIAKEYS"},
+			wantOpened:   []string{"https://us-east-1.console.aws.amazon.com/"},
+			wantAWS:      []string{"sso login --profile keys"},
+			wantActions:  []string{"GetCallerIdentity ASIAKEYS", "GetCallerIdentity ASIAKEYS", "ListAccountAliases ASIAKEYS"},
+		},
+		{
+			name:         "Identity Center fallback",
+			args:         []string{"-p", "dev", "--fallback"},
+			terminal:     true,
+			wantStderr:   "Signed in with the fallback profile breakglass instead of dev",
+			wantSessions: []string{"ASIABREAKGLASS"},
+			wantOpened:   []string{"https://us-east-1.console.aws.amazon.com/"},
+		},
+		{
+			name:       "fallback needs confirmation",
+			args:       []string{"-p", "dev"},
+			wantCode:   3,
+			wantStderr: "pass --fallback to use it",
+		},
+		{
+			name:       "no fallback",
+			args:       []string{"-p", "dev", "--yes", "--no-fallback"},
+			wantCode:   3,
+			wantStderr: "SSO login failed",
+		},
+		{
+			name:       "rejected keys",
+			args:       []string{"-p", "stale"},
+			wantCode:   4,
+			wantStderr: "credentials still invalid after SSO login",
+			wantAWS:    []string{"sso login --profile stale"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := newTestHarness(t)
+			if tc.setup != nil {
+				tc.setup(h)
+			}
+			run := h.Run
+			if tc.terminal {
+				run = h.RunInTerminal
+			}
+			result := run(tc.args...)
+
+			if result.ExitCode != tc.wantCode || !strings.Contains(result.Stderr, tc.wantStderr) {
+				t.Fatalf("exit code %d, want %d, with stderr containing %q; stderr:\n%s", result.ExitCode, tc.wantCode, tc.wantStderr, result.Stderr)
+			}
+			if got := h.AWS.SigninSessions(); !slices.Equal(got, tc.wantSessions) {
+				t.Fatalf("sign-in tokens were issued for %q, want %q", got, tc.wantSessions)
+			}
+			if got := h.AWSCalls(); !slices.Equal(got, tc.wantAWS) {
+				t.Fatalf("the aws CLI ran with %q, want %q", got, tc.wantAWS)
+			}
+			if got := h.AWS.Actions(); tc.wantActions != nil && !slices.Equal(got, tc.wantActions) {
+				t.Fatalf("STS and IAM were called with %q, want %q", got, tc.wantActions)
+			}
+
+			// Browsers are started without waiting for them, so they may log in any order.
+			opened := destinations(t, h, h.OpenedURLs(len(tc.wantOpened)))
+			slices.Sort(opened)
+			wantOpened := slices.Sorted(slices.Values(tc.wantOpened))
+			if !slices.Equal(opened, wantOpened) {
+				t.Fatalf("the browser opened %q, want %q", opened, wantOpened)
+			}
+			var printed []string
+			for _, line := range strings.Split(result.Stdout, "\n") {
+				if strings.HasPrefix(line, "http") {
+					printed = append(printed, line)
+				}
+			}
+			if got := destinations(t, h, printed); !slices.Equal(got, tc.wantPrinted) {
+				t.Fatalf("printed %q, want %q; stdout:\n%s", got, tc.wantPrinted, result.Stdout)
+			}
+		})
+	}
+}
+
+func TestBrowserCommand(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness(t)
+	result := h.RunInTerminal("-p", "keys", "--browser", h.Browser()+" --new-window {url}")
+	if result.ExitCode != 0 {
+		t.Fatalf("exit code %d; stderr:\n%s", result.ExitCode, result.Stderr)
+	}
+	opened := h.OpenedURLs(1)
+	if len(opened) != 1 || !strings.HasPrefix(opened[0], "--new-window "+h.AWS.FederationURL()+"?Action=login&") {
+		t.Fatalf("the browser ran with %q, want --new-window and the sign-in URL", opened)
+	}
+}
+
+// destinations returns the console destinations of sign-in URLs, checking that each
+// signs in through the fake's federation endpoint.
+func destinations(t *testing.T, h *Harness, signinURLs []string) []string {
+	t.Helper()
+	var dests []string
+	for _, raw := range signinURLs {
+		u, err := url.Parse(raw)
+		if err != nil || !strings.HasPrefix(raw, h.AWS.FederationURL()+"?") {
+			t.Fatalf("%q is not a sign-in URL of the fake federation endpoint", raw)
+		}
+		q := u.Query()
+		if q.Get("Action") != "login" || !strings.HasPrefix(q.Get("SigninToken"), "signin-token-") {
+			t.Fatalf("%q does not sign in with a token of the fake", raw)
+		}
+		dests = append(dests, q.Get("Destination"))
+	}
+	return dests
+}
@@ -0,0 +1,183 @@
+package e2etest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// credentialPattern extracts the access key ID from a SigV4 Authorization header.
+var credentialPattern = regexp.MustCompile(`Credential=([^/]+)/`)
+
+// FakeAWS stands in for every AWS endpoint the CLI calls, all of which are pointed at it
+// with endpoint overrides:
+//
+//   - STS answers GetCallerIdentity for the access keys given to AddIdentity, and
+//     rejects other keys as invalid.
+//   - The federation endpoint, at /federation, issues a sign-in token for any session.
+//   - IAM Identity Center answers every call with a server error, as when it is down.
+//
+// Every other call is refused as access denied, which the CLI tolerates for optional
+// lookups such as account aliases.
+type FakeAWS struct {
+	*httptest.Server
+
+	// loginMarker is the file the fake aws CLI writes on 'aws sso login'.
+	loginMarker string
+
+	mu         sync.Mutex
+	identities map[string]string
+	expired    map[string]bool
+	actions    []string
+	sessions   []string
+}
+
+func newFakeAWS(t *testing.T, loginMarker string) *FakeAWS {
+	t.Helper()
+	f := &FakeAWS{loginMarker: loginMarker, identities: map[string]string{}, expired: map[string]bool{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// FederationURL is the federation endpoint of the fake.
+func (f *FakeAWS) FederationURL() string {
+	return f.URL + "/federation"
+}
+
+// AddIdentity makes STS answer GetCallerIdentity for accessKeyID with arn.
+func (f *FakeAWS) AddIdentity(accessKeyID, arn string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.identities[accessKeyID] = arn
+}
+
+// ExpireUntilLogin makes STS reject accessKeyID as expired until 'aws sso login' runs.
+func (f *FakeAWS) ExpireUntilLogin(accessKeyID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expired[accessKeyID] = true
+}
+
+// Actions returns the STS and IAM actions called so far, in order, each followed by the access
+// key ID that signed it, such as "GetCallerIdentity ASIAEXAMPLE".
+func (f *FakeAWS) Actions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.actions...)
+}
+
+// SigninSessions returns the access key IDs of the sessions sign-in tokens were
+// issued for, in order.
+func (f *FakeAWS) SigninSessions() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sessions...)
+}
+
+func (f *FakeAWS) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/federation":
+		f.serveFederation(w, r)
+	case r.Header.Get("X-Amz-Target") != "":
+		// JSON protocol services, such as Organizations.
+		writeJSONError(w, http.StatusBadRequest, "AccessDeniedException", "not available in the fake")
+	case r.Method == http.MethodPost && r.URL.Path == "/":
+		f.serveQuery(w, r)
+	default:
+		// IAM Identity Center's OIDC and portal endpoints are REST-JSON APIs under paths
+		// such as /client/register.
+		writeJSONError(w, http.StatusServiceUnavailable, "ServiceUnavailableException", "IAM Identity Center is unavailable")
+	}
+}
+
+func (f *FakeAWS) serveFederation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("Action") != "getSigninToken" {
+		http.Error(w, "unsupported action", http.StatusBadRequest)
+		return
+	}
+	var session struct {
+		SessionID string `json:"sessionId"`
+	}
+	if err := json.Unmarshal([]byte(q.Get("Session")), &session); err != nil || session.SessionID == "" {
+		http.Error(w, "invalid session", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.sessions = append(f.sessions, session.SessionID)
+	n := len(f.sessions)
+	f.mu.Unlock()
+	writeJSON(w, map[string]string{"SigninToken": fmt.Sprintf("signin-token-%d", n)})
+}
+
+// serveQuery answers the query protocol services, STS and IAM, which share the root path.
+func (f *FakeAWS) serveQuery(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := r.PostForm.Get("Action")
+	accessKeyID := ""
+	if m := credentialPattern.FindStringSubmatch(r.Header.Get("Authorization")); m != nil {
+		accessKeyID = m[1]
+	}
+
+	f.mu.Lock()
+	f.actions = append(f.actions, action+" "+accessKeyID)
+	arn := f.identities[accessKeyID]
+	expired := f.expired[accessKeyID]
+	f.mu.Unlock()
+	if expired {
+		if _, err := os.Stat(f.loginMarker); err != nil {
+			writeQueryError(w, http.StatusBadRequest, "ExpiredToken", "The security token included in the request is expired")
+			return
+		}
+	}
+
+	switch {
+	case action != "GetCallerIdentity":
+		writeQueryError(w, http.StatusForbidden, "AccessDenied", action+" is not available in the fake")
+	case arn == "":
+		writeQueryError(w, http.StatusForbidden, "InvalidClientTokenId", "The security token included in the request is invalid")
+	default:
+		account := strings.Split(arn, ":")[4]
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>%s</Arn>
+    <UserId>%s</UserId>
+    <Account>%s</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata><RequestId>e2etest</RequestId></ResponseMetadata>
+</GetCallerIdentityResponse>`, arn, accessKeyID, account)
+	}
+}
+
+func writeQueryError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error>
+  <RequestId>e2etest</RequestId>
+</ErrorResponse>`, code, message)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("X-Amzn-Errortype", code)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"__type": code, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package e2etest
+
+import (
+	"errors"
+	"os"
+)
+
+// openTerminal reports that pseudo-terminals are only opened on Linux.
+func openTerminal() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("pseudo-terminals are only opened on linux")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,7 +10,11 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		// exec passes on the exit code of the command it ran, which reported its own error.
+		var exitErr *cmd.ExitError
+		if !errors.As(err, &exitErr) {
+			fmt.Fprintln(os.Stderr, cmd.ErrorMessage(err))
+		}
+		os.Exit(cmd.ExitCode(err))
 	}
 }
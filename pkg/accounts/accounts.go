@@ -0,0 +1,162 @@
+// Package accounts caches descriptive account metadata (IAM alias, Organizations name)
+// so that lookups, which add latency and need extra permissions, happen rarely.
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// FileName is the name of the account cache within the state directory.
+const FileName = "accounts.json"
+
+// DefaultTTL is how long cached metadata is trusted. Aliases and names rarely change.
+const DefaultTTL = 7 * 24 * time.Hour
+
+// Entry is the cached metadata for one account. Empty fields are cached too, so
+// accounts whose lookups are denied are not retried on every run.
+type Entry struct {
+	Alias     string    `json:"alias,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Cache persists account metadata keyed by account ID in a JSON file.
+type Cache struct {
+	path string
+	ttl  time.Duration
+	// mu serializes updates, so concurrent lookups do not drop each other's entries.
+	mu sync.Mutex
+}
+
+// NewCache creates a cache backed by accounts.json in the aws-console state directory.
+func NewCache() *Cache {
+	dir, err := paths.StateDir()
+	if err != nil {
+		return NewCacheAt("", DefaultTTL)
+	}
+	return NewCacheAt(filepath.Join(dir, FileName), DefaultTTL)
+}
+
+// NewCacheAt creates a cache backed by the given file. An empty path disables persistence.
+func NewCacheAt(path string, ttl time.Duration) *Cache {
+	return &Cache{path: path, ttl: ttl}
+}
+
+// Path returns the backing file path.
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// Load returns the cached entries keyed by account ID. A missing file yields an empty map.
+func (c *Cache) Load() (map[string]Entry, error) {
+	entries := map[string]Entry{}
+	if c.path == "" {
+		return entries, nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("failed to read account cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse account cache %s: %w", c.path, err)
+	}
+	return entries, nil
+}
+
+// Lookup returns metadata for accountID, describing the account with profile's
+// credentials when the cache has no fresh entry. If the lookup fails, a stale entry is
+// returned in its place; with no entry at all, the error is returned alongside an
+// AccountInfo carrying only the ID.
+func (c *Cache) Lookup(ctx context.Context, svc awslib.Service, profile, accountID string, now time.Time) (awslib.AccountInfo, error) {
+	entries, err := c.Load()
+	if err != nil {
+		entries = map[string]Entry{}
+	}
+
+	cached, ok := entries[accountID]
+	if ok && now.Sub(cached.FetchedAt) < c.ttl {
+		return cached.info(accountID), nil
+	}
+
+	info, err := svc.DescribeAccount(ctx, profile, accountID)
+	if err != nil {
+		if ok {
+			return cached.info(accountID), nil
+		}
+		return awslib.AccountInfo{ID: accountID}, err
+	}
+
+	if err := c.put(accountID, Entry{Alias: info.Alias, Name: info.Name, FetchedAt: now.UTC()}); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// put stores entry for accountID, re-reading the file so entries saved since Lookup
+// loaded it are kept.
+func (c *Cache) put(accountID string, entry Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.Load()
+	if err != nil {
+		entries = map[string]Entry{}
+	}
+	entries[accountID] = entry
+	return c.save(entries)
+}
+
+func (e Entry) info(accountID string) awslib.AccountInfo {
+	return awslib.AccountInfo{ID: accountID, Alias: e.Alias, Name: e.Name}
+}
+
+func (c *Cache) save(entries map[string]Entry) error {
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode account cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write account cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write account cache: %w", err)
+	}
+	return nil
+}
+
+// Label formats info for display: the alias or name with the ID in parentheses, or
+// just the ID when neither is known.
+func Label(info awslib.AccountInfo) string {
+	switch {
+	case info.Alias != "":
+		return fmt.Sprintf("%s (%s)", info.Alias, info.ID)
+	case info.Name != "":
+		return fmt.Sprintf("%s (%s)", info.Name, info.ID)
+	}
+	return info.ID
+}
@@ -0,0 +1,115 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+func TestCacheLookup(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCacheAt(filepath.Join(t.TempDir(), "nested", FileName), 24*time.Hour)
+	svc := &mocks.Service{
+		DescribeAccountFunc: func(ctx context.Context, profile string, accountID string) (awslib.AccountInfo, error) {
+			return awslib.AccountInfo{ID: accountID, Alias: "acme-prod"}, nil
+		},
+	}
+
+	info, err := cache.Lookup(context.Background(), svc, "prod", "123456789012", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Alias != "acme-prod" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+
+	if _, err := cache.Lookup(context.Background(), svc, "prod", "123456789012", now.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if svc.DescribeAccountCalls.Load() != 1 {
+		t.Fatalf("expected cached entry to be reused, got %d lookups", svc.DescribeAccountCalls.Load())
+	}
+
+	if _, err := cache.Lookup(context.Background(), svc, "prod", "123456789012", now.Add(25*time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if svc.DescribeAccountCalls.Load() != 2 {
+		t.Fatalf("expected expired entry to be refreshed, got %d lookups", svc.DescribeAccountCalls.Load())
+	}
+}
+
+func TestCacheLookupFallsBackToStaleEntry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewCacheAt(filepath.Join(t.TempDir(), FileName), time.Hour)
+	lookupErr := errors.New("throttled")
+	svc := &mocks.Service{
+		DescribeAccountFunc: func(ctx context.Context, profile string, accountID string) (awslib.AccountInfo, error) {
+			return awslib.AccountInfo{}, lookupErr
+		},
+	}
+
+	info, err := cache.Lookup(context.Background(), svc, "prod", "123456789012", now)
+	if !errors.Is(err, lookupErr) {
+		t.Fatalf("expected lookup error, got %v", err)
+	}
+	if info != (awslib.AccountInfo{ID: "123456789012"}) {
+		t.Fatalf("expected ID-only info, got %+v", info)
+	}
+
+	if err := cache.save(map[string]Entry{"123456789012": {Name: "Production", FetchedAt: now}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err = cache.Lookup(context.Background(), svc, "prod", "123456789012", now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("expected stale entry without error, got %v", err)
+	}
+	if info.Name != "Production" {
+		t.Fatalf("expected stale entry, got %+v", info)
+	}
+}
+
+func TestCacheWithoutPathDoesNotPersist(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCacheAt("", DefaultTTL)
+	svc := &mocks.Service{
+		DescribeAccountFunc: func(ctx context.Context, profile string, accountID string) (awslib.AccountInfo, error) {
+			return awslib.AccountInfo{ID: accountID, Alias: "acme"}, nil
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Lookup(context.Background(), svc, "prod", "123456789012", time.Now()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if svc.DescribeAccountCalls.Load() != 2 {
+		t.Fatalf("expected every lookup to hit the service, got %d", svc.DescribeAccountCalls.Load())
+	}
+}
+
+func TestLabel(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		info awslib.AccountInfo
+		want string
+	}{
+		{awslib.AccountInfo{ID: "123456789012", Alias: "acme-prod", Name: "Production"}, "acme-prod (123456789012)"},
+		{awslib.AccountInfo{ID: "123456789012", Name: "Production"}, "Production (123456789012)"},
+		{awslib.AccountInfo{ID: "123456789012"}, "123456789012"},
+	} {
+		if got := Label(tc.info); got != tc.want {
+			t.Fatalf("Label(%+v) = %q, want %q", tc.info, got, tc.want)
+		}
+	}
+}
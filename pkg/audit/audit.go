@@ -0,0 +1,444 @@
+// Package audit sends a record of each console session aws-console opens to a security
+// team's webhook or EventBridge event bus. Records never contain credentials, sign-in
+// tokens, or sign-in URLs. Records that cannot be delivered are queued in a local file
+// and sent again along with the next one.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// QueueFileName is the name of the queue of undelivered records within the state
+// directory.
+const QueueFileName = "audit-queue.jsonl"
+
+// Source is the EventBridge source of records, and DetailType their detail type.
+const (
+	Source     = "aws-console"
+	DetailType = "AWS Console Opened"
+)
+
+// MaxQueued bounds the queue of undelivered records; the oldest are dropped first.
+const MaxQueued = 1000
+
+// Defaults of an Emitter.
+const (
+	DefaultAttempts = 3
+	DefaultBackoff  = time.Second
+	// DefaultTimeout bounds each delivery attempt.
+	DefaultTimeout = 10 * time.Second
+)
+
+// staleLockAge is how old a queue lock must be to be broken, well past the longest an
+// Emit can take; lockRetryInterval is how often a held lock is tried again.
+const (
+	staleLockAge      = 5 * time.Minute
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// Event records one console session being opened.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Profile   string    `json:"profile,omitempty"`
+	Account   string    `json:"account,omitempty"`
+	Partition string    `json:"partition,omitempty"`
+	// ARN is the caller identity the console signs in as, such as an assumed-role ARN.
+	ARN  string `json:"arn,omitempty"`
+	Role string `json:"role,omitempty"`
+	// Session is the role session name of an assumed-role identity, which CloudTrail
+	// records against every call the console makes.
+	Session     string   `json:"session,omitempty"`
+	Regions     []string `json:"regions,omitempty"`
+	Destination string   `json:"destination,omitempty"`
+	// DurationSeconds is the console session duration that was requested.
+	DurationSeconds int64 `json:"duration_seconds"`
+	// Guest names the teammate a guest session was shared with.
+	Guest string `json:"guest,omitempty"`
+	// FallbackFor names the profile whose IAM Identity Center sign-in failed when
+	// Profile signed in as its fallback, bypassing it.
+	FallbackFor string `json:"fallback_for,omitempty"`
+	// User and Host name the local user and machine that opened the console.
+	User    string `json:"user,omitempty"`
+	Host    string `json:"host,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Sink delivers records to one destination.
+type Sink interface {
+	// Name identifies the sink in the queue, so a record is retried only where it failed.
+	Name() string
+	Send(ctx context.Context, e Event) error
+}
+
+// Webhook POSTs each record as a JSON object to URL.
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+	// Client sends the requests; nil uses http.DefaultClient.
+	Client *http.Client
+}
+
+// Name returns "webhook".
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Send POSTs e and expects a 2xx response.
+func (w *Webhook) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create audit webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("audit webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// PutEventsAPI is the subset of the EventBridge client used to put records on a bus.
+type PutEventsAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridge puts each record on an event bus, with Source and DetailType.
+type EventBridge struct {
+	// HTTPClient sends the requests, such as through a proxy; nil uses the SDK's.
+	HTTPClient *http.Client
+
+	bus     string
+	profile string
+	region  string
+	// mu guards api, which is created on first use so that no AWS config is loaded
+	// unless a record is sent.
+	mu  sync.Mutex
+	api PutEventsAPI
+}
+
+// NewEventBridge creates a sink putting records on bus with the credentials of profile
+// in region. Empty values use the default credential chain and its region.
+func NewEventBridge(bus, profile, region string) *EventBridge {
+	return &EventBridge{bus: bus, profile: profile, region: region}
+}
+
+// NewEventBridgeWithClient creates a sink putting records on bus through api.
+func NewEventBridgeWithClient(bus string, api PutEventsAPI) *EventBridge {
+	return &EventBridge{bus: bus, api: api}
+}
+
+// Name returns "eventbridge".
+func (b *EventBridge) Name() string {
+	return "eventbridge"
+}
+
+// Send puts e on the event bus.
+func (b *EventBridge) Send(ctx context.Context, e Event) error {
+	api, err := b.client(ctx)
+	if err != nil {
+		return err
+	}
+	detail, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	out, err := api.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []ebtypes.PutEventsRequestEntry{{
+			EventBusName: aws.String(b.bus),
+			Source:       aws.String(Source),
+			DetailType:   aws.String(DetailType),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(e.Time),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put audit record on event bus %s: %w", b.bus, err)
+	}
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		entry := out.Entries[0]
+		return fmt.Errorf("event bus %s rejected the audit record: %s: %s", b.bus, aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+	return nil
+}
+
+func (b *EventBridge) client(ctx context.Context) (PutEventsAPI, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.api != nil {
+		return b.api, nil
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	if b.profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(b.profile))
+	}
+	if b.region != "" {
+		opts = append(opts, awsconfig.WithRegion(b.region))
+	}
+	if b.HTTPClient != nil {
+		opts = append(opts, awsconfig.WithHTTPClient(b.HTTPClient))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config for audit records: %w", err)
+	}
+	b.api = eventbridge.NewFromConfig(cfg)
+	return b.api, nil
+}
+
+// queued is a record waiting to be delivered to one sink.
+type queued struct {
+	Sink  string `json:"sink"`
+	Event Event  `json:"event"`
+}
+
+// Emitter delivers records to every sink, retrying each delivery a few times. Records
+// that still fail are queued and delivered, oldest first, before the next record.
+type Emitter struct {
+	sinks []Sink
+	// queue is the file of undelivered records; empty disables queuing.
+	queue    string
+	attempts int
+	backoff  time.Duration
+	timeout  time.Duration
+	sleep    func(context.Context, time.Duration) error
+	// mu serializes emits from concurrent workflows, and the queue's lock file emits
+	// from other processes.
+	mu sync.Mutex
+}
+
+// NewEmitter creates an emitter delivering to sinks and queuing undelivered records in
+// the file at queue. An empty queue drops them instead.
+func NewEmitter(queue string, sinks ...Sink) *Emitter {
+	return &Emitter{
+		sinks:    sinks,
+		queue:    queue,
+		attempts: DefaultAttempts,
+		backoff:  DefaultBackoff,
+		timeout:  DefaultTimeout,
+		sleep:    sleepContext,
+	}
+}
+
+// QueuePath returns the file undelivered records are queued in.
+func (m *Emitter) QueuePath() string {
+	return m.queue
+}
+
+// Emit delivers e, and any queued records, to every sink. A sink that fails is not
+// tried again until the next Emit, and its records are queued. The error reports the
+// sinks that failed.
+func (m *Emitter) Emit(ctx context.Context, e Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pending, err := m.load()
+	if err != nil {
+		return err
+	}
+	for _, s := range m.sinks {
+		pending = append(pending, queued{Sink: s.Name(), Event: e})
+	}
+
+	var left []queued
+	var errs []error
+	failed := map[string]bool{}
+	for _, q := range pending {
+		sink := m.sink(q.Sink)
+		if sink == nil {
+			// The sink is no longer configured, so the record has nowhere to go.
+			continue
+		}
+		if failed[q.Sink] {
+			left = append(left, q)
+			continue
+		}
+		if err := m.deliver(ctx, sink, q.Event); err != nil {
+			failed[q.Sink] = true
+			left = append(left, q)
+			errs = append(errs, err)
+		}
+	}
+
+	if err := m.save(left); err != nil {
+		errs = append(errs, err)
+	} else if len(left) > 0 && m.queue != "" {
+		errs = append(errs, fmt.Errorf("%d audit records are queued in %s", len(left), m.queue))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Emitter) sink(name string) Sink {
+	for _, s := range m.sinks {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// deliver sends e to sink, retrying with a doubling backoff.
+func (m *Emitter) deliver(ctx context.Context, sink Sink, e Event) error {
+	backoff := m.backoff
+	var err error
+	for attempt := 1; attempt <= m.attempts; attempt++ {
+		if attempt > 1 {
+			if err := m.sleep(ctx, backoff); err != nil {
+				break
+			}
+			backoff *= 2
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, m.timeout)
+		err = sink.Send(attemptCtx, e)
+		cancel()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// lock acquires the lock file of the queue, so that two processes never send the same
+// queued records or overwrite each other's, breaking locks older than staleLockAge. It
+// returns the function that releases it.
+func (m *Emitter) lock(ctx context.Context) (func(), error) {
+	if m.queue == "" {
+		return func() {}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.queue), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	lockPath := m.queue + ".lock"
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock the audit queue: %w", err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if err := sleepContext(ctx, lockRetryInterval); err != nil {
+			return nil, fmt.Errorf("timed out waiting for the audit queue lock %s: %w", lockPath, err)
+		}
+	}
+}
+
+// load reads the queue. Lines that cannot be parsed, such as one cut short by a crash,
+// are skipped, and a missing file is an empty queue.
+func (m *Emitter) load() ([]queued, error) {
+	if m.queue == "" {
+		return nil, nil
+	}
+	f, err := os.Open(m.queue)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read the audit queue: %w", err)
+	}
+	defer f.Close()
+
+	var records []queued
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var q queued
+		if err := json.Unmarshal(scanner.Bytes(), &q); err == nil {
+			records = append(records, q)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the audit queue %s: %w", m.queue, err)
+	}
+	return records, nil
+}
+
+// save replaces the queue with records, keeping the newest MaxQueued. An empty queue
+// removes the file.
+func (m *Emitter) save(records []queued) error {
+	if m.queue == "" {
+		return nil
+	}
+	if len(records) == 0 {
+		if err := os.Remove(m.queue); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to clear the audit queue: %w", err)
+		}
+		return nil
+	}
+	if len(records) > MaxQueued {
+		records = records[len(records)-MaxQueued:]
+	}
+
+	var buf bytes.Buffer
+	for _, q := range records {
+		line, err := json.Marshal(q)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit record: %w", err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if err := os.MkdirAll(filepath.Dir(m.queue), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	tmp := m.queue + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write the audit queue: %w", err)
+	}
+	if err := os.Rename(tmp, m.queue); err != nil {
+		return fmt.Errorf("failed to write the audit queue: %w", err)
+	}
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,251 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+var testEvent = Event{
+	Time:            time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	Profile:         "prod",
+	Account:         "123456789012",
+	ARN:             "arn:aws:sts::123456789012:assumed-role/Admin/me",
+	Role:            "Admin",
+	DurationSeconds: 3600,
+}
+
+func TestWebhookSend(t *testing.T) {
+	t.Parallel()
+
+	var got Event
+	var header http.Header
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode record: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	w := &Webhook{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer t"}}
+	if err := w.Send(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Profile != "prod" || got.ARN != testEvent.ARN || got.DurationSeconds != 3600 || !got.Time.Equal(testEvent.Time) {
+		t.Fatalf("unexpected record: %+v", got)
+	}
+	if header.Get("Authorization") != "Bearer t" || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers: %v", header)
+	}
+
+	status = http.StatusBadGateway
+	if err := w.Send(context.Background(), testEvent); err == nil || !strings.Contains(err.Error(), "audit webhook returned 502") {
+		t.Fatalf("expected a status error, got %v", err)
+	}
+}
+
+type fakePutEvents struct {
+	input  *eventbridge.PutEventsInput
+	output *eventbridge.PutEventsOutput
+	err    error
+}
+
+func (f *fakePutEvents) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	f.input = params
+	if f.output == nil {
+		return &eventbridge.PutEventsOutput{}, f.err
+	}
+	return f.output, f.err
+}
+
+func TestEventBridgeSend(t *testing.T) {
+	t.Parallel()
+
+	api := &fakePutEvents{}
+	if err := NewEventBridgeWithClient("security", api).Send(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entry := api.input.Entries[0]
+	if aws.ToString(entry.EventBusName) != "security" || aws.ToString(entry.Source) != Source || aws.ToString(entry.DetailType) != DetailType {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if !strings.Contains(aws.ToString(entry.Detail), `"account":"123456789012"`) {
+		t.Fatalf("unexpected detail: %s", aws.ToString(entry.Detail))
+	}
+
+	api.output = &eventbridge.PutEventsOutput{
+		FailedEntryCount: 1,
+		Entries:          []ebtypes.PutEventsResultEntry{{ErrorCode: aws.String("AccessDenied"), ErrorMessage: aws.String("no")}},
+	}
+	if err := NewEventBridgeWithClient("security", api).Send(context.Background(), testEvent); err == nil || !strings.Contains(err.Error(), "rejected the audit record: AccessDenied: no") {
+		t.Fatalf("expected a rejected entry, got %v", err)
+	}
+}
+
+type fakeSink struct {
+	name  string
+	fails int
+	sent  []Event
+	calls int
+}
+
+func (f *fakeSink) Name() string {
+	return f.name
+}
+
+func (f *fakeSink) Send(ctx context.Context, e Event) error {
+	f.calls++
+	if f.fails > 0 {
+		f.fails--
+		return errors.New("unavailable")
+	}
+	f.sent = append(f.sent, e)
+	return nil
+}
+
+func newTestEmitter(queue string, sinks ...Sink) (*Emitter, *[]time.Duration) {
+	var slept []time.Duration
+	m := NewEmitter(queue, sinks...)
+	m.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return m, &slept
+}
+
+func TestEmitterRetries(t *testing.T) {
+	t.Parallel()
+
+	sink := &fakeSink{name: "webhook", fails: 2}
+	m, slept := newTestEmitter("", sink)
+	if err := m.Emit(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sink.calls != 3 || len(sink.sent) != 1 {
+		t.Fatalf("expected the third attempt to succeed, got %d calls", sink.calls)
+	}
+	if len(*slept) != 2 || (*slept)[0] != time.Second || (*slept)[1] != 2*time.Second {
+		t.Fatalf("expected a doubling backoff, got %v", *slept)
+	}
+}
+
+func TestEmitterQueuesUndelivered(t *testing.T) {
+	t.Parallel()
+
+	queue := filepath.Join(t.TempDir(), "state", QueueFileName)
+	up := &fakeSink{name: "eventbridge"}
+	down := &fakeSink{name: "webhook", fails: DefaultAttempts * 2}
+	m, _ := newTestEmitter(queue, up, down)
+
+	first, second := testEvent, testEvent
+	second.Profile = "dev"
+	if err := m.Emit(context.Background(), first); err == nil || !strings.Contains(err.Error(), "unavailable") || !strings.Contains(err.Error(), "1 audit records are queued") {
+		t.Fatalf("expected a queued record, got %v", err)
+	}
+	// The webhook is still down, so the queued record is not retried after its new one
+	// fails.
+	if err := m.Emit(context.Background(), second); err == nil || !strings.Contains(err.Error(), "2 audit records are queued") {
+		t.Fatalf("expected two queued records, got %v", err)
+	}
+	if len(up.sent) != 2 || down.calls != DefaultAttempts*2 {
+		t.Fatalf("unexpected deliveries: up %d, down %d calls", len(up.sent), down.calls)
+	}
+	info, err := os.Stat(queue)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected an owner-only queue, got %v (%v)", info, err)
+	}
+
+	// Once the webhook is back the queue is delivered in order and removed.
+	third := testEvent
+	third.Profile = "ops"
+	if err := m.Emit(context.Background(), third); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var profiles []string
+	for _, e := range down.sent {
+		profiles = append(profiles, e.Profile)
+	}
+	if strings.Join(profiles, ",") != "prod,dev,ops" {
+		t.Fatalf("expected the queued records first, got %v", profiles)
+	}
+	if len(up.sent) != 3 {
+		t.Fatalf("expected the event bus to get each record once, got %d", len(up.sent))
+	}
+	if _, err := os.Stat(queue); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the queue to be removed, got %v", err)
+	}
+}
+
+func TestEmitterDropsRecordsOfRemovedSinks(t *testing.T) {
+	t.Parallel()
+
+	queue := filepath.Join(t.TempDir(), QueueFileName)
+	down := &fakeSink{name: "webhook", fails: DefaultAttempts}
+	m, _ := newTestEmitter(queue, down)
+	if err := m.Emit(context.Background(), testEvent); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	sink := &fakeSink{name: "eventbridge"}
+	m, _ = newTestEmitter(queue, sink)
+	if err := m.Emit(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.sent) != 1 {
+		t.Fatalf("expected only the new record, got %+v", sink.sent)
+	}
+	if _, err := os.Stat(queue); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the queue to be removed, got %v", err)
+	}
+}
+
+func TestEmitterLocksQueue(t *testing.T) {
+	t.Parallel()
+
+	queue := filepath.Join(t.TempDir(), QueueFileName)
+	lockPath := queue + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process holds the lock, so nothing is sent until it lets go.
+	sink := &fakeSink{name: "webhook"}
+	m, _ := newTestEmitter(queue, sink)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := m.Emit(ctx, testEvent); err == nil || !strings.Contains(err.Error(), "timed out waiting for the audit queue lock") {
+		t.Fatalf("expected a lock timeout, got %v", err)
+	}
+	if len(sink.sent) != 0 {
+		t.Fatalf("expected no record sent while the queue is locked, got %+v", sink.sent)
+	}
+
+	// A lock left behind by a process that crashed is broken.
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Emit(context.Background(), testEvent); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.sent) != 1 {
+		t.Fatalf("expected the record sent, got %+v", sink.sent)
+	}
+	if _, err := os.Stat(lockPath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the lock to be released, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package arn parses Amazon Resource Names, such as the principal ARNs STS reports as
+// caller identities and the role ARNs of the shared AWS config.
+package arn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ARN is a parsed Amazon Resource Name:
+// arn:<partition>:<service>:<region>:<account>:<resource>.
+type ARN struct {
+	Partition string
+	Service   string
+	// Region is empty for global services such as IAM and STS.
+	Region string
+	// AccountID is empty for resources that belong to no account, such as AWS managed
+	// policies.
+	AccountID string
+	Resource  string
+}
+
+// Parse splits s into its parts. The resource, which may itself contain colons, is
+// kept whole.
+func Parse(s string) (ARN, error) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[1] == "" || parts[2] == "" || parts[5] == "" {
+		return ARN{}, fmt.Errorf("invalid ARN %q", s)
+	}
+	return ARN{
+		Partition: parts[1],
+		Service:   parts[2],
+		Region:    parts[3],
+		AccountID: parts[4],
+		Resource:  parts[5],
+	}, nil
+}
+
+func (a ARN) String() string {
+	return strings.Join([]string{"arn", a.Partition, a.Service, a.Region, a.AccountID, a.Resource}, ":")
+}
+
+// IsRoot reports whether a is the root user of its account,
+// arn:aws:iam::123456789012:root.
+func (a ARN) IsRoot() bool {
+	return a.Service == "iam" && a.Resource == "root"
+}
+
+// AssumedRole returns the role and session names of an STS assumed-role session, such
+// as Admin and alice for arn:aws:sts::123456789012:assumed-role/Admin/alice.
+func (a ARN) AssumedRole() (role, session string, ok bool) {
+	if a.Service != "sts" {
+		return "", "", false
+	}
+	rest, ok := strings.CutPrefix(a.Resource, "assumed-role/")
+	if !ok {
+		return "", "", false
+	}
+	role, session, _ = strings.Cut(rest, "/")
+	return role, session, role != ""
+}
+
+// Role returns the name of an IAM role, including any path, such as ops/Admin for
+// arn:aws:iam::123456789012:role/ops/Admin.
+func (a ARN) Role() (string, bool) {
+	if a.Service != "iam" {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(a.Resource, "role/")
+	return name, ok && name != ""
+}
+
+// Policy returns the name, including any path, of an IAM managed policy.
+func (a ARN) Policy() (string, bool) {
+	if a.Service != "iam" {
+		return "", false
+	}
+	name, ok := strings.CutPrefix(a.Resource, "policy/")
+	return name, ok && name != ""
+}
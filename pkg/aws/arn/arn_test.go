@@ -0,0 +1,87 @@
+package arn
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input   string
+		want    ARN
+		wantErr bool
+	}{
+		{input: "arn:aws:iam::123456789012:user/test", want: ARN{Partition: "aws", Service: "iam", AccountID: "123456789012", Resource: "user/test"}},
+		{input: "arn:aws-us-gov:sts::123456789012:assumed-role/Admin/alice", want: ARN{Partition: "aws-us-gov", Service: "sts", AccountID: "123456789012", Resource: "assumed-role/Admin/alice"}},
+		{input: "arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/fn:*", want: ARN{Partition: "aws", Service: "logs", Region: "us-east-1", AccountID: "123456789012", Resource: "log-group:/aws/lambda/fn:*"}},
+		{input: "arn:aws:iam::aws:policy/ReadOnlyAccess", want: ARN{Partition: "aws", Service: "iam", AccountID: "aws", Resource: "policy/ReadOnlyAccess"}},
+		{input: "arn:aws:iam::123456789012", wantErr: true},
+		{input: "arn::iam::123456789012:root", wantErr: true},
+		{input: "urn:aws:iam::123456789012:root", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected %q to be rejected, got %+v", tc.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+			if got.String() != tc.input {
+				t.Fatalf("String() = %q, want %q", got.String(), tc.input)
+			}
+		})
+	}
+}
+
+func TestPrincipals(t *testing.T) {
+	t.Parallel()
+
+	parse := func(s string) ARN {
+		t.Helper()
+		a, err := Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return a
+	}
+
+	if !parse("arn:aws-cn:iam::123456789012:root").IsRoot() || parse("arn:aws:iam::123456789012:user/root").IsRoot() {
+		t.Fatal("expected only the root user to be root")
+	}
+
+	role, session, ok := parse("arn:aws:sts::123456789012:assumed-role/Admin/alice@example.com").AssumedRole()
+	if !ok || role != "Admin" || session != "alice@example.com" {
+		t.Fatalf("unexpected assumed role %q %q %v", role, session, ok)
+	}
+	for _, s := range []string{"arn:aws:iam::123456789012:role/Admin", "arn:aws:sts::123456789012:federated-user/bob"} {
+		if _, _, ok := parse(s).AssumedRole(); ok {
+			t.Fatalf("expected %s not to be an assumed role", s)
+		}
+	}
+
+	if name, ok := parse("arn:aws:iam::123456789012:role/ops/Admin").Role(); !ok || name != "ops/Admin" {
+		t.Fatalf("unexpected role %q %v", name, ok)
+	}
+	if _, ok := parse("arn:aws:sts::123456789012:assumed-role/Admin/alice").Role(); ok {
+		t.Fatal("expected a session not to be an IAM role")
+	}
+
+	if name, ok := parse("arn:aws:iam::aws:policy/job-function/ViewOnlyAccess").Policy(); !ok || name != "job-function/ViewOnlyAccess" {
+		t.Fatalf("unexpected policy %q %v", name, ok)
+	}
+	if _, ok := parse("arn:aws:iam::123456789012:policy/").Policy(); ok {
+		t.Fatal("expected a policy without a name to be rejected")
+	}
+}
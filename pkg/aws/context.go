@@ -0,0 +1,20 @@
+package aws
+
+import "context"
+
+type regionKey struct{}
+
+// WithRegion returns a context that makes Service calls use region instead of the
+// region from the environment or shared config. An empty region is ignored.
+func WithRegion(ctx context.Context, region string) context.Context {
+	if region == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// RegionFromContext returns the region set with WithRegion, if any.
+func RegionFromContext(ctx context.Context) string {
+	region, _ := ctx.Value(regionKey{}).(string)
+	return region
+}
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+type optionsRecordingLoader struct {
+	got *config.LoadOptions
+}
+
+func (l optionsRecordingLoader) LoadDefaultConfig(ctx context.Context, optFns ...func(*config.LoadOptions) error) (awsv2.Config, error) {
+	for _, fn := range optFns {
+		if err := fn(l.got); err != nil {
+			return awsv2.Config{}, err
+		}
+	}
+	return awsv2.Config{}, nil
+}
+
+func TestWithRegion(t *testing.T) {
+	t.Parallel()
+
+	if got := RegionFromContext(context.Background()); got != "" {
+		t.Fatalf("expected no region, got %q", got)
+	}
+	if got := RegionFromContext(WithRegion(context.Background(), "")); got != "" {
+		t.Fatalf("expected empty region to be ignored, got %q", got)
+	}
+	if got := RegionFromContext(WithRegion(context.Background(), "eu-west-1")); got != "eu-west-1" {
+		t.Fatalf("expected eu-west-1, got %q", got)
+	}
+}
+
+func TestLoadConfigUsesContextRegion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		ctx        context.Context
+		wantRegion string
+	}{
+		{name: "no override", ctx: context.Background()},
+		{name: "override", ctx: WithRegion(context.Background(), "ap-southeast-2"), wantRegion: "ap-southeast-2"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := &config.LoadOptions{}
+			svc := newSDKService(optionsRecordingLoader{got: got}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+			if _, err := svc.loadConfig(tc.ctx, "dev"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Region != tc.wantRegion {
+				t.Fatalf("expected region %q, got %q", tc.wantRegion, got.Region)
+			}
+			if got.SharedConfigProfile != "dev" {
+				t.Fatalf("expected profile dev, got %q", got.SharedConfigProfile)
+			}
+		})
+	}
+}
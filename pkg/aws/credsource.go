@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CredentialSource is the kind of SDK credential provider that supplied credentials,
+// which decides how they can be federated.
+type CredentialSource string
+
+const (
+	// CredentialSourceUnknown is any provider not listed here.
+	CredentialSourceUnknown CredentialSource = ""
+	// CredentialSourceKeys are access keys from the shared credentials or config files, or
+	// given directly. They are long-lived unless a session token comes with them.
+	CredentialSourceKeys CredentialSource = "keys"
+	// CredentialSourceEnvironment are AWS_ACCESS_KEY_ID and friends, which hold long-lived
+	// keys or, as in Lambda, temporary role credentials.
+	CredentialSourceEnvironment CredentialSource = "environment"
+	// CredentialSourceProcess are credentials from a credential_process command.
+	CredentialSourceProcess CredentialSource = "process"
+	// CredentialSourceSSO are IAM Identity Center role credentials.
+	CredentialSourceSSO CredentialSource = "sso"
+	// CredentialSourceLogin are credentials from an AWS CLI console login (aws login).
+	CredentialSourceLogin CredentialSource = "login"
+	// CredentialSourceAssumeRole are credentials of a profile's role_arn.
+	CredentialSourceAssumeRole CredentialSource = "assume-role"
+	// CredentialSourceWebIdentity are role credentials for a web identity token, as in EKS.
+	CredentialSourceWebIdentity CredentialSource = "web-identity"
+	// CredentialSourceEC2 are the instance profile role credentials of an EC2 instance.
+	CredentialSourceEC2 CredentialSource = "ec2"
+	// CredentialSourceContainer are the task role credentials of an ECS task or another
+	// container credentials endpoint.
+	CredentialSourceContainer CredentialSource = "container"
+)
+
+// CredentialSources lists every known CredentialSource.
+var CredentialSources = []CredentialSource{
+	CredentialSourceKeys, CredentialSourceEnvironment, CredentialSourceProcess, CredentialSourceSSO,
+	CredentialSourceLogin, CredentialSourceAssumeRole, CredentialSourceWebIdentity,
+	CredentialSourceEC2, CredentialSourceContainer,
+}
+
+// ParseCredentialSource parses the name of a CredentialSource, such as "keys" or "sso".
+func ParseCredentialSource(name string) (CredentialSource, error) {
+	for _, s := range CredentialSources {
+		if strings.EqualFold(name, string(s)) {
+			return s, nil
+		}
+	}
+	names := make([]string, 0, len(CredentialSources))
+	for _, s := range CredentialSources {
+		names = append(names, string(s))
+	}
+	return CredentialSourceUnknown, fmt.Errorf("unknown credential source %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// CredentialSourceOf classifies the SDK provider name recorded in Credentials.Source.
+func CredentialSourceOf(provider string) CredentialSource {
+	switch {
+	case provider == "SSOProvider":
+		return CredentialSourceSSO
+	case provider == "LoginProvider":
+		return CredentialSourceLogin
+	case provider == "AssumeRoleProvider":
+		return CredentialSourceAssumeRole
+	case provider == "WebIdentityCredentials":
+		return CredentialSourceWebIdentity
+	case provider == "EC2RoleProvider":
+		return CredentialSourceEC2
+	case provider == "CredentialsEndpointProvider":
+		return CredentialSourceContainer
+	case provider == "ProcessProvider":
+		return CredentialSourceProcess
+	case provider == "EnvConfigCredentials":
+		return CredentialSourceEnvironment
+	case provider == "StaticCredentials", provider == KeySourceProvider, strings.HasPrefix(provider, "SharedConfigCredentials"):
+		return CredentialSourceKeys
+	}
+	return CredentialSourceUnknown
+}
+
+// RoleSession reports whether the source only ever supplies role session credentials,
+// which are federated as they are: STS does not allow GetSessionToken with them.
+func (s CredentialSource) RoleSession() bool {
+	switch s {
+	case CredentialSourceSSO, CredentialSourceAssumeRole, CredentialSourceWebIdentity,
+		CredentialSourceEC2, CredentialSourceContainer:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCredentialSourceOf(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		provider        string
+		want            CredentialSource
+		wantRoleSession bool
+	}{
+		{provider: "SSOProvider", want: CredentialSourceSSO, wantRoleSession: true},
+		{provider: "LoginProvider", want: CredentialSourceLogin},
+		{provider: "AssumeRoleProvider", want: CredentialSourceAssumeRole, wantRoleSession: true},
+		{provider: "WebIdentityCredentials", want: CredentialSourceWebIdentity, wantRoleSession: true},
+		{provider: "EC2RoleProvider", want: CredentialSourceEC2, wantRoleSession: true},
+		{provider: "CredentialsEndpointProvider", want: CredentialSourceContainer, wantRoleSession: true},
+		{provider: "ProcessProvider", want: CredentialSourceProcess},
+		{provider: "EnvConfigCredentials", want: CredentialSourceEnvironment},
+		{provider: "StaticCredentials", want: CredentialSourceKeys},
+		{provider: KeySourceProvider, want: CredentialSourceKeys},
+		{provider: "SharedConfigCredentials: /home/me/.aws/credentials", want: CredentialSourceKeys},
+		{provider: "CustomProvider", want: CredentialSourceUnknown},
+		{provider: "", want: CredentialSourceUnknown},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.provider, func(t *testing.T) {
+			t.Parallel()
+
+			got := CredentialSourceOf(tc.provider)
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+			if got.RoleSession() != tc.wantRoleSession {
+				t.Fatalf("expected RoleSession %v for %q", tc.wantRoleSession, got)
+			}
+		})
+	}
+}
+
+func TestParseCredentialSource(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range CredentialSources {
+		got, err := ParseCredentialSource(string(s))
+		if err != nil || got != s {
+			t.Fatalf("expected %q, got %q, %v", s, got, err)
+		}
+	}
+	if got, err := ParseCredentialSource("SSO"); err != nil || got != CredentialSourceSSO {
+		t.Fatalf("expected names to be case-insensitive, got %q, %v", got, err)
+	}
+	if _, err := ParseCredentialSource("static"); err == nil || !strings.Contains(err.Error(), `unknown credential source "static"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Console session limits of the federation endpoint.
+const (
+	MinSessionDuration = 15 * time.Minute
+	MaxSessionDuration = 12 * time.Hour
+	// MaxChainedSessionDuration caps sessions of roles assumed with role credentials.
+	MaxChainedSessionDuration = time.Hour
+)
+
+// CredentialKind describes how federation credentials were obtained, which bounds how
+// long a console session they can start.
+type CredentialKind string
+
+const (
+	// CredentialKindSessionToken credentials come from GetSessionToken with IAM user keys.
+	CredentialKindSessionToken CredentialKind = "session-token"
+	// CredentialKindRole credentials are a role session, such as SSO role credentials or
+	// a role assumed with IAM user keys.
+	CredentialKindRole CredentialKind = "role"
+	// CredentialKindRoleChained credentials come from assuming a role with role credentials.
+	CredentialKindRoleChained CredentialKind = "role-chained"
+	// CredentialKindFederationToken credentials come from GetFederationToken with IAM user
+	// keys.
+	CredentialKindFederationToken CredentialKind = "federation-token"
+)
+
+// CredentialKindFromARN returns the kind of temporary credentials held by the caller
+// identity arn.
+func CredentialKindFromARN(arn string) CredentialKind {
+	if strings.Contains(arn, ":assumed-role/") {
+		return CredentialKindRole
+	}
+	return CredentialKindSessionToken
+}
+
+// RoleSession reports whether credentials of kind are a role session.
+func (k CredentialKind) RoleSession() bool {
+	return k == CredentialKindRole || k == CredentialKindRoleChained
+}
+
+// MaxSessionDuration returns the longest console session credentials of kind can start.
+func (k CredentialKind) MaxSessionDuration() time.Duration {
+	if k == CredentialKindRoleChained {
+		return MaxChainedSessionDuration
+	}
+	return MaxSessionDuration
+}
+
+// DurationSeconds returns the DurationSeconds to request from STS for credentials of k
+// that start a console session of d: d within the limits STS accepts for k, such as an
+// hour for role chaining, or zero to leave the duration to STS.
+func (k CredentialKind) DurationSeconds(d time.Duration) int32 {
+	if d <= 0 {
+		return 0
+	}
+	return int32(min(max(d, MinSessionDuration), k.MaxSessionDuration()) / time.Second)
+}
+
+// ValidateSessionDuration checks d against the federation limits for credentials of kind.
+// An empty kind checks the limits that apply to every kind.
+func ValidateSessionDuration(kind CredentialKind, d time.Duration) error {
+	max := kind.MaxSessionDuration()
+	if d >= MinSessionDuration && d <= max {
+		return nil
+	}
+	if kind == CredentialKindRoleChained && d > max {
+		return fmt.Errorf("invalid duration %s: sessions of a role assumed with role credentials (role chaining) last at most %s", d, max)
+	}
+	return fmt.Errorf("invalid duration %s: must be between %s and %s", d, MinSessionDuration, max)
+}
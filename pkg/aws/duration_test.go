@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSessionDuration(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		kind          CredentialKind
+		duration      time.Duration
+		wantErrSubstr string
+	}{
+		{name: "session token maximum", kind: CredentialKindSessionToken, duration: 12 * time.Hour},
+		{name: "role minimum", kind: CredentialKindRole, duration: 15 * time.Minute},
+		{name: "too short", kind: CredentialKindRole, duration: 10 * time.Minute, wantErrSubstr: "must be between 15m0s and 12h0m0s"},
+		{name: "too long", kind: CredentialKindSessionToken, duration: 13 * time.Hour, wantErrSubstr: "must be between 15m0s and 12h0m0s"},
+		{name: "chained maximum", kind: CredentialKindRoleChained, duration: time.Hour},
+		{name: "chained too long", kind: CredentialKindRoleChained, duration: 2 * time.Hour, wantErrSubstr: "role chaining) last at most 1h0m0s"},
+		{name: "chained too short", kind: CredentialKindRoleChained, duration: time.Minute, wantErrSubstr: "must be between 15m0s and 1h0m0s"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateSessionDuration(tc.kind, tc.duration)
+			if tc.wantErrSubstr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
+
+func TestCredentialKindFromARN(t *testing.T) {
+	t.Parallel()
+
+	if got := CredentialKindFromARN("arn:aws:sts::123456789012:assumed-role/Admin/dev"); got != CredentialKindRole {
+		t.Fatalf("expected role, got %q", got)
+	}
+	if got := CredentialKindFromARN("arn:aws:iam::123456789012:user/alice"); got != CredentialKindSessionToken {
+		t.Fatalf("expected session-token, got %q", got)
+	}
+}
+
+func TestCredentialKindDurationSeconds(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		kind     CredentialKind
+		duration time.Duration
+		want     int32
+	}{
+		{name: "left to STS", kind: CredentialKindSessionToken, want: 0},
+		{name: "session token", kind: CredentialKindSessionToken, duration: 12 * time.Hour, want: 43200},
+		{name: "role chaining", kind: CredentialKindRoleChained, duration: 12 * time.Hour, want: 3600},
+		{name: "too short", kind: CredentialKindRole, duration: time.Minute, want: 900},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.kind.DurationSeconds(tc.duration); got != tc.want {
+				t.Fatalf("DurationSeconds(%s) = %d, want %d", tc.duration, got, tc.want)
+			}
+		})
+	}
+}
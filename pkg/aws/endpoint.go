@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// RegionPlaceholder is replaced with the request's region in STS endpoint templates, so
+// one setting can cover a VPC endpoint in each region.
+const RegionPlaceholder = "{region}"
+
+type stsEndpointKey struct{}
+
+// WithSTSEndpoint returns a context that sends STS calls to endpoint, such as a VPC
+// interface endpoint, instead of the public regional endpoint. endpoint may contain
+// RegionPlaceholder. An empty endpoint is ignored.
+func WithSTSEndpoint(ctx context.Context, endpoint string) context.Context {
+	if endpoint == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, stsEndpointKey{}, endpoint)
+}
+
+// STSEndpointFromContext returns the endpoint set with WithSTSEndpoint, if any.
+func STSEndpointFromContext(ctx context.Context) string {
+	endpoint, _ := ctx.Value(stsEndpointKey{}).(string)
+	return endpoint
+}
+
+// ValidateSTSEndpoint checks that template is an endpoint URL accepted by
+// validEndpointURL, once any RegionPlaceholder is filled in.
+func ValidateSTSEndpoint(template string) error {
+	_, err := ResolveSTSEndpoint(template, "us-east-1")
+	return err
+}
+
+// ResolveSTSEndpoint substitutes region into template and validates the result.
+func ResolveSTSEndpoint(template, region string) (string, error) {
+	if strings.Contains(template, RegionPlaceholder) && region == "" {
+		return "", fmt.Errorf("STS endpoint %s needs a region; set --region or the profile's region", template)
+	}
+
+	endpoint := strings.ReplaceAll(template, RegionPlaceholder, region)
+	if !validEndpointURL(endpoint) {
+		return "", fmt.Errorf("invalid STS endpoint %q (expected an https URL such as https://vpce-0123-abcd.sts.%s.vpce.amazonaws.com, or http to localhost)", template, RegionPlaceholder)
+	}
+	return endpoint, nil
+}
+
+type federationEndpointKey struct{}
+
+// WithFederationEndpoint returns a context whose sign-in tokens and login URLs use
+// endpoint instead of the partition's federation endpoint, such as a local stand-in
+// used for testing. An empty endpoint is ignored.
+func WithFederationEndpoint(ctx context.Context, endpoint string) context.Context {
+	if endpoint == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, federationEndpointKey{}, endpoint)
+}
+
+// FederationEndpointFromContext returns the endpoint set with WithFederationEndpoint, if any.
+func FederationEndpointFromContext(ctx context.Context) string {
+	endpoint, _ := ctx.Value(federationEndpointKey{}).(string)
+	return endpoint
+}
+
+// ValidateFederationEndpoint checks that endpoint is an endpoint URL accepted by
+// validEndpointURL.
+func ValidateFederationEndpoint(endpoint string) error {
+	if !validEndpointURL(endpoint) {
+		return fmt.Errorf("invalid federation endpoint %q (expected an https URL such as https://signin.aws.amazon.com/federation, or http to localhost)", endpoint)
+	}
+	return nil
+}
+
+// validEndpointURL reports whether endpoint is an https URL with a host, or an http URL
+// to a loopback host such as LocalStack's http://localhost:4566. Credentials are sent to
+// these endpoints, so plain http is refused anywhere else.
+func validEndpointURL(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		return isLoopbackHost(u.Hostname())
+	}
+	return false
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,172 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func TestResolveSTSEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		template      string
+		region        string
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name:     "fixed endpoint",
+			template: "https://vpce-0abc-1234.sts.us-east-1.vpce.amazonaws.com",
+			region:   "eu-west-1",
+			want:     "https://vpce-0abc-1234.sts.us-east-1.vpce.amazonaws.com",
+		},
+		{
+			name:     "per-region template",
+			template: "https://sts.{region}.internal.example.com",
+			region:   "eu-west-1",
+			want:     "https://sts.eu-west-1.internal.example.com",
+		},
+		{
+			name:          "template without region",
+			template:      "https://sts.{region}.internal.example.com",
+			wantErrSubstr: "needs a region",
+		},
+		{
+			name:          "plain http",
+			template:      "http://sts.internal.example.com",
+			region:        "us-east-1",
+			wantErrSubstr: `invalid STS endpoint "http://sts.internal.example.com"`,
+		},
+		{
+			name:     "localstack",
+			template: "http://localhost:4566",
+			region:   "us-east-1",
+			want:     "http://localhost:4566",
+		},
+		{
+			name:     "loopback address",
+			template: "http://127.0.0.1:4566",
+			region:   "us-east-1",
+			want:     "http://127.0.0.1:4566",
+		},
+		{
+			name:          "no host",
+			template:      "sts.internal.example.com",
+			region:        "us-east-1",
+			wantErrSubstr: "invalid STS endpoint",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ResolveSTSEndpoint(tc.template, tc.region)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSDKServiceUsesSTSEndpoint(t *testing.T) {
+	t.Parallel()
+
+	var options sts.Options
+	stsClient := fakeSTS{getCallerIdentityOutput: &sts.GetCallerIdentityOutput{
+		Arn:     awsv2.String("arn:aws:iam::123456789012:user/alice"),
+		Account: awsv2.String("123456789012"),
+	}}
+	svc := newSDKService(
+		fakeConfigLoader{cfg: awsv2.Config{Region: "eu-west-1"}},
+		fakeSTSFactory{client: stsClient, options: &options},
+		fakeIAMFactory{},
+		fakeOrganizationsFactory{},
+	)
+
+	ctx := WithSTSEndpoint(context.Background(), "https://vpce-0abc.sts.{region}.vpce.amazonaws.com")
+	if _, err := svc.GetCallerIdentity(ctx, "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := awsv2.ToString(options.BaseEndpoint); got != "https://vpce-0abc.sts.eu-west-1.vpce.amazonaws.com" {
+		t.Fatalf("unexpected STS endpoint: %q", got)
+	}
+
+	options = sts.Options{}
+	if _, err := svc.GetCallerIdentity(context.Background(), "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options.BaseEndpoint != nil {
+		t.Fatalf("expected default endpoint without WithSTSEndpoint, got %q", *options.BaseEndpoint)
+	}
+}
+
+func TestValidateFederationEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{endpoint: "https://signin.aws.amazon.com/federation"},
+		{endpoint: "http://localhost:8080/federation"},
+		{endpoint: "http://[::1]:8080/federation"},
+		{endpoint: "http://signin.internal/federation", wantErr: true},
+		{endpoint: "ftp://localhost/federation", wantErr: true},
+		{endpoint: "/federation", wantErr: true},
+	}
+	for _, tc := range testCases {
+		err := ValidateFederationEndpoint(tc.endpoint)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("ValidateFederationEndpoint(%q) = %v, want error %v", tc.endpoint, err, tc.wantErr)
+		}
+	}
+}
+
+func TestFederationClientUsesFederationEndpoint(t *testing.T) {
+	t.Parallel()
+
+	var requested string
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"token-123"}`)),
+		}, nil
+	}}, defaultFederationURL, defaultConsoleURL)
+	client.partitions = partitionEndpoints
+
+	ctx := WithFederationEndpoint(WithPartition(context.Background(), PartitionUSGov), "http://localhost:8080/federation")
+	loginURL, err := client.BuildConsoleURL(ctx, Credentials{AccessKeyID: "ASIA"}, 3600, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requested != "http://localhost:8080/federation" {
+		t.Fatalf("expected the sign-in token from the override, got %q", requested)
+	}
+	if !strings.HasPrefix(loginURL, "http://localhost:8080/federation?Action=login&") {
+		t.Fatalf("expected a login URL on the override, got %q", loginURL)
+	}
+	// The console is still the partition's.
+	if !strings.Contains(loginURL, url.QueryEscape("https://console.amazonaws-us-gov.com/")) {
+		t.Fatalf("expected the partition's console, got %q", loginURL)
+	}
+}
@@ -0,0 +1,192 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// Errors that callers tell apart, such as to exit with a distinct code. They are matched
+// with errors.Is, and are usually attached with MarkError so messages stay unchanged.
+var (
+	// ErrSSOLoginRequired means an SSO login is needed and was not, or could not be, done.
+	ErrSSOLoginRequired = errors.New("SSO login required")
+	// ErrCredentialsExpired means the credentials have expired or were rejected, and
+	// signing in again should fix them.
+	ErrCredentialsExpired = errors.New("credentials expired")
+	// ErrFederationThrottled means the federation endpoint kept rejecting requests as
+	// too frequent.
+	ErrFederationThrottled = errors.New("federation endpoint throttled the request")
+	// ErrThrottled means AWS or the federation endpoint kept rejecting requests as too
+	// frequent, after backing off. It is matched by every ThrottledError.
+	ErrThrottled = errors.New("request throttled")
+	// ErrUnsupportedPlatform means there is no supported way to do what was asked on
+	// this operating system.
+	ErrUnsupportedPlatform = errors.New("unsupported platform")
+)
+
+// MarkError returns an error with the message of err that also matches kind, one of the
+// errors above, with errors.Is.
+func MarkError(err, kind error) error {
+	if err == nil {
+		return nil
+	}
+	return &markedError{err: err, kind: kind}
+}
+
+type markedError struct {
+	err, kind error
+}
+
+func (e *markedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *markedError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
+
+// ThrottledError is returned when a request was throttled on every attempt. Its message
+// says when to try again instead of quoting the raw response.
+type ThrottledError struct {
+	// Service is what throttled the request, such as "STS" or "the federation endpoint".
+	Service string
+	// RetryAfter estimates how long to wait before trying again.
+	RetryAfter time.Duration
+	// Err is the error of the last attempt.
+	Err error
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s is throttling requests; try again in about %s", e.Service, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ThrottledError) Unwrap() []error {
+	return []error{e.Err, ErrThrottled}
+}
+
+// sdkMaxAttempts is how often SDK calls are tried before a throttling error is returned.
+const sdkMaxAttempts = 5
+
+// sdkThrottleWait estimates how long STS and other APIs stay throttled: the longest
+// backoff of the SDK's standard retryer.
+const sdkThrottleWait = 20 * time.Second
+
+// throttlingErrorCodes are API error codes for requests rejected as too frequent.
+var throttlingErrorCodes = map[string]bool{
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"RequestLimitExceeded":                   true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+}
+
+// throttled returns a ThrottledError for err when an SDK call was throttled on every
+// attempt, and err otherwise.
+func throttled(err error) error {
+	var apiErr smithy.APIError
+	if err == nil || !errors.As(err, &apiErr) || !throttlingErrorCodes[apiErr.ErrorCode()] {
+		return err
+	}
+	service := "AWS"
+	var opErr *smithy.OperationError
+	if errors.As(err, &opErr) && opErr.ServiceID != "" {
+		service = "AWS " + opErr.ServiceID
+	}
+	return &ThrottledError{Service: service, RetryAfter: sdkThrottleWait, Err: err}
+}
+
+// ErrorKind classifies why a call to AWS failed.
+type ErrorKind int
+
+const (
+	// ErrorKindUnknown errors may be caused by missing or invalid credentials.
+	ErrorKindUnknown ErrorKind = iota
+	// ErrorKindExpired errors mean the credentials or SSO token have expired or are
+	// invalid, and signing in again should fix them.
+	ErrorKindExpired
+	// ErrorKindNetwork errors mean AWS could not be reached.
+	ErrorKindNetwork
+	// ErrorKindAccessDenied errors mean the credentials are valid but not allowed to
+	// make the call.
+	ErrorKindAccessDenied
+	// ErrorKindThrottled errors mean AWS rejected the call as too frequent; waiting,
+	// not signing in again, fixes them.
+	ErrorKindThrottled
+)
+
+// String names the kind, such as "access-denied".
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindExpired:
+		return "expired"
+	case ErrorKindNetwork:
+		return "network"
+	case ErrorKindAccessDenied:
+		return "access-denied"
+	case ErrorKindThrottled:
+		return "throttled"
+	default:
+		return "unknown"
+	}
+}
+
+// expiredErrorCodes are API error codes for credentials that must be renewed.
+var expiredErrorCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+	"InvalidClientTokenId":  true,
+	"UnrecognizedClient":    true,
+	"UnauthorizedException": true,
+	"InvalidGrantException": true,
+	"RequestExpired":        true,
+	"SignatureDoesNotMatch": true,
+}
+
+// ClassifyError reports why err, returned by a call to AWS, happened.
+func ClassifyError(err error) ErrorKind {
+	if err == nil {
+		return ErrorKindUnknown
+	}
+	if errors.Is(err, ErrCredentialsExpired) {
+		return ErrorKindExpired
+	}
+	if errors.Is(err, ErrThrottled) {
+		return ErrorKindThrottled
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.ErrorCode()
+		if throttlingErrorCodes[code] {
+			return ErrorKindThrottled
+		}
+		if expiredErrorCodes[code] {
+			return ErrorKindExpired
+		}
+		if code == "AccessDenied" || code == "AccessDeniedException" {
+			return ErrorKindAccessDenied
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindNetwork
+	}
+
+	// The SDK's SSO credential provider reports an expired or missing token cache
+	// without an API error.
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "token") && (strings.Contains(msg, "expired") || strings.Contains(msg, "refresh cached sso token failed")) {
+		return ErrorKindExpired
+	}
+	return ErrorKindUnknown
+}
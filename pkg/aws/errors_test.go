@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "expired token", err: &smithy.GenericAPIError{Code: "ExpiredToken"}, want: ErrorKindExpired},
+		{name: "wrapped invalid key", err: fmt.Errorf("operation error STS: %w", &smithy.GenericAPIError{Code: "InvalidClientTokenId"}), want: ErrorKindExpired},
+		{name: "access denied", err: &smithy.GenericAPIError{Code: "AccessDenied"}, want: ErrorKindAccessDenied},
+		{name: "dns failure", err: fmt.Errorf("request send failed: %w", &net.DNSError{Err: "no such host", Name: "sts.amazonaws.com"}), want: ErrorKindNetwork},
+		{name: "timeout", err: fmt.Errorf("request canceled: %w", context.DeadlineExceeded), want: ErrorKindNetwork},
+		{name: "sso token", err: errors.New("failed to refresh cached credentials, refresh cached SSO token failed"), want: ErrorKindExpired},
+		{name: "sso token expired", err: errors.New("the SSO session token has expired or is invalid"), want: ErrorKindExpired},
+		{name: "marked expired", err: MarkError(errors.New("credentials still invalid"), ErrCredentialsExpired), want: ErrorKindExpired},
+		{name: "throttled", err: fmt.Errorf("operation error STS: %w", &smithy.GenericAPIError{Code: "Throttling"}), want: ErrorKindThrottled},
+		{name: "throttled federation", err: MarkError(&ThrottledError{Service: "the federation endpoint"}, ErrFederationThrottled), want: ErrorKindThrottled},
+		{name: "missing credentials", err: errors.New("failed to retrieve credentials: no EC2 IMDS role found"), want: ErrorKindUnknown},
+	}
+
+	for _, tc := range testCases {
+		if got := ClassifyError(tc.err); got != tc.want {
+			t.Fatalf("%s: ClassifyError(%v) = %d, want %d", tc.name, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestMarkError(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("device authorization denied")
+	err := MarkError(fmt.Errorf("SSO login failed: %w", cause), ErrSSOLoginRequired)
+	if err.Error() != "SSO login failed: device authorization denied" {
+		t.Fatalf("expected the message to be unchanged, got %q", err.Error())
+	}
+	if !errors.Is(err, ErrSSOLoginRequired) || !errors.Is(err, cause) {
+		t.Fatalf("expected %v to match both the kind and its cause", err)
+	}
+	if errors.Is(err, ErrCredentialsExpired) {
+		t.Fatalf("expected %v not to match another kind", err)
+	}
+	if MarkError(nil, ErrSSOLoginRequired) != nil {
+		t.Fatal("expected no error to stay nil")
+	}
+}
+
+func TestThrottled(t *testing.T) {
+	t.Parallel()
+
+	for _, code := range []string{"Throttling", "RequestLimitExceeded", "TooManyRequestsException"} {
+		apiErr := &smithy.GenericAPIError{Code: code, Message: "Rate exceeded"}
+		err := throttled(&smithy.OperationError{ServiceID: "STS", OperationName: "AssumeRole", Err: apiErr})
+		var throttledErr *ThrottledError
+		if !errors.As(err, &throttledErr) || !errors.Is(err, ErrThrottled) || !errors.Is(err, apiErr) {
+			t.Fatalf("%s: expected a throttled error wrapping the API error, got %#v", code, err)
+		}
+		if err.Error() != "AWS STS is throttling requests; try again in about 20s" {
+			t.Fatalf("%s: unexpected message %q", code, err)
+		}
+	}
+
+	for _, err := range []error{nil, errors.New("boom"), &smithy.GenericAPIError{Code: "AccessDenied"}} {
+		if got := throttled(err); got != err {
+			t.Fatalf("expected %v to be returned unchanged, got %v", err, got)
+		}
+	}
+	if err := throttled(&smithy.GenericAPIError{Code: "ThrottlingException"}); err == nil || !strings.HasPrefix(err.Error(), "AWS is throttling requests") {
+		t.Fatalf("expected AWS to be named without a service, got %v", err)
+	}
+}
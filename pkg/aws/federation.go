@@ -1,20 +1,139 @@
 package aws
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
 	defaultFederationURL = "https://signin.aws.amazon.com/federation"
 	defaultConsoleURL    = "https://console.aws.amazon.com/"
+	// SigninTokenTTL is how long the federation endpoint accepts a sign-in token, and so
+	// how long a sign-in URL can be used.
+	SigninTokenTTL = 15 * time.Minute
+	// DefaultIssuer identifies aws-console to the console when no issuer URL is set.
+	DefaultIssuer = "aws-console-cli"
+	// DefaultHTTPTimeout bounds each federation request unless WithHTTPTimeout overrides it.
+	DefaultHTTPTimeout = 15 * time.Second
 )
 
+// RetryPolicy controls how federation requests that fail with a transport error,
+// throttling, or a server error are retried. Delays grow exponentially from BaseDelay
+// up to MaxDelay, and each is drawn at random below that bound to spread out retries.
+type RetryPolicy struct {
+	// MaxAttempts counts the first request; one or less disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy is the policy NewFederationClient uses.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, BaseDelay: 250 * time.Millisecond, MaxDelay: 4 * time.Second}
+
+// backoff returns the longest delay before the retry following attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+type httpTimeoutKey struct{}
+
+// WithHTTPTimeout returns a context whose federation requests each time out after d.
+// A non-positive d is ignored.
+func WithHTTPTimeout(ctx context.Context, d time.Duration) context.Context {
+	if d <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, httpTimeoutKey{}, d)
+}
+
+// HTTPTimeoutFromContext returns the timeout set with WithHTTPTimeout, or DefaultHTTPTimeout.
+func HTTPTimeoutFromContext(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(httpTimeoutKey{}).(time.Duration); ok {
+		return d
+	}
+	return DefaultHTTPTimeout
+}
+
+type issuerKey struct{}
+
+// WithIssuer returns a context whose login URLs name issuer as the Issuer. When it is a
+// URL, the console links to it once the session expires. An empty issuer is ignored.
+func WithIssuer(ctx context.Context, issuer string) context.Context {
+	if issuer == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, issuerKey{}, issuer)
+}
+
+// ValidateIssuer checks that an issuer containing "://" is an absolute http or https
+// URL the console can link to. Other values are names shown by the console.
+func ValidateIssuer(issuer string) error {
+	if !strings.Contains(issuer, "://") {
+		return nil
+	}
+	u, err := url.Parse(issuer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid issuer URL %q (expected an http or https URL such as https://sso.example.com/aws)", issuer)
+	}
+	return nil
+}
+
+// IssuerFromContext returns the issuer set with WithIssuer, or DefaultIssuer.
+func IssuerFromContext(ctx context.Context) string {
+	if issuer, _ := ctx.Value(issuerKey{}).(string); issuer != "" {
+		return issuer
+	}
+	return DefaultIssuer
+}
+
+// SigninTokenCache stores console sign-in tokens between runs.
+type SigninTokenCache interface {
+	SigninToken(key string) (string, bool)
+	PutSigninToken(key, token string, expires time.Time) error
+}
+
+type signinTokenCacheKey struct{}
+
+// WithSigninTokenCache returns a context whose console URLs reuse sign-in tokens from
+// cache while they are valid.
+func WithSigninTokenCache(ctx context.Context, cache SigninTokenCache) context.Context {
+	return context.WithValue(ctx, signinTokenCacheKey{}, cache)
+}
+
+func signinTokenCacheFromContext(ctx context.Context) SigninTokenCache {
+	cache, _ := ctx.Value(signinTokenCacheKey{}).(SigninTokenCache)
+	return cache
+}
+
+type signinTokenScopeKey struct{}
+
+// WithSigninTokenScope returns a context whose cached sign-in tokens are also keyed by
+// scope, which describes what else limits the console session, such as its session
+// policy, so a token issued for one session is never reused for another.
+func WithSigninTokenScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, signinTokenScopeKey{}, scope)
+}
+
+func signinTokenScopeFromContext(ctx context.Context) string {
+	scope, _ := ctx.Value(signinTokenScopeKey{}).(string)
+	return scope
+}
+
 type federationHTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
@@ -24,15 +143,34 @@ type FederationClient struct {
 	client        federationHTTPClient
 	federationURL string
 	consoleURL    string
+	// partitions, when set, maps a partition from the request context to its endpoints.
+	partitions map[string]Endpoints
+	// Retry decides whether and when failed sign-in token requests are retried.
+	Retry RetryPolicy
+	// jitter picks the delay before a retry, at most max; sleep waits for it.
+	jitter func(max time.Duration) time.Duration
+	sleep  func(ctx context.Context, d time.Duration) error
 }
 
-// NewFederationClient creates a federation client with sane defaults.
+// NewFederationClient creates a federation client with sane defaults. Requests time
+// out after DefaultHTTPTimeout unless the request context sets another timeout.
 func NewFederationClient() *FederationClient {
-	return newFederationClient(
-		&http.Client{Timeout: 15 * time.Second},
+	f := newFederationClient(
+		&http.Client{},
 		defaultFederationURL,
 		defaultConsoleURL,
 	)
+	f.partitions = partitionEndpoints
+	f.Retry = DefaultRetryPolicy
+	return f
+}
+
+// WithTransport returns a copy of f whose requests go through transport, such as one
+// from NewTransport.
+func (f *FederationClient) WithTransport(transport http.RoundTripper) *FederationClient {
+	c := *f
+	c.client = &http.Client{Transport: transport}
+	return &c
 }
 
 func newFederationClient(client federationHTTPClient, federationURL string, consoleURL string) *FederationClient {
@@ -40,10 +178,96 @@ func newFederationClient(client federationHTTPClient, federationURL string, cons
 		client:        client,
 		federationURL: federationURL,
 		consoleURL:    consoleURL,
+		jitter:        fullJitter,
+		sleep:         sleepContext,
 	}
 }
 
-func (f *FederationClient) BuildConsoleURL(ctx context.Context, creds Credentials, durationSeconds int32) (string, error) {
+func fullJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max + 1)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (f *FederationClient) BuildConsoleURL(ctx context.Context, creds Credentials, durationSeconds int32, destination string) (string, error) {
+	urls, err := f.BuildConsoleURLs(ctx, creds, durationSeconds, []string{destination})
+	if err != nil {
+		return "", err
+	}
+	return urls[0], nil
+}
+
+func (f *FederationClient) BuildConsoleURLs(ctx context.Context, creds Credentials, durationSeconds int32, destinations []string) ([]string, error) {
+	endpoints, err := f.endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := f.cachedSigninToken(ctx, endpoints, creds, durationSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := IssuerFromContext(ctx)
+	urls := make([]string, 0, len(destinations))
+	for _, destination := range destinations {
+		region := destinationRegion(destination)
+		if region == "" {
+			region = RegionFromContext(ctx)
+		}
+		urls = append(urls, fmt.Sprintf(
+			"%s?Action=login&Issuer=%s&Destination=%s&SigninToken=%s",
+			endpoints.FederationURL,
+			url.QueryEscape(issuer),
+			url.QueryEscape(destinationURL(regionalConsoleURL(endpoints.ConsoleURL, region), destination)),
+			url.QueryEscape(token),
+		))
+	}
+	return urls, nil
+}
+
+// cachedSigninToken returns a sign-in token from the context's SigninTokenCache, if any,
+// and otherwise requests one and caches it. Tokens are keyed by endpoint, partition,
+// access key, session duration, and the scope set with WithSigninTokenScope, and never
+// outlive the credentials they were issued for. Failing to cache a token is not an error.
+func (f *FederationClient) cachedSigninToken(ctx context.Context, endpoints Endpoints, creds Credentials, durationSeconds int32) (string, error) {
+	cache := signinTokenCacheFromContext(ctx)
+	if cache == nil {
+		return f.signinToken(ctx, endpoints, creds, durationSeconds)
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%d|%s", endpoints.FederationURL, PartitionFromContext(ctx), creds.AccessKeyID, durationSeconds, signinTokenScopeFromContext(ctx))
+	if token, ok := cache.SigninToken(key); ok {
+		return token, nil
+	}
+
+	token, err := f.signinToken(ctx, endpoints, creds, durationSeconds)
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(SigninTokenTTL)
+	if !creds.Expires.IsZero() && creds.Expires.Before(expires) {
+		expires = creds.Expires
+	}
+	_ = cache.PutSigninToken(key, token, expires)
+	return token, nil
+}
+
+// signinToken exchanges credentials for a console sign-in token. A token can be used
+// for several logins until it expires 15 minutes after it is issued.
+func (f *FederationClient) signinToken(ctx context.Context, endpoints Endpoints, creds Credentials, durationSeconds int32) (string, error) {
 	sessionData := map[string]string{
 		"sessionId":    creds.AccessKeyID,
 		"sessionKey":   creds.SecretAccessKey,
@@ -55,31 +279,32 @@ func (f *FederationClient) BuildConsoleURL(ctx context.Context, creds Credential
 		return "", fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	tokenURL := fmt.Sprintf(
-		"%s?Action=getSigninToken&SessionDuration=%d&Session=%s",
-		f.federationURL,
-		durationSeconds,
-		url.QueryEscape(string(sessionJSON)),
-	)
+	tokenURL := endpoints.FederationURL + "?Action=getSigninToken"
+	// Without a SessionDuration the console session ends with the credentials.
+	if durationSeconds > 0 {
+		tokenURL += fmt.Sprintf("&SessionDuration=%d", durationSeconds)
+	}
+	tokenURL += "&Session=" + url.QueryEscape(string(sessionJSON))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to build federation request: %w", err)
 	}
 
-	resp, err := f.client.Do(req)
+	status, body, retryAfter, err := f.fetch(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to request signin token: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read federation response: %w", err)
+	if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+		return "", MarkError(&ThrottledError{
+			Service:    "the federation endpoint",
+			RetryAfter: cmp.Or(retryAfter, federationThrottleWait),
+			Err:        fmt.Errorf("federation endpoint returned HTTP %d: %s", status, string(body)),
+		}, ErrFederationThrottled)
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("federation endpoint returned HTTP %d: %s", resp.StatusCode, string(body))
+	if status != http.StatusOK {
+		return "", fmt.Errorf("federation endpoint returned HTTP %d: %s", status, string(body))
 	}
 
 	var tokenResp struct {
@@ -94,12 +319,145 @@ func (f *FederationClient) BuildConsoleURL(ctx context.Context, creds Credential
 		return "", fmt.Errorf("received empty signin token from federation endpoint")
 	}
 
-	loginURL := fmt.Sprintf(
-		"%s?Action=login&Issuer=aws-console-cli&Destination=%s&SigninToken=%s",
-		f.federationURL,
-		url.QueryEscape(f.consoleURL),
-		url.QueryEscape(tokenResp.SigninToken),
-	)
+	return tokenResp.SigninToken, nil
+}
+
+// federationThrottleWait estimates how long the federation endpoint stays throttled when
+// its response has no Retry-After header.
+const federationThrottleWait = 30 * time.Second
+
+// fetch sends req and returns the status, body, and Retry-After delay of the response,
+// retrying transport errors, throttling, and server errors according to f.Retry. A
+// Retry-After delay replaces the backoff; one longer than f.Retry.MaxDelay ends the
+// retries, as waiting that long is better left to the user.
+func (f *FederationClient) fetch(req *http.Request) (int, []byte, time.Duration, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		status, body, retryAfter, err := f.fetchOnce(req)
+		if attempt >= f.Retry.MaxAttempts || ctx.Err() != nil || !retryable(status, err) || retryAfter > f.Retry.MaxDelay {
+			return status, body, retryAfter, err
+		}
+
+		reason := fmt.Sprintf("HTTP %d", status)
+		if err != nil {
+			reason = err.Error()
+		}
+		delay := f.jitter(f.Retry.backoff(attempt))
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		LoggerFromContext(ctx).Debug("retrying federation request", "attempt", attempt+1, "delay", delay, "reason", reason)
+		if err := f.sleep(ctx, delay); err != nil {
+			return 0, nil, 0, fmt.Errorf("failed to request signin token: %w", err)
+		}
+	}
+}
+
+// fetchOnce makes a single attempt at req, bounded by the context's HTTP timeout.
+func (f *FederationClient) fetchOnce(req *http.Request) (int, []byte, time.Duration, error) {
+	timeout := HTTPTimeoutFromContext(req.Context())
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	resp, err := doHTTP(f.client, req.Clone(ctx))
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && req.Context().Err() == nil {
+			return 0, nil, 0, fmt.Errorf("failed to request signin token: timed out after %s", timeout)
+		}
+		return 0, nil, 0, fmt.Errorf("failed to request signin token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("failed to read federation response: %w", err)
+	}
+	return resp.StatusCode, body, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), nil
+}
+
+// parseRetryAfter reads a Retry-After header, given in seconds or as an HTTP date, as a
+// delay from now. Missing or invalid values are 0.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// retryable reports whether a federation attempt failed in a way worth retrying.
+func retryable(status int, err error) bool {
+	return err != nil || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
 
-	return loginURL, nil
+// endpoints picks the federation and console URLs for the partition in ctx, falling
+// back to the client's configured URLs when no partition is set. An endpoint set with
+// WithFederationEndpoint replaces the federation URL.
+func (f *FederationClient) endpoints(ctx context.Context) (Endpoints, error) {
+	e, err := f.partitionURLs(ctx)
+	if err != nil {
+		return Endpoints{}, err
+	}
+	if endpoint := FederationEndpointFromContext(ctx); endpoint != "" {
+		e.FederationURL = endpoint
+	}
+	return e, nil
+}
+
+func (f *FederationClient) partitionURLs(ctx context.Context) (Endpoints, error) {
+	fallback := Endpoints{FederationURL: f.federationURL, ConsoleURL: f.consoleURL}
+
+	partition := PartitionFromContext(ctx)
+	if partition == "" || f.partitions == nil {
+		return fallback, nil
+	}
+	e, ok := f.partitions[partition]
+	if !ok {
+		return Endpoints{}, fmt.Errorf("console federation is not available in partition %q", partition)
+	}
+	return e, nil
+}
+
+var consoleRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// regionalConsoleURL returns the console root for region, such as
+// https://us-west-2.console.aws.amazon.com/, so the console opens there instead of in
+// the last region used in the browser. Invalid regions leave consoleURL unchanged.
+func regionalConsoleURL(consoleURL, region string) string {
+	if !consoleRegionPattern.MatchString(region) {
+		return consoleURL
+	}
+	u, err := url.Parse(consoleURL)
+	if err != nil || strings.HasPrefix(u.Host, region+".") {
+		return consoleURL
+	}
+	u.Host = region + "." + u.Host
+	return u.String()
+}
+
+// destinationRegion returns the region query parameter of a console path, if any.
+func destinationRegion(destination string) string {
+	path, _, _ := strings.Cut(destination, "#")
+	_, rawQuery, ok := strings.Cut(path, "?")
+	if !ok {
+		return ""
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	return query.Get("region")
+}
+
+// destinationURL resolves a destination against the console root.
+func destinationURL(consoleURL, destination string) string {
+	if strings.HasPrefix(destination, "https://") {
+		return destination
+	}
+	return strings.TrimSuffix(consoleURL, "/") + "/" + strings.TrimPrefix(destination, "/")
 }
@@ -3,11 +3,14 @@ package aws
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 type fakeHTTPClient struct {
@@ -44,6 +47,9 @@ func TestFederationClientBuildConsoleURL(t *testing.T) {
 				if parsed.Query().Get("SigninToken") != "token-123" {
 					t.Fatalf("unexpected sign-in token: %q", parsed.Query().Get("SigninToken"))
 				}
+				if parsed.Query().Get("Destination") != "https://console.aws.amazon.com/health/home" {
+					t.Fatalf("unexpected destination: %q", parsed.Query().Get("Destination"))
+				}
 			},
 		},
 		{
@@ -85,7 +91,7 @@ func TestFederationClientBuildConsoleURL(t *testing.T) {
 				AccessKeyID:     "AKIA_TEST",
 				SecretAccessKey: "secret",
 				SessionToken:    "token",
-			}, 3600)
+			}, 3600, "health/home")
 
 			if tc.wantErrSubstr != "" {
 				if err == nil {
@@ -108,6 +114,41 @@ func TestFederationClientBuildConsoleURL(t *testing.T) {
 	}
 }
 
+func TestFederationClientSessionDuration(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		durationSeconds int32
+		want            []string
+	}{
+		{name: "requested", durationSeconds: 3600, want: []string{"3600"}},
+		{name: "omitted", durationSeconds: 0},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query()["SessionDuration"]
+				_, _ = w.Write([]byte(`{"SigninToken":"token-123"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			client := newFederationClient(server.Client(), server.URL, "https://console.aws.amazon.com/")
+			if _, err := client.BuildConsoleURL(context.Background(), Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, tc.durationSeconds, ""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("SessionDuration = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestFederationClientBuildConsoleURLClientError(t *testing.T) {
 	t.Parallel()
 
@@ -121,7 +162,7 @@ func TestFederationClientBuildConsoleURLClientError(t *testing.T) {
 		AccessKeyID:     "AKIA_TEST",
 		SecretAccessKey: "secret",
 		SessionToken:    "token",
-	}, 3600)
+	}, 3600, "")
 	if err == nil {
 		t.Fatal("expected error but got nil")
 	}
@@ -129,3 +170,469 @@ func TestFederationClientBuildConsoleURLClientError(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestFederationClientDestinationURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"":                                   "https://console.aws.amazon.com/",
+		"trustedadvisor/home":                "https://console.aws.amazon.com/trustedadvisor/home",
+		"/servicequotas/home":                "https://console.aws.amazon.com/servicequotas/home",
+		"https://health.aws.amazon.com/home": "https://health.aws.amazon.com/home",
+	}
+	for destination, want := range testCases {
+		if got := destinationURL("https://console.aws.amazon.com/", destination); got != want {
+			t.Fatalf("destinationURL(%q) = %q, want %q", destination, got, want)
+		}
+	}
+}
+
+func TestFederationClientBuildConsoleURLsReusesToken(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"token-123"}`)),
+		}, nil
+	}}, defaultFederationURL, defaultConsoleURL)
+
+	destinations := []string{"ec2/home?region=us-east-1", "ec2/home?region=eu-west-1"}
+	wantHosts := []string{"https://us-east-1.console.aws.amazon.com/", "https://eu-west-1.console.aws.amazon.com/"}
+	urls, err := client.BuildConsoleURLs(context.Background(), Credentials{AccessKeyID: "ASIA"}, 3600, destinations)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected a single token request, got %d", requests)
+	}
+	if len(urls) != len(destinations) {
+		t.Fatalf("expected %d URLs, got %d", len(destinations), len(urls))
+	}
+	for i, loginURL := range urls {
+		parsed, err := url.Parse(loginURL)
+		if err != nil {
+			t.Fatalf("failed to parse login URL: %v", err)
+		}
+		if got, want := parsed.Query().Get("Destination"), wantHosts[i]+destinations[i]; got != want {
+			t.Fatalf("URL %d: expected destination %q, got %q", i, want, got)
+		}
+		if parsed.Query().Get("SigninToken") != "token-123" {
+			t.Fatalf("URL %d: unexpected sign-in token %q", i, parsed.Query().Get("SigninToken"))
+		}
+	}
+}
+
+func TestFederationClientIssuer(t *testing.T) {
+	t.Parallel()
+
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"token-123"}`)),
+		}, nil
+	}}, defaultFederationURL, defaultConsoleURL)
+
+	testCases := map[string]string{
+		"": DefaultIssuer,
+		"http://127.0.0.1:17345/reauth?profile=dev&destination=ec2%2Fhome": "http://127.0.0.1:17345/reauth?profile=dev&destination=ec2%2Fhome",
+	}
+	for issuer, want := range testCases {
+		ctx := WithIssuer(context.Background(), issuer)
+		loginURL, err := client.BuildConsoleURL(ctx, Credentials{AccessKeyID: "ASIA"}, 3600, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		parsed, err := url.Parse(loginURL)
+		if err != nil {
+			t.Fatalf("failed to parse login URL: %v", err)
+		}
+		if got := parsed.Query().Get("Issuer"); got != want {
+			t.Fatalf("expected issuer %q, got %q", want, got)
+		}
+	}
+}
+
+func TestValidateIssuer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		issuer  string
+		wantErr bool
+	}{
+		{issuer: ""},
+		{issuer: "acme-sso"},
+		{issuer: "https://sso.example.com/aws?app=console"},
+		{issuer: "http://intranet/aws"},
+		{issuer: "ftp://sso.example.com/", wantErr: true},
+		{issuer: "https:///aws", wantErr: true},
+	}
+	for _, tc := range testCases {
+		err := ValidateIssuer(tc.issuer)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("ValidateIssuer(%q) = %v, want error %v", tc.issuer, err, tc.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "invalid issuer URL") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestFederationClientRegionalConsoleURL(t *testing.T) {
+	t.Parallel()
+
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"token-123"}`)),
+		}, nil
+	}}, defaultFederationURL, defaultConsoleURL)
+	client.partitions = partitionEndpoints
+
+	testCases := []struct {
+		name        string
+		region      string
+		partition   string
+		destination string
+		want        string
+	}{
+		{
+			name:        "no region",
+			destination: "ec2/home",
+			want:        "https://console.aws.amazon.com/ec2/home",
+		},
+		{
+			name:        "context region",
+			region:      "us-west-2",
+			destination: "ec2/home",
+			want:        "https://us-west-2.console.aws.amazon.com/ec2/home",
+		},
+		{
+			name:        "destination region wins",
+			region:      "us-west-2",
+			destination: "cloudshell/home?region=eu-west-1",
+			want:        "https://eu-west-1.console.aws.amazon.com/cloudshell/home?region=eu-west-1",
+		},
+		{
+			name:      "govcloud",
+			region:    "us-gov-west-1",
+			partition: PartitionUSGov,
+			want:      "https://us-gov-west-1.console.amazonaws-us-gov.com/",
+		},
+		{
+			name:        "absolute destination is kept",
+			region:      "us-west-2",
+			destination: "https://console.aws.amazon.com/s3/home",
+			want:        "https://console.aws.amazon.com/s3/home",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := WithPartition(WithRegion(context.Background(), tc.region), tc.partition)
+			loginURL, err := client.BuildConsoleURL(ctx, Credentials{AccessKeyID: "ASIA"}, 3600, tc.destination)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			parsed, err := url.Parse(loginURL)
+			if err != nil {
+				t.Fatalf("failed to parse login URL: %v", err)
+			}
+			if got := parsed.Query().Get("Destination"); got != tc.want {
+				t.Fatalf("expected destination %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+type memoryTokenCache map[string]string
+
+func (c memoryTokenCache) SigninToken(key string) (string, bool) {
+	token, ok := c[key]
+	return token, ok
+}
+
+func (c memoryTokenCache) PutSigninToken(key, token string, expires time.Time) error {
+	c[key] = token
+	return nil
+}
+
+func TestFederationClientCachesSigninToken(t *testing.T) {
+	t.Parallel()
+
+	requests := 0
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"token-123"}`)),
+		}, nil
+	}}, defaultFederationURL, defaultConsoleURL)
+
+	ctx := WithSigninTokenCache(context.Background(), memoryTokenCache{})
+	creds := Credentials{AccessKeyID: "ASIA", Expires: time.Now().Add(time.Hour)}
+	for i := 0; i < 2; i++ {
+		if _, err := client.BuildConsoleURL(ctx, creds, 3600, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected the cached token to be reused, got %d requests", requests)
+	}
+
+	if _, err := client.BuildConsoleURL(ctx, creds, 7200, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected a new token for a different duration, got %d requests", requests)
+	}
+
+	if _, err := client.BuildConsoleURL(WithSigninTokenScope(ctx, "read-only"), creds, 3600, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 3 {
+		t.Fatalf("expected a new token for a different scope, got %d requests", requests)
+	}
+
+	if _, err := client.BuildConsoleURL(WithPartition(ctx, PartitionUSGov), creds, 3600, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 4 {
+		t.Fatalf("expected a new token for a different partition, got %d requests", requests)
+	}
+}
+
+func TestFederationClientRetries(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		responses     []int
+		doErr         error
+		wantRequests  int
+		wantDelays    []time.Duration
+		wantErrSubstr string
+		wantErr       error
+	}{
+		{
+			name:         "retries throttling and server errors",
+			responses:    []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK},
+			wantRequests: 3,
+			wantDelays:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name:          "gives up after max attempts",
+			responses:     []int{500, 502, 503, 504, 200},
+			wantRequests:  4,
+			wantDelays:    []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond},
+			wantErrSubstr: "federation endpoint returned HTTP 504",
+		},
+		{
+			name:          "throttled on every attempt",
+			responses:     []int{429, 429, 429, 429},
+			wantRequests:  4,
+			wantDelays:    []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond},
+			wantErrSubstr: "the federation endpoint is throttling requests; try again in about 30s",
+			wantErr:       ErrFederationThrottled,
+		},
+		{
+			name:          "client errors are not retried",
+			responses:     []int{http.StatusBadRequest},
+			wantRequests:  1,
+			wantErrSubstr: "federation endpoint returned HTTP 400",
+		},
+		{
+			name:          "retries transport errors",
+			doErr:         errors.New("connection reset by peer"),
+			wantRequests:  4,
+			wantDelays:    []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond},
+			wantErrSubstr: "failed to request signin token: connection reset by peer",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := 0
+			client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+				requests++
+				if tc.doErr != nil {
+					return nil, tc.doErr
+				}
+				status := tc.responses[requests-1]
+				body := `{"SigninToken":"token-123"}`
+				if status != http.StatusOK {
+					body = "try again"
+				}
+				return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+			}}, defaultFederationURL, defaultConsoleURL)
+			client.Retry = RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+			client.jitter = func(max time.Duration) time.Duration { return max }
+			var delays []time.Duration
+			client.sleep = func(ctx context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			loginURL, err := client.BuildConsoleURL(context.Background(), Credentials{AccessKeyID: "ASIA"}, 3600, "")
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v to match %v", err, tc.wantErr)
+				}
+			} else if err != nil || !strings.Contains(loginURL, "SigninToken=token-123") {
+				t.Fatalf("unexpected result %q, %v", loginURL, err)
+			}
+			if requests != tc.wantRequests {
+				t.Fatalf("expected %d requests, got %d", tc.wantRequests, requests)
+			}
+			if len(delays) != len(tc.wantDelays) {
+				t.Fatalf("expected delays %v, got %v", tc.wantDelays, delays)
+			}
+			for i := range delays {
+				if delays[i] != tc.wantDelays[i] {
+					t.Fatalf("expected delays %v, got %v", tc.wantDelays, delays)
+				}
+			}
+		})
+	}
+}
+
+func TestFederationClientRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		status        int
+		retryAfter    string
+		wantRequests  int
+		wantDelays    []time.Duration
+		wantErrSubstr string
+	}{
+		{
+			name:          "waits as long as asked",
+			status:        http.StatusTooManyRequests,
+			retryAfter:    "2",
+			wantRequests:  3,
+			wantDelays:    []time.Duration{2 * time.Second, 2 * time.Second},
+			wantErrSubstr: "the federation endpoint is throttling requests; try again in about 2s",
+		},
+		{
+			name:          "gives up when asked to wait too long",
+			status:        http.StatusServiceUnavailable,
+			retryAfter:    "120",
+			wantRequests:  1,
+			wantErrSubstr: "the federation endpoint is throttling requests; try again in about 2m0s",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := 0
+			client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+				requests++
+				header := http.Header{"Retry-After": []string{tc.retryAfter}}
+				return &http.Response{StatusCode: tc.status, Header: header, Body: io.NopCloser(strings.NewReader("Rate exceeded"))}, nil
+			}}, defaultFederationURL, defaultConsoleURL)
+			client.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+			var delays []time.Duration
+			client.sleep = func(ctx context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			}
+
+			_, err := client.BuildConsoleURL(context.Background(), Credentials{AccessKeyID: "ASIA"}, 3600, "")
+			if err == nil || err.Error() != tc.wantErrSubstr || !errors.Is(err, ErrThrottled) || !errors.Is(err, ErrFederationThrottled) {
+				t.Fatalf("expected %q, got %v", tc.wantErrSubstr, err)
+			}
+			if requests != tc.wantRequests || fmt.Sprint(delays) != fmt.Sprint(tc.wantDelays) {
+				t.Fatalf("expected %d requests after %v, got %d after %v", tc.wantRequests, tc.wantDelays, requests, delays)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Duration{
+		"":                              0,
+		"30":                            30 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Sun, 01 Mar 2026 09:01:30 GMT": 90 * time.Second,
+		"Sun, 01 Mar 2026 08:59:00 GMT": 0,
+	} {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Fatalf("parseRetryAfter(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestFederationClientRetryStopsWhenCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}}, defaultFederationURL, defaultConsoleURL)
+	client.Retry = DefaultRetryPolicy
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	_, err := client.BuildConsoleURL(ctx, Credentials{AccessKeyID: "ASIA"}, 3600, "")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the retry to stop on cancellation, got %v", err)
+	}
+}
+
+func TestFederationClientTimeout(t *testing.T) {
+	t.Parallel()
+
+	var deadline time.Duration
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		d, _ := req.Context().Deadline()
+		deadline = time.Until(d)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}}, defaultFederationURL, defaultConsoleURL)
+
+	ctx := WithHTTPTimeout(context.Background(), 20*time.Millisecond)
+	_, err := client.BuildConsoleURL(ctx, Credentials{AccessKeyID: "ASIA"}, 3600, "")
+	if err == nil || !strings.Contains(err.Error(), "failed to request signin token: timed out after 20ms") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if deadline <= 0 || deadline > 20*time.Millisecond {
+		t.Fatalf("expected the request deadline to follow the context timeout, got %s", deadline)
+	}
+}
+
+func TestHTTPTimeoutFromContext(t *testing.T) {
+	t.Parallel()
+
+	if got := HTTPTimeoutFromContext(context.Background()); got != DefaultHTTPTimeout {
+		t.Fatalf("expected the default timeout, got %s", got)
+	}
+	if got := HTTPTimeoutFromContext(WithHTTPTimeout(context.Background(), 0)); got != DefaultHTTPTimeout {
+		t.Fatalf("expected a zero timeout to be ignored, got %s", got)
+	}
+	if got := HTTPTimeoutFromContext(WithHTTPTimeout(context.Background(), time.Minute)); got != time.Minute {
+		t.Fatalf("expected 1m, got %s", got)
+	}
+}
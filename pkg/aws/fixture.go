@@ -0,0 +1,168 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RecordEnv names the environment variable that records federation exchanges: "1" for
+// a file in the state directory, or the path of the file to write.
+const RecordEnv = "AWS_CONSOLE_RECORD"
+
+// fixtureHeaders are the response headers kept in fixtures. Others, such as request IDs,
+// cookies, and dates, change with every exchange or identify the caller.
+var fixtureHeaders = []string{"Content-Type", "Retry-After"}
+
+// Exchange is a federation request and its response, sanitized so that it holds no
+// credentials or sign-in tokens and can be committed as a test fixture.
+type Exchange struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// RecordingTransport sends requests through its next transport and writes each exchange,
+// sanitized, to a JSON fixture file that a ReplayTransport can serve.
+type RecordingTransport struct {
+	path      string
+	next      http.RoundTripper
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewRecordingTransport returns a transport that records the exchanges of next to path,
+// replacing the file. A nil next uses http.DefaultTransport.
+func NewRecordingTransport(path string, next http.RoundTripper) *RecordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &RecordingTransport{path: path, next: next}
+}
+
+// Path returns the fixture file exchanges are recorded to.
+func (t *RecordingTransport) Path() string {
+	return t.path
+}
+
+// RoundTrip sends req and records its exchange. Failing to write the fixture fails the
+// request, so that a recording is never silently incomplete.
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read federation response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange := Exchange{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Status: resp.StatusCode,
+		Body:   string(signinTokenJSON.ReplaceAll(body, []byte("${1}"+redacted+"${2}"))),
+	}
+	for _, name := range fixtureHeaders {
+		if v := resp.Header.Values(name); len(v) > 0 {
+			if exchange.Header == nil {
+				exchange.Header = http.Header{}
+			}
+			exchange.Header[name] = v
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchanges = append(t.exchanges, exchange)
+	if err := writeFixture(t.path, t.exchanges); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func writeFixture(path string, exchanges []Exchange) error {
+	data, err := json.MarshalIndent(exchanges, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode federation fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to write federation fixture: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write federation fixture: %w", err)
+	}
+	return nil
+}
+
+// ReplayTransport answers requests from recorded exchanges without touching the network.
+// Requests match an exchange by method and sanitized URL, so any credentials match; the
+// exchanges of a URL are served in the order they were recorded, and the last one again
+// once they run out.
+type ReplayTransport struct {
+	mu        sync.Mutex
+	exchanges map[string][]Exchange
+}
+
+// NewReplayTransport reads the fixture file at path, as written by a RecordingTransport.
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read federation fixture: %w", err)
+	}
+	var exchanges []Exchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("failed to parse federation fixture %s: %w", path, err)
+	}
+	t := &ReplayTransport{exchanges: map[string][]Exchange{}}
+	for _, e := range exchanges {
+		key := e.Method + " " + e.URL
+		t.exchanges[key] = append(t.exchanges[key], e)
+	}
+	return t, nil
+}
+
+// RoundTrip returns the next recorded response to req.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := req.Method + " " + redactURL(req.URL)
+
+	t.mu.Lock()
+	queue := t.exchanges[key]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("no recorded federation exchange for %s; record one with %s=1", key, RecordEnv)
+	}
+	e := queue[0]
+	if len(queue) > 1 {
+		t.exchanges[key] = queue[1:]
+	}
+	t.mu.Unlock()
+
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(e.Body))),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}, nil
+}
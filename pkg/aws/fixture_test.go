@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordingTransportSanitizesExchanges(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "aws-creds=secret")
+		w.Header().Set("X-Amzn-Requestid", "f00")
+		w.Write([]byte(`{"SigninToken":"live-token"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "federation.json")
+	recorder := NewRecordingTransport(path, nil)
+	client := NewFederationClient().WithTransport(recorder)
+	ctx := WithFederationEndpoint(context.Background(), server.URL+"/federation")
+	creds := Credentials{AccessKeyID: "ASIALIVE", SecretAccessKey: "live-secret", SessionToken: "live-session"}
+
+	loginURL, err := client.BuildConsoleURL(ctx, creds, 3600, "")
+	if err != nil || !strings.Contains(loginURL, "SigninToken=live-token") {
+		t.Fatalf("expected the live response to be passed through, got %q, %v", loginURL, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the fixture: %v", err)
+	}
+	for _, secret := range []string{"ASIALIVE", "live-secret", "live-session", "live-token", "aws-creds", "f00"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("expected %q to be sanitized from the fixture:\n%s", secret, data)
+		}
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected the fixture to be private, got %v", info.Mode())
+	}
+
+	replay, err := NewReplayTransport(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	creds = Credentials{AccessKeyID: "ASIAOTHER", SecretAccessKey: "other", SessionToken: "other"}
+	loginURL, err = NewFederationClient().WithTransport(replay).BuildConsoleURL(ctx, creds, 3600, "")
+	if err != nil || !strings.Contains(loginURL, "SigninToken=REDACTED") {
+		t.Fatalf("expected the recorded exchange to be replayed, got %q, %v", loginURL, err)
+	}
+}
+
+func TestFederationFixtures(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		fixture       string
+		partition     string
+		duration      int32
+		destination   string
+		wantURL       string
+		wantRetries   int
+		wantErrSubstr string
+	}{
+		{
+			fixture:     "signin.json",
+			duration:    43200,
+			destination: "s3",
+			wantURL:     "https://signin.aws.amazon.com/federation?Action=login&Issuer=aws-console-cli&Destination=https%3A%2F%2Fconsole.aws.amazon.com%2Fs3&SigninToken=REDACTED",
+		},
+		{
+			fixture:     "throttled.json",
+			duration:    3600,
+			wantURL:     "https://signin.aws.amazon.com/federation?Action=login&Issuer=aws-console-cli&Destination=https%3A%2F%2Fconsole.aws.amazon.com%2F&SigninToken=REDACTED",
+			wantRetries: 1,
+		},
+		{
+			fixture:   "govcloud.json",
+			partition: PartitionUSGov,
+			duration:  3600,
+			wantURL:   "https://signin.amazonaws-us-gov.com/federation?Action=login&Issuer=aws-console-cli&Destination=https%3A%2F%2Fconsole.amazonaws-us-gov.com%2F&SigninToken=REDACTED",
+		},
+		{
+			fixture:       "rejected.json",
+			duration:      3600,
+			wantErrSubstr: "federation endpoint returned HTTP 400: Bad Request",
+		},
+		{
+			fixture:       "signin.json",
+			duration:      3600,
+			wantErrSubstr: "no recorded federation exchange for GET https://signin.aws.amazon.com/federation?Action=getSigninToken&Session=REDACTED&SessionDuration=3600",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(strings.TrimSuffix(tc.fixture, ".json"), func(t *testing.T) {
+			t.Parallel()
+
+			replay, err := NewReplayTransport(filepath.Join("testdata", "federation", tc.fixture))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			client := NewFederationClient().WithTransport(replay)
+			client.Retry.MaxAttempts = 2
+			retries := 0
+			client.sleep = func(ctx context.Context, d time.Duration) error {
+				retries++
+				return nil
+			}
+			ctx := WithPartition(context.Background(), tc.partition)
+			creds := Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}
+
+			loginURL, err := client.BuildConsoleURL(ctx, creds, tc.duration, tc.destination)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if loginURL != tc.wantURL {
+				t.Fatalf("unexpected login URL\ngot:  %s\nwant: %s", loginURL, tc.wantURL)
+			}
+			if _, err := url.Parse(loginURL); err != nil {
+				t.Fatalf("invalid login URL: %v", err)
+			}
+			if retries != tc.wantRetries {
+				t.Fatalf("expected %d retries, got %d", tc.wantRetries, retries)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// redactedQueryParams carry credentials or sign-in tokens in federation URLs.
+var redactedQueryParams = []string{"Session", "SigninToken"}
+
+var signinTokenJSON = regexp.MustCompile(`("SigninToken"\s*:\s*")[^"]*(")`)
+
+type httpDebugKey struct{}
+
+// WithHTTPDebug returns a context that makes the federation client log each request
+// and response to w, with credentials and sign-in tokens redacted.
+func WithHTTPDebug(ctx context.Context, w io.Writer) context.Context {
+	if w == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, httpDebugKey{}, w)
+}
+
+// HTTPDebugFromContext returns the writer set with WithHTTPDebug, if any.
+func HTTPDebugFromContext(ctx context.Context) io.Writer {
+	w, _ := ctx.Value(httpDebugKey{}).(io.Writer)
+	return w
+}
+
+// doHTTP sends req, logging its latency at debug level and dumping the exchange when
+// the request context asks for it.
+func doHTTP(client federationHTTPClient, req *http.Request) (*http.Response, error) {
+	w := HTTPDebugFromContext(req.Context())
+	if w == nil {
+		start := time.Now()
+		resp, err := client.Do(req)
+		logFederationRequest(req, resp, err, time.Since(start))
+		return resp, err
+	}
+
+	fmt.Fprintf(w, "> %s %s\n", req.Method, redactURL(req.URL))
+	writeHeaders(w, "> ", req.Header)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	logFederationRequest(req, resp, err, time.Since(start))
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(w, "< error after %s: %v\n", latency, err)
+		return nil, err
+	}
+
+	fmt.Fprintf(w, "< %s %s (%s)\n", resp.Proto, resp.Status, latency)
+	writeHeaders(w, "< ", resp.Header)
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read federation response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	fmt.Fprintf(w, "<\n%s\n", signinTokenJSON.ReplaceAll(body, []byte("${1}"+redacted+"${2}")))
+
+	return resp, nil
+}
+
+func logFederationRequest(req *http.Request, resp *http.Response, err error, latency time.Duration) {
+	attrs := []any{"method", req.Method, "url", redactURL(req.URL), "duration", latency}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	} else {
+		attrs = append(attrs, "status", resp.StatusCode)
+	}
+	LoggerFromContext(req.Context()).Debug("federation request", attrs...)
+}
+
+func redactURL(u *url.URL) string {
+	clone := *u
+	query := clone.Query()
+	for _, name := range redactedQueryParams {
+		if query.Has(name) {
+			query.Set(name, redacted)
+		}
+	}
+	clone.RawQuery = query.Encode()
+	return clone.String()
+}
+
+func writeHeaders(w io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range header[name] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, name, v)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFederationClientHTTPDebug(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		debug        bool
+		doErr        error
+		wantContains []string
+	}{
+		{
+			name:  "dumps redacted exchange",
+			debug: true,
+			wantContains: []string{
+				"> GET https://signin.aws.amazon.com/federation?Action=getSigninToken&Session=REDACTED&SessionDuration=3600",
+				"< HTTP/1.1 200 OK (",
+				"< Content-Type: application/json",
+				`{"SigninToken":"REDACTED"}`,
+			},
+		},
+		{
+			name:         "dumps transport errors",
+			debug:        true,
+			doErr:        errors.New("proxy refused"),
+			wantContains: []string{"< error after", "proxy refused"},
+		},
+		{
+			name: "disabled",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+				if tc.doErr != nil {
+					return nil, tc.doErr
+				}
+				return &http.Response{
+					Proto:      "HTTP/1.1",
+					Status:     "200 OK",
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"secret-token"}`)),
+				}, nil
+			}}, defaultFederationURL, defaultConsoleURL)
+
+			dump := &bytes.Buffer{}
+			ctx := context.Background()
+			if tc.debug {
+				ctx = WithHTTPDebug(ctx, dump)
+			}
+
+			creds := Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret-key", SessionToken: "session-token"}
+			loginURL, err := client.BuildConsoleURL(ctx, creds, 3600, "")
+			if (err != nil) != (tc.doErr != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.doErr == nil && !strings.Contains(loginURL, "SigninToken=secret-token") {
+				t.Fatalf("expected response body to still be readable, got URL %q", loginURL)
+			}
+
+			out := dump.String()
+			if !tc.debug && out != "" {
+				t.Fatalf("expected no dump, got %q", out)
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out, want) {
+					t.Fatalf("expected dump to contain %q, got:\n%s", want, out)
+				}
+			}
+			for _, secret := range []string{"ASIAEXAMPLE", "secret-key", "session-token", "secret-token"} {
+				if strings.Contains(out, secret) {
+					t.Fatalf("expected dump not to contain %q, got:\n%s", secret, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"context"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// KeySourceProvider is the provider name of credentials from a KeySource.
+const KeySourceProvider = "KeySourceProvider"
+
+// KeySource supplies access keys from outside the shared AWS config, such as a password
+// manager. It is called when an AWS call first needs credentials. Keys with a session
+// token are temporary and expire at their Expires, when set.
+type KeySource func(ctx context.Context) (Credentials, error)
+
+type keySourceKey struct{}
+
+// keySource is a KeySource in a context, and the profile it is limited to.
+type keySource struct {
+	source  KeySource
+	profile string
+	scoped  bool
+}
+
+// WithKeySource returns a context that makes Service calls sign with the keys from
+// source instead of the credentials of the profile they name. The profile's other
+// settings, such as its region, still apply.
+func WithKeySource(ctx context.Context, source KeySource) context.Context {
+	return context.WithValue(ctx, keySourceKey{}, keySource{source: source})
+}
+
+// WithProfileKeySource is like WithKeySource, but only for Service calls that name
+// profile; calls for other profiles, such as a fallback profile, use their own
+// credentials.
+func WithProfileKeySource(ctx context.Context, profile string, source KeySource) context.Context {
+	return context.WithValue(ctx, keySourceKey{}, keySource{source: source, profile: profile, scoped: true})
+}
+
+// KeySourceFromContext returns the source set with WithKeySource or
+// WithProfileKeySource, if any.
+func KeySourceFromContext(ctx context.Context) (KeySource, bool) {
+	v, ok := ctx.Value(keySourceKey{}).(keySource)
+	return v.source, ok && v.source != nil
+}
+
+// KeySourceFor returns the source that signs Service calls for profile, if any.
+func KeySourceFor(ctx context.Context, profile string) (KeySource, bool) {
+	v, ok := ctx.Value(keySourceKey{}).(keySource)
+	if !ok || v.source == nil || v.scoped && v.profile != profile {
+		return nil, false
+	}
+	return v.source, true
+}
+
+// keySourceProvider returns a provider of the keys from source for SDK clients.
+func keySourceProvider(source KeySource) awsv2.CredentialsProvider {
+	return awsv2.NewCredentialsCache(awsv2.CredentialsProviderFunc(func(ctx context.Context) (awsv2.Credentials, error) {
+		creds, err := source(ctx)
+		if err != nil {
+			return awsv2.Credentials{}, err
+		}
+		return awsv2.Credentials{
+			AccessKeyID:     creds.AccessKeyID,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+			Source:          KeySourceProvider,
+			CanExpire:       !creds.Expires.IsZero(),
+			Expires:         creds.Expires,
+		}, nil
+	}))
+}
@@ -0,0 +1,98 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+func TestLoadConfigUsesContextKeySource(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := KeySourceFromContext(context.Background()); ok {
+		t.Fatal("expected no key source")
+	}
+
+	calls := 0
+	ctx := WithKeySource(context.Background(), func(ctx context.Context) (Credentials, error) {
+		calls++
+		return Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret"}, nil
+	})
+	got := &config.LoadOptions{}
+	svc := newSDKService(optionsRecordingLoader{got: got}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+	if _, err := svc.loadConfig(ctx, "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.SharedConfigProfile != "dev" {
+		t.Fatalf("expected the profile's settings to still apply, got profile %q", got.SharedConfigProfile)
+	}
+	if got.Credentials == nil {
+		t.Fatal("expected a credentials provider")
+	}
+	if calls != 0 {
+		t.Fatal("expected the key source not to be called until credentials are needed")
+	}
+
+	creds, err := got.Credentials.Retrieve(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.AccessKeyID != "AKIAEXAMPLE" || creds.Source != KeySourceProvider || creds.CanExpire {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+	if CredentialSourceOf(creds.Source) != CredentialSourceKeys {
+		t.Fatalf("expected keys from a key source to be federated as long-lived keys")
+	}
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	ctx = WithKeySource(context.Background(), func(ctx context.Context) (Credentials, error) {
+		return Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token", Expires: expires}, nil
+	})
+	got = &config.LoadOptions{}
+	svc = newSDKService(optionsRecordingLoader{got: got}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+	if _, err := svc.loadConfig(ctx, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds, err = got.Credentials.Retrieve(ctx); err != nil || !creds.CanExpire || !creds.Expires.Equal(expires) {
+		t.Fatalf("expected temporary keys to expire at %s, got %+v, %v", expires, creds, err)
+	}
+
+	ctx = WithKeySource(context.Background(), func(ctx context.Context) (Credentials, error) {
+		return Credentials{}, errors.New("vault is locked")
+	})
+	got = &config.LoadOptions{}
+	svc = newSDKService(optionsRecordingLoader{got: got}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+	if _, err := svc.loadConfig(ctx, "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := got.Credentials.Retrieve(ctx); err == nil {
+		t.Fatal("expected the key source's error")
+	}
+}
+
+func TestLoadConfigScopedKeySource(t *testing.T) {
+	t.Parallel()
+
+	ctx := WithProfileKeySource(context.Background(), "dev", func(ctx context.Context) (Credentials, error) {
+		return Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret"}, nil
+	})
+	for _, tc := range []struct {
+		profile string
+		want    bool
+	}{
+		{profile: "dev", want: true},
+		{profile: "breakglass", want: false},
+	} {
+		got := &config.LoadOptions{}
+		svc := newSDKService(optionsRecordingLoader{got: got}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+		if _, err := svc.loadConfig(ctx, tc.profile); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if (got.Credentials != nil) != tc.want {
+			t.Fatalf("key source for %s = %v, want %v", tc.profile, got.Credentials != nil, tc.want)
+		}
+	}
+}
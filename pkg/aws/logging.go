@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+type loggerKey struct{}
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// WithLogger returns a context that makes Service and federation calls log to l.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	if l == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the logger set with WithLogger, or one that discards
+// everything.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return discardLogger
+}
+
+// logAPICalls adds a middleware that logs every AWS API call at debug level, with its
+// latency including retries.
+func logAPICalls(l *slog.Logger) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("aws-console.LogAPICall",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, md, err := next.HandleInitialize(ctx, in)
+				attrs := []any{
+					"service", awsmiddleware.GetServiceID(ctx),
+					"operation", awsmiddleware.GetOperationName(ctx),
+					"region", awsmiddleware.GetRegion(ctx),
+					"duration", time.Since(start),
+				}
+				if requestID, ok := awsmiddleware.GetRequestIDMetadata(md); ok {
+					attrs = append(attrs, "request_id", requestID)
+				}
+				if err != nil {
+					attrs = append(attrs, "error", err)
+				}
+				l.Debug("AWS API call", attrs...)
+				return out, md, err
+			}), middleware.After)
+	}
+}
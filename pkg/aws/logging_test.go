@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+)
+
+func TestLoggerFromContext(t *testing.T) {
+	t.Parallel()
+
+	if LoggerFromContext(context.Background()).Enabled(context.Background(), slog.LevelError) {
+		t.Fatal("expected the default logger to discard records")
+	}
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if got := LoggerFromContext(WithLogger(context.Background(), l)); got != l {
+		t.Fatal("expected the context logger to be returned")
+	}
+}
+
+func TestLogAPICalls(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		err          error
+		wantContains []string
+	}{
+		{
+			name:         "success",
+			wantContains: []string{"msg=\"AWS API call\"", "service=STS", "operation=GetCallerIdentity", "region=us-east-1", "duration="},
+		},
+		{
+			name:         "failure",
+			err:          errors.New("access denied"),
+			wantContains: []string{"operation=GetCallerIdentity", "error=\"access denied\""},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			stack := middleware.NewStack("GetCallerIdentity", func() interface{} { return nil })
+			if err := stack.Initialize.Add(&awsmiddleware.RegisterServiceMetadata{
+				ServiceID:     "STS",
+				OperationName: "GetCallerIdentity",
+				Region:        "us-east-1",
+			}, middleware.Before); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := logAPICalls(slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug})))(stack); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			handler := middleware.DecorateHandler(middleware.HandlerFunc(func(ctx context.Context, in interface{}) (interface{}, middleware.Metadata, error) {
+				return nil, middleware.Metadata{}, tc.err
+			}), stack)
+			if _, _, err := handler.Handle(context.Background(), struct{}{}); !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(out.String(), want) {
+					t.Fatalf("expected log to contain %q, got %q", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFederationClientLogsRequests(t *testing.T) {
+	t.Parallel()
+
+	client := newFederationClient(fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"secret-token"}`)),
+		}, nil
+	}}, defaultFederationURL, defaultConsoleURL)
+
+	out := &bytes.Buffer{}
+	ctx := WithLogger(context.Background(), slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	creds := Credentials{AccessKeyID: "ASIAEXAMPLE", SecretAccessKey: "secret-key", SessionToken: "session-token"}
+	if _, err := client.BuildConsoleURL(ctx, creds, 3600, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{"msg=\"federation request\"", "method=GET", "Session=REDACTED", "status=200", "duration="} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected log to contain %q, got %q", want, got)
+		}
+	}
+	for _, secret := range []string{"secret-key", "session-token", "secret-token"} {
+		if strings.Contains(got, secret) {
+			t.Fatalf("log leaked %q: %q", secret, got)
+		}
+	}
+}
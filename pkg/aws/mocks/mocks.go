@@ -3,22 +3,45 @@ package mocks
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
 	awslib "github.com/eculver/aws-console/pkg/aws"
 )
 
 type Service struct {
-	GetCallerIdentityFunc   func(ctx context.Context, profile string) (awslib.Identity, error)
-	RetrieveCredentialsFunc func(ctx context.Context, profile string) (awslib.Credentials, error)
-	GetSessionTokenFunc     func(ctx context.Context, profile string, durationSeconds int32) (awslib.Credentials, error)
+	GetCallerIdentityFunc         func(ctx context.Context, profile string) (awslib.Identity, error)
+	RetrieveCredentialsFunc       func(ctx context.Context, profile string) (awslib.Credentials, error)
+	GetSessionTokenFunc           func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error)
+	ListMFADevicesFunc            func(ctx context.Context, profile string) ([]string, error)
+	SimulatePrincipalPolicyFunc   func(ctx context.Context, profile string, principalARN string, actions []string) (map[string]bool, error)
+	GetAccountAliasFunc           func(ctx context.Context, profile string) (string, error)
+	DescribeAccountFunc           func(ctx context.Context, profile string, accountID string) (awslib.AccountInfo, error)
+	ListAccountsFunc              func(ctx context.Context, profile string) ([]awslib.OrganizationAccount, error)
+	AssumeRoleFunc                func(ctx context.Context, profile string, input awslib.AssumeRoleInput) (awslib.Credentials, error)
+	GetFederationTokenFunc        func(ctx context.Context, profile string, input awslib.FederationTokenInput) (awslib.Credentials, error)
+	GetRoleARNFunc                func(ctx context.Context, profile string, sessionARN string) (string, error)
+	GetRoleCredentialsFunc        func(ctx context.Context, role awslib.SSORole) (awslib.Credentials, error)
+	AssumeRoleWithSAMLFunc        func(ctx context.Context, input awslib.SAMLRoleInput) (awslib.Credentials, error)
+	AssumeRoleWithWebIdentityFunc func(ctx context.Context, input awslib.WebIdentityRoleInput) (awslib.Credentials, error)
 
-	GetCallerIdentityCalls   int
-	RetrieveCredentialsCalls int
-	GetSessionTokenCalls     int
+	GetCallerIdentityCalls         atomic.Int64
+	RetrieveCredentialsCalls       atomic.Int64
+	GetSessionTokenCalls           atomic.Int64
+	ListMFADevicesCalls            atomic.Int64
+	SimulatePrincipalPolicyCalls   atomic.Int64
+	GetAccountAliasCalls           atomic.Int64
+	DescribeAccountCalls           atomic.Int64
+	ListAccountsCalls              atomic.Int64
+	AssumeRoleCalls                atomic.Int64
+	GetFederationTokenCalls        atomic.Int64
+	GetRoleARNCalls                atomic.Int64
+	GetRoleCredentialsCalls        atomic.Int64
+	AssumeRoleWithSAMLCalls        atomic.Int64
+	AssumeRoleWithWebIdentityCalls atomic.Int64
 }
 
 func (m *Service) GetCallerIdentity(ctx context.Context, profile string) (awslib.Identity, error) {
-	m.GetCallerIdentityCalls++
+	m.GetCallerIdentityCalls.Add(1)
 	if m.GetCallerIdentityFunc == nil {
 		return awslib.Identity{}, fmt.Errorf("GetCallerIdentityFunc is not set")
 	}
@@ -26,37 +49,173 @@ func (m *Service) GetCallerIdentity(ctx context.Context, profile string) (awslib
 }
 
 func (m *Service) RetrieveCredentials(ctx context.Context, profile string) (awslib.Credentials, error) {
-	m.RetrieveCredentialsCalls++
+	m.RetrieveCredentialsCalls.Add(1)
 	if m.RetrieveCredentialsFunc == nil {
 		return awslib.Credentials{}, fmt.Errorf("RetrieveCredentialsFunc is not set")
 	}
 	return m.RetrieveCredentialsFunc(ctx, profile)
 }
 
-func (m *Service) GetSessionToken(ctx context.Context, profile string, durationSeconds int32) (awslib.Credentials, error) {
-	m.GetSessionTokenCalls++
+func (m *Service) GetSessionToken(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
+	m.GetSessionTokenCalls.Add(1)
 	if m.GetSessionTokenFunc == nil {
 		return awslib.Credentials{}, fmt.Errorf("GetSessionTokenFunc is not set")
 	}
-	return m.GetSessionTokenFunc(ctx, profile, durationSeconds)
+	return m.GetSessionTokenFunc(ctx, profile, input)
+}
+
+func (m *Service) ListMFADevices(ctx context.Context, profile string) ([]string, error) {
+	m.ListMFADevicesCalls.Add(1)
+	if m.ListMFADevicesFunc == nil {
+		return nil, fmt.Errorf("ListMFADevicesFunc is not set")
+	}
+	return m.ListMFADevicesFunc(ctx, profile)
+}
+
+func (m *Service) SimulatePrincipalPolicy(ctx context.Context, profile string, principalARN string, actions []string) (map[string]bool, error) {
+	m.SimulatePrincipalPolicyCalls.Add(1)
+	if m.SimulatePrincipalPolicyFunc == nil {
+		return nil, fmt.Errorf("SimulatePrincipalPolicyFunc is not set")
+	}
+	return m.SimulatePrincipalPolicyFunc(ctx, profile, principalARN, actions)
+}
+
+func (m *Service) GetAccountAlias(ctx context.Context, profile string) (string, error) {
+	m.GetAccountAliasCalls.Add(1)
+	if m.GetAccountAliasFunc == nil {
+		return "", fmt.Errorf("GetAccountAliasFunc is not set")
+	}
+	return m.GetAccountAliasFunc(ctx, profile)
+}
+
+func (m *Service) DescribeAccount(ctx context.Context, profile string, accountID string) (awslib.AccountInfo, error) {
+	m.DescribeAccountCalls.Add(1)
+	if m.DescribeAccountFunc == nil {
+		return awslib.AccountInfo{}, fmt.Errorf("DescribeAccountFunc is not set")
+	}
+	return m.DescribeAccountFunc(ctx, profile, accountID)
+}
+
+func (m *Service) ListAccounts(ctx context.Context, profile string) ([]awslib.OrganizationAccount, error) {
+	m.ListAccountsCalls.Add(1)
+	if m.ListAccountsFunc == nil {
+		return nil, fmt.Errorf("ListAccountsFunc is not set")
+	}
+	return m.ListAccountsFunc(ctx, profile)
+}
+
+func (m *Service) AssumeRole(ctx context.Context, profile string, input awslib.AssumeRoleInput) (awslib.Credentials, error) {
+	m.AssumeRoleCalls.Add(1)
+	if m.AssumeRoleFunc == nil {
+		return awslib.Credentials{}, fmt.Errorf("AssumeRoleFunc is not set")
+	}
+	return m.AssumeRoleFunc(ctx, profile, input)
+}
+
+func (m *Service) GetFederationToken(ctx context.Context, profile string, input awslib.FederationTokenInput) (awslib.Credentials, error) {
+	m.GetFederationTokenCalls.Add(1)
+	if m.GetFederationTokenFunc == nil {
+		return awslib.Credentials{}, fmt.Errorf("GetFederationTokenFunc is not set")
+	}
+	return m.GetFederationTokenFunc(ctx, profile, input)
+}
+
+func (m *Service) GetRoleARN(ctx context.Context, profile string, sessionARN string) (string, error) {
+	m.GetRoleARNCalls.Add(1)
+	if m.GetRoleARNFunc == nil {
+		return "", fmt.Errorf("GetRoleARNFunc is not set")
+	}
+	return m.GetRoleARNFunc(ctx, profile, sessionARN)
+}
+
+func (m *Service) GetRoleCredentials(ctx context.Context, role awslib.SSORole) (awslib.Credentials, error) {
+	m.GetRoleCredentialsCalls.Add(1)
+	if m.GetRoleCredentialsFunc == nil {
+		return awslib.Credentials{}, fmt.Errorf("GetRoleCredentialsFunc is not set")
+	}
+	return m.GetRoleCredentialsFunc(ctx, role)
+}
+
+func (m *Service) AssumeRoleWithSAML(ctx context.Context, input awslib.SAMLRoleInput) (awslib.Credentials, error) {
+	m.AssumeRoleWithSAMLCalls.Add(1)
+	if m.AssumeRoleWithSAMLFunc == nil {
+		return awslib.Credentials{}, fmt.Errorf("AssumeRoleWithSAMLFunc is not set")
+	}
+	return m.AssumeRoleWithSAMLFunc(ctx, input)
+}
+
+func (m *Service) AssumeRoleWithWebIdentity(ctx context.Context, input awslib.WebIdentityRoleInput) (awslib.Credentials, error) {
+	m.AssumeRoleWithWebIdentityCalls.Add(1)
+	if m.AssumeRoleWithWebIdentityFunc == nil {
+		return awslib.Credentials{}, fmt.Errorf("AssumeRoleWithWebIdentityFunc is not set")
+	}
+	return m.AssumeRoleWithWebIdentityFunc(ctx, input)
 }
 
 type FederationBuilder struct {
-	BuildConsoleURLFunc func(ctx context.Context, creds awslib.Credentials, durationSeconds int32) (string, error)
+	BuildConsoleURLFunc  func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error)
+	BuildConsoleURLsFunc func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destinations []string) ([]string, error)
 
-	BuildConsoleURLCalls int
-	LastCredentials      awslib.Credentials
-	LastDurationSeconds  int32
+	BuildConsoleURLCalls  atomic.Int64
+	BuildConsoleURLsCalls atomic.Int64
+	LastCredentials       awslib.Credentials
+	LastDurationSeconds   int32
+	LastDestination       string
+	LastDestinations      []string
 }
 
-func (m *FederationBuilder) BuildConsoleURL(ctx context.Context, creds awslib.Credentials, durationSeconds int32) (string, error) {
-	m.BuildConsoleURLCalls++
+func (m *FederationBuilder) BuildConsoleURL(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+	m.BuildConsoleURLCalls.Add(1)
 	m.LastCredentials = creds
 	m.LastDurationSeconds = durationSeconds
+	m.LastDestination = destination
 
 	if m.BuildConsoleURLFunc == nil {
 		return "", fmt.Errorf("BuildConsoleURLFunc is not set")
 	}
 
-	return m.BuildConsoleURLFunc(ctx, creds, durationSeconds)
+	return m.BuildConsoleURLFunc(ctx, creds, durationSeconds, destination)
+}
+
+func (m *FederationBuilder) BuildConsoleURLs(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destinations []string) ([]string, error) {
+	m.BuildConsoleURLsCalls.Add(1)
+	m.LastCredentials = creds
+	m.LastDurationSeconds = durationSeconds
+	m.LastDestinations = append([]string(nil), destinations...)
+
+	if m.BuildConsoleURLsFunc == nil {
+		return nil, fmt.Errorf("BuildConsoleURLsFunc is not set")
+	}
+
+	return m.BuildConsoleURLsFunc(ctx, creds, durationSeconds, destinations)
+}
+
+type ProfileLister struct {
+	ListProfilesFunc func() ([]awslib.Profile, error)
+
+	ListProfilesCalls atomic.Int64
+}
+
+func (m *ProfileLister) ListProfiles() ([]awslib.Profile, error) {
+	m.ListProfilesCalls.Add(1)
+	if m.ListProfilesFunc == nil {
+		return nil, fmt.Errorf("ListProfilesFunc is not set")
+	}
+	return m.ListProfilesFunc()
+}
+
+type SSOSessionReader struct {
+	SSOSessionFunc func(name string) (awslib.SSOSession, error)
+
+	SSOSessionCalls atomic.Int64
+	LastName        string
+}
+
+func (m *SSOSessionReader) SSOSession(name string) (awslib.SSOSession, error) {
+	m.SSOSessionCalls.Add(1)
+	m.LastName = name
+	if m.SSOSessionFunc == nil {
+		return awslib.SSOSession{}, fmt.Errorf("SSOSessionFunc is not set")
+	}
+	return m.SSOSessionFunc(name)
 }
@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/eculver/aws-console/pkg/aws/arn"
+)
+
+// Partitions with a console federation endpoint.
+const (
+	PartitionAWS   = "aws"
+	PartitionUSGov = "aws-us-gov"
+	PartitionChina = "aws-cn"
+)
+
+// Endpoints are the federation and console URLs of a partition.
+type Endpoints struct {
+	FederationURL string
+	ConsoleURL    string
+}
+
+var partitionEndpoints = map[string]Endpoints{
+	PartitionAWS: {
+		FederationURL: defaultFederationURL,
+		ConsoleURL:    defaultConsoleURL,
+	},
+	PartitionUSGov: {
+		FederationURL: "https://signin.amazonaws-us-gov.com/federation",
+		ConsoleURL:    "https://console.amazonaws-us-gov.com/",
+	},
+	PartitionChina: {
+		FederationURL: "https://signin.amazonaws.cn/federation",
+		ConsoleURL:    "https://console.amazonaws.cn/",
+	},
+}
+
+// PartitionEndpoints returns the endpoints for partition, if console federation is
+// available there.
+func PartitionEndpoints(partition string) (Endpoints, bool) {
+	e, ok := partitionEndpoints[partition]
+	return e, ok
+}
+
+// Partitions returns the partitions with a console federation endpoint, sorted.
+func Partitions() []string {
+	names := make([]string, 0, len(partitionEndpoints))
+	for name := range partitionEndpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidatePartition checks that partition has a console federation endpoint.
+func ValidatePartition(partition string) error {
+	if _, ok := PartitionEndpoints(partition); !ok {
+		return fmt.Errorf("unknown partition %q (expected one of: %s)", partition, strings.Join(Partitions(), ", "))
+	}
+	return nil
+}
+
+// LogoutURL returns the link that signs the browser out of the console in partition.
+// An empty partition means the commercial partition. Console sessions live in browser cookies, so this ends every session in that browser.
+func LogoutURL(partition string) (string, error) {
+	if partition == "" {
+		partition = PartitionAWS
+	}
+	endpoints, ok := PartitionEndpoints(partition)
+	if !ok {
+		return "", fmt.Errorf("console sign-out is not available in partition %q", partition)
+	}
+	return strings.TrimSuffix(endpoints.FederationURL, "/federation") + "/oauth?Action=logout", nil
+}
+
+// PartitionFromARN returns the partition segment of s, such as "aws-us-gov", or ""
+// when s is not an ARN.
+func PartitionFromARN(s string) string {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return ""
+	}
+	return a.Partition
+}
+
+type partitionKey struct{}
+
+// WithPartition returns a context that makes the federation client use the endpoints
+// of partition. An empty partition is ignored.
+func WithPartition(ctx context.Context, partition string) context.Context {
+	if partition == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, partitionKey{}, partition)
+}
+
+// PartitionFromContext returns the partition set with WithPartition, if any.
+func PartitionFromContext(ctx context.Context) string {
+	partition, _ := ctx.Value(partitionKey{}).(string)
+	return partition
+}
@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPartitionFromARN(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"arn:aws:iam::123456789012:user/test":                         "aws",
+		"arn:aws-us-gov:sts::123456789012:assumed-role/Admin/session": "aws-us-gov",
+		"arn:aws-cn:iam::123456789012:root":                           "aws-cn",
+		"not-an-arn":                                                  "",
+		"":                                                            "",
+	}
+	for arn, want := range testCases {
+		if got := PartitionFromARN(arn); got != want {
+			t.Fatalf("PartitionFromARN(%q) = %q, want %q", arn, got, want)
+		}
+	}
+}
+
+func TestFederationClientPartitionEndpoints(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		partition       string
+		wantFederation  string
+		wantDestination string
+		wantErrSubstr   string
+	}{
+		{
+			name:            "no partition uses defaults",
+			wantFederation:  "https://signin.aws.amazon.com/federation",
+			wantDestination: "https://console.aws.amazon.com/",
+		},
+		{
+			name:            "govcloud",
+			partition:       PartitionUSGov,
+			wantFederation:  "https://signin.amazonaws-us-gov.com/federation",
+			wantDestination: "https://console.amazonaws-us-gov.com/",
+		},
+		{
+			name:            "china",
+			partition:       PartitionChina,
+			wantFederation:  "https://signin.amazonaws.cn/federation",
+			wantDestination: "https://console.amazonaws.cn/",
+		},
+		{
+			name:          "unsupported partition",
+			partition:     "aws-iso",
+			wantErrSubstr: `console federation is not available in partition "aws-iso"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requested string
+			client := NewFederationClient()
+			client.client = fakeHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+				requested = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"SigninToken":"token"}`)),
+				}, nil
+			}}
+
+			ctx := WithPartition(context.Background(), tc.partition)
+			loginURL, err := client.BuildConsoleURL(ctx, Credentials{AccessKeyID: "ASIA"}, 3600, "")
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if requested != tc.wantFederation {
+				t.Fatalf("expected token request to %q, got %q", tc.wantFederation, requested)
+			}
+			if !strings.HasPrefix(loginURL, tc.wantFederation+"?") {
+				t.Fatalf("expected login URL on %q, got %q", tc.wantFederation, loginURL)
+			}
+			parsed, err := url.Parse(loginURL)
+			if err != nil {
+				t.Fatalf("failed to parse login URL: %v", err)
+			}
+			if got := parsed.Query().Get("Destination"); got != tc.wantDestination {
+				t.Fatalf("expected destination %q, got %q", tc.wantDestination, got)
+			}
+		})
+	}
+}
+
+func TestValidatePartition(t *testing.T) {
+	t.Parallel()
+
+	for _, partition := range []string{PartitionAWS, PartitionUSGov, PartitionChina} {
+		if err := ValidatePartition(partition); err != nil {
+			t.Fatalf("ValidatePartition(%q) returned error: %v", partition, err)
+		}
+	}
+	err := ValidatePartition("aws-iso")
+	if err == nil || !strings.Contains(err.Error(), `unknown partition "aws-iso" (expected one of: aws, aws-cn, aws-us-gov)`) {
+		t.Fatalf("expected unknown partition error, got %v", err)
+	}
+}
+
+func TestLogoutURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"":             "https://signin.aws.amazon.com/oauth?Action=logout",
+		PartitionAWS:   "https://signin.aws.amazon.com/oauth?Action=logout",
+		PartitionUSGov: "https://signin.amazonaws-us-gov.com/oauth?Action=logout",
+		PartitionChina: "https://signin.amazonaws.cn/oauth?Action=logout",
+	}
+	for partition, want := range testCases {
+		got, err := LogoutURL(partition)
+		if err != nil {
+			t.Fatalf("LogoutURL(%q) returned error: %v", partition, err)
+		}
+		if got != want {
+			t.Fatalf("LogoutURL(%q) = %q, want %q", partition, got, want)
+		}
+	}
+
+	if _, err := LogoutURL("aws-iso"); err == nil {
+		t.Fatal("expected error for a partition without console sign-in")
+	}
+}
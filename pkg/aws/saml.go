@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// SAMLRoleInput describes a role to assume with a SAML assertion from an identity
+// provider, for sts:AssumeRoleWithSAML.
+type SAMLRoleInput struct {
+	RoleARN string
+	// PrincipalARN is the IAM SAML provider that trusts the identity provider.
+	PrincipalARN string
+	// Assertion is the base64 encoded SAMLResponse of the identity provider.
+	Assertion string
+	// DurationSeconds is zero to take the default of STS, one hour.
+	DurationSeconds int32
+}
+
+// unsignedRegions are where unsigned STS calls, AssumeRoleWithSAML and
+// AssumeRoleWithWebIdentity, are sent in each partition when no region is configured:
+// they need no credentials, and so no profile to take a region from.
+var unsignedRegions = map[string]string{
+	PartitionAWS:   "us-east-1",
+	PartitionUSGov: "us-gov-west-1",
+	PartitionChina: "cn-north-1",
+}
+
+// AssumeRoleWithSAML exchanges a SAML assertion for the credentials of input.RoleARN.
+// The request is not signed, so no profile is read.
+func (s *SDKService) AssumeRoleWithSAML(ctx context.Context, input SAMLRoleInput) (Credentials, error) {
+	cfg, err := s.loadConfig(ctx, "")
+	if err != nil {
+		return Credentials{}, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = unsignedRegions[PartitionFromARN(input.PrincipalARN)]
+	}
+
+	client, err := s.stsClient(ctx, cfg)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	params := &sts.AssumeRoleWithSAMLInput{
+		RoleArn:       awsv2.String(input.RoleARN),
+		PrincipalArn:  awsv2.String(input.PrincipalARN),
+		SAMLAssertion: awsv2.String(input.Assertion),
+	}
+	if input.DurationSeconds > 0 {
+		params.DurationSeconds = awsv2.Int32(input.DurationSeconds)
+	}
+
+	out, err := client.AssumeRoleWithSAML(ctx, params)
+	if err != nil {
+		return Credentials{}, throttled(err)
+	}
+	if out.Credentials == nil {
+		return Credentials{}, fmt.Errorf("STS AssumeRoleWithSAML returned empty credentials")
+	}
+
+	return Credentials{
+		AccessKeyID:     awsv2.ToString(out.Credentials.AccessKeyId),
+		SecretAccessKey: awsv2.ToString(out.Credentials.SecretAccessKey),
+		SessionToken:    awsv2.ToString(out.Credentials.SessionToken),
+		Expires:         awsv2.ToTime(out.Credentials.Expiration),
+		Source:          "SAML",
+	}, nil
+}
@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+func TestSDKServiceAssumeRoleWithSAML(t *testing.T) {
+	t.Parallel()
+
+	expiration := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	input := SAMLRoleInput{
+		RoleARN:         "arn:aws:iam::123456789012:role/Admin",
+		PrincipalARN:    "arn:aws:iam::123456789012:saml-provider/ADFS",
+		Assertion:       "UkVTUE9OU0U=",
+		DurationSeconds: 28800,
+	}
+
+	testCases := []struct {
+		name          string
+		stsClient     fakeSTS
+		wantErrSubstr string
+	}{
+		{
+			name: "credentials",
+			stsClient: fakeSTS{samlOutput: &sts.AssumeRoleWithSAMLOutput{Credentials: &ststypes.Credentials{
+				AccessKeyId:     awsv2.String("ASIASAML"),
+				SecretAccessKey: awsv2.String("secret"),
+				SessionToken:    awsv2.String("token"),
+				Expiration:      awsv2.Time(expiration),
+			}}},
+		},
+		{name: "sts error", stsClient: fakeSTS{samlErr: errors.New("InvalidIdentityToken")}, wantErrSubstr: "InvalidIdentityToken"},
+		{name: "empty credentials", stsClient: fakeSTS{samlOutput: &sts.AssumeRoleWithSAMLOutput{}}, wantErrSubstr: "STS AssumeRoleWithSAML returned empty credentials"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var request sts.AssumeRoleWithSAMLInput
+			client := tc.stsClient
+			client.samlInput = &request
+
+			svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{client: client}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+			creds, err := svc.AssumeRoleWithSAML(context.Background(), input)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AssumeRoleWithSAML returned error: %v", err)
+			}
+			if creds.AccessKeyID != "ASIASAML" || creds.SessionToken != "token" || !creds.Expires.Equal(expiration) {
+				t.Fatalf("unexpected credentials: %+v", creds)
+			}
+			if awsv2.ToString(request.RoleArn) != input.RoleARN || awsv2.ToString(request.PrincipalArn) != input.PrincipalARN ||
+				awsv2.ToString(request.SAMLAssertion) != input.Assertion || awsv2.ToInt32(request.DurationSeconds) != 28800 {
+				t.Fatalf("unexpected AssumeRoleWithSAML request: %+v", request)
+			}
+		})
+	}
+}
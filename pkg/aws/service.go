@@ -1,12 +1,22 @@
 package aws
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/eculver/aws-console/pkg/aws/arn"
 )
 
 type configLoader interface {
@@ -22,40 +32,108 @@ func (defaultConfigLoader) LoadDefaultConfig(ctx context.Context, optFns ...func
 type stsAPI interface {
 	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
 	GetSessionToken(ctx context.Context, params *sts.GetSessionTokenInput, optFns ...func(*sts.Options)) (*sts.GetSessionTokenOutput, error)
+	AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error)
+	GetFederationToken(ctx context.Context, params *sts.GetFederationTokenInput, optFns ...func(*sts.Options)) (*sts.GetFederationTokenOutput, error)
+	AssumeRoleWithSAML(ctx context.Context, params *sts.AssumeRoleWithSAMLInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleWithSAMLOutput, error)
+	AssumeRoleWithWebIdentity(ctx context.Context, params *sts.AssumeRoleWithWebIdentityInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleWithWebIdentityOutput, error)
 }
 
+// DefaultRoleSessionName names role sessions when AssumeRoleInput.SessionName is empty.
+const DefaultRoleSessionName = "aws-console"
+
 type stsClientFactory interface {
-	NewFromConfig(cfg awsv2.Config) stsAPI
+	NewFromConfig(cfg awsv2.Config, optFns ...func(*sts.Options)) stsAPI
 }
 
 type defaultSTSClientFactory struct{}
 
-func (defaultSTSClientFactory) NewFromConfig(cfg awsv2.Config) stsAPI {
-	return sts.NewFromConfig(cfg)
+func (defaultSTSClientFactory) NewFromConfig(cfg awsv2.Config, optFns ...func(*sts.Options)) stsAPI {
+	return sts.NewFromConfig(cfg, optFns...)
+}
+
+type iamAPI interface {
+	GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error)
+	SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error)
+	ListAccountAliases(ctx context.Context, params *iam.ListAccountAliasesInput, optFns ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error)
+	ListMFADevices(ctx context.Context, params *iam.ListMFADevicesInput, optFns ...func(*iam.Options)) (*iam.ListMFADevicesOutput, error)
+}
+
+type iamClientFactory interface {
+	NewFromConfig(cfg awsv2.Config) iamAPI
+}
+
+type defaultIAMClientFactory struct{}
+
+func (defaultIAMClientFactory) NewFromConfig(cfg awsv2.Config) iamAPI {
+	return iam.NewFromConfig(cfg)
+}
+
+type organizationsAPI interface {
+	DescribeAccount(ctx context.Context, params *organizations.DescribeAccountInput, optFns ...func(*organizations.Options)) (*organizations.DescribeAccountOutput, error)
+	ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error)
+}
+
+type organizationsClientFactory interface {
+	NewFromConfig(cfg awsv2.Config) organizationsAPI
+}
+
+type defaultOrganizationsClientFactory struct{}
+
+func (defaultOrganizationsClientFactory) NewFromConfig(cfg awsv2.Config) organizationsAPI {
+	return organizations.NewFromConfig(cfg)
 }
 
 // SDKService is the concrete implementation backed by AWS SDK v2.
 type SDKService struct {
 	loader     configLoader
 	stsFactory stsClientFactory
+	iamFactory iamClientFactory
+	orgFactory organizationsClientFactory
+	ssoFactory ssoClientFactory
 }
 
 // NewService creates an AWS service implementation that uses AWS SDK v2.
 func NewService() *SDKService {
-	return newSDKService(defaultConfigLoader{}, defaultSTSClientFactory{})
+	return newSDKService(defaultConfigLoader{}, defaultSTSClientFactory{}, defaultIAMClientFactory{}, defaultOrganizationsClientFactory{})
 }
 
-func newSDKService(loader configLoader, stsFactory stsClientFactory) *SDKService {
+func newSDKService(loader configLoader, stsFactory stsClientFactory, iamFactory iamClientFactory, orgFactory organizationsClientFactory) *SDKService {
 	return &SDKService{
 		loader:     loader,
 		stsFactory: stsFactory,
+		iamFactory: iamFactory,
+		orgFactory: orgFactory,
+		ssoFactory: defaultSSOClientFactory{},
 	}
 }
 
 func (s *SDKService) loadConfig(ctx context.Context, profile string) (awsv2.Config, error) {
 	var opts []func(*config.LoadOptions) error
-	if profile != "" {
-		opts = append(opts, config.WithSharedConfigProfile(profile))
+	if role, ok := SSORoleFromContext(ctx); ok {
+		// The role has no profile, so the shared config and credentials files are not
+		// read at all; the session's region applies unless one was chosen.
+		opts = append(opts,
+			config.WithSharedConfigFiles([]string{}),
+			config.WithSharedCredentialsFiles([]string{}),
+			config.WithRegion(role.Region),
+			config.WithCredentialsProvider(s.credentialsProvider(role)),
+		)
+	} else {
+		if profile != "" {
+			opts = append(opts, config.WithSharedConfigProfile(profile))
+		}
+		if source, ok := KeySourceFor(ctx, profile); ok {
+			opts = append(opts, config.WithCredentialsProvider(keySourceProvider(source)))
+		}
+	}
+	if region := RegionFromContext(ctx); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	// Back off longer than the SDK's default three attempts before giving up on
+	// throttled calls.
+	opts = append(opts, config.WithRetryMaxAttempts(sdkMaxAttempts))
+	if l := LoggerFromContext(ctx); l.Enabled(ctx, slog.LevelDebug) {
+		opts = append(opts, config.WithAPIOptions([]func(*middleware.Stack) error{logAPICalls(l)}))
 	}
 
 	cfg, err := s.loader.LoadDefaultConfig(ctx, opts...)
@@ -65,18 +143,50 @@ func (s *SDKService) loadConfig(ctx context.Context, profile string) (awsv2.Conf
 	return cfg, nil
 }
 
+// stsClient creates an STS client, pointed at the endpoint set with WithSTSEndpoint if any.
+func (s *SDKService) stsClient(ctx context.Context, cfg awsv2.Config) (stsAPI, error) {
+	template := STSEndpointFromContext(ctx)
+	if template == "" {
+		return s.stsFactory.NewFromConfig(cfg), nil
+	}
+
+	endpoint, err := ResolveSTSEndpoint(template, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	return s.stsFactory.NewFromConfig(cfg, func(o *sts.Options) {
+		o.BaseEndpoint = awsv2.String(endpoint)
+	}), nil
+}
+
 func (s *SDKService) GetCallerIdentity(ctx context.Context, profile string) (Identity, error) {
 	cfg, err := s.loadConfig(ctx, profile)
 	if err != nil {
 		return Identity{}, err
 	}
 
-	out, err := s.stsFactory.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	client, err := s.stsClient(ctx, cfg)
 	if err != nil {
 		return Identity{}, err
 	}
 
-	return Identity{Arn: awsv2.ToString(out.Arn)}, nil
+	out, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return Identity{}, throttled(err)
+	}
+
+	identity := Identity{
+		Arn:     awsv2.ToString(out.Arn),
+		Account: awsv2.ToString(out.Account),
+		UserID:  awsv2.ToString(out.UserId),
+	}
+	if a, err := identity.ParseARN(); err == nil {
+		identity.Partition = a.Partition
+		if identity.Account == "" {
+			identity.Account = a.AccountID
+		}
+	}
+	return identity, nil
 }
 
 func (s *SDKService) RetrieveCredentials(ctx context.Context, profile string) (Credentials, error) {
@@ -87,29 +197,47 @@ func (s *SDKService) RetrieveCredentials(ctx context.Context, profile string) (C
 
 	creds, err := cfg.Credentials.Retrieve(ctx)
 	if err != nil {
-		return Credentials{}, err
+		return Credentials{}, throttled(err)
 	}
+	LoggerFromContext(ctx).Debug("retrieved credentials", "profile", profile, "source", creds.Source,
+		"access_key_id", creds.AccessKeyID, "temporary", creds.SessionToken != "")
 
-	return Credentials{
+	result := Credentials{
 		AccessKeyID:     creds.AccessKeyID,
 		SecretAccessKey: creds.SecretAccessKey,
 		SessionToken:    creds.SessionToken,
-	}, nil
+		Source:          creds.Source,
+	}
+	if creds.CanExpire {
+		result.Expires = creds.Expires
+	}
+	return result, nil
 }
 
-func (s *SDKService) GetSessionToken(ctx context.Context, profile string, durationSeconds int32) (Credentials, error) {
+func (s *SDKService) GetSessionToken(ctx context.Context, profile string, input SessionTokenInput) (Credentials, error) {
 	cfg, err := s.loadConfig(ctx, profile)
 	if err != nil {
 		return Credentials{}, err
 	}
 
-	out, err := s.stsFactory.NewFromConfig(cfg).GetSessionToken(ctx, &sts.GetSessionTokenInput{
-		DurationSeconds: awsv2.Int32(durationSeconds),
-	})
+	client, err := s.stsClient(ctx, cfg)
 	if err != nil {
 		return Credentials{}, err
 	}
 
+	params := &sts.GetSessionTokenInput{}
+	if input.DurationSeconds > 0 {
+		params.DurationSeconds = awsv2.Int32(input.DurationSeconds)
+	}
+	if input.MFASerial != "" {
+		params.SerialNumber = awsv2.String(input.MFASerial)
+		params.TokenCode = awsv2.String(input.MFAToken)
+	}
+	out, err := client.GetSessionToken(ctx, params)
+	if err != nil {
+		return Credentials{}, throttled(err)
+	}
+
 	if out.Credentials == nil {
 		return Credentials{}, fmt.Errorf("STS GetSessionToken returned empty credentials")
 	}
@@ -118,5 +246,295 @@ func (s *SDKService) GetSessionToken(ctx context.Context, profile string, durati
 		AccessKeyID:     awsv2.ToString(out.Credentials.AccessKeyId),
 		SecretAccessKey: awsv2.ToString(out.Credentials.SecretAccessKey),
 		SessionToken:    awsv2.ToString(out.Credentials.SessionToken),
+		Expires:         awsv2.ToTime(out.Credentials.Expiration),
+	}, nil
+}
+
+func (s *SDKService) ListMFADevices(ctx context.Context, profile string) ([]string, error) {
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a user name, IAM lists the devices of the calling user.
+	out, err := s.iamFactory.NewFromConfig(cfg).ListMFADevices(ctx, &iam.ListMFADevicesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MFA devices: %w", err)
+	}
+
+	serials := make([]string, 0, len(out.MFADevices))
+	for _, device := range out.MFADevices {
+		serials = append(serials, awsv2.ToString(device.SerialNumber))
+	}
+	return serials, nil
+}
+
+func (s *SDKService) AssumeRole(ctx context.Context, profile string, input AssumeRoleInput) (Credentials, error) {
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	client, err := s.stsClient(ctx, cfg)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	params := &sts.AssumeRoleInput{
+		RoleArn:         awsv2.String(input.RoleARN),
+		RoleSessionName: awsv2.String(DefaultRoleSessionName),
+	}
+	if input.SessionName != "" {
+		params.RoleSessionName = awsv2.String(input.SessionName)
+	}
+	if input.ExternalID != "" {
+		params.ExternalId = awsv2.String(input.ExternalID)
+	}
+	if input.MFASerial != "" {
+		params.SerialNumber = awsv2.String(input.MFASerial)
+		params.TokenCode = awsv2.String(input.MFAToken)
+	}
+	if input.DurationSeconds > 0 {
+		params.DurationSeconds = awsv2.Int32(input.DurationSeconds)
+	}
+	params.PolicyArns, params.Policy = sessionPolicyParams(input.SessionPolicy)
+	for _, tag := range input.SessionTags() {
+		params.Tags = append(params.Tags, ststypes.Tag{Key: awsv2.String(tag.Key), Value: awsv2.String(tag.Value)})
+	}
+	params.TransitiveTagKeys = input.TransitiveTagKeys
+	if input.SourceIdentity != "" {
+		params.SourceIdentity = awsv2.String(input.SourceIdentity)
+	}
+
+	out, err := client.AssumeRole(ctx, params)
+	if err != nil {
+		return Credentials{}, throttled(err)
+	}
+	if out.Credentials == nil {
+		return Credentials{}, fmt.Errorf("STS AssumeRole returned empty credentials")
+	}
+
+	return Credentials{
+		AccessKeyID:     awsv2.ToString(out.Credentials.AccessKeyId),
+		SecretAccessKey: awsv2.ToString(out.Credentials.SecretAccessKey),
+		SessionToken:    awsv2.ToString(out.Credentials.SessionToken),
+		Expires:         awsv2.ToTime(out.Credentials.Expiration),
 	}, nil
 }
+
+func (s *SDKService) GetFederationToken(ctx context.Context, profile string, input FederationTokenInput) (Credentials, error) {
+	if input.SessionPolicy == nil {
+		return Credentials{}, errors.New("a federation token requires a session policy")
+	}
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	client, err := s.stsClient(ctx, cfg)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	params := &sts.GetFederationTokenInput{
+		Name: awsv2.String(cmp.Or(input.Name, DefaultRoleSessionName)),
+	}
+	if input.DurationSeconds > 0 {
+		params.DurationSeconds = awsv2.Int32(input.DurationSeconds)
+	}
+	params.PolicyArns, params.Policy = sessionPolicyParams(input.SessionPolicy)
+
+	out, err := client.GetFederationToken(ctx, params)
+	if err != nil {
+		return Credentials{}, throttled(err)
+	}
+	if out.Credentials == nil {
+		return Credentials{}, fmt.Errorf("STS GetFederationToken returned empty credentials")
+	}
+
+	return Credentials{
+		AccessKeyID:     awsv2.ToString(out.Credentials.AccessKeyId),
+		SecretAccessKey: awsv2.ToString(out.Credentials.SecretAccessKey),
+		SessionToken:    awsv2.ToString(out.Credentials.SessionToken),
+		Expires:         awsv2.ToTime(out.Credentials.Expiration),
+	}, nil
+}
+
+// sessionPolicyParams converts p to the session policy parameters of STS requests.
+func sessionPolicyParams(p *SessionPolicy) ([]ststypes.PolicyDescriptorType, *string) {
+	if p == nil {
+		return nil, nil
+	}
+	var arns []ststypes.PolicyDescriptorType
+	for _, arn := range p.PolicyARNs {
+		arns = append(arns, ststypes.PolicyDescriptorType{Arn: awsv2.String(arn)})
+	}
+	var policy *string
+	if p.Policy != "" {
+		policy = awsv2.String(p.Policy)
+	}
+	return arns, policy
+}
+
+func (s *SDKService) GetRoleARN(ctx context.Context, profile string, sessionARN string) (string, error) {
+	roleName := assumedRoleName(sessionARN)
+	if roleName == "" {
+		return "", fmt.Errorf("%s is not an assumed-role session", sessionARN)
+	}
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return "", err
+	}
+	return roleARN(ctx, s.iamFactory.NewFromConfig(cfg), roleName)
+}
+
+// roleARN looks up the full ARN of the role roleName, which includes its path.
+func roleARN(ctx context.Context, client iamAPI, roleName string) (string, error) {
+	out, err := client.GetRole(ctx, &iam.GetRoleInput{RoleName: awsv2.String(roleName)})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up role %s: %w", roleName, err)
+	}
+	if out.Role == nil {
+		return "", fmt.Errorf("IAM GetRole returned no role for %s", roleName)
+	}
+	return awsv2.ToString(out.Role.Arn), nil
+}
+
+func (s *SDKService) SimulatePrincipalPolicy(ctx context.Context, profile string, principalARN string, actions []string) (map[string]bool, error) {
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.iamFactory.NewFromConfig(cfg)
+
+	// Policies are attached to the role, not the STS session, so resolve the role's
+	// full ARN (including its path) before simulating.
+	if roleName := assumedRoleName(principalARN); roleName != "" {
+		if principalARN, err = roleARN(ctx, client, roleName); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := client.SimulatePrincipalPolicy(ctx, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: awsv2.String(principalARN),
+		ActionNames:     actions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make(map[string]bool, len(out.EvaluationResults))
+	for _, result := range out.EvaluationResults {
+		decisions[awsv2.ToString(result.EvalActionName)] = result.EvalDecision == iamtypes.PolicyEvaluationDecisionTypeAllowed
+	}
+	return decisions, nil
+}
+
+func (s *SDKService) GetAccountAlias(ctx context.Context, profile string) (string, error) {
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return "", err
+	}
+	return s.accountAlias(ctx, cfg)
+}
+
+// accountAlias returns the account's first IAM alias. An account has at most one, and
+// principals that may not list it get "".
+func (s *SDKService) accountAlias(ctx context.Context, cfg awsv2.Config) (string, error) {
+	aliases, err := s.iamFactory.NewFromConfig(cfg).ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+	switch {
+	case err == nil:
+		if len(aliases.AccountAliases) > 0 {
+			return aliases.AccountAliases[0], nil
+		}
+		return "", nil
+	case isAccessDenied(err):
+		return "", nil
+	default:
+		return "", fmt.Errorf("failed to list account aliases: %w", err)
+	}
+}
+
+func (s *SDKService) DescribeAccount(ctx context.Context, profile string, accountID string) (AccountInfo, error) {
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+
+	info := AccountInfo{ID: accountID}
+	if info.Alias, err = s.accountAlias(ctx, cfg); err != nil {
+		return AccountInfo{}, err
+	}
+
+	// Only the management account and delegated administrators may describe accounts,
+	// so a denial here is expected for most principals.
+	account, err := s.orgFactory.NewFromConfig(cfg).DescribeAccount(ctx, &organizations.DescribeAccountInput{
+		AccountId: awsv2.String(accountID),
+	})
+	switch {
+	case err == nil:
+		if account.Account != nil {
+			info.Name = awsv2.ToString(account.Account.Name)
+		}
+	case !isAccessDenied(err):
+		return AccountInfo{}, fmt.Errorf("failed to describe account %s: %w", accountID, err)
+	}
+
+	return info, nil
+}
+
+func (s *SDKService) ListAccounts(ctx context.Context, profile string) ([]OrganizationAccount, error) {
+	cfg, err := s.loadConfig(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []OrganizationAccount
+	pages := organizations.NewListAccountsPaginator(s.orgFactory.NewFromConfig(cfg), &organizations.ListAccountsInput{})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the accounts of the organization: %w", err)
+		}
+		for _, a := range page.Accounts {
+			// State replaces the deprecated Status, which older responses carry alone.
+			state := string(a.State)
+			if state == "" {
+				state = string(a.Status)
+			}
+			accounts = append(accounts, OrganizationAccount{
+				ID:    awsv2.ToString(a.Id),
+				Name:  awsv2.ToString(a.Name),
+				Email: awsv2.ToString(a.Email),
+				State: state,
+			})
+		}
+	}
+	return accounts, nil
+}
+
+// isAccessDenied reports whether err is an API error meaning the principal may not make
+// the call, or that the account is not in an organization.
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "AccessDenied", "AccessDeniedException", "AWSOrganizationsNotInUseException":
+		return true
+	}
+	return false
+}
+
+// assumedRoleName returns the role name from an STS assumed-role ARN
+// (arn:aws:sts::123456789012:assumed-role/Admin/session), or "" for other ARNs.
+func assumedRoleName(s string) string {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return ""
+	}
+	role, _, _ := a.AssumedRole()
+	return role
+}
@@ -3,14 +3,22 @@ package aws
 import (
 	"context"
 	"errors"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go"
 )
 
 type fakeConfigLoader struct {
@@ -30,6 +38,24 @@ type fakeSTS struct {
 	getCallerIdentityErr    error
 	getSessionTokenOutput   *sts.GetSessionTokenOutput
 	getSessionTokenErr      error
+	assumeRoleOutput        *sts.AssumeRoleOutput
+	assumeRoleErr           error
+	federationTokenOutput   *sts.GetFederationTokenOutput
+	federationTokenErr      error
+	samlOutput              *sts.AssumeRoleWithSAMLOutput
+	samlErr                 error
+	webIdentityOutput       *sts.AssumeRoleWithWebIdentityOutput
+	webIdentityErr          error
+	// getSessionTokenInput, when set, receives the GetSessionToken request.
+	getSessionTokenInput *sts.GetSessionTokenInput
+	// assumeRoleInput, when set, receives the AssumeRole request.
+	assumeRoleInput *sts.AssumeRoleInput
+	// federationTokenInput, when set, receives the GetFederationToken request.
+	federationTokenInput *sts.GetFederationTokenInput
+	// samlInput, when set, receives the AssumeRoleWithSAML request.
+	samlInput *sts.AssumeRoleWithSAMLInput
+	// webIdentityInput, when set, receives the AssumeRoleWithWebIdentity request.
+	webIdentityInput *sts.AssumeRoleWithWebIdentityInput
 }
 
 func (f fakeSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
@@ -40,17 +66,156 @@ func (f fakeSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIde
 }
 
 func (f fakeSTS) GetSessionToken(ctx context.Context, params *sts.GetSessionTokenInput, optFns ...func(*sts.Options)) (*sts.GetSessionTokenOutput, error) {
+	if f.getSessionTokenInput != nil {
+		*f.getSessionTokenInput = *params
+	}
 	if f.getSessionTokenErr != nil {
 		return nil, f.getSessionTokenErr
 	}
 	return f.getSessionTokenOutput, nil
 }
 
+func (f fakeSTS) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	if f.assumeRoleInput != nil {
+		*f.assumeRoleInput = *params
+	}
+	if f.assumeRoleErr != nil {
+		return nil, f.assumeRoleErr
+	}
+	return f.assumeRoleOutput, nil
+}
+
+func (f fakeSTS) GetFederationToken(ctx context.Context, params *sts.GetFederationTokenInput, optFns ...func(*sts.Options)) (*sts.GetFederationTokenOutput, error) {
+	if f.federationTokenInput != nil {
+		*f.federationTokenInput = *params
+	}
+	if f.federationTokenErr != nil {
+		return nil, f.federationTokenErr
+	}
+	return f.federationTokenOutput, nil
+}
+
+func (f fakeSTS) AssumeRoleWithSAML(ctx context.Context, params *sts.AssumeRoleWithSAMLInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleWithSAMLOutput, error) {
+	if f.samlInput != nil {
+		*f.samlInput = *params
+	}
+	if f.samlErr != nil {
+		return nil, f.samlErr
+	}
+	return f.samlOutput, nil
+}
+
+func (f fakeSTS) AssumeRoleWithWebIdentity(ctx context.Context, params *sts.AssumeRoleWithWebIdentityInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	if f.webIdentityInput != nil {
+		*f.webIdentityInput = *params
+	}
+	if f.webIdentityErr != nil {
+		return nil, f.webIdentityErr
+	}
+	return f.webIdentityOutput, nil
+}
+
 type fakeSTSFactory struct {
 	client stsAPI
+	// options, when set, receives the client options after optFns are applied.
+	options *sts.Options
+}
+
+func (f fakeSTSFactory) NewFromConfig(cfg awsv2.Config, optFns ...func(*sts.Options)) stsAPI {
+	if f.options != nil {
+		for _, fn := range optFns {
+			fn(f.options)
+		}
+	}
+	return f.client
+}
+
+type fakeIAM struct {
+	getRoleOutput   *iam.GetRoleOutput
+	getRoleErr      error
+	simulateOutput  *iam.SimulatePrincipalPolicyOutput
+	simulateErr     error
+	simulatedSource *string
+	aliasesOutput   *iam.ListAccountAliasesOutput
+	aliasesErr      error
+	mfaOutput       *iam.ListMFADevicesOutput
+	mfaErr          error
+}
+
+func (f fakeIAM) GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	if f.getRoleErr != nil {
+		return nil, f.getRoleErr
+	}
+	return f.getRoleOutput, nil
+}
+
+func (f fakeIAM) SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	if f.simulatedSource != nil {
+		*f.simulatedSource = awsv2.ToString(params.PolicySourceArn)
+	}
+	if f.simulateErr != nil {
+		return nil, f.simulateErr
+	}
+	return f.simulateOutput, nil
+}
+
+func (f fakeIAM) ListAccountAliases(ctx context.Context, params *iam.ListAccountAliasesInput, optFns ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error) {
+	if f.aliasesErr != nil {
+		return nil, f.aliasesErr
+	}
+	return f.aliasesOutput, nil
+}
+
+func (f fakeIAM) ListMFADevices(ctx context.Context, params *iam.ListMFADevicesInput, optFns ...func(*iam.Options)) (*iam.ListMFADevicesOutput, error) {
+	if f.mfaErr != nil {
+		return nil, f.mfaErr
+	}
+	return f.mfaOutput, nil
+}
+
+type fakeIAMFactory struct {
+	client iamAPI
+}
+
+func (f fakeIAMFactory) NewFromConfig(cfg awsv2.Config) iamAPI {
+	return f.client
+}
+
+type fakeOrganizations struct {
+	describeOutput *organizations.DescribeAccountOutput
+	describeErr    error
+	// listPages are returned in turn, each with a NextToken unless it is the last.
+	listPages []*organizations.ListAccountsOutput
+	listErr   error
+}
+
+func (f fakeOrganizations) DescribeAccount(ctx context.Context, params *organizations.DescribeAccountInput, optFns ...func(*organizations.Options)) (*organizations.DescribeAccountOutput, error) {
+	if f.describeErr != nil {
+		return nil, f.describeErr
+	}
+	return f.describeOutput, nil
 }
 
-func (f fakeSTSFactory) NewFromConfig(cfg awsv2.Config) stsAPI {
+func (f fakeOrganizations) ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	page := 0
+	if params.NextToken != nil {
+		page, _ = strconv.Atoi(*params.NextToken)
+	}
+	out := *f.listPages[page]
+	if page+1 < len(f.listPages) {
+		out.NextToken = awsv2.String(strconv.Itoa(page + 1))
+	}
+	return &out, nil
+}
+
+type fakeOrganizationsFactory struct {
+	client organizationsAPI
+}
+
+func (f fakeOrganizationsFactory) NewFromConfig(cfg awsv2.Config) organizationsAPI {
 	return f.client
 }
 
@@ -62,6 +227,14 @@ func (f failingCredentialsProvider) Retrieve(ctx context.Context) (awsv2.Credent
 	return awsv2.Credentials{}, f.err
 }
 
+type expiringCredentialsProvider struct {
+	creds awsv2.Credentials
+}
+
+func (f expiringCredentialsProvider) Retrieve(ctx context.Context) (awsv2.Credentials, error) {
+	return f.creds, nil
+}
+
 func TestSDKServiceGetCallerIdentity(t *testing.T) {
 	t.Parallel()
 
@@ -70,6 +243,8 @@ func TestSDKServiceGetCallerIdentity(t *testing.T) {
 		loader        configLoader
 		stsClient     stsAPI
 		wantArn       string
+		wantAccount   string
+		wantUserID    string
 		wantErrSubstr string
 	}{
 		{
@@ -77,10 +252,14 @@ func TestSDKServiceGetCallerIdentity(t *testing.T) {
 			loader: fakeConfigLoader{cfg: awsv2.Config{}},
 			stsClient: fakeSTS{
 				getCallerIdentityOutput: &sts.GetCallerIdentityOutput{
-					Arn: awsv2.String("arn:aws:iam::123456789012:user/test"),
+					Arn:     awsv2.String("arn:aws:iam::123456789012:user/test"),
+					Account: awsv2.String("123456789012"),
+					UserId:  awsv2.String("AIDAEXAMPLE"),
 				},
 			},
-			wantArn: "arn:aws:iam::123456789012:user/test",
+			wantArn:     "arn:aws:iam::123456789012:user/test",
+			wantAccount: "123456789012",
+			wantUserID:  "AIDAEXAMPLE",
 		},
 		{
 			name:          "config load failure",
@@ -101,7 +280,7 @@ func TestSDKServiceGetCallerIdentity(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			svc := newSDKService(tc.loader, fakeSTSFactory{client: tc.stsClient})
+			svc := newSDKService(tc.loader, fakeSTSFactory{client: tc.stsClient}, fakeIAMFactory{}, fakeOrganizationsFactory{})
 			identity, err := svc.GetCallerIdentity(context.Background(), "test-profile")
 
 			if tc.wantErrSubstr != "" {
@@ -121,6 +300,15 @@ func TestSDKServiceGetCallerIdentity(t *testing.T) {
 			if identity.Arn != tc.wantArn {
 				t.Fatalf("unexpected ARN: %q", identity.Arn)
 			}
+			if identity.Account != tc.wantAccount {
+				t.Fatalf("unexpected account: %q", identity.Account)
+			}
+			if identity.UserID != tc.wantUserID {
+				t.Fatalf("unexpected user ID: %q", identity.UserID)
+			}
+			if identity.Partition != "aws" {
+				t.Fatalf("unexpected partition: %q", identity.Partition)
+			}
 		})
 	}
 }
@@ -144,12 +332,35 @@ func TestSDKServiceRetrieveCredentials(t *testing.T) {
 		}),
 	}
 
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	expiringCfg := awsv2.Config{
+		Credentials: awsv2.NewCredentialsCache(expiringCredentialsProvider{
+			creds: awsv2.Credentials{
+				AccessKeyID:     "ASIA_TEST",
+				SecretAccessKey: "secret",
+				SessionToken:    "token",
+				CanExpire:       true,
+				Expires:         expires,
+			},
+		}),
+	}
+
 	testCases := []struct {
 		name          string
 		loader        configLoader
 		wantCreds     Credentials
 		wantErrSubstr string
 	}{
+		{
+			name:   "expiring credentials",
+			loader: fakeConfigLoader{cfg: expiringCfg},
+			wantCreds: Credentials{
+				AccessKeyID:     "ASIA_TEST",
+				SecretAccessKey: "secret",
+				SessionToken:    "token",
+				Expires:         expires,
+			},
+		},
 		{
 			name:   "success",
 			loader: fakeConfigLoader{cfg: successCfg},
@@ -157,6 +368,7 @@ func TestSDKServiceRetrieveCredentials(t *testing.T) {
 				AccessKeyID:     "AKIA_TEST",
 				SecretAccessKey: "secret",
 				SessionToken:    "token",
+				Source:          credentials.StaticCredentialsName,
 			},
 		},
 		{
@@ -176,7 +388,7 @@ func TestSDKServiceRetrieveCredentials(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			svc := newSDKService(tc.loader, fakeSTSFactory{client: fakeSTS{}})
+			svc := newSDKService(tc.loader, fakeSTSFactory{client: fakeSTS{}}, fakeIAMFactory{}, fakeOrganizationsFactory{})
 			creds, err := svc.RetrieveCredentials(context.Background(), "test-profile")
 
 			if tc.wantErrSubstr != "" {
@@ -253,8 +465,8 @@ func TestSDKServiceGetSessionToken(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			svc := newSDKService(tc.loader, fakeSTSFactory{client: tc.stsClient})
-			creds, err := svc.GetSessionToken(context.Background(), "test-profile", 3600)
+			svc := newSDKService(tc.loader, fakeSTSFactory{client: tc.stsClient}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+			creds, err := svc.GetSessionToken(context.Background(), "test-profile", SessionTokenInput{DurationSeconds: 3600})
 
 			if tc.wantErrSubstr != "" {
 				if err == nil {
@@ -276,3 +488,636 @@ func TestSDKServiceGetSessionToken(t *testing.T) {
 		})
 	}
 }
+
+func TestSDKServiceGetSessionTokenWithMFA(t *testing.T) {
+	t.Parallel()
+
+	var request sts.GetSessionTokenInput
+	client := fakeSTS{
+		getSessionTokenOutput: &sts.GetSessionTokenOutput{Credentials: &ststypes.Credentials{
+			AccessKeyId:     awsv2.String("AKIA_TEMP"),
+			SecretAccessKey: awsv2.String("temp-secret"),
+			SessionToken:    awsv2.String("temp-token"),
+		}},
+		getSessionTokenInput: &request,
+	}
+	svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{client: client}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+
+	input := SessionTokenInput{DurationSeconds: 3600, MFASerial: "arn:aws:iam::123456789012:mfa/alice", MFAToken: "123456"}
+	if _, err := svc.GetSessionToken(context.Background(), "test-profile", input); err != nil {
+		t.Fatalf("GetSessionToken returned error: %v", err)
+	}
+	if awsv2.ToInt32(request.DurationSeconds) != 3600 ||
+		awsv2.ToString(request.SerialNumber) != input.MFASerial ||
+		awsv2.ToString(request.TokenCode) != input.MFAToken {
+		t.Fatalf("unexpected GetSessionToken request: %+v", request)
+	}
+}
+
+func TestSDKServiceGetSessionTokenDefaultDuration(t *testing.T) {
+	t.Parallel()
+
+	var request sts.GetSessionTokenInput
+	client := fakeSTS{
+		getSessionTokenOutput: &sts.GetSessionTokenOutput{Credentials: &ststypes.Credentials{
+			AccessKeyId:     awsv2.String("AKIA_TEMP"),
+			SecretAccessKey: awsv2.String("temp-secret"),
+			SessionToken:    awsv2.String("temp-token"),
+		}},
+		getSessionTokenInput: &request,
+	}
+	svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{client: client}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+
+	if _, err := svc.GetSessionToken(context.Background(), "test-profile", SessionTokenInput{}); err != nil {
+		t.Fatalf("GetSessionToken returned error: %v", err)
+	}
+	if request.DurationSeconds != nil {
+		t.Fatalf("GetSessionToken requested DurationSeconds %d, want STS's default", *request.DurationSeconds)
+	}
+}
+
+func TestSDKServiceListMFADevices(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		iamClient     fakeIAM
+		want          []string
+		wantErrSubstr string
+	}{
+		{
+			name: "devices",
+			iamClient: fakeIAM{mfaOutput: &iam.ListMFADevicesOutput{MFADevices: []iamtypes.MFADevice{
+				{SerialNumber: awsv2.String("arn:aws:iam::123456789012:mfa/alice")},
+				{SerialNumber: awsv2.String("arn:aws:iam::123456789012:mfa/alice-backup")},
+			}}},
+			want: []string{"arn:aws:iam::123456789012:mfa/alice", "arn:aws:iam::123456789012:mfa/alice-backup"},
+		},
+		{
+			name:      "no devices",
+			iamClient: fakeIAM{mfaOutput: &iam.ListMFADevicesOutput{}},
+			want:      []string{},
+		},
+		{
+			name:          "iam failure",
+			iamClient:     fakeIAM{mfaErr: errors.New("AccessDenied")},
+			wantErrSubstr: "failed to list MFA devices: AccessDenied",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{}, fakeIAMFactory{client: tc.iamClient}, fakeOrganizationsFactory{})
+			serials, err := svc.ListMFADevices(context.Background(), "test-profile")
+
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListMFADevices returned error: %v", err)
+			}
+			if strings.Join(serials, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("expected %v, got %v", tc.want, serials)
+			}
+		})
+	}
+}
+
+func TestSDKServiceSimulatePrincipalPolicy(t *testing.T) {
+	t.Parallel()
+
+	simulated := &iam.SimulatePrincipalPolicyOutput{
+		EvaluationResults: []iamtypes.EvaluationResult{
+			{EvalActionName: awsv2.String("aws-portal:ViewBilling"), EvalDecision: iamtypes.PolicyEvaluationDecisionTypeAllowed},
+			{EvalActionName: awsv2.String("ce:GetCostAndUsage"), EvalDecision: iamtypes.PolicyEvaluationDecisionTypeImplicitDeny},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		principalARN  string
+		iamClient     fakeIAM
+		wantSource    string
+		wantDecisions map[string]bool
+		wantErrSubstr string
+	}{
+		{
+			name:         "iam user",
+			principalARN: "arn:aws:iam::123456789012:user/alice",
+			iamClient:    fakeIAM{simulateOutput: simulated},
+			wantSource:   "arn:aws:iam::123456789012:user/alice",
+			wantDecisions: map[string]bool{
+				"aws-portal:ViewBilling": true,
+				"ce:GetCostAndUsage":     false,
+			},
+		},
+		{
+			name:         "assumed role resolves role ARN",
+			principalARN: "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_abc/alice",
+			iamClient: fakeIAM{
+				getRoleOutput: &iam.GetRoleOutput{Role: &iamtypes.Role{
+					Arn: awsv2.String("arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Admin_abc"),
+				}},
+				simulateOutput: simulated,
+			},
+			wantSource: "arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Admin_abc",
+			wantDecisions: map[string]bool{
+				"aws-portal:ViewBilling": true,
+				"ce:GetCostAndUsage":     false,
+			},
+		},
+		{
+			name:          "role lookup failure",
+			principalARN:  "arn:aws:sts::123456789012:assumed-role/Admin/alice",
+			iamClient:     fakeIAM{getRoleErr: errors.New("access denied")},
+			wantErrSubstr: "failed to look up role Admin: access denied",
+		},
+		{
+			name:          "simulation failure",
+			principalARN:  "arn:aws:iam::123456789012:user/alice",
+			iamClient:     fakeIAM{simulateErr: errors.New("not authorized")},
+			wantErrSubstr: "not authorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var source string
+			client := tc.iamClient
+			client.simulatedSource = &source
+
+			svc := newSDKService(fakeConfigLoader{cfg: awsv2.Config{}}, fakeSTSFactory{client: fakeSTS{}}, fakeIAMFactory{client: client}, fakeOrganizationsFactory{})
+			decisions, err := svc.SimulatePrincipalPolicy(context.Background(), "test-profile", tc.principalARN, []string{"aws-portal:ViewBilling", "ce:GetCostAndUsage"})
+
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SimulatePrincipalPolicy returned error: %v", err)
+			}
+			if source != tc.wantSource {
+				t.Fatalf("expected simulation against %q, got %q", tc.wantSource, source)
+			}
+			for action, want := range tc.wantDecisions {
+				if decisions[action] != want {
+					t.Fatalf("expected %s allowed=%v, got %v", action, want, decisions[action])
+				}
+			}
+		})
+	}
+}
+
+func TestSDKServiceDescribeAccount(t *testing.T) {
+	t.Parallel()
+
+	denied := &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not allowed"}
+
+	testCases := []struct {
+		name          string
+		iamClient     fakeIAM
+		orgClient     fakeOrganizations
+		want          AccountInfo
+		wantErrSubstr string
+	}{
+		{
+			name:      "alias and name",
+			iamClient: fakeIAM{aliasesOutput: &iam.ListAccountAliasesOutput{AccountAliases: []string{"acme-prod"}}},
+			orgClient: fakeOrganizations{describeOutput: &organizations.DescribeAccountOutput{
+				Account: &orgtypes.Account{Name: awsv2.String("Production")},
+			}},
+			want: AccountInfo{ID: "123456789012", Alias: "acme-prod", Name: "Production"},
+		},
+		{
+			name:      "denied lookups are left empty",
+			iamClient: fakeIAM{aliasesErr: &smithy.GenericAPIError{Code: "AccessDenied"}},
+			orgClient: fakeOrganizations{describeErr: denied},
+			want:      AccountInfo{ID: "123456789012"},
+		},
+		{
+			name:      "account outside an organization",
+			iamClient: fakeIAM{aliasesOutput: &iam.ListAccountAliasesOutput{}},
+			orgClient: fakeOrganizations{describeErr: &smithy.GenericAPIError{Code: "AWSOrganizationsNotInUseException"}},
+			want:      AccountInfo{ID: "123456789012"},
+		},
+		{
+			name:          "other alias errors fail",
+			iamClient:     fakeIAM{aliasesErr: errors.New("connection reset")},
+			orgClient:     fakeOrganizations{describeErr: denied},
+			wantErrSubstr: "failed to list account aliases: connection reset",
+		},
+		{
+			name:          "other organizations errors fail",
+			iamClient:     fakeIAM{aliasesOutput: &iam.ListAccountAliasesOutput{}},
+			orgClient:     fakeOrganizations{describeErr: errors.New("throttled")},
+			wantErrSubstr: "failed to describe account 123456789012: throttled",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{}, fakeIAMFactory{client: tc.iamClient}, fakeOrganizationsFactory{client: tc.orgClient})
+			info, err := svc.DescribeAccount(context.Background(), "test-profile", "123456789012")
+
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DescribeAccount returned error: %v", err)
+			}
+			if info != tc.want {
+				t.Fatalf("expected %+v, got %+v", tc.want, info)
+			}
+		})
+	}
+}
+
+func TestSDKServiceListAccounts(t *testing.T) {
+	t.Parallel()
+
+	org := fakeOrganizations{listPages: []*organizations.ListAccountsOutput{
+		{Accounts: []orgtypes.Account{
+			{Id: awsv2.String("111111111111"), Name: awsv2.String("Management"), Email: awsv2.String("root@example.com"), State: orgtypes.AccountStateActive},
+		}},
+		{Accounts: []orgtypes.Account{
+			{Id: awsv2.String("222222222222"), Name: awsv2.String("Legacy"), Status: orgtypes.AccountStatusSuspended},
+		}},
+	}}
+	svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{client: org})
+	accounts, err := svc.ListAccounts(context.Background(), "test-profile")
+	if err != nil {
+		t.Fatalf("ListAccounts returned error: %v", err)
+	}
+	want := []OrganizationAccount{
+		{ID: "111111111111", Name: "Management", Email: "root@example.com", State: "ACTIVE"},
+		{ID: "222222222222", Name: "Legacy", State: "SUSPENDED"},
+	}
+	if !slices.Equal(accounts, want) {
+		t.Fatalf("expected %+v, got %+v", want, accounts)
+	}
+
+	svc = newSDKService(fakeConfigLoader{}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{client: fakeOrganizations{
+		listErr: &smithy.GenericAPIError{Code: "AWSOrganizationsNotInUseException"},
+	}})
+	if _, err := svc.ListAccounts(context.Background(), "test-profile"); err == nil || !strings.Contains(err.Error(), "failed to list the accounts of the organization") {
+		t.Fatalf("expected the listing to fail, got %v", err)
+	}
+}
+
+func TestSDKServiceGetAccountAlias(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		iamClient     fakeIAM
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name:      "alias",
+			iamClient: fakeIAM{aliasesOutput: &iam.ListAccountAliasesOutput{AccountAliases: []string{"acme-prod"}}},
+			want:      "acme-prod",
+		},
+		{
+			name:      "no alias",
+			iamClient: fakeIAM{aliasesOutput: &iam.ListAccountAliasesOutput{}},
+		},
+		{
+			name:      "missing permission",
+			iamClient: fakeIAM{aliasesErr: &smithy.GenericAPIError{Code: "AccessDenied", Message: "not authorized to perform: iam:ListAccountAliases"}},
+		},
+		{
+			name:          "other errors fail",
+			iamClient:     fakeIAM{aliasesErr: errors.New("connection reset")},
+			wantErrSubstr: "failed to list account aliases: connection reset",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{}, fakeIAMFactory{client: tc.iamClient}, fakeOrganizationsFactory{})
+			alias, err := svc.GetAccountAlias(context.Background(), "test-profile")
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAccountAlias returned error: %v", err)
+			}
+			if alias != tc.want {
+				t.Fatalf("expected alias %q, got %q", tc.want, alias)
+			}
+		})
+	}
+}
+
+func TestSDKServiceAssumeRole(t *testing.T) {
+	t.Parallel()
+
+	expiration := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	assumed := &sts.AssumeRoleOutput{Credentials: &ststypes.Credentials{
+		AccessKeyId:     awsv2.String("ASIAROLE"),
+		SecretAccessKey: awsv2.String("secret"),
+		SessionToken:    awsv2.String("token"),
+		Expiration:      awsv2.Time(expiration),
+	}}
+
+	testCases := []struct {
+		name          string
+		input         AssumeRoleInput
+		stsClient     fakeSTS
+		wantRequest   sts.AssumeRoleInput
+		wantErrSubstr string
+	}{
+		{
+			name:      "defaults",
+			input:     AssumeRoleInput{RoleARN: "arn:aws:iam::210987654321:role/Admin"},
+			stsClient: fakeSTS{assumeRoleOutput: assumed},
+			wantRequest: sts.AssumeRoleInput{
+				RoleArn:         awsv2.String("arn:aws:iam::210987654321:role/Admin"),
+				RoleSessionName: awsv2.String(DefaultRoleSessionName),
+			},
+		},
+		{
+			name: "all options",
+			input: AssumeRoleInput{
+				RoleARN:         "arn:aws:iam::210987654321:role/Admin",
+				ExternalID:      "ext-123",
+				SessionName:     "alice",
+				MFASerial:       "arn:aws:iam::123456789012:mfa/alice",
+				MFAToken:        "123456",
+				DurationSeconds: 3600,
+			},
+			stsClient: fakeSTS{assumeRoleOutput: assumed},
+			wantRequest: sts.AssumeRoleInput{
+				RoleArn:         awsv2.String("arn:aws:iam::210987654321:role/Admin"),
+				RoleSessionName: awsv2.String("alice"),
+				ExternalId:      awsv2.String("ext-123"),
+				SerialNumber:    awsv2.String("arn:aws:iam::123456789012:mfa/alice"),
+				TokenCode:       awsv2.String("123456"),
+				DurationSeconds: awsv2.Int32(3600),
+			},
+		},
+		{
+			name: "session policy",
+			input: AssumeRoleInput{
+				RoleARN:       "arn:aws:iam::210987654321:role/Admin",
+				SessionPolicy: &SessionPolicy{PolicyARNs: []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}, Policy: `{"Version":"2012-10-17"}`},
+			},
+			stsClient: fakeSTS{assumeRoleOutput: assumed},
+			wantRequest: sts.AssumeRoleInput{
+				RoleArn:         awsv2.String("arn:aws:iam::210987654321:role/Admin"),
+				RoleSessionName: awsv2.String(DefaultRoleSessionName),
+				PolicyArns:      []ststypes.PolicyDescriptorType{{Arn: awsv2.String("arn:aws:iam::aws:policy/ReadOnlyAccess")}},
+				Policy:          awsv2.String(`{"Version":"2012-10-17"}`),
+			},
+		},
+		{
+			name: "session tags and source identity",
+			input: AssumeRoleInput{
+				RoleARN:           "arn:aws:iam::210987654321:role/Admin",
+				Tags:              []SessionTag{{Key: "team", Value: "payments"}, {Key: "env", Value: "prod"}},
+				TransitiveTagKeys: []string{"team"},
+				SourceIdentity:    "alice@example.com",
+				Reason:            "INC-1234",
+			},
+			stsClient: fakeSTS{assumeRoleOutput: assumed},
+			wantRequest: sts.AssumeRoleInput{
+				RoleArn:           awsv2.String("arn:aws:iam::210987654321:role/Admin"),
+				RoleSessionName:   awsv2.String(DefaultRoleSessionName),
+				Tags:              []ststypes.Tag{{Key: awsv2.String("team"), Value: awsv2.String("payments")}, {Key: awsv2.String("env"), Value: awsv2.String("prod")}, {Key: awsv2.String("Reason"), Value: awsv2.String("INC-1234")}},
+				TransitiveTagKeys: []string{"team"},
+				SourceIdentity:    awsv2.String("alice@example.com"),
+			},
+		},
+		{
+			name:          "sts error",
+			input:         AssumeRoleInput{RoleARN: "arn:aws:iam::210987654321:role/Admin"},
+			stsClient:     fakeSTS{assumeRoleErr: errors.New("AccessDenied")},
+			wantErrSubstr: "AccessDenied",
+		},
+		{
+			name:          "empty credentials",
+			input:         AssumeRoleInput{RoleARN: "arn:aws:iam::210987654321:role/Admin"},
+			stsClient:     fakeSTS{assumeRoleOutput: &sts.AssumeRoleOutput{}},
+			wantErrSubstr: "STS AssumeRole returned empty credentials",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var request sts.AssumeRoleInput
+			client := tc.stsClient
+			client.assumeRoleInput = &request
+
+			svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{client: client}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+			creds, err := svc.AssumeRole(context.Background(), "dev", tc.input)
+
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AssumeRole returned error: %v", err)
+			}
+			if creds.AccessKeyID != "ASIAROLE" || creds.SessionToken != "token" || !creds.Expires.Equal(expiration) {
+				t.Fatalf("unexpected credentials: %+v", creds)
+			}
+			if awsv2.ToString(request.RoleArn) != awsv2.ToString(tc.wantRequest.RoleArn) ||
+				awsv2.ToString(request.RoleSessionName) != awsv2.ToString(tc.wantRequest.RoleSessionName) ||
+				awsv2.ToString(request.ExternalId) != awsv2.ToString(tc.wantRequest.ExternalId) ||
+				awsv2.ToString(request.SerialNumber) != awsv2.ToString(tc.wantRequest.SerialNumber) ||
+				awsv2.ToString(request.TokenCode) != awsv2.ToString(tc.wantRequest.TokenCode) ||
+				awsv2.ToInt32(request.DurationSeconds) != awsv2.ToInt32(tc.wantRequest.DurationSeconds) ||
+				awsv2.ToString(request.Policy) != awsv2.ToString(tc.wantRequest.Policy) ||
+				policyARNs(request.PolicyArns) != policyARNs(tc.wantRequest.PolicyArns) ||
+				sessionTags(request.Tags) != sessionTags(tc.wantRequest.Tags) ||
+				strings.Join(request.TransitiveTagKeys, ",") != strings.Join(tc.wantRequest.TransitiveTagKeys, ",") ||
+				awsv2.ToString(request.SourceIdentity) != awsv2.ToString(tc.wantRequest.SourceIdentity) {
+				t.Fatalf("unexpected AssumeRole request: %+v", request)
+			}
+		})
+	}
+}
+
+func TestSDKServiceGetFederationToken(t *testing.T) {
+	t.Parallel()
+
+	expiration := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	issued := &sts.GetFederationTokenOutput{Credentials: &ststypes.Credentials{
+		AccessKeyId:     awsv2.String("ASIAFED"),
+		SecretAccessKey: awsv2.String("secret"),
+		SessionToken:    awsv2.String("token"),
+		Expiration:      awsv2.Time(expiration),
+	}}
+	readOnly := ReadOnlySessionPolicy(PartitionAWS)
+
+	testCases := []struct {
+		name          string
+		input         FederationTokenInput
+		stsClient     fakeSTS
+		wantName      string
+		wantErrSubstr string
+	}{
+		{
+			name:      "default name",
+			input:     FederationTokenInput{DurationSeconds: 3600, SessionPolicy: readOnly},
+			stsClient: fakeSTS{federationTokenOutput: issued},
+			wantName:  DefaultRoleSessionName,
+		},
+		{
+			name:      "named",
+			input:     FederationTokenInput{Name: "alice", DurationSeconds: 3600, SessionPolicy: readOnly},
+			stsClient: fakeSTS{federationTokenOutput: issued},
+			wantName:  "alice",
+		},
+		{
+			name:          "no session policy",
+			input:         FederationTokenInput{DurationSeconds: 3600},
+			stsClient:     fakeSTS{federationTokenOutput: issued},
+			wantErrSubstr: "a federation token requires a session policy",
+		},
+		{
+			name:          "sts error",
+			input:         FederationTokenInput{SessionPolicy: readOnly},
+			stsClient:     fakeSTS{federationTokenErr: errors.New("AccessDenied")},
+			wantErrSubstr: "AccessDenied",
+		},
+		{
+			name:          "empty credentials",
+			input:         FederationTokenInput{SessionPolicy: readOnly},
+			stsClient:     fakeSTS{federationTokenOutput: &sts.GetFederationTokenOutput{}},
+			wantErrSubstr: "STS GetFederationToken returned empty credentials",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var request sts.GetFederationTokenInput
+			client := tc.stsClient
+			client.federationTokenInput = &request
+
+			svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{client: client}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+			creds, err := svc.GetFederationToken(context.Background(), "alice", tc.input)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetFederationToken returned error: %v", err)
+			}
+			if creds.AccessKeyID != "ASIAFED" || creds.SessionToken != "token" || !creds.Expires.Equal(expiration) {
+				t.Fatalf("unexpected credentials: %+v", creds)
+			}
+			if awsv2.ToString(request.Name) != tc.wantName ||
+				awsv2.ToInt32(request.DurationSeconds) != tc.input.DurationSeconds ||
+				policyARNs(request.PolicyArns) != "arn:aws:iam::aws:policy/ReadOnlyAccess" ||
+				request.Policy != nil {
+				t.Fatalf("unexpected GetFederationToken request: %+v", request)
+			}
+		})
+	}
+}
+
+func TestSDKServiceGetRoleARN(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		sessionARN    string
+		iamClient     fakeIAM
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name:       "role with path",
+			sessionARN: "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_abc/alice",
+			iamClient: fakeIAM{getRoleOutput: &iam.GetRoleOutput{Role: &iamtypes.Role{
+				Arn: awsv2.String("arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Admin_abc"),
+			}}},
+			want: "arn:aws:iam::123456789012:role/aws-reserved/sso.amazonaws.com/AWSReservedSSO_Admin_abc",
+		},
+		{
+			name:          "not a role session",
+			sessionARN:    "arn:aws:iam::123456789012:user/alice",
+			wantErrSubstr: "arn:aws:iam::123456789012:user/alice is not an assumed-role session",
+		},
+		{
+			name:          "lookup denied",
+			sessionARN:    "arn:aws:sts::123456789012:assumed-role/Admin/alice",
+			iamClient:     fakeIAM{getRoleErr: errors.New("AccessDenied")},
+			wantErrSubstr: "failed to look up role Admin: AccessDenied",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := newSDKService(fakeConfigLoader{}, fakeSTSFactory{}, fakeIAMFactory{client: tc.iamClient}, fakeOrganizationsFactory{})
+			got, err := svc.GetRoleARN(context.Background(), "dev", tc.sessionARN)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRoleARN returned error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+// policyARNs joins the ARNs of the session policies of an STS request.
+func sessionTags(tags []ststypes.Tag) string {
+	pairs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		pairs = append(pairs, awsv2.ToString(tag.Key)+"="+awsv2.ToString(tag.Value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func policyARNs(policies []ststypes.PolicyDescriptorType) string {
+	arns := make([]string, 0, len(policies))
+	for _, p := range policies {
+		arns = append(arns, awsv2.ToString(p.Arn))
+	}
+	return strings.Join(arns, ",")
+}
@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultSessionNameTemplate names role sessions after the local user and the time they
+// were opened, so CloudTrail shows who assumed the role.
+const DefaultSessionNameTemplate = "aws-console-{user}-{time}"
+
+// ReasonTagKey is the session tag AssumeRoleInput.Reason is attached as.
+const ReasonTagKey = "Reason"
+
+// MaxRoleSessionNameLength is the longest role session name STS accepts.
+const MaxRoleSessionNameLength = 64
+
+var (
+	sessionNamePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+	sessionNameInvalid     = regexp.MustCompile(`[^\w+=,.@-]+`)
+	ticketPattern          = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[0-9]+\b`)
+)
+
+// SessionNameValues are what the placeholders of a session name template expand to.
+type SessionNameValues struct {
+	// User and Host name the local user and machine.
+	User string
+	Host string
+	// Reason is the --reason the session was opened for; {ticket} is the first ticket ID,
+	// such as INC-1234, found in it.
+	Reason string
+	Time   time.Time
+}
+
+// ValidateSessionNameTemplate checks that template uses only the placeholders {user},
+// {host}, {reason}, {ticket}, and {time}.
+func ValidateSessionNameTemplate(template string) error {
+	for _, placeholder := range sessionNamePlaceholder.FindAllString(template, -1) {
+		switch placeholder {
+		case "{user}", "{host}", "{reason}", "{ticket}", "{time}":
+		default:
+			return fmt.Errorf("invalid session name template %q: unknown placeholder %s (expected {user}, {host}, {reason}, {ticket}, or {time})", template, placeholder)
+		}
+	}
+	return nil
+}
+
+// ExpandSessionName expands the placeholders of template with v. Characters STS does
+// not allow in role session names become dashes, and the name is cut to
+// MaxRoleSessionNameLength. An empty result is DefaultRoleSessionName.
+func ExpandSessionName(template string, v SessionNameValues) string {
+	name := strings.NewReplacer(
+		"{user}", v.User,
+		"{host}", v.Host,
+		"{reason}", v.Reason,
+		"{ticket}", ticketPattern.FindString(v.Reason),
+		"{time}", v.Time.UTC().Format("20060102T150405Z"),
+	).Replace(template)
+	name = sessionNameInvalid.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-")
+	// Empty placeholders leave doubled dashes behind.
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	if len(name) > MaxRoleSessionNameLength {
+		name = strings.TrimRight(name[:MaxRoleSessionNameLength], "-")
+	}
+	if len(name) < 2 {
+		return DefaultRoleSessionName
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSessionNameTemplate(t *testing.T) {
+	t.Parallel()
+
+	for _, template := range []string{DefaultSessionNameTemplate, "{host}-{ticket}", "static"} {
+		if err := ValidateSessionNameTemplate(template); err != nil {
+			t.Fatalf("unexpected error for %q: %v", template, err)
+		}
+	}
+	err := ValidateSessionNameTemplate("aws-console-{username}")
+	if err == nil || !strings.Contains(err.Error(), "unknown placeholder {username}") {
+		t.Fatalf("expected an unknown placeholder error, got %v", err)
+	}
+}
+
+func TestExpandSessionName(t *testing.T) {
+	t.Parallel()
+
+	values := SessionNameValues{
+		User:   "alice",
+		Host:   "build-01.example.com",
+		Reason: "OPS-42: restart the payments API",
+		Time:   time.Date(2026, 10, 16, 9, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	}
+
+	testCases := []struct {
+		name     string
+		template string
+		values   SessionNameValues
+		want     string
+	}{
+		{name: "default", template: DefaultSessionNameTemplate, values: values, want: "aws-console-alice-20261016T073000Z"},
+		{name: "host and ticket", template: "{user}@{host}-{ticket}", values: values, want: "alice@build-01.example.com-OPS-42"},
+		{name: "reason is sanitized", template: "{reason}", values: values, want: "OPS-42-restart-the-payments-API"},
+		{name: "domain user", template: "{user}", values: SessionNameValues{User: `CORP\alice`}, want: "CORP-alice"},
+		{name: "empty placeholders", template: "aws-console-{ticket}-{user}", values: SessionNameValues{User: "alice"}, want: "aws-console-alice"},
+		{name: "too long", template: "{reason}", values: SessionNameValues{Reason: strings.Repeat("a", 63) + " b"}, want: strings.Repeat("a", 63)},
+		{name: "nothing left", template: "{ticket}", values: SessionNameValues{}, want: DefaultRoleSessionName},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ExpandSessionName(tc.template, tc.values); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/eculver/aws-console/pkg/aws/arn"
+)
+
+// ReadOnlyAccessPolicy is the name of the AWS managed policy that grants read-only
+// access to every service.
+const ReadOnlyAccessPolicy = "ReadOnlyAccess"
+
+// MaxSessionPolicyARNs is the most managed policies STS accepts in one session policy.
+const MaxSessionPolicyARNs = 10
+
+// SessionPolicy limits temporary credentials to the permissions that both the policies
+// of the principal requesting them and the session policy allow.
+type SessionPolicy struct {
+	// PolicyARNs are managed policies, such as ManagedPolicyARN(partition, "ReadOnlyAccess").
+	PolicyARNs []string
+	// Policy is an inline policy document in JSON.
+	Policy string
+}
+
+// ManagedPolicyARN returns the ARN of the AWS managed policy name in partition.
+func ManagedPolicyARN(partition, name string) string {
+	if partition == "" {
+		partition = PartitionAWS
+	}
+	return "arn:" + partition + ":iam::aws:policy/" + name
+}
+
+// ReadOnlySessionPolicy returns the session policy of read-only console sessions in
+// partition, the AWS managed ReadOnlyAccess policy.
+func ReadOnlySessionPolicy(partition string) *SessionPolicy {
+	return &SessionPolicy{PolicyARNs: []string{ManagedPolicyARN(partition, ReadOnlyAccessPolicy)}}
+}
+
+// Validate checks that p limits the session with at most MaxSessionPolicyARNs managed
+// policies, each named by a valid ARN.
+func (p *SessionPolicy) Validate() error {
+	if len(p.PolicyARNs) == 0 && p.Policy == "" {
+		return errors.New("a session policy needs a policy document or managed policy ARNs")
+	}
+	if len(p.PolicyARNs) > MaxSessionPolicyARNs {
+		return fmt.Errorf("a session policy can have at most %d managed policy ARNs, got %d", MaxSessionPolicyARNs, len(p.PolicyARNs))
+	}
+	for _, arn := range p.PolicyARNs {
+		if err := ValidatePolicyARN(arn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidatePolicyARN checks that arn names an IAM managed policy.
+func ValidatePolicyARN(s string) error {
+	a, err := arn.Parse(s)
+	if err != nil || a.AccountID == "" {
+		return fmt.Errorf("invalid policy ARN %q (expected arn:<partition>:iam::<account>:policy/<name>)", s)
+	}
+	if _, ok := a.Policy(); !ok {
+		return fmt.Errorf("invalid policy ARN %q (expected arn:<partition>:iam::<account>:policy/<name>)", s)
+	}
+	return nil
+}
+
+// ParsePolicyDocument checks that doc is a JSON IAM policy document and returns it
+// compacted, since STS limits the packed size of session policies.
+func ParsePolicyDocument(doc []byte) (string, error) {
+	var policy map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		return "", fmt.Errorf("invalid policy document: %w", err)
+	}
+	if _, ok := policy["Statement"]; !ok {
+		return "", errors.New("invalid policy document: no Statement")
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, doc); err != nil {
+		return "", fmt.Errorf("invalid policy document: %w", err)
+	}
+	return compact.String(), nil
+}
@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadOnlySessionPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		partition string
+		want      string
+	}{
+		{partition: "", want: "arn:aws:iam::aws:policy/ReadOnlyAccess"},
+		{partition: PartitionAWS, want: "arn:aws:iam::aws:policy/ReadOnlyAccess"},
+		{partition: PartitionUSGov, want: "arn:aws-us-gov:iam::aws:policy/ReadOnlyAccess"},
+		{partition: PartitionChina, want: "arn:aws-cn:iam::aws:policy/ReadOnlyAccess"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.want, func(t *testing.T) {
+			t.Parallel()
+
+			p := ReadOnlySessionPolicy(tc.partition)
+			if len(p.PolicyARNs) != 1 || p.PolicyARNs[0] != tc.want || p.Policy != "" {
+				t.Fatalf("unexpected session policy %+v", p)
+			}
+		})
+	}
+}
+
+func TestSessionPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make([]string, MaxSessionPolicyARNs+1)
+	for i := range tooMany {
+		tooMany[i] = ManagedPolicyARN("", ReadOnlyAccessPolicy)
+	}
+
+	testCases := []struct {
+		name          string
+		policy        SessionPolicy
+		wantErrSubstr string
+	}{
+		{name: "managed policies", policy: SessionPolicy{PolicyARNs: []string{"arn:aws:iam::aws:policy/ReadOnlyAccess", "arn:aws:iam::123456789012:policy/team/Deploy"}}},
+		{name: "inline policy", policy: SessionPolicy{Policy: `{"Statement":[]}`}},
+		{name: "empty", wantErrSubstr: "needs a policy document or managed policy ARNs"},
+		{name: "too many", policy: SessionPolicy{PolicyARNs: tooMany}, wantErrSubstr: "at most 10 managed policy ARNs, got 11"},
+		{name: "role ARN", policy: SessionPolicy{PolicyARNs: []string{"arn:aws:iam::123456789012:role/Admin"}}, wantErrSubstr: `invalid policy ARN "arn:aws:iam::123456789012:role/Admin"`},
+		{name: "no name", policy: SessionPolicy{PolicyARNs: []string{"arn:aws:iam::aws:policy/"}}, wantErrSubstr: "invalid policy ARN"},
+		{name: "no account", policy: SessionPolicy{PolicyARNs: []string{"arn:aws:iam:::policy/Deploy"}}, wantErrSubstr: "invalid policy ARN"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.policy.Validate()
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParsePolicyDocument(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		doc           string
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name: "compacted",
+			doc:  "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": [{\"Effect\": \"Allow\", \"Action\": \"s3:Get*\", \"Resource\": \"*\"}]\n}\n",
+			want: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:Get*","Resource":"*"}]}`,
+		},
+		{name: "not JSON", doc: "Version: 2012-10-17", wantErrSubstr: "invalid policy document"},
+		{name: "not an object", doc: `["s3:Get*"]`, wantErrSubstr: "invalid policy document"},
+		{name: "no statement", doc: `{"Version":"2012-10-17"}`, wantErrSubstr: "no Statement"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParsePolicyDocument([]byte(tc.doc))
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits STS places on the tags of a role session.
+const (
+	MaxSessionTags           = 50
+	MaxSessionTagKeyLength   = 128
+	MaxSessionTagValueLength = 256
+)
+
+var (
+	sessionTagKeyPattern   = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]+$`)
+	sessionTagValuePattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+	sourceIdentityPattern  = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+)
+
+// SessionTag is a key-value pair passed to AssumeRole. IAM policies of the role and of
+// the resources it reaches can refer to it as aws:PrincipalTag/<key>, and CloudTrail
+// records it with the session.
+type SessionTag struct {
+	Key   string
+	Value string
+}
+
+// String returns the tag as key=value.
+func (t SessionTag) String() string {
+	return t.Key + "=" + t.Value
+}
+
+// ParseSessionTag parses a key=value session tag. The value may be empty.
+func ParseSessionTag(s string) (SessionTag, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return SessionTag{}, fmt.Errorf("invalid session tag %q (expected key=value)", s)
+	}
+	tag := SessionTag{Key: key, Value: value}
+	if err := tag.Validate(); err != nil {
+		return SessionTag{}, err
+	}
+	return tag, nil
+}
+
+// Validate checks the key and value of t against the characters and lengths STS allows.
+func (t SessionTag) Validate() error {
+	if len(t.Key) > MaxSessionTagKeyLength || !sessionTagKeyPattern.MatchString(t.Key) {
+		return fmt.Errorf("invalid session tag key %q (1-%d letters, digits, spaces, or _.:/=+-@ characters)", t.Key, MaxSessionTagKeyLength)
+	}
+	if len(t.Value) > MaxSessionTagValueLength || !sessionTagValuePattern.MatchString(t.Value) {
+		return fmt.Errorf("invalid value for session tag %s (up to %d letters, digits, spaces, or _.:/=+-@ characters)", t.Key, MaxSessionTagValueLength)
+	}
+	return nil
+}
+
+// ValidateSessionTags checks tags and transitive keys as a whole: at most MaxSessionTags
+// tags, no key given twice (STS compares keys case-insensitively), and every transitive
+// key naming one of the tags.
+func ValidateSessionTags(tags []SessionTag, transitiveKeys []string) error {
+	if len(tags) > MaxSessionTags {
+		return fmt.Errorf("a role session can have at most %d tags, got %d", MaxSessionTags, len(tags))
+	}
+	keys := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if err := tag.Validate(); err != nil {
+			return err
+		}
+		folded := strings.ToLower(tag.Key)
+		if keys[folded] {
+			return fmt.Errorf("session tag %s is given more than once", tag.Key)
+		}
+		keys[folded] = true
+	}
+	for _, key := range transitiveKeys {
+		if !keys[strings.ToLower(key)] {
+			return fmt.Errorf("transitive tag key %s is not one of the session tags", key)
+		}
+	}
+	return nil
+}
+
+// ValidateSourceIdentity checks that identity is a source identity STS accepts.
+func ValidateSourceIdentity(identity string) error {
+	if !sourceIdentityPattern.MatchString(identity) {
+		return fmt.Errorf("invalid source identity %q (2-64 letters, digits, or +=,.@_- characters)", identity)
+	}
+	return nil
+}
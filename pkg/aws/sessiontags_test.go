@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseSessionTag(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input         string
+		want          SessionTag
+		wantErrSubstr string
+	}{
+		{input: "team=payments", want: SessionTag{Key: "team", Value: "payments"}},
+		{input: "cost-center=", want: SessionTag{Key: "cost-center"}},
+		{input: "purpose=incident 1234: db=down", want: SessionTag{Key: "purpose", Value: "incident 1234: db=down"}},
+		{input: "team", wantErrSubstr: "expected key=value"},
+		{input: "=payments", wantErrSubstr: "invalid session tag key"},
+		{input: "team=pay;ments", wantErrSubstr: "invalid value for session tag team"},
+		{input: strings.Repeat("k", MaxSessionTagKeyLength+1) + "=v", wantErrSubstr: "invalid session tag key"},
+		{input: "k=" + strings.Repeat("v", MaxSessionTagValueLength+1), wantErrSubstr: "invalid value for session tag k"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseSessionTag(tc.input)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateSessionTags(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make([]SessionTag, MaxSessionTags+1)
+	for i := range tooMany {
+		tooMany[i] = SessionTag{Key: fmt.Sprintf("tag%d", i)}
+	}
+
+	testCases := []struct {
+		name           string
+		tags           []SessionTag
+		transitiveKeys []string
+		wantErrSubstr  string
+	}{
+		{name: "none"},
+		{name: "transitive", tags: []SessionTag{{Key: "team", Value: "payments"}, {Key: "env", Value: "prod"}}, transitiveKeys: []string{"Team"}},
+		{name: "too many", tags: tooMany, wantErrSubstr: "at most 50 tags, got 51"},
+		{name: "duplicate key", tags: []SessionTag{{Key: "team", Value: "a"}, {Key: "Team", Value: "b"}}, wantErrSubstr: "session tag Team is given more than once"},
+		{name: "unknown transitive key", tags: []SessionTag{{Key: "team", Value: "payments"}}, transitiveKeys: []string{"env"}, wantErrSubstr: "transitive tag key env is not one of the session tags"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateSessionTags(tc.tags, tc.transitiveKeys)
+			if tc.wantErrSubstr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSourceIdentity(t *testing.T) {
+	t.Parallel()
+
+	for _, identity := range []string{"alice", "alice@example.com", "ci-deploy_42"} {
+		if err := ValidateSourceIdentity(identity); err != nil {
+			t.Fatalf("unexpected error for %q: %v", identity, err)
+		}
+	}
+	for _, identity := range []string{"a", "alice smith", "aws:alice", strings.Repeat("a", 65)} {
+		if err := ValidateSourceIdentity(identity); err == nil {
+			t.Fatalf("expected an error for %q", identity)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package aws
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eculver/aws-console/pkg/aws/arn"
+)
+
+// Profile source types reported by ListProfiles.
+const (
+	ProfileSourceSSO               = "sso"
+	ProfileSourceAssumeRole        = "assume-role"
+	ProfileSourceWebIdentity       = "web-identity"
+	ProfileSourceCredentialProcess = "credential-process"
+	ProfileSourceStatic            = "static"
+	ProfileSourceSecretManager     = "secret-manager"
+	ProfileSourceUnknown           = "unknown"
+)
+
+// SharedConfig reads profiles from the shared AWS config and credentials files.
+type SharedConfig struct {
+	configFile      string
+	credentialsFile string
+}
+
+// NewSharedConfig creates a reader for the shared config and credentials files, honoring
+// AWS_CONFIG_FILE and AWS_SHARED_CREDENTIALS_FILE.
+func NewSharedConfig() *SharedConfig {
+	return newSharedConfig(defaultConfigFile(), defaultCredentialsFile())
+}
+
+func newSharedConfig(configFile, credentialsFile string) *SharedConfig {
+	return &SharedConfig{configFile: configFile, credentialsFile: credentialsFile}
+}
+
+func defaultConfigFile() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", "config")
+}
+
+func defaultCredentialsFile() string {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", "credentials")
+}
+
+// ListProfiles returns the profiles defined in the shared config file, in file order,
+// followed by profiles that only appear in the credentials file. Keys from the credentials
+// file are merged into the config profile of the same name. Missing files yield no profiles.
+func (s *SharedConfig) ListProfiles() ([]Profile, error) {
+	sections, err := s.sections()
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := readINIFile(s.credentialsFile, "AWS credentials file")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	keysByName := map[string]map[string]string{}
+	for _, section := range sections {
+		name, ok := profileName(section.name)
+		if !ok {
+			continue
+		}
+		if _, seen := keysByName[name]; !seen {
+			names = append(names, name)
+			keysByName[name] = map[string]string{}
+		}
+		mergeKeys(keysByName[name], section.keys)
+	}
+	// The credentials file names its sections after the profile, without a "profile " prefix.
+	for _, section := range credentials {
+		name := strings.TrimSpace(section.name)
+		if name == "" {
+			continue
+		}
+		if _, seen := keysByName[name]; !seen {
+			names = append(names, name)
+			keysByName[name] = map[string]string{}
+		}
+		mergeKeys(keysByName[name], section.keys)
+	}
+
+	profiles := make([]Profile, 0, len(names))
+	for _, name := range names {
+		profiles = append(profiles, profileFromKeys(name, keysByName[name]))
+	}
+	return profiles, nil
+}
+
+func mergeKeys(dst, src map[string]string) {
+	for key, value := range src {
+		dst[key] = value
+	}
+}
+
+// SSOSession returns the [sso-session name] section of the shared config.
+func (s *SharedConfig) SSOSession(name string) (SSOSession, error) {
+	sections, err := s.sections()
+	if err != nil {
+		return SSOSession{}, err
+	}
+
+	for _, section := range sections {
+		sessionName, ok := strings.CutPrefix(section.name, "sso-session ")
+		if !ok || strings.TrimSpace(sessionName) != name {
+			continue
+		}
+		return SSOSession{
+			Name:               name,
+			StartURL:           section.keys["sso_start_url"],
+			Region:             section.keys["sso_region"],
+			RegistrationScopes: splitList(section.keys["sso_registration_scopes"]),
+			ClientName:         section.keys["aws_console_client_name"],
+			RegistrationCache:  section.keys["aws_console_registration_cache"],
+		}, nil
+	}
+	return SSOSession{}, fmt.Errorf("sso-session %q not found in AWS config", name)
+}
+
+// sections reads and parses the config file. A missing file has no sections.
+func (s *SharedConfig) sections() ([]iniSection, error) {
+	return readINIFile(s.configFile, "AWS config file")
+}
+
+// readINIFile reads and parses path, described as kind in errors. A missing file has no sections.
+func readINIFile(path, kind string) ([]iniSection, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", kind, err)
+	}
+	defer f.Close()
+
+	sections, err := parseINI(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s %s: %w", kind, path, err)
+	}
+	return sections, nil
+}
+
+// splitList splits a comma-separated config value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// profileName maps a config section header to a profile name.
+func profileName(section string) (string, bool) {
+	if section == "default" {
+		return "default", true
+	}
+	if name, ok := strings.CutPrefix(section, "profile "); ok {
+		name = strings.TrimSpace(name)
+		return name, name != ""
+	}
+	return "", false
+}
+
+func profileFromKeys(name string, keys map[string]string) Profile {
+	profile := Profile{
+		Name:               name,
+		Region:             keys["region"],
+		Source:             ProfileSourceUnknown,
+		MFASerial:          keys["mfa_serial"],
+		STSEndpoint:        keys["aws_console_sts_endpoint"],
+		FederationEndpoint: keys["aws_console_federation_endpoint"],
+		Browser:            keys["aws_console_browser"],
+		BrowserProfile:     keys["aws_console_browser_profile"],
+		Container:          keys["aws_console_container"],
+		IsolateAccounts:    keys["aws_console_isolate_accounts"],
+		Partition:          keys["aws_console_partition"],
+		Issuer:             keys["aws_console_issuer"],
+		Destination:        keys["aws_console_destination"],
+		Color:              keys["aws_console_color"],
+		CredentialSource:   keys["aws_console_credential_source"],
+		MFASource:          keys["aws_console_mfa_source"],
+		Validate:           keys["aws_console_validate"],
+		OrgRole:            keys["aws_console_org_role"],
+		Fallback:           keys["aws_console_fallback"],
+		CABundle:           keys["ca_bundle"],
+	}
+
+	switch {
+	case keys["sso_session"] != "" || keys["sso_start_url"] != "":
+		profile.Source = ProfileSourceSSO
+		profile.SSOSession = keys["sso_session"]
+		profile.SSOStartURL = keys["sso_start_url"]
+		profile.SSORegion = keys["sso_region"]
+		profile.AccountID = keys["sso_account_id"]
+		profile.RoleName = keys["sso_role_name"]
+	case keys["role_arn"] != "" && keys["web_identity_token_file"] != "":
+		profile.Source = ProfileSourceWebIdentity
+		profile.RoleARN = keys["role_arn"]
+		profile.AccountID, profile.RoleName = splitRoleARN(profile.RoleARN)
+	case keys["role_arn"] != "":
+		profile.Source = ProfileSourceAssumeRole
+		profile.RoleARN = keys["role_arn"]
+		profile.SourceProfile = keys["source_profile"]
+		profile.AccountID, profile.RoleName = splitRoleARN(profile.RoleARN)
+	case keys["credential_process"] != "":
+		profile.Source = ProfileSourceCredentialProcess
+	case keys["aws_console_credential_source"] != "":
+		profile.Source = ProfileSourceSecretManager
+	case keys["aws_access_key_id"] != "":
+		profile.Source = ProfileSourceStatic
+	}
+
+	return profile
+}
+
+// splitRoleARN extracts the account ID and role name from an IAM role ARN such as
+// arn:aws:iam::123456789012:role/path/Admin.
+func splitRoleARN(roleARN string) (string, string) {
+	a, err := arn.Parse(roleARN)
+	if err != nil {
+		return "", ""
+	}
+	name, ok := a.Role()
+	if !ok {
+		return a.AccountID, ""
+	}
+	return a.AccountID, name[strings.LastIndex(name, "/")+1:]
+}
+
+type iniSection struct {
+	name string
+	keys map[string]string
+}
+
+// parseINI parses the subset of INI used by the AWS shared config files. Unknown keys are kept,
+// nested sub-properties (indented lines) are ignored, and comments start with '#' or ';'.
+func parseINI(r io.Reader) ([]iniSection, error) {
+	var sections []iniSection
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineNo)
+			}
+			sections = append(sections, iniSection{
+				name: strings.Join(strings.Fields(line[1:end]), " "),
+				keys: map[string]string{},
+			})
+			continue
+		}
+
+		if raw[0] == ' ' || raw[0] == '\t' {
+			// Continuation of a nested property block (e.g. s3 settings).
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		if len(sections) == 0 {
+			return nil, fmt.Errorf("line %d: key outside of a section", lineNo)
+		}
+		sections[len(sections)-1].keys[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
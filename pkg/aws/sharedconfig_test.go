@@ -0,0 +1,228 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleSharedConfig = `
+# comment line
+[default]
+region = us-east-1
+
+[profile dev]
+sso_session = my-sso
+sso_account_id = 123456789012
+sso_role_name = AdministratorAccess
+region = us-west-2
+aws_console_fallback = breakglass, prod-admin
+
+[profile prod-admin]
+aws_console_sts_endpoint = https://sts.{region}.internal.example.com
+role_arn = arn:aws:iam::210987654321:role/ops/Admin
+source_profile = dev
+s3 =
+  max_concurrent_requests = 20
+
+[profile ci]
+role_arn = arn:aws:iam::111122223333:role/CI
+web_identity_token_file = /tmp/token
+
+[profile vault]
+credential_process = /usr/local/bin/vault-creds
+ca_bundle = /etc/ssl/corp-ca.pem
+aws_console_partition = aws-cn
+aws_console_issuer = https://sso.example.com/aws
+
+[profile keys]
+aws_access_key_id = AKIA_TEST
+aws_secret_access_key = secret
+mfa_serial = arn:aws:iam::123456789012:mfa/alice
+aws_console_browser = firefox
+aws_console_browser_profile = work
+aws_console_container = keys-{account}
+aws_console_destination = cloudwatch
+aws_console_color = red
+
+[profile vaulted-keys]
+mfa_serial = arn:aws:iam::123456789012:mfa/bob
+aws_console_credential_source = 1password:Private/AWS
+aws_console_mfa_source = 1password:Private/AWS
+
+[profile legacy-sso]
+sso_start_url = https://legacy.awsapps.com/start
+sso_region = eu-west-1
+sso_account_id = 444455556666
+sso_role_name = ReadOnly
+
+[sso-session my-sso]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+sso_registration_scopes = sso:account:access, codewhisperer:completions
+aws_console_client_name = acme-aws-console
+aws_console_registration_cache = ~/.cache/acme/sso
+`
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestSharedConfigListProfiles(t *testing.T) {
+	t.Parallel()
+
+	profiles, err := newSharedConfig(writeConfigFile(t, sampleSharedConfig), "").ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles returned error: %v", err)
+	}
+
+	want := []Profile{
+		{Name: "default", Source: ProfileSourceUnknown, Region: "us-east-1"},
+		{Name: "dev", Source: ProfileSourceSSO, Region: "us-west-2", AccountID: "123456789012", RoleName: "AdministratorAccess", SSOSession: "my-sso", Fallback: "breakglass, prod-admin"},
+		{Name: "prod-admin", Source: ProfileSourceAssumeRole, AccountID: "210987654321", RoleName: "Admin", RoleARN: "arn:aws:iam::210987654321:role/ops/Admin", SourceProfile: "dev", STSEndpoint: "https://sts.{region}.internal.example.com"},
+		{Name: "ci", Source: ProfileSourceWebIdentity, AccountID: "111122223333", RoleName: "CI", RoleARN: "arn:aws:iam::111122223333:role/CI"},
+		{Name: "vault", Source: ProfileSourceCredentialProcess, CABundle: "/etc/ssl/corp-ca.pem", Partition: "aws-cn", Issuer: "https://sso.example.com/aws"},
+		{Name: "keys", Source: ProfileSourceStatic, MFASerial: "arn:aws:iam::123456789012:mfa/alice", Browser: "firefox", BrowserProfile: "work", Container: "keys-{account}", Destination: "cloudwatch", Color: "red"},
+		{Name: "vaulted-keys", Source: ProfileSourceSecretManager, MFASerial: "arn:aws:iam::123456789012:mfa/bob", CredentialSource: "1password:Private/AWS", MFASource: "1password:Private/AWS"},
+		{Name: "legacy-sso", Source: ProfileSourceSSO, AccountID: "444455556666", RoleName: "ReadOnly", SSOStartURL: "https://legacy.awsapps.com/start", SSORegion: "eu-west-1"},
+	}
+
+	if len(profiles) != len(want) {
+		t.Fatalf("expected %d profiles, got %d: %+v", len(want), len(profiles), profiles)
+	}
+	for i := range want {
+		if profiles[i] != want[i] {
+			t.Fatalf("profile %d: got %+v want %+v", i, profiles[i], want[i])
+		}
+	}
+	if got := profiles[1].FallbackProfiles(); strings.Join(got, " ") != "breakglass prod-admin" {
+		t.Fatalf("FallbackProfiles() = %q, want [breakglass prod-admin]", got)
+	}
+}
+
+func TestSharedConfigListProfilesMergesCredentialsFile(t *testing.T) {
+	t.Parallel()
+
+	configFile := writeConfigFile(t, `
+[default]
+region = us-east-1
+
+[profile dev]
+sso_session = my-sso
+sso_account_id = 123456789012
+sso_role_name = AdministratorAccess
+`)
+	credentialsFile := filepath.Join(t.TempDir(), "credentials")
+	credentials := `
+[default]
+aws_access_key_id = AKIA_DEFAULT
+aws_secret_access_key = secret
+
+[legacy]
+aws_access_key_id = AKIA_LEGACY
+aws_secret_access_key = secret
+region = eu-west-1
+`
+	if err := os.WriteFile(credentialsFile, []byte(credentials), 0o600); err != nil {
+		t.Fatalf("failed to write credentials file: %v", err)
+	}
+
+	profiles, err := newSharedConfig(configFile, credentialsFile).ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles returned error: %v", err)
+	}
+
+	want := []Profile{
+		{Name: "default", Source: ProfileSourceStatic, Region: "us-east-1"},
+		{Name: "dev", Source: ProfileSourceSSO, AccountID: "123456789012", RoleName: "AdministratorAccess", SSOSession: "my-sso"},
+		{Name: "legacy", Source: ProfileSourceStatic, Region: "eu-west-1"},
+	}
+	if len(profiles) != len(want) {
+		t.Fatalf("expected %d profiles, got %d: %+v", len(want), len(profiles), profiles)
+	}
+	for i := range want {
+		if profiles[i] != want[i] {
+			t.Fatalf("profile %d: got %+v want %+v", i, profiles[i], want[i])
+		}
+	}
+}
+
+func TestSharedConfigListProfilesMissingFile(t *testing.T) {
+	t.Parallel()
+
+	profiles, err := newSharedConfig(filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "missing")).ListProfiles()
+	if err != nil {
+		t.Fatalf("expected no error for missing file, got %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Fatalf("expected no profiles, got %+v", profiles)
+	}
+}
+
+func TestSharedConfigListProfilesParseErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		contents      string
+		wantErrSubstr string
+	}{
+		{
+			name:          "unterminated section",
+			contents:      "[profile dev\nregion = us-east-1\n",
+			wantErrSubstr: "line 1: unterminated section header",
+		},
+		{
+			name:          "key outside section",
+			contents:      "region = us-east-1\n",
+			wantErrSubstr: "line 1: key outside of a section",
+		},
+		{
+			name:          "missing separator",
+			contents:      "[default]\nregion\n",
+			wantErrSubstr: "line 2: expected key = value",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newSharedConfig(writeConfigFile(t, tc.contents), "").ListProfiles()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
+
+func TestSharedConfigSSOSession(t *testing.T) {
+	t.Parallel()
+
+	cfg := newSharedConfig(writeConfigFile(t, sampleSharedConfig), "")
+
+	session, err := cfg.SSOSession("my-sso")
+	if err != nil {
+		t.Fatalf("SSOSession returned error: %v", err)
+	}
+	if session.Name != "my-sso" || session.StartURL != "https://example.awsapps.com/start" || session.Region != "us-east-1" {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+	if strings.Join(session.RegistrationScopes, "|") != "sso:account:access|codewhisperer:completions" {
+		t.Fatalf("unexpected scopes: %v", session.RegistrationScopes)
+	}
+	if session.ClientName != "acme-aws-console" || session.RegistrationCache != "~/.cache/acme/sso" {
+		t.Fatalf("unexpected client settings: %+v", session)
+	}
+
+	if _, err := cfg.SSOSession("missing"); err == nil || !strings.Contains(err.Error(), `sso-session "missing" not found`) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package ssocache reads and writes the SSO token cache shared with the AWS CLI and
+// SDKs under ~/.aws/sso/cache, so token expiry can be checked without calling AWS.
+package ssocache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoToken is returned when no token is cached for a session.
+var ErrNoToken = errors.New("no cached SSO token")
+
+// Token is a cached SSO access token, in the format written by `aws sso login`.
+type Token struct {
+	StartURL    string    `json:"startUrl,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	// The refresh token and client registration are only cached for sso-session
+	// profiles; the SDKs use them to renew an expired access token without a login.
+	RefreshToken          string    `json:"refreshToken,omitempty"`
+	ClientID              string    `json:"clientId,omitempty"`
+	ClientSecret          string    `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt time.Time `json:"registrationExpiresAt,omitempty"`
+}
+
+// tokenFile mirrors Token with the timestamps as strings, since older AWS CLI versions
+// write them as "2006-01-02T15:04:05UTC" rather than RFC 3339.
+type tokenFile struct {
+	StartURL              string `json:"startUrl,omitempty"`
+	Region                string `json:"region,omitempty"`
+	AccessToken           string `json:"accessToken"`
+	ExpiresAt             string `json:"expiresAt"`
+	RefreshToken          string `json:"refreshToken,omitempty"`
+	ClientID              string `json:"clientId,omitempty"`
+	ClientSecret          string `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt string `json:"registrationExpiresAt,omitempty"`
+}
+
+// Expired reports whether the access token has expired at now.
+func (t Token) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// Refreshable reports whether an expired access token can still be renewed with its
+// refresh token at now.
+func (t Token) Refreshable(now time.Time) bool {
+	return t.RefreshToken != "" && t.ClientID != "" && now.Before(t.RegistrationExpiresAt)
+}
+
+// Cache is a directory of cached SSO tokens.
+type Cache struct {
+	dir string
+}
+
+// NewCache returns the cache in ~/.aws/sso/cache. An unresolvable home directory yields
+// a cache that holds no tokens.
+func NewCache() *Cache {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return NewCacheAt("")
+	}
+	return NewCacheAt(filepath.Join(home, ".aws", "sso", "cache"))
+}
+
+// NewCacheAt returns the cache in dir.
+func NewCacheAt(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Key returns the cache key for a profile: its sso-session name, or its start URL for
+// profiles configured without an sso-session.
+func Key(session, startURL string) string {
+	if session != "" {
+		return session
+	}
+	return startURL
+}
+
+// Path returns the file holding the token for key.
+func (c *Cache) Path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Token returns the cached token for key, or ErrNoToken.
+func (c *Cache) Token(key string) (Token, error) {
+	if c.dir == "" || key == "" {
+		return Token{}, ErrNoToken
+	}
+	return ReadToken(c.Path(key))
+}
+
+// ReadToken returns the token cached in the file at path, or ErrNoToken.
+func ReadToken(path string) (Token, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Token{}, ErrNoToken
+	}
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to read SSO token cache: %w", err)
+	}
+
+	var f tokenFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Token{}, fmt.Errorf("failed to parse SSO token cache %s: %w", path, err)
+	}
+	if f.AccessToken == "" {
+		return Token{}, ErrNoToken
+	}
+
+	t := Token{
+		StartURL:     f.StartURL,
+		Region:       f.Region,
+		AccessToken:  f.AccessToken,
+		RefreshToken: f.RefreshToken,
+		ClientID:     f.ClientID,
+		ClientSecret: f.ClientSecret,
+	}
+	if t.ExpiresAt, err = parseTime(f.ExpiresAt); err != nil {
+		return Token{}, fmt.Errorf("invalid expiresAt in SSO token cache %s: %w", path, err)
+	}
+	if f.RegistrationExpiresAt != "" {
+		if t.RegistrationExpiresAt, err = parseTime(f.RegistrationExpiresAt); err != nil {
+			return Token{}, fmt.Errorf("invalid registrationExpiresAt in SSO token cache %s: %w", path, err)
+		}
+	}
+	return t, nil
+}
+
+// Put stores t for key, readable only by the owner.
+func (c *Cache) Put(key string, t Token) error {
+	if c.dir == "" {
+		return errors.New("no SSO token cache directory")
+	}
+
+	f := tokenFile{
+		StartURL:     t.StartURL,
+		Region:       t.Region,
+		AccessToken:  t.AccessToken,
+		ExpiresAt:    t.ExpiresAt.UTC().Format(time.RFC3339),
+		RefreshToken: t.RefreshToken,
+		ClientID:     t.ClientID,
+		ClientSecret: t.ClientSecret,
+	}
+	if !t.RegistrationExpiresAt.IsZero() {
+		f.RegistrationExpiresAt = t.RegistrationExpiresAt.UTC().Format(time.RFC3339)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SSO token: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create SSO token cache directory: %w", err)
+	}
+	path := c.Path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write SSO token: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write SSO token: %w", err)
+	}
+	return nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if strings.HasSuffix(value, "UTC") {
+		return time.Parse("2006-01-02T15:04:05UTC", value)
+	}
+	return time.Parse(time.RFC3339, value)
+}
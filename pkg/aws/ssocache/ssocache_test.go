@@ -0,0 +1,114 @@
+package ssocache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheToken(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cache := NewCacheAt(dir)
+
+	testCases := []struct {
+		name        string
+		key         string
+		contents    string
+		wantExpires time.Time
+		wantErr     error
+	}{
+		{
+			name:        "sso-session token",
+			key:         "my-sso",
+			contents:    `{"startUrl":"https://example.awsapps.com/start","region":"us-east-1","accessToken":"token","expiresAt":"2030-01-02T03:04:05Z","refreshToken":"refresh","clientId":"client","registrationExpiresAt":"2030-03-01T00:00:00Z"}`,
+			wantExpires: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			name:        "legacy timestamp",
+			key:         "https://legacy.awsapps.com/start",
+			contents:    `{"startUrl":"https://legacy.awsapps.com/start","region":"us-east-1","accessToken":"token","expiresAt":"2019-11-14T04:05:45UTC"}`,
+			wantExpires: time.Date(2019, 11, 14, 4, 5, 45, 0, time.UTC),
+		},
+		{
+			name:    "missing",
+			key:     "other",
+			wantErr: ErrNoToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.contents != "" {
+			if err := os.WriteFile(cache.Path(tc.key), []byte(tc.contents), 0o600); err != nil {
+				t.Fatalf("failed to write token: %v", err)
+			}
+		}
+
+		token, err := cache.Token(tc.key)
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("%s: expected %v, got %v", tc.name, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !token.ExpiresAt.Equal(tc.wantExpires) {
+			t.Fatalf("%s: expected expiry %s, got %s", tc.name, tc.wantExpires, token.ExpiresAt)
+		}
+	}
+}
+
+func TestCacheKeyMatchesAWSCLI(t *testing.T) {
+	t.Parallel()
+
+	// The AWS CLI names cache files after the SHA-1 of the session name or start URL.
+	got := filepath.Base(NewCacheAt("/cache").Path(Key("my-sso", "https://example.awsapps.com/start")))
+	if want := "0ad374308c5a4e22f723adf10145eafad7c4031c.json"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+	if Key("", "https://example.awsapps.com/start") != "https://example.awsapps.com/start" {
+		t.Fatal("expected the start URL to key profiles without an sso-session")
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	token := Token{AccessToken: "token", ExpiresAt: now.Add(-time.Minute)}
+	if !token.Expired(now) || token.Refreshable(now) {
+		t.Fatal("expected an expired token without a refresh token")
+	}
+
+	token.RefreshToken, token.ClientID, token.RegistrationExpiresAt = "refresh", "client", now.Add(time.Hour)
+	if !token.Refreshable(now) {
+		t.Fatal("expected the token to be refreshable")
+	}
+	if token.Refreshable(now.Add(2 * time.Hour)) {
+		t.Fatal("expected an expired registration to prevent refreshing")
+	}
+}
+
+func TestCachePut(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCacheAt(filepath.Join(t.TempDir(), "sso", "cache"))
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := cache.Put("my-sso", Token{AccessToken: "token", ExpiresAt: expires, Region: "us-east-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token, err := cache.Token("my-sso")
+	if err != nil || token.AccessToken != "token" || !token.ExpiresAt.Equal(expires) {
+		t.Fatalf("unexpected token %+v (%v)", token, err)
+	}
+	info, err := os.Stat(cache.Path("my-sso"))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a 0600 token file, got %v (%v)", info, err)
+	}
+}
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	ssoportal "github.com/aws/aws-sdk-go-v2/service/sso"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+)
+
+type ssoAPI interface {
+	GetRoleCredentials(ctx context.Context, params *ssoportal.GetRoleCredentialsInput, optFns ...func(*ssoportal.Options)) (*ssoportal.GetRoleCredentialsOutput, error)
+}
+
+type ssoClientFactory interface {
+	New(region string) ssoAPI
+}
+
+type defaultSSOClientFactory struct{}
+
+func (defaultSSOClientFactory) New(region string) ssoAPI {
+	return ssoportal.New(ssoportal.Options{Region: region})
+}
+
+// SSORole is a role assigned in the SSO access portal, signed in to without a profile
+// in the shared AWS config.
+type SSORole struct {
+	AccountID string
+	RoleName  string
+	StartURL  string
+	// Region is the sso_region of the SSO session.
+	Region string
+	// TokenFile is the cached SSO token that role credentials are requested with.
+	TokenFile string
+}
+
+type ssoRoleKey struct{}
+
+// WithSSORole returns a context that makes Service calls use the credentials of role
+// instead of those of the profile they name.
+func WithSSORole(ctx context.Context, role SSORole) context.Context {
+	return context.WithValue(ctx, ssoRoleKey{}, role)
+}
+
+// SSORoleFromContext returns the role set with WithSSORole, if any.
+func SSORoleFromContext(ctx context.Context) (SSORole, bool) {
+	role, ok := ctx.Value(ssoRoleKey{}).(SSORole)
+	return role, ok
+}
+
+// GetRoleCredentials requests the role's credentials from the access portal with the
+// cached SSO token, without reading the shared AWS config.
+func (s *SDKService) GetRoleCredentials(ctx context.Context, role SSORole) (Credentials, error) {
+	token, err := ssocache.ReadToken(role.TokenFile)
+	if errors.Is(err, ssocache.ErrNoToken) || err == nil && token.Expired(time.Now()) {
+		return Credentials{}, MarkError(fmt.Errorf("the SSO session of %s has expired; sign in again", role.StartURL), ErrCredentialsExpired)
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	out, err := s.ssoFactory.New(role.Region).GetRoleCredentials(ctx, &ssoportal.GetRoleCredentialsInput{
+		AccessToken: awsv2.String(token.AccessToken),
+		AccountId:   awsv2.String(role.AccountID),
+		RoleName:    awsv2.String(role.RoleName),
+	})
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get credentials of role %s in account %s: %w", role.RoleName, role.AccountID, err)
+	}
+	if out.RoleCredentials == nil {
+		return Credentials{}, fmt.Errorf("SSO GetRoleCredentials returned empty credentials")
+	}
+	LoggerFromContext(ctx).Debug("retrieved SSO role credentials", "account", role.AccountID, "role", role.RoleName,
+		"access_key_id", awsv2.ToString(out.RoleCredentials.AccessKeyId))
+
+	return Credentials{
+		AccessKeyID:     awsv2.ToString(out.RoleCredentials.AccessKeyId),
+		SecretAccessKey: awsv2.ToString(out.RoleCredentials.SecretAccessKey),
+		SessionToken:    awsv2.ToString(out.RoleCredentials.SessionToken),
+		Expires:         time.UnixMilli(out.RoleCredentials.Expiration),
+		Source:          "SSOProvider",
+	}, nil
+}
+
+// credentialsProvider returns a provider of the role's credentials for SDK clients.
+func (s *SDKService) credentialsProvider(role SSORole) awsv2.CredentialsProvider {
+	return awsv2.NewCredentialsCache(awsv2.CredentialsProviderFunc(func(ctx context.Context) (awsv2.Credentials, error) {
+		creds, err := s.GetRoleCredentials(ctx, role)
+		if err != nil {
+			return awsv2.Credentials{}, err
+		}
+		return awsv2.Credentials{
+			AccessKeyID:     creds.AccessKeyID,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+			Source:          creds.Source,
+			CanExpire:       true,
+			Expires:         creds.Expires,
+		}, nil
+	}))
+}
@@ -0,0 +1,148 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	ssoportal "github.com/aws/aws-sdk-go-v2/service/sso"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+)
+
+type fakeSSOClient struct {
+	region *string
+	input  *ssoportal.GetRoleCredentialsInput
+	err    error
+}
+
+func (f *fakeSSOClient) New(region string) ssoAPI {
+	*f.region = region
+	return f
+}
+
+func (f *fakeSSOClient) GetRoleCredentials(ctx context.Context, params *ssoportal.GetRoleCredentialsInput, optFns ...func(*ssoportal.Options)) (*ssoportal.GetRoleCredentialsOutput, error) {
+	f.input = params
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ssoportal.GetRoleCredentialsOutput{RoleCredentials: &ssotypes.RoleCredentials{
+		AccessKeyId:     awsv2.String("ASIAROLE"),
+		SecretAccessKey: awsv2.String("secret"),
+		SessionToken:    awsv2.String("session"),
+		Expiration:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+	}}, nil
+}
+
+func TestLoadConfigUsesContextSSORole(t *testing.T) {
+	t.Parallel()
+
+	role := SSORole{AccountID: "123456789012", RoleName: "Admin", StartURL: "https://corp.awsapps.com/start", Region: "us-east-1", TokenFile: "/cache/token.json"}
+	ctx := WithSSORole(context.Background(), role)
+	if got, ok := SSORoleFromContext(ctx); !ok || got != role {
+		t.Fatalf("expected %+v, got %+v", role, got)
+	}
+	if _, ok := SSORoleFromContext(context.Background()); ok {
+		t.Fatal("expected no role")
+	}
+
+	got := &config.LoadOptions{}
+	svc := newSDKService(optionsRecordingLoader{got: got}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+	if _, err := svc.loadConfig(WithRegion(ctx, "eu-central-1"), "123456789012/Admin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.SharedConfigProfile != "" {
+		t.Fatalf("expected the role not to be looked up as a profile, got %q", got.SharedConfigProfile)
+	}
+	if got.SharedConfigFiles == nil || len(got.SharedConfigFiles) != 0 || got.SharedCredentialsFiles == nil || len(got.SharedCredentialsFiles) != 0 {
+		t.Fatalf("expected the shared config files not to be read, got %q and %q", got.SharedConfigFiles, got.SharedCredentialsFiles)
+	}
+	if got.Region != "eu-central-1" {
+		t.Fatalf("expected the chosen region to override the session's, got %q", got.Region)
+	}
+	if got.Credentials == nil {
+		t.Fatal("expected the role's credentials provider")
+	}
+}
+
+func TestGetRoleCredentials(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tokens := ssocache.NewCacheAt(dir)
+	if err := tokens.Put("corp", ssocache.Token{AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("failed to cache token: %v", err)
+	}
+	if err := tokens.Put("expired", ssocache.Token{AccessToken: "token", ExpiresAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("failed to cache token: %v", err)
+	}
+
+	testCases := []struct {
+		name          string
+		tokenFile     string
+		apiErr        error
+		wantErrSubstr string
+		wantExpired   bool
+	}{
+		{name: "signed in", tokenFile: tokens.Path("corp")},
+		{name: "expired token", tokenFile: tokens.Path("expired"), wantErrSubstr: "has expired", wantExpired: true},
+		{name: "missing token", tokenFile: filepath.Join(dir, "missing.json"), wantErrSubstr: "has expired", wantExpired: true},
+		{
+			name:          "not assigned",
+			tokenFile:     tokens.Path("corp"),
+			apiErr:        errors.New("ForbiddenException: No access"),
+			wantErrSubstr: "failed to get credentials of role Admin in account 123456789012: ForbiddenException",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var region string
+			client := &fakeSSOClient{region: &region, err: tc.apiErr}
+			svc := newSDKService(optionsRecordingLoader{got: &config.LoadOptions{}}, fakeSTSFactory{}, fakeIAMFactory{}, fakeOrganizationsFactory{})
+			svc.ssoFactory = client
+
+			role := SSORole{AccountID: "123456789012", RoleName: "Admin", StartURL: "https://corp.awsapps.com/start", Region: "eu-west-1", TokenFile: tc.tokenFile}
+			creds, err := svc.GetRoleCredentials(context.Background(), role)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if errors.Is(err, ErrCredentialsExpired) != tc.wantExpired {
+					t.Fatalf("expected ErrCredentialsExpired to match %v, got %v", tc.wantExpired, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if region != "eu-west-1" || awsv2.ToString(client.input.AccessToken) != "token" ||
+				awsv2.ToString(client.input.AccountId) != "123456789012" || awsv2.ToString(client.input.RoleName) != "Admin" {
+				t.Fatalf("unexpected request in %s: %+v", region, client.input)
+			}
+			want := Credentials{
+				AccessKeyID:     "ASIAROLE",
+				SecretAccessKey: "secret",
+				SessionToken:    "session",
+				Expires:         time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+				Source:          "SSOProvider",
+			}
+			if creds.AccessKeyID != want.AccessKeyID || creds.SecretAccessKey != want.SecretAccessKey || creds.SessionToken != want.SessionToken ||
+				!creds.Expires.Equal(want.Expires) || creds.Source != want.Source {
+				t.Fatalf("expected %+v, got %+v", want, creds)
+			}
+			if CredentialSourceOf(creds.Source) != CredentialSourceSSO {
+				t.Fatalf("expected SSO credentials, got source %q", creds.Source)
+			}
+		})
+	}
+}
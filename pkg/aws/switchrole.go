@@ -0,0 +1,125 @@
+package aws
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/eculver/aws-console/pkg/aws/arn"
+)
+
+// SwitchRole describes a role switch for a user already signed in to the console.
+type SwitchRole struct {
+	// Account is an account ID or alias.
+	Account string
+	// RoleName may include a path, e.g. "ops/Admin".
+	RoleName string
+	// DisplayName labels the session in the console navigation bar.
+	DisplayName string
+	// Color is a color name (see SwitchRoleColors) or a six-digit hex value.
+	Color string
+}
+
+// SwitchRoleColors are the colors offered by the console's switch-role form.
+var SwitchRoleColors = map[string]string{
+	"red":    "F2B0A9",
+	"orange": "FBBF93",
+	"yellow": "FAD791",
+	"green":  "B7CA9D",
+	"blue":   "99BCE3",
+}
+
+const maxSwitchRoleDisplayName = 64
+
+var (
+	accountIDPattern    = regexp.MustCompile(`^[0-9]{12}$`)
+	accountAliasPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{1,61}[a-z0-9])$`)
+	roleNamePattern     = regexp.MustCompile(`^([\w+=,.@-]+/)*[\w+=,.@-]{1,64}$`)
+	hexColorPattern     = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+)
+
+// SwitchRoleURL builds the console switch-role link for r in partition. No credentials
+// are involved; the console prompts the signed-in user to confirm the switch.
+func SwitchRoleURL(partition string, r SwitchRole) (string, error) {
+	endpoints, ok := PartitionEndpoints(partition)
+	if !ok {
+		return "", fmt.Errorf("console role switching is not available in partition %q", partition)
+	}
+	if !validAccount(r.Account) {
+		return "", fmt.Errorf("invalid account %q (expected a 12-digit account ID or an account alias)", r.Account)
+	}
+	if !roleNamePattern.MatchString(r.RoleName) {
+		return "", fmt.Errorf("invalid role name %q", r.RoleName)
+	}
+	if len(r.DisplayName) > maxSwitchRoleDisplayName {
+		return "", fmt.Errorf("display name must be at most %d characters", maxSwitchRoleDisplayName)
+	}
+
+	query := url.Values{}
+	query.Set("account", r.Account)
+	query.Set("roleName", r.RoleName)
+	if r.DisplayName != "" {
+		query.Set("displayName", r.DisplayName)
+	}
+	if r.Color != "" {
+		color, err := switchRoleColor(r.Color)
+		if err != nil {
+			return "", err
+		}
+		query.Set("color", color)
+	}
+
+	base := strings.TrimSuffix(endpoints.FederationURL, "/federation") + "/switchrole"
+	return base + "?" + query.Encode(), nil
+}
+
+// validAccount accepts account IDs and aliases. All-digit values are taken to be
+// account IDs, so a mistyped ID is not mistaken for an alias.
+func validAccount(account string) bool {
+	if strings.Trim(account, "0123456789") == "" {
+		return accountIDPattern.MatchString(account)
+	}
+	return accountAliasPattern.MatchString(account)
+}
+
+// ValidateSwitchRoleColor checks a switch-role session color.
+func ValidateSwitchRoleColor(color string) error {
+	_, err := switchRoleColor(color)
+	return err
+}
+
+func switchRoleColor(color string) (string, error) {
+	if hex, ok := SwitchRoleColors[strings.ToLower(color)]; ok {
+		return hex, nil
+	}
+	if hexColorPattern.MatchString(color) {
+		return strings.ToUpper(color), nil
+	}
+	return "", fmt.Errorf("invalid color %q (expected red, orange, yellow, green, blue, or a hex value such as F2B0A9)", color)
+}
+
+// ParseRoleARN splits an IAM role ARN into its partition, account ID, and role name
+// (including any path).
+func ParseRoleARN(s string) (partition, account, roleName string, err error) {
+	a, err := arn.Parse(s)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid role ARN %q", s)
+	}
+	roleName, ok := a.Role()
+	if !ok {
+		return "", "", "", fmt.Errorf("invalid role ARN %q", s)
+	}
+	return a.Partition, a.AccountID, roleName, nil
+}
+
+// RoleARN builds the ARN of the role named roleName (including any path) in account.
+func RoleARN(partition, account, roleName string) (string, error) {
+	if !accountIDPattern.MatchString(account) {
+		return "", fmt.Errorf("invalid account ID %q (expected 12 digits)", account)
+	}
+	if !roleNamePattern.MatchString(roleName) {
+		return "", fmt.Errorf("invalid role name %q", roleName)
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, account, roleName), nil
+}
@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSwitchRoleURL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		partition     string
+		role          SwitchRole
+		want          string
+		wantErrSubstr string
+	}{
+		{
+			name:      "minimal",
+			partition: PartitionAWS,
+			role:      SwitchRole{Account: "999988887777", RoleName: "Admin"},
+			want:      "https://signin.aws.amazon.com/switchrole?account=999988887777&roleName=Admin",
+		},
+		{
+			name:      "display name and named color",
+			partition: PartitionAWS,
+			role:      SwitchRole{Account: "999988887777", RoleName: "ops/Admin", DisplayName: "prod admin", Color: "Red"},
+			want:      "https://signin.aws.amazon.com/switchrole?account=999988887777&color=F2B0A9&displayName=prod+admin&roleName=ops%2FAdmin",
+		},
+		{
+			name:      "hex color and alias",
+			partition: PartitionAWS,
+			role:      SwitchRole{Account: "my-org-prod", RoleName: "Admin", Color: "99bce3"},
+			want:      "https://signin.aws.amazon.com/switchrole?account=my-org-prod&color=99BCE3&roleName=Admin",
+		},
+		{
+			name:      "govcloud",
+			partition: PartitionUSGov,
+			role:      SwitchRole{Account: "999988887777", RoleName: "Admin"},
+			want:      "https://signin.amazonaws-us-gov.com/switchrole?account=999988887777&roleName=Admin",
+		},
+		{
+			name:          "invalid account",
+			partition:     PartitionAWS,
+			role:          SwitchRole{Account: "999", RoleName: "Admin"},
+			wantErrSubstr: `invalid account "999"`,
+		},
+		{
+			name:          "invalid role",
+			partition:     PartitionAWS,
+			role:          SwitchRole{Account: "999988887777", RoleName: "Admin&x=y"},
+			wantErrSubstr: `invalid role name "Admin&x=y"`,
+		},
+		{
+			name:          "invalid color",
+			partition:     PartitionAWS,
+			role:          SwitchRole{Account: "999988887777", RoleName: "Admin", Color: "purple"},
+			wantErrSubstr: `invalid color "purple"`,
+		},
+		{
+			name:          "display name too long",
+			partition:     PartitionAWS,
+			role:          SwitchRole{Account: "999988887777", RoleName: "Admin", DisplayName: strings.Repeat("x", 65)},
+			wantErrSubstr: "display name must be at most 64 characters",
+		},
+		{
+			name:          "unsupported partition",
+			partition:     "aws-iso",
+			role:          SwitchRole{Account: "999988887777", RoleName: "Admin"},
+			wantErrSubstr: `not available in partition "aws-iso"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := SwitchRoleURL(tc.partition, tc.role)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRoleARN(t *testing.T) {
+	t.Parallel()
+
+	partition, account, role, err := ParseRoleARN("arn:aws-us-gov:iam::999988887777:role/ops/Admin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if partition != "aws-us-gov" || account != "999988887777" || role != "ops/Admin" {
+		t.Fatalf("unexpected parse result: %q %q %q", partition, account, role)
+	}
+
+	for _, arn := range []string{"Admin", "arn:aws:iam::999988887777:user/bob", "arn:aws:sts::999988887777:assumed-role/Admin/me"} {
+		if _, _, _, err := ParseRoleARN(arn); err == nil {
+			t.Fatalf("expected %q to be rejected", arn)
+		}
+	}
+}
+
+func TestRoleARN(t *testing.T) {
+	t.Parallel()
+
+	arn, err := RoleARN("aws-cn", "999988887777", DefaultOrganizationRole)
+	if err != nil || arn != "arn:aws-cn:iam::999988887777:role/OrganizationAccountAccessRole" {
+		t.Fatalf("unexpected role ARN %q, %v", arn, err)
+	}
+	if _, err := RoleARN("aws", "acme-prod", "Admin"); err == nil {
+		t.Fatal("expected an account alias to be rejected")
+	}
+	if _, err := RoleARN("aws", "999988887777", "Admin role"); err == nil {
+		t.Fatal("expected an invalid role name to be rejected")
+	}
+}
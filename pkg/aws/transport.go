@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportOptions configure how the federation client reaches the federation endpoint.
+type TransportOptions struct {
+	// Proxy is the URL of the proxy every request goes through. When empty, HTTPS_PROXY,
+	// HTTP_PROXY, and NO_PROXY are honored.
+	Proxy string
+	// CABundle is a PEM file of certificates trusted in addition to the system roots,
+	// such as the root of a TLS-intercepting proxy.
+	CABundle string
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool
+}
+
+// ValidateProxy checks that proxy is an http, https, or socks5 URL with a host.
+func ValidateProxy(proxy string) error {
+	_, err := parseProxy(proxy)
+	return err
+}
+
+func parseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q (expected a URL such as http://proxy.example.com:3128)", proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("invalid proxy %q: unsupported scheme %q (expected http, https, or socks5)", proxy, u.Scheme)
+}
+
+// NewTransport returns an HTTP transport configured by opts, with the defaults of
+// http.DefaultTransport otherwise.
+func NewTransport(opts TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxy, err := parseProxy(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if opts.CABundle == "" && !opts.InsecureSkipVerify {
+		return transport, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CABundle != "" {
+		roots, err := loadCABundle(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = roots
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// loadCABundle returns the system roots together with the certificates in path.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("failed to read CA bundle: no PEM certificates in %s", path)
+	}
+	return roots, nil
+}
@@ -1,10 +1,48 @@
 package aws
 
-import "context"
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/aws/arn"
+)
 
 // Identity captures the principal that authenticated with STS.
 type Identity struct {
-	Arn string
+	Arn     string
+	Account string
+	// UserID is the unique ID of the principal, e.g. "AROAEXAMPLE:session" for a role.
+	UserID string
+	// Partition is parsed from Arn, e.g. "aws" or "aws-us-gov".
+	Partition string
+}
+
+// ParseARN parses the principal's ARN.
+func (i Identity) ParseARN() (arn.ARN, error) {
+	return arn.Parse(i.Arn)
+}
+
+// RoleName returns the role of an assumed-role session, or "" for other principals.
+func (i Identity) RoleName() string {
+	return assumedRoleName(i.Arn)
+}
+
+// SessionName returns the role session name of an assumed-role session, which for SSO
+// roles is the user's name in the identity store, or "" for other principals.
+func (i Identity) SessionName() string {
+	a, err := i.ParseARN()
+	if err != nil {
+		return ""
+	}
+	_, session, _ := a.AssumedRole()
+	return session
+}
+
+// IsRoot reports whether the principal is the root user of its account.
+func (i Identity) IsRoot() bool {
+	a, err := i.ParseARN()
+	return err == nil && a.IsRoot()
 }
 
 // Credentials are temporary or long-lived AWS credentials.
@@ -12,16 +50,260 @@ type Credentials struct {
 	AccessKeyID     string
 	SecretAccessKey string
 	SessionToken    string
+	// Expires is zero when the credentials do not expire.
+	Expires time.Time
+	// Kind is how temporary credentials were obtained; empty for long-lived keys.
+	Kind CredentialKind
+	// Source names the SDK provider that supplied the credentials, e.g. "SSOProvider".
+	Source string
+}
+
+// ValidAt reports whether the credentials are still valid at t. Credentials that do not
+// expire always are.
+func (c Credentials) ValidAt(t time.Time) bool {
+	return c.Expires.IsZero() || t.Before(c.Expires)
+}
+
+// Remaining returns how long after now the credentials stay valid, or zero once they
+// have expired. It is meaningless for credentials that do not expire.
+func (c Credentials) Remaining(now time.Time) time.Duration {
+	return max(c.Expires.Sub(now), 0)
 }
 
 // Service handles credential and identity operations against AWS APIs.
 type Service interface {
 	GetCallerIdentity(ctx context.Context, profile string) (Identity, error)
 	RetrieveCredentials(ctx context.Context, profile string) (Credentials, error)
-	GetSessionToken(ctx context.Context, profile string, durationSeconds int32) (Credentials, error)
+	// GetSessionToken exchanges the profile's long-lived IAM user keys for temporary credentials.
+	GetSessionToken(ctx context.Context, profile string, input SessionTokenInput) (Credentials, error)
+	// ListMFADevices returns the serial numbers of the MFA devices assigned to the profile's IAM user.
+	ListMFADevices(ctx context.Context, profile string) ([]string, error)
+	// SimulatePrincipalPolicy reports, per action, whether the principal's IAM policies allow it.
+	// Assumed-role session ARNs are resolved to their underlying role.
+	SimulatePrincipalPolicy(ctx context.Context, profile string, principalARN string, actions []string) (map[string]bool, error)
+	// GetAccountAlias returns the IAM alias of the profile's account, or "" when it has
+	// none or the principal may not list it.
+	GetAccountAlias(ctx context.Context, profile string) (string, error)
+	// DescribeAccount looks up the account's IAM alias and Organizations name. Lookups the
+	// principal is not allowed to make leave the corresponding field empty.
+	DescribeAccount(ctx context.Context, profile string, accountID string) (AccountInfo, error)
+	// ListAccounts returns every account of the profile's organization. Only the
+	// management account and delegated administrators may list them.
+	ListAccounts(ctx context.Context, profile string) ([]OrganizationAccount, error)
+	// AssumeRole assumes input.RoleARN with the profile's credentials.
+	AssumeRole(ctx context.Context, profile string, input AssumeRoleInput) (Credentials, error)
+	// GetFederationToken exchanges the profile's long-lived IAM user keys for temporary
+	// credentials of a federated user, limited by input.SessionPolicy.
+	GetFederationToken(ctx context.Context, profile string, input FederationTokenInput) (Credentials, error)
+	// GetRoleARN returns the ARN, including its path, of the IAM role behind the
+	// assumed-role session sessionARN.
+	GetRoleARN(ctx context.Context, profile string, sessionARN string) (string, error)
+	// GetRoleCredentials requests the credentials of a role assigned in the SSO access
+	// portal, for which no profile exists.
+	GetRoleCredentials(ctx context.Context, role SSORole) (Credentials, error)
+	// AssumeRoleWithSAML exchanges a SAML assertion from an identity provider for the
+	// credentials of a role, for which no profile exists.
+	AssumeRoleWithSAML(ctx context.Context, input SAMLRoleInput) (Credentials, error)
+	// AssumeRoleWithWebIdentity exchanges an OIDC token, such as a CI job's, for the
+	// credentials of a role, for which no profile exists.
+	AssumeRoleWithWebIdentity(ctx context.Context, input WebIdentityRoleInput) (Credentials, error)
+}
+
+// AssumeRoleInput describes a role to assume on top of a profile's credentials.
+type AssumeRoleInput struct {
+	RoleARN    string
+	ExternalID string
+	// SessionName defaults to DefaultRoleSessionName.
+	SessionName string
+	// MFASerial and MFAToken are required when the role's trust policy demands MFA.
+	MFASerial       string
+	MFAToken        string
+	DurationSeconds int32
+	// SessionPolicy, when set, limits the role session to part of the role's permissions.
+	SessionPolicy *SessionPolicy
+	// Tags are attached to the role session. Those named by TransitiveTagKeys also pass
+	// on to roles assumed from it.
+	Tags              []SessionTag
+	TransitiveTagKeys []string
+	// SourceIdentity is recorded in CloudTrail for the session and every role session
+	// chained from it, and cannot be changed by them.
+	SourceIdentity string
+	// Reason, when set, says why the session was opened. It is attached as the
+	// ReasonTagKey session tag.
+	Reason string
+}
+
+// SessionTags returns Tags along with the tag Reason is attached as.
+func (i AssumeRoleInput) SessionTags() []SessionTag {
+	if i.Reason == "" {
+		return i.Tags
+	}
+	return append(slices.Clip(i.Tags), SessionTag{Key: ReasonTagKey, Value: i.Reason})
+}
+
+// FederationTokenInput describes a GetFederationToken request.
+type FederationTokenInput struct {
+	// Name is the federated user's name, shown in CloudTrail.
+	Name            string
+	DurationSeconds int32
+	// SessionPolicy is required: a federated user without one has no permissions.
+	SessionPolicy *SessionPolicy
+}
+
+// SessionTokenInput describes a GetSessionToken request.
+type SessionTokenInput struct {
+	DurationSeconds int32
+	// MFASerial and MFAToken are required when the user's policies demand MFA.
+	MFASerial string
+	MFAToken  string
+}
+
+// AccountInfo is descriptive metadata about an AWS account.
+type AccountInfo struct {
+	ID    string
+	Alias string
+	Name  string
+}
+
+// DefaultOrganizationRole is the role AWS Organizations creates in the accounts it
+// creates, trusted by the management account.
+const DefaultOrganizationRole = "OrganizationAccountAccessRole"
+
+// OrganizationAccount is a member account of an AWS organization.
+type OrganizationAccount struct {
+	ID    string
+	Name  string
+	Email string
+	// State is ACTIVE, SUSPENDED, PENDING_CLOSURE, or another Organizations account state.
+	State string
+}
+
+// Active reports whether the account can be signed in to.
+func (a OrganizationAccount) Active() bool {
+	return a.State == "" || a.State == "ACTIVE"
 }
 
-// FederationURLBuilder builds a federated console login URL.
+// FederationURLBuilder builds a federated console login URL. The destination is a
+// console path relative to the console root (e.g. "health/home") or an absolute console
+// URL; an empty destination lands on the console home page.
 type FederationURLBuilder interface {
-	BuildConsoleURL(ctx context.Context, creds Credentials, durationSeconds int32) (string, error)
+	BuildConsoleURL(ctx context.Context, creds Credentials, durationSeconds int32, destination string) (string, error)
+	// BuildConsoleURLs fetches a single sign-in token and returns one login URL per
+	// destination, in order.
+	BuildConsoleURLs(ctx context.Context, creds Credentials, durationSeconds int32, destinations []string) ([]string, error)
+}
+
+// Profile is a named profile from the shared AWS config.
+type Profile struct {
+	Name      string
+	Source    string
+	Region    string
+	AccountID string
+	RoleName  string
+	// RoleARN is the role_arn of assume-role and web identity profiles.
+	RoleARN string
+	// SourceProfile is the source_profile of assume-role profiles, the profile whose
+	// credentials assume the role.
+	SourceProfile string
+	// SSOSession names the [sso-session] section used by SSO profiles, if any.
+	SSOSession string
+	// SSOStartURL and SSORegion are set for SSO profiles configured without an
+	// sso-session.
+	SSOStartURL string
+	SSORegion   string
+	// MFASerial is the mfa_serial key, the MFA device used when requesting session
+	// tokens or assuming the profile's role.
+	MFASerial string
+	// STSEndpoint is the aws_console_sts_endpoint key, overriding the STS endpoint.
+	STSEndpoint string
+	// FederationEndpoint is the aws_console_federation_endpoint key, overriding the
+	// federation endpoint.
+	FederationEndpoint string
+	// Browser and BrowserProfile are the aws_console_browser and aws_console_browser_profile
+	// keys, choosing where the console opens for this profile.
+	Browser        string
+	BrowserProfile string
+	// Container is the aws_console_container key, a Firefox container for this profile.
+	Container string
+	// IsolateAccounts is the aws_console_isolate_accounts key, opening the console for
+	// this profile in a browser profile of the account's own.
+	IsolateAccounts string
+	// Partition is the aws_console_partition key, overriding the partition detected from
+	// the caller identity.
+	Partition string
+	// CABundle is the ca_bundle key, a PEM file of extra trusted certificates.
+	CABundle string
+	// Issuer is the aws_console_issuer key, the issuer name or URL for this profile's
+	// console sessions.
+	Issuer string
+	// Destination is the aws_console_destination key, the console page opened for this
+	// profile when none is given.
+	Destination string
+	// Color is the aws_console_color key, the session color of switch-role links to
+	// this profile's role.
+	Color string
+	// CredentialSource is the aws_console_credential_source key, a password manager item
+	// holding the profile's access keys, such as "1password:Private/AWS prod".
+	CredentialSource string
+	// MFASource is the aws_console_mfa_source key, where MFA codes for the profile come
+	// from.
+	MFASource string
+	// Validate is the aws_console_validate key, when the profile's credentials are
+	// checked before federating.
+	Validate string
+	// OrgRole is the aws_console_org_role key, the role assumed in member accounts of
+	// the organization opened with this profile.
+	OrgRole string
+	// Fallback is the aws_console_fallback key, a comma-separated list of profiles to
+	// sign in with instead, in order, when IAM Identity Center fails for this one.
+	Fallback string
+}
+
+// FallbackProfiles returns the profiles named by the aws_console_fallback key, in order.
+func (p Profile) FallbackProfiles() []string {
+	return splitList(p.Fallback)
+}
+
+// ChainedRole reports whether the credentials of the profile named name, among
+// profiles, come from role chaining: its role_arn is assumed with the credentials of a
+// source_profile that are themselves a role session, such as those of another role_arn
+// profile or an SSO profile. STS limits such sessions to an hour.
+func ChainedRole(profiles []Profile, name string) bool {
+	byName := make(map[string]Profile, len(profiles))
+	for _, p := range profiles {
+		byName[p.Name] = p
+	}
+	p, ok := byName[name]
+	// A profile may name itself as its source_profile to assume its role with its own
+	// access keys.
+	if !ok || p.Source != ProfileSourceAssumeRole || p.SourceProfile == "" || p.SourceProfile == p.Name {
+		return false
+	}
+	switch byName[p.SourceProfile].Source {
+	case ProfileSourceAssumeRole, ProfileSourceWebIdentity, ProfileSourceSSO:
+		return true
+	}
+	return false
+}
+
+// SSOSession is an [sso-session] section of the shared AWS config.
+type SSOSession struct {
+	Name               string
+	StartURL           string
+	Region             string
+	RegistrationScopes []string
+	// ClientName and RegistrationCache configure the OIDC client aws-console registers
+	// for native SSO login (aws_console_client_name, aws_console_registration_cache).
+	ClientName        string
+	RegistrationCache string
+}
+
+// SSOSessionReader reads [sso-session] sections of the shared AWS config.
+type SSOSessionReader interface {
+	SSOSession(name string) (SSOSession, error)
+}
+
+// ProfileLister enumerates the profiles available in the shared AWS config.
+type ProfileLister interface {
+	ListProfiles() ([]Profile, error)
 }
@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCredentialsExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		expires       time.Time
+		at            time.Time
+		wantValid     bool
+		wantRemaining time.Duration
+	}{
+		{name: "never expires", at: now.Add(24 * time.Hour), wantValid: true},
+		{name: "before expiry", expires: now.Add(time.Hour), at: now.Add(59 * time.Minute), wantValid: true, wantRemaining: time.Hour},
+		{name: "at expiry", expires: now.Add(time.Hour), at: now.Add(time.Hour), wantRemaining: time.Hour},
+		{name: "already expired", expires: now.Add(-time.Minute), at: now},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			creds := Credentials{AccessKeyID: "ASIA", Expires: tc.expires}
+			if got := creds.ValidAt(tc.at); got != tc.wantValid {
+				t.Fatalf("ValidAt(%s) = %v, want %v", tc.at, got, tc.wantValid)
+			}
+			if !tc.expires.IsZero() {
+				if got := creds.Remaining(now); got != tc.wantRemaining {
+					t.Fatalf("Remaining = %s, want %s", got, tc.wantRemaining)
+				}
+			}
+		})
+	}
+}
+
+func TestIdentityPrincipal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		arn         string
+		wantRole    string
+		wantSession string
+		wantRoot    bool
+	}{
+		{arn: "arn:aws:sts::123456789012:assumed-role/Admin/alice@example.com", wantRole: "Admin", wantSession: "alice@example.com"},
+		{arn: "arn:aws-us-gov:iam::123456789012:root", wantRoot: true},
+		{arn: "arn:aws:iam::123456789012:user/alice"},
+		{arn: "arn:aws:sts::123456789012:federated-user/alice"},
+		{arn: "not-an-arn"},
+	}
+
+	for _, tc := range testCases {
+		identity := Identity{Arn: tc.arn}
+		if got := identity.RoleName(); got != tc.wantRole {
+			t.Fatalf("RoleName of %s = %q, want %q", tc.arn, got, tc.wantRole)
+		}
+		if got := identity.SessionName(); got != tc.wantSession {
+			t.Fatalf("SessionName of %s = %q, want %q", tc.arn, got, tc.wantSession)
+		}
+		if got := identity.IsRoot(); got != tc.wantRoot {
+			t.Fatalf("IsRoot of %s = %v, want %v", tc.arn, got, tc.wantRoot)
+		}
+	}
+}
+
+func TestChainedRole(t *testing.T) {
+	t.Parallel()
+
+	profiles := []Profile{
+		{Name: "keys", Source: ProfileSourceStatic},
+		{Name: "sso", Source: ProfileSourceSSO},
+		{Name: "admin", Source: ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/Admin", SourceProfile: "keys"},
+		{Name: "deploy", Source: ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/Deploy", SourceProfile: "admin"},
+		{Name: "from-sso", Source: ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/Deploy", SourceProfile: "sso"},
+		{Name: "self", Source: ProfileSourceAssumeRole, RoleARN: "arn:aws:iam::123456789012:role/Admin", SourceProfile: "self"},
+	}
+
+	testCases := []struct {
+		profile string
+		want    bool
+	}{
+		{profile: "keys"},
+		{profile: "sso"},
+		{profile: "admin"},
+		{profile: "deploy", want: true},
+		{profile: "from-sso", want: true},
+		{profile: "self"},
+		{profile: "missing"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.profile, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ChainedRole(profiles, tc.profile); got != tc.want {
+				t.Fatalf("ChainedRole(%q) = %v, want %v", tc.profile, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package aws
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// WebIdentityRoleInput describes a role to assume with an OIDC token, for
+// sts:AssumeRoleWithWebIdentity.
+type WebIdentityRoleInput struct {
+	RoleARN string
+	// SessionName defaults to DefaultRoleSessionName.
+	SessionName string
+	// Token is the OIDC ID token, a JWT, issued by a provider the role trusts.
+	Token string
+	// DurationSeconds is zero to take the default of STS, one hour.
+	DurationSeconds int32
+}
+
+// TokenSource returns an OIDC token to assume a role with. It is called once for each
+// role assumed, since CI providers issue tokens that only last minutes.
+type TokenSource func(ctx context.Context) (string, error)
+
+// TokenFile returns a TokenSource that reads the token from the file at path, as
+// Kubernetes and EKS project it and AWS_WEB_IDENTITY_TOKEN_FILE names it.
+func TokenFile(path string) TokenSource {
+	return func(context.Context) (string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the web identity token: %w", err)
+		}
+		return parseToken(data)
+	}
+}
+
+// TokenCommand returns a TokenSource that takes the token from what run prints: the
+// token itself, or a JSON document holding it under value (as the GitHub Actions token
+// endpoint returns it), id_token, or token.
+func TokenCommand(run func(ctx context.Context) ([]byte, error)) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		out, err := run(ctx)
+		if err != nil {
+			return "", fmt.Errorf("the web identity token command failed: %w", err)
+		}
+		return parseToken(out)
+	}
+}
+
+func parseToken(data []byte) (string, error) {
+	token := strings.TrimSpace(string(data))
+	if strings.HasPrefix(token, "{") {
+		var doc struct {
+			Value   string `json:"value"`
+			IDToken string `json:"id_token"`
+			Token   string `json:"token"`
+		}
+		if err := json.Unmarshal([]byte(token), &doc); err != nil {
+			return "", fmt.Errorf("invalid web identity token document: %w", err)
+		}
+		token = cmp.Or(doc.Value, doc.IDToken, doc.Token)
+	}
+	if token == "" {
+		return "", errors.New("the web identity token is empty")
+	}
+	if strings.Count(token, ".") != 2 || strings.ContainsAny(token, " \t\r\n") {
+		return "", errors.New("the web identity token is not a JWT")
+	}
+	return token, nil
+}
+
+// AssumeRoleWithWebIdentity exchanges an OIDC token for the credentials of
+// input.RoleARN. The request is not signed, so no profile is read.
+func (s *SDKService) AssumeRoleWithWebIdentity(ctx context.Context, input WebIdentityRoleInput) (Credentials, error) {
+	cfg, err := s.loadConfig(ctx, "")
+	if err != nil {
+		return Credentials{}, err
+	}
+	if cfg.Region == "" {
+		cfg.Region = unsignedRegions[PartitionFromARN(input.RoleARN)]
+	}
+
+	client, err := s.stsClient(ctx, cfg)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	params := &sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          awsv2.String(input.RoleARN),
+		RoleSessionName:  awsv2.String(DefaultRoleSessionName),
+		WebIdentityToken: awsv2.String(input.Token),
+	}
+	if input.SessionName != "" {
+		params.RoleSessionName = awsv2.String(input.SessionName)
+	}
+	if input.DurationSeconds > 0 {
+		params.DurationSeconds = awsv2.Int32(input.DurationSeconds)
+	}
+
+	out, err := client.AssumeRoleWithWebIdentity(ctx, params)
+	if err != nil {
+		return Credentials{}, throttled(err)
+	}
+	if out.Credentials == nil {
+		return Credentials{}, fmt.Errorf("STS AssumeRoleWithWebIdentity returned empty credentials")
+	}
+
+	return Credentials{
+		AccessKeyID:     awsv2.ToString(out.Credentials.AccessKeyId),
+		SecretAccessKey: awsv2.ToString(out.Credentials.SecretAccessKey),
+		SessionToken:    awsv2.ToString(out.Credentials.SessionToken),
+		Expires:         awsv2.ToTime(out.Credentials.Expiration),
+		Source:          "WebIdentity",
+	}, nil
+}
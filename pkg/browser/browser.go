@@ -0,0 +1,666 @@
+// Package browser opens URLs in the system default browser or in a chosen browser and
+// browser profile, with a launcher per platform.
+//
+// The default browser is the first installed command of the BROWSER environment
+// variable, when it is set, and otherwise the platform's own: open on macOS, xdg-open
+// on Linux, or the browser xdg-settings names where xdg-open is missing, start on
+// Windows, and wslview or PowerShell under WSL.
+package browser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// WSL is the platform of Linux running under the Windows Subsystem for Linux, where the
+// default browser is the one on the Windows side.
+const WSL = "wsl"
+
+// ErrUnsupportedPlatform is returned when there is no known way to open the default
+// browser on the platform.
+var ErrUnsupportedPlatform = errors.New("unsupported platform")
+
+// Placeholders substituted into command templates.
+const (
+	URLPlaceholder     = "{url}"
+	ProfilePlaceholder = "{profile}"
+)
+
+// Runner runs and starts external commands.
+type Runner interface {
+	Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+	Start(name string, args []string) error
+}
+
+// Options choose where a URL is opened.
+type Options struct {
+	// Browser is a known browser name (see Names), a command template containing
+	// {url}, or empty for the system default browser.
+	Browser string
+	// Profile is the browser profile: a profile directory such as "Profile 2" for
+	// Chromium-based browsers, or a profile name for Firefox.
+	Profile string
+	// NewWindow isolates the page in its own browser window.
+	NewWindow bool
+	// Container opens the page in this Firefox Multi-Account Container, through the
+	// ext+container protocol of the "Open external links in a container" extension.
+	// It implies Firefox when no browser is chosen.
+	Container string
+	// Private opens the page in a private (incognito) window, whose cookies are kept
+	// apart from the browser's normal windows.
+	Private bool
+	// DataDir runs the browser with this directory as its profile: the user data
+	// directory of a Chromium-based browser, or a Firefox profile directory. Its cookies,
+	// bookmarks, and extensions are kept apart from every other profile's.
+	DataDir string
+}
+
+type family int
+
+const (
+	familyChromium family = iota
+	familyFirefox
+	familySafari
+)
+
+// knownBrowser describes how to launch a browser on each platform.
+type knownBrowser struct {
+	family family
+	// macApp is the application name passed to `open -a`.
+	macApp string
+	// linuxExecutable is the executable looked up on PATH.
+	linuxExecutable string
+	// privateFlag opens a private window; empty when it cannot be requested.
+	privateFlag string
+}
+
+var knownBrowsers = map[string]knownBrowser{
+	"chrome":   {family: familyChromium, macApp: "Google Chrome", linuxExecutable: "google-chrome", privateFlag: "--incognito"},
+	"chromium": {family: familyChromium, macApp: "Chromium", linuxExecutable: "chromium", privateFlag: "--incognito"},
+	"brave":    {family: familyChromium, macApp: "Brave Browser", linuxExecutable: "brave-browser", privateFlag: "--incognito"},
+	"edge":     {family: familyChromium, macApp: "Microsoft Edge", linuxExecutable: "microsoft-edge", privateFlag: "--inprivate"},
+	"vivaldi":  {family: familyChromium, macApp: "Vivaldi", linuxExecutable: "vivaldi", privateFlag: "--incognito"},
+	"firefox":  {family: familyFirefox, macApp: "Firefox", linuxExecutable: "firefox", privateFlag: "-private-window"},
+	"safari":   {family: familySafari, macApp: "Safari"},
+}
+
+// Names returns the known browser names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(knownBrowsers))
+	for name := range knownBrowsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseSpec splits a "browser:profile" value such as "chrome:Profile 2". Command
+// templates are returned whole.
+func ParseSpec(spec string) (string, string) {
+	if IsTemplate(spec) {
+		return spec, ""
+	}
+	name, profile, _ := strings.Cut(spec, ":")
+	return strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(profile)
+}
+
+// IsTemplate reports whether value is a command template rather than a browser name.
+func IsTemplate(value string) bool {
+	return strings.Contains(value, URLPlaceholder)
+}
+
+// placeholderPattern matches a placeholder in a command template, written {name} or
+// {{name}}.
+var placeholderPattern = regexp.MustCompile(`\{\{(\w*)\}\}|\{(\w*)\}`)
+
+// ParseTemplate splits a command template into the command and its arguments, with
+// placeholders written {{name}} rewritten as {name}. Fields are separated by whitespace,
+// which single or double quotes keep inside one, as in
+//
+//	open -na 'Google Chrome' --args --profile-directory={{profile}} {{url}}
+func ParseTemplate(template string) ([]string, error) {
+	fields, err := splitFields(template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid browser command %q: %w", template, err)
+	}
+	for i, field := range fields {
+		var unknown string
+		fields[i] = placeholderPattern.ReplaceAllStringFunc(field, func(match string) string {
+			name := strings.Trim(match, "{}")
+			if "{"+name+"}" != URLPlaceholder && "{"+name+"}" != ProfilePlaceholder && unknown == "" {
+				unknown = match
+			}
+			return "{" + name + "}"
+		})
+		if unknown != "" {
+			return nil, fmt.Errorf("invalid browser command %q: unknown placeholder %s (expected %s or %s)", template, unknown, URLPlaceholder, ProfilePlaceholder)
+		}
+	}
+	switch {
+	case len(fields) == 0:
+		return nil, fmt.Errorf("invalid browser command %q: no command to run", template)
+	case strings.Contains(fields[0], "{"):
+		return nil, fmt.Errorf("invalid browser command %q: the command itself cannot be a placeholder", template)
+	case !slices.ContainsFunc(fields, func(f string) bool { return strings.Contains(f, URLPlaceholder) }):
+		return nil, fmt.Errorf("invalid browser command %q: %s must be given", template, URLPlaceholder)
+	}
+	return fields, nil
+}
+
+// splitFields splits s on whitespace outside single and double quotes, and removes the
+// quotes. Backslashes are kept, for Windows paths.
+func splitFields(s string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inField := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				field.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inField = r, true
+		case unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// ContainerURL wraps targetURL so Firefox opens it in the named container.
+func ContainerURL(container, targetURL string) string {
+	return "ext+container:name=" + url.QueryEscape(container) + "&url=" + url.QueryEscape(targetURL)
+}
+
+// Validate checks that opts name a known browser or a command template, and that a
+// profile, container, or private window is only requested from a browser that
+// supports one.
+func Validate(opts Options) error {
+	if opts.Private {
+		if opts.Container != "" {
+			return errors.New("containers are not available in private windows")
+		}
+		if IsTemplate(opts.Browser) {
+			return fmt.Errorf("a private window cannot be requested through a command template; add the browser's private window flag to the template instead")
+		}
+		if b, ok := knownBrowsers[opts.Browser]; ok && b.privateFlag == "" {
+			return fmt.Errorf("%s cannot open a private window from the command line", opts.Browser)
+		}
+	}
+	if opts.Container != "" && opts.Browser != "" && !IsTemplate(opts.Browser) {
+		if b, ok := knownBrowsers[opts.Browser]; ok && b.family != familyFirefox {
+			return fmt.Errorf("containers are only supported by firefox, not %s", opts.Browser)
+		}
+	}
+	if opts.DataDir != "" && opts.Profile != "" {
+		return errors.New("a browser profile cannot be chosen in an isolated profile directory")
+	}
+	if opts.Browser == "" {
+		if opts.Profile != "" {
+			return errors.New("a browser profile requires --browser")
+		}
+		if opts.DataDir != "" {
+			return errors.New("isolated browser profiles require --browser chrome, firefox, or another browser aws-console knows")
+		}
+		return nil
+	}
+	if IsTemplate(opts.Browser) {
+		if opts.DataDir != "" {
+			return errors.New("isolated browser profiles cannot be opened through a command template; choose a browser by name instead")
+		}
+		_, err := ParseTemplate(opts.Browser)
+		return err
+	}
+	b, ok := knownBrowsers[opts.Browser]
+	if !ok {
+		return fmt.Errorf("unknown browser %q (expected one of: %s, or a command containing %s)", opts.Browser, strings.Join(Names(), ", "), URLPlaceholder)
+	}
+	if b.family == familySafari && (opts.Profile != "" || opts.DataDir != "") {
+		return errors.New("safari does not support browser profiles")
+	}
+	return nil
+}
+
+// BrowserEnv is the environment variable listing the commands that open the default
+// browser, as a list of commands separated by colons (semicolons on Windows). Each
+// command's %s is replaced with the URL, and %% with a percent sign; a command without
+// %s gets the URL as its last argument.
+const BrowserEnv = "BROWSER"
+
+// Launcher opens URLs on one platform.
+type Launcher struct {
+	goos   string
+	runner Runner
+	// getenv reads BROWSER; nil reads nothing.
+	getenv func(string) string
+	// stderr receives warnings about options the browser cannot honor.
+	stderr io.Writer
+}
+
+// New creates a launcher for goos (a runtime.GOOS value, or WSL) that reads BROWSER
+// through getenv.
+func New(goos string, runner Runner, getenv func(string) string, stderr io.Writer) *Launcher {
+	return &Launcher{goos: goos, runner: runner, getenv: getenv, stderr: stderr}
+}
+
+// Open opens targetURL as described by opts.
+func (l *Launcher) Open(targetURL string, opts Options) error {
+	if err := Validate(opts); err != nil {
+		return err
+	}
+	if opts.Container != "" {
+		targetURL = ContainerURL(opts.Container, targetURL)
+		if opts.Browser == "" {
+			opts.Browser = "firefox"
+		}
+	}
+
+	switch {
+	case IsTemplate(opts.Browser):
+		return l.openTemplate(targetURL, opts)
+	case opts.Browser != "":
+		return l.openKnown(targetURL, opts)
+	default:
+		return l.openDefault(targetURL, opts)
+	}
+}
+
+// openTemplate runs a command template. It is split into fields before {url} and
+// {profile} are substituted, so substituted values stay single arguments.
+func (l *Launcher) openTemplate(targetURL string, opts Options) error {
+	fields, err := ParseTemplate(opts.Browser)
+	if err != nil {
+		return err
+	}
+	replacer := strings.NewReplacer(URLPlaceholder, targetURL, ProfilePlaceholder, opts.Profile)
+	for i, field := range fields {
+		fields[i] = replacer.Replace(field)
+	}
+	return l.runner.Start(fields[0], fields[1:])
+}
+
+func (l *Launcher) openKnown(targetURL string, opts Options) error {
+	b := knownBrowsers[opts.Browser]
+	args := browserArgs(b, opts)
+
+	switch l.goos {
+	case "darwin":
+		if len(args) == 0 {
+			return l.runner.Start("open", []string{"-a", b.macApp, targetURL})
+		}
+		// Arguments only reach the browser through --args, which needs a new instance;
+		// browsers hand the URL over to an already running one.
+		return l.runner.Start("open", append(append([]string{"-na", b.macApp, "--args"}, args...), targetURL))
+	case "linux", WSL:
+		if b.linuxExecutable == "" {
+			return fmt.Errorf("%s is not available on linux", opts.Browser)
+		}
+		return l.runner.Start(b.linuxExecutable, append(args, targetURL))
+	default:
+		return fmt.Errorf("--browser %s is not supported on %s; use a command template containing %s instead", opts.Browser, l.goos, URLPlaceholder)
+	}
+}
+
+// browserArgs returns the command-line flags that select opts' profile and window.
+func browserArgs(b knownBrowser, opts Options) []string {
+	var args []string
+	switch b.family {
+	case familyChromium:
+		if opts.DataDir != "" {
+			// A new profile directory would otherwise open on the welcome pages.
+			args = append(args, "--user-data-dir="+opts.DataDir, "--no-first-run", "--no-default-browser-check")
+		}
+		if opts.Profile != "" {
+			args = append(args, "--profile-directory="+opts.Profile)
+		}
+		if opts.Private {
+			args = append(args, b.privateFlag)
+		}
+		if opts.NewWindow {
+			args = append(args, "--new-window")
+		}
+	case familyFirefox:
+		// -profile takes a directory, which need not be registered in profiles.ini the
+		// way the profile names -P takes are.
+		if opts.DataDir != "" {
+			args = append(args, "-profile", opts.DataDir)
+		}
+		if opts.Profile != "" {
+			args = append(args, "-P", opts.Profile)
+		}
+		// -private-window takes the URL and always opens a new window.
+		if opts.Private {
+			args = append(args, b.privateFlag)
+		} else if opts.NewWindow {
+			args = append(args, "-new-window")
+		}
+	}
+	return args
+}
+
+// openDefault opens targetURL in the system default browser.
+func (l *Launcher) openDefault(targetURL string, opts Options) error {
+	if opts.Private {
+		return l.openDefaultPrivate(targetURL)
+	}
+	if opts.NewWindow {
+		if command, args, ok := l.newWindowCommand(targetURL); ok {
+			return l.runner.Start(command, args)
+		}
+		fmt.Fprintln(l.stderr, "Warning: cannot request a new window from the default browser; opening it normally")
+	}
+
+	commands, err := l.defaultCommands(targetURL)
+	if err != nil {
+		return err
+	}
+	err = l.startFirst(commands)
+	var missing *notInstalledError
+	if l.goos == "linux" && errors.As(err, &missing) {
+		// Minimal desktops may do without xdg-open and still name a default browser.
+		if executable, ok := l.defaultBrowserExecutable(); ok {
+			if err := l.runner.Start(executable, []string{targetURL}); !errors.Is(err, exec.ErrNotFound) {
+				return err
+			}
+			missing.names = append(missing.names, executable)
+		}
+	}
+	return err
+}
+
+type command struct {
+	name string
+	args []string
+}
+
+// defaultCommands returns the commands that open targetURL in the default browser, in
+// the order they are tried: those of BROWSER, then the platform's.
+func (l *Launcher) defaultCommands(targetURL string) ([]command, error) {
+	var commands []command
+	if l.getenv != nil {
+		separator := ":"
+		if l.goos == "windows" {
+			separator = ";"
+		}
+		var err error
+		if commands, err = parseBrowserEnv(l.getenv(BrowserEnv), separator, targetURL); err != nil {
+			return nil, err
+		}
+	}
+	platform, err := l.platformCommands(targetURL)
+	if err != nil && len(commands) == 0 {
+		return nil, err
+	}
+	return append(commands, platform...), nil
+}
+
+// parseBrowserEnv returns the commands of a BROWSER value, with targetURL substituted,
+// given the separator of its list. Empty entries are skipped.
+func parseBrowserEnv(value, separator, targetURL string) ([]command, error) {
+	var commands []command
+	for _, entry := range strings.Split(value, separator) {
+		fields, err := splitFields(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s command %q: %w", BrowserEnv, entry, err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		substituted := false
+		for i, field := range fields {
+			fields[i], substituted = expandPercent(field, targetURL, substituted)
+		}
+		if !substituted {
+			fields = append(fields, targetURL)
+		}
+		commands = append(commands, command{name: fields[0], args: fields[1:]})
+	}
+	return commands, nil
+}
+
+// expandPercent replaces %s in field with targetURL and %% with a percent sign,
+// reporting whether the URL was substituted here or before.
+func expandPercent(field, targetURL string, substituted bool) (string, bool) {
+	if !strings.Contains(field, "%") {
+		return field, substituted
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '%' && i+1 < len(field) {
+			switch field[i+1] {
+			case 's':
+				b.WriteString(targetURL)
+				substituted = true
+				i++
+				continue
+			case '%':
+				b.WriteByte('%')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String(), substituted
+}
+
+// platformCommands returns the platform's commands that open targetURL in the default
+// browser, in the order they are tried.
+func (l *Launcher) platformCommands(targetURL string) ([]command, error) {
+	switch l.goos {
+	case "darwin":
+		return []command{{name: "open", args: []string{targetURL}}}, nil
+	case "linux":
+		return []command{{name: "xdg-open", args: []string{targetURL}}}, nil
+	case "windows":
+		// start is a cmd builtin; its first quoted argument is the window title.
+		return []command{{name: "cmd", args: []string{"/c", "start", "", escapeCmd(targetURL)}}}, nil
+	case WSL:
+		// wslview comes with wslu on most distributions; PowerShell is always there.
+		return []command{
+			{name: "wslview", args: []string{targetURL}},
+			{name: "powershell.exe", args: []string{"-NoProfile", "-NonInteractive", "-Command", "Start-Process " + quotePowerShell(targetURL)}},
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPlatform, l.goos)
+	}
+}
+
+// Executables returns the executables Open would start for opts, any one of which is
+// enough, so callers can check that a browser can be opened without opening one. The
+// private window of the default browser on Linux is not covered, since it depends on
+// the default browser.
+func (l *Launcher) Executables(opts Options) ([]string, error) {
+	if err := Validate(opts); err != nil {
+		return nil, err
+	}
+	if opts.Container != "" && opts.Browser == "" {
+		opts.Browser = "firefox"
+	}
+
+	switch {
+	case IsTemplate(opts.Browser):
+		fields, err := ParseTemplate(opts.Browser)
+		if err != nil {
+			return nil, err
+		}
+		return fields[:1], nil
+	case opts.Browser != "":
+		b := knownBrowsers[opts.Browser]
+		switch l.goos {
+		case "darwin":
+			return []string{"open"}, nil
+		case "linux", WSL:
+			if b.linuxExecutable == "" {
+				return nil, fmt.Errorf("%s is not available on linux", opts.Browser)
+			}
+			return []string{b.linuxExecutable}, nil
+		default:
+			return nil, fmt.Errorf("--browser %s is not supported on %s; use a command template containing %s instead", opts.Browser, l.goos, URLPlaceholder)
+		}
+	default:
+		commands, err := l.defaultCommands("")
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(commands))
+		for i, c := range commands {
+			names[i] = c.name
+		}
+		return names, nil
+	}
+}
+
+// notInstalledError reports that none of the commands that open a browser is installed.
+type notInstalledError struct {
+	goos  string
+	names []string
+}
+
+func (e *notInstalledError) Error() string {
+	return fmt.Sprintf("cannot open a browser on %s: none of %s is installed", e.goos, strings.Join(e.names, ", "))
+}
+
+// startFirst starts the first of commands that is installed.
+func (l *Launcher) startFirst(commands []command) error {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		if err := l.runner.Start(c.name, c.args); !errors.Is(err, exec.ErrNotFound) {
+			return err
+		}
+		names[i] = c.name
+	}
+	return &notInstalledError{goos: l.goos, names: names}
+}
+
+// escapeCmd escapes the characters cmd.exe would otherwise interpret, such as the &
+// between query parameters.
+func escapeCmd(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune("^&|<>()%", r) {
+			b.WriteByte('^')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// quotePowerShell quotes value as a PowerShell single-quoted string.
+func quotePowerShell(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// IsWSL reports whether this Linux system runs under the Windows Subsystem for Linux.
+func IsWSL() bool {
+	return isWSL(os.Getenv, os.ReadFile)
+}
+
+func isWSL(getenv func(string) string, readFile func(string) ([]byte, error)) bool {
+	if getenv("WSL_DISTRO_NAME") != "" || getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	release, err := readFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+// newWindowCommand returns the command that opens targetURL in a new window of the
+// default browser. macOS handles this generically with `open -n`; on Linux the default
+// browser is looked up with xdg-settings and launched directly with its own flag.
+func (l *Launcher) newWindowCommand(targetURL string) (string, []string, bool) {
+	switch l.goos {
+	case "darwin":
+		return "open", []string{"-n", targetURL}, true
+	case "linux":
+		executable, ok := l.defaultBrowserExecutable()
+		if !ok {
+			return "", nil, false
+		}
+		flag, ok := newWindowFlag(executable)
+		if !ok {
+			return "", nil, false
+		}
+		return executable, []string{flag, targetURL}, true
+	default:
+		return "", nil, false
+	}
+}
+
+// openDefaultPrivate opens targetURL in a private window of the default browser. Only
+// Linux can tell which browser that is, through xdg-settings; elsewhere, and for
+// browsers without a private window flag, it fails rather than open a normal window.
+func (l *Launcher) openDefaultPrivate(targetURL string) error {
+	if l.goos == "linux" {
+		if executable, ok := l.defaultBrowserExecutable(); ok {
+			if flag, ok := privateWindowFlag(executable); ok {
+				return l.runner.Start(executable, []string{flag, targetURL})
+			}
+		}
+	}
+	return fmt.Errorf("cannot open a private window in the default browser on %s; choose a browser with --browser", l.goos)
+}
+
+// defaultBrowserExecutable returns the executable of the default browser on Linux.
+func (l *Launcher) defaultBrowserExecutable() (string, bool) {
+	var out bytes.Buffer
+	if err := l.runner.Run("xdg-settings", []string{"get", "default-web-browser"}, nil, &out, &bytes.Buffer{}); err != nil {
+		return "", false
+	}
+	executable := strings.TrimSuffix(strings.TrimSpace(out.String()), ".desktop")
+	return executable, executable != ""
+}
+
+// privateWindowFlag returns the private window flag understood by a browser executable.
+func privateWindowFlag(executable string) (string, bool) {
+	switch {
+	case strings.Contains(executable, "firefox"):
+		return "-private-window", true
+	case strings.Contains(executable, "edge"):
+		return "--inprivate", true
+	case strings.Contains(executable, "chrom"), strings.Contains(executable, "brave"),
+		strings.Contains(executable, "vivaldi"):
+		return "--incognito", true
+	default:
+		return "", false
+	}
+}
+
+// newWindowFlag returns the new-window flag understood by a browser executable.
+func newWindowFlag(executable string) (string, bool) {
+	switch {
+	case strings.Contains(executable, "firefox"):
+		return "-new-window", true
+	case strings.Contains(executable, "chrom"), strings.Contains(executable, "brave"),
+		strings.Contains(executable, "edge"), strings.Contains(executable, "vivaldi"):
+		return "--new-window", true
+	default:
+		return "", false
+	}
+}
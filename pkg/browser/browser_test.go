@@ -0,0 +1,522 @@
+package browser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type startCall struct {
+	name string
+	args []string
+}
+
+type fakeRunner struct {
+	runOutput string
+	// missing names the commands that are not installed.
+	missing []string
+	starts  []startCall
+}
+
+func (f *fakeRunner) Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	io.WriteString(stdout, f.runOutput)
+	return nil
+}
+
+func (f *fakeRunner) Start(name string, args []string) error {
+	for _, missing := range f.missing {
+		if name == missing {
+			return &exec.Error{Name: name, Err: exec.ErrNotFound}
+		}
+	}
+	f.starts = append(f.starts, startCall{name: name, args: append([]string(nil), args...)})
+	return nil
+}
+
+func TestParseSpec(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		spec        string
+		wantBrowser string
+		wantProfile string
+	}{
+		{spec: "", wantBrowser: ""},
+		{spec: "firefox", wantBrowser: "firefox"},
+		{spec: "Chrome:Profile 2", wantBrowser: "chrome", wantProfile: "Profile 2"},
+		{spec: "my-browser --incognito {url}", wantBrowser: "my-browser --incognito {url}"},
+	}
+
+	for _, tc := range testCases {
+		gotBrowser, gotProfile := ParseSpec(tc.spec)
+		if gotBrowser != tc.wantBrowser || gotProfile != tc.wantProfile {
+			t.Fatalf("ParseSpec(%q) = %q, %q; want %q, %q", tc.spec, gotBrowser, gotProfile, tc.wantBrowser, tc.wantProfile)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		opts          Options
+		wantErrSubstr string
+	}{
+		{name: "default browser"},
+		{name: "known browser", opts: Options{Browser: "brave", Profile: "Work"}},
+		{name: "template", opts: Options{Browser: "open -a Arc {url}"}},
+		{name: "invalid template", opts: Options{Browser: "open -a 'Arc {url}"}, wantErrSubstr: "unterminated ' quote"},
+		{name: "unknown browser", opts: Options{Browser: "netscape"}, wantErrSubstr: `unknown browser "netscape"`},
+		{name: "profile without browser", opts: Options{Profile: "Work"}, wantErrSubstr: "a browser profile requires --browser"},
+		{name: "firefox container", opts: Options{Browser: "firefox", Container: "prod"}},
+		{name: "chrome container", opts: Options{Browser: "chrome", Container: "prod"}, wantErrSubstr: "containers are only supported by firefox, not chrome"},
+		{name: "safari profile", opts: Options{Browser: "safari", Profile: "Work"}, wantErrSubstr: "safari does not support browser profiles"},
+		{name: "private window", opts: Options{Browser: "edge", Private: true}},
+		{name: "private default browser", opts: Options{Private: true}},
+		{name: "private safari", opts: Options{Browser: "safari", Private: true}, wantErrSubstr: "safari cannot open a private window from the command line"},
+		{name: "private container", opts: Options{Browser: "firefox", Container: "prod", Private: true}, wantErrSubstr: "containers are not available in private windows"},
+		{name: "private template", opts: Options{Browser: "open -a Arc {url}", Private: true}, wantErrSubstr: "cannot be requested through a command template"},
+		{name: "data dir", opts: Options{Browser: "vivaldi", DataDir: "/profiles/vivaldi/123456789012"}},
+		{name: "data dir without browser", opts: Options{DataDir: "/profiles"}, wantErrSubstr: "isolated browser profiles require --browser"},
+		{name: "data dir template", opts: Options{Browser: "open -a Arc {url}", DataDir: "/profiles"}, wantErrSubstr: "cannot be opened through a command template"},
+		{name: "data dir and profile", opts: Options{Browser: "chrome", Profile: "Work", DataDir: "/profiles"}, wantErrSubstr: "cannot be chosen in an isolated profile directory"},
+		{name: "safari data dir", opts: Options{Browser: "safari", DataDir: "/profiles"}, wantErrSubstr: "safari does not support browser profiles"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := Validate(tc.opts)
+			if tc.wantErrSubstr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+			}
+		})
+	}
+}
+
+func TestLauncherOpen(t *testing.T) {
+	t.Parallel()
+
+	const target = "https://example.com/?a=1&b=2"
+
+	testCases := []struct {
+		name string
+		goos string
+		opts Options
+		// defaultBrowser is what xdg-settings reports as the default browser.
+		defaultBrowser string
+		// browserEnv is the value of BROWSER.
+		browserEnv    string
+		missing       []string
+		wantName      string
+		wantArgs      []string
+		wantErrSubstr string
+	}{
+		{
+			name:     "darwin chrome",
+			goos:     "darwin",
+			opts:     Options{Browser: "chrome"},
+			wantName: "open",
+			wantArgs: []string{"-a", "Google Chrome", target},
+		},
+		{
+			name:     "darwin chrome profile",
+			goos:     "darwin",
+			opts:     Options{Browser: "chrome", Profile: "Profile 2", NewWindow: true},
+			wantName: "open",
+			wantArgs: []string{"-na", "Google Chrome", "--args", "--profile-directory=Profile 2", "--new-window", target},
+		},
+		{
+			name:     "linux firefox profile",
+			goos:     "linux",
+			opts:     Options{Browser: "firefox", Profile: "work"},
+			wantName: "firefox",
+			wantArgs: []string{"-P", "work", target},
+		},
+		{
+			name:     "darwin chrome data dir",
+			goos:     "darwin",
+			opts:     Options{Browser: "chrome", DataDir: "/profiles/chrome/123456789012"},
+			wantName: "open",
+			wantArgs: []string{"-na", "Google Chrome", "--args", "--user-data-dir=/profiles/chrome/123456789012", "--no-first-run", "--no-default-browser-check", target},
+		},
+		{
+			name:     "linux firefox data dir",
+			goos:     "linux",
+			opts:     Options{Browser: "firefox", DataDir: "/profiles/firefox/123456789012", NewWindow: true},
+			wantName: "firefox",
+			wantArgs: []string{"-profile", "/profiles/firefox/123456789012", "-new-window", target},
+		},
+		{
+			name:     "container implies firefox",
+			goos:     "linux",
+			opts:     Options{Container: "acme prod"},
+			wantName: "firefox",
+			wantArgs: []string{"ext+container:name=acme+prod&url=https%3A%2F%2Fexample.com%2F%3Fa%3D1%26b%3D2"},
+		},
+		{
+			name:          "linux safari",
+			goos:          "linux",
+			opts:          Options{Browser: "safari"},
+			wantErrSubstr: "safari is not available on linux",
+		},
+		{
+			name:          "windows named browser",
+			goos:          "windows",
+			opts:          Options{Browser: "edge"},
+			wantErrSubstr: "use a command template",
+		},
+		{
+			name:     "darwin chrome incognito",
+			goos:     "darwin",
+			opts:     Options{Browser: "chrome", Profile: "Profile 2", Private: true},
+			wantName: "open",
+			wantArgs: []string{"-na", "Google Chrome", "--args", "--profile-directory=Profile 2", "--incognito", target},
+		},
+		{
+			name:     "linux edge inprivate",
+			goos:     "linux",
+			opts:     Options{Browser: "edge", Private: true},
+			wantName: "microsoft-edge",
+			wantArgs: []string{"--inprivate", target},
+		},
+		{
+			name:     "linux firefox private window",
+			goos:     "linux",
+			opts:     Options{Browser: "firefox", Private: true, NewWindow: true},
+			wantName: "firefox",
+			wantArgs: []string{"-private-window", target},
+		},
+		{
+			name:           "linux default browser private window",
+			goos:           "linux",
+			opts:           Options{Private: true},
+			defaultBrowser: "brave-browser.desktop\n",
+			wantName:       "brave-browser",
+			wantArgs:       []string{"--incognito", target},
+		},
+		{
+			name:           "linux unknown default browser private window",
+			goos:           "linux",
+			opts:           Options{Private: true},
+			defaultBrowser: "epiphany.desktop\n",
+			wantErrSubstr:  "cannot open a private window in the default browser on linux",
+		},
+		{
+			name:          "darwin default browser private window",
+			goos:          "darwin",
+			opts:          Options{Private: true},
+			wantErrSubstr: "choose a browser with --browser",
+		},
+		{
+			name:     "windows default browser",
+			goos:     "windows",
+			wantName: "cmd",
+			wantArgs: []string{"/c", "start", "", "https://example.com/?a=1^&b=2"},
+		},
+		{
+			name:     "wsl default browser",
+			goos:     WSL,
+			wantName: "wslview",
+			wantArgs: []string{target},
+		},
+		{
+			name:     "wsl without wslview",
+			goos:     WSL,
+			missing:  []string{"wslview"},
+			wantName: "powershell.exe",
+			wantArgs: []string{"-NoProfile", "-NonInteractive", "-Command", "Start-Process '" + target + "'"},
+		},
+		{
+			name:          "wsl without a launcher",
+			goos:          WSL,
+			missing:       []string{"wslview", "powershell.exe"},
+			wantErrSubstr: "none of wslview, powershell.exe is installed",
+		},
+		{
+			name:     "wsl named browser",
+			goos:     WSL,
+			opts:     Options{Browser: "firefox"},
+			wantName: "firefox",
+			wantArgs: []string{target},
+		},
+		{
+			name:       "BROWSER",
+			goos:       "linux",
+			browserEnv: "firefox --new-tab %s:chromium",
+			wantName:   "firefox",
+			wantArgs:   []string{"--new-tab", target},
+		},
+		{
+			name:       "BROWSER fallback entry",
+			goos:       "linux",
+			browserEnv: "netscape:lynx -dump",
+			missing:    []string{"netscape"},
+			wantName:   "lynx",
+			wantArgs:   []string{"-dump", target},
+		},
+		{
+			name:       "BROWSER not installed",
+			goos:       "darwin",
+			browserEnv: "netscape",
+			missing:    []string{"netscape"},
+			wantName:   "open",
+			wantArgs:   []string{target},
+		},
+		{
+			name:       "BROWSER on windows",
+			goos:       "windows",
+			browserEnv: `"C:\Program Files\Arc\arc.exe" %s;cmd`,
+			wantName:   `C:\Program Files\Arc\arc.exe`,
+			wantArgs:   []string{target},
+		},
+		{
+			name:          "invalid BROWSER",
+			goos:          "linux",
+			browserEnv:    "firefox '%s",
+			wantErrSubstr: "invalid BROWSER command",
+		},
+		{
+			name:           "linux without xdg-open",
+			goos:           "linux",
+			defaultBrowser: "firefox.desktop\n",
+			missing:        []string{"xdg-open"},
+			wantName:       "firefox",
+			wantArgs:       []string{target},
+		},
+		{
+			name:           "linux without a browser",
+			goos:           "linux",
+			defaultBrowser: "firefox.desktop\n",
+			missing:        []string{"xdg-open", "firefox"},
+			wantErrSubstr:  "none of xdg-open, firefox is installed",
+		},
+		{
+			name:     "quoted template",
+			goos:     "darwin",
+			opts:     Options{Browser: `open -na 'Google Chrome' --args "--profile-directory={{profile}}" {{url}}`, Profile: "Profile 2"},
+			wantName: "open",
+			wantArgs: []string{"-na", "Google Chrome", "--args", "--profile-directory=Profile 2", target},
+		},
+		{
+			name:     "template",
+			goos:     "windows",
+			opts:     Options{Browser: `C:\Browsers\chrome.exe --profile-directory={profile} {url}`, Profile: "Profile 2"},
+			wantName: `C:\Browsers\chrome.exe`,
+			wantArgs: []string{"--profile-directory=Profile 2", target},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := &fakeRunner{runOutput: tc.defaultBrowser, missing: tc.missing}
+			getenv := func(key string) string {
+				if key == BrowserEnv {
+					return tc.browserEnv
+				}
+				return ""
+			}
+			err := New(tc.goos, runner, getenv, &bytes.Buffer{}).Open(target, tc.opts)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(runner.starts) != 1 {
+				t.Fatalf("expected one command, got %+v", runner.starts)
+			}
+			call := runner.starts[0]
+			if call.name != tc.wantName || strings.Join(call.args, "|") != strings.Join(tc.wantArgs, "|") {
+				t.Fatalf("unexpected command: %s %q, want %s %q", call.name, call.args, tc.wantName, tc.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseBrowserEnv(t *testing.T) {
+	t.Parallel()
+
+	const target = "https://example.com/?a=1&b=2"
+	testCases := []struct {
+		name          string
+		value         string
+		separator     string
+		want          []string
+		wantErrSubstr string
+	}{
+		{name: "unset", value: "", separator: ":"},
+		{name: "command", value: "firefox", separator: ":", want: []string{"firefox|" + target}},
+		{name: "placeholder", value: "chromium --app=%s", separator: ":", want: []string{"chromium|--app=" + target}},
+		{name: "percent", value: "echo 100%% %s", separator: ":", want: []string{"echo|100%|" + target}},
+		{name: "other verbs are kept", value: "launcher %d", separator: ":", want: []string{"launcher|%d|" + target}},
+		{name: "list", value: "firefox:w3m::lynx %s", separator: ":", want: []string{"firefox|" + target, "w3m|" + target, "lynx|" + target}},
+		{name: "quoted", value: `open -a 'Google Chrome' %s`, separator: ":", want: []string{"open|-a|Google Chrome|" + target}},
+		{name: "windows list", value: `C:\Arc\arc.exe;msedge %s`, separator: ";", want: []string{`C:\Arc\arc.exe|` + target, "msedge|" + target}},
+		{name: "unterminated quote", value: `firefox "%s`, separator: ":", wantErrSubstr: `unterminated " quote`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			commands, err := parseBrowserEnv(tc.value, tc.separator, target)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, c := range commands {
+				got = append(got, strings.Join(append([]string{c.name}, c.args...), "|"))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLauncherExecutables(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		goos          string
+		opts          Options
+		want          []string
+		wantErrSubstr string
+	}{
+		{name: "linux default", goos: "linux", want: []string{"xdg-open"}},
+		{name: "macOS default", goos: "darwin", want: []string{"open"}},
+		{name: "windows default", goos: "windows", want: []string{"cmd"}},
+		{name: "wsl default", goos: WSL, want: []string{"wslview", "powershell.exe"}},
+		{name: "known browser", goos: "linux", opts: Options{Browser: "brave"}, want: []string{"brave-browser"}},
+		{name: "container implies firefox", goos: "linux", opts: Options{Container: "prod"}, want: []string{"firefox"}},
+		{name: "template", goos: "windows", opts: Options{Browser: `"C:\Program Files\Arc\arc.exe" {url}`}, want: []string{`C:\Program Files\Arc\arc.exe`}},
+		{name: "safari on linux", goos: "linux", opts: Options{Browser: "safari"}, wantErrSubstr: "safari is not available on linux"},
+		{name: "unsupported platform", goos: "plan9", wantErrSubstr: "unsupported platform: plan9"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := New(tc.goos, nil, nil, io.Discard).Executables(tc.opts)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		template      string
+		want          []string
+		wantErrSubstr string
+	}{
+		{name: "plain", template: "/opt/arc/arc {url}", want: []string{"/opt/arc/arc", "{url}"}},
+		{name: "double braces", template: "launcher --profile={{profile}} {{url}}", want: []string{"launcher", "--profile={profile}", "{url}"}},
+		{name: "quotes", template: `open -a 'Google Chrome' "{url}"`, want: []string{"open", "-a", "Google Chrome", "{url}"}},
+		{name: "windows path", template: `"C:\Program Files\Arc\arc.exe" {url}`, want: []string{`C:\Program Files\Arc\arc.exe`, "{url}"}},
+		{name: "unterminated quote", template: "open -a 'Google Chrome {url}", wantErrSubstr: "unterminated ' quote"},
+		{name: "unknown placeholder", template: "open {{account}} {url}", wantErrSubstr: "unknown placeholder {{account}}"},
+		{name: "placeholder command", template: "{url}", wantErrSubstr: "the command itself cannot be a placeholder"},
+		{name: "url only in quotes", template: `sh -c "open '{url}'"`, want: []string{"sh", "-c", "open '{url}'"}},
+		{name: "no url", template: "open -a Safari", wantErrSubstr: "{url} must be given"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseTemplate(tc.template)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Fatalf("ParseTemplate(%q) = %q, want %q", tc.template, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsWSL(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		env     map[string]string
+		release string
+		want    bool
+	}{
+		{name: "distribution variable", env: map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}, want: true},
+		{name: "WSL 2 kernel", release: "5.15.153.1-microsoft-standard-WSL2\n", want: true},
+		{name: "WSL 1 kernel", release: "4.4.0-19041-Microsoft\n", want: true},
+		{name: "linux", release: "6.8.0-45-generic\n"},
+		{name: "no kernel release"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getenv := func(key string) string { return tc.env[key] }
+			readFile := func(string) ([]byte, error) {
+				if tc.release == "" {
+					return nil, errors.New("no such file")
+				}
+				return []byte(tc.release), nil
+			}
+			if got := isWSL(getenv, readFile); got != tc.want {
+				t.Fatalf("isWSL() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package browserprofiles manages a browser profile directory of its own for each AWS
+// account, so that every account keeps its own console cookies, bookmarks, and
+// extensions, and signing in to one never signs another out.
+package browserprofiles
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DirName is the name of the directory holding the profiles within the state directory.
+const DirName = "browser-profiles"
+
+// namePattern matches the browser and account names that can be used as directory
+// names: no separators, no "..", nothing hidden.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Profile is the profile directory of one account in one browser.
+type Profile struct {
+	Browser string
+	Account string
+	Path    string
+	// LastUsed is when the browser last wrote to the top of the directory.
+	LastUsed time.Time
+}
+
+// Manager keeps profile directories under <dir>/<browser>/<account>. Each browser has
+// its own, since their profile formats are not interchangeable.
+type Manager struct {
+	dir string
+}
+
+// NewManagerAt creates a manager for profiles under dir. An empty dir disables it.
+func NewManagerAt(dir string) *Manager {
+	return &Manager{dir: dir}
+}
+
+// Dir returns the directory holding the profiles.
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Path returns the profile directory of account in browser, whether or not it exists.
+func (m *Manager) Path(browser, account string) (string, error) {
+	if m.dir == "" {
+		return "", errors.New("browser profiles are unavailable: no state directory")
+	}
+	for _, name := range []string{browser, account} {
+		if !namePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid browser profile name %q", name)
+		}
+	}
+	return filepath.Join(m.dir, browser, account), nil
+}
+
+// Ensure returns the profile directory of account in browser, creating it with
+// owner-only permissions if it does not exist yet, and reports whether it was created.
+func (m *Manager) Ensure(browser, account string) (string, bool, error) {
+	path, err := m.Path(browser, account)
+	if err != nil {
+		return "", false, err
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path, false, nil
+	}
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return "", false, fmt.Errorf("failed to create browser profile: %w", err)
+	}
+	return path, true, nil
+}
+
+// List returns the profile directories that exist, sorted by browser and account.
+func (m *Manager) List() ([]Profile, error) {
+	if m.dir == "" {
+		return nil, nil
+	}
+	browsers, err := os.ReadDir(m.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read browser profiles: %w", err)
+	}
+
+	var profiles []Profile
+	for _, b := range browsers {
+		if !b.IsDir() || !namePattern.MatchString(b.Name()) {
+			continue
+		}
+		accounts, err := os.ReadDir(filepath.Join(m.dir, b.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read browser profiles: %w", err)
+		}
+		for _, a := range accounts {
+			if !a.IsDir() || !namePattern.MatchString(a.Name()) {
+				continue
+			}
+			p := Profile{Browser: b.Name(), Account: a.Name(), Path: filepath.Join(m.dir, b.Name(), a.Name())}
+			if info, err := a.Info(); err == nil {
+				p.LastUsed = info.ModTime()
+			}
+			profiles = append(profiles, p)
+		}
+	}
+	slices.SortFunc(profiles, func(a, b Profile) int {
+		return strings.Compare(a.Browser+"/"+a.Account, b.Browser+"/"+b.Account)
+	})
+	return profiles, nil
+}
+
+// Purge deletes the profile directories of accounts, in every browser, and returns the
+// ones deleted. No accounts deletes them all.
+func (m *Manager) Purge(accounts ...string) ([]Profile, error) {
+	profiles, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	var purged []Profile
+	for _, p := range profiles {
+		if len(accounts) > 0 && !slices.Contains(accounts, p.Account) {
+			continue
+		}
+		if err := os.RemoveAll(p.Path); err != nil {
+			return purged, fmt.Errorf("failed to delete browser profile: %w", err)
+		}
+		purged = append(purged, p)
+	}
+	return purged, nil
+}
@@ -0,0 +1,117 @@
+package browserprofiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManagerEnsureAndList(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), DirName)
+	m := NewManagerAt(dir)
+
+	path, created, err := m.Ensure("chrome", "123456789012")
+	if err != nil || !created {
+		t.Fatalf("expected the profile to be created, got %v, %v", created, err)
+	}
+	if path != filepath.Join(dir, "chrome", "123456789012") {
+		t.Fatalf("unexpected profile path %q", path)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o700 {
+		t.Fatalf("expected a private profile directory, got %v, %v", info, err)
+	}
+	if again, created, err := m.Ensure("chrome", "123456789012"); err != nil || created || again != path {
+		t.Fatalf("expected the profile to be reused, got %q, %v, %v", again, created, err)
+	}
+	if _, _, err := m.Ensure("firefox", "210987654321"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Files beside the profiles are not profiles.
+	if err := os.WriteFile(filepath.Join(dir, "chrome", "notes.txt"), nil, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	profiles, err := m.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, p := range profiles {
+		if p.LastUsed.IsZero() {
+			t.Fatalf("expected when %s was last used, got %+v", p.Path, p)
+		}
+		got = append(got, p.Browser+"/"+p.Account)
+	}
+	if strings.Join(got, ",") != "chrome/123456789012,firefox/210987654321" {
+		t.Fatalf("unexpected profiles %v", got)
+	}
+}
+
+func TestManagerPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		dir           string
+		browser       string
+		account       string
+		wantErrSubstr string
+	}{
+		{name: "account", dir: "/state", browser: "firefox", account: "123456789012"},
+		{name: "profile name", dir: "/state", browser: "brave", account: "acme-prod.admin"},
+		{name: "traversal", dir: "/state", browser: "chrome", account: "..", wantErrSubstr: `invalid browser profile name ".."`},
+		{name: "separator", dir: "/state", browser: "chrome", account: "a/b", wantErrSubstr: `invalid browser profile name "a/b"`},
+		{name: "no state directory", browser: "chrome", account: "123456789012", wantErrSubstr: "no state directory"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, err := NewManagerAt(tc.dir).Path(tc.browser, tc.account)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := filepath.Join(tc.dir, tc.browser, tc.account); path != want {
+				t.Fatalf("got %q, want %q", path, want)
+			}
+		})
+	}
+}
+
+func TestManagerPurge(t *testing.T) {
+	t.Parallel()
+
+	m := NewManagerAt(filepath.Join(t.TempDir(), DirName))
+	if purged, err := m.Purge(); err != nil || len(purged) != 0 {
+		t.Fatalf("expected nothing to purge before any profile exists, got %+v, %v", purged, err)
+	}
+	for _, p := range [][2]string{{"chrome", "111111111111"}, {"firefox", "111111111111"}, {"chrome", "222222222222"}} {
+		if _, _, err := m.Ensure(p[0], p[1]); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	purged, err := m.Purge("111111111111")
+	if err != nil || len(purged) != 2 {
+		t.Fatalf("expected the account's profile in both browsers to be purged, got %+v, %v", purged, err)
+	}
+	profiles, _ := m.List()
+	if len(profiles) != 1 || profiles[0].Account != "222222222222" {
+		t.Fatalf("expected the other account to be kept, got %+v", profiles)
+	}
+
+	if purged, err := m.Purge(); err != nil || len(purged) != 1 {
+		t.Fatalf("expected every profile to be purged, got %+v, %v", purged, err)
+	}
+}
@@ -0,0 +1,64 @@
+// Package buildinfo describes the build of the running binary: the version, commit, and
+// date stamped in with -ldflags, or read from the Go toolchain's own build information
+// when they were not.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Info describes a build.
+type Info struct {
+	Version string
+	// Commit is the revision the binary was built from, with a -dirty suffix when the
+	// working tree had changes.
+	Commit string
+	// Date is when the binary was built, or when its commit was made, in RFC 3339.
+	Date      string
+	GoVersion string
+	// Platform is the target, as GOOS/GOARCH.
+	Platform string
+	// Channel is the release channel updates are taken from, such as stable or beta.
+	Channel string
+}
+
+// Read returns the Info of the running binary. Empty commit and date are read from the
+// version control information the Go toolchain records.
+func Read(version, commit, date, channel string) Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Channel:   channel,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info = fromSettings(info, build.Settings)
+	}
+	return info
+}
+
+func fromSettings(info Info, settings []debug.BuildSetting) Info {
+	var revision, modified string
+	for _, s := range settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if info.Commit == "" && revision != "" {
+		info.Commit = revision[:min(len(revision), 12)]
+		if modified == "true" {
+			info.Commit += "-dirty"
+		}
+	}
+	return info
+}
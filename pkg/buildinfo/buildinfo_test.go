@@ -0,0 +1,26 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestFromSettings(t *testing.T) {
+	t.Parallel()
+
+	settings := []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+		{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+		{Key: "vcs.modified", Value: "true"},
+	}
+
+	got := fromSettings(Info{Version: "dev"}, settings)
+	if got.Commit != "0123456789ab-dirty" || got.Date != "2026-01-02T03:04:05Z" {
+		t.Fatalf("unexpected info from build settings: %+v", got)
+	}
+
+	stamped := fromSettings(Info{Commit: "abc1234", Date: "2026-02-01T00:00:00Z"}, settings)
+	if stamped.Commit != "abc1234" || stamped.Date != "2026-02-01T00:00:00Z" {
+		t.Fatalf("expected values from -ldflags to win, got %+v", stamped)
+	}
+}
@@ -0,0 +1,207 @@
+// Package cachestore keeps cached credentials and sign-in tokens on a cache server
+// shared by a team, such as the users of a bastion host: Redis, or any HTTP server that
+// keeps what is PUT to a URL. Entries are encrypted and authenticated with AES-256-GCM
+// before they leave the host, under a key the team shares out of band, so the server
+// only ever holds ciphertext.
+package cachestore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// KeySize is the size of the encryption key, for AES-256.
+	KeySize = 32
+	// DefaultTTL is how long the server keeps an entry when no TTL is set: as long as
+	// the longest role session.
+	DefaultTTL = 12 * time.Hour
+	// DefaultTimeout bounds each request to the server, so a server that is down only
+	// costs a cache miss.
+	DefaultTimeout = 5 * time.Second
+
+	// keyPrefix starts the server key of every entry.
+	keyPrefix = "aws-console/"
+)
+
+var (
+	// ErrNotFound is returned by Get when the server has no entry for the key.
+	ErrNotFound = errors.New("entry not found on the cache server")
+
+	namespacePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+)
+
+// Store is a key-value store of cache entries. Get fails for missing entries; Delete
+// ignores them.
+type Store interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+}
+
+// Options configure the store Open returns.
+type Options struct {
+	// URL is the server: redis:// or rediss:// for Redis, http:// or https:// for an
+	// HTTP server.
+	URL string
+	// Headers are added to the requests of an HTTP server.
+	Headers map[string]string
+	// Key encrypts the entries; it must be KeySize bytes.
+	Key []byte
+	// Namespace, when set, keeps the entries apart from those of other teams sharing the
+	// server.
+	Namespace string
+	// TTL is how long the server keeps an entry; zero means DefaultTTL.
+	TTL time.Duration
+	// Transport, when set, carries the requests of an HTTP server, and its TLS
+	// configuration, such as a CA bundle, is used for Redis too.
+	Transport *http.Transport
+}
+
+// Open returns the encrypted store of the server at opts.URL.
+func Open(opts Options) (Store, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid cache server URL %q", opts.URL)
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	var store Store
+	switch u.Scheme {
+	case "redis", "rediss":
+		store, err = newRedis(u, ttl, opts.Transport)
+		if err != nil {
+			return nil, err
+		}
+	case "http", "https":
+		h := &HTTP{URL: opts.URL, Headers: opts.Headers, TTL: ttl}
+		if opts.Transport != nil {
+			h.Client = &http.Client{Transport: opts.Transport, Timeout: DefaultTimeout}
+		}
+		store = h
+	default:
+		return nil, fmt.Errorf("unsupported cache server URL %q (expected redis, rediss, http, or https)", opts.URL)
+	}
+	return NewEncrypted(store, opts.Key, opts.Namespace)
+}
+
+// newRedis returns the Redis store of u, redis://[user:password@]host[:port][/db].
+func newRedis(u *url.URL, ttl time.Duration, transport *http.Transport) (*Redis, error) {
+	r := &Redis{Addr: u.Host, TTL: ttl}
+	if u.Port() == "" {
+		r.Addr = u.Host + ":6379"
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			r.Username, r.Password = u.User.Username(), password
+		} else {
+			// redis://secret@host gives the password alone, as redis-cli reads it.
+			r.Password = u.User.Username()
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid Redis database %q in the cache server URL", db)
+		}
+		r.DB = n
+	}
+	if u.Scheme == "rediss" {
+		r.TLS = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+		if transport != nil && transport.TLSClientConfig != nil {
+			r.TLS = transport.TLSClientConfig.Clone()
+			r.TLS.ServerName = u.Hostname()
+		}
+	}
+	return r, nil
+}
+
+// ParseKey decodes an encryption key given in base64, as 'openssl rand -base64 32'
+// prints one.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("the key must be %d bytes in base64, as 'openssl rand -base64 %d' prints", KeySize, KeySize)
+	}
+	return key, nil
+}
+
+// Encrypted is a Store that seals entries with AES-256-GCM before passing them to
+// another. Each entry is bound to its key, so the server cannot swap one entry for
+// another, and entries that fail to open are reported as errors, never returned.
+type Encrypted struct {
+	store  Store
+	aead   cipher.AEAD
+	prefix string
+}
+
+// NewEncrypted returns a store that encrypts entries with key and keeps them in store,
+// under keys prefixed with namespace, when set.
+func NewEncrypted(store Store, key []byte, namespace string) (*Encrypted, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("the cache server key must be %d bytes, got %d", KeySize, len(key))
+	}
+	prefix := keyPrefix
+	if namespace != "" {
+		if !namespacePattern.MatchString(namespace) {
+			return nil, fmt.Errorf("invalid cache server namespace %q (up to 64 letters, digits, ., _, or -)", namespace)
+		}
+		prefix += namespace + "/"
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypted{store: store, aead: aead, prefix: prefix}, nil
+}
+
+// Get returns the decrypted entry for key.
+func (e *Encrypted) Get(key string) ([]byte, error) {
+	key = e.prefix + key
+	data, err := e.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	size := e.aead.NonceSize()
+	if len(data) < size {
+		return nil, errors.New("cache server entry is too short to be encrypted")
+	}
+	value, err := e.aead.Open(nil, data[:size], data[size:], []byte(key))
+	if err != nil {
+		return nil, errors.New("cache server entry failed to decrypt: it was written with another key, or changed")
+	}
+	return value, nil
+}
+
+// Set encrypts value and stores it for key, after a random nonce.
+func (e *Encrypted) Set(key string, value []byte) error {
+	key = e.prefix + key
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	return e.store.Set(key, e.aead.Seal(nonce, nonce, value, []byte(key)))
+}
+
+// Delete removes the entry for key.
+func (e *Encrypted) Delete(key string) error {
+	return e.store.Delete(e.prefix + key)
+}
@@ -0,0 +1,123 @@
+package cachestore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TTLHeader tells an HTTP server, in seconds, how long it may keep an entry.
+	TTLHeader = "X-TTL"
+
+	// maxEntrySize bounds the entries read from an HTTP server.
+	maxEntrySize = 1 << 20
+)
+
+// HTTP keeps entries on an HTTP server: each is PUT to, read with GET from, and removed
+// with DELETE at its key below URL, with an application/octet-stream body. Get expects
+// 404 Not Found for missing entries, and Delete accepts it.
+type HTTP struct {
+	URL     string
+	Headers map[string]string
+	// TTL is sent with each entry as TTLHeader; servers should drop entries after it.
+	TTL time.Duration
+	// Client sends the requests; nil means a client with DefaultTimeout.
+	Client *http.Client
+}
+
+// Get returns the entry for key.
+func (h *HTTP) Get(key string) ([]byte, error) {
+	resp, err := h.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("the cache server answered %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from the cache server: %w", err)
+	}
+	if len(data) > maxEntrySize {
+		return nil, fmt.Errorf("cache server entry is larger than %d bytes", maxEntrySize)
+	}
+	return data, nil
+}
+
+// Set stores value for key.
+func (h *HTTP) Set(key string, value []byte) error {
+	resp, err := h.do(http.MethodPut, key, value)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("the cache server answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Delete removes the entry for key.
+func (h *HTTP) Delete(key string) error {
+	resp, err := h.do(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("the cache server answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (h *HTTP) do(method, key string, body []byte) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(h.URL, "/")+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("invalid cache server request: %w", err)
+	}
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/octet-stream")
+		if h.TTL > 0 {
+			req.Header.Set(TTLHeader, strconv.Itoa(int(h.TTL.Seconds())))
+		}
+	}
+
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to reach the cache server: %w", err)
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the request's context once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
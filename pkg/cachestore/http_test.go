@@ -0,0 +1,80 @@
+package cachestore
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHTTP(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	entries := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		key, ok := strings.CutPrefix(r.URL.Path, "/cache/")
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			value, ok := entries[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, value)
+		case http.MethodPut:
+			if r.Header.Get(TTLHeader) != "3600" || r.Header.Get("Content-Type") != "application/octet-stream" {
+				t.Errorf("unexpected headers %v", r.Header)
+			}
+			body, _ := io.ReadAll(r.Body)
+			entries[key] = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			if _, ok := entries[key]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(entries, key)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	store := &HTTP{URL: server.URL + "/cache/", Headers: map[string]string{"Authorization": "Bearer token"}, TTL: time.Hour}
+	if err := store.Set("aws-console/credentials/abc", []byte("sealed")); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if entries["aws-console/credentials/abc"] != "sealed" {
+		t.Fatalf("server holds %v", entries)
+	}
+	if got, err := store.Get("aws-console/credentials/abc"); err != nil || string(got) != "sealed" {
+		t.Fatalf("Get() = %q, %v", got, err)
+	}
+	if err := store.Delete("aws-console/credentials/abc"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete("aws-console/credentials/abc"); err != nil {
+		t.Fatalf("Delete() of a missing entry error = %v", err)
+	}
+	if _, err := store.Get("aws-console/credentials/abc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() of a missing entry error = %v, want ErrNotFound", err)
+	}
+
+	unauthorized := &HTTP{URL: server.URL + "/cache"}
+	if err := unauthorized.Set("key", []byte("sealed")); err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Fatalf("Set() without a token error = %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package cachestore
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis keeps entries in a Redis server, speaking just enough of its protocol to
+// authenticate, select a database, and GET, SET, and DEL keys. Each call opens its own
+// connection: a command touches the cache a few times at most.
+type Redis struct {
+	// Addr is the server's host:port.
+	Addr string
+	// TLS, when set, encrypts the connection, as rediss:// URLs do.
+	TLS *tls.Config
+	// Username and Password authenticate with AUTH when Password is set; Username is
+	// empty for servers without ACL users.
+	Username string
+	Password string
+	DB       int
+	// TTL is when the server expires each entry.
+	TTL time.Duration
+	// Timeout bounds each call; zero means DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Get returns the entry for key.
+func (r *Redis) Get(key string) ([]byte, error) {
+	reply, err := r.do("GET", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNotFound
+	}
+	return reply, nil
+}
+
+// Set stores value for key, expiring after TTL.
+func (r *Redis) Set(key string, value []byte) error {
+	args := []string{"SET", key, string(value)}
+	if r.TTL > 0 {
+		args = append(args, "PX", strconv.FormatInt(r.TTL.Milliseconds(), 10))
+	}
+	_, err := r.do(args...)
+	return err
+}
+
+// Delete removes the entry for key.
+func (r *Redis) Delete(key string) error {
+	_, err := r.do("DEL", key)
+	return err
+}
+
+// do runs the command args on a new connection and returns its reply: the value of
+// a bulk string, nil for a null reply, and the text of simple strings and integers.
+func (r *Redis) do(args ...string) ([]byte, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if r.TLS != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", r.Addr, r.TLS)
+	} else {
+		conn, err = dialer.Dial("tcp", r.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the cache server: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var commands [][]string
+	if r.Password != "" {
+		auth := []string{"AUTH", r.Password}
+		if r.Username != "" {
+			auth = []string{"AUTH", r.Username, r.Password}
+		}
+		commands = append(commands, auth)
+	}
+	if r.DB != 0 {
+		commands = append(commands, []string{"SELECT", strconv.Itoa(r.DB)})
+	}
+	commands = append(commands, args)
+
+	// Commands are pipelined: written together, their replies read in order.
+	w := bufio.NewWriter(conn)
+	for _, c := range commands {
+		writeCommand(w, c)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write to the cache server: %w", err)
+	}
+
+	rd := bufio.NewReader(conn)
+	var reply []byte
+	for _, c := range commands {
+		if reply, err = readReply(rd); err != nil {
+			return nil, fmt.Errorf("cache server %s failed: %w", c[0], err)
+		}
+	}
+	return reply, nil
+}
+
+// writeCommand writes args as a RESP array of bulk strings.
+func writeCommand(w *bufio.Writer, args []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(a), a)
+	}
+}
+
+// readReply reads one RESP reply that is not an array.
+func readReply(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch kind, rest := line[0], line[1:]; kind {
+	case '+', ':':
+		return []byte(rest), nil
+	case '-':
+		return nil, errors.New(rest)
+	case '$':
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < -1 || n > maxEntrySize {
+			return nil, fmt.Errorf("invalid reply %q", line)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,161 @@
+package cachestore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the commands Redis sends from memory, requiring AUTH with password.
+type fakeRedis struct {
+	password string
+
+	mu       sync.Mutex
+	entries  map[string]string
+	commands []string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{password: password, entries: map[string]string{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authenticated := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		var reply string
+		switch {
+		case args[0] == "AUTH":
+			authenticated = args[len(args)-1] == f.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid username-password pair\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "SELECT":
+			reply = "+OK\r\n"
+		case args[0] == "GET":
+			value, ok := f.entries[args[1]]
+			reply = "$-1\r\n"
+			if ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			}
+		case args[0] == "SET":
+			f.entries[args[1]] = args[2]
+			reply = "+OK\r\n"
+		case args[0] == "DEL":
+			delete(f.entries, args[1])
+			reply = ":1\r\n"
+		}
+		f.mu.Unlock()
+		io.WriteString(conn, reply)
+	}
+}
+
+// readCommand reads a RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		data, err := readReply(r)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = string(data)
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	t.Parallel()
+
+	server, addr := startFakeRedis(t, "secret")
+	store := &Redis{Addr: addr, Password: "secret", DB: 3, TTL: time.Hour, Timeout: time.Second}
+
+	value := "sealed\r\nwith a line break"
+	if err := store.Set("aws-console/credentials/abc", []byte(value)); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := store.Get("aws-console/credentials/abc"); err != nil || string(got) != value {
+		t.Fatalf("Get() = %q, %v", got, err)
+	}
+	if err := store.Delete("aws-console/credentials/abc"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get("aws-console/credentials/abc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() of a missing entry error = %v, want ErrNotFound", err)
+	}
+
+	server.mu.Lock()
+	commands := server.commands
+	server.mu.Unlock()
+	want := "SET aws-console/credentials/abc " + value + " PX 3600000"
+	if len(commands) != 12 || commands[0] != "AUTH secret" || commands[1] != "SELECT 3" || commands[2] != want {
+		t.Fatalf("commands = %q", commands)
+	}
+
+	wrong := &Redis{Addr: addr, Password: "wrong", Timeout: time.Second}
+	if _, err := wrong.Get("key"); err == nil || !strings.Contains(err.Error(), "cache server AUTH failed: WRONGPASS") {
+		t.Fatalf("Get() with a wrong password error = %v", err)
+	}
+	unauthenticated := &Redis{Addr: addr, Timeout: time.Second}
+	if _, err := unauthenticated.Get("key"); err == nil || !strings.Contains(err.Error(), "NOAUTH") {
+		t.Fatalf("Get() without a password error = %v", err)
+	}
+}
+
+func TestRedisUnreachable(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	store := &Redis{Addr: addr, Timeout: time.Second}
+	if _, err := store.Get("key"); err == nil || !strings.Contains(err.Error(), "failed to reach the cache server") {
+		t.Fatalf("Get() error = %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package clipboard copies text to the system clipboard with the platform's clipboard
+// command, falling back to the OSC 52 terminal escape sequence when none is installed.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Runner runs external commands.
+type Runner interface {
+	Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
+// ErrUnavailable is returned when no clipboard command is installed and there is no
+// terminal to fall back to.
+var ErrUnavailable = errors.New("no clipboard command found")
+
+type command struct {
+	name string
+	args []string
+}
+
+// Clipboard copies text on one platform.
+type Clipboard struct {
+	goos   string
+	runner Runner
+	getenv func(string) string
+	// terminal receives the OSC 52 sequence when no clipboard command is found; nil
+	// disables the fallback.
+	terminal io.Writer
+}
+
+// New creates a clipboard for goos (a runtime.GOOS value). terminal, when not nil, is
+// the terminal asked to set the clipboard if no clipboard command is installed.
+func New(goos string, runner Runner, getenv func(string) string, terminal io.Writer) *Clipboard {
+	return &Clipboard{goos: goos, runner: runner, getenv: getenv, terminal: terminal}
+}
+
+// Copy places text on the clipboard, trying each of the platform's clipboard commands
+// in turn.
+func (c *Clipboard) Copy(text string) error {
+	for _, cmd := range c.commands() {
+		var stderr strings.Builder
+		err := c.runner.Run(cmd.name, cmd.args, strings.NewReader(text), io.Discard, &stderr)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, exec.ErrNotFound) {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return fmt.Errorf("%s failed: %w: %s", cmd.name, err, msg)
+			}
+			return fmt.Errorf("%s failed: %w", cmd.name, err)
+		}
+	}
+
+	if c.terminal == nil {
+		return ErrUnavailable
+	}
+	_, err := io.WriteString(c.terminal, OSC52(text))
+	return err
+}
+
+// commands returns the clipboard commands of the platform, in order of preference.
+// On Linux, wl-copy is preferred in a Wayland session and the X11 tools otherwise.
+func (c *Clipboard) commands() []command {
+	switch c.goos {
+	case "darwin":
+		return []command{{name: "pbcopy"}}
+	case "windows":
+		return []command{{name: "clip"}}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		x11 := []command{
+			{name: "xclip", args: []string{"-selection", "clipboard"}},
+			{name: "xsel", args: []string{"--clipboard", "--input"}},
+		}
+		wayland := command{name: "wl-copy"}
+		if c.getenv != nil && c.getenv("WAYLAND_DISPLAY") != "" {
+			return append([]command{wayland}, x11...)
+		}
+		return append(x11, wayland)
+	default:
+		return nil
+	}
+}
+
+// OSC52 returns the escape sequence that asks a terminal to set the clipboard to text.
+// Most terminal emulators honor it, including over SSH.
+func OSC52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
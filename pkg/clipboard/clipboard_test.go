@@ -0,0 +1,138 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type runCall struct {
+	name  string
+	args  []string
+	stdin string
+}
+
+type fakeRunner struct {
+	// installed lists the commands found on PATH; others fail with exec.ErrNotFound.
+	installed map[string]error
+	calls     []runCall
+}
+
+func (f *fakeRunner) Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	input, _ := io.ReadAll(stdin)
+	f.calls = append(f.calls, runCall{name: name, args: append([]string(nil), args...), stdin: string(input)})
+	err, ok := f.installed[name]
+	if !ok {
+		return &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	if err != nil {
+		io.WriteString(stderr, "Error: Can't open display\n")
+	}
+	return err
+}
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+
+	const text = "https://signin.aws.amazon.com/federation?Action=login"
+
+	testCases := []struct {
+		name          string
+		goos          string
+		env           map[string]string
+		installed     map[string]error
+		terminal      bool
+		wantCommand   string
+		wantArgs      []string
+		wantTried     int
+		wantOSC52     bool
+		wantErrSubstr string
+	}{
+		{name: "macOS", goos: "darwin", installed: map[string]error{"pbcopy": nil}, wantCommand: "pbcopy", wantTried: 1},
+		{name: "windows", goos: "windows", installed: map[string]error{"clip": nil}, wantCommand: "clip", wantTried: 1},
+		{
+			name:        "x11",
+			goos:        "linux",
+			installed:   map[string]error{"xclip": nil, "wl-copy": nil},
+			wantCommand: "xclip",
+			wantArgs:    []string{"-selection", "clipboard"},
+			wantTried:   1,
+		},
+		{
+			name:        "wayland",
+			goos:        "linux",
+			env:         map[string]string{"WAYLAND_DISPLAY": "wayland-0"},
+			installed:   map[string]error{"xclip": nil, "wl-copy": nil},
+			wantCommand: "wl-copy",
+			wantTried:   1,
+		},
+		{
+			name:        "falls through missing commands",
+			goos:        "linux",
+			installed:   map[string]error{"xsel": nil},
+			wantCommand: "xsel",
+			wantArgs:    []string{"--clipboard", "--input"},
+			wantTried:   2,
+		},
+		{name: "terminal fallback", goos: "linux", terminal: true, wantTried: 3, wantOSC52: true},
+		{name: "unsupported platform uses the terminal", goos: "plan9", terminal: true, wantOSC52: true},
+		{name: "no clipboard", goos: "linux", wantTried: 3, wantErrSubstr: "no clipboard command found"},
+		{
+			name:          "command failure",
+			goos:          "linux",
+			installed:     map[string]error{"xclip": errors.New("exit status 1")},
+			terminal:      true,
+			wantTried:     1,
+			wantErrSubstr: "xclip failed: exit status 1: Error: Can't open display",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := &fakeRunner{installed: tc.installed}
+			var terminal *bytes.Buffer
+			var w io.Writer
+			if tc.terminal {
+				terminal = &bytes.Buffer{}
+				w = terminal
+			}
+			getenv := func(key string) string { return tc.env[key] }
+
+			err := New(tc.goos, runner, getenv, w).Copy(text)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(runner.calls) != tc.wantTried {
+				t.Fatalf("expected %d commands to be tried, got %+v", tc.wantTried, runner.calls)
+			}
+
+			if tc.wantCommand != "" {
+				last := runner.calls[len(runner.calls)-1]
+				if last.name != tc.wantCommand || strings.Join(last.args, " ") != strings.Join(tc.wantArgs, " ") || last.stdin != text {
+					t.Fatalf("unexpected clipboard command: %+v", last)
+				}
+			}
+			if tc.wantOSC52 && terminal.String() != OSC52(text) {
+				t.Fatalf("expected the OSC 52 sequence, got %q", terminal.String())
+			}
+		})
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	t.Parallel()
+
+	if got := OSC52("hello"); got != "\x1b]52;c;aGVsbG8=\a" {
+		t.Fatalf("unexpected sequence %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BundleVersion is the version of the bundle format, written as aws-console-bundle.
+const BundleVersion = 1
+
+// Bundle is the part of a config file a team shares as a standard setup: aliases,
+// groups, bookmarks, session policies, and some settings, such as browser choices and
+// policies, top-level and per profile. Hooks, the audit log, the shortener, telemetry,
+// SAML providers, and the cache server are never part of one, so a bundle holds neither secrets nor
+// commands to run.
+type Bundle struct {
+	Version         int                          `yaml:"aws-console-bundle"`
+	Settings        map[string]string            `yaml:"settings,omitempty"`
+	Profiles        map[string]map[string]string `yaml:"profiles,omitempty"`
+	Aliases         map[string]string            `yaml:"aliases,omitempty"`
+	Groups          map[string][]string          `yaml:"groups,omitempty"`
+	Bookmarks       map[string]Bookmark          `yaml:"bookmarks,omitempty"`
+	SessionPolicies map[string]SessionPolicy     `yaml:"session-policies,omitempty"`
+}
+
+// BundleEntry names an entry of a bundle, such as the bookmark prod-billing or the
+// browser setting of the profile prod.
+type BundleEntry struct {
+	// Section is the section of the entry: settings, aliases, groups, bookmarks,
+	// session-policies, or profiles.<profile>.
+	Section string
+	Name    string
+}
+
+func (e BundleEntry) String() string {
+	return e.Section + "." + e.Name
+}
+
+// MergeResult is what merging a bundle into a file changed.
+type MergeResult struct {
+	// Added are the entries of the bundle the file did not have.
+	Added []BundleEntry
+	// Conflicts are the entries the file already had with another value, which it kept.
+	Conflicts []BundleEntry
+}
+
+// Bundle returns the shareable part of f, with the settings named by keys.
+func (f *File) Bundle(keys []string) *Bundle {
+	b := &Bundle{
+		Version:         BundleVersion,
+		Settings:        pickSettings(f.Settings, keys),
+		Aliases:         maps.Clone(f.Aliases),
+		Groups:          maps.Clone(f.Groups),
+		Bookmarks:       maps.Clone(f.Bookmarks),
+		SessionPolicies: maps.Clone(f.SessionPolicies),
+	}
+	for profile, section := range f.Profiles {
+		if picked := pickSettings(section, keys); len(picked) > 0 {
+			if b.Profiles == nil {
+				b.Profiles = map[string]map[string]string{}
+			}
+			b.Profiles[profile] = picked
+		}
+	}
+	return b
+}
+
+func pickSettings(settings map[string]string, keys []string) map[string]string {
+	var picked map[string]string
+	for key, value := range settings {
+		if slices.Contains(keys, key) {
+			if picked == nil {
+				picked = map[string]string{}
+			}
+			picked[key] = value
+		}
+	}
+	return picked
+}
+
+// ParseBundle reads a bundle, rejecting sections it may not hold and session policies
+// that refer to files, which would be read relative to someone else's config file.
+func ParseBundle(data []byte) (*Bundle, error) {
+	var b Bundle
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	switch {
+	case b.Version == 0:
+		return nil, errors.New("not an aws-console bundle: aws-console-bundle is not set")
+	case b.Version > BundleVersion:
+		return nil, fmt.Errorf("unsupported bundle version %d (this aws-console reads version %d)", b.Version, BundleVersion)
+	}
+	for _, name := range slices.Sorted(maps.Keys(b.SessionPolicies)) {
+		if b.SessionPolicies[name].File != "" {
+			return nil, fmt.Errorf("session policy %q of the bundle refers to a file; bundles hold policies inline", name)
+		}
+	}
+	return &b, nil
+}
+
+// Merge adds the entries of b that f does not have. Entries f has with the same value
+// are left alone, and those it has with another value are kept and reported as
+// conflicts, so a shared setup never overwrites local changes. Entries are listed
+// section by section, sorted by name.
+func (f *File) Merge(b *Bundle) MergeResult {
+	var r MergeResult
+	f.Settings = mergeSection(&r, "settings", f.Settings, b.Settings, func(a, b string) bool { return a == b })
+	for _, profile := range slices.Sorted(maps.Keys(b.Profiles)) {
+		if len(b.Profiles[profile]) == 0 {
+			continue
+		}
+		if f.Profiles == nil {
+			f.Profiles = map[string]map[string]string{}
+		}
+		f.Profiles[profile] = mergeSection(&r, "profiles."+profile, f.Profiles[profile], b.Profiles[profile], func(a, b string) bool { return a == b })
+	}
+	f.Aliases = mergeSection(&r, "aliases", f.Aliases, b.Aliases, func(a, b string) bool { return a == b })
+	f.Groups = mergeSection(&r, "groups", f.Groups, b.Groups, slices.Equal[[]string])
+	f.Bookmarks = mergeSection(&r, "bookmarks", f.Bookmarks, b.Bookmarks, func(a, b Bookmark) bool { return a == b })
+	f.SessionPolicies = mergeSection(&r, "session-policies", f.SessionPolicies, b.SessionPolicies, func(a, b SessionPolicy) bool {
+		return slices.Equal(a.PolicyARNs, b.PolicyARNs) && a.Policy == b.Policy && a.File == b.File
+	})
+	return r
+}
+
+// mergeSection adds the entries of from missing in into, recording each in r, and
+// returns into, created when entries are added to a nil map.
+func mergeSection[V any](r *MergeResult, section string, into, from map[string]V, equal func(a, b V) bool) map[string]V {
+	for _, name := range slices.Sorted(maps.Keys(from)) {
+		entry := BundleEntry{Section: section, Name: name}
+		existing, ok := into[name]
+		switch {
+		case !ok:
+			if into == nil {
+				into = map[string]V{}
+			}
+			into[name] = from[name]
+			r.Added = append(r.Added, entry)
+		case !equal(existing, from[name]):
+			r.Conflicts = append(r.Conflicts, entry)
+		}
+	}
+	return into
+}
+
+// Encode returns b as YAML.
+func (b *Bundle) Encode() ([]byte, error) {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFileBundle(t *testing.T) {
+	t.Parallel()
+
+	f := &File{
+		Settings: map[string]string{"browser": "firefox", "duration": "4h"},
+		Profiles: map[string]map[string]string{
+			"prod": {"container": "prod", "destination": "cloudwatch"},
+			"dev":  {"duration": "1h"},
+		},
+		Aliases:   map[string]string{"p": "prod"},
+		Bookmarks: map[string]Bookmark{"billing": {Profile: "p", Destination: "billing/home"}},
+		Hooks:     Hooks{PreOpen: []Hook{{Command: "notify-send"}}},
+		Audit:     Audit{Webhook: "https://audit.example.com", Headers: map[string]string{"Authorization": "Bearer secret"}},
+	}
+
+	b := f.Bundle([]string{"browser", "container"})
+	if b.Version != BundleVersion || len(b.Settings) != 1 || b.Settings["browser"] != "firefox" {
+		t.Fatalf("unexpected settings %+v", b)
+	}
+	if len(b.Profiles) != 1 || len(b.Profiles["prod"]) != 1 || b.Profiles["prod"]["container"] != "prod" {
+		t.Fatalf("unexpected profiles %v", b.Profiles)
+	}
+	data, err := b.Encode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "notify-send") {
+		t.Fatalf("expected no audit log or hooks in the bundle, got:\n%s", data)
+	}
+
+	parsed, err := ParseBundle(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parsed.Bookmarks["billing"] != f.Bookmarks["billing"] || parsed.Aliases["p"] != "prod" {
+		t.Fatalf("unexpected bundle %+v", parsed)
+	}
+}
+
+func TestParseBundle(t *testing.T) {
+	t.Parallel()
+
+	for data, wantErrSubstr := range map[string]string{
+		"bookmarks: {}\n":                                "aws-console-bundle is not set",
+		"aws-console-bundle: 2\n":                        "unsupported bundle version 2",
+		"aws-console-bundle: 1\nhooks:\n  pre-open: [x]": "field hooks not found",
+		"aws-console-bundle: 1\nsession-policies:\n  s3:\n    file: s3.json\n": `session policy "s3" of the bundle refers to a file`,
+	} {
+		if _, err := ParseBundle([]byte(data)); err == nil || !strings.Contains(err.Error(), wantErrSubstr) {
+			t.Fatalf("ParseBundle(%q): expected error containing %q, got %v", data, wantErrSubstr, err)
+		}
+	}
+}
+
+func TestFileMerge(t *testing.T) {
+	t.Parallel()
+
+	f := &File{
+		Settings:  map[string]string{"browser": "chrome"},
+		Profiles:  map[string]map[string]string{"prod": {"duration": "1h"}},
+		Groups:    map[string][]string{"all": {"prod", "dev"}},
+		Bookmarks: map[string]Bookmark{"billing": {Profile: "prod", Destination: "billing/home"}},
+	}
+	b := &Bundle{
+		Version:   BundleVersion,
+		Settings:  map[string]string{"browser": "firefox", "strict": "true"},
+		Profiles:  map[string]map[string]string{"prod": {"container": "prod"}, "empty": {}},
+		Aliases:   map[string]string{"p": "prod"},
+		Groups:    map[string][]string{"all": {"prod", "dev"}, "prod-only": {"prod"}},
+		Bookmarks: map[string]Bookmark{"billing": {Profile: "prod", Destination: "cost-explorer"}},
+		SessionPolicies: map[string]SessionPolicy{
+			"read-only": {PolicyARNs: []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"}},
+		},
+	}
+
+	r := f.Merge(b)
+	if got := fmt.Sprint(r.Added); got != "[settings.strict profiles.prod.container aliases.p groups.prod-only session-policies.read-only]" {
+		t.Fatalf("unexpected added entries %s", got)
+	}
+	if got := fmt.Sprint(r.Conflicts); got != "[settings.browser bookmarks.billing]" {
+		t.Fatalf("unexpected conflicts %s", got)
+	}
+	if f.Settings["browser"] != "chrome" || f.Bookmarks["billing"].Destination != "billing/home" {
+		t.Fatalf("expected conflicting entries to keep their values, got %+v", f)
+	}
+	if f.Profiles["prod"]["duration"] != "1h" || f.Profiles["prod"]["container"] != "prod" || f.Aliases["p"] != "prod" {
+		t.Fatalf("unexpected merged file %+v", f)
+	}
+	if _, ok := f.Profiles["empty"]; ok {
+		t.Fatal("expected empty profile sections to be left out")
+	}
+	if again := f.Merge(b); len(again.Added) != 0 || len(again.Conflicts) != 2 {
+		t.Fatalf("expected a second merge to add nothing, got %+v", again)
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"github.com/spf13/pflag"
+)
+
+// Source identifies the layer a setting's value was read from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceProfile Source = "profile"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Setting declares a configurable value and the places it may be read from.
+type Setting struct {
+	Key         string
+	Description string
+	Default     string
+	// Flag is the command-line flag name, if any.
+	Flag string
+	// Env lists environment variables in priority order.
+	Env []string
+	// ProfileKey is the key read from the selected profile in the shared AWS config, if any.
+	ProfileKey string
+	// FileKey is the key read from the aws-console config file, if any.
+	FileKey string
+}
+
+// Value is a resolved setting along with where it came from.
+type Value struct {
+	Setting Setting
+	Value   string
+	Source  Source
+	// Origin names the specific flag, variable, or file that supplied the value.
+	Origin string
+}
+
+// Overridden reports whether the value differs from the setting's built-in default.
+func (v Value) Overridden() bool {
+	return v.Source != SourceDefault && v.Value != v.Setting.Default
+}
+
+// Layer supplies setting values from a single source.
+type Layer struct {
+	Source Source
+	Lookup func(s Setting) (value string, origin string, ok bool)
+}
+
+// Resolve computes the effective value of each setting. Layers are consulted in order,
+// so the first layer that supplies a value wins; settings no layer supplies fall back
+// to their defaults.
+func Resolve(settings []Setting, layers ...Layer) []Value {
+	values := make([]Value, 0, len(settings))
+	for _, s := range settings {
+		value := Value{Setting: s, Value: s.Default, Source: SourceDefault}
+		for _, layer := range layers {
+			if v, origin, ok := layer.Lookup(s); ok {
+				value = Value{Setting: s, Value: v, Source: layer.Source, Origin: origin}
+				break
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// Lookup returns the value for key from a resolved set.
+func Lookup(values []Value, key string) (Value, bool) {
+	for _, v := range values {
+		if v.Setting.Key == key {
+			return v, true
+		}
+	}
+	return Value{}, false
+}
+
+// FlagLayer reads settings from flags that were explicitly set on the command line.
+func FlagLayer(flags *pflag.FlagSet) Layer {
+	return Layer{
+		Source: SourceFlag,
+		Lookup: func(s Setting) (string, string, bool) {
+			if s.Flag == "" || flags == nil {
+				return "", "", false
+			}
+			f := flags.Lookup(s.Flag)
+			if f == nil || !f.Changed {
+				return "", "", false
+			}
+			return f.Value.String(), "--" + f.Name, true
+		},
+	}
+}
+
+// EnvLayer reads settings from non-empty environment variables.
+func EnvLayer(lookupEnv func(string) (string, bool)) Layer {
+	return Layer{
+		Source: SourceEnv,
+		Lookup: func(s Setting) (string, string, bool) {
+			for _, name := range s.Env {
+				if v, ok := lookupEnv(name); ok && v != "" {
+					return v, name, true
+				}
+			}
+			return "", "", false
+		},
+	}
+}
+
+// ProfileLayer reads settings from the keys of a profile section in the shared AWS config.
+func ProfileLayer(profile string, keys map[string]string) Layer {
+	return Layer{
+		Source: SourceProfile,
+		Lookup: func(s Setting) (string, string, bool) {
+			if s.ProfileKey == "" {
+				return "", "", false
+			}
+			if v, ok := keys[s.ProfileKey]; ok && v != "" {
+				return v, "profile " + profile, true
+			}
+			return "", "", false
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func testSettings() []Setting {
+	return []Setting{
+		{Key: "profile", Flag: "profile", Env: []string{"AWS_PROFILE"}},
+		{Key: "region", Env: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}, ProfileKey: "region"},
+		{Key: "duration", Default: "43200", Flag: "duration"},
+	}
+}
+
+func testEnv(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("profile", "", "")
+	flags.String("duration", "43200", "")
+	if err := flags.Parse([]string{"--profile", "flag-profile"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	values := Resolve(testSettings(),
+		FlagLayer(flags),
+		EnvLayer(testEnv(map[string]string{
+			"AWS_PROFILE":        "env-profile",
+			"AWS_REGION":         "",
+			"AWS_DEFAULT_REGION": "eu-west-1",
+		})),
+		ProfileLayer("flag-profile", map[string]string{"region": "us-west-2"}),
+	)
+
+	testCases := []struct {
+		key        string
+		wantValue  string
+		wantSource Source
+		wantOrigin string
+		wantOver   bool
+	}{
+		{key: "profile", wantValue: "flag-profile", wantSource: SourceFlag, wantOrigin: "--profile", wantOver: true},
+		{key: "region", wantValue: "eu-west-1", wantSource: SourceEnv, wantOrigin: "AWS_DEFAULT_REGION", wantOver: true},
+		{key: "duration", wantValue: "43200", wantSource: SourceDefault, wantOver: false},
+	}
+
+	for _, tc := range testCases {
+		v, ok := Lookup(values, tc.key)
+		if !ok {
+			t.Fatalf("expected %q to be resolved", tc.key)
+		}
+		if v.Value != tc.wantValue || v.Source != tc.wantSource || v.Origin != tc.wantOrigin {
+			t.Fatalf("%s: got value=%q source=%q origin=%q", tc.key, v.Value, v.Source, v.Origin)
+		}
+		if v.Overridden() != tc.wantOver {
+			t.Fatalf("%s: expected Overridden()=%v", tc.key, tc.wantOver)
+		}
+	}
+}
+
+func TestResolveProfileLayer(t *testing.T) {
+	t.Parallel()
+
+	values := Resolve(testSettings(),
+		EnvLayer(testEnv(nil)),
+		ProfileLayer("dev", map[string]string{"region": "us-west-2"}),
+	)
+
+	v, _ := Lookup(values, "region")
+	if v.Value != "us-west-2" || v.Source != SourceProfile || v.Origin != "profile dev" {
+		t.Fatalf("unexpected region value: %+v", v)
+	}
+}
+
+func TestOverriddenWhenValueMatchesDefault(t *testing.T) {
+	t.Parallel()
+
+	v := Value{Setting: Setting{Default: "43200"}, Value: "43200", Source: SourceFlag}
+	if v.Overridden() {
+		t.Fatal("expected a value equal to the default not to count as an override")
+	}
+}
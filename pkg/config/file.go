@@ -0,0 +1,618 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourceFile marks values read from the aws-console config file.
+const SourceFile Source = "file"
+
+// GroupPrefix marks the name of a group where a profile is expected, as in "@all-prod".
+const GroupPrefix = "@"
+
+// File is the aws-console config file. Top-level keys are setting defaults, the
+// profiles section overrides them per AWS profile, aliases map short names to profile
+// names, groups name several profiles at once, bookmarks name console pages of a
+// profile, warm lists the profiles 'aws-console warm' prepares, session-policies
+// names session policies --session-policy can refer to, hooks are shell commands run
+// around opening the console, saml-providers are identity providers --saml signs in
+// to, shortener is the redirector --shorten exchanges sign-in URLs with, audit sends a
+// record of each console opened to a webhook or EventBridge event bus, telemetry
+// exports how long each step took to an OpenTelemetry collector, and cache-server is
+// the encrypted cache credential-store server shares with the other users of a host:
+//
+//	browser: firefox
+//	duration: 4h
+//	profiles:
+//	  prod-admin:
+//	    destination: cloudwatch
+//	aliases:
+//	  prod: prod-admin
+//	groups:
+//	  all-prod: [prod, prod-eu]
+//	  everything: ["@all-prod", dev]
+//	bookmarks:
+//	  prod-billing:
+//	    profile: prod
+//	    destination: billing/home
+//	warm: [prod, dev]
+//	session-policies:
+//	  s3-only:
+//	    file: ~/policies/s3-only.json
+//	  deploy:
+//	    policy-arns: [arn:aws:iam::123456789012:policy/Deploy]
+//	hooks:
+//	  pre-open:
+//	    - tmux rename-window "aws:$AWS_CONSOLE_PROFILE"
+//	  post-open:
+//	    - command: ~/bin/audit-webhook
+//	      on-failure: fail
+//	      timeout: 10s
+//	audit:
+//	  webhook: https://audit.example.com/aws-console
+//	  headers:
+//	    Authorization: Bearer ${AUDIT_TOKEN}
+//	telemetry:
+//	  endpoint: https://otel.example.com:4318
+//	cache-server:
+//	  url: rediss://:${REDIS_PASSWORD}@cache.internal:6379
+//	  key: ${AWS_CONSOLE_CACHE_KEY}
+type File struct {
+	Settings map[string]string            `yaml:",inline"`
+	Profiles map[string]map[string]string `yaml:"profiles,omitempty"`
+	Aliases  map[string]string            `yaml:"aliases,omitempty"`
+	// Groups lists the members of each group: profiles, aliases, or other groups
+	// written as "@group".
+	Groups    map[string][]string `yaml:"groups,omitempty"`
+	Bookmarks map[string]Bookmark `yaml:"bookmarks,omitempty"`
+	// Warm lists profiles, aliases, or groups whose sessions are prepared ahead of use.
+	Warm []string `yaml:"warm,omitempty"`
+	// SessionPolicies are named session policies that limit the permissions of a console
+	// session.
+	SessionPolicies map[string]SessionPolicy `yaml:"session-policies,omitempty"`
+	Hooks           Hooks                    `yaml:"hooks,omitempty"`
+	Audit           Audit                    `yaml:"audit,omitempty"`
+	// SAMLProviders are identity providers consoles can be opened through with
+	// sts:AssumeRoleWithSAML, by name.
+	SAMLProviders map[string]SAMLProvider `yaml:"saml-providers,omitempty"`
+	// Shortener is the redirector --shorten exchanges sign-in URLs with for short links.
+	Shortener Shortener `yaml:"shortener,omitempty"`
+	// Telemetry is the OpenTelemetry collector step timings are exported to.
+	Telemetry Telemetry `yaml:"telemetry,omitempty"`
+	// CacheServer is where credential-store server keeps cached credentials and sign-in
+	// tokens.
+	CacheServer CacheServer `yaml:"cache-server,omitempty"`
+}
+
+// Bookmark is a console page of a profile, opened by name.
+type Bookmark struct {
+	// Profile is the AWS profile, or an alias for one.
+	Profile string `yaml:"profile"`
+	// Destination is the console page, in any form --destination accepts; empty opens
+	// the console home page.
+	Destination string `yaml:"destination,omitempty"`
+}
+
+// SessionPolicy is a named session policy: managed policies, an inline policy
+// document, or both.
+type SessionPolicy struct {
+	PolicyARNs []string `yaml:"policy-arns,omitempty"`
+	// Policy is an inline JSON policy document; File is the path of one, relative to
+	// the config file. At most one of them is set.
+	Policy string `yaml:"policy,omitempty"`
+	File   string `yaml:"file,omitempty"`
+}
+
+// SAMLProvider is a SAML identity provider, such as AD FS or Keycloak, for
+// organizations that federate into AWS without IAM Identity Center.
+type SAMLProvider struct {
+	// Driver is how to sign in: adfs, keycloak, or command.
+	Driver string `yaml:"driver"`
+	// URL is the identity provider: the AD FS host, or the IdP-initiated SSO URL of the
+	// Keycloak client.
+	URL string `yaml:"url,omitempty"`
+	// Command is a shell command for the command driver that prints the identity
+	// provider's response, such as curl --negotiate for Kerberos.
+	Command string `yaml:"command,omitempty"`
+	// Username is asked for when empty.
+	Username string `yaml:"username,omitempty"`
+	// RoleARN is the role to assume among those the assertion grants; when empty and
+	// the assertion grants several, one is picked.
+	RoleARN string `yaml:"role-arn,omitempty"`
+}
+
+func (p SAMLProvider) validate() error {
+	switch {
+	case p.Driver == "":
+		return errors.New("sets no driver")
+	case p.Driver == "command" && strings.TrimSpace(p.Command) == "":
+		return errors.New("sets no command")
+	case p.Driver != "command" && p.URL == "":
+		return errors.New("sets no url")
+	}
+	return nil
+}
+
+// Hooks are shell commands run, in order, around opening the console.
+type Hooks struct {
+	// PreOpen runs once the sign-in URL is ready, before it is opened.
+	PreOpen []Hook `yaml:"pre-open,omitempty"`
+	// PostOpen runs once the console has been opened.
+	PostOpen []Hook `yaml:"post-open,omitempty"`
+}
+
+// What a failing hook does.
+const (
+	// HookWarn prints a warning and carries on. It is the default.
+	HookWarn = "warn"
+	// HookFail stops with an error: before the console opens for a pre-open hook.
+	HookFail = "fail"
+	// HookIgnore carries on silently.
+	HookIgnore = "ignore"
+)
+
+// DefaultHookTimeout is how long a hook may run when it sets no timeout.
+const DefaultHookTimeout = 30 * time.Second
+
+// Hook is a shell command run by a hook. A hook written as a plain string is just its
+// command.
+type Hook struct {
+	Command string `yaml:"command"`
+	// OnFailure is HookWarn, HookFail, or HookIgnore; empty means HookWarn.
+	OnFailure string `yaml:"on-failure,omitempty"`
+	// Timeout is a duration such as 10s; empty means DefaultHookTimeout.
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// UnmarshalYAML accepts a hook written as its command alone.
+func (h *Hook) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&h.Command)
+	}
+	type plain Hook
+	return node.Decode((*plain)(h))
+}
+
+// TimeoutDuration returns how long the hook may run.
+func (h Hook) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(h.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultHookTimeout
+}
+
+// validate reports a hook without a command, an unknown failure policy, or a timeout
+// that is not a positive duration.
+func (h Hook) validate() error {
+	if strings.TrimSpace(h.Command) == "" {
+		return errors.New("sets no command")
+	}
+	switch h.OnFailure {
+	case "", HookWarn, HookFail, HookIgnore:
+	default:
+		return fmt.Errorf("has unsupported on-failure %q (expected %s, %s, or %s)", h.OnFailure, HookWarn, HookFail, HookIgnore)
+	}
+	if h.Timeout != "" {
+		if d, err := time.ParseDuration(h.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("has invalid timeout %q: must be a positive duration", h.Timeout)
+		}
+	}
+	return nil
+}
+
+// Audit is where records of the consoles opened are sent. Both a webhook and an event
+// bus may be set; neither disables auditing.
+type Audit struct {
+	// Webhook is an http or https URL records are POSTed to as JSON.
+	Webhook string `yaml:"webhook,omitempty"`
+	// Headers are added to webhook requests. Values may refer to environment variables,
+	// as in ${AUDIT_TOKEN}, to keep secrets out of the file.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// EventBus is the name or ARN of an EventBridge event bus records are put on.
+	EventBus string `yaml:"event-bus,omitempty"`
+	// Profile and Region choose the credentials and region of PutEvents calls; empty
+	// uses the default credential chain and its region.
+	Profile string `yaml:"profile,omitempty"`
+	Region  string `yaml:"region,omitempty"`
+}
+
+// Enabled reports whether records are sent anywhere.
+func (a Audit) Enabled() bool {
+	return a.Webhook != "" || a.EventBus != ""
+}
+
+// validate reports a webhook that is not an http or https URL, and event bus settings
+// without an event bus.
+func (a Audit) validate() error {
+	if a.Webhook != "" {
+		u, err := url.Parse(a.Webhook)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("audit webhook %q is not an http or https URL", a.Webhook)
+		}
+	} else if len(a.Headers) > 0 {
+		return errors.New("audit headers are set without a webhook")
+	}
+	if a.EventBus == "" && (a.Profile != "" || a.Region != "") {
+		return errors.New("audit profile and region are set without an event-bus")
+	}
+	return nil
+}
+
+// Shortener is a self-hosted redirector that exchanges sign-in URLs for short,
+// single-use links. It holds working sign-in URLs, so it must be as trusted as the
+// credentials.
+type Shortener struct {
+	// URL is the https endpoint sign-in URLs are POSTed to.
+	URL string `yaml:"url,omitempty"`
+	// Headers are added to its requests. Values may refer to environment variables, as
+	// in ${SHORTENER_TOKEN}, to keep secrets out of the file.
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// validate reports a URL that is not https, since sign-in URLs must not be sent in the
+// clear, and headers without a URL.
+func (s Shortener) validate() error {
+	if s.URL != "" {
+		u, err := url.Parse(s.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("shortener url %q is not an https URL", s.URL)
+		}
+	} else if len(s.Headers) > 0 {
+		return errors.New("shortener headers are set without a url")
+	}
+	return nil
+}
+
+// Telemetry is an OpenTelemetry collector that receives the steps of each command as
+// spans over OTLP/HTTP. It is off unless an endpoint is set.
+type Telemetry struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, such as
+	// https://otel.example.com:4318; spans are sent to its /v1/traces path.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Headers are added to its requests. Values may refer to environment variables, as
+	// in ${OTEL_TOKEN}, to keep secrets out of the file.
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// validate reports an endpoint that is not an http or https URL, and headers without
+// an endpoint.
+func (t Telemetry) validate() error {
+	if t.Endpoint != "" {
+		u, err := url.Parse(t.Endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("telemetry endpoint %q is not an http or https URL", t.Endpoint)
+		}
+	} else if len(t.Headers) > 0 {
+		return errors.New("telemetry headers are set without an endpoint")
+	}
+	return nil
+}
+
+// CacheServer is a Redis or HTTP server that keeps the credentials and sign-in tokens
+// cached by every user of a host, such as a shared bastion, encrypted under a key the
+// team shares. It is used with credential-store server.
+type CacheServer struct {
+	// URL is redis://, rediss://, http://, or https://. It may refer to environment
+	// variables, as in rediss://:${REDIS_PASSWORD}@cache.internal.
+	URL string `yaml:"url,omitempty"`
+	// Headers are added to the requests of an HTTP server, expanded like URL.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Key is the base64 AES-256 key entries are encrypted with, which should refer to an
+	// environment variable, as in ${AWS_CONSOLE_CACHE_KEY}.
+	Key string `yaml:"key,omitempty"`
+	// Namespace keeps the entries apart from those of other teams on the same server.
+	Namespace string `yaml:"namespace,omitempty"`
+	// TTL is a duration such as 8h after which the server drops entries; empty means
+	// 12h.
+	TTL string `yaml:"ttl,omitempty"`
+	// Share lets users reuse each other's sessions of the same account and role, rather
+	// than only their own.
+	Share bool `yaml:"share,omitempty"`
+}
+
+// TTLDuration returns how long the server keeps entries, zero for its default.
+func (c CacheServer) TTLDuration() time.Duration {
+	d, _ := time.ParseDuration(c.TTL)
+	return d
+}
+
+// validate reports a URL of another scheme, one that would carry credentials in the
+// clear beyond the loopback interface, a URL without a key, headers without an HTTP
+// URL, and an invalid TTL.
+func (c CacheServer) validate() error {
+	if c.URL == "" {
+		if len(c.Headers) > 0 || c.Key != "" || c.Namespace != "" || c.TTL != "" || c.Share {
+			return errors.New("cache-server is set without a url")
+		}
+		return nil
+	}
+	// Userinfo may refer to the environment, which url.Parse rejects.
+	u, err := url.Parse(os.Expand(c.URL, func(string) string { return "x" }))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("cache-server url %q is not a URL", c.URL)
+	}
+	switch u.Scheme {
+	case "rediss", "https":
+	case "redis", "http":
+		if !isLoopback(u.Hostname()) {
+			return fmt.Errorf("cache-server url %q is not encrypted: use %ss:// for servers beyond this host", c.URL, u.Scheme)
+		}
+	default:
+		return fmt.Errorf("cache-server url %q is not a redis, rediss, http, or https URL", c.URL)
+	}
+	if len(c.Headers) > 0 && !strings.HasPrefix(u.Scheme, "http") {
+		return errors.New("cache-server headers are set for a Redis url")
+	}
+	if c.Key == "" {
+		return errors.New("cache-server key is not set")
+	}
+	if c.TTL != "" {
+		if d, err := time.ParseDuration(c.TTL); err != nil || d <= 0 {
+			return fmt.Errorf("cache-server ttl %q is not a positive duration", c.TTL)
+		}
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LoadFile reads the config file at path. A missing file, or an empty path, yields an
+// empty File.
+func LoadFile(path string) (*File, error) {
+	f := &File{}
+	if path == "" {
+		return f, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Save writes the file to path, creating its directory. Comments in an existing file
+// are not preserved.
+func (f *File) Save(path string) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Set stores value for key, in the section of profile when it is not empty.
+func (f *File) Set(profile, key, value string) {
+	if profile == "" {
+		if f.Settings == nil {
+			f.Settings = map[string]string{}
+		}
+		f.Settings[key] = value
+		return
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]map[string]string{}
+	}
+	if f.Profiles[profile] == nil {
+		f.Profiles[profile] = map[string]string{}
+	}
+	f.Profiles[profile][key] = value
+}
+
+// Unset removes key, from the section of profile when it is not empty, and reports
+// whether it was set. Emptied profile sections are removed.
+func (f *File) Unset(profile, key string) bool {
+	if profile == "" {
+		_, ok := f.Settings[key]
+		delete(f.Settings, key)
+		return ok
+	}
+	_, ok := f.Profiles[profile][key]
+	delete(f.Profiles[profile], key)
+	if len(f.Profiles[profile]) == 0 {
+		delete(f.Profiles, profile)
+	}
+	return ok
+}
+
+// Keys returns every setting key used in the file, top-level or in a profile section,
+// sorted.
+func (f *File) Keys() []string {
+	seen := map[string]bool{}
+	for key := range f.Settings {
+		seen[key] = true
+	}
+	for _, section := range f.Profiles {
+		for key := range section {
+			seen[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Alias returns the profile name that name is an alias for, following aliases of
+// aliases, or name itself.
+func (f *File) Alias(name string) string {
+	seen := map[string]bool{}
+	for !seen[name] {
+		target, ok := f.Aliases[name]
+		if !ok || target == "" {
+			break
+		}
+		seen[name] = true
+		name = target
+	}
+	return name
+}
+
+// Expand returns the profiles name stands for: for "@group", the members of the group
+// with nested groups expanded, aliases resolved, and duplicates removed, in order;
+// otherwise the profile name is an alias for, or name itself.
+func (f *File) Expand(name string) ([]string, error) {
+	group, ok := strings.CutPrefix(name, GroupPrefix)
+	if !ok {
+		return []string{f.Alias(name)}, nil
+	}
+	var profiles []string
+	if err := f.expandGroup(group, nil, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// expandGroup appends the profiles of group to profiles. path holds the groups being
+// expanded, to detect a group that contains itself.
+func (f *File) expandGroup(group string, path []string, profiles *[]string) error {
+	path = append(slices.Clip(path), GroupPrefix+group)
+	if slices.Contains(path[:len(path)-1], GroupPrefix+group) {
+		return fmt.Errorf("group cycle: %s", strings.Join(path, " -> "))
+	}
+	members, ok := f.Groups[group]
+	if !ok {
+		return fmt.Errorf("unknown group %q", GroupPrefix+group)
+	}
+	for _, member := range members {
+		if nested, ok := strings.CutPrefix(member, GroupPrefix); ok {
+			if err := f.expandGroup(nested, path, profiles); err != nil {
+				return err
+			}
+			continue
+		}
+		if profile := f.Alias(member); !slices.Contains(*profiles, profile) {
+			*profiles = append(*profiles, profile)
+		}
+	}
+	return nil
+}
+
+// Validate reports aliases that lead back to themselves, groups that cannot be
+// expanded, session policies that are empty or have two policy documents, and invalid
+// hooks, SAML providers, or audit settings.
+func (f *File) Validate() error {
+	for _, name := range slices.Sorted(maps.Keys(f.Aliases)) {
+		chain := []string{name}
+		for target := f.Aliases[name]; target != ""; target = f.Aliases[target] {
+			chain = append(chain, target)
+			if slices.Contains(chain[:len(chain)-1], target) {
+				return fmt.Errorf("alias cycle: %s", strings.Join(chain, " -> "))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(f.Groups)) {
+		if _, err := f.Expand(GroupPrefix + name); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(f.SessionPolicies)) {
+		p := f.SessionPolicies[name]
+		switch {
+		case p.Policy != "" && p.File != "":
+			return fmt.Errorf("session policy %q sets both policy and file", name)
+		case len(p.PolicyARNs) == 0 && p.Policy == "" && p.File == "":
+			return fmt.Errorf("session policy %q sets none of policy-arns, policy, or file", name)
+		}
+	}
+	for i, h := range f.Hooks.PreOpen {
+		if err := h.validate(); err != nil {
+			return fmt.Errorf("pre-open hook %d %w", i+1, err)
+		}
+	}
+	for i, h := range f.Hooks.PostOpen {
+		if err := h.validate(); err != nil {
+			return fmt.Errorf("post-open hook %d %w", i+1, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(f.SAMLProviders)) {
+		if err := f.SAMLProviders[name].validate(); err != nil {
+			return fmt.Errorf("SAML provider %q %w", name, err)
+		}
+	}
+	if err := f.Shortener.validate(); err != nil {
+		return err
+	}
+	if err := f.Telemetry.validate(); err != nil {
+		return err
+	}
+	if err := f.CacheServer.validate(); err != nil {
+		return err
+	}
+	return f.Audit.validate()
+}
+
+// Bookmark returns the bookmark called name, with an aliased profile replaced by the
+// profile it names.
+func (f *File) Bookmark(name string) (Bookmark, bool) {
+	b, ok := f.Bookmarks[name]
+	if !ok || b.Profile == "" {
+		return Bookmark{}, false
+	}
+	b.Profile = f.Alias(b.Profile)
+	return b, true
+}
+
+// FileLayer reads settings from the config file at path: first from the section of
+// profile, then from the top level. Only settings with a FileKey are read.
+func FileLayer(f *File, path, profile string) Layer {
+	return Layer{
+		Source: SourceFile,
+		Lookup: func(s Setting) (string, string, bool) {
+			if s.FileKey == "" || f == nil {
+				return "", "", false
+			}
+			if profile != "" {
+				if v, ok := f.Profiles[profile][s.FileKey]; ok && v != "" {
+					return v, fmt.Sprintf("%s (profile %s)", path, profile), true
+				}
+			}
+			if v, ok := f.Settings[s.FileKey]; ok && v != "" {
+				return v, path, true
+			}
+			return "", "", false
+		},
+	}
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleFile = `
+browser: firefox
+duration: 4h
+verbose: true
+profiles:
+  prod-admin:
+    duration: 1h
+    destination: cloudwatch
+aliases:
+  prod: prod-admin
+bookmarks:
+  prod-billing:
+    profile: prod
+    destination: billing/home
+  broken:
+    destination: s3
+warm: [prod, dev]
+session-policies:
+  s3-only:
+    file: policies/s3-only.json
+  deploy:
+    policy-arns: [arn:aws:iam::123456789012:policy/Deploy]
+hooks:
+  pre-open:
+    - tmux rename-window "$AWS_CONSOLE_PROFILE"
+  post-open:
+    - command: audit-webhook
+      on-failure: fail
+      timeout: 10s
+saml-providers:
+  corp:
+    driver: adfs
+    url: https://adfs.example.com
+    role-arn: arn:aws:iam::123456789012:role/Admin
+shortener:
+  url: https://go.example.com/api/links
+  headers:
+    Authorization: Bearer ${SHORTENER_TOKEN}
+telemetry:
+  endpoint: http://localhost:4318
+cache-server:
+  url: rediss://:${REDIS_PASSWORD}@cache.internal
+  key: ${AWS_CONSOLE_CACHE_KEY}
+  namespace: platform
+  ttl: 8h
+audit:
+  webhook: https://audit.example.com/aws-console
+  headers:
+    Authorization: Bearer ${AUDIT_TOKEN}
+  event-bus: security
+  region: us-east-1
+`
+
+func writeFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, sampleFile)
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if f.Settings["browser"] != "firefox" || f.Settings["verbose"] != "true" {
+		t.Fatalf("unexpected settings: %+v", f.Settings)
+	}
+	if f.Profiles["prod-admin"]["destination"] != "cloudwatch" {
+		t.Fatalf("unexpected profiles: %+v", f.Profiles)
+	}
+	if got := f.Alias("prod"); got != "prod-admin" {
+		t.Fatalf("expected alias to resolve, got %q", got)
+	}
+	if got := f.Alias("dev"); got != "dev" {
+		t.Fatalf("expected unknown names to resolve to themselves, got %q", got)
+	}
+	if b, ok := f.Bookmark("prod-billing"); !ok || b != (Bookmark{Profile: "prod-admin", Destination: "billing/home"}) {
+		t.Fatalf("expected the bookmark with its alias resolved, got %+v (%v)", b, ok)
+	}
+	for _, name := range []string{"broken", "missing"} {
+		if _, ok := f.Bookmark(name); ok {
+			t.Fatalf("expected no bookmark %q", name)
+		}
+	}
+	if got := strings.Join(f.Warm, ","); got != "prod,dev" {
+		t.Fatalf("unexpected warm profiles: %s", got)
+	}
+	if p := f.SessionPolicies["s3-only"]; p.File != "policies/s3-only.json" {
+		t.Fatalf("unexpected session policy: %+v", p)
+	}
+	if p := f.SAMLProviders["corp"]; p.Driver != "adfs" || p.RoleARN != "arn:aws:iam::123456789012:role/Admin" {
+		t.Fatalf("unexpected SAML provider %+v", p)
+	}
+	if p := f.SessionPolicies["deploy"]; strings.Join(p.PolicyARNs, ",") != "arn:aws:iam::123456789012:policy/Deploy" {
+		t.Fatalf("unexpected session policy: %+v", p)
+	}
+	if len(f.Hooks.PreOpen) != 1 || f.Hooks.PreOpen[0] != (Hook{Command: `tmux rename-window "$AWS_CONSOLE_PROFILE"`}) {
+		t.Fatalf("unexpected pre-open hooks: %+v", f.Hooks.PreOpen)
+	}
+	if h := f.Hooks.PreOpen[0]; h.TimeoutDuration() != DefaultHookTimeout {
+		t.Fatalf("expected the default timeout, got %s", h.TimeoutDuration())
+	}
+	if f.Shortener.URL != "https://go.example.com/api/links" || f.Shortener.Headers["Authorization"] != "Bearer ${SHORTENER_TOKEN}" {
+		t.Fatalf("unexpected shortener: %+v", f.Shortener)
+	}
+	if f.Telemetry.Endpoint != "http://localhost:4318" {
+		t.Fatalf("unexpected telemetry: %+v", f.Telemetry)
+	}
+	if c := f.CacheServer; c.URL != "rediss://:${REDIS_PASSWORD}@cache.internal" || c.Key != "${AWS_CONSOLE_CACHE_KEY}" || c.Namespace != "platform" || c.TTLDuration() != 8*time.Hour {
+		t.Fatalf("unexpected cache server: %+v", c)
+	}
+	if len(f.Hooks.PostOpen) != 1 || f.Hooks.PostOpen[0] != (Hook{Command: "audit-webhook", OnFailure: HookFail, Timeout: "10s"}) {
+		t.Fatalf("unexpected post-open hooks: %+v", f.Hooks.PostOpen)
+	}
+	if h := f.Hooks.PostOpen[0]; h.TimeoutDuration() != 10*time.Second {
+		t.Fatalf("expected a 10s timeout, got %s", h.TimeoutDuration())
+	}
+	if a := f.Audit; !a.Enabled() || a.Webhook != "https://audit.example.com/aws-console" || a.Headers["Authorization"] != "Bearer ${AUDIT_TOKEN}" || a.EventBus != "security" || a.Region != "us-east-1" {
+		t.Fatalf("unexpected audit settings: %+v", a)
+	}
+	if got := strings.Join(f.Keys(), ","); got != "browser,destination,duration,verbose" {
+		t.Fatalf("unexpected keys: %s", got)
+	}
+}
+
+func TestLoadFileMissingAndInvalid(t *testing.T) {
+	t.Parallel()
+
+	f, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || len(f.Settings) != 0 {
+		t.Fatalf("expected an empty file, got %+v, %v", f, err)
+	}
+
+	_, err = LoadFile(writeFile(t, "browser: [firefox"))
+	if err == nil || !strings.Contains(err.Error(), "failed to parse config file") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+
+	for contents, want := range map[string]string{
+		"aliases:\n  a: b\n  b: c\n  c: a\n":                                              "alias cycle: a -> b -> c -> a",
+		"groups:\n  x: [dev, \"@y\"]\n  y: [\"@x\"]\n":                                    "group cycle: @x -> @y -> @x",
+		"groups:\n  x: [\"@missing\"]\n":                                                  `unknown group "@missing"`,
+		"session-policies:\n  empty: {}\n":                                                `session policy "empty" sets none of policy-arns, policy, or file`,
+		"session-policies:\n  both:\n    policy: \"{}\"\n    file: p.json\n":              `session policy "both" sets both policy and file`,
+		"hooks:\n  pre-open:\n    - on-failure: fail\n":                                   "pre-open hook 1 sets no command",
+		"hooks:\n  post-open:\n    - true\n    - command: x\n      on-failure: abort\n":   `post-open hook 2 has unsupported on-failure "abort"`,
+		"hooks:\n  post-open:\n    - command: x\n      timeout: soon\n":                   `post-open hook 1 has invalid timeout "soon"`,
+		"saml-providers:\n  corp:\n    url: https://adfs.example.com\n":                   `SAML provider "corp" sets no driver`,
+		"saml-providers:\n  corp:\n    driver: keycloak\n":                                `SAML provider "corp" sets no url`,
+		"saml-providers:\n  krb:\n    driver: command\n":                                  `SAML provider "krb" sets no command`,
+		"shortener:\n  url: http://go.example.com\n":                                      `shortener url "http://go.example.com" is not an https URL`,
+		"shortener:\n  headers:\n    X-Token: t\n":                                        "shortener headers are set without a url",
+		"telemetry:\n  endpoint: localhost:4318\n":                                        `telemetry endpoint "localhost:4318" is not an http or https URL`,
+		"telemetry:\n  headers:\n    X-Token: t\n":                                        "telemetry headers are set without an endpoint",
+		"cache-server:\n  url: redis://cache.internal\n  key: k\n":                        `cache-server url "redis://cache.internal" is not encrypted: use rediss:// for servers beyond this host`,
+		"cache-server:\n  url: memcached://localhost\n  key: k\n":                         `cache-server url "memcached://localhost" is not a redis, rediss, http, or https URL`,
+		"cache-server:\n  url: https://cache.example.com\n":                               "cache-server key is not set",
+		"cache-server:\n  url: redis://127.0.0.1\n  key: k\n  headers:\n    X-Token: t\n": "cache-server headers are set for a Redis url",
+		"cache-server:\n  url: http://localhost:8080\n  key: k\n  ttl: forever\n":         `cache-server ttl "forever" is not a positive duration`,
+		"cache-server:\n  key: k\n":                                                       "cache-server is set without a url",
+		"audit:\n  webhook: audit.example.com\n":                                          `audit webhook "audit.example.com" is not an http or https URL`,
+		"audit:\n  headers:\n    X-Token: t\n":                                            "audit headers are set without a webhook",
+		"audit:\n  region: us-east-1\n":                                                   "audit profile and region are set without an event-bus",
+	} {
+		if _, err := LoadFile(writeFile(t, contents)); err == nil || !strings.Contains(err.Error(), "invalid config file") || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected an error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestFileExpand(t *testing.T) {
+	t.Parallel()
+
+	f := &File{
+		Aliases: map[string]string{"prod": "prod-admin", "p": "prod", "eu": "prod-eu-admin"},
+		Groups: map[string][]string{
+			"all-prod":   {"prod", "eu"},
+			"everything": {"dev", "@all-prod", "p"},
+			"empty":      {},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		want          []string
+		wantErrSubstr string
+	}{
+		{name: "dev", want: []string{"dev"}},
+		{name: "p", want: []string{"prod-admin"}},
+		{name: "@all-prod", want: []string{"prod-admin", "prod-eu-admin"}},
+		{name: "@everything", want: []string{"dev", "prod-admin", "prod-eu-admin"}},
+		{name: "@empty"},
+		{name: "@qa", wantErrSubstr: `unknown group "@qa"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := f.Expand(tc.name)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFileSetUnsetSave(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+	f := &File{}
+	f.Set("", "browser", "chrome")
+	f.Set("prod", "container", "prod")
+	if err := f.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded.Settings["browser"] != "chrome" || loaded.Profiles["prod"]["container"] != "prod" {
+		t.Fatalf("unexpected file after save: %+v", loaded)
+	}
+
+	if !loaded.Unset("prod", "container") || loaded.Unset("prod", "container") {
+		t.Fatal("expected Unset to report whether the key was set")
+	}
+	if _, ok := loaded.Profiles["prod"]; ok {
+		t.Fatal("expected the emptied profile section to be removed")
+	}
+}
+
+func TestFileLayer(t *testing.T) {
+	t.Parallel()
+
+	path := writeFile(t, sampleFile)
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	settings := []Setting{
+		{Key: "duration", Default: "12h", Env: []string{"AWS_CONSOLE_DURATION"}, FileKey: "duration"},
+		{Key: "browser", FileKey: "browser"},
+		{Key: "region", Env: []string{"AWS_REGION"}},
+	}
+	env := EnvLayer(testEnv(map[string]string{"AWS_CONSOLE_DURATION": "2h", "AWS_REGION": "eu-west-1"}))
+
+	values := Resolve(settings, FileLayer(f, path, "prod-admin"), env)
+	testCases := []struct {
+		key        string
+		wantValue  string
+		wantSource Source
+		wantOrigin string
+	}{
+		{key: "duration", wantValue: "1h", wantSource: SourceFile, wantOrigin: path + " (profile prod-admin)"},
+		{key: "browser", wantValue: "firefox", wantSource: SourceFile, wantOrigin: path},
+		{key: "region", wantValue: "eu-west-1", wantSource: SourceEnv, wantOrigin: "AWS_REGION"},
+	}
+	for _, tc := range testCases {
+		v, _ := Lookup(values, tc.key)
+		if v.Value != tc.wantValue || v.Source != tc.wantSource || v.Origin != tc.wantOrigin {
+			t.Fatalf("%s: got value=%q source=%q origin=%q", tc.key, v.Value, v.Source, v.Origin)
+		}
+	}
+}
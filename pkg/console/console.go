@@ -0,0 +1,619 @@
+// Package console generates federated AWS Management Console sign-in URLs for a
+// profile, so other Go programs can open the console the way the aws-console CLI does.
+//
+// A Client checks the profile's credentials, turns them into temporary credentials the
+// federation endpoint accepts, and exchanges those for a sign-in URL:
+//
+//	client := console.New()
+//	u, err := client.OpenConsole(ctx, console.Options{Profile: "dev", Destination: "/s3/home"})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(u)
+//
+// The region, STS endpoint, partition, issuer, HTTP timeout, and logger are taken from
+// ctx; see the With functions of package aws.
+package console
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/destination"
+)
+
+// Client generates console sign-in URLs. Service and Federation are required; the
+// hooks are optional.
+type Client struct {
+	Service    awslib.Service
+	Federation awslib.FederationURLBuilder
+
+	// MFAToken returns the current code of the MFA device serial when one is needed and
+	// Options.AssumeRole.MFAToken is empty. optional is true for a device found by
+	// listing the user's devices rather than one that was configured; returning "" for
+	// it requests credentials without MFA. A nil MFAToken skips optional devices and
+	// fails when a code is required.
+	MFAToken func(ctx context.Context, serial string, optional bool) (string, error)
+	// Progress, when set, receives short status messages, such as that a role is being
+	// assumed.
+	Progress func(msg string)
+	// Step, when set, is called as each AWS request starts, with one of "credentials",
+	// "assume-role", "session-token", "federation-token", or "federation", and returns a function called
+	// when it ends.
+	Step func(name string) func()
+	// Now, when set, replaces time.Now to tell how long credentials have left.
+	Now func() time.Time
+	// Policy, when set, is called with the profile's credentials before they are
+	// federated, and an error it returns refuses them.
+	Policy func(ctx context.Context, check PolicyCheck) error
+}
+
+// PolicyCheck describes credentials about to be federated, for Client.Policy.
+type PolicyCheck struct {
+	Profile  string
+	Identity awslib.Identity
+	// Provider is the SDK credential provider that supplied the credentials, and Source
+	// its kind.
+	Provider string
+	Source   awslib.CredentialSource
+	// LongLived is true for IAM user access keys without a session token.
+	LongLived bool
+	Strategy  Strategy
+}
+
+// New returns a Client that uses the AWS SDK's shared configuration and the public
+// federation endpoints.
+func New() *Client {
+	return &Client{Service: awslib.NewService(), Federation: awslib.NewFederationClient()}
+}
+
+// Options describes the console session to open.
+type Options struct {
+	// Profile is the shared config profile; empty uses the default credential chain.
+	Profile string
+	// Destination is a console path relative to the console root, such as "/s3/home";
+	// empty opens the console home page.
+	Destination string
+	// Regions, when set, requests one sign-in URL per region for Destination, all
+	// sharing one sign-in token.
+	Regions []string
+	// SessionDuration is how long the console session lasts; zero is the longest the
+	// credentials allow. A longer one is an error unless LimitDuration is set.
+	SessionDuration time.Duration
+	// LimitDuration shortens a SessionDuration that is too long for the credentials to
+	// their limit instead of failing.
+	LimitDuration bool
+	// AssumeRole, when its RoleARN is set, federates as that role instead of the profile.
+	// Its MFASerial and MFAToken also apply to session tokens for long-lived keys.
+	AssumeRole awslib.AssumeRoleInput
+	// MFASerial is the MFA device to use with long-lived keys when AssumeRole.MFASerial
+	// is empty, such as the profile's mfa_serial. When both are empty the user's MFA
+	// devices are listed.
+	MFASerial string
+	// Identity, when set, is the caller identity of Profile, already checked; otherwise
+	// it is looked up.
+	Identity *awslib.Identity
+	// Credentials, when set, are federated as they are, such as ones cached from an
+	// earlier Session, instead of being resolved for Profile.
+	Credentials *awslib.Credentials
+	// ChainedRole reports that the profile's own credentials come from role chaining,
+	// as awslib.ChainedRole tells, so they are federated as role-chained credentials.
+	ChainedRole bool
+	// ReadOnly limits the console session to read-only access with the
+	// awslib.ReadOnlySessionPolicy session policy; see Strategy.
+	ReadOnly bool
+	// SessionPolicy, when set, limits the console session to the permissions it allows,
+	// like ReadOnly. The two cannot be combined, since session policies widen each other.
+	SessionPolicy *awslib.SessionPolicy
+}
+
+// SessionDurationFor returns the console session length to request with credentials
+// of kind.
+func (o Options) SessionDurationFor(kind awslib.CredentialKind) (time.Duration, error) {
+	limit := kind.MaxSessionDuration()
+	switch {
+	case o.SessionDuration == 0:
+		return limit, nil
+	case o.SessionDuration <= limit:
+		return o.SessionDuration, nil
+	case o.LimitDuration:
+		return limit, nil
+	}
+	return 0, awslib.ValidateSessionDuration(kind, o.SessionDuration)
+}
+
+// LimitToCredentials returns how long a console session of duration started with creds
+// can last. Role sessions, such as the role credentials of an IAM Identity Center
+// permission set, cannot start one that outlasts them, and the federation endpoint may
+// reject a SessionDuration longer than the role allows, so the session is limited to
+// the whole minutes they have left.
+func LimitToCredentials(creds awslib.Credentials, duration time.Duration, now time.Time) time.Duration {
+	if !creds.Kind.RoleSession() || creds.Expires.IsZero() {
+		return duration
+	}
+	return min(duration, creds.Remaining(now).Truncate(time.Minute))
+}
+
+// Strategy is how a profile's credentials become the temporary credentials a console
+// session is federated with.
+type Strategy string
+
+const (
+	// StrategyDirect federates the profile's own temporary credentials.
+	StrategyDirect Strategy = "direct"
+	// StrategyAssumeRole assumes Options.AssumeRole with the profile's credentials.
+	StrategyAssumeRole Strategy = "assume-role"
+	// StrategySessionToken exchanges long-lived IAM user keys with GetSessionToken.
+	StrategySessionToken Strategy = "session-token"
+	// StrategyAssumeOwnRole assumes the role of the profile's role session again, with a
+	// session policy.
+	StrategyAssumeOwnRole Strategy = "assume-own-role"
+	// StrategyFederationToken exchanges long-lived IAM user keys with GetFederationToken,
+	// with a session policy.
+	StrategyFederationToken Strategy = "federation-token"
+)
+
+// Strategy returns how creds, the profile's credentials, are federated. It follows the
+// provider that supplied them: role credentials, such as those of an EC2 instance, an
+// ECS task, or IAM Identity Center, are federated directly, since STS does not allow
+// GetSessionToken with them. Credentials from other providers, such as environment
+// variables in Lambda, are federated directly when they are temporary.
+//
+// Read-only sessions, and sessions limited by a session policy, need credentials a
+// session policy can be attached to: temporary credentials assume their own role again,
+// and IAM user keys request a federation token.
+func (o Options) Strategy(creds awslib.Credentials) (Strategy, error) {
+	if o.ReadOnly && o.SessionPolicy != nil {
+		return "", errors.New("a read-only session cannot also have a session policy")
+	}
+	limited := o.ReadOnly || o.SessionPolicy != nil
+	source := awslib.CredentialSourceOf(creds.Source)
+	switch {
+	case o.AssumeRole.RoleARN != "":
+		return StrategyAssumeRole, nil
+	case creds.SessionToken != "" && limited:
+		return StrategyAssumeOwnRole, nil
+	case creds.SessionToken != "":
+		return StrategyDirect, nil
+	case source.RoleSession():
+		return "", fmt.Errorf("%s returned role credentials without a session token", creds.Source)
+	case limited:
+		return StrategyFederationToken, nil
+	}
+	return StrategySessionToken, nil
+}
+
+// Plan is how Credentials federates the profile's credentials, as Options.Plan tells
+// without requesting any.
+type Plan struct {
+	Strategy Strategy
+	// Kind is the kind of the credentials federated.
+	Kind awslib.CredentialKind
+	// SessionPolicy, when set, limits the credentials federated.
+	SessionPolicy *awslib.SessionPolicy
+	// Identity is the caller identity of the console session.
+	Identity awslib.Identity
+}
+
+// Plan returns how creds, the profile's credentials, and identity, their caller
+// identity, are federated: the Strategy, the kind of credentials it yields, and the
+// session policy that limits them. It fails where Credentials would before requesting
+// anything, such as for a session whose role cannot assume itself.
+func (o Options) Plan(ctx context.Context, creds awslib.Credentials, identity awslib.Identity) (Plan, error) {
+	strategy, err := o.Strategy(creds)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan := Plan{Strategy: strategy, Identity: identity}
+	switch {
+	case o.ReadOnly:
+		plan.SessionPolicy = awslib.ReadOnlySessionPolicy(cmp.Or(awslib.PartitionFromContext(ctx), identity.Partition))
+	case o.SessionPolicy != nil:
+		plan.SessionPolicy = o.SessionPolicy
+	}
+
+	switch strategy {
+	case StrategyAssumeRole:
+		plan.Kind = assumedRoleKind(creds)
+		// The console session is the role's, in the role's account, not the profile's.
+		plan.Identity = AssumedRoleIdentity(identity, o.AssumeRole)
+	case StrategyAssumeOwnRole:
+		if err := CheckOwnRole(identity); err != nil {
+			return Plan{}, err
+		}
+		plan.Kind = assumedRoleKind(creds)
+	case StrategyFederationToken:
+		plan.Kind = awslib.CredentialKindFederationToken
+	case StrategySessionToken:
+		plan.Kind = awslib.CredentialKindSessionToken
+	default:
+		plan.Kind = o.DirectKind(identity)
+	}
+	return plan, nil
+}
+
+// assumedRoleKind returns the kind of the role credentials that base assumes.
+// Assuming a role with credentials that are themselves temporary is role chaining.
+func assumedRoleKind(base awslib.Credentials) awslib.CredentialKind {
+	if base.SessionToken != "" {
+		return awslib.CredentialKindRoleChained
+	}
+	return awslib.CredentialKindRole
+}
+
+// Session is the temporary credentials a console session is federated with.
+type Session struct {
+	Credentials awslib.Credentials
+	// Identity is the caller identity of the profile or, when a role was assumed, of
+	// the role session.
+	Identity awslib.Identity
+}
+
+// URL is a console sign-in URL and the session behind it.
+type URL struct {
+	Session
+	// SignInURLs holds the sign-in URL, or one per Options.Regions in order. They are
+	// valid for 15 minutes.
+	SignInURLs []string
+	// SessionDuration is how long the console session lasts once signed in.
+	SessionDuration time.Duration
+}
+
+// String returns the first sign-in URL.
+func (u URL) String() string {
+	if len(u.SignInURLs) == 0 {
+		return ""
+	}
+	return u.SignInURLs[0]
+}
+
+// OpenConsole returns a sign-in URL for the console session described by opts. It
+// requests a sign-in token but opens nothing.
+func (c *Client) OpenConsole(ctx context.Context, opts Options) (URL, error) {
+	var session Session
+	var err error
+	if opts.Credentials != nil {
+		if session.Identity, err = c.identity(ctx, opts); err != nil {
+			return URL{}, err
+		}
+		session.Credentials = *opts.Credentials
+	} else if session, err = c.Credentials(ctx, opts); err != nil {
+		return URL{}, err
+	}
+
+	// GovCloud and China identities must federate through their own partition's endpoints.
+	if awslib.PartitionFromContext(ctx) == "" && session.Identity.Partition != "" {
+		ctx = awslib.WithPartition(ctx, session.Identity.Partition)
+	}
+
+	duration, err := opts.SessionDurationFor(session.Credentials.Kind)
+	if err != nil {
+		return URL{}, err
+	}
+	if duration < opts.SessionDuration {
+		awslib.LoggerFromContext(ctx).Info(fmt.Sprintf("Limiting the session to %s for %s credentials", duration, session.Credentials.Kind))
+	}
+	requested := duration
+	if limited := LimitToCredentials(session.Credentials, duration, c.now()); limited < duration {
+		awslib.LoggerFromContext(ctx).Info(fmt.Sprintf("Limiting the session to %s, when the role credentials expire", limited))
+		duration = limited
+		// The federation endpoint refuses a shorter SessionDuration; without one the
+		// session ends with the credentials anyway.
+		requested = 0
+		if limited >= awslib.MinSessionDuration {
+			requested = limited
+		}
+	}
+
+	awslib.LoggerFromContext(ctx).Info(fmt.Sprintf("Requesting a console sign-in token for a %s session", duration))
+	done := c.step("federation")
+	urls, err := c.signInURLs(ctx, session.Credentials, int32(requested/time.Second), opts)
+	done()
+	if err != nil {
+		return URL{}, fmt.Errorf("failed to build console URL: %w", err)
+	}
+	return URL{Session: session, SignInURLs: urls, SessionDuration: duration}, nil
+}
+
+// Credentials returns the temporary credentials OpenConsole federates for opts: the
+// profile's own, those of opts.AssumeRole, or a session token for long-lived keys.
+// opts.Credentials is ignored.
+func (c *Client) Credentials(ctx context.Context, opts Options) (Session, error) {
+	identity, err := c.identity(ctx, opts)
+	if err != nil {
+		return Session{}, err
+	}
+
+	done := c.step("credentials")
+	creds, err := c.Service.RetrieveCredentials(ctx, opts.Profile)
+	done()
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+
+	plan, err := opts.Plan(ctx, creds, identity)
+	if err != nil {
+		return Session{}, err
+	}
+	if c.Policy != nil {
+		check := PolicyCheck{
+			Profile:   opts.Profile,
+			Identity:  identity,
+			Provider:  creds.Source,
+			Source:    awslib.CredentialSourceOf(creds.Source),
+			LongLived: creds.SessionToken == "",
+			Strategy:  plan.Strategy,
+		}
+		if err := c.Policy(ctx, check); err != nil {
+			return Session{}, err
+		}
+	}
+	awslib.LoggerFromContext(ctx).Info(fmt.Sprintf("Federating credentials from %s with the %s strategy", cmp.Or(creds.Source, "an unknown provider"), plan.Strategy))
+	if plan.SessionPolicy != nil {
+		opts.AssumeRole.SessionPolicy = plan.SessionPolicy
+	}
+
+	switch plan.Strategy {
+	case StrategyAssumeRole:
+		done = c.step("assume-role")
+		creds, err = c.assumeRole(ctx, creds, opts)
+		done()
+	case StrategySessionToken:
+		// Long-lived IAM user keys cannot federate; exchange them for temporary credentials.
+		c.progress("No session token found, requesting temporary credentials...")
+		done = c.step("session-token")
+		creds, err = c.sessionToken(ctx, opts)
+		done()
+	case StrategyAssumeOwnRole:
+		done = c.step("assume-role")
+		creds, err = c.assumeOwnRole(ctx, creds, identity, opts)
+		done()
+	case StrategyFederationToken:
+		c.progress("Requesting read-only temporary credentials...")
+		done = c.step("federation-token")
+		creds, err = c.federationToken(ctx, opts)
+		done()
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	creds.Kind = plan.Kind
+	return Session{Credentials: creds, Identity: plan.Identity}, nil
+}
+
+// DirectKind returns the kind of the profile's own credentials, those of identity,
+// which StrategyDirect federates.
+func (o Options) DirectKind(identity awslib.Identity) awslib.CredentialKind {
+	kind := awslib.CredentialKindFromARN(identity.Arn)
+	if kind == awslib.CredentialKindRole && o.ChainedRole {
+		return awslib.CredentialKindRoleChained
+	}
+	return kind
+}
+
+// AssumedRoleIdentity returns the identity of the role session that assuming role
+// with the credentials of identity starts.
+func AssumedRoleIdentity(identity awslib.Identity, role awslib.AssumeRoleInput) awslib.Identity {
+	partition, account, name, err := awslib.ParseRoleARN(role.RoleARN)
+	if err != nil {
+		return identity
+	}
+	// A role's path is not part of the ARN of its sessions.
+	name = name[strings.LastIndex(name, "/")+1:]
+	session := cmp.Or(role.SessionName, awslib.DefaultRoleSessionName)
+	identity.Arn = fmt.Sprintf("arn:%s:sts::%s:assumed-role/%s/%s", partition, account, name, session)
+	identity.Account = account
+	return identity
+}
+
+// identity returns opts.Identity, or looks up the caller identity of opts.Profile.
+func (c *Client) identity(ctx context.Context, opts Options) (awslib.Identity, error) {
+	if opts.Identity != nil {
+		return *opts.Identity, nil
+	}
+	identity, err := c.Service.GetCallerIdentity(ctx, opts.Profile)
+	if err != nil {
+		return awslib.Identity{}, fmt.Errorf("failed to check credentials: %w", err)
+	}
+	return identity, nil
+}
+
+// assumeRole exchanges base, the profile's credentials, for the role in opts.
+func (c *Client) assumeRole(ctx context.Context, base awslib.Credentials, opts Options) (awslib.Credentials, error) {
+	input := opts.AssumeRole
+	kind := assumedRoleKind(base)
+	duration, err := opts.SessionDurationFor(kind)
+	if err != nil {
+		return awslib.Credentials{}, err
+	}
+	input.DurationSeconds = kind.DurationSeconds(duration)
+
+	if input.MFASerial != "" && input.MFAToken == "" {
+		if input.MFAToken, err = c.mfaToken(ctx, input.MFASerial, false); err != nil {
+			return awslib.Credentials{}, err
+		}
+	}
+
+	c.progress(fmt.Sprintf("Assuming role %s...", input.RoleARN))
+	creds, err := c.Service.AssumeRole(ctx, opts.Profile, input)
+	if err != nil {
+		return awslib.Credentials{}, fmt.Errorf("failed to assume role %s: %w", input.RoleARN, err)
+	}
+	creds.Kind = kind
+	return creds, nil
+}
+
+// ssoRolePrefix starts the name of the role IAM Identity Center creates for each
+// permission set in an account.
+const ssoRolePrefix = "AWSReservedSSO_"
+
+// CheckOwnRole returns an error unless identity is a role session, whose role
+// StrategyAssumeOwnRole can assume again. Temporary credentials of IAM users cannot be
+// limited with a session policy, and neither can IAM Identity Center sessions: the
+// AWSReservedSSO_ role of a permission set trusts only Identity Center's SAML provider,
+// so it can never assume itself.
+func CheckOwnRole(identity awslib.Identity) error {
+	if awslib.CredentialKindFromARN(identity.Arn) != awslib.CredentialKindRole {
+		return fmt.Errorf("cannot limit the temporary credentials of %s with a session policy; use a role or long-lived IAM user keys", identity.Arn)
+	}
+	if strings.HasPrefix(identity.RoleName(), ssoRolePrefix) {
+		return fmt.Errorf("cannot limit the IAM Identity Center session of %s with a session policy, since its permission set's role cannot assume itself; "+
+			"sign in with a read-only permission set instead, or pass --role-arn with a role the permission set may assume", identity.Arn)
+	}
+	return nil
+}
+
+// assumeOwnRole assumes the role of identity, the profile's role session, again with
+// base, so that the session policy in opts applies to the new session. Options.Plan has
+// checked that the role can.
+func (c *Client) assumeOwnRole(ctx context.Context, base awslib.Credentials, identity awslib.Identity, opts Options) (awslib.Credentials, error) {
+	roleARN, err := c.Service.GetRoleARN(ctx, opts.Profile, identity.Arn)
+	if err != nil {
+		return awslib.Credentials{}, err
+	}
+	opts.AssumeRole.RoleARN = roleARN
+	creds, err := c.assumeRole(ctx, base, opts)
+	if err != nil {
+		return awslib.Credentials{}, fmt.Errorf("%w (the role's trust policy must allow it to assume itself)", err)
+	}
+	return creds, nil
+}
+
+// federationToken exchanges the profile's long-lived IAM user keys for temporary
+// credentials of a federated user limited by the session policy in opts. Federation
+// tokens cannot carry MFA.
+func (c *Client) federationToken(ctx context.Context, opts Options) (awslib.Credentials, error) {
+	duration, err := opts.SessionDurationFor(awslib.CredentialKindFederationToken)
+	if err != nil {
+		return awslib.Credentials{}, err
+	}
+	creds, err := c.Service.GetFederationToken(ctx, opts.Profile, awslib.FederationTokenInput{
+		DurationSeconds: awslib.CredentialKindFederationToken.DurationSeconds(duration),
+		SessionPolicy:   opts.AssumeRole.SessionPolicy,
+	})
+	if err != nil {
+		return awslib.Credentials{}, fmt.Errorf("failed to get a federation token: %w", err)
+	}
+	creds.Kind = awslib.CredentialKindFederationToken
+	return creds, nil
+}
+
+// sessionToken exchanges the profile's long-lived IAM user keys for temporary
+// credentials, with an MFA code when the user has a device so that the session
+// satisfies policies that require MFA.
+func (c *Client) sessionToken(ctx context.Context, opts Options) (awslib.Credentials, error) {
+	duration, err := opts.SessionDurationFor(awslib.CredentialKindSessionToken)
+	if err != nil {
+		return awslib.Credentials{}, err
+	}
+	input := awslib.SessionTokenInput{
+		DurationSeconds: awslib.CredentialKindSessionToken.DurationSeconds(duration),
+		MFASerial:       opts.AssumeRole.MFASerial,
+		MFAToken:        opts.AssumeRole.MFAToken,
+	}
+
+	discovered := false
+	if input.MFASerial == "" {
+		input.MFASerial = opts.MFASerial
+	}
+	if input.MFASerial == "" {
+		input.MFASerial = c.discoverMFASerial(ctx, opts.Profile)
+		discovered = input.MFASerial != ""
+	}
+
+	if input.MFASerial != "" && input.MFAToken == "" {
+		if input.MFAToken, err = c.mfaToken(ctx, input.MFASerial, discovered); err != nil {
+			return awslib.Credentials{}, err
+		}
+		if input.MFAToken == "" {
+			awslib.LoggerFromContext(ctx).Info(fmt.Sprintf("Requesting a session token without MFA device %s", input.MFASerial))
+			input.MFASerial = ""
+		}
+	}
+
+	creds, err := c.Service.GetSessionToken(ctx, opts.Profile, input)
+	if err != nil {
+		return awslib.Credentials{}, fmt.Errorf("failed to get temporary credentials: %w", err)
+	}
+	creds.Kind = awslib.CredentialKindSessionToken
+	return creds, nil
+}
+
+// discoverMFASerial asks IAM for the MFA devices of the profile's user. Users without a
+// device, or who may not list their devices, get a session without MFA.
+func (c *Client) discoverMFASerial(ctx context.Context, profile string) string {
+	log := awslib.LoggerFromContext(ctx)
+	serials, err := c.Service.ListMFADevices(ctx, profile)
+	if err != nil {
+		log.Info(fmt.Sprintf("Skipping MFA device discovery: %v", err))
+		return ""
+	}
+	if len(serials) == 0 {
+		return ""
+	}
+	if len(serials) > 1 {
+		log.Info(fmt.Sprintf("Using MFA device %s of %d; set mfa_serial or --mfa-serial to choose another", serials[0], len(serials)))
+	}
+	return serials[0]
+}
+
+// mfaToken asks the MFAToken hook for a code for serial.
+func (c *Client) mfaToken(ctx context.Context, serial string, optional bool) (string, error) {
+	if c.MFAToken == nil {
+		if optional {
+			return "", nil
+		}
+		return "", fmt.Errorf("an MFA code is required for MFA device %s", serial)
+	}
+	token, err := c.MFAToken(ctx, serial, optional)
+	if err != nil {
+		return "", err
+	}
+	if token == "" && !optional {
+		return "", fmt.Errorf("an MFA code is required for MFA device %s", serial)
+	}
+	return token, nil
+}
+
+// signInURLs returns one sign-in URL per requested region, sharing a single sign-in
+// token, or a single URL when no regions were requested.
+func (c *Client) signInURLs(ctx context.Context, creds awslib.Credentials, durationSeconds int32, opts Options) ([]string, error) {
+	if len(opts.Regions) == 0 {
+		signInURL, err := c.Federation.BuildConsoleURL(ctx, creds, durationSeconds, opts.Destination)
+		if err != nil {
+			return nil, err
+		}
+		return []string{signInURL}, nil
+	}
+
+	destinations := make([]string, 0, len(opts.Regions))
+	for _, region := range opts.Regions {
+		destinations = append(destinations, destination.WithRegion(opts.Destination, region))
+	}
+	return c.Federation.BuildConsoleURLs(ctx, creds, durationSeconds, destinations)
+}
+
+func (c *Client) progress(msg string) {
+	if c.Progress != nil {
+		c.Progress(msg)
+	}
+}
+
+func (c *Client) step(name string) func() {
+	if c.Step == nil {
+		return func() {}
+	}
+	return c.Step(name)
+}
+
+func (c *Client) now() time.Time {
+	if c.Now == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
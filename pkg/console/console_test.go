@@ -0,0 +1,790 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+const (
+	testRoleARN   = "arn:aws:iam::210987654321:role/Admin"
+	testMFASerial = "arn:aws:iam::123456789012:mfa/alice"
+)
+
+var (
+	roleIdentity = awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Dev/alice", Account: "123456789012", Partition: "aws"}
+	userIdentity = awslib.Identity{Arn: "arn:aws:iam::123456789012:user/alice", Account: "123456789012", Partition: "aws"}
+	roleCreds    = awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}
+	keys         = awslib.Credentials{AccessKeyID: "AKIA", SecretAccessKey: "secret"}
+)
+
+func TestClientOpenConsole(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		opts             Options
+		identity         awslib.Identity
+		creds            awslib.Credentials
+		devices          []string
+		mfaToken         func(ctx context.Context, serial string, optional bool) (string, error)
+		wantKind         awslib.CredentialKind
+		wantDuration     time.Duration
+		wantAccount      string
+		wantAssumeRole   awslib.AssumeRoleInput
+		wantSessionToken awslib.SessionTokenInput
+		wantURLs         []string
+		wantErrSubstr    string
+	}{
+		{
+			name:         "role credentials",
+			opts:         Options{Profile: "dev", Destination: "/s3/home"},
+			identity:     roleIdentity,
+			creds:        roleCreds,
+			wantKind:     awslib.CredentialKindRole,
+			wantDuration: 12 * time.Hour,
+			wantAccount:  "123456789012",
+			wantURLs:     []string{"https://signin.example.com/?d=/s3/home"},
+		},
+		{
+			name:             "long-lived keys with a configured MFA device",
+			opts:             Options{Profile: "dev", SessionDuration: time.Hour, MFASerial: testMFASerial},
+			identity:         userIdentity,
+			creds:            keys,
+			mfaToken:         func(ctx context.Context, serial string, optional bool) (string, error) { return "123456", nil },
+			wantKind:         awslib.CredentialKindSessionToken,
+			wantDuration:     time.Hour,
+			wantAccount:      "123456789012",
+			wantSessionToken: awslib.SessionTokenInput{DurationSeconds: 3600, MFASerial: testMFASerial, MFAToken: "123456"},
+			wantURLs:         []string{"https://signin.example.com/?d="},
+		},
+		{
+			name:         "role profile sourced from another role",
+			opts:         Options{Profile: "chained", ChainedRole: true},
+			identity:     roleIdentity,
+			creds:        awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Source: "AssumeRoleProvider"},
+			wantKind:     awslib.CredentialKindRoleChained,
+			wantDuration: time.Hour,
+			wantAccount:  "123456789012",
+			wantURLs:     []string{"https://signin.example.com/?d="},
+		},
+		{
+			name:         "EC2 instance role",
+			opts:         Options{Profile: "dev"},
+			identity:     roleIdentity,
+			creds:        awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Source: "EC2RoleProvider"},
+			wantKind:     awslib.CredentialKindRole,
+			wantDuration: 12 * time.Hour,
+			wantAccount:  "123456789012",
+			wantURLs:     []string{"https://signin.example.com/?d="},
+		},
+		{
+			name:          "ECS task role without a session token",
+			opts:          Options{Profile: "dev"},
+			identity:      roleIdentity,
+			creds:         awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", Source: "CredentialsEndpointProvider"},
+			wantErrSubstr: "CredentialsEndpointProvider returned role credentials without a session token",
+		},
+		{
+			name:          "configured MFA device without a hook",
+			opts:          Options{Profile: "dev", MFASerial: testMFASerial},
+			identity:      userIdentity,
+			creds:         keys,
+			wantErrSubstr: "an MFA code is required for MFA device " + testMFASerial,
+		},
+		{
+			name:             "discovered MFA device without a hook",
+			opts:             Options{Profile: "dev"},
+			identity:         userIdentity,
+			creds:            keys,
+			devices:          []string{testMFASerial},
+			wantKind:         awslib.CredentialKindSessionToken,
+			wantDuration:     12 * time.Hour,
+			wantAccount:      "123456789012",
+			wantSessionToken: awslib.SessionTokenInput{DurationSeconds: 43200},
+			wantURLs:         []string{"https://signin.example.com/?d="},
+		},
+		{
+			name:           "role chaining limited to an hour",
+			opts:           Options{Profile: "dev", AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}, SessionDuration: 4 * time.Hour, LimitDuration: true},
+			identity:       roleIdentity,
+			creds:          roleCreds,
+			wantKind:       awslib.CredentialKindRoleChained,
+			wantDuration:   time.Hour,
+			wantAccount:    "210987654321",
+			wantAssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN, DurationSeconds: 3600},
+			wantURLs:       []string{"https://signin.example.com/?d="},
+		},
+		{
+			name:          "role chaining with too long a duration",
+			opts:          Options{Profile: "dev", AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}, SessionDuration: 4 * time.Hour},
+			identity:      roleIdentity,
+			creds:         roleCreds,
+			wantErrSubstr: "role chaining",
+		},
+		{
+			name:           "assume role with session-token credentials",
+			opts:           Options{Profile: "dev", AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+			identity:       userIdentity,
+			creds:          roleCreds,
+			wantKind:       awslib.CredentialKindRoleChained,
+			wantDuration:   time.Hour,
+			wantAccount:    "210987654321",
+			wantAssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN, DurationSeconds: 3600},
+			wantURLs:       []string{"https://signin.example.com/?d="},
+		},
+		{
+			name:           "assume role with long-lived keys",
+			opts:           Options{Profile: "dev", AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN, MFASerial: testMFASerial}},
+			identity:       userIdentity,
+			creds:          keys,
+			mfaToken:       func(ctx context.Context, serial string, optional bool) (string, error) { return "654321", nil },
+			wantKind:       awslib.CredentialKindRole,
+			wantDuration:   12 * time.Hour,
+			wantAccount:    "210987654321",
+			wantAssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN, MFASerial: testMFASerial, MFAToken: "654321", DurationSeconds: 43200},
+			wantURLs:       []string{"https://signin.example.com/?d="},
+		},
+		{
+			name:         "regions",
+			opts:         Options{Profile: "dev", Destination: "/ec2/home", Regions: []string{"us-east-1", "eu-west-1"}},
+			identity:     roleIdentity,
+			creds:        roleCreds,
+			wantKind:     awslib.CredentialKindRole,
+			wantDuration: 12 * time.Hour,
+			wantAccount:  "123456789012",
+			wantURLs: []string{
+				"https://signin.example.com/?d=/ec2/home?region=us-east-1",
+				"https://signin.example.com/?d=/ec2/home?region=eu-west-1",
+			},
+		},
+		{
+			name:     "MFA prompt fails",
+			opts:     Options{Profile: "dev", AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN, MFASerial: testMFASerial}},
+			identity: roleIdentity,
+			creds:    roleCreds,
+			mfaToken: func(ctx context.Context, serial string, optional bool) (string, error) {
+				return "", errors.New("no terminal")
+			},
+			wantErrSubstr: "no terminal",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotAssumeRole awslib.AssumeRoleInput
+			var gotSessionToken awslib.SessionTokenInput
+			var gotKind awslib.CredentialKind
+			var gotPartition string
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return tc.identity, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return tc.creds, nil
+				},
+				ListMFADevicesFunc: func(ctx context.Context, profile string) ([]string, error) {
+					return tc.devices, nil
+				},
+				GetSessionTokenFunc: func(ctx context.Context, profile string, input awslib.SessionTokenInput) (awslib.Credentials, error) {
+					gotSessionToken = input
+					return roleCreds, nil
+				},
+				AssumeRoleFunc: func(ctx context.Context, profile string, input awslib.AssumeRoleInput) (awslib.Credentials, error) {
+					gotAssumeRole = input
+					return roleCreds, nil
+				},
+			}
+			federation := &mocks.FederationBuilder{
+				BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+					gotKind, gotPartition = creds.Kind, awslib.PartitionFromContext(ctx)
+					return "https://signin.example.com/?d=" + destination, nil
+				},
+				BuildConsoleURLsFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destinations []string) ([]string, error) {
+					gotKind, gotPartition = creds.Kind, awslib.PartitionFromContext(ctx)
+					urls := make([]string, 0, len(destinations))
+					for _, d := range destinations {
+						urls = append(urls, "https://signin.example.com/?d="+d)
+					}
+					return urls, nil
+				},
+			}
+			var steps []string
+			client := &Client{
+				Service:    service,
+				Federation: federation,
+				MFAToken:   tc.mfaToken,
+				Step: func(name string) func() {
+					steps = append(steps, name)
+					return func() {}
+				},
+			}
+
+			got, err := client.OpenConsole(context.Background(), tc.opts)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				if federation.BuildConsoleURLCalls.Load() != 0 || service.AssumeRoleCalls.Load() != 0 || service.GetSessionTokenCalls.Load() != 0 {
+					t.Fatal("expected the error before requesting credentials or a sign-in token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if strings.Join(got.SignInURLs, ",") != strings.Join(tc.wantURLs, ",") || got.String() != tc.wantURLs[0] {
+				t.Fatalf("expected URLs %v, got %v", tc.wantURLs, got.SignInURLs)
+			}
+			if got.SessionDuration != tc.wantDuration {
+				t.Fatalf("expected a %s session, got %s", tc.wantDuration, got.SessionDuration)
+			}
+			if gotKind != tc.wantKind || got.Credentials.Kind != tc.wantKind {
+				t.Fatalf("expected %q credentials to be federated, got %q", tc.wantKind, gotKind)
+			}
+			if got.Identity.Account != tc.wantAccount {
+				t.Fatalf("expected account %s, got %s", tc.wantAccount, got.Identity.Account)
+			}
+			if gotPartition != "aws" {
+				t.Fatalf("expected the identity's partition, got %q", gotPartition)
+			}
+			if !reflect.DeepEqual(gotAssumeRole, tc.wantAssumeRole) {
+				t.Fatalf("expected AssumeRole input %+v, got %+v", tc.wantAssumeRole, gotAssumeRole)
+			}
+			if gotSessionToken != tc.wantSessionToken {
+				t.Fatalf("expected GetSessionToken input %+v, got %+v", tc.wantSessionToken, gotSessionToken)
+			}
+			if steps[0] != "credentials" || steps[len(steps)-1] != "federation" {
+				t.Fatalf("unexpected steps %v", steps)
+			}
+		})
+	}
+}
+
+func TestClientOpenConsoleWithCredentials(t *testing.T) {
+	t.Parallel()
+
+	service := &mocks.Service{}
+	client := &Client{
+		Service: service,
+		Federation: &mocks.FederationBuilder{
+			BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+				if creds.AccessKeyID != "ASIACACHED" || durationSeconds != 3600 {
+					t.Errorf("unexpected federation request with %+v for %ds", creds, durationSeconds)
+				}
+				return "https://signin.example.com/", nil
+			},
+		},
+	}
+
+	cached := awslib.Credentials{AccessKeyID: "ASIACACHED", SecretAccessKey: "secret", SessionToken: "token", Kind: awslib.CredentialKindRoleChained}
+	got, err := client.OpenConsole(context.Background(), Options{
+		Identity:      &roleIdentity,
+		Credentials:   &cached,
+		LimitDuration: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.String() != "https://signin.example.com/" || got.Identity != roleIdentity {
+		t.Fatalf("unexpected result %+v", got)
+	}
+	if service.GetCallerIdentityCalls.Load() != 0 || service.RetrieveCredentialsCalls.Load() != 0 {
+		t.Fatal("expected the given identity and credentials to be used")
+	}
+}
+
+func TestClientOpenConsoleLimitsRoleSessions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		kind          awslib.CredentialKind
+		expires       time.Time
+		wantDuration  time.Duration
+		wantRequested int32
+	}{
+		{name: "role credentials that outlast the session", kind: awslib.CredentialKindRole, expires: now.Add(13 * time.Hour), wantDuration: 12 * time.Hour, wantRequested: 43200},
+		{name: "permission set session", kind: awslib.CredentialKindRole, expires: now.Add(8*time.Hour - 30*time.Second), wantDuration: 7*time.Hour + 59*time.Minute, wantRequested: 28740},
+		{name: "under the federation minimum", kind: awslib.CredentialKindRole, expires: now.Add(10 * time.Minute), wantDuration: 10 * time.Minute},
+		{name: "session token", kind: awslib.CredentialKindSessionToken, expires: now.Add(time.Hour), wantDuration: 12 * time.Hour, wantRequested: 43200},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotRequested int32
+			client := &Client{
+				Service: &mocks.Service{},
+				Federation: &mocks.FederationBuilder{
+					BuildConsoleURLFunc: func(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+						gotRequested = durationSeconds
+						return "https://signin.example.com/", nil
+					},
+				},
+				Now: func() time.Time { return now },
+			}
+			creds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Kind: tc.kind, Expires: tc.expires}
+			got, err := client.OpenConsole(context.Background(), Options{Identity: &roleIdentity, Credentials: &creds})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.SessionDuration != tc.wantDuration || gotRequested != tc.wantRequested {
+				t.Fatalf("got a %s session requested for %ds, want %s for %ds", got.SessionDuration, gotRequested, tc.wantDuration, tc.wantRequested)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsReadOnly(t *testing.T) {
+	t.Parallel()
+
+	readOnly := "arn:aws:iam::aws:policy/ReadOnlyAccess"
+	deploy := "arn:aws:iam::123456789012:policy/Deploy"
+	ownRole := "arn:aws:iam::123456789012:role/teams/Dev"
+	testCases := []struct {
+		name           string
+		opts           Options
+		identity       awslib.Identity
+		creds          awslib.Credentials
+		assumeErr      error
+		wantKind       awslib.CredentialKind
+		wantRoleARN    string
+		wantFederation bool
+		wantPolicyARN  string
+		wantAccount    string
+		wantErrSubstr  string
+	}{
+		{
+			name:          "role session assumes its own role",
+			opts:          Options{Profile: "dev", ReadOnly: true},
+			identity:      roleIdentity,
+			creds:         roleCreds,
+			wantKind:      awslib.CredentialKindRoleChained,
+			wantRoleARN:   ownRole,
+			wantPolicyARN: readOnly,
+			wantAccount:   "123456789012",
+		},
+		{
+			name:           "IAM user keys request a federation token",
+			opts:           Options{Profile: "dev", ReadOnly: true},
+			identity:       userIdentity,
+			creds:          keys,
+			wantKind:       awslib.CredentialKindFederationToken,
+			wantFederation: true,
+			wantPolicyARN:  readOnly,
+			wantAccount:    "123456789012",
+		},
+		{
+			name:          "assumed role gets the session policy",
+			opts:          Options{Profile: "dev", ReadOnly: true, AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+			identity:      userIdentity,
+			creds:         keys,
+			wantKind:      awslib.CredentialKindRole,
+			wantRoleARN:   testRoleARN,
+			wantPolicyARN: readOnly,
+			wantAccount:   "210987654321",
+		},
+		{
+			name:          "GovCloud policy",
+			opts:          Options{Profile: "gov", ReadOnly: true},
+			identity:      awslib.Identity{Arn: "arn:aws-us-gov:sts::123456789012:assumed-role/Dev/alice", Account: "123456789012", Partition: "aws-us-gov"},
+			creds:         roleCreds,
+			wantKind:      awslib.CredentialKindRoleChained,
+			wantRoleARN:   ownRole,
+			wantPolicyARN: "arn:aws-us-gov:iam::aws:policy/ReadOnlyAccess",
+			wantAccount:   "123456789012",
+		},
+		{
+			name:          "session policy",
+			opts:          Options{Profile: "dev", SessionPolicy: &awslib.SessionPolicy{PolicyARNs: []string{deploy}}},
+			identity:      roleIdentity,
+			creds:         roleCreds,
+			wantKind:      awslib.CredentialKindRoleChained,
+			wantRoleARN:   ownRole,
+			wantPolicyARN: deploy,
+			wantAccount:   "123456789012",
+		},
+		{
+			name:           "session policy with IAM user keys",
+			opts:           Options{Profile: "dev", SessionPolicy: &awslib.SessionPolicy{PolicyARNs: []string{deploy}}},
+			identity:       userIdentity,
+			creds:          keys,
+			wantKind:       awslib.CredentialKindFederationToken,
+			wantFederation: true,
+			wantPolicyARN:  deploy,
+			wantAccount:    "123456789012",
+		},
+		{
+			name:          "read-only with a session policy",
+			opts:          Options{Profile: "dev", ReadOnly: true, SessionPolicy: &awslib.SessionPolicy{PolicyARNs: []string{deploy}}},
+			identity:      roleIdentity,
+			creds:         roleCreds,
+			wantErrSubstr: "a read-only session cannot also have a session policy",
+		},
+		{
+			name:          "temporary IAM user credentials",
+			opts:          Options{Profile: "dev", ReadOnly: true},
+			identity:      userIdentity,
+			creds:         roleCreds,
+			wantErrSubstr: "cannot limit the temporary credentials of arn:aws:iam::123456789012:user/alice with a session policy",
+		},
+		{
+			name:          "IAM Identity Center session",
+			opts:          Options{Profile: "dev", ReadOnly: true},
+			identity:      awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_AdministratorAccess_0123abcd/alice", Account: "123456789012", Partition: "aws"},
+			creds:         roleCreds,
+			wantErrSubstr: "cannot limit the IAM Identity Center session of arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_AdministratorAccess_0123abcd/alice with a session policy",
+		},
+		{
+			name:          "role cannot assume itself",
+			opts:          Options{Profile: "dev", ReadOnly: true},
+			identity:      roleIdentity,
+			creds:         roleCreds,
+			assumeErr:     errors.New("AccessDenied"),
+			wantErrSubstr: "failed to assume role " + ownRole + ": AccessDenied (the role's trust policy must allow it to assume itself)",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var policy *awslib.SessionPolicy
+			var roleARN string
+			service := &mocks.Service{
+				GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+					return tc.identity, nil
+				},
+				RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+					return tc.creds, nil
+				},
+				GetRoleARNFunc: func(ctx context.Context, profile string, sessionARN string) (string, error) {
+					return ownRole, nil
+				},
+				AssumeRoleFunc: func(ctx context.Context, profile string, input awslib.AssumeRoleInput) (awslib.Credentials, error) {
+					roleARN, policy = input.RoleARN, input.SessionPolicy
+					return roleCreds, tc.assumeErr
+				},
+				GetFederationTokenFunc: func(ctx context.Context, profile string, input awslib.FederationTokenInput) (awslib.Credentials, error) {
+					policy = input.SessionPolicy
+					return roleCreds, nil
+				},
+			}
+
+			session, err := (&Client{Service: service}).Credentials(context.Background(), tc.opts)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if session.Credentials.Kind != tc.wantKind {
+				t.Fatalf("expected %q credentials, got %q", tc.wantKind, session.Credentials.Kind)
+			}
+			if roleARN != tc.wantRoleARN || (service.GetFederationTokenCalls.Load() == 1) != tc.wantFederation {
+				t.Fatalf("unexpected requests: assumed %q, %d federation tokens", roleARN, service.GetFederationTokenCalls.Load())
+			}
+			if policy == nil || len(policy.PolicyARNs) != 1 || policy.PolicyARNs[0] != tc.wantPolicyARN {
+				t.Fatalf("expected session policy %s, got %+v", tc.wantPolicyARN, policy)
+			}
+			if session.Identity.Account != tc.wantAccount {
+				t.Fatalf("expected account %s, got %s", tc.wantAccount, session.Identity.Account)
+			}
+			if service.GetSessionTokenCalls.Load() != 0 {
+				t.Fatal("expected no session token without a session policy")
+			}
+		})
+	}
+}
+
+func TestClientCredentialsIdentityError(t *testing.T) {
+	t.Parallel()
+
+	client := &Client{Service: &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return awslib.Identity{}, errors.New("ExpiredToken")
+		},
+	}}
+	_, err := client.Credentials(context.Background(), Options{Profile: "dev"})
+	if err == nil || !strings.Contains(err.Error(), "failed to check credentials: ExpiredToken") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClientCredentialsPolicy(t *testing.T) {
+	t.Parallel()
+
+	var got PolicyCheck
+	service := &mocks.Service{
+		GetCallerIdentityFunc: func(ctx context.Context, profile string) (awslib.Identity, error) {
+			return userIdentity, nil
+		},
+		RetrieveCredentialsFunc: func(ctx context.Context, profile string) (awslib.Credentials, error) {
+			creds := keys
+			creds.Source = "SharedConfigCredentials: /home/alice/.aws/credentials"
+			return creds, nil
+		},
+	}
+	client := &Client{
+		Service: service,
+		Policy: func(ctx context.Context, check PolicyCheck) error {
+			got = check
+			return errors.New("refused")
+		},
+	}
+
+	_, err := client.Credentials(context.Background(), Options{Profile: "dev"})
+	if err == nil || err.Error() != "refused" {
+		t.Fatalf("expected the policy error, got %v", err)
+	}
+	if got.Profile != "dev" || got.Source != awslib.CredentialSourceKeys || !got.LongLived || got.Strategy != StrategySessionToken || got.Identity.Arn != userIdentity.Arn {
+		t.Fatalf("unexpected policy check: %+v", got)
+	}
+	if service.GetSessionTokenCalls.Load() != 0 {
+		t.Fatal("expected no session token for refused credentials")
+	}
+}
+
+func TestOptionsStrategy(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		opts          Options
+		creds         awslib.Credentials
+		want          Strategy
+		wantErrSubstr string
+	}{
+		{name: "SSO", creds: awslib.Credentials{SessionToken: "token", Source: "SSOProvider"}, want: StrategyDirect},
+		{name: "EC2 instance role", creds: awslib.Credentials{SessionToken: "token", Source: "EC2RoleProvider"}, want: StrategyDirect},
+		{name: "ECS task role", creds: awslib.Credentials{SessionToken: "token", Source: "CredentialsEndpointProvider"}, want: StrategyDirect},
+		{name: "Lambda environment", creds: awslib.Credentials{SessionToken: "token", Source: "EnvConfigCredentials"}, want: StrategyDirect},
+		{name: "environment keys", creds: awslib.Credentials{Source: "EnvConfigCredentials"}, want: StrategySessionToken},
+		{name: "shared credentials file", creds: awslib.Credentials{Source: "SharedConfigCredentials: /home/me/.aws/credentials"}, want: StrategySessionToken},
+		{name: "unknown provider", creds: awslib.Credentials{}, want: StrategySessionToken},
+		{
+			name:  "assume role",
+			opts:  Options{AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+			creds: awslib.Credentials{SessionToken: "token", Source: "EC2RoleProvider"},
+			want:  StrategyAssumeRole,
+		},
+		{
+			name:          "role credentials without a session token",
+			creds:         awslib.Credentials{Source: "EC2RoleProvider"},
+			wantErrSubstr: "EC2RoleProvider returned role credentials without a session token",
+		},
+		{name: "read-only SSO", opts: Options{ReadOnly: true}, creds: awslib.Credentials{SessionToken: "token", Source: "SSOProvider"}, want: StrategyAssumeOwnRole},
+		{name: "read-only keys", opts: Options{ReadOnly: true}, creds: awslib.Credentials{Source: "EnvConfigCredentials"}, want: StrategyFederationToken},
+		{
+			name:  "read-only assume role",
+			opts:  Options{ReadOnly: true, AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+			creds: awslib.Credentials{Source: "EnvConfigCredentials"},
+			want:  StrategyAssumeRole,
+		},
+		{
+			name:          "read-only role credentials without a session token",
+			opts:          Options{ReadOnly: true},
+			creds:         awslib.Credentials{Source: "EC2RoleProvider"},
+			wantErrSubstr: "EC2RoleProvider returned role credentials without a session token",
+		},
+		{
+			name:  "session policy with SSO",
+			opts:  Options{SessionPolicy: &awslib.SessionPolicy{Policy: `{"Statement":[]}`}},
+			creds: awslib.Credentials{SessionToken: "token", Source: "SSOProvider"},
+			want:  StrategyAssumeOwnRole,
+		},
+		{
+			name:  "session policy with keys",
+			opts:  Options{SessionPolicy: &awslib.SessionPolicy{Policy: `{"Statement":[]}`}},
+			creds: awslib.Credentials{Source: "EnvConfigCredentials"},
+			want:  StrategyFederationToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.opts.Strategy(tc.creds)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("expected %q, got %q (%v)", tc.want, got, err)
+			}
+		})
+	}
+}
+
+func TestOptionsPlan(t *testing.T) {
+	t.Parallel()
+
+	ssoIdentity := awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/AWSReservedSSO_Admin_0123/alice", Account: "123456789012", Partition: "aws"}
+	policy := &awslib.SessionPolicy{Policy: `{"Statement":[]}`}
+
+	testCases := []struct {
+		name          string
+		opts          Options
+		creds         awslib.Credentials
+		identity      awslib.Identity
+		want          Plan
+		wantErrSubstr string
+	}{
+		{
+			name:     "direct",
+			creds:    roleCreds,
+			identity: roleIdentity,
+			want:     Plan{Strategy: StrategyDirect, Kind: awslib.CredentialKindRole, Identity: roleIdentity},
+		},
+		{
+			name:     "direct role chained",
+			opts:     Options{ChainedRole: true},
+			creds:    roleCreds,
+			identity: roleIdentity,
+			want:     Plan{Strategy: StrategyDirect, Kind: awslib.CredentialKindRoleChained, Identity: roleIdentity},
+		},
+		{
+			name:     "session token",
+			creds:    keys,
+			identity: userIdentity,
+			want:     Plan{Strategy: StrategySessionToken, Kind: awslib.CredentialKindSessionToken, Identity: userIdentity},
+		},
+		{
+			name:     "assume role with keys",
+			opts:     Options{AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN}},
+			creds:    keys,
+			identity: userIdentity,
+			want: Plan{
+				Strategy: StrategyAssumeRole,
+				Kind:     awslib.CredentialKindRole,
+				Identity: awslib.Identity{Arn: "arn:aws:sts::210987654321:assumed-role/Admin/aws-console", Account: "210987654321", Partition: "aws"},
+			},
+		},
+		{
+			name:     "assume role chained with a session policy",
+			opts:     Options{AssumeRole: awslib.AssumeRoleInput{RoleARN: testRoleARN, SessionName: "alice"}, SessionPolicy: policy},
+			creds:    roleCreds,
+			identity: roleIdentity,
+			want: Plan{
+				Strategy:      StrategyAssumeRole,
+				Kind:          awslib.CredentialKindRoleChained,
+				SessionPolicy: policy,
+				Identity:      awslib.Identity{Arn: "arn:aws:sts::210987654321:assumed-role/Admin/alice", Account: "210987654321", Partition: "aws"},
+			},
+		},
+		{
+			name:     "read-only own role",
+			opts:     Options{ReadOnly: true},
+			creds:    roleCreds,
+			identity: roleIdentity,
+			want: Plan{
+				Strategy:      StrategyAssumeOwnRole,
+				Kind:          awslib.CredentialKindRoleChained,
+				SessionPolicy: awslib.ReadOnlySessionPolicy("aws"),
+				Identity:      roleIdentity,
+			},
+		},
+		{
+			name:          "read-only IAM Identity Center session",
+			opts:          Options{ReadOnly: true},
+			creds:         roleCreds,
+			identity:      ssoIdentity,
+			wantErrSubstr: "permission set's role cannot assume itself",
+		},
+		{
+			name:     "federation token",
+			opts:     Options{SessionPolicy: policy},
+			creds:    keys,
+			identity: userIdentity,
+			want:     Plan{Strategy: StrategyFederationToken, Kind: awslib.CredentialKindFederationToken, SessionPolicy: policy, Identity: userIdentity},
+		},
+		{
+			name:          "read-only with a session policy",
+			opts:          Options{ReadOnly: true, SessionPolicy: policy},
+			creds:         keys,
+			identity:      userIdentity,
+			wantErrSubstr: "cannot also have a session policy",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.opts.Plan(context.Background(), tc.creds, tc.identity)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestOptionsSessionDurationFor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		opts          Options
+		kind          awslib.CredentialKind
+		want          time.Duration
+		wantErrSubstr string
+	}{
+		{name: "default", kind: awslib.CredentialKindRole, want: 12 * time.Hour},
+		{name: "default chained", kind: awslib.CredentialKindRoleChained, want: time.Hour},
+		{name: "within the limit", opts: Options{SessionDuration: 2 * time.Hour}, kind: awslib.CredentialKindRole, want: 2 * time.Hour},
+		{name: "limited", opts: Options{SessionDuration: 2 * time.Hour, LimitDuration: true}, kind: awslib.CredentialKindRoleChained, want: time.Hour},
+		{name: "too long", opts: Options{SessionDuration: 2 * time.Hour}, kind: awslib.CredentialKindRoleChained, wantErrSubstr: "role chaining"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.opts.SessionDurationFor(tc.kind)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Fatalf("expected %s, got %s (%v)", tc.want, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+)
+
+// DefaultWarmWorkers is how many profiles Warm prepares at once when not told otherwise.
+const DefaultWarmWorkers = 4
+
+// CredentialCache keeps the temporary credentials of console sessions between runs,
+// keyed by profile and the ARN of the role assumed, if any. A *credcache.Cache is one.
+type CredentialCache interface {
+	Credentials(profile, roleARN string) (awslib.Identity, awslib.Credentials, bool)
+	PutCredentials(profile, roleARN string, identity awslib.Identity, creds awslib.Credentials) error
+}
+
+// WarmResult is the outcome of preparing the console session of one Options.
+type WarmResult struct {
+	Profile string
+	// Cached is true when the credentials came from the cache, so only a sign-in token
+	// was requested.
+	Cached bool
+	URL    URL
+	Err    error
+}
+
+// Warm prepares console sessions for each of opts, so that later sessions for them
+// start without calling AWS: credentials are stored in cache, and sign-in tokens in the
+// SigninTokenCache of ctx. Up to workers sessions are prepared at once; the hooks of c
+// may be called concurrently. The results are in the order of opts.
+func (c *Client) Warm(ctx context.Context, opts []Options, workers int, cache CredentialCache) []WarmResult {
+	if workers <= 0 {
+		workers = DefaultWarmWorkers
+	}
+	results := make([]WarmResult, len(opts))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(workers, len(opts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = c.warm(ctx, opts[i], cache)
+			}
+		}()
+	}
+	for i := range opts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// warm prepares the console session of o, reusing cached credentials when they are
+// still fresh.
+func (c *Client) warm(ctx context.Context, o Options, cache CredentialCache) WarmResult {
+	result := WarmResult{Profile: o.Profile}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	if o.Credentials == nil && cache != nil {
+		if identity, creds, ok := cache.Credentials(o.Profile, o.AssumeRole.RoleARN); ok {
+			o.Identity, o.Credentials = &identity, &creds
+			result.Cached = true
+		}
+	}
+	if o.Credentials == nil {
+		session, err := c.Credentials(ctx, o)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if cache != nil {
+			if err := cache.PutCredentials(o.Profile, o.AssumeRole.RoleARN, session.Identity, session.Credentials); err != nil {
+				result.Err = fmt.Errorf("failed to cache credentials: %w", err)
+				return result
+			}
+		}
+		o.Identity, o.Credentials = &session.Identity, &session.Credentials
+	}
+
+	result.URL, result.Err = c.OpenConsole(ctx, o)
+	return result
+}
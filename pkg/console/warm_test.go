@@ -0,0 +1,135 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/aws/mocks"
+)
+
+// warmService answers concurrent calls and records how many credential lookups ran at
+// once.
+type warmService struct {
+	*mocks.Service
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	retrieved   sync.Map
+}
+
+func (s *warmService) GetCallerIdentity(ctx context.Context, profile string) (awslib.Identity, error) {
+	if profile == "broken" {
+		return awslib.Identity{}, errors.New("ExpiredToken")
+	}
+	return roleIdentity, nil
+}
+
+func (s *warmService) RetrieveCredentials(ctx context.Context, profile string) (awslib.Credentials, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		peak := s.maxInFlight.Load()
+		if n <= peak || s.maxInFlight.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	s.retrieved.Store(profile, true)
+	return awslib.Credentials{AccessKeyID: "ASIA" + strings.ToUpper(profile), SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}, nil
+}
+
+type warmFederation struct {
+	mocks.FederationBuilder
+}
+
+func (*warmFederation) BuildConsoleURL(ctx context.Context, creds awslib.Credentials, durationSeconds int32, destination string) (string, error) {
+	return "https://signin.example.com/?key=" + creds.AccessKeyID, nil
+}
+
+// memoryCache is a CredentialCache safe for concurrent use.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]awslib.Credentials
+}
+
+func (c *memoryCache) Credentials(profile, roleARN string) (awslib.Identity, awslib.Credentials, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	creds, ok := c.entries[profile]
+	return roleIdentity, creds, ok
+}
+
+func (c *memoryCache) PutCredentials(profile, roleARN string, identity awslib.Identity, creds awslib.Credentials) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[profile] = creds
+	return nil
+}
+
+func TestClientWarm(t *testing.T) {
+	t.Parallel()
+
+	service := &warmService{Service: &mocks.Service{}}
+	client := &Client{Service: service, Federation: &warmFederation{}}
+	cache := &memoryCache{entries: map[string]awslib.Credentials{
+		"cached": {AccessKeyID: "ASIACACHED", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)},
+	}}
+
+	profiles := []string{"a", "b", "cached", "broken", "c", "d"}
+	opts := make([]Options, 0, len(profiles))
+	for _, p := range profiles {
+		opts = append(opts, Options{Profile: p, LimitDuration: true})
+	}
+	results := client.Warm(context.Background(), opts, 2, cache)
+
+	if len(results) != len(profiles) {
+		t.Fatalf("got %d results, want %d", len(results), len(profiles))
+	}
+	for i, r := range results {
+		if r.Profile != profiles[i] {
+			t.Fatalf("result %d is for %q, want %q", i, r.Profile, profiles[i])
+		}
+		switch r.Profile {
+		case "broken":
+			if r.Err == nil || !strings.Contains(r.Err.Error(), "failed to check credentials: ExpiredToken") {
+				t.Fatalf("broken error = %v", r.Err)
+			}
+		case "cached":
+			if r.Err != nil || !r.Cached || r.URL.String() != "https://signin.example.com/?key=ASIACACHED" {
+				t.Fatalf("cached result = %+v", r)
+			}
+			if _, ok := service.retrieved.Load("cached"); ok {
+				t.Fatal("expected cached credentials not to be retrieved")
+			}
+		default:
+			if r.Err != nil || r.Cached || r.URL.String() != "https://signin.example.com/?key=ASIA"+strings.ToUpper(r.Profile) {
+				t.Fatalf("%s result = %+v", r.Profile, r)
+			}
+			if _, _, ok := cache.Credentials(r.Profile, ""); !ok {
+				t.Fatalf("expected credentials for %s to be cached", r.Profile)
+			}
+		}
+	}
+	if peak := service.maxInFlight.Load(); peak > 2 {
+		t.Fatalf("%d profiles were warmed at once, want at most 2", peak)
+	}
+}
+
+func TestClientWarmCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := &Client{Service: &warmService{Service: &mocks.Service{}}, Federation: &warmFederation{}}
+	results := client.Warm(ctx, []Options{{Profile: "a"}, {Profile: "b"}}, 0, nil)
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Fatalf("result for %s error = %v, want context.Canceled", r.Profile, r.Err)
+		}
+	}
+}
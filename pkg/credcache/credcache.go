@@ -0,0 +1,325 @@
+// Package credcache caches federation credentials and console sign-in tokens between
+// runs, so opening the console repeatedly within a session does not call STS and the
+// federation endpoint every time.
+package credcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// Names of the cache subdirectories within the aws-console cache directory.
+const (
+	CredentialsDirName  = "credentials"
+	SigninTokensDirName = "signin-tokens"
+)
+
+const (
+	// MinRemaining is how much validity an entry needs left to be reused, so a console
+	// session does not start with credentials that are about to expire.
+	MinRemaining = 5 * time.Minute
+
+	lockTimeout       = 5 * time.Second
+	lockRetryInterval = 20 * time.Millisecond
+	// staleLockAge is when a lock left behind by a killed process is broken.
+	staleLockAge = 30 * time.Second
+)
+
+// Cache stores one JSON file per entry, named by a hash of its key, under the
+// credentials and signin-tokens subdirectories. Writes hold a lock file so concurrent
+// runs do not interleave.
+//
+// A cache with a CacheStore keeps the entries in the store instead, and writes an
+// empty file per entry only so that Purge can find them.
+type Cache struct {
+	dir   string
+	store CacheStore
+	now   func() time.Time
+	// scope, when set, returns what else the entries of a profile are keyed by.
+	scope func(profile string) string
+}
+
+// CacheStore keeps cache entries somewhere other than plaintext files: an OS
+// credential store, such as the macOS Keychain, or a cache server shared by the users
+// of a host. Get fails for missing entries; Delete ignores them.
+type CacheStore interface {
+	Get(key string) ([]byte, error)
+	Set(key string, secret []byte) error
+	Delete(key string) error
+}
+
+// NewCache creates a cache in the aws-console cache directory.
+func NewCache() *Cache {
+	dir, err := paths.CacheDir()
+	if err != nil {
+		return NewCacheAt("")
+	}
+	return NewCacheAt(dir)
+}
+
+// NewCacheAt creates a cache rooted at dir. An empty dir disables caching.
+func NewCacheAt(dir string) *Cache {
+	return newCacheAt(dir, time.Now)
+}
+
+// NewStoreCache creates a cache rooted at dir that keeps its entries in store. An
+// empty dir disables caching.
+func NewStoreCache(dir string, store CacheStore) *Cache {
+	c := newCacheAt(dir, time.Now)
+	c.store = store
+	return c
+}
+
+func newCacheAt(dir string, now func() time.Time) *Cache {
+	return &Cache{dir: dir, now: now}
+}
+
+// WithScope keys the entries of each profile by scope(profile) as well as by its name,
+// and returns c. A store shared by users with AWS configs of their own, such as a
+// cache server, needs a scope such as the account and role a profile signs in to, so
+// that profiles of the same name never read each other's entries.
+func (c *Cache) WithScope(scope func(profile string) string) *Cache {
+	c.scope = scope
+	return c
+}
+
+// profileKey is the part of entry keys that names profile.
+func (c *Cache) profileKey(profile string) string {
+	if c.scope == nil {
+		return profile
+	}
+	return profile + "\x00" + c.scope(profile)
+}
+
+type credentialsEntry struct {
+	Identity    awslib.Identity    `json:"identity"`
+	Credentials awslib.Credentials `json:"credentials"`
+}
+
+type validatedEntry struct {
+	Identity    awslib.Identity `json:"identity"`
+	ValidatedAt time.Time       `json:"validated_at"`
+}
+
+type signinTokenEntry struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Credentials returns the cached identity and federation credentials for profile and
+// roleARN (empty when no role is assumed), if they have at least MinRemaining left.
+func (c *Cache) Credentials(profile, roleARN string) (awslib.Identity, awslib.Credentials, bool) {
+	var entry credentialsEntry
+	if !c.read(CredentialsDirName, credentialsKey(c.profileKey(profile), roleARN), &entry) || !c.fresh(entry.Credentials.Expires) {
+		return awslib.Identity{}, awslib.Credentials{}, false
+	}
+	return entry.Identity, entry.Credentials, true
+}
+
+// Expires returns when the credentials cached for profile and roleARN expire, even
+// when that is too soon, or already past, for them to be reused. It reads the cache
+// only, for reports such as a shell prompt that must never call AWS.
+func (c *Cache) Expires(profile, roleARN string) (time.Time, bool) {
+	var entry credentialsEntry
+	if !c.read(CredentialsDirName, credentialsKey(c.profileKey(profile), roleARN), &entry) || entry.Credentials.Expires.IsZero() {
+		return time.Time{}, false
+	}
+	return entry.Credentials.Expires, true
+}
+
+// CredentialsFile returns the file of the entry for profile and roleARN, which is
+// rewritten whenever its credentials are, for tools that watch it; it is empty when
+// caching is disabled. The file may not exist yet.
+func (c *Cache) CredentialsFile(profile, roleARN string) string {
+	if c.dir == "" {
+		return ""
+	}
+	return c.path(CredentialsDirName, credentialsKey(c.profileKey(profile), roleARN))
+}
+
+// PutCredentials caches credentials for profile and roleARN. Credentials that do not
+// expire are never cached, since they are already stored by the AWS config.
+func (c *Cache) PutCredentials(profile, roleARN string, identity awslib.Identity, creds awslib.Credentials) error {
+	if creds.Expires.IsZero() {
+		return nil
+	}
+	return c.write(CredentialsDirName, credentialsKey(c.profileKey(profile), roleARN), credentialsEntry{Identity: identity, Credentials: creds})
+}
+
+// Validated returns the caller identity of profile as of its last successful credential
+// check, if that was within maxAge; a maxAge of 0 accepts a check of any age. Entries
+// live among the credentials, so removing those forgets them too.
+func (c *Cache) Validated(profile string, maxAge time.Duration) (awslib.Identity, time.Time, bool) {
+	var entry validatedEntry
+	if !c.read(CredentialsDirName, validatedKey(c.profileKey(profile)), &entry) || maxAge > 0 && c.now().Sub(entry.ValidatedAt) > maxAge {
+		return awslib.Identity{}, time.Time{}, false
+	}
+	return entry.Identity, entry.ValidatedAt, true
+}
+
+// PutValidated records that the credentials of profile were just checked and belong
+// to identity.
+func (c *Cache) PutValidated(profile string, identity awslib.Identity) error {
+	return c.write(CredentialsDirName, validatedKey(c.profileKey(profile)), validatedEntry{Identity: identity, ValidatedAt: c.now().UTC()})
+}
+
+// SigninToken implements awslib.SigninTokenCache.
+func (c *Cache) SigninToken(key string) (string, bool) {
+	var entry signinTokenEntry
+	if !c.read(SigninTokensDirName, key, &entry) || !c.fresh(entry.ExpiresAt) {
+		return "", false
+	}
+	return entry.Token, true
+}
+
+// PutSigninToken implements awslib.SigninTokenCache.
+func (c *Cache) PutSigninToken(key, token string, expires time.Time) error {
+	return c.write(SigninTokensDirName, key, signinTokenEntry{Token: token, ExpiresAt: expires.UTC()})
+}
+
+// fresh reports whether expires leaves at least MinRemaining from now.
+func (c *Cache) fresh(expires time.Time) bool {
+	return !expires.IsZero() && expires.Sub(c.now()) >= MinRemaining
+}
+
+func credentialsKey(profile, roleARN string) string {
+	return profile + "\x00" + roleARN
+}
+
+func validatedKey(profile string) string {
+	return "validated\x00" + profile
+}
+
+// Purge deletes the entries of kind from the cache's CacheStore, so that removing the
+// kind's directory removes them all. It does nothing for a cache of files. Only the
+// entries this host wrote are found, from their placeholder files: entries that other
+// hosts or users wrote to a shared store stay until it expires them.
+func (c *Cache) Purge(kind string) error {
+	if c.dir == "" || c.store == nil {
+		return nil
+	}
+	names, err := os.ReadDir(filepath.Join(c.dir, kind))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to list %s cache entries: %w", kind, err)
+	}
+
+	var errs []error
+	for _, entry := range names {
+		if name, ok := strings.CutSuffix(entry.Name(), ".json"); ok {
+			if err := c.store.Delete(kind + "/" + name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove %s cache entry %s from its store: %w", kind, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Cache) path(kind, key string) string {
+	return filepath.Join(c.dir, kind, entryName(key)+".json")
+}
+
+// storeKey is the CacheStore key of the entry for key.
+func storeKey(kind, key string) string {
+	return kind + "/" + entryName(key)
+}
+
+func entryName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// read decodes the entry for key into v. Missing and unreadable entries are misses.
+func (c *Cache) read(kind, key string, v any) bool {
+	if c.dir == "" {
+		return false
+	}
+	var data []byte
+	var err error
+	if c.store != nil {
+		data, err = c.store.Get(storeKey(kind, key))
+	} else {
+		data, err = os.ReadFile(c.path(kind, key))
+	}
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+func (c *Cache) write(kind, key string, v any) error {
+	if c.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s cache entry: %w", kind, err)
+	}
+
+	path := c.path(kind, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	unlock, err := lock(path, lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if c.store != nil {
+		if err := c.store.Set(storeKey(kind, key), data); err != nil {
+			return fmt.Errorf("failed to write %s cache entry to its store: %w", kind, err)
+		}
+		// The empty file records the entry for Purge; it holds nothing secret.
+		data = nil
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s cache entry: %w", kind, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s cache entry: %w", kind, err)
+	}
+	return nil
+}
+
+// lock acquires an exclusive lock file next to path within timeout, breaking locks older
+// than staleLockAge, and returns the function that releases it.
+func lock(path string, timeout time.Duration) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock cache entry: %w", err)
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for cache lock %s", lockPath)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
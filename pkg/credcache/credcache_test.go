@@ -0,0 +1,287 @@
+package credcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+)
+
+func TestCacheCredentials(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := now
+	dir := t.TempDir()
+	cache := newCacheAt(dir, func() time.Time { return clock })
+
+	identity := awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012", Partition: "aws"}
+	creds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: now.Add(time.Hour)}
+
+	if _, _, ok := cache.Credentials("dev", ""); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := cache.PutCredentials("dev", "", identity, creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gotIdentity, gotCreds, ok := cache.Credentials("dev", "")
+	if !ok {
+		t.Fatal("expected a hit")
+	}
+	if gotIdentity != identity || gotCreds.AccessKeyID != "ASIA" || !gotCreds.Expires.Equal(creds.Expires) {
+		t.Fatalf("unexpected entry: %+v %+v", gotIdentity, gotCreds)
+	}
+	if _, _, ok := cache.Credentials("dev", "arn:aws:iam::210987654321:role/Admin"); ok {
+		t.Fatal("expected entries to be keyed by role")
+	}
+
+	clock = now.Add(time.Hour - MinRemaining + time.Second)
+	if _, _, ok := cache.Credentials("dev", ""); ok {
+		t.Fatal("expected credentials close to expiry to be a miss")
+	}
+	if expires, ok := cache.Expires("dev", ""); !ok || !expires.Equal(creds.Expires) {
+		t.Fatalf("expected the expiry of credentials close to expiry, got %v, %v", expires, ok)
+	}
+	if _, ok := cache.Expires("prod", ""); ok {
+		t.Fatal("expected no expiry for a profile without cached credentials")
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, CredentialsDirName, "*.json"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("expected one cache file, got %v (%v)", matches, err)
+	}
+	if file := cache.CredentialsFile("dev", ""); file != matches[0] {
+		t.Fatalf("CredentialsFile() = %q, want %q", file, matches[0])
+	}
+	info, err := os.Stat(matches[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected 0600 permissions, got %o", perm)
+	}
+}
+
+func TestCachePutCredentialsSkipsLongLivedKeys(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cache := NewCacheAt(dir)
+	if err := cache.PutCredentials("keys", "", awslib.Identity{}, awslib.Credentials{AccessKeyID: "AKIA"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, CredentialsDirName)); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be written, got %v", err)
+	}
+}
+
+func TestCacheValidated(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := now
+	cache := newCacheAt(t.TempDir(), func() time.Time { return clock })
+	identity := awslib.Identity{Arn: "arn:aws:sts::123456789012:assumed-role/Admin/dev", Account: "123456789012"}
+
+	if _, _, ok := cache.Validated("dev", 0); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := cache.PutValidated("dev", identity); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock = now.Add(20 * time.Minute)
+	if _, _, ok := cache.Validated("dev", 15*time.Minute); ok {
+		t.Fatal("expected a check older than maxAge to be a miss")
+	}
+	got, validatedAt, ok := cache.Validated("dev", 0)
+	if !ok || got != identity || !validatedAt.Equal(now) {
+		t.Fatalf("expected a check of any age to be a hit, got %+v at %s (%v)", got, validatedAt, ok)
+	}
+	if _, _, ok := cache.Validated("prod", 0); ok {
+		t.Fatal("expected entries to be keyed by profile")
+	}
+	if _, _, ok := cache.Credentials("dev", ""); ok {
+		t.Fatal("expected a validated identity not to stand in for credentials")
+	}
+}
+
+func TestCacheSigninToken(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := now
+	cache := newCacheAt(t.TempDir(), func() time.Time { return clock })
+
+	if err := cache.PutSigninToken("key", "token-123", now.Add(15*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token, ok := cache.SigninToken("key"); !ok || token != "token-123" {
+		t.Fatalf("expected cached token, got %q %v", token, ok)
+	}
+	if _, ok := cache.SigninToken("other"); ok {
+		t.Fatal("expected a miss for another key")
+	}
+
+	clock = now.Add(11 * time.Minute)
+	if _, ok := cache.SigninToken("key"); ok {
+		t.Fatal("expected a token close to expiry to be a miss")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCacheAt("")
+	creds := awslib.Credentials{AccessKeyID: "ASIA", Expires: time.Now().Add(time.Hour)}
+	if err := cache.PutCredentials("dev", "", awslib.Identity{}, creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, ok := cache.Credentials("dev", ""); ok {
+		t.Fatal("expected a disabled cache to miss")
+	}
+	if file := cache.CredentialsFile("dev", ""); file != "" {
+		t.Fatalf("CredentialsFile() = %q, want none", file)
+	}
+}
+
+func TestLockTimesOut(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "entry.json")
+	unlock, err := lock(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer unlock()
+
+	if _, err := lock(path, 50*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out waiting for cache lock") {
+		t.Fatalf("expected lock timeout, got %v", err)
+	}
+}
+
+func TestLockBreaksStaleLock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "entry.json")
+	if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	unlock, err := lock(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected stale lock to be broken, got %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Fatalf("expected lock file to be removed, got %v", err)
+	}
+}
+
+// memoryStore is a CacheStore in memory.
+type memoryStore struct {
+	secrets map[string][]byte
+}
+
+func (m *memoryStore) Get(key string) ([]byte, error) {
+	secret, ok := m.secrets[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return secret, nil
+}
+
+func (m *memoryStore) Set(key string, secret []byte) error {
+	m.secrets[key] = secret
+	return nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	delete(m.secrets, key)
+	return nil
+}
+
+func TestStoreCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := &memoryStore{secrets: map[string][]byte{}}
+	cache := NewStoreCache(dir, store)
+
+	creds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}
+	if err := cache.PutCredentials("dev", "", awslib.Identity{Account: "123456789012"}, creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cache.PutSigninToken("key", "signin-token", time.Now().Add(15*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, got, ok := cache.Credentials("dev", ""); !ok || got.SecretAccessKey != "secret" {
+		t.Fatalf("Credentials() = %+v, %v, want a hit from the store", got, ok)
+	}
+	if token, ok := cache.SigninToken("key"); !ok || token != "signin-token" {
+		t.Fatalf("SigninToken() = %q, %v, want a hit from the store", token, ok)
+	}
+	if len(store.secrets) != 2 {
+		t.Fatalf("store holds %d secrets, want 2", len(store.secrets))
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("expected two entry files, got %v (%v)", matches, err)
+	}
+	for _, path := range matches {
+		if data, err := os.ReadFile(path); err != nil || len(data) != 0 {
+			t.Fatalf("entry file %s = %q (%v), want it empty", path, data, err)
+		}
+	}
+
+	if err := cache.Purge(CredentialsDirName); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if _, _, ok := cache.Credentials("dev", ""); ok {
+		t.Fatal("expected a miss after Purge")
+	}
+	if _, ok := cache.SigninToken("key"); !ok {
+		t.Fatal("expected Purge to keep other kinds")
+	}
+	if err := cache.Purge("missing"); err != nil {
+		t.Fatalf("Purge() of a missing kind error = %v", err)
+	}
+}
+
+func TestStoreCacheScope(t *testing.T) {
+	t.Parallel()
+
+	store := &memoryStore{secrets: map[string][]byte{}}
+	accounts := map[string]string{"alice": "111111111111", "bob": "222222222222"}
+	alice := NewStoreCache(t.TempDir(), store).WithScope(func(profile string) string { return accounts["alice"] })
+	bob := NewStoreCache(t.TempDir(), store).WithScope(func(profile string) string { return accounts["bob"] })
+
+	creds := awslib.Credentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", SessionToken: "token", Expires: time.Now().Add(time.Hour)}
+	if err := alice.PutCredentials("dev", "", awslib.Identity{Account: accounts["alice"]}, creds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := alice.PutValidated("dev", awslib.Identity{Account: accounts["alice"]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, ok := alice.Credentials("dev", ""); !ok {
+		t.Fatal("expected a hit for the same scope")
+	}
+	if _, _, ok := bob.Credentials("dev", ""); ok {
+		t.Fatal("expected a miss for a profile of the same name in another scope")
+	}
+	if _, _, ok := bob.Validated("dev", 0); ok {
+		t.Fatal("expected no validated identity for a profile of the same name in another scope")
+	}
+}
@@ -0,0 +1,106 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+)
+
+// Client calls a daemon listening on a Unix socket.
+type Client struct {
+	path string
+	http *http.Client
+}
+
+// NewClient returns a Client for the daemon listening on the socket at path.
+func NewClient(path string) *Client {
+	dialer := &net.Dialer{Timeout: time.Second}
+	return &Client{
+		path: path,
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				conn, err := dialer.DialContext(ctx, "unix", path)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+				}
+				return conn, nil
+			},
+		}},
+	}
+}
+
+// Path returns the path of the socket.
+func (c *Client) Path() string {
+	return c.path
+}
+
+// Status describes the daemon and the profiles it has prepared.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := c.call(ctx, http.MethodGet, "/v1/status", nil, &status)
+	return status, err
+}
+
+// Credentials returns temporary credentials for profile.
+func (c *Client) Credentials(ctx context.Context, profile string) (awslib.Credentials, error) {
+	var creds Credentials
+	if err := c.call(ctx, http.MethodPost, "/v1/creds", CredentialsRequest{Profile: profile}, &creds); err != nil {
+		return awslib.Credentials{}, err
+	}
+	return awslib.Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expires:         creds.Expires,
+	}, nil
+}
+
+// Open returns a sign-in URL for the profile and destination of req.
+func (c *Client) Open(ctx context.Context, req OpenRequest) (OpenResponse, error) {
+	var resp OpenResponse
+	err := c.call(ctx, http.MethodPost, "/v1/open", req, &resp)
+	return resp, err
+}
+
+func (c *Client) call(ctx context.Context, method, path string, body, out any) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return fmt.Errorf("failed to encode the daemon request: %w", err)
+		}
+	}
+	// The host is ignored: every request goes to the socket.
+	req, err := http.NewRequestWithContext(ctx, method, "http://daemon"+path, &reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create the daemon request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrNotRunning) {
+			return fmt.Errorf("%w on %s", ErrNotRunning, c.path)
+		}
+		return fmt.Errorf("failed to call the daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+			return fmt.Errorf("the daemon answered %s", resp.Status)
+		}
+		return errors.New(e.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode the daemon response: %w", err)
+	}
+	return nil
+}
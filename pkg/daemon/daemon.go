@@ -0,0 +1,237 @@
+// Package daemon serves credentials and console sign-in URLs to local clients over a
+// Unix socket, so editor plugins and scripts can request them from a long-running
+// aws-console process instead of starting one and loading the configuration each time.
+//
+// The API is JSON over HTTP on the socket:
+//
+//	GET  /v1/status  the daemon's process and the profiles it has prepared
+//	POST /v1/creds   {"profile": "dev"} returns temporary credentials
+//	POST /v1/open    {"profile": "dev", "destination": "s3"} returns a sign-in URL
+//
+// Errors are returned as {"error": "..."} with a non-2xx status.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+)
+
+// SocketName is the file name of the socket in the aws-console state directory.
+const SocketName = "daemon.sock"
+
+// ErrNotRunning is returned by a Client when no daemon listens on its socket.
+var ErrNotRunning = errors.New("the aws-console daemon is not running")
+
+// Backend resolves what the daemon serves. Its methods may be called concurrently.
+type Backend interface {
+	Credentials(ctx context.Context, profile string) (awslib.Credentials, error)
+	Open(ctx context.Context, req OpenRequest) (OpenResponse, error)
+	Status(ctx context.Context) Status
+}
+
+// CredentialsRequest asks for the credentials of a profile.
+type CredentialsRequest struct {
+	Profile string `json:"profile"`
+}
+
+// Credentials are the temporary credentials of a profile, as sent over the socket.
+type Credentials struct {
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token,omitempty"`
+	Expires         time.Time `json:"expires,omitzero"`
+}
+
+// OpenRequest asks for a sign-in URL for a profile. Destination is a service or console
+// path as given to aws-console -d; empty opens the console home page.
+type OpenRequest struct {
+	Profile     string `json:"profile"`
+	Destination string `json:"destination,omitempty"`
+}
+
+// OpenResponse carries a sign-in URL, which the client opens in a browser.
+type OpenResponse struct {
+	URL string `json:"url"`
+}
+
+// Status describes a running daemon.
+type Status struct {
+	PID      int             `json:"pid"`
+	Started  time.Time       `json:"started"`
+	Profiles []ProfileStatus `json:"profiles"`
+}
+
+// ProfileStatus is what the daemon last prepared for a profile.
+type ProfileStatus struct {
+	Profile string `json:"profile"`
+	Account string `json:"account,omitempty"`
+	// Expires is when the profile's credentials expire; zero when they do not.
+	Expires time.Time `json:"expires,omitzero"`
+	// Refreshed is when the credentials were last prepared or served.
+	Refreshed time.Time `json:"refreshed,omitzero"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves a Backend on a Unix socket until Close.
+type Server struct {
+	path     string
+	listener net.Listener
+	server   *http.Server
+}
+
+// Listen creates the socket at path, readable only by the current user, and serves
+// backend on it in the background. A socket left behind by a daemon that is no longer
+// running is replaced; one that still answers is an error.
+func Listen(path string, backend Backend) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create the daemon socket directory: %w", err)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already running on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove the stale daemon socket: %w", err)
+	}
+
+	listener, err := listenPrivate(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		path:     path,
+		listener: listener,
+		server:   &http.Server{Handler: Handler(backend), ReadHeaderTimeout: 10 * time.Second},
+	}
+	go func() { _ = s.server.Serve(listener) }()
+	return s, nil
+}
+
+// listenPrivate listens on a socket at path that only the current user can connect to.
+// The socket is bound in a new directory only the user can enter and moved into place
+// once its permissions are restricted, so no other user can connect to it in between.
+func listenPrivate(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".daemon-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the daemon socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bound := filepath.Join(dir, filepath.Base(path))
+	listener, err := net.Listen("unix", bound)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	// The socket is removed by Close under its final name.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(bound, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict access to the daemon socket: %w", err)
+	}
+	if err := os.Rename(bound, path); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	return listener, nil
+}
+
+// Path returns the path of the socket.
+func (s *Server) Path() string {
+	return s.path
+}
+
+// Close stops the server once the requests in flight are answered, or after timeout,
+// and removes the socket.
+func (s *Server) Close(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = s.server.Close()
+	}
+	// Closing the listener removes the socket; this covers a listener closed early.
+	if rmErr := os.Remove(s.path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) && err == nil {
+		err = rmErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stop the daemon: %w", err)
+	}
+	return nil
+}
+
+// Handler returns the HTTP API over backend.
+func Handler(backend Backend) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, backend.Status(r.Context()))
+	})
+	mux.HandleFunc("POST /v1/creds", func(w http.ResponseWriter, r *http.Request) {
+		var req CredentialsRequest
+		if !readRequest(w, r, &req) {
+			return
+		}
+		creds, err := backend.Credentials(r.Context(), req.Profile)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, Credentials{
+			AccessKeyID:     creds.AccessKeyID,
+			SecretAccessKey: creds.SecretAccessKey,
+			SessionToken:    creds.SessionToken,
+			Expires:         creds.Expires,
+		})
+	})
+	mux.HandleFunc("POST /v1/open", func(w http.ResponseWriter, r *http.Request) {
+		var req OpenRequest
+		if !readRequest(w, r, &req) {
+			return
+		}
+		resp, err := backend.Open(r.Context(), req)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+	return mux
+}
+
+// readRequest decodes a request body that names a profile, answering the request
+// itself when it cannot.
+func readRequest(w http.ResponseWriter, r *http.Request, req interface{ profile() string }) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return false
+	}
+	if req.profile() == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "missing profile"})
+		return false
+	}
+	return true
+}
+
+func (r *CredentialsRequest) profile() string { return r.Profile }
+
+func (r *OpenRequest) profile() string { return r.Profile }
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,178 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	awslib "github.com/eculver/aws-console/pkg/aws"
+)
+
+type fakeBackend struct{}
+
+func (fakeBackend) Credentials(ctx context.Context, profile string) (awslib.Credentials, error) {
+	if profile != "dev" {
+		return awslib.Credentials{}, errors.New(`profile "` + profile + `" not found in AWS config`)
+	}
+	return awslib.Credentials{
+		AccessKeyID:     "ASIA",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		Expires:         time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, nil
+}
+
+func (fakeBackend) Open(ctx context.Context, req OpenRequest) (OpenResponse, error) {
+	return OpenResponse{URL: "https://signin.aws.amazon.com/federation?Destination=" + req.Destination}, nil
+}
+
+func (fakeBackend) Status(ctx context.Context) Status {
+	return Status{PID: 42, Profiles: []ProfileStatus{{Profile: "dev", Account: "123456789012"}}}
+}
+
+func TestServerAndClient(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), SocketName)
+	server, err := Listen(path, fakeBackend{})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { server.Close(time.Second) })
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat the socket: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected owner-only permissions, got %v", perm)
+	}
+
+	ctx := context.Background()
+	client := NewClient(path)
+
+	status, err := client.Status(ctx)
+	if err != nil || status.PID != 42 || len(status.Profiles) != 1 || status.Profiles[0].Account != "123456789012" {
+		t.Fatalf("unexpected status %+v (%v)", status, err)
+	}
+
+	creds, err := client.Credentials(ctx, "dev")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.AccessKeyID != "ASIA" || creds.SessionToken != "token" || !creds.Expires.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	if _, err := client.Credentials(ctx, "nope"); err == nil || err.Error() != `profile "nope" not found in AWS config` {
+		t.Fatalf("expected the backend's error, got %v", err)
+	}
+	if _, err := client.Credentials(ctx, ""); err == nil || err.Error() != "missing profile" {
+		t.Fatalf("expected a missing profile error, got %v", err)
+	}
+
+	resp, err := client.Open(ctx, OpenRequest{Profile: "dev", Destination: "s3"})
+	if err != nil || !strings.HasSuffix(resp.URL, "Destination=s3") {
+		t.Fatalf("unexpected response %+v (%v)", resp, err)
+	}
+
+	if err := server.Close(time.Second); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the socket to be removed, got %v", err)
+	}
+	if _, err := client.Status(ctx); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning after closing, got %v", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// A socket nobody listens on is left behind by a daemon that did not stop cleanly.
+	stale := filepath.Join(dir, "stale.sock")
+	listener, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("failed to create a socket: %v", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	server, err := Listen(stale, fakeBackend{})
+	if err != nil {
+		t.Fatalf("expected the stale socket to be replaced, got %v", err)
+	}
+	t.Cleanup(func() { server.Close(time.Second) })
+
+	// The socket is bound in a private directory, which is gone once it is in place.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read the socket directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "stale.sock" {
+		t.Fatalf("expected only the socket in its directory, got %v", entries)
+	}
+
+	if _, err := Listen(stale, fakeBackend{}); err == nil || !strings.Contains(err.Error(), "a daemon is already running on") {
+		t.Fatalf("expected an already running error, got %v", err)
+	}
+}
+
+func TestClientNotRunning(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(filepath.Join(t.TempDir(), SocketName))
+	if _, err := client.Credentials(context.Background(), "dev"); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("expected ErrNotRunning, got %v", err)
+	}
+}
+
+func TestLocks(t *testing.T) {
+	t.Parallel()
+
+	var locks Locks
+	var wg sync.WaitGroup
+	var running, maxRunning atomic.Int32
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.Lock("dev")
+			defer unlock()
+			n := running.Add(1)
+			if n > maxRunning.Load() {
+				maxRunning.Store(n)
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	if got := maxRunning.Load(); got != 1 {
+		t.Fatalf("expected one holder of a key at a time, got %d", got)
+	}
+
+	// Another key is not held up.
+	unlock := locks.Lock("dev")
+	defer unlock()
+	done := make(chan struct{})
+	go func() {
+		locks.Lock("prod")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a lock on another key waited")
+	}
+}
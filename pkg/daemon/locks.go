@@ -0,0 +1,28 @@
+package daemon
+
+import "sync"
+
+// Locks serializes work per key, such as a profile, so concurrent requests for one
+// profile resolve its credentials once while other profiles proceed. The zero value is
+// ready to use.
+type Locks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Lock waits until no one else holds key, and returns the function that releases it.
+func (l *Locks) Lock(key string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+	m, ok := l.locks[key]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[key] = m
+	}
+	l.mu.Unlock()
+
+	m.Lock()
+	return m.Unlock
+}
@@ -0,0 +1,234 @@
+package destination
+
+import (
+	"slices"
+	"strings"
+)
+
+// Page is a console page of the catalog, found by the words naming it, as in
+// 'aws-console dynamodb tables'.
+type Page struct {
+	// Service is the name of the page's service and Aliases other names it goes by.
+	Service string
+	Aliases []string
+	// Name is the page of the service, such as "tables"; empty is its home page.
+	Name string
+	// Destination is the page as --destination takes it.
+	Destination string
+}
+
+// Title is the words that name p, such as "dynamodb tables".
+func (p Page) Title() string {
+	if p.Name == "" {
+		return p.Service
+	}
+	return p.Service + " " + p.Name
+}
+
+// catalog lists the console pages positional arguments are matched against: each
+// service's home page, followed by its most visited pages.
+var catalog = buildCatalog([]catalogService{
+	{service: "acm", aliases: []string{"certificates"}, home: "acm/home", pages: map[string]string{"certificates": "acm/home#/certificates/list"}},
+	{service: "apigateway", aliases: []string{"api"}, home: "apigateway/main/apis"},
+	{service: "athena", home: "athena/home"},
+	{service: "bedrock", home: "bedrock/home"},
+	{service: "billing", home: "billing/home", pages: map[string]string{"bills": "billing/home#/bills"}},
+	{service: "cloudformation", aliases: []string{"cfn"}, home: "cloudformation/home", pages: map[string]string{"stacks": "cloudformation/home#/stacks"}},
+	{service: "cloudfront", home: "cloudfront/v4/home", pages: map[string]string{"distributions": "cloudfront/v4/home#/distributions"}},
+	{service: "cloudshell", home: "cloudshell"},
+	{service: "cloudtrail", home: "cloudtrail/home", pages: map[string]string{"events": "cloudtrail/home#/events"}},
+	{service: "cloudwatch", aliases: []string{"cw"}, home: "cloudwatch/home", pages: map[string]string{
+		"alarms":     "cloudwatch/home#alarmsV2:",
+		"dashboards": "cloudwatch/home#dashboards",
+		"logs":       "cloudwatch/home#logsV2:log-groups",
+	}},
+	{service: "codebuild", home: "codesuite/codebuild/projects"},
+	{service: "codepipeline", home: "codesuite/codepipeline/pipelines"},
+	{service: "cognito", home: "cognito/v2/idp/user-pools"},
+	{service: "cost-explorer", home: "costmanagement/home#/cost-explorer"},
+	{service: "dynamodb", aliases: []string{"ddb"}, home: "dynamodbv2/home", pages: map[string]string{"tables": "dynamodbv2/home#tables"}},
+	{service: "ec2", home: "ec2/home", pages: map[string]string{
+		"amis":            "ec2/home#Images:",
+		"instances":       "ec2/home#Instances:",
+		"key pairs":       "ec2/home#KeyPairs:",
+		"load balancers":  "ec2/home#LoadBalancers:",
+		"security groups": "ec2/home#SecurityGroups:",
+		"volumes":         "ec2/home#Volumes:",
+	}},
+	{service: "ecr", home: "ecr/home", pages: map[string]string{"repositories": "ecr/private-registry/repositories"}},
+	{service: "ecs", home: "ecs/v2/home", pages: map[string]string{"clusters": "ecs/v2/clusters"}},
+	{service: "eks", home: "eks/home", pages: map[string]string{"clusters": "eks/home#/clusters"}},
+	{service: "elasticache", home: "elasticache/home"},
+	{service: "eventbridge", aliases: []string{"events"}, home: "events/home", pages: map[string]string{"rules": "events/home#/rules"}},
+	{service: "glue", home: "glue/home"},
+	{service: "guardduty", home: "guardduty/home", pages: map[string]string{"findings": "guardduty/home#/findings"}},
+	{service: "iam", home: "iam/home#/home", pages: map[string]string{
+		"policies": "iam/home#/policies",
+		"roles":    "iam/home#/roles",
+		"users":    "iam/home#/users",
+	}},
+	{service: "identitycenter", aliases: []string{"sso"}, home: "singlesignon/home"},
+	{service: "kinesis", home: "kinesis/home"},
+	{service: "kms", home: "kms/home", pages: map[string]string{"keys": "kms/home#/kms/keys"}},
+	{service: "lambda", home: "lambda/home", pages: map[string]string{
+		"functions": "lambda/home#/functions",
+		"layers":    "lambda/home#/layers",
+	}},
+	{service: "logs", home: "cloudwatch/home#logsV2:log-groups", pages: map[string]string{"insights": "cloudwatch/home#logsV2:logs-insights"}},
+	{service: "opensearch", home: "aos/home"},
+	{service: "organizations", home: "organizations/v2/home"},
+	{service: "quicksight", home: "quicksight"},
+	{service: "rds", home: "rds/home", pages: map[string]string{
+		"databases": "rds/home#databases:",
+		"snapshots": "rds/home#snapshots-list:",
+	}},
+	{service: "redshift", home: "redshiftv2/home"},
+	{service: "route53", home: "route53/v2/home", pages: map[string]string{"hosted zones": "route53/v2/hostedzones"}},
+	{service: "s3", home: "s3/home", pages: map[string]string{"buckets": "s3/buckets"}},
+	{service: "sagemaker", home: "sagemaker/home"},
+	{service: "secretsmanager", aliases: []string{"secrets"}, home: "secretsmanager/home", pages: map[string]string{"secrets": "secretsmanager/listsecrets"}},
+	{service: "securityhub", home: "securityhub/home"},
+	{service: "sns", home: "sns/v3/home", pages: map[string]string{"topics": "sns/v3/home#/topics"}},
+	{service: "sqs", home: "sqs/v3/home", pages: map[string]string{"queues": "sqs/v3/home#/queues"}},
+	{service: "ssm", aliases: []string{"systems-manager"}, home: "systems-manager/home", pages: map[string]string{"parameters": "systems-manager/parameters"}},
+	{service: "stepfunctions", aliases: []string{"sfn", "states"}, home: "states/home", pages: map[string]string{"state machines": "states/home#/statemachines"}},
+	{service: "vpc", home: "vpc/home", pages: map[string]string{
+		"route tables": "vpc/home#RouteTables:",
+		"subnets":      "vpc/home#subnets:",
+	}},
+	{service: "waf", home: "wafv2/homev2"},
+})
+
+// catalogService is a service of the catalog, with its pages by name.
+type catalogService struct {
+	service string
+	aliases []string
+	home    string
+	pages   map[string]string
+}
+
+func buildCatalog(services []catalogService) []Page {
+	var pages []Page
+	for _, s := range services {
+		pages = append(pages, Page{Service: s.service, Aliases: s.aliases, Destination: s.home})
+		names := make([]string, 0, len(s.pages))
+		for name := range s.pages {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			pages = append(pages, Page{Service: s.service, Aliases: s.aliases, Name: name, Destination: s.pages[name]})
+		}
+	}
+	return pages
+}
+
+// Catalog returns the console pages Search matches against.
+func Catalog() []Page {
+	return append([]Page(nil), catalog...)
+}
+
+// Match is a Page found by Search, with how closely it matches: the higher the score,
+// the closer.
+type Match struct {
+	Page  Page
+	Score int
+}
+
+// Scores of a word matched against a name, from the closest match down.
+const (
+	scoreExact    = 4
+	scorePrefix   = 3
+	scoreTypo     = 2
+	scoreContains = 1
+)
+
+// Search matches the words of a query, such as "dynamo tables", against the catalog
+// and returns the pages they could name, closest first. The first word names the
+// service and the rest its page, each exactly, by a prefix, with a typo, or as part of
+// a longer name. A service's home page is closer than its other pages to a query that
+// names no page.
+func Search(words []string) []Match {
+	if len(words) == 0 {
+		return nil
+	}
+	var matches []Match
+	for _, p := range catalog {
+		score := bestScore(strings.ToLower(words[0]), append([]string{p.Service}, p.Aliases...))
+		if score == 0 {
+			continue
+		}
+		// Scores are weighted so that the service decides, then the page.
+		score *= 10
+		rest := words[1:]
+		switch {
+		case len(rest) == 0 && p.Name == "":
+			score += scoreExact
+		case len(rest) == 0:
+		case p.Name == "":
+			continue
+		default:
+			pageScore := 0
+			for _, word := range rest {
+				s := bestScore(strings.ToLower(word), strings.Fields(p.Name))
+				if s == 0 {
+					pageScore = 0
+					break
+				}
+				pageScore += s
+			}
+			if pageScore == 0 {
+				continue
+			}
+			score += pageScore
+		}
+		matches = append(matches, Match{Page: p, Score: score})
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int { return b.Score - a.Score })
+	return matches
+}
+
+// bestScore scores word against the closest of names, or returns 0 when none match.
+func bestScore(word string, names []string) int {
+	best := 0
+	for _, name := range names {
+		best = max(best, wordScore(word, name))
+	}
+	return best
+}
+
+func wordScore(word, name string) int {
+	switch {
+	case word == name:
+		return scoreExact
+	case strings.HasPrefix(name, word):
+		return scorePrefix
+	case len(word) >= 4 && distance(word, name) <= max(1, len(word)/5):
+		return scoreTypo
+	case len(word) >= 3 && strings.Contains(name, word):
+		return scoreContains
+	}
+	return 0
+}
+
+// distance is the Levenshtein distance between a and b: the number of characters
+// inserted, deleted, or replaced to turn one into the other.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
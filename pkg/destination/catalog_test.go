@@ -0,0 +1,82 @@
+package destination
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		query []string
+		// want are the titles of the closest matches, tied for the best score.
+		want []string
+	}{
+		{query: []string{"s3"}, want: []string{"s3"}},
+		{query: []string{"S3", "buckets"}, want: []string{"s3 buckets"}},
+		{query: []string{"dynamodb", "tables"}, want: []string{"dynamodb tables"}},
+		{query: []string{"dynamo"}, want: []string{"dynamodb"}},
+		{query: []string{"ddb", "tab"}, want: []string{"dynamodb tables"}},
+		{query: []string{"lamda"}, want: []string{"lambda"}},
+		{query: []string{"ec2", "sec"}, want: []string{"ec2 security groups"}},
+		{query: []string{"ec2", "security", "groups"}, want: []string{"ec2 security groups"}},
+		{query: []string{"logs"}, want: []string{"logs"}},
+		{query: []string{"cloud"}, want: []string{"cloudformation", "cloudfront", "cloudshell", "cloudtrail", "cloudwatch"}},
+		{query: []string{"ec"}, want: []string{"ec2", "ecr", "ecs"}},
+		{query: []string{"s3", "tables"}},
+		{query: []string{"prod"}},
+		{query: nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(strings.Join(tc.query, " "), func(t *testing.T) {
+			t.Parallel()
+
+			matches := Search(tc.query)
+			var got []string
+			for _, m := range matches {
+				if m.Score == matches[0].Score {
+					got = append(got, m.Page.Title())
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCatalogDestinations(t *testing.T) {
+	t.Parallel()
+
+	seen := map[string]bool{}
+	for _, p := range Catalog() {
+		if seen[p.Title()] {
+			t.Fatalf("duplicate page %q", p.Title())
+		}
+		seen[p.Title()] = true
+		if _, err := Resolve(p.Destination, "aws", "us-east-1"); err != nil {
+			t.Fatalf("invalid destination of %q: %v", p.Title(), err)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"lamda", "lambda", 1},
+		{"cloudwatch", "cloudwacth", 2},
+		{"ec2", "ecs", 1},
+	} {
+		if got := distance(tc.a, tc.b); got != tc.want {
+			t.Fatalf("distance(%q, %q): expected %d, got %d", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
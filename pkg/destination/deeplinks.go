@@ -0,0 +1,104 @@
+package destination
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DeepLink is a page of a service UI embedded in the console that is reached by more
+// than a service name, such as a CloudWatch dashboard by its name. It is given as a
+// destination of the form "<name>:<argument>", as in dashboard:Prod-API.
+type DeepLink struct {
+	Name        string
+	Description string
+	// Arg describes the argument after the colon, for help text; empty when the page
+	// takes none.
+	Arg string
+	// path validates the argument and builds the console path of the page, relative to
+	// the console root.
+	path func(arg string) (string, error)
+}
+
+var (
+	dashboardNamePattern    = regexp.MustCompile(`^[A-Za-z0-9_-]{1,255}$`)
+	openSearchDomainPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{2,27}$`)
+	grafanaWorkspacePattern = regexp.MustCompile(`^g-[0-9a-f]{10}$`)
+)
+
+var deepLinks = []DeepLink{
+	{
+		Name:        "dashboard",
+		Description: "A CloudWatch dashboard, by name",
+		Arg:         "<dashboard>",
+		path: func(arg string) (string, error) {
+			if !dashboardNamePattern.MatchString(arg) {
+				return "", fmt.Errorf("invalid CloudWatch dashboard name %q (up to 255 letters, digits, - or _)", arg)
+			}
+			return "cloudwatch/home#dashboards/dashboard/" + url.PathEscape(arg), nil
+		},
+	},
+	{
+		Name:        "query-editor",
+		Description: "The Redshift query editor v2, signed in with the console session",
+		path:        func(string) (string, error) { return "sqlworkbench/home#/client", nil },
+	},
+	{
+		Name:        "opensearch",
+		Description: "An OpenSearch Service domain, with the link to its OpenSearch Dashboards",
+		Arg:         "<domain>",
+		path: func(arg string) (string, error) {
+			if !openSearchDomainPattern.MatchString(arg) {
+				return "", fmt.Errorf("invalid OpenSearch domain name %q (3 to 28 lowercase letters, digits, or hyphens, starting with a letter)", arg)
+			}
+			return "aos/home#opensearch/domains/" + arg, nil
+		},
+	},
+	{
+		Name:        "grafana",
+		Description: "An Amazon Managed Grafana workspace, by ID",
+		Arg:         "<workspace-id>",
+		path: func(arg string) (string, error) {
+			if !grafanaWorkspacePattern.MatchString(arg) {
+				return "", fmt.Errorf("invalid Grafana workspace ID %q (expected e.g. g-0123456789)", arg)
+			}
+			return "grafana/home#/workspaces/" + arg, nil
+		},
+	},
+}
+
+// DeepLinks returns the deep links destinations may name, in display order.
+func DeepLinks() []DeepLink {
+	return append([]DeepLink(nil), deepLinks...)
+}
+
+// Usage is how the deep link is written as a destination, such as "dashboard:<dashboard>".
+func (l DeepLink) Usage() string {
+	if l.Arg == "" {
+		return l.Name
+	}
+	return l.Name + ":" + l.Arg
+}
+
+// resolveDeepLink builds the console path of value when it names a deep link, as in
+// dashboard:Prod-API; ok is false for any other destination. The name of a deep link
+// that takes an argument is left to Normalize when given alone, so "grafana" still
+// opens the Grafana console.
+func resolveDeepLink(value string) (path string, ok bool, err error) {
+	name, arg, hasArg := strings.Cut(value, ":")
+	for _, l := range deepLinks {
+		if !strings.EqualFold(l.Name, name) || (l.Arg != "" && !hasArg) {
+			continue
+		}
+		switch {
+		case l.Arg != "" && arg == "":
+			return "", true, fmt.Errorf("the %s destination needs an argument, as in %s", l.Name, l.Usage())
+		case l.Arg == "" && arg != "":
+			return "", true, fmt.Errorf("the %s destination takes no argument, got %q", l.Name, arg)
+		}
+		path, err := l.path(arg)
+		return path, true, err
+	}
+	return "", false, nil
+}
@@ -0,0 +1,59 @@
+package destination
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveDeepLink(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value         string
+		want          string
+		wantErrSubstr string
+	}{
+		{value: "dashboard:Prod-API", want: "cloudwatch/home#dashboards/dashboard/Prod-API"},
+		{value: "Dashboard:service_latency", want: "cloudwatch/home#dashboards/dashboard/service_latency"},
+		{value: "dashboard:prod api", wantErrSubstr: `invalid CloudWatch dashboard name "prod api"`},
+		{value: "dashboard:", wantErrSubstr: "the dashboard destination needs an argument, as in dashboard:<dashboard>"},
+		{value: "query-editor", want: "sqlworkbench/home#/client"},
+		{value: "query-editor:analytics", wantErrSubstr: `the query-editor destination takes no argument, got "analytics"`},
+		{value: "opensearch:logs-prod", want: "aos/home#opensearch/domains/logs-prod"},
+		{value: "opensearch:Logs", wantErrSubstr: `invalid OpenSearch domain name "Logs"`},
+		{value: "opensearch:../../iam", wantErrSubstr: "invalid OpenSearch domain name"},
+		{value: "grafana:g-0123456789", want: "grafana/home#/workspaces/g-0123456789"},
+		{value: "grafana:0123456789", wantErrSubstr: `invalid Grafana workspace ID "0123456789"`},
+		{value: "grafana", want: "grafana/home"},
+		{value: "cloudwatch/home#alarmsV2:", want: "cloudwatch/home#alarmsV2:"},
+	}
+
+	for _, tc := range testCases {
+		got, err := Resolve(tc.value, "aws", "us-east-1")
+		if tc.wantErrSubstr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("Resolve(%q): expected error containing %q, got %v", tc.value, tc.wantErrSubstr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Resolve(%q): unexpected error: %v", tc.value, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Resolve(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestDeepLinkUsage(t *testing.T) {
+	t.Parallel()
+
+	var usages []string
+	for _, l := range DeepLinks() {
+		usages = append(usages, l.Usage())
+	}
+	want := "dashboard:<dashboard>,query-editor,opensearch:<domain>,grafana:<workspace-id>"
+	if got := strings.Join(usages, ","); got != want {
+		t.Fatalf("unexpected usages %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,234 @@
+package destination
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Shortcut is a named console page that can be opened directly.
+type Shortcut struct {
+	Name        string
+	Description string
+	// Args describes the optional positional arguments, for help text.
+	Args string
+	// MaxArgs is the number of positional arguments the shortcut accepts.
+	MaxArgs int
+	// Regional pages open in the selected region rather than the console's last-used one.
+	Regional bool
+	// path builds the console path, relative to the console root, from the arguments.
+	path func(args []string) (string, error)
+}
+
+// Path returns the console path for the shortcut, relative to the console root.
+func (s Shortcut) Path(args []string) (string, error) {
+	if len(args) > s.MaxArgs {
+		return "", fmt.Errorf("%s accepts at most %d argument(s), got %d", s.Name, s.MaxArgs, len(args))
+	}
+	return s.path(args)
+}
+
+var (
+	serviceCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	regionPattern      = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+)
+
+var shortcuts = []Shortcut{
+	{
+		Name:        "health",
+		Description: "Open the AWS Health Dashboard",
+		path:        staticPath("health/home#/account/dashboard/open-issues"),
+	},
+	{
+		Name:        "trusted-advisor",
+		Description: "Open the Trusted Advisor console",
+		path:        staticPath("trustedadvisor/home"),
+	},
+	{
+		Name:        "quotas",
+		Description: "Open Service Quotas, optionally for a single service (e.g. ec2, lambda)",
+		Args:        "[service]",
+		MaxArgs:     1,
+		path: func(args []string) (string, error) {
+			if len(args) == 0 {
+				return "servicequotas/home", nil
+			}
+			service := args[0]
+			if !serviceCodePattern.MatchString(service) {
+				return "", fmt.Errorf("invalid service code %q (expected e.g. ec2, lambda, dynamodb)", service)
+			}
+			return "servicequotas/home/services/" + service + "/quotas", nil
+		},
+	},
+	{
+		Name:        "cloudshell",
+		Description: "Open AWS CloudShell in the selected region",
+		Regional:    true,
+		path:        staticPath("cloudshell/home"),
+	},
+}
+
+func staticPath(path string) func([]string) (string, error) {
+	return func([]string) (string, error) {
+		return path, nil
+	}
+}
+
+// ValidateRegion checks that region looks like an AWS region code such as us-east-1.
+func ValidateRegion(region string) error {
+	if !regionPattern.MatchString(region) {
+		return fmt.Errorf("invalid region %q (expected e.g. us-east-1, eu-west-1)", region)
+	}
+	return nil
+}
+
+// WithRegion adds a region query parameter to a console path, ahead of any fragment.
+// An empty region leaves the path unchanged.
+func WithRegion(path, region string) string {
+	if region == "" {
+		return path
+	}
+
+	fragment := ""
+	if i := strings.Index(path, "#"); i >= 0 {
+		path, fragment = path[:i], path[i:]
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "region=" + url.QueryEscape(region) + fragment
+}
+
+// serviceAliases maps service shorthands to their console paths where the path is not
+// simply "<service>/home".
+var serviceAliases = map[string]string{
+	"cw":             "cloudwatch/home",
+	"logs":           "cloudwatch/home#logsV2:log-groups",
+	"ddb":            "dynamodbv2/home",
+	"dynamodb":       "dynamodbv2/home",
+	"cfn":            "cloudformation/home",
+	"secrets":        "secretsmanager/home",
+	"route53":        "route53/v2/home",
+	"sso":            "singlesignon/home",
+	"identitycenter": "singlesignon/home",
+	"ecs":            "ecs/v2/home",
+	"sqs":            "sqs/v3/home",
+	"sns":            "sns/v3/home",
+	"iam":            "iam/home#/home",
+	"billing":        "billing/home",
+	"cost-explorer":  "costmanagement/home#/cost-explorer",
+}
+
+// consoleHostSuffixes are the hosts absolute destinations may point at, including
+// regional console hosts such as us-west-2.console.aws.amazon.com, and the hosts of
+// services with consoles of their own.
+var consoleHostSuffixes = []string{"console.aws.amazon.com", "console.amazonaws-us-gov.com", "console.amazonaws.cn", quickSightHost}
+
+// quickSightHost is the host of the QuickSight console, which is not part of the AWS
+// console. It is only available in the aws partition.
+const quickSightHost = "quicksight.aws.amazon.com"
+
+// service is a destination that needs more than a path on the console host.
+type service struct {
+	// url builds the destination for partition and region, either of which may be
+	// empty: a console path, or an absolute URL.
+	url func(partition, region string) (string, error)
+}
+
+// services are the destinations resolved by Resolve, by name.
+var services = map[string]service{
+	// CloudShell opens in the console's last-used region unless it is pinned.
+	"cloudshell": {url: func(partition, region string) (string, error) {
+		return WithRegion("cloudshell/home", region), nil
+	}},
+	"quicksight": {url: func(partition, region string) (string, error) {
+		if partition != "" && partition != "aws" {
+			return "", fmt.Errorf("the quicksight destination is not available in partition %q", partition)
+		}
+		host := quickSightHost
+		if region != "" {
+			host = region + "." + host
+		}
+		return "https://" + host + "/sn/start", nil
+	}},
+}
+
+// Resolve is Normalize for a destination opened in partition and region, either of
+// which may be empty. Services that need special handling, such as CloudShell, which
+// is pinned to region, and QuickSight, which has a host of its own, are resolved
+// against them, and deep links such as dashboard:<name> are built from their argument.
+func Resolve(value, partition, region string) (string, error) {
+	if path, ok, err := resolveDeepLink(strings.TrimSpace(value)); ok {
+		return path, err
+	}
+	if s, ok := services[strings.ToLower(strings.TrimSpace(value))]; ok {
+		if region != "" {
+			if err := ValidateRegion(region); err != nil {
+				return "", err
+			}
+		}
+		return s.url(partition, region)
+	}
+	return Normalize(value)
+}
+
+// Normalize turns a --destination value into a console path relative to the console
+// root, or an absolute console URL. It accepts a service shorthand such as "ec2" or
+// "cw", a console path such as "s3/buckets/my-bucket", or a full console URL.
+func Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme != "https" || !consoleHost(u.Hostname()) {
+			return "", fmt.Errorf("invalid destination %q (URLs must point at the AWS console, e.g. https://console.aws.amazon.com/ec2/home)", value)
+		}
+		return value, nil
+	}
+
+	path := strings.TrimPrefix(value, "/")
+	if strings.ContainsAny(path, " \t\n\r") || strings.Contains(path, "..") {
+		return "", fmt.Errorf("invalid destination %q", value)
+	}
+	if strings.ContainsAny(path, "/?#") {
+		return path, nil
+	}
+
+	service := strings.ToLower(path)
+	if alias, ok := serviceAliases[service]; ok {
+		return alias, nil
+	}
+	if !serviceCodePattern.MatchString(service) {
+		return "", fmt.Errorf("invalid destination %q (expected a service such as ec2 or s3, a console path, or a console URL)", value)
+	}
+	return service + "/home", nil
+}
+
+func consoleHost(host string) bool {
+	for _, suffix := range consoleHostSuffixes {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Shortcuts returns the built-in shortcuts in display order.
+func Shortcuts() []Shortcut {
+	return append([]Shortcut(nil), shortcuts...)
+}
+
+// LookupShortcut finds a built-in shortcut by name.
+func LookupShortcut(name string) (Shortcut, bool) {
+	for _, s := range shortcuts {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Shortcut{}, false
+}
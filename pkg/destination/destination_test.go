@@ -0,0 +1,181 @@
+package destination
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShortcutPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		shortcut      string
+		args          []string
+		wantPath      string
+		wantErrSubstr string
+	}{
+		{name: "health", shortcut: "health", wantPath: "health/home#/account/dashboard/open-issues"},
+		{name: "trusted advisor", shortcut: "trusted-advisor", wantPath: "trustedadvisor/home"},
+		{name: "quotas home", shortcut: "quotas", wantPath: "servicequotas/home"},
+		{name: "quotas for service", shortcut: "quotas", args: []string{"ec2"}, wantPath: "servicequotas/home/services/ec2/quotas"},
+		{name: "quotas invalid service", shortcut: "quotas", args: []string{"EC2/../x"}, wantErrSubstr: `invalid service code "EC2/../x"`},
+		{name: "cloudshell", shortcut: "cloudshell", wantPath: "cloudshell/home"},
+		{name: "too many args", shortcut: "health", args: []string{"extra"}, wantErrSubstr: "health accepts at most 0 argument(s), got 1"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			shortcut, ok := LookupShortcut(tc.shortcut)
+			if !ok {
+				t.Fatalf("shortcut %q not found", tc.shortcut)
+			}
+
+			path, err := shortcut.Path(tc.args)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if path != tc.wantPath {
+				t.Fatalf("expected path %q, got %q", tc.wantPath, path)
+			}
+		})
+	}
+}
+
+func TestLookupShortcutUnknown(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := LookupShortcut("nope"); ok {
+		t.Fatal("expected unknown shortcut lookup to fail")
+	}
+}
+
+func TestWithRegion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path   string
+		region string
+		want   string
+	}{
+		{path: "cloudshell/home", region: "", want: "cloudshell/home"},
+		{path: "cloudshell/home", region: "eu-west-1", want: "cloudshell/home?region=eu-west-1"},
+		{path: "ec2/home?tab=a", region: "us-east-2", want: "ec2/home?tab=a&region=us-east-2"},
+		{path: "health/home#/account", region: "us-east-1", want: "health/home?region=us-east-1#/account"},
+	}
+
+	for _, tc := range testCases {
+		if got := WithRegion(tc.path, tc.region); got != tc.want {
+			t.Fatalf("WithRegion(%q, %q) = %q, want %q", tc.path, tc.region, got, tc.want)
+		}
+	}
+}
+
+func TestValidateRegion(t *testing.T) {
+	t.Parallel()
+
+	for _, region := range []string{"us-east-1", "eu-central-2", "us-gov-west-1", "cn-northwest-1", "ap-southeast-4"} {
+		if err := ValidateRegion(region); err != nil {
+			t.Fatalf("expected %q to be valid, got %v", region, err)
+		}
+	}
+	for _, region := range []string{"", "us-east", "US-EAST-1", "mars-1", "us-east-1/../x"} {
+		if err := ValidateRegion(region); err == nil {
+			t.Fatalf("expected %q to be invalid", region)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value         string
+		want          string
+		wantErrSubstr string
+	}{
+		{value: "", want: ""},
+		{value: "ec2", want: "ec2/home"},
+		{value: "S3", want: "s3/home"},
+		{value: "cloudwatch", want: "cloudwatch/home"},
+		{value: "cw", want: "cloudwatch/home"},
+		{value: "billing", want: "billing/home"},
+		{value: "cost-explorer", want: "costmanagement/home#/cost-explorer"},
+		{value: "dynamodb", want: "dynamodbv2/home"},
+		{value: "s3/buckets/my-bucket", want: "s3/buckets/my-bucket"},
+		{value: "/lambda/home#/functions", want: "lambda/home#/functions"},
+		{value: "ec2/home?region=us-west-2#Instances:", want: "ec2/home?region=us-west-2#Instances:"},
+		{value: "https://us-west-2.console.aws.amazon.com/ec2/home", want: "https://us-west-2.console.aws.amazon.com/ec2/home"},
+		{value: "https://console.amazonaws-us-gov.com/s3/home", want: "https://console.amazonaws-us-gov.com/s3/home"},
+		{value: "https://eu-west-1.quicksight.aws.amazon.com/sn/start", want: "https://eu-west-1.quicksight.aws.amazon.com/sn/start"},
+		{value: "https://evil.example.com/console.aws.amazon.com", wantErrSubstr: "must point at the AWS console"},
+		{value: "http://console.aws.amazon.com/ec2/home", wantErrSubstr: "must point at the AWS console"},
+		{value: "s3/../../etc", wantErrSubstr: `invalid destination "s3/../../etc"`},
+		{value: "my service", wantErrSubstr: "invalid destination"},
+		{value: "ec2!", wantErrSubstr: "expected a service such as ec2 or s3"},
+	}
+
+	for _, tc := range testCases {
+		got, err := Normalize(tc.value)
+		if tc.wantErrSubstr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("Normalize(%q): expected error containing %q, got %v", tc.value, tc.wantErrSubstr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Normalize(%q): unexpected error: %v", tc.value, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Normalize(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value         string
+		partition     string
+		region        string
+		want          string
+		wantErrSubstr string
+	}{
+		{value: "cloudshell", want: "cloudshell/home"},
+		{value: "cloudshell", region: "eu-west-1", want: "cloudshell/home?region=eu-west-1"},
+		{value: "CloudShell", partition: "aws-us-gov", region: "us-gov-west-1", want: "cloudshell/home?region=us-gov-west-1"},
+		{value: "quicksight", want: "https://quicksight.aws.amazon.com/sn/start"},
+		{value: "quicksight", partition: "aws", region: "us-east-1", want: "https://us-east-1.quicksight.aws.amazon.com/sn/start"},
+		{value: "quicksight", partition: "aws-cn", wantErrSubstr: `not available in partition "aws-cn"`},
+		{value: "quicksight", region: "evil.example.com/", wantErrSubstr: "invalid region"},
+		{value: "ec2", region: "us-west-2", want: "ec2/home"},
+		{value: "cloudshell/home", region: "us-west-2", want: "cloudshell/home"},
+		{value: "ec2!", wantErrSubstr: "expected a service such as ec2 or s3"},
+	}
+
+	for _, tc := range testCases {
+		got, err := Resolve(tc.value, tc.partition, tc.region)
+		if tc.wantErrSubstr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("Resolve(%q): expected error containing %q, got %v", tc.value, tc.wantErrSubstr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Resolve(%q): unexpected error: %v", tc.value, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Resolve(%q, %q, %q) = %q, want %q", tc.value, tc.partition, tc.region, got, tc.want)
+		}
+	}
+}
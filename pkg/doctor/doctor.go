@@ -0,0 +1,83 @@
+// Package doctor runs diagnostic checks of the environment aws-console runs in, and
+// reports what each found along with how to fix what is wrong.
+package doctor
+
+import (
+	"context"
+	"sync"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Result is what a check found. Fix says how to resolve a warning or failure.
+type Result struct {
+	Status Status
+	Detail string
+	Fix    string
+}
+
+// OK reports a check that passed.
+func OK(detail string) Result {
+	return Result{Status: StatusOK, Detail: detail}
+}
+
+// Warn reports a problem that does not stop aws-console from working.
+func Warn(detail, fix string) Result {
+	return Result{Status: StatusWarn, Detail: detail, Fix: fix}
+}
+
+// Fail reports a problem that stops aws-console from working.
+func Fail(detail, fix string) Result {
+	return Result{Status: StatusFail, Detail: detail, Fix: fix}
+}
+
+// Skip reports a check that does not apply.
+func Skip(reason string) Result {
+	return Result{Status: StatusSkip, Detail: reason}
+}
+
+// Check is one diagnostic. Run must be safe to call concurrently with other checks.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Result
+}
+
+// Report is the result of a named check.
+type Report struct {
+	Check string
+	Result
+}
+
+// Run runs checks concurrently and returns their reports in the order of checks.
+func Run(ctx context.Context, checks []Check) []Report {
+	reports := make([]Report, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i] = Report{Check: c.Name, Result: c.Run(ctx)}
+		}()
+	}
+	wg.Wait()
+	return reports
+}
+
+// Failed counts the reports whose check failed.
+func Failed(reports []Report) int {
+	n := 0
+	for _, r := range reports {
+		if r.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package doctor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	// Every check waits for the others to start, so they must run concurrently.
+	var started sync.WaitGroup
+	started.Add(3)
+	wait := func(r Result) func(context.Context) Result {
+		return func(ctx context.Context) Result {
+			started.Done()
+			started.Wait()
+			return r
+		}
+	}
+	checks := []Check{
+		{Name: "first", Run: wait(OK("fine"))},
+		{Name: "second", Run: wait(Fail("broken", "fix it"))},
+		{Name: "third", Run: wait(Warn("odd", "look at it"))},
+	}
+
+	done := make(chan []Report, 1)
+	go func() { done <- Run(context.Background(), checks) }()
+	var reports []Report
+	select {
+	case reports = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the checks did not run concurrently")
+	}
+
+	want := []Report{
+		{Check: "first", Result: Result{Status: StatusOK, Detail: "fine"}},
+		{Check: "second", Result: Result{Status: StatusFail, Detail: "broken", Fix: "fix it"}},
+		{Check: "third", Result: Result{Status: StatusWarn, Detail: "odd", Fix: "look at it"}},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("expected %d reports, got %+v", len(want), reports)
+	}
+	for i := range want {
+		if reports[i] != want[i] {
+			t.Fatalf("report %d: expected %+v, got %+v", i, want[i], reports[i])
+		}
+	}
+	if got := Failed(reports); got != 1 {
+		t.Fatalf("expected 1 failure, got %d", got)
+	}
+}
@@ -0,0 +1,183 @@
+// Package history keeps a local log of the console sessions aws-console has opened, so
+// that recently used profiles and accounts can be looked up. Nothing leaves the machine.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// FileName is the name of the history file within the state directory.
+const FileName = "history.jsonl"
+
+// MaxEntries bounds the history; the oldest entries are dropped first.
+const MaxEntries = 1000
+
+// Entry records one console sign-in.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Profile     string    `json:"profile,omitempty"`
+	Account     string    `json:"account,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+}
+
+// Filter selects entries. Zero fields match everything.
+type Filter struct {
+	// Profiles and Accounts match any of the given values.
+	Profiles []string
+	Accounts []string
+	// Since drops entries older than it.
+	Since time.Time
+	// Limit keeps only the most recent entries.
+	Limit int
+}
+
+func (f Filter) match(e Entry) bool {
+	if len(f.Profiles) > 0 && !slices.Contains(f.Profiles, e.Profile) {
+		return false
+	}
+	if len(f.Accounts) > 0 && !slices.Contains(f.Accounts, e.Account) {
+		return false
+	}
+	return f.Since.IsZero() || !e.Time.Before(f.Since)
+}
+
+// Store appends entries to a JSON Lines file, one object per line.
+type Store struct {
+	path string
+	// mu serializes appends from concurrent workflows.
+	mu sync.Mutex
+}
+
+// NewStore creates a store backed by history.jsonl in the aws-console state directory.
+func NewStore() *Store {
+	dir, err := paths.StateDir()
+	if err != nil {
+		return NewStoreAt("")
+	}
+	return NewStoreAt(filepath.Join(dir, FileName))
+}
+
+// NewStoreAt creates a store backed by the given file. An empty path disables persistence.
+func NewStoreAt(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the backing file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append adds e to the end of the history, dropping the oldest entries beyond
+// MaxEntries.
+func (s *Store) Append(e Entry) error {
+	if s.path == "" {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+	if bytes.Count(data, []byte{'\n'}) >= MaxEntries {
+		return s.rewrite(append(newest(data, MaxEntries-1), line...))
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// newest returns the last n complete lines of data.
+func newest(data []byte, n int) []byte {
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	start := len(data)
+	for ; n > 0 && start > 0; n-- {
+		start = bytes.LastIndexByte(data[:start-1], '\n') + 1
+	}
+	return data[start:]
+}
+
+// rewrite replaces the history with data.
+func (s *Store) rewrite(data []byte) error {
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching f, newest first. Lines that cannot be parsed, such
+// as one cut short by a crash, are skipped. A missing file yields no entries.
+func (s *Store) Query(f Filter) ([]Entry, error) {
+	if s.path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if f.match(e) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history %s: %w", s.path, err)
+	}
+
+	// Entries are appended as they happen, so reversing puts the latest first; the sort
+	// only moves entries recorded out of order, such as after a clock change.
+	slices.Reverse(entries)
+	slices.SortStableFunc(entries, func(a, b Entry) int { return b.Time.Compare(a.Time) })
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[:f.Limit]
+	}
+	return entries, nil
+}
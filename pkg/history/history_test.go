@@ -0,0 +1,142 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreAppendAndQuery(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "history.jsonl")
+	store := NewStoreAt(path)
+
+	entries, err := store.Query(Filter{})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected no entries from a missing file, got %+v (%v)", entries, err)
+	}
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i, e := range []Entry{
+		{Profile: "dev", Account: "123456789012"},
+		{Profile: "prod", Account: "210987654321", Region: "eu-west-1"},
+		{Profile: "dev", Account: "123456789012", Destination: "s3"},
+	} {
+		e.Time = start.Add(time.Duration(i) * time.Hour)
+		if err := store.Append(e); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// A line cut short by a crash is skipped.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open history: %v", err)
+	}
+	f.WriteString(`{"time":"2025-01-01T`)
+	f.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat history: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected owner-only permissions, got %v", perm)
+	}
+
+	testCases := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "all, newest first", want: []string{"dev/s3", "prod/", "dev/"}},
+		{name: "by profile", filter: Filter{Profiles: []string{"dev"}}, want: []string{"dev/s3", "dev/"}},
+		{name: "by account", filter: Filter{Accounts: []string{"210987654321"}}, want: []string{"prod/"}},
+		{name: "since", filter: Filter{Since: start.Add(time.Hour)}, want: []string{"dev/s3", "prod/"}},
+		{name: "limit", filter: Filter{Limit: 1}, want: []string{"dev/s3"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := store.Query(tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Profile+"/"+e.Destination)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreWithoutPath(t *testing.T) {
+	t.Parallel()
+
+	store := NewStoreAt("")
+	if err := store.Append(Entry{Profile: "dev", Time: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, err := store.Query(Filter{}); err != nil || len(entries) != 0 {
+		t.Fatalf("expected no entries, got %+v (%v)", entries, err)
+	}
+}
+
+func TestStoreAppendDropsOldest(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	var data []byte
+	for i := 0; i < MaxEntries; i++ {
+		line, err := json.Marshal(Entry{Time: start.Add(time.Duration(i) * time.Minute), Profile: "old"})
+		if err != nil {
+			t.Fatalf("failed to encode entry: %v", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write history: %v", err)
+	}
+
+	store := NewStoreAt(path)
+	for i := 0; i < 2; i++ {
+		if err := store.Append(Entry{Time: start.Add(time.Duration(MaxEntries+i) * time.Minute), Profile: "new"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	entries, err := store.Query(Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != MaxEntries {
+		t.Fatalf("expected %d entries, got %d", MaxEntries, len(entries))
+	}
+	if entries[0].Profile != "new" || entries[1].Profile != "new" {
+		t.Fatalf("expected the new entries first, got %+v", entries[:2])
+	}
+	if oldest := entries[len(entries)-1].Time; !oldest.Equal(start.Add(2 * time.Minute)) {
+		t.Fatalf("expected the two oldest entries dropped, got oldest %s", oldest)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat history: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected owner-only permissions, got %v", perm)
+	}
+}
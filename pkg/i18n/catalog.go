@@ -0,0 +1,162 @@
+package i18n
+
+// catalog maps the English format string of each translated message to its
+// translation. English needs no entries.
+var catalog = map[Language]map[string]string{
+	Japanese: {
+		// Sign-in workflow.
+		"Authenticated as: %s\n": "認証済み: %s\n",
+		"Account: %s\n":          "アカウント: %s\n",
+		"Warning: %v\n":          "警告: %v\n",
+		"Warning: --partition %s differs from the partition of %s\n":                                                          "警告: --partition %s は %s のパーティションと異なります\n",
+		"Warning: the console session is limited to %s, when the role credentials of %s expire\n":                             "警告: %[2]s のロールの認証情報が失効するため、コンソールセッションは %[1]s に制限されます\n",
+		"Console session valid for %s\n":                                                                                      "コンソールセッションの有効期間: %s\n",
+		"Access: read-only (%s session policy)\n":                                                                             "アクセス: 読み取り専用（%s セッションポリシー）\n",
+		"Access: limited by a session policy":                                                                                 "アクセス: セッションポリシーで制限されています",
+		"Copied the sign-in URL to the clipboard.":                                                                            "サインイン URL をクリップボードにコピーしました。",
+		"No display found; printing the sign-in URL instead of opening a browser (pass --headless=false to open one anyway).": "ディスプレイが見つからないため、ブラウザーを開く代わりにサインイン URL を表示します (それでも開くには --headless=false を指定してください)。",
+		"QR codes are not shown in accessible mode; printing the sign-in URL instead.":                                        "アクセシブルモードでは QR コードを表示しません。代わりにサインイン URL を表示します。",
+		"Opening AWS Console in %s in your browser...\n":                                                                      "ブラウザで %s の AWS コンソールを開いています...\n",
+		"Opening AWS Console in your browser...":                                                                              "ブラウザで AWS コンソールを開いています...",
+		"Warning: %s; opening the console as it is usually a compliance violation.\n":                                         "警告: %s。このままコンソールを開くことは、通常コンプライアンス違反になります。\n",
+		"Open the console anyway? [y/N] ":                                                                                     "それでもコンソールを開きますか？ [y/N] ",
+		"Warning: IAM Identity Center failed for %s (%v); the fallback profile %s can sign in instead.\n":                     "警告: %s の IAM Identity Center でのサインインに失敗しました（%v）。代わりにフォールバックプロファイル %s でサインインできます。\n",
+		"Sign in with %s? [y/N] ":                                                                                             "%s でサインインしますか？ [y/N] ",
+		"Fallback profile %s failed too: %v\n":                                                                                "フォールバックプロファイル %s も失敗しました: %v\n",
+		"Warning: the fallback profile %s of %s is not in the AWS config\n":                                                   "警告: %[2]s のフォールバックプロファイル %[1]s が AWS の設定にありません\n",
+		"Signed in with the fallback profile %s instead of %s; this console session bypasses IAM Identity Center.\n":          "%[2]s の代わりにフォールバックプロファイル %[1]s でサインインしました。このコンソールセッションは IAM Identity Center を経由しません。\n",
+		"Sharing a read-only %s console session of %s with %s\n":                                                              "読み取り専用の %s のコンソールセッション（%s）を %s と共有します\n",
+		"Sharing a %s console session of %s with %s, limited by a session policy\n":                                           "セッションポリシーで制限した %s のコンソールセッション（%s）を %s と共有します\n",
+		"Share it? [y/N] ": "共有しますか? [y/N] ",
+		"The sign-in URL is not single-use: for 15 minutes, anyone who sees it can sign in as this session\n": "サインイン URL は一度限りではありません。15 分間は、URL を見た人は誰でもこのセッションとしてサインインできます\n",
+		"MFA code for %s: ":                   "%s の MFA コード: ",
+		"Signing in to SAML provider %s...\n": "SAML プロバイダー %s にサインインしています...\n",
+		"Username for %s: ":                   "%s のユーザー名: ",
+		"Password for %s: ":                   "%s のパスワード: ",
+		"Warning: the sign-in URL is sent to %s; until the short link is used or expires, anyone in control of that redirector can open this console session\n": "警告: サインイン URL は %s に送信されます。短縮リンクが使用されるか失効するまで、そのリダイレクターを管理できる人は誰でもこのコンソールセッションを開けます\n",
+
+		// SSO login.
+		"%s, attempting SSO login...\n":                      "%s。SSO ログインを試みます...\n",
+		"No cached SSO token for %s":                         "%s のキャッシュされた SSO トークンがありません",
+		"The SSO token for %s expired at %s":                 "%s の SSO トークンは %s に失効しました",
+		"Credentials are not valid, attempting SSO login...": "認証情報が無効です。SSO ログインを試みます...",
+		"Signed in to %s\n":                                  "%s にサインインしました\n",
+		"Approve the sign-in request in your browser. If it does not open, visit:\n\n  %s\n\nand confirm the code %s.\n": "ブラウザでサインイン要求を承認してください。ブラウザが開かない場合は次の URL にアクセスし、\n\n  %s\n\nコード %s を確認してください。\n",
+		"Warning: failed to open browser: %v\n": "警告: ブラウザを開けませんでした: %v\n",
+
+		// Pickers.
+		"profile":      "プロファイル",
+		"account":      "アカウント",
+		"role":         "ロール",
+		"console page": "コンソールページ",
+		"Select a %s (number or search, empty to cancel): ": "%sを選択してください（番号または検索語、空欄でキャンセル）: ",
+		"Enter a number between 1 and %d.\n":                "1 から %d までの番号を入力してください。\n",
+		"No %ss match %q.\n":                                "%[2]q に一致する%[1]sはありません。\n",
+		"action":                                            "操作",
+		"Choices (%d):\n":                                   "選択肢 (%d 件):\n",
+		"%d of %d: %s\n":                                    "%[1]d 件目 (全 %[2]d 件): %[3]s\n",
+		"Type the number of a %s, or letters to search; enter nothing to cancel: ": "%sの番号、または検索する文字を入力してください。何も入力しないとキャンセルします: ",
+
+		// Errors.
+		"Error: %v": "エラー: %v",
+		"profile %q not found in AWS config (run 'aws-console list' to see configured profiles)": "プロファイル %q が AWS の設定に見つかりません（設定済みのプロファイルは 'aws-console list' で確認できます）",
+		"profile %q not found in AWS config":                                                     "プロファイル %q が AWS の設定に見つかりません",
+		"failed to list profiles: %w":                                                            "プロファイルを一覧表示できませんでした: %w",
+		"no profile given: name one as an argument or with --profile":                            "プロファイルが指定されていません。引数または --profile で指定してください",
+		"--profile can only be repeated when opening the console":                                "--profile を複数回指定できるのはコンソールを開くときだけです",
+		"conflicting profiles: %q given as an argument but --profile is %q":                      "プロファイルが矛盾しています: 引数では %q ですが、--profile は %q です",
+		"a console page cannot be given both as arguments and with --destination or --service":   "コンソールページは、引数と --destination または --service の両方では指定できません",
+		"%s needs an SSO login; run 'aws-console %s' to sign in":                                 "%s には SSO ログインが必要です。'aws-console %s' を実行してサインインしてください",
+		"SSO login failed: %w":                                "SSO ログインに失敗しました: %w",
+		"credentials still invalid after SSO login: %w":       "SSO ログイン後も認証情報が無効です: %w",
+		"failed to reach AWS to check credentials for %s: %w": "%s の認証情報を確認するために AWS に接続できませんでした: %w",
+		"failed to check credentials for %s: %w":              "%s の認証情報を確認できませんでした: %w",
+		"failed to copy the sign-in URL to the clipboard: %w": "サインイン URL をクリップボードにコピーできませんでした: %w",
+		"failed to render the sign-in URL as a QR code: %w":   "サインイン URL を QR コードに変換できませんでした: %w",
+		"interrupted: %w":                                  "中断されました: %w",
+		"gave up after the %s deadline: %w":                "期限の %s を過ぎたため中止しました: %w",
+		"unknown setting %q in config file %s":             "設定ファイル %[2]s に不明な設定 %[1]q があります",
+		"invalid %s setting: %w":                           "%s の設定が無効です: %w",
+		"unsupported language %q (expected en, ja, or de)": "サポートされていない言語 %q です（en、ja、de のいずれかを指定してください）",
+		"--quiet cannot be used with --verbose or --debug": "--quiet は --verbose や --debug と同時に使用できません",
+		"--read-only cannot be combined with --%s":         "--read-only は --%s と同時に指定できません",
+	},
+	German: {
+		// Sign-in workflow.
+		"Authenticated as: %s\n": "Angemeldet als: %s\n",
+		"Account: %s\n":          "Konto: %s\n",
+		"Warning: %v\n":          "Warnung: %v\n",
+		"Warning: --partition %s differs from the partition of %s\n":                                                          "Warnung: --partition %s weicht von der Partition von %s ab\n",
+		"Warning: the console session is limited to %s, when the role credentials of %s expire\n":                             "Warnung: Die Konsolensitzung ist auf %s begrenzt, da dann die Rollen-Anmeldedaten von %s ablaufen\n",
+		"Console session valid for %s\n":                                                                                      "Konsolensitzung gültig für %s\n",
+		"Access: read-only (%s session policy)\n":                                                                             "Zugriff: nur lesend (Sitzungsrichtlinie %s)\n",
+		"Access: limited by a session policy":                                                                                 "Zugriff: durch eine Sitzungsrichtlinie eingeschränkt",
+		"Copied the sign-in URL to the clipboard.":                                                                            "Die Anmelde-URL wurde in die Zwischenablage kopiert.",
+		"No display found; printing the sign-in URL instead of opening a browser (pass --headless=false to open one anyway).": "Kein Display gefunden; die Anmelde-URL wird ausgegeben, statt einen Browser zu öffnen (mit --headless=false wird trotzdem einer geöffnet).",
+		"QR codes are not shown in accessible mode; printing the sign-in URL instead.":                                        "Im barrierefreien Modus werden keine QR-Codes angezeigt; stattdessen wird die Anmelde-URL ausgegeben.",
+		"Opening AWS Console in %s in your browser...\n":                                                                      "AWS-Konsole in %s wird im Browser geöffnet...\n",
+		"Opening AWS Console in your browser...":                                                                              "AWS-Konsole wird im Browser geöffnet...",
+		"Warning: %s; opening the console as it is usually a compliance violation.\n":                                         "Warnung: %s; die Konsole so zu öffnen, verstößt in der Regel gegen Compliance-Vorgaben.\n",
+		"Open the console anyway? [y/N] ":                                                                                     "Konsole trotzdem öffnen? [y/N] ",
+		"Warning: IAM Identity Center failed for %s (%v); the fallback profile %s can sign in instead.\n":                     "Warnung: IAM Identity Center ist für %s fehlgeschlagen (%v); stattdessen kann das Fallback-Profil %s anmelden.\n",
+		"Sign in with %s? [y/N] ":                                                                                             "Mit %s anmelden? [y/N] ",
+		"Fallback profile %s failed too: %v\n":                                                                                "Auch das Fallback-Profil %s ist fehlgeschlagen: %v\n",
+		"Warning: the fallback profile %s of %s is not in the AWS config\n":                                                   "Warnung: Das Fallback-Profil %s von %s steht nicht in der AWS-Konfiguration\n",
+		"Signed in with the fallback profile %s instead of %s; this console session bypasses IAM Identity Center.\n":          "Mit dem Fallback-Profil %s statt %s angemeldet; diese Konsolensitzung umgeht IAM Identity Center.\n",
+		"Sharing a read-only %s console session of %s with %s\n":                                                              "Eine schreibgeschützte Konsolensitzung von %s als %s wird mit %s geteilt\n",
+		"Sharing a %s console session of %s with %s, limited by a session policy\n":                                           "Eine durch eine Sitzungsrichtlinie eingeschränkte Konsolensitzung von %s als %s wird mit %s geteilt\n",
+		"Share it? [y/N] ": "Teilen? [y/N] ",
+		"The sign-in URL is not single-use: for 15 minutes, anyone who sees it can sign in as this session\n": "Die Anmelde-URL ist nicht einmalig: 15 Minuten lang kann sich jeder, der sie sieht, als diese Sitzung anmelden\n",
+		"MFA code for %s: ":                   "MFA-Code für %s: ",
+		"Signing in to SAML provider %s...\n": "Anmeldung beim SAML-Anbieter %s...\n",
+		"Username for %s: ":                   "Benutzername für %s: ",
+		"Password for %s: ":                   "Passwort für %s: ",
+		"Warning: the sign-in URL is sent to %s; until the short link is used or expires, anyone in control of that redirector can open this console session\n": "Warnung: Die Anmelde-URL wird an %s gesendet; bis der Kurzlink verwendet wird oder abläuft, kann jeder, der diesen Weiterleitungsdienst kontrolliert, diese Konsolensitzung öffnen\n",
+
+		// SSO login.
+		"%s, attempting SSO login...\n":                      "%s, SSO-Anmeldung wird versucht...\n",
+		"No cached SSO token for %s":                         "Kein zwischengespeichertes SSO-Token für %s",
+		"The SSO token for %s expired at %s":                 "Das SSO-Token für %s ist um %s abgelaufen",
+		"Credentials are not valid, attempting SSO login...": "Die Anmeldedaten sind ungültig, SSO-Anmeldung wird versucht...",
+		"Signed in to %s\n":                                  "Bei %s angemeldet\n",
+		"Approve the sign-in request in your browser. If it does not open, visit:\n\n  %s\n\nand confirm the code %s.\n": "Bestätigen Sie die Anmeldeanfrage im Browser. Falls er sich nicht öffnet, rufen Sie\n\n  %s\n\nauf und bestätigen Sie den Code %s.\n",
+		"Warning: failed to open browser: %v\n": "Warnung: Browser konnte nicht geöffnet werden: %v\n",
+
+		// Pickers.
+		"profile":      "Profil",
+		"account":      "Konto",
+		"role":         "Rolle",
+		"console page": "Konsolenseite",
+		"Select a %s (number or search, empty to cancel): ": "%s auswählen (Nummer oder Suche, leer zum Abbrechen): ",
+		"Enter a number between 1 and %d.\n":                "Geben Sie eine Zahl zwischen 1 und %d ein.\n",
+		"No %ss match %q.\n":                                "Kein %s passt zu %q.\n",
+		"action":                                            "Aktion",
+		"Choices (%d):\n":                                   "Auswahl (%d):\n",
+		"%d of %d: %s\n":                                    "%d von %d: %s\n",
+		"Type the number of a %s, or letters to search; enter nothing to cancel: ": "Nummer für %s oder Buchstaben zur Suche eingeben; nichts eingeben zum Abbrechen: ",
+
+		// Errors.
+		"Error: %v": "Fehler: %v",
+		"profile %q not found in AWS config (run 'aws-console list' to see configured profiles)": "Profil %q nicht in der AWS-Konfiguration gefunden (mit 'aws-console list' werden die konfigurierten Profile angezeigt)",
+		"profile %q not found in AWS config":                                                     "Profil %q nicht in der AWS-Konfiguration gefunden",
+		"failed to list profiles: %w":                                                            "Profile konnten nicht aufgelistet werden: %w",
+		"no profile given: name one as an argument or with --profile":                            "Kein Profil angegeben: als Argument oder mit --profile angeben",
+		"--profile can only be repeated when opening the console":                                "--profile kann nur beim Öffnen der Konsole wiederholt werden",
+		"conflicting profiles: %q given as an argument but --profile is %q":                      "Widersprüchliche Profile: %q als Argument angegeben, aber --profile ist %q",
+		"a console page cannot be given both as arguments and with --destination or --service":   "Eine Konsolenseite kann nicht zugleich als Argument und mit --destination oder --service angegeben werden",
+		"%s needs an SSO login; run 'aws-console %s' to sign in":                                 "%s benötigt eine SSO-Anmeldung; zum Anmelden 'aws-console %s' ausführen",
+		"SSO login failed: %w":                                "SSO-Anmeldung fehlgeschlagen: %w",
+		"credentials still invalid after SSO login: %w":       "Die Anmeldedaten sind auch nach der SSO-Anmeldung ungültig: %w",
+		"failed to reach AWS to check credentials for %s: %w": "AWS war nicht erreichbar, um die Anmeldedaten von %s zu prüfen: %w",
+		"failed to check credentials for %s: %w":              "Die Anmeldedaten von %s konnten nicht geprüft werden: %w",
+		"failed to copy the sign-in URL to the clipboard: %w": "Die Anmelde-URL konnte nicht in die Zwischenablage kopiert werden: %w",
+		"failed to render the sign-in URL as a QR code: %w":   "Die Anmelde-URL konnte nicht als QR-Code dargestellt werden: %w",
+		"interrupted: %w":                                  "Unterbrochen: %w",
+		"gave up after the %s deadline: %w":                "Nach Ablauf der Frist von %s abgebrochen: %w",
+		"unknown setting %q in config file %s":             "Unbekannte Einstellung %q in der Konfigurationsdatei %s",
+		"invalid %s setting: %w":                           "Ungültige Einstellung %s: %w",
+		"unsupported language %q (expected en, ja, or de)": "Nicht unterstützte Sprache %q (erwartet: en, ja oder de)",
+		"--quiet cannot be used with --verbose or --debug": "--quiet kann nicht mit --verbose oder --debug verwendet werden",
+		"--read-only cannot be combined with --%s":         "--read-only kann nicht mit --%s kombiniert werden",
+	},
+}
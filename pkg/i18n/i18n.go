@@ -0,0 +1,180 @@
+// Package i18n translates the messages aws-console shows people on a terminal:
+// prompts, progress, warnings, and errors. Output meant for scripts, such as sign-in
+// URLs, tables, and exported credentials, is never translated.
+//
+// Messages are looked up by their English format string, so call sites read as they
+// would untranslated, and a message without a translation is shown in English. Errors
+// are created with Errorf, which keeps their format for a Printer to translate.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Language is a language messages can be shown in, as an ISO 639-1 code.
+type Language string
+
+const (
+	English  Language = "en"
+	Japanese Language = "ja"
+	German   Language = "de"
+)
+
+// Languages returns the supported languages.
+func Languages() []Language {
+	return []Language{English, Japanese, German}
+}
+
+// Parse reads a language code, BCP 47 tag, or POSIX locale, such as "ja", "de-DE", or
+// "ja_JP.UTF-8". The C and POSIX locales are English.
+func Parse(s string) (Language, error) {
+	code := strings.ToLower(s)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	if code == "c" || code == "posix" {
+		return English, nil
+	}
+	for _, lang := range Languages() {
+		if code == string(lang) {
+			return lang, nil
+		}
+	}
+	return "", Errorf("unsupported language %q (expected en, ja, or de)", s)
+}
+
+// Detect returns the language of the locale, read from LC_ALL, LC_MESSAGES, or LANG,
+// whichever is set first, as POSIX programs do. Locales of unsupported languages are
+// shown in English.
+func Detect(lookupEnv func(string) (string, bool)) Language {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v, ok := lookupEnv(name); ok && v != "" {
+			lang, err := Parse(v)
+			if err != nil {
+				return English
+			}
+			return lang
+		}
+	}
+	return English
+}
+
+// Printer formats messages in one language. A nil Printer formats them in English.
+type Printer struct {
+	lang     Language
+	messages map[string]string
+}
+
+// NewPrinter returns a Printer for lang.
+func NewPrinter(lang Language) *Printer {
+	return &Printer{lang: lang, messages: catalog[lang]}
+}
+
+// Language returns the language the Printer formats messages in.
+func (p *Printer) Language() Language {
+	if p == nil {
+		return English
+	}
+	return p.lang
+}
+
+// Sprintf formats the translation of format. Translations may reorder the arguments
+// with explicit indexes such as %[2]s. Errors among the arguments are translated too.
+func (p *Printer) Sprintf(format string, a ...any) string {
+	return fmt.Sprintf(p.translate(format), p.translateErrors(a)...)
+}
+
+// Fprintf writes the translation of format to w.
+func (p *Printer) Fprintf(w io.Writer, format string, a ...any) {
+	fmt.Fprintf(w, p.translate(format), p.translateErrors(a)...)
+}
+
+// Error returns the message of err in the language of the Printer. Errors created with
+// Errorf are translated, along with the errors they format. A wrapper whose message is
+// that of the error it wraps, such as one that only classifies it, is seen through;
+// other errors are shown as they are.
+func (p *Printer) Error(err error) string {
+	if err == nil {
+		return ""
+	}
+	if p == nil {
+		return err.Error()
+	}
+	if e, ok := err.(*Error); ok {
+		// The arguments stay errors, so formats that wrap them with %w still apply.
+		args := make([]any, len(e.args))
+		for i, a := range e.args {
+			if aerr, ok := a.(error); ok && aerr != nil {
+				a = errors.New(p.Error(aerr))
+			}
+			args[i] = a
+		}
+		return fmt.Errorf(p.translate(e.format), args...).Error()
+	}
+	if inner := errors.Unwrap(err); inner != nil && inner.Error() == err.Error() {
+		return p.Error(inner)
+	}
+	return err.Error()
+}
+
+// translateErrors replaces the errors among a with their translated messages.
+func (p *Printer) translateErrors(a []any) []any {
+	if p == nil {
+		return a
+	}
+	translated := make([]any, len(a))
+	for i, arg := range a {
+		if err, ok := arg.(error); ok && err != nil {
+			arg = p.Error(err)
+		}
+		translated[i] = arg
+	}
+	return translated
+}
+
+// Fprintln writes the translation of msg and a newline to w.
+func (p *Printer) Fprintln(w io.Writer, msg string) {
+	fmt.Fprintln(w, p.translate(msg))
+}
+
+func (p *Printer) translate(format string) string {
+	if p == nil {
+		return format
+	}
+	if t, ok := p.messages[format]; ok {
+		return t
+	}
+	return format
+}
+
+// Error is an error whose message a Printer can translate. Its Error method returns the
+// message in English.
+type Error struct {
+	format string
+	args   []any
+	err    error
+}
+
+// Errorf formats an error like fmt.Errorf, wrapping the errors given for %w, and keeps
+// its format and arguments so that a Printer can translate it.
+func Errorf(format string, a ...any) error {
+	return &Error{format: format, args: a, err: fmt.Errorf(format, a...)}
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the errors wrapped with %w.
+func (e *Error) Unwrap() []error {
+	switch err := e.err.(type) {
+	case interface{ Unwrap() error }:
+		return []error{err.Unwrap()}
+	case interface{ Unwrap() []error }:
+		return err.Unwrap()
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package i18n
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]Language{
+		"ja":          Japanese,
+		"ja_JP.UTF-8": Japanese,
+		"de-DE":       German,
+		"DE_at@euro":  German,
+		"en_US.UTF-8": English,
+		"C":           English,
+		"POSIX":       English,
+		"C.UTF-8":     English,
+	}
+	for s, want := range testCases {
+		if got, err := Parse(s); err != nil || got != want {
+			t.Fatalf("Parse(%q) = %q, %v, want %q", s, got, err, want)
+		}
+	}
+	if _, err := Parse("fr_FR.UTF-8"); err == nil {
+		t.Fatal("expected French to be unsupported")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		env  map[string]string
+		want Language
+	}{
+		{name: "nothing set", want: English},
+		{name: "LANG", env: map[string]string{"LANG": "de_DE.UTF-8"}, want: German},
+		{name: "LC_MESSAGES over LANG", env: map[string]string{"LC_MESSAGES": "ja_JP.UTF-8", "LANG": "de_DE.UTF-8"}, want: Japanese},
+		{name: "LC_ALL over everything", env: map[string]string{"LC_ALL": "C", "LC_MESSAGES": "ja_JP.UTF-8"}, want: English},
+		{name: "unsupported language", env: map[string]string{"LANG": "fr_FR.UTF-8"}, want: English},
+		{name: "empty values are skipped", env: map[string]string{"LC_ALL": "", "LANG": "ja"}, want: Japanese},
+	}
+
+	for _, tc := range testCases {
+		lookup := func(name string) (string, bool) {
+			v, ok := tc.env[name]
+			return v, ok
+		}
+		if got := Detect(lookup); got != tc.want {
+			t.Fatalf("%s: Detect = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	t.Parallel()
+
+	var nilPrinter *Printer
+	if got := nilPrinter.Sprintf("Console session valid for %s\n", "1h0m0s"); got != "Console session valid for 1h0m0s\n" {
+		t.Fatalf("expected a nil printer to print English, got %q", got)
+	}
+
+	ja := NewPrinter(Japanese)
+	buf := &bytes.Buffer{}
+	ja.Fprintf(buf, "No %ss match %q.\n", ja.Sprintf("profile"), "prd")
+	if got := buf.String(); got != "\"prd\" に一致するプロファイルはありません。\n" {
+		t.Fatalf("unexpected translation %q", got)
+	}
+
+	de := NewPrinter(German)
+	buf.Reset()
+	de.Fprintln(buf, "Opening AWS Console in your browser...")
+	de.Fprintln(buf, "A message without a translation")
+	if got := buf.String(); got != "AWS-Konsole wird im Browser geöffnet...\nA message without a translation\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+// markedError classifies the error it wraps without changing its message.
+type markedError struct{ err error }
+
+func (e markedError) Error() string { return e.err.Error() }
+func (e markedError) Unwrap() error { return e.err }
+
+func TestPrinterError(t *testing.T) {
+	t.Parallel()
+
+	notFound := Errorf("profile %q not found in AWS config (run 'aws-console list' to see configured profiles)", "prod")
+	err := Errorf("SSO login failed: %w", markedError{Errorf("failed to list profiles: %w", notFound)})
+	if !errors.Is(err, notFound) {
+		t.Fatal("expected Errorf to wrap the errors given for %w")
+	}
+	english := `SSO login failed: failed to list profiles: profile "prod" not found in AWS config (run 'aws-console list' to see configured profiles)`
+	if got := err.Error(); got != english {
+		t.Fatalf("expected the English message, got %q", got)
+	}
+
+	testCases := []struct {
+		name    string
+		printer *Printer
+		err     error
+		want    string
+	}{
+		{name: "nil printer", err: err, want: english},
+		{name: "English", printer: NewPrinter(English), err: err, want: english},
+		{name: "Japanese, through a wrapper", printer: NewPrinter(Japanese), err: err, want: `SSO ログインに失敗しました: プロファイルを一覧表示できませんでした: プロファイル "prod" が AWS の設定に見つかりません（設定済みのプロファイルは 'aws-console list' で確認できます）`},
+		{name: "untranslated wrapper", printer: NewPrinter(German), err: Errorf("no translation: %w", notFound), want: `no translation: Profil "prod" nicht in der AWS-Konfiguration gefunden (mit 'aws-console list' werden die konfigurierten Profile angezeigt)`},
+		{name: "other errors", printer: NewPrinter(German), err: fmt.Errorf("SSO login failed: %w", notFound), want: `SSO login failed: profile "prod" not found in AWS config (run 'aws-console list' to see configured profiles)`},
+	}
+	for _, tc := range testCases {
+		if got := tc.printer.Error(tc.err); got != tc.want {
+			t.Fatalf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+
+	if got := NewPrinter(German).Sprintf("Error: %v", Errorf("interrupted: %w", context.Canceled)); got != "Fehler: Unterbrochen: context canceled" {
+		t.Fatalf("expected errors among the arguments to be translated, got %q", got)
+	}
+}
+
+// verbPattern matches the formatting verbs of a format string, with any explicit
+// argument index.
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0]*\d*(?:\.\d+)?([a-zA-Z%])`)
+
+// formatVerbs returns the verb used for each argument of format, in argument order.
+func formatVerbs(format string) []string {
+	var verbs []string
+	next := 0
+	for _, m := range verbPattern.FindAllStringSubmatch(format, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		if m[1] != "" {
+			next, _ = strconv.Atoi(m[1])
+			next--
+		}
+		for len(verbs) <= next {
+			verbs = append(verbs, "")
+		}
+		verbs[next] = m[2]
+		next++
+	}
+	return verbs
+}
+
+func TestCatalogKeepsVerbs(t *testing.T) {
+	t.Parallel()
+
+	for lang, messages := range catalog {
+		for format, translation := range messages {
+			if want, got := formatVerbs(format), formatVerbs(translation); !slices.Equal(want, got) {
+				t.Errorf("%s translation of %q uses verbs %q, want %q", lang, format, got, want)
+			}
+		}
+	}
+	if !slices.Equal(formatVerbs("%[2]q matches %[1]s"), []string{"s", "q"}) {
+		t.Fatal("expected explicit indexes to reorder the verbs")
+	}
+}
+
+func TestCatalogsTranslateTheSameMessages(t *testing.T) {
+	t.Parallel()
+
+	for format := range catalog[Japanese] {
+		if _, ok := catalog[German][format]; !ok {
+			t.Errorf("no German translation of %q", format)
+		}
+	}
+	for format := range catalog[German] {
+		if _, ok := catalog[Japanese][format]; !ok {
+			t.Errorf("no Japanese translation of %q", format)
+		}
+	}
+}
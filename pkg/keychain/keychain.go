@@ -0,0 +1,260 @@
+// Package keychain stores secrets in the operating system's credential store: the
+// macOS Keychain, the Windows Credential Manager, or a Secret Service such as GNOME
+// Keyring on Linux through libsecret. Each is driven by the platform's own command-line
+// tool, so no cgo is needed.
+package keychain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Service names the entries aws-console stores, as the Keychain service, the Secret
+// Service attribute, and the Credential Manager target prefix.
+const Service = "aws-console"
+
+var (
+	// ErrNotFound is returned by Get when there is no secret for the key.
+	ErrNotFound = errors.New("secret not found in keychain")
+	// ErrUnsupported is returned on platforms without a supported credential store.
+	ErrUnsupported = errors.New("no supported keychain on this platform")
+)
+
+// notFoundExitCode is the exit status of 'security' for a missing item, which the
+// Windows script reuses.
+const notFoundExitCode = 44
+
+// Runner runs external commands.
+type Runner interface {
+	Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
+// Keychain stores secrets in the credential store of one platform. Secrets are stored
+// base64-encoded, since some stores only hold text.
+type Keychain struct {
+	goos   string
+	runner Runner
+}
+
+// New creates a keychain for goos (a runtime.GOOS value).
+func New(goos string, runner Runner) *Keychain {
+	return &Keychain{goos: goos, runner: runner}
+}
+
+// Supported reports whether goos has a supported credential store.
+func Supported(goos string) bool {
+	switch goos {
+	case "darwin", "windows", "linux", "freebsd", "openbsd", "netbsd":
+		return true
+	}
+	return false
+}
+
+// Get returns the secret stored for key.
+func (k *Keychain) Get(key string) ([]byte, error) {
+	var res result
+	switch {
+	case k.goos == "darwin":
+		res = k.run("security", []string{"find-generic-password", "-s", Service, "-a", key, "-w"}, "")
+	case k.goos == "windows":
+		res = k.run("powershell", powershellArgs, credentialScript(fmt.Sprintf(
+			"$s = [AwsConsoleCredential]::Read('%s'); if ($s -eq $null) { exit %d }; [Console]::Out.Write($s)",
+			target(key), notFoundExitCode)))
+	case Supported(k.goos):
+		res = k.run("secret-tool", []string{"lookup", "service", Service, "account", key}, "")
+		// secret-tool exits with status 1 and no message for a missing secret.
+		if res.exitCode == 1 && res.stderr == "" {
+			return nil, ErrNotFound
+		}
+	default:
+		return nil, ErrUnsupported
+	}
+	if err := res.error(); err != nil {
+		return nil, err
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(res.stdout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode keychain secret %s: %w", key, err)
+	}
+	return secret, nil
+}
+
+// Set stores secret for key, replacing any secret already stored. The secret is passed
+// on standard input, never as an argument other processes could see.
+func (k *Keychain) Set(key string, secret []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(secret)
+	switch {
+	case k.goos == "darwin":
+		// 'security -i' reads commands from stdin, and reports a failed one only on
+		// stderr; -U updates an existing item.
+		res := k.run("security", []string{"-i"}, fmt.Sprintf(
+			"add-generic-password -U -s %s -a %s -l %s -w %s\n", Service, key, Service, encoded))
+		if res.err == nil && res.stderr != "" {
+			return fmt.Errorf("security failed: %s", res.stderr)
+		}
+		return res.error()
+	case k.goos == "windows":
+		return k.run("powershell", powershellArgs, credentialScript(fmt.Sprintf(
+			"[AwsConsoleCredential]::Write('%s', '%s')", target(key), encoded))).error()
+	case Supported(k.goos):
+		return k.run("secret-tool", []string{"store", "--label", Service + " " + key, "service", Service, "account", key}, encoded).error()
+	}
+	return ErrUnsupported
+}
+
+// Delete removes the secret stored for key. A missing secret is not an error.
+func (k *Keychain) Delete(key string) error {
+	var res result
+	switch {
+	case k.goos == "darwin":
+		res = k.run("security", []string{"delete-generic-password", "-s", Service, "-a", key}, "")
+	case k.goos == "windows":
+		res = k.run("powershell", powershellArgs, credentialScript(fmt.Sprintf(
+			"[AwsConsoleCredential]::Delete('%s')", target(key))))
+	case Supported(k.goos):
+		res = k.run("secret-tool", []string{"clear", "service", Service, "account", key}, "")
+	default:
+		return ErrUnsupported
+	}
+	if err := res.error(); !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// result is the outcome of a keychain command.
+type result struct {
+	name     string
+	stdout   string
+	stderr   string
+	exitCode int
+	err      error
+}
+
+// run runs a keychain command with stdin.
+func (k *Keychain) run(name string, args []string, stdin string) result {
+	var stdout, stderr bytes.Buffer
+	err := k.runner.Run(name, args, strings.NewReader(stdin), &stdout, &stderr)
+	res := result{name: name, stdout: stdout.String(), stderr: strings.TrimSpace(stderr.String()), err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.exitCode = exitErr.ExitCode()
+	}
+	return res
+}
+
+// error describes a failed command. A missing item, as reported by 'security' or the
+// Windows script, is ErrNotFound.
+func (r result) error() error {
+	switch {
+	case r.err == nil:
+		return nil
+	case r.exitCode == notFoundExitCode:
+		return ErrNotFound
+	case errors.Is(r.err, exec.ErrNotFound):
+		return fmt.Errorf("%s is required to use the keychain: %w", r.name, r.err)
+	case r.stderr != "":
+		return fmt.Errorf("%s failed: %w: %s", r.name, r.err, r.stderr)
+	}
+	return fmt.Errorf("%s failed: %w", r.name, r.err)
+}
+
+// target is the Credential Manager target name of key.
+func target(key string) string {
+	return Service + ":" + key
+}
+
+// powershellArgs run the script given on standard input.
+var powershellArgs = []string{"-NoProfile", "-NonInteractive", "-Command", "-"}
+
+// credentialScript prefixes statement with a PowerShell type that calls the Credential
+// Manager functions of advapi32.dll.
+func credentialScript(statement string) string {
+	return credentialType + "\n" + statement + "\n"
+}
+
+const credentialType = `$ErrorActionPreference = 'Stop'
+Add-Type -TypeDefinition @'
+using System;
+using System.ComponentModel;
+using System.Runtime.InteropServices;
+using System.Text;
+
+public static class AwsConsoleCredential {
+    const int Generic = 1;
+    const int PersistLocalMachine = 2;
+    const int ErrorNotFound = 1168;
+
+    [StructLayout(LayoutKind.Sequential, CharSet = CharSet.Unicode)]
+    struct Credential {
+        public int Flags;
+        public int Type;
+        public string TargetName;
+        public string Comment;
+        public System.Runtime.InteropServices.ComTypes.FILETIME LastWritten;
+        public int CredentialBlobSize;
+        public IntPtr CredentialBlob;
+        public int Persist;
+        public int AttributeCount;
+        public IntPtr Attributes;
+        public string TargetAlias;
+        public string UserName;
+    }
+
+    [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+    static extern bool CredReadW(string target, int type, int flags, out IntPtr credential);
+    [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+    static extern bool CredWriteW(ref Credential credential, int flags);
+    [DllImport("advapi32.dll", CharSet = CharSet.Unicode, SetLastError = true)]
+    static extern bool CredDeleteW(string target, int type, int flags);
+    [DllImport("advapi32.dll")]
+    static extern void CredFree(IntPtr buffer);
+
+    public static string Read(string target) {
+        IntPtr ptr;
+        if (!CredReadW(target, Generic, 0, out ptr)) {
+            int err = Marshal.GetLastWin32Error();
+            if (err == ErrorNotFound) { return null; }
+            throw new Win32Exception(err);
+        }
+        try {
+            Credential c = (Credential)Marshal.PtrToStructure(ptr, typeof(Credential));
+            byte[] blob = new byte[c.CredentialBlobSize];
+            Marshal.Copy(c.CredentialBlob, blob, 0, blob.Length);
+            return Encoding.UTF8.GetString(blob);
+        } finally {
+            CredFree(ptr);
+        }
+    }
+
+    public static void Write(string target, string secret) {
+        byte[] blob = Encoding.UTF8.GetBytes(secret);
+        Credential c = new Credential();
+        c.Type = Generic;
+        c.TargetName = target;
+        c.UserName = "aws-console";
+        c.Persist = PersistLocalMachine;
+        c.CredentialBlobSize = blob.Length;
+        c.CredentialBlob = Marshal.AllocHGlobal(blob.Length);
+        try {
+            Marshal.Copy(blob, 0, c.CredentialBlob, blob.Length);
+            if (!CredWriteW(ref c, 0)) { throw new Win32Exception(Marshal.GetLastWin32Error()); }
+        } finally {
+            Marshal.FreeHGlobal(c.CredentialBlob);
+        }
+    }
+
+    public static void Delete(string target) {
+        if (!CredDeleteW(target, Generic, 0)) {
+            int err = Marshal.GetLastWin32Error();
+            if (err != ErrorNotFound) { throw new Win32Exception(err); }
+        }
+    }
+}
+'@`
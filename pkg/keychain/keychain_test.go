@@ -0,0 +1,265 @@
+package keychain
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type runCall struct {
+	name  string
+	args  []string
+	stdin string
+}
+
+// fakeRunner records commands and answers them with stdout, stderr, and err.
+type fakeRunner struct {
+	stdout string
+	stderr string
+	err    error
+	calls  []runCall
+}
+
+func (f *fakeRunner) Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	input, _ := io.ReadAll(stdin)
+	f.calls = append(f.calls, runCall{name: name, args: append([]string(nil), args...), stdin: string(input)})
+	io.WriteString(stdout, f.stdout)
+	io.WriteString(stderr, f.stderr)
+	return f.err
+}
+
+// exitError returns the error of a process that exited with code.
+func exitError(t *testing.T, code string) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", "exit "+code).Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected an exit error, got %v", err)
+	}
+	return err
+}
+
+const secret = `{"AccessKeyID":"ASIA"}`
+
+var encoded = base64.StdEncoding.EncodeToString([]byte(secret))
+
+func TestKeychainSet(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		goos        string
+		wantCommand string
+		wantArgs    []string
+		wantStdin   string
+	}{
+		{
+			goos:        "darwin",
+			wantCommand: "security",
+			wantArgs:    []string{"-i"},
+			wantStdin:   "add-generic-password -U -s aws-console -a credentials/abc -l aws-console -w " + encoded + "\n",
+		},
+		{
+			goos:        "linux",
+			wantCommand: "secret-tool",
+			wantArgs:    []string{"store", "--label", "aws-console credentials/abc", "service", "aws-console", "account", "credentials/abc"},
+			wantStdin:   encoded,
+		},
+		{
+			goos:        "windows",
+			wantCommand: "powershell",
+			wantArgs:    []string{"-NoProfile", "-NonInteractive", "-Command", "-"},
+			wantStdin:   "[AwsConsoleCredential]::Write('aws-console:credentials/abc', '" + encoded + "')",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.goos, func(t *testing.T) {
+			t.Parallel()
+
+			runner := &fakeRunner{}
+			if err := New(tc.goos, runner).Set("credentials/abc", []byte(secret)); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if len(runner.calls) != 1 {
+				t.Fatalf("ran %d commands, want 1", len(runner.calls))
+			}
+			call := runner.calls[0]
+			if call.name != tc.wantCommand || strings.Join(call.args, " ") != strings.Join(tc.wantArgs, " ") {
+				t.Fatalf("ran %s %v, want %s %v", call.name, call.args, tc.wantCommand, tc.wantArgs)
+			}
+			if !strings.Contains(call.stdin, tc.wantStdin) {
+				t.Fatalf("stdin = %q, want it to contain %q", call.stdin, tc.wantStdin)
+			}
+			if strings.Contains(strings.Join(call.args, " "), encoded) {
+				t.Fatal("the secret was passed as an argument")
+			}
+		})
+	}
+}
+
+func TestKeychainSetSecurityFailure(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeRunner{stderr: "security: SecKeychainItemCreateFromContent: User interaction is not allowed."}
+	err := New("darwin", runner).Set("credentials/abc", []byte(secret))
+	if err == nil || !strings.Contains(err.Error(), "User interaction is not allowed") {
+		t.Fatalf("Set() error = %v, want the security error", err)
+	}
+}
+
+func TestKeychainGet(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		goos          string
+		stdout        string
+		stderr        string
+		exitCode      string
+		runErr        error
+		wantCommand   string
+		wantArgs      []string
+		wantNotFound  bool
+		wantErrSubstr string
+	}{
+		{
+			name:        "macOS",
+			goos:        "darwin",
+			stdout:      encoded + "\n",
+			wantCommand: "security",
+			wantArgs:    []string{"find-generic-password", "-s", "aws-console", "-a", "credentials/abc", "-w"},
+		},
+		{
+			name:         "macOS missing",
+			goos:         "darwin",
+			stderr:       "security: SecKeychainSearchCopyNext: The specified item could not be found in the keychain.",
+			exitCode:     "44",
+			wantCommand:  "security",
+			wantNotFound: true,
+		},
+		{
+			name:        "linux",
+			goos:        "linux",
+			stdout:      encoded,
+			wantCommand: "secret-tool",
+			wantArgs:    []string{"lookup", "service", "aws-console", "account", "credentials/abc"},
+		},
+		{
+			name:         "linux missing",
+			goos:         "linux",
+			exitCode:     "1",
+			wantCommand:  "secret-tool",
+			wantNotFound: true,
+		},
+		{
+			name:          "linux without a secret service",
+			goos:          "linux",
+			stderr:        "secret-tool: Cannot autolaunch D-Bus without X11 $DISPLAY",
+			exitCode:      "1",
+			wantCommand:   "secret-tool",
+			wantErrSubstr: "secret-tool failed: exit status 1: secret-tool: Cannot autolaunch D-Bus",
+		},
+		{
+			name:          "linux without secret-tool",
+			goos:          "linux",
+			runErr:        &exec.Error{Name: "secret-tool", Err: exec.ErrNotFound},
+			wantCommand:   "secret-tool",
+			wantErrSubstr: "secret-tool is required to use the keychain",
+		},
+		{
+			name:        "windows",
+			goos:        "windows",
+			stdout:      encoded,
+			wantCommand: "powershell",
+			wantArgs:    []string{"-NoProfile", "-NonInteractive", "-Command", "-"},
+		},
+		{
+			name:         "windows missing",
+			goos:         "windows",
+			exitCode:     "44",
+			wantCommand:  "powershell",
+			wantNotFound: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := &fakeRunner{stdout: tc.stdout, stderr: tc.stderr, err: tc.runErr}
+			if tc.exitCode != "" {
+				runner.err = exitError(t, tc.exitCode)
+			}
+			got, err := New(tc.goos, runner).Get("credentials/abc")
+
+			if runner.calls[0].name != tc.wantCommand {
+				t.Fatalf("ran %s, want %s", runner.calls[0].name, tc.wantCommand)
+			}
+			if tc.wantArgs != nil && strings.Join(runner.calls[0].args, " ") != strings.Join(tc.wantArgs, " ") {
+				t.Fatalf("args = %v, want %v", runner.calls[0].args, tc.wantArgs)
+			}
+			switch {
+			case tc.wantNotFound:
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Get() error = %v, want ErrNotFound", err)
+				}
+			case tc.wantErrSubstr != "":
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("Get() error = %v, want it to contain %q", err, tc.wantErrSubstr)
+				}
+			default:
+				if err != nil || string(got) != secret {
+					t.Fatalf("Get() = %q, %v, want %q", got, err, secret)
+				}
+			}
+		})
+	}
+}
+
+func TestKeychainDelete(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeRunner{err: exitError(t, "44")}
+	if err := New("darwin", runner).Delete("credentials/abc"); err != nil {
+		t.Fatalf("Delete() of a missing item error = %v", err)
+	}
+	if got := strings.Join(runner.calls[0].args, " "); got != "delete-generic-password -s aws-console -a credentials/abc" {
+		t.Fatalf("args = %q", got)
+	}
+
+	runner = &fakeRunner{}
+	if err := New("linux", runner).Delete("credentials/abc"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got := strings.Join(runner.calls[0].args, " "); got != "clear service aws-console account credentials/abc" {
+		t.Fatalf("args = %q", got)
+	}
+
+	runner = &fakeRunner{err: exitError(t, "3"), stderr: "Access denied"}
+	if err := New("windows", runner).Delete("credentials/abc"); err == nil || !strings.Contains(err.Error(), "Access denied") {
+		t.Fatalf("Delete() error = %v, want the failure", err)
+	}
+}
+
+func TestKeychainUnsupported(t *testing.T) {
+	t.Parallel()
+
+	k := New("plan9", &fakeRunner{})
+	if Supported("plan9") {
+		t.Fatal("Supported(plan9) = true")
+	}
+	if _, err := k.Get("key"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Get() error = %v, want ErrUnsupported", err)
+	}
+	if err := k.Set("key", nil); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Set() error = %v, want ErrUnsupported", err)
+	}
+	if err := k.Delete("key"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Delete() error = %v, want ErrUnsupported", err)
+	}
+}
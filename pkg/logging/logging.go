@@ -0,0 +1,145 @@
+// Package logging builds the slog logger behind --verbose and --debug. Records are
+// written as plain lines meant for people: the message, then any attributes as
+// key=value pairs, with secrets masked.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Redacted replaces masked values.
+const Redacted = "REDACTED"
+
+var (
+	// sensitiveKey matches attribute keys whose values are secrets.
+	sensitiveKey = regexp.MustCompile(`(?i)(secret|token|password|passphrase|session_?key|external_?id|mfa_?code)`)
+	// accessKeyID matches access key IDs anywhere in a value.
+	accessKeyID = regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{12}([A-Z0-9]{4})\b`)
+	// secretParam matches secrets carried in URL query parameters or JSON fields.
+	secretParam = regexp.MustCompile(`(?i)((?:SigninToken|Session|SessionToken|SecretAccessKey)"?\s*[:=]\s*"?)[^"&\s,}]+`)
+)
+
+// Level returns the level to log at: debug records with debug, progress details with
+// verbose, and only warnings otherwise.
+func Level(verbose, debug bool) slog.Level {
+	switch {
+	case debug:
+		return slog.LevelDebug
+	case verbose:
+		return slog.LevelInfo
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// New returns a logger writing records at level and above to w.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(&handler{mu: &sync.Mutex{}, w: w, level: level})
+}
+
+// Mask returns value with secrets hidden: values of sensitive keys entirely, and
+// access key IDs and tokens embedded in other values. Access key IDs keep their last
+// four characters so they can still be told apart.
+func Mask(key, value string) string {
+	if value != "" && sensitiveKey.MatchString(key) {
+		return Redacted
+	}
+	value = accessKeyID.ReplaceAllString(value, "${1}…${2}")
+	return secretParam.ReplaceAllString(value, "${1}"+Redacted)
+}
+
+type handler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Level
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var b strings.Builder
+	if r.Level < slog.LevelInfo {
+		b.WriteString("debug: ")
+	}
+	b.WriteString(Mask("", r.Message))
+	for _, a := range h.attrs {
+		appendAttr(&b, "", a)
+	}
+	prefix := strings.Join(h.groups, ".")
+	if prefix != "" {
+		prefix += "."
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	prefix := strings.Join(h.groups, ".")
+	for _, a := range attrs {
+		if prefix != "" {
+			a.Key = prefix + "." + a.Key
+		}
+		clone.attrs = append(clone.attrs, a)
+	}
+	return &clone
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.groups = append(append([]string(nil), h.groups...), name)
+	return &clone
+}
+
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	key := prefix + a.Key
+	if a.Value.Kind() == slog.KindGroup {
+		for _, member := range a.Value.Group() {
+			appendAttr(b, key+".", member)
+		}
+		return
+	}
+
+	var value string
+	switch a.Value.Kind() {
+	case slog.KindDuration:
+		value = a.Value.Duration().Round(time.Millisecond).String()
+	case slog.KindTime:
+		value = a.Value.Time().Format(time.RFC3339)
+	default:
+		value = fmt.Sprint(a.Value.Any())
+	}
+	value = Mask(key, value)
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s=%s", key, value)
+}
@@ -0,0 +1,95 @@
+// Package notify shows desktop notifications with the platform's notification command.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// WSL is the platform of Linux running under the Windows Subsystem for Linux, whose
+// notifications are shown by Windows.
+const WSL = "wsl"
+
+// Runner runs external commands.
+type Runner interface {
+	Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
+// ErrUnavailable is returned when no notification command is installed.
+var ErrUnavailable = errors.New("no notification command found")
+
+type command struct {
+	name string
+	args []string
+}
+
+// Notifier shows notifications on one platform.
+type Notifier struct {
+	goos   string
+	runner Runner
+}
+
+// New creates a notifier for goos (a runtime.GOOS value, or WSL).
+func New(goos string, runner Runner) *Notifier {
+	return &Notifier{goos: goos, runner: runner}
+}
+
+// Notify shows a notification with title and message, trying each of the platform's
+// notification commands in turn.
+func (n *Notifier) Notify(title, message string) error {
+	for _, cmd := range n.commands(title, message) {
+		var stderr strings.Builder
+		err := n.runner.Run(cmd.name, cmd.args, nil, io.Discard, &stderr)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, exec.ErrNotFound) {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return fmt.Errorf("%s failed: %w: %s", cmd.name, err, msg)
+			}
+			return fmt.Errorf("%s failed: %w", cmd.name, err)
+		}
+	}
+	return ErrUnavailable
+}
+
+// commands returns the notification commands of the platform, in order of preference.
+// Title and message are passed as arguments, never interpolated into a script.
+func (n *Notifier) commands(title, message string) []command {
+	// The balloon disappears with the icon, so the script waits before removing it.
+	powershell := command{name: "powershell.exe", args: []string{
+		"-NoProfile", "-NonInteractive", "-Command",
+		"Add-Type -AssemblyName System.Windows.Forms, System.Drawing; " +
+			"$n = New-Object System.Windows.Forms.NotifyIcon; " +
+			"$n.Icon = [System.Drawing.SystemIcons]::Information; $n.Visible = $true; " +
+			"$n.ShowBalloonTip(10000, " + quotePowerShell(title) + ", " + quotePowerShell(message) + ", 'Info'); " +
+			"Start-Sleep -Seconds 5; $n.Dispose()",
+	}}
+	notifySend := command{name: "notify-send", args: []string{"--app-name=aws-console", "--", title, message}}
+
+	switch n.goos {
+	case "darwin":
+		return []command{{name: "osascript", args: []string{
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message,
+		}}}
+	case "windows":
+		return []command{powershell}
+	case WSL:
+		return []command{powershell, notifySend}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return []command{notifySend}
+	default:
+		return nil
+	}
+}
+
+// quotePowerShell quotes value as a PowerShell single-quoted string.
+func quotePowerShell(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
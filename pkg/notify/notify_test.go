@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+type runCall struct {
+	name string
+	args []string
+}
+
+type fakeRunner struct {
+	// installed lists the commands found on PATH; others fail with exec.ErrNotFound.
+	installed map[string]error
+	calls     []runCall
+}
+
+func (f *fakeRunner) Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	f.calls = append(f.calls, runCall{name: name, args: append([]string(nil), args...)})
+	err, ok := f.installed[name]
+	if !ok {
+		return &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	if err != nil {
+		io.WriteString(stderr, "Cannot connect to the notification server\n")
+	}
+	return err
+}
+
+func TestNotify(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		goos          string
+		installed     map[string]error
+		wantCommand   string
+		wantArgs      []string
+		wantTried     int
+		wantErrSubstr string
+	}{
+		{
+			name:        "macOS",
+			goos:        "darwin",
+			installed:   map[string]error{"osascript": nil},
+			wantCommand: "osascript",
+			wantArgs:    []string{"-e", "on run argv", "-e", "display notification (item 2 of argv) with title (item 1 of argv)", "-e", "end run", "aws-console", `Reopened "prod"`},
+			wantTried:   1,
+		},
+		{
+			name:        "linux",
+			goos:        "linux",
+			installed:   map[string]error{"notify-send": nil},
+			wantCommand: "notify-send",
+			wantArgs:    []string{"--app-name=aws-console", "--", "aws-console", `Reopened "prod"`},
+			wantTried:   1,
+		},
+		{
+			name:        "wsl falls back to notify-send",
+			goos:        WSL,
+			installed:   map[string]error{"notify-send": nil},
+			wantCommand: "notify-send",
+			wantTried:   2,
+		},
+		{
+			name:          "command fails",
+			goos:          "linux",
+			installed:     map[string]error{"notify-send": errors.New("exit status 1")},
+			wantTried:     1,
+			wantErrSubstr: "notify-send failed: exit status 1: Cannot connect to the notification server",
+		},
+		{
+			name:          "not installed",
+			goos:          "linux",
+			wantTried:     1,
+			wantErrSubstr: ErrUnavailable.Error(),
+		},
+		{
+			name:          "unsupported platform",
+			goos:          "plan9",
+			wantErrSubstr: ErrUnavailable.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			runner := &fakeRunner{installed: tc.installed}
+			err := New(tc.goos, runner).Notify("aws-console", `Reopened "prod"`)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(runner.calls) != tc.wantTried {
+				t.Fatalf("expected %d commands tried, got %+v", tc.wantTried, runner.calls)
+			}
+			if tc.wantCommand == "" {
+				return
+			}
+			last := runner.calls[len(runner.calls)-1]
+			if last.name != tc.wantCommand {
+				t.Fatalf("expected %s, got %s", tc.wantCommand, last.name)
+			}
+			if tc.wantArgs != nil && strings.Join(last.args, "|") != strings.Join(tc.wantArgs, "|") {
+				t.Fatalf("unexpected arguments %q", last.args)
+			}
+		})
+	}
+}
+
+func TestNotifyWindowsQuoting(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeRunner{installed: map[string]error{"powershell.exe": nil}}
+	if err := New("windows", runner).Notify("aws-console", "it's prod"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := runner.calls[0].args[len(runner.calls[0].args)-1]
+	if !strings.Contains(script, "ShowBalloonTip(10000, 'aws-console', 'it''s prod', 'Info')") {
+		t.Fatalf("expected quoted title and message, got %q", script)
+	}
+}
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format selects how tabular results are rendered.
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatCSV   Format = "csv"
+	FormatJSON  Format = "json"
+)
+
+// Formats lists the supported output formats in display order.
+var Formats = []Format{FormatTable, FormatCSV, FormatJSON}
+
+// ParseFormat validates a user-supplied output format. An empty value selects the table format.
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatTable:
+		return FormatTable, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q (expected one of: %s)", value, formatList())
+	}
+}
+
+func formatList() string {
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Column describes a single column of a Table.
+type Column struct {
+	// Header is the human-readable heading used by the table and CSV formats.
+	Header string
+	// Key is the field name used by the JSON format.
+	Key string
+}
+
+// Table is a set of rows with a fixed column layout.
+type Table struct {
+	Columns []Column
+	Rows    [][]string
+}
+
+// Render writes the table to w in the requested format.
+func Render(w io.Writer, format Format, t Table) error {
+	switch format {
+	case FormatTable, "":
+		return renderTable(w, t)
+	case FormatCSV:
+		return renderCSV(w, t)
+	case FormatJSON:
+		return renderJSON(w, t)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+func renderTable(w io.Writer, t Table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	headers := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		headers[i] = c.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, row := range t.Rows {
+		cells := make([]string, len(t.Columns))
+		for i := range t.Columns {
+			cells[i] = "-"
+			if i < len(row) && row[i] != "" {
+				cells[i] = row[i]
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func renderCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+
+	headers := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		headers[i] = c.Key
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+
+	for _, row := range t.Rows {
+		record := make([]string, len(t.Columns))
+		copy(record, row)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func renderJSON(w io.Writer, t Table) error {
+	records := make([]record, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		records = append(records, record{columns: t.Columns, values: row})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// record is one row of a Table in the JSON format. It marshals to an object whose keys
+// follow the column order, where a map would sort them.
+type record struct {
+	columns []Column
+	values  []string
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, c := range r.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		value := ""
+		if i < len(r.values) {
+			value = r.values[i]
+		}
+		key, err := json.Marshal(c.Key)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(encoded)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func sampleTable() Table {
+	return Table{
+		Columns: []Column{
+			{Header: "PROFILE", Key: "profile"},
+			{Header: "ACCOUNT", Key: "account"},
+			{Header: "SOURCE", Key: "source"},
+		},
+		Rows: [][]string{
+			{"dev", "123456789012", "sso"},
+			{"production-admin", "", "assume-role"},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value         string
+		want          Format
+		wantErrSubstr string
+	}{
+		{value: "", want: FormatTable},
+		{value: "table", want: FormatTable},
+		{value: "CSV", want: FormatCSV},
+		{value: " json ", want: FormatJSON},
+		{value: "yaml", wantErrSubstr: `unsupported output format "yaml" (expected one of: table, csv, json)`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseFormat(tc.value)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatTable, sampleTable()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "" +
+		"PROFILE           ACCOUNT       SOURCE\n" +
+		"dev               123456789012  sso\n" +
+		"production-admin  -             assume-role\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected table output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatCSV, sampleTable()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "profile,account,source\ndev,123456789012,sso\nproduction-admin,,assume-role\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatJSON, sampleTable()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var records []map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("failed to decode JSON output: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[1]["profile"] != "production-admin" || records[1]["account"] != "" {
+		t.Fatalf("unexpected record: %+v", records[1])
+	}
+
+	want := `[
+  {
+    "profile": "dev",
+    "account": "123456789012",
+    "source": "sso"
+  },
+  {
+    "profile": "production-admin",
+    "account": "",
+    "source": "assume-role"
+  }
+]
+`
+	if buf.String() != want {
+		t.Fatalf("expected keys in column order:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderJSONEmptyTable(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatJSON, Table{Columns: sampleTable().Columns}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Fatalf("expected empty JSON array, got %q", buf.String())
+	}
+}
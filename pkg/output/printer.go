@@ -0,0 +1,89 @@
+package output
+
+import (
+	"io"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+// Level is how much a Printer shows.
+type Level int
+
+const (
+	// LevelQuiet shows only prompts, which wait for an answer.
+	LevelQuiet Level = iota
+	// LevelNormal also shows progress messages, notes, and warnings.
+	LevelNormal
+)
+
+// Printer writes the messages aws-console shows people, as opposed to the results of a
+// command: prompts, progress messages, notes, and warnings, translated into the
+// language of the Printer. Results, such as sign-in URLs, tables, and exported
+// credentials, are written directly and are never suppressed or translated.
+//
+// A nil Printer shows every message in English.
+type Printer struct {
+	tr    *i18n.Printer
+	level Level
+}
+
+// NewPrinter returns a Printer showing messages at level in lang.
+func NewPrinter(lang i18n.Language, level Level) *Printer {
+	return &Printer{tr: i18n.NewPrinter(lang), level: level}
+}
+
+// Language returns the language the Printer shows messages in.
+func (p *Printer) Language() i18n.Language {
+	if p == nil {
+		return i18n.English
+	}
+	return p.tr.Language()
+}
+
+// Quiet reports whether the Printer shows only prompts.
+func (p *Printer) Quiet() bool {
+	return p != nil && p.level == LevelQuiet
+}
+
+// Sprintf formats the translation of format.
+func (p *Printer) Sprintf(format string, a ...any) string {
+	return p.translator().Sprintf(format, a...)
+}
+
+// Fprintf writes the translation of an informational message to w, unless the Printer
+// is quiet.
+func (p *Printer) Fprintf(w io.Writer, format string, a ...any) {
+	if !p.Quiet() {
+		p.translator().Fprintf(w, format, a...)
+	}
+}
+
+// Fprintln writes the translation of an informational message and a newline to w,
+// unless the Printer is quiet.
+func (p *Printer) Fprintln(w io.Writer, msg string) {
+	if !p.Quiet() {
+		p.translator().Fprintln(w, msg)
+	}
+}
+
+// Writer returns w, or a writer that discards what is written to it when the Printer
+// is quiet, for the progress output of programs run on behalf of a command.
+func (p *Printer) Writer(w io.Writer) io.Writer {
+	if p.Quiet() {
+		return io.Discard
+	}
+	return w
+}
+
+// Promptf writes the translation of a prompt, or of instructions a command waits on,
+// to w at every level.
+func (p *Printer) Promptf(w io.Writer, format string, a ...any) {
+	p.translator().Fprintf(w, format, a...)
+}
+
+func (p *Printer) translator() *i18n.Printer {
+	if p == nil {
+		return nil
+	}
+	return p.tr
+}
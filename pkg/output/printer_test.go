@@ -0,0 +1,40 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/eculver/aws-console/pkg/i18n"
+)
+
+func TestPrinterLevels(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		printer *Printer
+		want    string
+	}{
+		{name: "nil", printer: nil, want: "Opening AWS Console in your browser...\nWarning: stale cache\nSigned out\nMFA code for arn: "},
+		{name: "normal", printer: NewPrinter(i18n.English, LevelNormal), want: "Opening AWS Console in your browser...\nWarning: stale cache\nSigned out\nMFA code for arn: "},
+		{name: "quiet", printer: NewPrinter(i18n.English, LevelQuiet), want: "MFA code for arn: "},
+		{name: "quiet German", printer: NewPrinter(i18n.German, LevelQuiet), want: "MFA-Code für arn: "},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			tc.printer.Fprintln(buf, "Opening AWS Console in your browser...")
+			tc.printer.Fprintf(buf, "Warning: %v\n", "stale cache")
+			io.WriteString(tc.printer.Writer(buf), "Signed out\n")
+			tc.printer.Promptf(buf, "MFA code for %s: ", "arn")
+			if got := buf.String(); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const appName = "aws-console"
+
+// StateDir returns the directory for persistent local state such as usage data,
+// honoring XDG_STATE_HOME and defaulting to ~/.local/state/aws-console.
+func StateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// CacheDir returns the directory for disposable cached data such as credentials and
+// sign-in tokens, honoring XDG_CACHE_HOME and defaulting to ~/.cache/aws-console.
+func CacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// ConfigDir returns the directory for user configuration, honoring XDG_CONFIG_HOME
+// and defaulting to ~/.config/aws-console.
+func ConfigDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+func xdgDir(envVar string, fallback ...string) (string, error) {
+	if base := os.Getenv(envVar); base != "" {
+		return filepath.Join(base, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append(append([]string{home}, fallback...), appName)...), nil
+}
+
+// ExpandHome replaces a leading "~" in path with the user's home directory.
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,91 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "/home/tester")
+
+	dir, err := StateDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join("/home/tester", ".local", "state", "aws-console"); dir != want {
+		t.Fatalf("expected %q, got %q", want, dir)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	dir, err = StateDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join("/xdg/state", "aws-console"); dir != want {
+		t.Fatalf("expected %q, got %q", want, dir)
+	}
+}
+
+func TestCacheDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", "/home/tester")
+
+	dir, err := CacheDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join("/home/tester", ".cache", "aws-console"); dir != want {
+		t.Fatalf("expected %q, got %q", want, dir)
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	dir, err = CacheDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join("/xdg/cache", "aws-console"); dir != want {
+		t.Fatalf("expected %q, got %q", want, dir)
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/tester")
+
+	dir, err := ConfigDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join("/home/tester", ".config", "aws-console"); dir != want {
+		t.Fatalf("expected %q, got %q", want, dir)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	dir, err = ConfigDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join("/xdg/config", "aws-console"); dir != want {
+		t.Fatalf("expected %q, got %q", want, dir)
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	t.Setenv("HOME", "/home/tester")
+
+	for in, want := range map[string]string{
+		"~":           "/home/tester",
+		"~/cache/sso": filepath.Join("/home/tester", "cache", "sso"),
+		"/abs/path":   "/abs/path",
+		"rel/~/path":  "rel/~/path",
+	} {
+		got, err := ExpandHome(in)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Fatalf("ExpandHome(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package prompt implements the interactive prompts shown on a terminal.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrCanceled is returned when the user dismisses a prompt without choosing.
+var ErrCanceled = errors.New("selection canceled")
+
+// Picker asks the user to choose one of several items.
+type Picker interface {
+	// Pick returns the index of the chosen item. title names what is being chosen,
+	// e.g. "profile".
+	Pick(title string, items []string) (int, error)
+}
+
+// LinePicker is a line-based Picker: it lists the items with numbers, and the user
+// either enters a number or types a search that narrows the list with a fuzzy match.
+// It needs no terminal raw mode, so it works in any terminal and is easy to drive in tests.
+type LinePicker struct {
+	in  *bufio.Reader
+	out io.Writer
+	// sprintf formats the picker's messages, and translates titles passed through it.
+	sprintf func(format string, a ...any) string
+	// accessible words the list for screen readers.
+	accessible bool
+}
+
+// NewLinePicker creates a picker that reads answers from in and writes the list to out.
+func NewLinePicker(in io.Reader, out io.Writer) *LinePicker {
+	return &LinePicker{in: bufio.NewReader(in), out: out, sprintf: fmt.Sprintf}
+}
+
+// Localized returns a picker sharing p's input and output that formats its messages,
+// and the title, with sprintf, such as the Sprintf of an i18n.Printer.
+func (p *LinePicker) Localized(sprintf func(format string, a ...any) string) *LinePicker {
+	localized := *p
+	localized.sprintf = sprintf
+	return &localized
+}
+
+// Accessible returns a picker sharing p's input and output that words the list for a
+// screen reader: it announces how many items there are, and labels each with its
+// position, as in "2 of 4: prod".
+func (p *LinePicker) Accessible() *LinePicker {
+	accessible := *p
+	accessible.accessible = true
+	return &accessible
+}
+
+// Pick implements Picker. An empty answer or end of input cancels the prompt.
+func (p *LinePicker) Pick(title string, items []string) (int, error) {
+	if len(items) == 0 {
+		return 0, fmt.Errorf("no %ss to choose from", title)
+	}
+
+	title = p.sprintf(title)
+	matches := Filter("", items)
+	for {
+		if p.accessible {
+			fmt.Fprint(p.out, p.sprintf("Choices (%d):\n", len(matches)))
+			for i, idx := range matches {
+				fmt.Fprint(p.out, p.sprintf("%d of %d: %s\n", i+1, len(matches), items[idx]))
+			}
+			fmt.Fprint(p.out, p.sprintf("Type the number of a %s, or letters to search; enter nothing to cancel: ", title))
+		} else {
+			for i, idx := range matches {
+				fmt.Fprintf(p.out, "%3d) %s\n", i+1, items[idx])
+			}
+			fmt.Fprint(p.out, p.sprintf("Select a %s (number or search, empty to cancel): ", title))
+		}
+
+		line, err := p.in.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Fprintln(p.out)
+			return 0, ErrCanceled
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			return 0, ErrCanceled
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n >= 1 && n <= len(matches) {
+				return matches[n-1], nil
+			}
+			fmt.Fprint(p.out, p.sprintf("Enter a number between 1 and %d.\n", len(matches)))
+			continue
+		}
+
+		filtered := Filter(answer, items)
+		switch len(filtered) {
+		case 0:
+			fmt.Fprint(p.out, p.sprintf("No %ss match %q.\n", title, answer))
+		case 1:
+			return filtered[0], nil
+		default:
+			matches = filtered
+		}
+	}
+}
+
+// Filter returns the indexes of the items that fuzzy-match query, in order. An empty
+// query matches every item.
+func Filter(query string, items []string) []int {
+	matches := make([]int, 0, len(items))
+	for i, item := range items {
+		if FuzzyMatch(query, item) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// FuzzyMatch reports whether the characters of query appear in s in order, ignoring
+// case, so "pdadm" matches "prod-admin".
+func FuzzyMatch(query, s string) bool {
+	query = strings.ToLower(query)
+	s = strings.ToLower(s)
+	for _, r := range query {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
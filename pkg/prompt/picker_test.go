@@ -0,0 +1,129 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		query string
+		s     string
+		want  bool
+	}{
+		{query: "", s: "dev", want: true},
+		{query: "dev", s: "dev", want: true},
+		{query: "pdadm", s: "prod-admin", want: true},
+		{query: "PROD", s: "prod-admin", want: true},
+		{query: "admp", s: "prod-admin", want: false},
+		{query: "staging", s: "stage", want: false},
+	}
+
+	for _, tc := range testCases {
+		if got := FuzzyMatch(tc.query, tc.s); got != tc.want {
+			t.Fatalf("FuzzyMatch(%q, %q) = %v, want %v", tc.query, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestLinePickerPick(t *testing.T) {
+	t.Parallel()
+
+	items := []string{"dev", "prod-admin", "prod-readonly", "staging"}
+
+	testCases := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr error
+		wantOut string
+	}{
+		{name: "number", input: "2\n", want: 1},
+		{name: "unique search", input: "stg\n", want: 3},
+		{name: "narrowing search then number", input: "prod\n2\n", want: 2, wantOut: "  2) prod-readonly"},
+		{name: "out of range number", input: "9\n1\n", want: 0, wantOut: "Enter a number between 1 and 4."},
+		{name: "no matches", input: "xyz\n4\n", want: 3, wantOut: `No profiles match "xyz".`},
+		{name: "empty answer cancels", input: "\n", wantErr: ErrCanceled},
+		{name: "end of input cancels", input: "", wantErr: ErrCanceled},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := &bytes.Buffer{}
+			got, err := NewLinePicker(strings.NewReader(tc.input), out).Pick("profile", items)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected index %d, got %d", tc.want, got)
+			}
+			if !strings.Contains(out.String(), tc.wantOut) {
+				t.Fatalf("expected output to contain %q, got %q", tc.wantOut, out.String())
+			}
+		})
+	}
+}
+
+func TestLinePickerNoItems(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewLinePicker(strings.NewReader("1\n"), &bytes.Buffer{}).Pick("profile", nil)
+	if err == nil || !strings.Contains(err.Error(), "no profiles to choose from") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLinePickerLocalized(t *testing.T) {
+	t.Parallel()
+
+	messages := map[string]string{
+		"profile": "Profil",
+		"Select a %s (number or search, empty to cancel): ": "%s auswählen (Nummer oder Suche, leer zum Abbrechen): ",
+	}
+	sprintf := func(format string, a ...any) string {
+		if t, ok := messages[format]; ok {
+			format = t
+		}
+		return fmt.Sprintf(format, a...)
+	}
+
+	out := &bytes.Buffer{}
+	got, err := NewLinePicker(strings.NewReader("2\n"), out).Localized(sprintf).Pick("profile", []string{"dev", "prod"})
+	if err != nil || got != 1 {
+		t.Fatalf("Pick = %d, %v, want 1", got, err)
+	}
+	if !strings.Contains(out.String(), "Profil auswählen (Nummer oder Suche, leer zum Abbrechen): ") {
+		t.Fatalf("expected a translated prompt, got %q", out)
+	}
+}
+
+func TestLinePickerAccessible(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	got, err := NewLinePicker(strings.NewReader("prod\n2\n"), out).Accessible().Pick("profile", []string{"dev", "prod-admin", "prod-readonly"})
+	if err != nil || got != 2 {
+		t.Fatalf("Pick = %d, %v, want 2", got, err)
+	}
+	want := "Choices (3):\n1 of 3: dev\n2 of 3: prod-admin\n3 of 3: prod-readonly\n" +
+		"Type the number of a profile, or letters to search; enter nothing to cancel: " +
+		"Choices (2):\n1 of 2: prod-admin\n2 of 2: prod-readonly\n" +
+		"Type the number of a profile, or letters to search; enter nothing to cancel: "
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
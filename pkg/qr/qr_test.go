@@ -0,0 +1,317 @@
+package qr
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	t.Parallel()
+
+	// The 1-M "HELLO WORLD" example of the standard's tutorial literature.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	t.Parallel()
+
+	if got := formatBits(0); got != 0b111011111000100 {
+		t.Fatalf("unexpected format bits for L mask 0: %015b", got)
+	}
+	if got := formatBits(7); got != 0b110100101110110 {
+		t.Fatalf("unexpected format bits for L mask 7: %015b", got)
+	}
+	if got := versionBits(7); got != 0x07C94 {
+		t.Fatalf("unexpected version bits for version 7: %#x", got)
+	}
+	if got := versionBits(40); got != 0x28C69 {
+		t.Fatalf("unexpected version bits for version 40: %#x", got)
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		version int
+		want    []int
+	}{
+		{version: 1},
+		{version: 2, want: []int{6, 18}},
+		{version: 7, want: []int{6, 22, 38}},
+		{version: 32, want: []int{6, 34, 60, 86, 112, 138}},
+		{version: 40, want: []int{6, 30, 58, 86, 114, 142, 170}},
+	}
+	for _, tc := range testCases {
+		got := alignmentPositions(tc.version)
+		if len(got) != len(tc.want) {
+			t.Fatalf("version %d: expected %v, got %v", tc.version, tc.want, got)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("version %d: expected %v, got %v", tc.version, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	signin := "https://signin.aws.amazon.com/federation?Action=login&Destination=https%3A%2F%2Fconsole.aws.amazon.com%2F&SigninToken=" + strings.Repeat("a1B2c3D4", 150)
+
+	testCases := []struct {
+		name        string
+		text        string
+		wantVersion int
+	}{
+		{name: "short", text: "hello", wantVersion: 1},
+		{name: "version 1 capacity", text: strings.Repeat("x", 17), wantVersion: 1},
+		{name: "version 2", text: strings.Repeat("x", 18), wantVersion: 2},
+		{name: "wide count field", text: strings.Repeat("y", 271), wantVersion: 10},
+		{name: "version 11", text: strings.Repeat("y", 272), wantVersion: 11},
+		{name: "sign-in URL", text: signin, wantVersion: 26},
+		{name: "largest", text: strings.Repeat("z", MaxBytes), wantVersion: 40},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := Encode(tc.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.Version != tc.wantVersion || c.Size() != 17+4*tc.wantVersion {
+				t.Fatalf("expected version %d, got %d (size %d)", tc.wantVersion, c.Version, c.Size())
+			}
+			if got := decode(t, c); got != tc.text {
+				t.Fatalf("decoded %q, want %q", got, tc.text)
+			}
+		})
+	}
+}
+
+// TestEncodeGolden compares codes with testdata/*.golden, the output of a reference
+// encoder, github.com/skip2/go-qrcode at level Low with its border disabled, one row
+// of modules per line with '#' for dark. Encoders may pick different masks, since
+// they score the penalty rules differently, so the code is masked with the golden
+// one's mask first; everything else must match module for module.
+func TestEncodeGolden(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		text        string
+		wantVersion int
+		mask        int
+	}{
+		{name: "hello", text: "hello", wantVersion: 1, mask: 7},
+		{name: "version-2", text: strings.Repeat("x", 18), wantVersion: 2, mask: 0},
+		{name: "federation-url", text: "https://signin.aws.amazon.com/federation?action=login", wantVersion: 3, mask: 6},
+		{name: "console-url", text: "https://console.aws.amazon.com/s3/home?region=eu-west-1#/buckets/my-bucket-name?prefix=logs/", wantVersion: 5, mask: 4},
+		{name: "version-12", text: "aws-console sign-in token: " + strings.Repeat("q1w2e3r4t5", 30), wantVersion: 12, mask: 2},
+		{name: "version-26", text: "https://signin.aws.amazon.com/federation?action=login&signintoken=" + strings.Repeat("a1b2c3d4e5f6g7h8", 80), wantVersion: 26, mask: 2},
+		{name: "version-40", text: strings.Repeat("z", MaxBytes), wantVersion: 40, mask: 1},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			golden, err := os.ReadFile(filepath.Join("testdata", tc.name+".golden"))
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+			want := strings.Split(strings.TrimSuffix(string(golden), "\n"), "\n")
+
+			c, err := Encode(tc.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.Version != tc.wantVersion || len(want) != c.Size() {
+				t.Fatalf("expected version %d with %d rows, got version %d", tc.wantVersion, len(want), c.Version)
+			}
+			c.applyMask(c.mask)
+			c.applyMask(tc.mask)
+			c.drawFormat(tc.mask)
+
+			for y, row := range want {
+				var got strings.Builder
+				for x := 0; x < c.Size(); x++ {
+					if c.Dark(x, y) {
+						got.WriteByte('#')
+					} else {
+						got.WriteByte('.')
+					}
+				}
+				if got.String() != row {
+					t.Fatalf("row %d differs:\nexpected %s\ngot      %s", y, row, got.String())
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	t.Parallel()
+
+	_, err := Encode(strings.Repeat("z", MaxBytes+1))
+	if err == nil || !strings.Contains(err.Error(), "too long for a QR code (2954 bytes, at most 2953)") {
+		t.Fatalf("expected a length error, got %v", err)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	t.Parallel()
+
+	c, err := Encode("hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.Terminal(), "\n"), "\n")
+	// 21 modules plus the quiet zone, two rows per line.
+	if len(lines) != 15 {
+		t.Fatalf("expected 15 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		body := strings.TrimSuffix(strings.TrimPrefix(line, "\x1b[30;107m"), "\x1b[0m")
+		if n := len([]rune(body)); n != 29 {
+			t.Fatalf("expected 29 columns, got %d in %q", n, line)
+		}
+	}
+	// The top-left finder pattern starts on the fifth column of the third line.
+	if first := []rune(strings.TrimPrefix(lines[2], "\x1b[30;107m")); string(first[:4]) != "    " || first[4] != '█' {
+		t.Fatalf("unexpected finder pattern row %q", lines[2])
+	}
+}
+
+func TestMonochrome(t *testing.T) {
+	t.Parallel()
+
+	c, err := Encode("hello")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := c.Monochrome()
+	if strings.Contains(out, "\x1b") {
+		t.Fatalf("expected no escape sequences, got %q", out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 15 {
+		t.Fatalf("expected 15 lines, got %d", len(lines))
+	}
+	// The quiet zone is drawn, and the finder pattern left blank.
+	if first := []rune(lines[2]); string(first[:4]) != "████" || first[4] != ' ' {
+		t.Fatalf("unexpected finder pattern row %q", lines[2])
+	}
+}
+
+// decode reads a code back: the format information, the mask, the interleaved
+// codewords, and the byte mode segment they carry.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+
+	format := 0
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | bitOf(c.Dark(14-i, 8))
+	}
+	format = format<<1 | bitOf(c.Dark(7, 8))
+	format = format<<1 | bitOf(c.Dark(8, 8))
+	format = format<<1 | bitOf(c.Dark(8, 7))
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | bitOf(c.Dark(8, i))
+	}
+	format ^= 0x5412
+	if format>>13 != formatLevelL {
+		t.Fatalf("unexpected error correction level in format %015b", format)
+	}
+	mask := format >> 10 & 7
+
+	// Collect data modules column pair by column pair, alternating direction.
+	var bits []bool
+	upward := true
+	for right := c.size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for n := 0; n < c.size; n++ {
+			y := n
+			if upward {
+				y = c.size - 1 - n
+			}
+			for _, x := range []int{right, right - 1} {
+				if !c.function[y][x] {
+					bits = append(bits, c.Dark(x, y) != maskBit(mask, x, y))
+				}
+			}
+		}
+		upward = !upward
+	}
+
+	raw := rawModules(c.Version) / 8
+	codewords := make([]byte, raw)
+	for i := 0; i < raw*8; i++ {
+		if bits[i] {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	// De-interleave the data codewords and check each block's error correction.
+	numBlocks, eccLen := eccBlocks[c.Version], eccCodewordsPerBlock[c.Version]
+	numShort := numBlocks - raw%numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < raw/numBlocks-eccLen+1; i++ {
+		for j := range blocks {
+			if i < raw/numBlocks-eccLen || j >= numShort {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for j := range blocks {
+		ecc := make([]byte, eccLen)
+		for i := range ecc {
+			ecc[i] = codewords[k+i*numBlocks+j]
+		}
+		if !bytes.Equal(rsRemainder(blocks[j], rsDivisor(eccLen)), ecc) {
+			t.Fatalf("block %d has wrong error correction codewords", j)
+		}
+		data = append(data, blocks[j]...)
+	}
+
+	read := func(pos, n int) int {
+		v := 0
+		for i := pos; i < pos+n; i++ {
+			v = v<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+		return v
+	}
+	if mode := read(0, 4); mode != 0x4 {
+		t.Fatalf("expected byte mode, got %#x", mode)
+	}
+	n := read(4, countBits(c.Version))
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(read(4+countBits(c.Version)+8*i, 8))
+	}
+	return string(out)
+}
+
+func bitOf(dark bool) int {
+	if dark {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+// Package redirect serves sign-in URLs from a loopback address, each to a single
+// request, so a browser can be opened with a short local URL instead of a sign-in URL
+// that would linger in process lists and shell history.
+package redirect
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Path is the path of every one-time URL; a token in the query tells them apart.
+const Path = "/once"
+
+// Server redirects each one-time URL to its target once.
+type Server struct {
+	listener net.Listener
+	server   *http.Server
+
+	mu      sync.Mutex
+	targets map[string]string
+	// served receives a value each time a URL is used.
+	served chan struct{}
+}
+
+// Start listens on a free port of 127.0.0.1 and serves one-time URLs until Close.
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the sign-in redirect: %w", err)
+	}
+	s := &Server{listener: listener, targets: make(map[string]string), served: make(chan struct{}, 1)}
+	s.server = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = s.server.Serve(listener) }()
+	return s, nil
+}
+
+// Add returns a URL that redirects to target the first time it is requested.
+func (s *Server) Add(target string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate a redirect token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	s.targets[token] = target
+	s.mu.Unlock()
+	return fmt.Sprintf("http://%s%s?token=%s", s.listener.Addr(), Path, token), nil
+}
+
+// Pending returns how many URLs have not been used yet.
+func (s *Server) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.targets)
+}
+
+// Wait returns once every URL has been used, or fails after timeout.
+func (s *Server) Wait(ctx context.Context, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for s.Pending() > 0 {
+		select {
+		case <-s.served:
+		case <-timer.C:
+			return fmt.Errorf("the browser did not open the sign-in link within %s", timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close invalidates the URLs not used yet and stops the server once the responses in
+// flight are written.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	clear(s.targets)
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("failed to stop the sign-in redirect: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP redirects a one-time URL to its target and forgets it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != Path {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// Only loopback hostnames are accepted so a page on another site cannot reach the
+	// server through DNS rebinding.
+	if host, _, err := net.SplitHostPort(r.Host); err != nil || !loopbackHost(host) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	s.mu.Lock()
+	target, ok := s.targets[token]
+	delete(s.targets, token)
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "This sign-in link has already been used or has expired.", http.StatusGone)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	http.Redirect(w, r, target, http.StatusFound)
+	select {
+	case s.served <- struct{}{}:
+	default:
+	}
+}
+
+func loopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
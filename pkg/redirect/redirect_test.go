@@ -0,0 +1,82 @@
+package redirect
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	s, err := Start()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	const target = "https://signin.aws.amazon.com/federation?Action=login&SigninToken=secret"
+	once, err := s.Add(target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(once, "http://127.0.0.1:") || strings.Contains(once, "secret") {
+		t.Fatalf("unexpected one-time URL %q", once)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	get := func(rawURL, host string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		if host != "" {
+			req.Host = host
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := get(once, "attacker.example:80"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected a foreign host to be refused, got %d", resp.StatusCode)
+	}
+	if resp := get(strings.Replace(once, "token=", "token=x", 1), ""); resp.StatusCode != http.StatusGone {
+		t.Fatalf("expected an unknown token to be refused, got %d", resp.StatusCode)
+	}
+
+	resp := get(once, "")
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != target {
+		t.Fatalf("expected a redirect to the target, got %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if err := s.Wait(context.Background(), time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp := get(once, ""); resp.StatusCode != http.StatusGone {
+		t.Fatalf("expected the URL to work only once, got %d", resp.StatusCode)
+	}
+}
+
+func TestServerWaitTimeout(t *testing.T) {
+	t.Parallel()
+
+	s, err := Start()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	if _, err := s.Add("https://example.com/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = s.Wait(context.Background(), 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "did not open the sign-in link within 10ms") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
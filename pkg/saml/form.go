@@ -0,0 +1,97 @@
+package saml
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	formPattern  = regexp.MustCompile(`(?is)<form\b([^>]*)>(.*?)</form>`)
+	inputPattern = regexp.MustCompile(`(?is)<input\b([^>]*)>`)
+	attrPattern  = regexp.MustCompile(`(?s)([\w:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// form is an HTML form of an identity provider's page.
+type form struct {
+	action string
+	values url.Values
+	// username and password name the form's login fields, if it has them.
+	username string
+	password string
+}
+
+// fill sets the login fields of f to l.
+func (f *form) fill(l Login) {
+	f.values.Set(f.username, l.Username)
+	f.values.Set(f.password, l.Password)
+}
+
+// parseForms returns the forms of page. HTML is not parsed in full: identity providers'
+// forms are simple enough to be read from their input tags.
+func parseForms(page string) []form {
+	var forms []form
+	for _, m := range formPattern.FindAllStringSubmatch(page, -1) {
+		f := form{action: attributes(m[1])["action"], values: url.Values{}}
+		for _, input := range inputPattern.FindAllStringSubmatch(m[2], -1) {
+			attrs := attributes(input[1])
+			name := attrs["name"]
+			if name == "" {
+				continue
+			}
+			switch strings.ToLower(attrs["type"]) {
+			case "submit", "button", "image", "reset":
+				continue
+			case "password":
+				f.password = name
+			default:
+				if f.username == "" && isUsernameField(name) {
+					f.username = name
+				}
+			}
+			f.values.Set(name, attrs["value"])
+		}
+		forms = append(forms, f)
+	}
+	return forms
+}
+
+// attributes returns the attributes of a tag, with their names in lower case and
+// their values unescaped.
+func attributes(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attrPattern.FindAllStringSubmatch(tag, -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return attrs
+}
+
+// findResponse returns the SAMLResponse posted by a form of page, or "".
+func findResponse(page string) string {
+	for _, f := range parseForms(page) {
+		if response := f.values.Get("SAMLResponse"); response != "" {
+			return response
+		}
+	}
+	return ""
+}
+
+// findLoginForm returns the first form of page with a username and a password field.
+func findLoginForm(page string) (form, bool) {
+	for _, f := range parseForms(page) {
+		if f.username != "" && f.password != "" {
+			return f, true
+		}
+	}
+	return form{}, false
+}
+
+// findForm returns the first form of page, such as one submitted on page load.
+func findForm(page string) (form, bool) {
+	forms := parseForms(page)
+	if len(forms) == 0 {
+		return form{}, false
+	}
+	return forms[0], true
+}
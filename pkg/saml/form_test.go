@@ -0,0 +1,38 @@
+package saml
+
+import "testing"
+
+func TestParseForms(t *testing.T) {
+	t.Parallel()
+
+	page := `<html><body>
+<form method="post" id="loginForm" action="/adfs/ls/?SAMLRequest=abc&amp;client-request-id=1">
+  <input id="userNameInput" name="UserName" type="email" value="" />
+  <input id="passwordInput" name='Password' type="password">
+  <input type=hidden name=AuthMethod value=FormsAuthentication>
+  <input type="submit" name="submit" value="Sign in">
+</form>
+<form action="https://signin.aws.amazon.com/saml"><input type="hidden" name="SAMLResponse" value="UkVTUE9OU0U="/></form>
+</body></html>`
+
+	forms := parseForms(page)
+	if len(forms) != 2 {
+		t.Fatalf("expected 2 forms, got %d", len(forms))
+	}
+	login := forms[0]
+	if login.action != "/adfs/ls/?SAMLRequest=abc&client-request-id=1" {
+		t.Fatalf("unexpected action %q", login.action)
+	}
+	if login.username != "UserName" || login.password != "Password" {
+		t.Fatalf("unexpected login fields %q and %q", login.username, login.password)
+	}
+	if login.values.Get("AuthMethod") != "FormsAuthentication" || login.values.Has("submit") {
+		t.Fatalf("unexpected form values %v", login.values)
+	}
+	if got, ok := findLoginForm(page); !ok || got.action != login.action {
+		t.Fatalf("expected the login form, got %+v", got)
+	}
+	if got := findResponse(page); got != "UkVTUE9OU0U=" {
+		t.Fatalf("expected the SAML response, got %q", got)
+	}
+}
@@ -0,0 +1,116 @@
+package saml
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attributes AWS reads from a SAML assertion.
+const (
+	roleAttribute            = "https://aws.amazon.com/SAML/Attributes/Role"
+	sessionDurationAttribute = "https://aws.amazon.com/SAML/Attributes/SessionDuration"
+)
+
+// Role is a role a SAML assertion lets its subject assume.
+type Role struct {
+	RoleARN string
+	// PrincipalARN is the IAM SAML provider that trusts the identity provider.
+	PrincipalARN string
+}
+
+// Response is what a SAML response grants in AWS.
+type Response struct {
+	// Assertion is the base64 encoded SAMLResponse, as passed to
+	// sts:AssumeRoleWithSAML.
+	Assertion string
+	Roles     []Role
+	// SessionDuration is how long role sessions may last, or zero when the identity
+	// provider leaves it to the role.
+	SessionDuration time.Duration
+}
+
+type samlResponse struct {
+	Attributes []struct {
+		Name   string   `xml:"Name,attr"`
+		Values []string `xml:"AttributeValue"`
+	} `xml:"Assertion>AttributeStatement>Attribute"`
+	EncryptedAssertion *struct{} `xml:"EncryptedAssertion"`
+}
+
+// ParseResponse decodes the base64 encoded SAMLResponse assertion and reads the roles
+// and session duration it grants in AWS.
+func ParseResponse(assertion string) (Response, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(assertion))
+	if err != nil {
+		return Response{}, fmt.Errorf("invalid SAML response: %w", err)
+	}
+	var doc samlResponse
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return Response{}, fmt.Errorf("invalid SAML response: %w", err)
+	}
+	if doc.EncryptedAssertion != nil {
+		return Response{}, errors.New("the SAML assertion is encrypted; AWS needs it unencrypted")
+	}
+
+	r := Response{Assertion: strings.TrimSpace(assertion)}
+	for _, attr := range doc.Attributes {
+		switch attr.Name {
+		case roleAttribute:
+			for _, value := range attr.Values {
+				role, err := parseRole(value)
+				if err != nil {
+					return Response{}, err
+				}
+				r.Roles = append(r.Roles, role)
+			}
+		case sessionDurationAttribute:
+			if len(attr.Values) == 0 {
+				continue
+			}
+			seconds, err := strconv.Atoi(strings.TrimSpace(attr.Values[0]))
+			if err != nil || seconds <= 0 {
+				return Response{}, fmt.Errorf("invalid SessionDuration %q in the SAML assertion", attr.Values[0])
+			}
+			r.SessionDuration = time.Duration(seconds) * time.Second
+		}
+	}
+	if len(r.Roles) == 0 {
+		return Response{}, errors.New("the SAML assertion grants no AWS roles (no " + roleAttribute + " attribute)")
+	}
+	return r, nil
+}
+
+// parseRole reads a Role attribute value: a role ARN and a SAML provider ARN separated
+// by a comma, in either order.
+func parseRole(value string) (Role, error) {
+	first, second, ok := strings.Cut(strings.TrimSpace(value), ",")
+	if !ok {
+		return Role{}, fmt.Errorf("invalid role %q in the SAML assertion: expected a role ARN and a SAML provider ARN", value)
+	}
+	first, second = strings.TrimSpace(first), strings.TrimSpace(second)
+	if strings.Contains(first, ":saml-provider/") {
+		first, second = second, first
+	}
+	if !strings.Contains(first, ":role/") || !strings.Contains(second, ":saml-provider/") {
+		return Role{}, fmt.Errorf("invalid role %q in the SAML assertion: expected a role ARN and a SAML provider ARN", value)
+	}
+	return Role{RoleARN: first, PrincipalARN: second}, nil
+}
+
+// Role returns the role of r with roleARN, or its only role when roleARN is empty.
+func (r Response) Role(roleARN string) (Role, error) {
+	for _, role := range r.Roles {
+		if role.RoleARN == roleARN || (roleARN == "" && len(r.Roles) == 1) {
+			return role, nil
+		}
+	}
+	if roleARN == "" {
+		return Role{}, fmt.Errorf("the SAML assertion grants %d roles; choose one", len(r.Roles))
+	}
+	return Role{}, fmt.Errorf("the SAML assertion does not grant role %s", roleARN)
+}
@@ -0,0 +1,109 @@
+package saml
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testRoleARN     = "arn:aws:iam::123456789012:role/Admin"
+	testOtherRole   = "arn:aws:iam::123456789012:role/ReadOnly"
+	testProviderARN = "arn:aws:iam::123456789012:saml-provider/ADFS"
+)
+
+// testAssertion returns a base64 encoded SAML response with attributes, written as
+// name=value pairs.
+func testAssertion(attributes ...string) string {
+	var b strings.Builder
+	b.WriteString(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion">`)
+	b.WriteString(`<saml:Assertion><saml:AttributeStatement>`)
+	for _, attr := range attributes {
+		name, value, _ := strings.Cut(attr, "=")
+		b.WriteString(`<saml:Attribute Name="` + name + `"><saml:AttributeValue>` + value + `</saml:AttributeValue></saml:Attribute>`)
+	}
+	b.WriteString(`</saml:AttributeStatement></saml:Assertion></samlp:Response>`)
+	return base64.StdEncoding.EncodeToString([]byte(b.String()))
+}
+
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		assertion     string
+		wantRoles     []Role
+		wantDuration  time.Duration
+		wantErrSubstr string
+	}{
+		{
+			name:         "role and duration",
+			assertion:    testAssertion(roleAttribute+"="+testRoleARN+","+testProviderARN, sessionDurationAttribute+"=28800"),
+			wantRoles:    []Role{{RoleARN: testRoleARN, PrincipalARN: testProviderARN}},
+			wantDuration: 8 * time.Hour,
+		},
+		{
+			name:      "provider first",
+			assertion: testAssertion(roleAttribute + "=" + testProviderARN + "," + testOtherRole),
+			wantRoles: []Role{{RoleARN: testOtherRole, PrincipalARN: testProviderARN}},
+		},
+		{name: "not base64", assertion: "not base64!", wantErrSubstr: "invalid SAML response"},
+		{name: "no roles", assertion: testAssertion("name=alice"), wantErrSubstr: "grants no AWS roles"},
+		{name: "bad role", assertion: testAssertion(roleAttribute + "=" + testRoleARN), wantErrSubstr: "expected a role ARN and a SAML provider ARN"},
+		{name: "bad duration", assertion: testAssertion(roleAttribute+"="+testRoleARN+","+testProviderARN, sessionDurationAttribute+"=soon"), wantErrSubstr: `invalid SessionDuration "soon"`},
+		{
+			name:          "encrypted",
+			assertion:     base64.StdEncoding.EncodeToString([]byte(`<Response><EncryptedAssertion/></Response>`)),
+			wantErrSubstr: "encrypted",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseResponse(tc.assertion)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got.Roles) != len(tc.wantRoles) || got.Roles[0] != tc.wantRoles[0] {
+				t.Fatalf("expected roles %+v, got %+v", tc.wantRoles, got.Roles)
+			}
+			if got.SessionDuration != tc.wantDuration {
+				t.Fatalf("expected session duration %s, got %s", tc.wantDuration, got.SessionDuration)
+			}
+			if got.Assertion != tc.assertion {
+				t.Fatal("expected the assertion to be kept for AssumeRoleWithSAML")
+			}
+		})
+	}
+}
+
+func TestResponseRole(t *testing.T) {
+	t.Parallel()
+
+	admin := Role{RoleARN: testRoleARN, PrincipalARN: testProviderARN}
+	readOnly := Role{RoleARN: testOtherRole, PrincipalARN: testProviderARN}
+
+	if role, err := (Response{Roles: []Role{admin}}).Role(""); err != nil || role != admin {
+		t.Fatalf("expected the only role, got %+v, %v", role, err)
+	}
+	both := Response{Roles: []Role{admin, readOnly}}
+	if role, err := both.Role(testOtherRole); err != nil || role != readOnly {
+		t.Fatalf("expected the named role, got %+v, %v", role, err)
+	}
+	if _, err := both.Role(""); err == nil || !strings.Contains(err.Error(), "grants 2 roles") {
+		t.Fatalf("expected an error asking to choose a role, got %v", err)
+	}
+	if _, err := both.Role("arn:aws:iam::123456789012:role/Other"); err == nil || !strings.Contains(err.Error(), "does not grant role") {
+		t.Fatalf("expected an error for a role not granted, got %v", err)
+	}
+}
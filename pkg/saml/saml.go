@@ -0,0 +1,201 @@
+// Package saml signs in to a SAML identity provider, such as AD FS or Keycloak, and
+// returns the SAML assertion it issues for AWS, for sts:AssumeRoleWithSAML.
+package saml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Drivers of identity providers.
+const (
+	// DriverADFS signs in to Active Directory Federation Services with forms
+	// authentication, through its IdP-initiated sign-on page.
+	DriverADFS = "adfs"
+	// DriverKeycloak signs in to the IdP-initiated SSO URL of a Keycloak client for AWS.
+	DriverKeycloak = "keycloak"
+	// DriverCommand runs a command that prints the identity provider's response, such
+	// as curl --negotiate for Kerberos (Windows integrated) authentication.
+	DriverCommand = "command"
+)
+
+// Drivers lists the drivers New accepts.
+var Drivers = []string{DriverADFS, DriverKeycloak, DriverCommand}
+
+// maxPageSize bounds the pages read from an identity provider.
+const maxPageSize = 4 << 20
+
+// Login is the username and password to sign in to the identity provider with.
+type Login struct {
+	Username string
+	Password string
+}
+
+// Provider is a SAML identity provider driver.
+type Provider interface {
+	// Assertion signs in and returns the base64 encoded SAMLResponse the identity
+	// provider posts to AWS. login is only called when the identity provider asks for
+	// a username and password.
+	Assertion(ctx context.Context, login func() (Login, error)) (string, error)
+}
+
+// Options configure a Provider.
+type Options struct {
+	// URL is the identity provider: the AD FS host, or the IdP-initiated SSO URL of
+	// the Keycloak client.
+	URL string
+	// Transport carries requests to the identity provider; nil uses
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// Run runs the command of DriverCommand and returns its output.
+	Run func(ctx context.Context) ([]byte, error)
+}
+
+// New returns the Provider of driver.
+func New(driver string, opts Options) (Provider, error) {
+	switch driver {
+	case DriverADFS, DriverKeycloak:
+		u, err := url.Parse(opts.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s URL %q: expected an https:// URL", driver, opts.URL)
+		}
+		if driver == DriverADFS && (u.Path == "" || u.Path == "/") {
+			u.Path = "/adfs/ls/IdpInitiatedSignOn.aspx"
+			u.RawQuery = "loginToRp=urn:amazon:webservices"
+		}
+		jar, _ := cookiejar.New(nil)
+		client := &http.Client{Transport: opts.Transport, Jar: jar}
+		return &formProvider{url: u.String(), host: u.Host, client: client}, nil
+	case DriverCommand:
+		if opts.Run == nil {
+			return nil, errors.New("the command driver needs a command")
+		}
+		return commandProvider(opts.Run), nil
+	}
+	return nil, fmt.Errorf("unknown SAML driver %q (expected %s)", driver, strings.Join(Drivers, ", "))
+}
+
+// formProvider signs in through the HTML login form of an identity provider, as a
+// browser would: it fills the username and password in, posts the form, and follows
+// any forms posted on page load until one carries the SAMLResponse.
+type formProvider struct {
+	url string
+	// host is the identity provider's host, the only one forms are posted to.
+	host   string
+	client *http.Client
+}
+
+func (p *formProvider) Assertion(ctx context.Context, login func() (Login, error)) (string, error) {
+	page, pageURL, err := p.fetch(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return "", err
+	}
+	loggedIn := false
+	// Identity providers may chain a few auto-submitted forms, such as a home realm
+	// choice, before the response.
+	for range 5 {
+		if response := findResponse(page); response != "" {
+			return response, nil
+		}
+		form, ok := findLoginForm(page)
+		if !ok {
+			form, ok = findForm(page)
+		} else {
+			if loggedIn {
+				return "", errors.New("the identity provider rejected the username or password")
+			}
+			l, err := login()
+			if err != nil {
+				return "", err
+			}
+			form.fill(l)
+			loggedIn = true
+		}
+		if !ok {
+			break
+		}
+		action, err := resolveAction(pageURL, form.action, p.host)
+		if err != nil {
+			return "", err
+		}
+		if page, pageURL, err = p.fetch(ctx, http.MethodPost, action, form.values); err != nil {
+			return "", err
+		}
+	}
+	return "", errors.New("the identity provider did not return a SAML response for AWS")
+}
+
+// fetch requests target and returns the page along with its URL after redirects.
+func (p *formProvider) fetch(ctx context.Context, method, target string, values url.Values) (string, *url.URL, error) {
+	var body io.Reader
+	if values != nil {
+		body = strings.NewReader(values.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return "", nil, err
+	}
+	if values != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to reach the identity provider: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read the identity provider's response: %w", err)
+	}
+	if resp.StatusCode >= 400 && findResponse(string(data)) == "" {
+		return "", nil, fmt.Errorf("the identity provider returned %s", resp.Status)
+	}
+	return string(data), resp.Request.URL, nil
+}
+
+// resolveAction returns the URL a form of the page at base posts to, which must be an
+// https:// URL on host: a form elsewhere, or a page redirected elsewhere, is refused
+// rather than sent the password or session cookies.
+func resolveAction(base *url.URL, action, host string) (string, error) {
+	u, err := base.Parse(action)
+	if err != nil {
+		return "", fmt.Errorf("invalid form action %q: %w", action, err)
+	}
+	if u.Scheme != "https" || u.Host != host {
+		return "", fmt.Errorf("refusing to post credentials to %s, which is not the identity provider %s", u.Redacted(), host)
+	}
+	return u.String(), nil
+}
+
+// commandProvider runs a command and takes the SAMLResponse from its output: the page
+// the identity provider returned, or the bare base64 encoded response.
+type commandProvider func(ctx context.Context) ([]byte, error)
+
+func (run commandProvider) Assertion(ctx context.Context, _ func() (Login, error)) (string, error) {
+	out, err := run(ctx)
+	if err != nil {
+		return "", fmt.Errorf("the SAML command failed: %w", err)
+	}
+	if response := findResponse(string(out)); response != "" {
+		return response, nil
+	}
+	response := strings.TrimSpace(string(out))
+	if response == "" || strings.ContainsAny(response, "<> \n") {
+		return "", errors.New("the SAML command printed no SAML response")
+	}
+	return response, nil
+}
+
+// usernameFields are the input names login forms are known to use for the username.
+var usernameFields = []string{"UserName", "username", "j_username", "login"}
+
+func isUsernameField(name string) bool {
+	return slices.Contains(usernameFields, name)
+}
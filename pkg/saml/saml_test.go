@@ -0,0 +1,205 @@
+package saml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeIdP serves a login form at every path but /login, which the form posts to, and
+// answers a correct login with an auto-submitted form carrying the SAML response.
+func fakeIdP(t *testing.T, usernameField, passwordField string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			http.Error(w, "no session", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue(usernameField) != "alice" || r.FormValue(passwordField) != "s3cret" || r.FormValue("AuthMethod") != "FormsAuthentication" {
+			fmt.Fprint(w, loginPage(usernameField, passwordField))
+			return
+		}
+		fmt.Fprintf(w, `<form method="post" action="https://signin.aws.amazon.com/saml"><input type="hidden" name="SAMLResponse" value="%s" /></form>`, testAssertion(roleAttribute+"="+testRoleARN+","+testProviderARN))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Path: "/"})
+		fmt.Fprint(w, loginPage(usernameField, passwordField))
+	})
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func loginPage(usernameField, passwordField string) string {
+	return `<form method="post" action="/login"><input name="` + usernameField + `"><input type="password" name="` + passwordField + `"><input type="hidden" name="AuthMethod" value="FormsAuthentication"></form>`
+}
+
+func TestFormProviderAssertion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		driver        string
+		usernameField string
+		passwordField string
+		path          string
+		login         Login
+		wantErrSubstr string
+	}{
+		{name: "adfs", driver: DriverADFS, usernameField: "UserName", passwordField: "Password", login: Login{Username: "alice", Password: "s3cret"}},
+		{name: "keycloak", driver: DriverKeycloak, usernameField: "username", passwordField: "password", path: "/realms/corp/protocol/saml/clients/amazon-aws", login: Login{Username: "alice", Password: "s3cret"}},
+		{name: "wrong password", driver: DriverADFS, usernameField: "UserName", passwordField: "Password", login: Login{Username: "alice", Password: "wrong"}, wantErrSubstr: "rejected the username or password"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := fakeIdP(t, tc.usernameField, tc.passwordField)
+			provider, err := New(tc.driver, Options{URL: server.URL + tc.path, Transport: server.Client().Transport})
+			if err != nil {
+				t.Fatal(err)
+			}
+			logins := 0
+			assertion, err := provider.Assertion(context.Background(), func() (Login, error) {
+				logins++
+				return tc.login, nil
+			})
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if logins != 1 {
+				t.Fatalf("expected to ask for the login once, got %d", logins)
+			}
+			response, err := ParseResponse(assertion)
+			if err != nil || response.Roles[0].RoleARN != testRoleARN {
+				t.Fatalf("unexpected assertion %+v, %v", response, err)
+			}
+		})
+	}
+}
+
+func TestFormProviderRefusesOtherHosts(t *testing.T) {
+	t.Parallel()
+
+	// The login form posts to a host other than the identity provider's.
+	var posted bool
+	elsewhere := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted = true
+	}))
+	defer elsewhere.Close()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Replace(loginPage("UserName", "Password"), `action="/login"`, `action="`+elsewhere.URL+`/login"`, 1))
+	}))
+	defer server.Close()
+
+	provider, err := New(DriverADFS, Options{URL: server.URL, Transport: server.Client().Transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = provider.Assertion(context.Background(), func() (Login, error) {
+		return Login{Username: "alice", Password: "s3cret"}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "refusing to post credentials to "+elsewhere.URL+"/login") {
+		t.Fatalf("expected the form to be refused, got %v", err)
+	}
+	if posted {
+		t.Fatal("expected nothing posted to the other host")
+	}
+}
+
+func TestCommandProviderAssertion(t *testing.T) {
+	t.Parallel()
+
+	assertion := testAssertion(roleAttribute + "=" + testRoleARN + "," + testProviderARN)
+	testCases := []struct {
+		name          string
+		output        string
+		err           error
+		wantErrSubstr string
+	}{
+		{name: "page", output: `<html><form><input type="hidden" name="SAMLResponse" value="` + assertion + `"></form></html>`},
+		{name: "bare response", output: assertion + "\n"},
+		{name: "error page", output: "<html>Unauthorized</html>", wantErrSubstr: "printed no SAML response"},
+		{name: "failure", err: errors.New("exit status 6"), wantErrSubstr: "the SAML command failed: exit status 6"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			provider, err := New(DriverCommand, Options{Run: func(ctx context.Context) ([]byte, error) {
+				return []byte(tc.output), tc.err
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := provider.Assertion(context.Background(), func() (Login, error) {
+				t.Fatal("the command driver should not ask for a login")
+				return Login{}, nil
+			})
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != assertion {
+				t.Fatalf("expected the assertion, got %q", got)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		driver        string
+		url           string
+		wantErrSubstr string
+	}{
+		{driver: DriverADFS, url: "https://adfs.example.com"},
+		{driver: DriverADFS, url: "http://adfs.example.com", wantErrSubstr: "expected an https:// URL"},
+		{driver: DriverKeycloak, url: "", wantErrSubstr: "invalid keycloak URL"},
+		{driver: DriverCommand, wantErrSubstr: "needs a command"},
+		{driver: "okta", wantErrSubstr: `unknown SAML driver "okta"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.driver+tc.url, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := New(tc.driver, Options{URL: tc.url})
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fp, ok := p.(*formProvider); !ok || fp.url != "https://adfs.example.com/adfs/ls/IdpInitiatedSignOn.aspx?loginToRp=urn:amazon:webservices" {
+				t.Fatalf("expected the IdP-initiated sign-on page, got %+v", p)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Package secrets reads AWS access keys and MFA codes from password managers and
+// YubiKeys through their command-line tools, so long-lived keys need not be kept in
+// ~/.aws/credentials.
+//
+// An item is referred to as "<manager>:<item>":
+//
+//	1password:Private/AWS prod   op read op://Private/AWS prod/username, .../password
+//	pass:aws/prod                pass show aws/prod
+//	bitwarden:AWS prod           bw get username "AWS prod", bw get password "AWS prod"
+//	yubikey:AWS:me@prod          ykman oath accounts code --single "AWS:me@prod"
+//
+// The item's username is the access key ID and its password the secret access key. For
+// pass, the password is the first line of the entry and the access key ID a line such as
+// "username: AKIA...". MFA codes come from the item's one-time password. A YubiKey holds
+// only one-time passwords, in the OATH account named by the item.
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Password managers, as named in item references.
+const (
+	OnePassword = "1password"
+	Pass        = "pass"
+	Bitwarden   = "bitwarden"
+	// YubiKey is a YubiKey's OATH application, read with ykman.
+	YubiKey = "yubikey"
+)
+
+// Runner runs external commands.
+type Runner interface {
+	Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+}
+
+// Item is an item of a password manager.
+type Item struct {
+	Manager string
+	// Name is the item's name or path within the manager, such as "Private/AWS prod"
+	// for 1Password, or the OATH account of a YubiKey.
+	Name string
+}
+
+// Keys are long-lived access keys read from an item.
+type Keys struct {
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// managerAliases maps the short names of the command-line tools to their managers.
+var managerAliases = map[string]string{
+	OnePassword: OnePassword,
+	"op":        OnePassword,
+	Pass:        Pass,
+	Bitwarden:   Bitwarden,
+	"bw":        Bitwarden,
+	YubiKey:     YubiKey,
+	"ykman":     YubiKey,
+}
+
+// Parse reads an item reference such as "1password:Private/AWS prod". A 1Password item
+// may also be given as a secret reference, "1password:op://Private/AWS prod".
+func Parse(ref string) (Item, error) {
+	manager, name, ok := strings.Cut(ref, ":")
+	if !ok {
+		return Item{}, fmt.Errorf("invalid secret reference %q: expected <manager>:<item>, where manager is %s, %s, %s, or %s", ref, OnePassword, Pass, Bitwarden, YubiKey)
+	}
+	item := Item{Manager: managerAliases[strings.ToLower(manager)], Name: strings.TrimSpace(name)}
+	if item.Manager == "" {
+		return Item{}, fmt.Errorf("invalid secret reference %q: unsupported password manager %q (expected %s, %s, %s, or %s)", ref, manager, OnePassword, Pass, Bitwarden, YubiKey)
+	}
+	if item.Manager == OnePassword {
+		item.Name = strings.Trim(strings.TrimPrefix(item.Name, "op://"), "/")
+		if strings.Count(item.Name, "/") != 1 {
+			return Item{}, fmt.Errorf("invalid secret reference %q: a 1Password item is given as <vault>/<item>", ref)
+		}
+	}
+	if item.Name == "" {
+		return Item{}, fmt.Errorf("invalid secret reference %q: no item given", ref)
+	}
+	return item, nil
+}
+
+// String returns the item reference.
+func (i Item) String() string {
+	return i.Manager + ":" + i.Name
+}
+
+// Keys reads the access keys stored in the item. stdin is passed to the manager's
+// command-line tool, which may ask on it to unlock the vault; nil gives it none.
+func (i Item) Keys(r Runner, stdin io.Reader) (Keys, error) {
+	var keys Keys
+	var err error
+	switch i.Manager {
+	case OnePassword:
+		if keys.AccessKeyID, err = i.run(r, stdin, "op", "read", "op://"+i.Name+"/username"); err != nil {
+			return Keys{}, err
+		}
+		keys.SecretAccessKey, err = i.run(r, stdin, "op", "read", "op://"+i.Name+"/password")
+	case Bitwarden:
+		if keys.AccessKeyID, err = i.run(r, stdin, "bw", "get", "username", i.Name); err != nil {
+			return Keys{}, err
+		}
+		keys.SecretAccessKey, err = i.run(r, stdin, "bw", "get", "password", i.Name)
+	case Pass:
+		var entry string
+		if entry, err = i.run(r, stdin, "pass", "show", i.Name); err == nil {
+			keys = passKeys(entry)
+		}
+	case YubiKey:
+		return Keys{}, fmt.Errorf("%s holds one-time passwords, not access keys", i)
+	default:
+		return Keys{}, fmt.Errorf("unsupported password manager %q", i.Manager)
+	}
+	if err != nil {
+		return Keys{}, err
+	}
+	if keys.AccessKeyID == "" || keys.SecretAccessKey == "" {
+		return Keys{}, fmt.Errorf("%s does not hold an access key ID and secret access key", i)
+	}
+	return keys, nil
+}
+
+// TOTP returns the current one-time password of the item. stdin is passed on as it is
+// to Keys.
+func (i Item) TOTP(r Runner, stdin io.Reader) (string, error) {
+	var code string
+	var err error
+	switch i.Manager {
+	case OnePassword:
+		code, err = i.run(r, stdin, "op", "read", "op://"+i.Name+"/one-time password?attribute=otp")
+	case Bitwarden:
+		code, err = i.run(r, stdin, "bw", "get", "totp", i.Name)
+	case Pass:
+		// The pass-otp extension.
+		code, err = i.run(r, stdin, "pass", "otp", i.Name)
+	case YubiKey:
+		// An account that requires touch waits for it; ykman fails on its own after a
+		// while if the key is never touched.
+		code, err = i.run(r, stdin, "ykman", "oath", "accounts", "code", "--single", i.Name)
+	default:
+		return "", fmt.Errorf("unsupported password manager %q", i.Manager)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !totpPattern.MatchString(code) {
+		return "", fmt.Errorf("%s did not return a 6-digit one-time password", i)
+	}
+	return code, nil
+}
+
+var totpPattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+// run runs a password manager command and returns its trimmed output. Its stderr is
+// included in errors, as it explains failures such as a locked vault.
+func (i Item) run(r Runner, stdin io.Reader, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	if err := r.Run(name, args, stdin, &stdout, &stderr); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s is needed to read %s but is not installed", name, i)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("failed to read %s with %s: %w: %s", i, name, err, msg)
+		}
+		return "", fmt.Errorf("failed to read %s with %s: %w", i, name, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// passKeys reads the keys of a pass entry: the password on its first line, and the
+// access key ID on a "username:", "user:", "login:", or "aws_access_key_id" line. An
+// "aws_secret_access_key" line may hold the secret access key instead.
+func passKeys(entry string) Keys {
+	var keys Keys
+	for i, line := range strings.Split(entry, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			key, value, _ = strings.Cut(line, "=")
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "username", "user", "login", "aws_access_key_id":
+			keys.AccessKeyID = strings.TrimSpace(value)
+		case "aws_secret_access_key":
+			keys.SecretAccessKey = strings.TrimSpace(value)
+		default:
+			if i == 0 {
+				keys.SecretAccessKey = strings.TrimSpace(line)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,156 @@
+package secrets
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeRunner answers commands, keyed by their name and arguments joined with spaces.
+type fakeRunner struct {
+	outputs map[string]string
+	errs    map[string]error
+	calls   []string
+}
+
+func (f *fakeRunner) Run(name string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	call := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, call)
+	if err, ok := f.errs[call]; ok {
+		io.WriteString(stderr, "vault is locked\n")
+		return err
+	}
+	out, ok := f.outputs[call]
+	if !ok {
+		return &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	io.WriteString(stdout, out)
+	return nil
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for ref, want := range map[string]Item{
+		"1password:Private/AWS prod":          {Manager: OnePassword, Name: "Private/AWS prod"},
+		"op:op://Private/AWS prod":            {Manager: OnePassword, Name: "Private/AWS prod"},
+		"pass:aws/prod":                       {Manager: Pass, Name: "aws/prod"},
+		"bw:AWS prod":                         {Manager: Bitwarden, Name: "AWS prod"},
+		"Bitwarden:0d3f-4e2a-8c1b-9a7e61f":    {Manager: Bitwarden, Name: "0d3f-4e2a-8c1b-9a7e61f"},
+		"yubikey:Amazon Web Services:me@prod": {Manager: YubiKey, Name: "Amazon Web Services:me@prod"},
+		"ykman:aws":                           {Manager: YubiKey, Name: "aws"},
+	} {
+		got, err := Parse(ref)
+		if err != nil || got != want {
+			t.Fatalf("Parse(%q) = %+v, %v; want %+v", ref, got, err, want)
+		}
+	}
+
+	for ref, want := range map[string]string{
+		"aws/prod":           "expected <manager>:<item>",
+		"keepass:aws":        `unsupported password manager "keepass"`,
+		"pass:":              "no item given",
+		"1password:AWS prod": "<vault>/<item>",
+		"1password:a/b/c":    "<vault>/<item>",
+		"bitwarden:   ":      "no item given",
+	} {
+		if _, err := Parse(ref); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Parse(%q): expected an error containing %q, got %v", ref, want, err)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+
+	want := Keys{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret/key"}
+	testCases := []struct {
+		ref     string
+		outputs map[string]string
+	}{
+		{
+			ref: "1password:Private/AWS prod",
+			outputs: map[string]string{
+				"op read op://Private/AWS prod/username": "AKIAEXAMPLE\n",
+				"op read op://Private/AWS prod/password": "secret/key\n",
+			},
+		},
+		{
+			ref: "bitwarden:AWS prod",
+			outputs: map[string]string{
+				"bw get username AWS prod": "AKIAEXAMPLE",
+				"bw get password AWS prod": "secret/key",
+			},
+		},
+		{
+			ref:     "pass:aws/prod",
+			outputs: map[string]string{"pass show aws/prod": "secret/key\nusername: AKIAEXAMPLE\nurl: https://console.aws.amazon.com\n"},
+		},
+		{
+			ref:     "pass:aws/dev",
+			outputs: map[string]string{"pass show aws/dev": "\naws_access_key_id = AKIAEXAMPLE\naws_secret_access_key = secret/key\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		item, err := Parse(tc.ref)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := item.Keys(&fakeRunner{outputs: tc.outputs}, nil)
+		if err != nil || got != want {
+			t.Fatalf("%s: got %+v, %v; want %+v", tc.ref, got, err, want)
+		}
+	}
+}
+
+func TestKeysErrors(t *testing.T) {
+	t.Parallel()
+
+	item := Item{Manager: Bitwarden, Name: "AWS prod"}
+	if _, err := item.Keys(&fakeRunner{}, nil); err == nil || !strings.Contains(err.Error(), "bw is needed to read bitwarden:AWS prod but is not installed") {
+		t.Fatalf("expected a missing command error, got %v", err)
+	}
+
+	runner := &fakeRunner{errs: map[string]error{"bw get username AWS prod": errors.New("exit status 1")}}
+	if _, err := item.Keys(runner, nil); err == nil || !strings.Contains(err.Error(), "exit status 1: vault is locked") {
+		t.Fatalf("expected the command's error, got %v", err)
+	}
+
+	item = Item{Manager: Pass, Name: "aws/prod"}
+	if _, err := item.Keys(&fakeRunner{outputs: map[string]string{"pass show aws/prod": "secret\n"}}, nil); err == nil || !strings.Contains(err.Error(), "does not hold an access key ID") {
+		t.Fatalf("expected an incomplete item error, got %v", err)
+	}
+
+	item = Item{Manager: YubiKey, Name: "aws"}
+	if _, err := item.Keys(&fakeRunner{}, nil); err == nil || !strings.Contains(err.Error(), "yubikey:aws holds one-time passwords, not access keys") {
+		t.Fatalf("expected a YubiKey to hold no keys, got %v", err)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	t.Parallel()
+
+	runner := &fakeRunner{outputs: map[string]string{
+		"op read op://Private/AWS prod/one-time password?attribute=otp": "123456\n",
+		"bw get totp AWS prod": "654321",
+		"pass otp aws/prod":    "not a code",
+		"ykman oath accounts code --single Amazon Web Services:me@prod": "024680\n",
+	}}
+	for ref, want := range map[string]string{
+		"1password:Private/AWS prod":          "123456",
+		"bitwarden:AWS prod":                  "654321",
+		"yubikey:Amazon Web Services:me@prod": "024680",
+	} {
+		item, _ := Parse(ref)
+		if got, err := item.TOTP(runner, nil); err != nil || got != want {
+			t.Fatalf("%s: got %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	item, _ := Parse("pass:aws/prod")
+	if _, err := item.TOTP(runner, nil); err == nil || !strings.Contains(err.Error(), "did not return a 6-digit one-time password") {
+		t.Fatalf("expected an invalid code error, got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+// Package sessions tracks the console sessions aws-console has opened so that the ones
+// that have not yet expired can be listed, re-opened, or signed out.
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// FileName is the name of the sessions file within the state directory.
+const FileName = "sessions.json"
+
+// Session is a federated console session opened by aws-console.
+type Session struct {
+	ID      string `json:"id"`
+	Profile string `json:"profile"`
+	// FallbackFor names the profile whose IAM Identity Center sign-in failed when
+	// Profile signed in as its fallback.
+	FallbackFor string    `json:"fallback_for,omitempty"`
+	Account     string    `json:"account,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Partition   string    `json:"partition,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	OpenedAt    time.Time `json:"opened_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Active reports whether the session has not yet expired at now.
+func (s Session) Active(now time.Time) bool {
+	return now.Before(s.ExpiresAt)
+}
+
+// Store persists sessions in a JSON file.
+type Store struct {
+	path string
+	// mu serializes updates, so concurrent changes are not lost.
+	mu sync.Mutex
+}
+
+// NewStore creates a store backed by sessions.json in the aws-console state directory.
+func NewStore() *Store {
+	dir, err := paths.StateDir()
+	if err != nil {
+		return NewStoreAt("")
+	}
+	return NewStoreAt(filepath.Join(dir, FileName))
+}
+
+// NewStoreAt creates a store backed by the given file. An empty path disables persistence.
+func NewStoreAt(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the backing file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load returns every recorded session, oldest first. A missing file yields no sessions.
+func (s *Store) Load() ([]Session, error) {
+	if s.path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+
+	var sessions []Session
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to parse sessions %s: %w", s.path, err)
+	}
+	return sessions, nil
+}
+
+// Active returns the sessions that have not expired at now, oldest first.
+func (s *Store) Active(now time.Time) ([]Session, error) {
+	sessions, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	return activeAt(sessions, now), nil
+}
+
+// Find returns the active session with the given ID.
+func (s *Store) Find(id string, now time.Time) (Session, error) {
+	sessions, err := s.Active(now)
+	if err != nil {
+		return Session{}, err
+	}
+	for _, session := range sessions {
+		if session.ID == id {
+			return session, nil
+		}
+	}
+	return Session{}, fmt.Errorf("no active session with ID %q (run 'aws-console sessions' to list them)", id)
+}
+
+// Add records session with a new ID and returns it. Expired sessions are dropped.
+func (s *Store) Add(session Session, now time.Time) (Session, error) {
+	id, err := newID()
+	if err != nil {
+		return Session{}, err
+	}
+	session.ID = id
+	if s.path == "" {
+		return session, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.Load()
+	if err != nil {
+		return Session{}, err
+	}
+	if err := s.save(append(activeAt(sessions, now), session)); err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// Remove forgets the session with the given ID, if it is recorded.
+func (s *Store) Remove(id string) error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.Load()
+	if err != nil {
+		return err
+	}
+	kept := sessions[:0]
+	for _, session := range sessions {
+		if session.ID != id {
+			kept = append(kept, session)
+		}
+	}
+	return s.save(kept)
+}
+
+func (s *Store) save(sessions []Session) error {
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sessions: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write sessions: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write sessions: %w", err)
+	}
+	return nil
+}
+
+func activeAt(sessions []Session, now time.Time) []Session {
+	var active []Session
+	for _, session := range sessions {
+		if session.Active(now) {
+			active = append(active, session)
+		}
+	}
+	return active
+}
+
+// newID returns a short random identifier that is easy to type.
+func newID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,91 @@
+package sessions
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreAddAndActive(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStoreAt(filepath.Join(t.TempDir(), "nested", FileName))
+
+	expired, err := store.Add(Session{Profile: "old", OpenedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}, now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dev, err := store.Add(Session{Profile: "dev", Account: "123456789012", Destination: "ec2/home", OpenedAt: now, ExpiresAt: now.Add(time.Hour)}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(dev.ID) != 8 || dev.ID == expired.ID {
+		t.Fatalf("unexpected session ID %q", dev.ID)
+	}
+
+	active, err := store.Active(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(active) != 1 || active[0] != dev {
+		t.Fatalf("expected only the dev session to be active, got %+v", active)
+	}
+
+	all, err := store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("expected expired sessions to be pruned when adding, got %+v", all)
+	}
+}
+
+func TestStoreFindAndRemove(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStoreAt(filepath.Join(t.TempDir(), FileName))
+	session, err := store.Add(Session{Profile: "dev", OpenedAt: now, ExpiresAt: now.Add(time.Hour)}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	found, err := store.Find(session.ID, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if found != session {
+		t.Fatalf("expected %+v, got %+v", session, found)
+	}
+
+	if _, err := store.Find(session.ID, now.Add(2*time.Hour)); err == nil || !strings.Contains(err.Error(), "no active session") {
+		t.Fatalf("expected expired session not to be found, got %v", err)
+	}
+
+	if err := store.Remove(session.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Find(session.ID, now); err == nil {
+		t.Fatal("expected removed session not to be found")
+	}
+}
+
+func TestStoreWithoutPath(t *testing.T) {
+	t.Parallel()
+
+	store := NewStoreAt("")
+	now := time.Now()
+	session, err := store.Add(Session{Profile: "dev", ExpiresAt: now.Add(time.Hour)}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if session.ID == "" {
+		t.Fatal("expected an ID even without persistence")
+	}
+	active, err := store.Active(now)
+	if err != nil || len(active) != 0 {
+		t.Fatalf("expected no persisted sessions, got %+v, %v", active, err)
+	}
+}
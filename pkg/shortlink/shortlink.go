@@ -0,0 +1,98 @@
+// Package shortlink exchanges sign-in URLs for short, single-use links on a self-hosted
+// redirector, for pasting where long URLs break, such as chat tools.
+//
+// The redirector holds a working sign-in URL until its link is used or expires, so it
+// must be trusted as much as the credentials themselves.
+package shortlink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client exchanges URLs with the redirector at Endpoint.
+type Client struct {
+	// Endpoint is the https URL that sign-in URLs are POSTed to.
+	Endpoint string
+	// Headers are added to each request, such as an Authorization header.
+	Headers map[string]string
+	// HTTP sends the requests; nil uses http.DefaultClient.
+	HTTP *http.Client
+}
+
+// request is the JSON document POSTed to the redirector.
+type request struct {
+	URL string `json:"url"`
+	// ExpiresAt is when the redirector should forget the URL, used or not.
+	ExpiresAt time.Time `json:"expires_at"`
+	// SingleUse asks the redirector to forget the URL once its link is followed.
+	SingleUse bool `json:"single_use"`
+}
+
+// response is the JSON document the redirector answers with.
+type response struct {
+	URL string `json:"url"`
+}
+
+// Shorten returns a single-use link to target that expires at expires.
+func (c *Client) Shorten(ctx context.Context, target string, expires time.Time) (string, error) {
+	body, err := json.Marshal(request{URL: target, ExpiresAt: expires.UTC(), SingleUse: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode the shortener request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create the shortener request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("shortener request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the shortener response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("shortener returned %s", resp.Status)
+	}
+
+	var out response
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", fmt.Errorf("invalid shortener response: %w", err)
+	}
+	if err := checkLink(out.URL, target); err != nil {
+		return "", err
+	}
+	return out.URL, nil
+}
+
+// checkLink rejects a link that is not an https URL, or that carries the sign-in
+// token of target, as a redirector echoing the URL back would.
+func checkLink(link, target string) error {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("shortener returned %q, which is not an https URL", link)
+	}
+	if t, err := url.Parse(target); err == nil && t.RawQuery != "" && strings.Contains(link, t.RawQuery) {
+		return fmt.Errorf("shortener returned the sign-in URL instead of a short link")
+	}
+	return nil
+}
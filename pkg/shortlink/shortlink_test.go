@@ -0,0 +1,71 @@
+package shortlink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShorten(t *testing.T) {
+	t.Parallel()
+
+	const target = "https://signin.aws.amazon.com/federation?Action=login&SigninToken=secret"
+	expires := time.Date(2030, 1, 1, 0, 15, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		status        int
+		body          string
+		want          string
+		wantErrSubstr string
+	}{
+		{name: "short link", status: http.StatusCreated, body: `{"url": "https://go.example.com/x7Kq"}`, want: "https://go.example.com/x7Kq"},
+		{name: "error status", status: http.StatusUnauthorized, body: `{}`, wantErrSubstr: "shortener returned 401 Unauthorized"},
+		{name: "not JSON", status: http.StatusOK, body: `<html>`, wantErrSubstr: "invalid shortener response"},
+		{name: "plain http", status: http.StatusOK, body: `{"url": "http://go.example.com/x7Kq"}`, wantErrSubstr: "not an https URL"},
+		{name: "no link", status: http.StatusOK, body: `{}`, wantErrSubstr: "not an https URL"},
+		{name: "echoed sign-in URL", status: http.StatusOK, body: `{"url": "` + target + `"}`, wantErrSubstr: "returned the sign-in URL"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req request
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("unexpected request %s with headers %v", r.Method, r.Header)
+				}
+				if req.URL != target || !req.ExpiresAt.Equal(expires) || !req.SingleUse {
+					t.Errorf("unexpected request %+v", req)
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			t.Cleanup(server.Close)
+
+			c := &Client{Endpoint: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+			got, err := c.Shorten(context.Background(), target, expires)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package sso
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+	"github.com/eculver/aws-console/pkg/aws/ssocache"
+)
+
+// deviceCodeGrant is the OAuth grant type used to exchange a device code for a token.
+const deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+
+// defaultPollInterval is used when StartDeviceAuthorization does not return an interval.
+const defaultPollInterval = 5 * time.Second
+
+// slowDownIncrease is added to the polling interval each time the service asks the
+// client to slow down, as required by RFC 8628.
+const slowDownIncrease = 5 * time.Second
+
+// LoginAPI is the subset of the SSO OIDC client used for the device authorization flow.
+type LoginAPI interface {
+	OIDCAPI
+	StartDeviceAuthorization(ctx context.Context, params *ssooidc.StartDeviceAuthorizationInput, optFns ...func(*ssooidc.Options)) (*ssooidc.StartDeviceAuthorizationOutput, error)
+	CreateToken(ctx context.Context, params *ssooidc.CreateTokenInput, optFns ...func(*ssooidc.Options)) (*ssooidc.CreateTokenOutput, error)
+}
+
+// NewOIDCClient returns an SSO OIDC client for region. The device authorization flow is
+// unauthenticated, so no credentials are loaded. AWS_ENDPOINT_URL_SSO_OIDC, or
+// AWS_ENDPOINT_URL, overrides its endpoint, as for clients of the AWS SDK config.
+func NewOIDCClient(region string) *ssooidc.Client {
+	return ssooidc.New(ssooidc.Options{Region: region, BaseEndpoint: endpointOverride("SSO_OIDC")})
+}
+
+// endpointOverride returns the endpoint the AWS_ENDPOINT_URL_<service> or
+// AWS_ENDPOINT_URL environment variable sets for service, or nil for the default.
+func endpointOverride(service string) *string {
+	if endpoint := cmp.Or(os.Getenv("AWS_ENDPOINT_URL_"+service), os.Getenv("AWS_ENDPOINT_URL")); endpoint != "" {
+		return aws.String(endpoint)
+	}
+	return nil
+}
+
+// Authorization is a pending device authorization that the user approves in a browser.
+type Authorization struct {
+	// VerificationURL opens the approval page with UserCode filled in.
+	VerificationURL string
+	UserCode        string
+	ExpiresAt       time.Time
+}
+
+// DeviceLogin performs the OAuth device authorization flow against IAM Identity Center,
+// the same login `aws sso login` performs.
+type DeviceLogin struct {
+	api       LoginAPI
+	registrar *Registrar
+	now       func() time.Time
+	sleep     func(context.Context, time.Duration) error
+}
+
+// NewDeviceLogin creates a device login backed by the given OIDC client, which must be
+// configured for the session's sso_region.
+func NewDeviceLogin(api LoginAPI) *DeviceLogin {
+	return newDeviceLogin(api, time.Now, sleepContext)
+}
+
+func newDeviceLogin(api LoginAPI, now func() time.Time, sleep func(context.Context, time.Duration) error) *DeviceLogin {
+	return &DeviceLogin{api: api, registrar: newRegistrar(api, now), now: now, sleep: sleep}
+}
+
+// Login registers a client for cfg, starts a device authorization and calls notify so the
+// user can approve it, then polls until the token is issued. The returned token is in
+// the format of the shared SSO token cache.
+func (l *DeviceLogin) Login(ctx context.Context, cfg ClientConfig, notify func(Authorization)) (ssocache.Token, error) {
+	reg, err := l.registrar.Register(ctx, cfg)
+	if err != nil {
+		return ssocache.Token{}, err
+	}
+
+	auth, err := l.api.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     aws.String(reg.ClientID),
+		ClientSecret: aws.String(reg.ClientSecret),
+		StartUrl:     aws.String(cfg.StartURL),
+	})
+	if err != nil {
+		return ssocache.Token{}, fmt.Errorf("failed to start device authorization for %s: %w", cfg.StartURL, err)
+	}
+
+	expiresAt := l.now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	notify(Authorization{
+		VerificationURL: aws.ToString(auth.VerificationUriComplete),
+		UserCode:        aws.ToString(auth.UserCode),
+		ExpiresAt:       expiresAt,
+	})
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	for {
+		if err := l.sleep(ctx, interval); err != nil {
+			return ssocache.Token{}, err
+		}
+
+		out, err := l.api.CreateToken(ctx, &ssooidc.CreateTokenInput{
+			ClientId:     aws.String(reg.ClientID),
+			ClientSecret: aws.String(reg.ClientSecret),
+			GrantType:    aws.String(deviceCodeGrant),
+			DeviceCode:   auth.DeviceCode,
+		})
+		if err == nil {
+			return ssocache.Token{
+				StartURL:              cfg.StartURL,
+				Region:                cfg.Region,
+				AccessToken:           aws.ToString(out.AccessToken),
+				ExpiresAt:             l.now().Add(time.Duration(out.ExpiresIn) * time.Second).UTC(),
+				RefreshToken:          aws.ToString(out.RefreshToken),
+				ClientID:              reg.ClientID,
+				ClientSecret:          reg.ClientSecret,
+				RegistrationExpiresAt: reg.ExpiresAt,
+			}, nil
+		}
+
+		var pending *types.AuthorizationPendingException
+		var slowDown *types.SlowDownException
+		var expired *types.ExpiredTokenException
+		var denied *types.AccessDeniedException
+		switch {
+		case errors.As(err, &pending):
+		case errors.As(err, &slowDown):
+			interval += slowDownIncrease
+		case errors.As(err, &expired):
+			return ssocache.Token{}, errors.New("the device authorization expired before it was approved")
+		case errors.As(err, &denied):
+			return ssocache.Token{}, errors.New("the device authorization was denied")
+		default:
+			return ssocache.Token{}, fmt.Errorf("failed to create SSO token: %w", err)
+		}
+
+		if !l.now().Before(expiresAt) {
+			return ssocache.Token{}, errors.New("the device authorization expired before it was approved")
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,202 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+)
+
+type fakeDeviceOIDC struct {
+	fakeOIDC
+	// tokenErrs are returned by successive CreateToken calls before a token is issued.
+	tokenErrs   []error
+	tokenCalls  int
+	lastStart   *ssooidc.StartDeviceAuthorizationInput
+	lastToken   *ssooidc.CreateTokenInput
+	startErr    error
+	authExpires int32
+}
+
+func (f *fakeDeviceOIDC) StartDeviceAuthorization(ctx context.Context, params *ssooidc.StartDeviceAuthorizationInput, optFns ...func(*ssooidc.Options)) (*ssooidc.StartDeviceAuthorizationOutput, error) {
+	f.lastStart = params
+	if f.startErr != nil {
+		return nil, f.startErr
+	}
+	return &ssooidc.StartDeviceAuthorizationOutput{
+		DeviceCode:              aws.String("device-code"),
+		UserCode:                aws.String("ABCD-EFGH"),
+		VerificationUriComplete: aws.String("https://device.sso.us-east-1.amazonaws.com/?user_code=ABCD-EFGH"),
+		ExpiresIn:               f.authExpires,
+		Interval:                1,
+	}, nil
+}
+
+func (f *fakeDeviceOIDC) CreateToken(ctx context.Context, params *ssooidc.CreateTokenInput, optFns ...func(*ssooidc.Options)) (*ssooidc.CreateTokenOutput, error) {
+	f.lastToken = params
+	f.tokenCalls++
+	if f.tokenCalls <= len(f.tokenErrs) {
+		return nil, f.tokenErrs[f.tokenCalls-1]
+	}
+	return &ssooidc.CreateTokenOutput{
+		AccessToken:  aws.String("access-token"),
+		RefreshToken: aws.String("refresh-token"),
+		ExpiresIn:    28800,
+	}, nil
+}
+
+func TestDeviceLogin(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name          string
+		tokenErrs     []error
+		startErr      error
+		wantCalls     int
+		wantSleeps    []time.Duration
+		wantErrSubstr string
+	}{
+		{
+			name:       "approved after polling",
+			tokenErrs:  []error{&types.AuthorizationPendingException{}, &types.SlowDownException{}},
+			wantCalls:  3,
+			wantSleeps: []time.Duration{time.Second, time.Second, 6 * time.Second},
+		},
+		{
+			name:          "denied",
+			tokenErrs:     []error{&types.AccessDeniedException{}},
+			wantCalls:     1,
+			wantSleeps:    []time.Duration{time.Second},
+			wantErrSubstr: "the device authorization was denied",
+		},
+		{
+			name:          "expired",
+			tokenErrs:     []error{&types.ExpiredTokenException{}},
+			wantCalls:     1,
+			wantSleeps:    []time.Duration{time.Second},
+			wantErrSubstr: "the device authorization expired before it was approved",
+		},
+		{
+			name:          "start fails",
+			startErr:      errors.New("InvalidClientException"),
+			wantErrSubstr: "failed to start device authorization for https://corp.awsapps.com/start",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := start
+			var sleeps []time.Duration
+			api := &fakeDeviceOIDC{
+				fakeOIDC:    fakeOIDC{expiresAt: start.Add(90 * 24 * time.Hour)},
+				tokenErrs:   tc.tokenErrs,
+				startErr:    tc.startErr,
+				authExpires: 600,
+			}
+			login := newDeviceLogin(api, func() time.Time { return now }, func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				now = now.Add(d)
+				return nil
+			})
+			cfg := ClientConfig{Session: "corp", StartURL: "https://corp.awsapps.com/start", Region: "us-east-1", ClientName: DefaultClientName, Scopes: DefaultScopes}
+
+			var notified Authorization
+			token, err := login.Login(context.Background(), cfg, func(auth Authorization) { notified = auth })
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if api.tokenCalls != tc.wantCalls {
+				t.Fatalf("expected %d CreateToken calls, got %d", tc.wantCalls, api.tokenCalls)
+			}
+			if len(sleeps) != len(tc.wantSleeps) {
+				t.Fatalf("expected sleeps %v, got %v", tc.wantSleeps, sleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tc.wantSleeps[i] {
+					t.Fatalf("expected sleeps %v, got %v", tc.wantSleeps, sleeps)
+				}
+			}
+			if tc.wantErrSubstr != "" {
+				return
+			}
+
+			if notified.UserCode != "ABCD-EFGH" || !strings.Contains(notified.VerificationURL, "user_code=ABCD-EFGH") || !notified.ExpiresAt.Equal(start.Add(10*time.Minute)) {
+				t.Fatalf("unexpected authorization: %+v", notified)
+			}
+			if aws.ToString(api.lastToken.GrantType) != deviceCodeGrant || aws.ToString(api.lastToken.DeviceCode) != "device-code" {
+				t.Fatalf("unexpected CreateToken input: %+v", api.lastToken)
+			}
+			if token.AccessToken != "access-token" || token.RefreshToken != "refresh-token" || token.ClientID != "client-id" ||
+				token.StartURL != cfg.StartURL || token.Region != "us-east-1" || !token.ExpiresAt.Equal(now.Add(8*time.Hour)) {
+				t.Fatalf("unexpected token: %+v", token)
+			}
+		})
+	}
+}
+
+func TestDeviceLoginTimesOut(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	pending := make([]error, 100)
+	for i := range pending {
+		pending[i] = &types.AuthorizationPendingException{}
+	}
+	api := &fakeDeviceOIDC{fakeOIDC: fakeOIDC{expiresAt: now.Add(24 * time.Hour)}, tokenErrs: pending, authExpires: 3}
+	login := newDeviceLogin(api, func() time.Time { return now }, func(ctx context.Context, d time.Duration) error {
+		now = now.Add(d)
+		return nil
+	})
+
+	_, err := login.Login(context.Background(), ClientConfig{Session: "corp", StartURL: "https://corp.awsapps.com/start"}, func(Authorization) {})
+	if err == nil || !strings.Contains(err.Error(), "expired before it was approved") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if api.tokenCalls != 3 {
+		t.Fatalf("expected polling to stop once the authorization expired, got %d calls", api.tokenCalls)
+	}
+}
+
+func TestEndpointOverride(t *testing.T) {
+	testCases := []struct {
+		name    string
+		env     map[string]string
+		service string
+		want    string
+	}{
+		{name: "none", service: "SSO_OIDC"},
+		{name: "service", env: map[string]string{"AWS_ENDPOINT_URL_SSO_OIDC": "http://127.0.0.1:4566/oidc"}, service: "SSO_OIDC", want: "http://127.0.0.1:4566/oidc"},
+		{name: "other service", env: map[string]string{"AWS_ENDPOINT_URL_SSO_OIDC": "http://127.0.0.1:4566/oidc"}, service: "SSO"},
+		{
+			name:    "all services",
+			env:     map[string]string{"AWS_ENDPOINT_URL": "http://127.0.0.1:4566", "AWS_ENDPOINT_URL_SSO": "http://127.0.0.1:4566/portal"},
+			service: "SSO_OIDC",
+			want:    "http://127.0.0.1:4566",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_SSO", "AWS_ENDPOINT_URL_SSO_OIDC"} {
+				t.Setenv(name, tc.env[name])
+			}
+			if got := aws.ToString(endpointOverride(tc.service)); got != tc.want {
+				t.Fatalf("endpointOverride(%q) = %q, want %q", tc.service, got, tc.want)
+			}
+		})
+	}
+}
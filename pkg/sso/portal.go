@@ -0,0 +1,76 @@
+package sso
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ssoportal "github.com/aws/aws-sdk-go-v2/service/sso"
+)
+
+// PortalAPI is the subset of the SSO access portal client used to list account
+// assignments.
+type PortalAPI interface {
+	ListAccounts(ctx context.Context, params *ssoportal.ListAccountsInput, optFns ...func(*ssoportal.Options)) (*ssoportal.ListAccountsOutput, error)
+	ListAccountRoles(ctx context.Context, params *ssoportal.ListAccountRolesInput, optFns ...func(*ssoportal.Options)) (*ssoportal.ListAccountRolesOutput, error)
+}
+
+// NewPortalClient returns an SSO access portal client for region. Its calls are
+// authorized by the access token passed to each, so no credentials are loaded.
+// AWS_ENDPOINT_URL_SSO, or AWS_ENDPOINT_URL, overrides its endpoint.
+func NewPortalClient(region string) *ssoportal.Client {
+	return ssoportal.New(ssoportal.Options{Region: region, BaseEndpoint: endpointOverride("SSO")})
+}
+
+// Account is an AWS account assigned to the user in the access portal.
+type Account struct {
+	ID   string
+	Name string
+}
+
+// Portal lists the accounts and roles the holder of an SSO access token may sign in to.
+type Portal struct {
+	api PortalAPI
+}
+
+// NewPortal creates a portal backed by the given client, which must be configured for
+// the session's sso_region.
+func NewPortal(api PortalAPI) *Portal {
+	return &Portal{api: api}
+}
+
+// Accounts returns the accounts assigned to the holder of accessToken.
+func (p *Portal) Accounts(ctx context.Context, accessToken string) ([]Account, error) {
+	var accounts []Account
+	pages := ssoportal.NewListAccountsPaginator(p.api, &ssoportal.ListAccountsInput{AccessToken: aws.String(accessToken)})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SSO accounts: %w", err)
+		}
+		for _, a := range page.AccountList {
+			accounts = append(accounts, Account{ID: aws.ToString(a.AccountId), Name: aws.ToString(a.AccountName)})
+		}
+	}
+	return accounts, nil
+}
+
+// AccountRoles returns the names of the roles the holder of accessToken may use in
+// accountID.
+func (p *Portal) AccountRoles(ctx context.Context, accessToken, accountID string) ([]string, error) {
+	var roles []string
+	pages := ssoportal.NewListAccountRolesPaginator(p.api, &ssoportal.ListAccountRolesInput{
+		AccessToken: aws.String(accessToken),
+		AccountId:   aws.String(accountID),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list SSO roles in account %s: %w", accountID, err)
+		}
+		for _, r := range page.RoleList {
+			roles = append(roles, aws.ToString(r.RoleName))
+		}
+	}
+	return roles, nil
+}
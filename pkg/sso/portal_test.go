@@ -0,0 +1,124 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ssoportal "github.com/aws/aws-sdk-go-v2/service/sso"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+)
+
+// fakePortal serves each list in pages of one entry.
+type fakePortal struct {
+	accounts []ssotypes.AccountInfo
+	roles    map[string][]string
+	err      error
+	tokens   []string
+}
+
+func (f *fakePortal) ListAccounts(ctx context.Context, params *ssoportal.ListAccountsInput, optFns ...func(*ssoportal.Options)) (*ssoportal.ListAccountsOutput, error) {
+	f.tokens = append(f.tokens, aws.ToString(params.AccessToken))
+	if f.err != nil {
+		return nil, f.err
+	}
+	i, next := page(params.NextToken, len(f.accounts))
+	out := &ssoportal.ListAccountsOutput{NextToken: next}
+	if i < len(f.accounts) {
+		out.AccountList = f.accounts[i : i+1]
+	}
+	return out, nil
+}
+
+func (f *fakePortal) ListAccountRoles(ctx context.Context, params *ssoportal.ListAccountRolesInput, optFns ...func(*ssoportal.Options)) (*ssoportal.ListAccountRolesOutput, error) {
+	f.tokens = append(f.tokens, aws.ToString(params.AccessToken))
+	if f.err != nil {
+		return nil, f.err
+	}
+	roles := f.roles[aws.ToString(params.AccountId)]
+	i, next := page(params.NextToken, len(roles))
+	out := &ssoportal.ListAccountRolesOutput{NextToken: next}
+	if i < len(roles) {
+		out.RoleList = []ssotypes.RoleInfo{{AccountId: params.AccountId, RoleName: aws.String(roles[i])}}
+	}
+	return out, nil
+}
+
+// page returns the index of the entry a page token points at and the token of the
+// following page, if any.
+func page(token *string, n int) (int, *string) {
+	i := len(aws.ToString(token))
+	if i+1 >= n {
+		return i, nil
+	}
+	return i, aws.String(strings.Repeat("x", i+1))
+}
+
+func TestPortalAccounts(t *testing.T) {
+	t.Parallel()
+
+	api := &fakePortal{accounts: []ssotypes.AccountInfo{
+		{AccountId: aws.String("111111111111"), AccountName: aws.String("dev")},
+		{AccountId: aws.String("222222222222"), AccountName: aws.String("prod")},
+	}}
+	accounts, err := NewPortal(api).Accounts(context.Background(), "token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Account{{ID: "111111111111", Name: "dev"}, {ID: "222222222222", Name: "prod"}}
+	if len(accounts) != len(want) || accounts[0] != want[0] || accounts[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, accounts)
+	}
+	if strings.Join(api.tokens, ",") != "token,token" {
+		t.Fatalf("expected each page to be requested with the access token, got %q", api.tokens)
+	}
+}
+
+func TestPortalAccountRoles(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		api           *fakePortal
+		want          []string
+		wantErrSubstr string
+	}{
+		{
+			name: "paged",
+			api:  &fakePortal{roles: map[string][]string{"111111111111": {"Admin", "ReadOnly"}}},
+			want: []string{"Admin", "ReadOnly"},
+		},
+		{
+			name: "none assigned",
+			api:  &fakePortal{},
+		},
+		{
+			name:          "error",
+			api:           &fakePortal{err: errors.New("UnauthorizedException: session token not found or invalid")},
+			wantErrSubstr: "failed to list SSO roles in account 111111111111: UnauthorizedException",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			roles, err := NewPortal(tc.api).AccountRoles(context.Background(), "token", "111111111111")
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(roles, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("expected roles %q, got %q", tc.want, roles)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package sso implements the parts of the IAM Identity Center (SSO) login flow that
+// aws-console performs itself rather than delegating to the AWS CLI.
+package sso
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// DefaultClientName is the OIDC client name registered when the sso-session does not set
+// aws_console_client_name.
+const DefaultClientName = "aws-console"
+
+// DefaultScopes are requested when the sso-session does not set sso_registration_scopes.
+var DefaultScopes = []string{"sso:account:access"}
+
+// registrationRefreshWindow is how long before expiry a cached registration is replaced,
+// so a login started with it cannot outlive it.
+const registrationRefreshWindow = 15 * time.Minute
+
+// grantTypes are the OAuth grants requested for the device authorization flow.
+var grantTypes = []string{"urn:ietf:params:oauth:grant-type:device_code", "refresh_token"}
+
+// ClientConfig describes the OIDC client registered for an sso-session.
+type ClientConfig struct {
+	Session    string
+	StartURL   string
+	Region     string
+	ClientName string
+	Scopes     []string
+	// CacheDir holds cached registrations, one file per distinct client configuration.
+	CacheDir string
+}
+
+// NewClientConfig resolves the client configuration for session, applying defaults for
+// unset values. Registrations are cached under defaultCacheDir unless the session sets
+// aws_console_registration_cache.
+func NewClientConfig(session awslib.SSOSession, defaultCacheDir string) (ClientConfig, error) {
+	if session.StartURL == "" || session.Region == "" {
+		return ClientConfig{}, fmt.Errorf("sso-session %q must set sso_start_url and sso_region", session.Name)
+	}
+
+	cfg := ClientConfig{
+		Session:    session.Name,
+		StartURL:   session.StartURL,
+		Region:     session.Region,
+		ClientName: session.ClientName,
+		Scopes:     slices.Clone(session.RegistrationScopes),
+		CacheDir:   defaultCacheDir,
+	}
+	if cfg.ClientName == "" {
+		cfg.ClientName = DefaultClientName
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = slices.Clone(DefaultScopes)
+	}
+	slices.Sort(cfg.Scopes)
+	cfg.Scopes = slices.Compact(cfg.Scopes)
+
+	if session.RegistrationCache != "" {
+		dir, err := paths.ExpandHome(session.RegistrationCache)
+		if err != nil {
+			return ClientConfig{}, fmt.Errorf("failed to resolve registration cache for sso-session %q: %w", session.Name, err)
+		}
+		cfg.CacheDir = dir
+	}
+	return cfg, nil
+}
+
+// DefaultCacheDir returns the registration cache used when a session does not configure one.
+func DefaultCacheDir() (string, error) {
+	dir, err := paths.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sso"), nil
+}
+
+// Registration is a registered OIDC client.
+type Registration struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Scopes       []string  `json:"scopes"`
+}
+
+// OIDCAPI is the subset of the SSO OIDC client used for registration.
+type OIDCAPI interface {
+	RegisterClient(ctx context.Context, params *ssooidc.RegisterClientInput, optFns ...func(*ssooidc.Options)) (*ssooidc.RegisterClientOutput, error)
+}
+
+// Registrar registers OIDC clients, reusing cached registrations until they near expiry.
+type Registrar struct {
+	api OIDCAPI
+	now func() time.Time
+}
+
+// NewRegistrar creates a registrar backed by the given OIDC client, which must be
+// configured for the session's sso_region.
+func NewRegistrar(api OIDCAPI) *Registrar {
+	return newRegistrar(api, time.Now)
+}
+
+func newRegistrar(api OIDCAPI, now func() time.Time) *Registrar {
+	return &Registrar{api: api, now: now}
+}
+
+// Register returns a client registration for cfg, from the cache when a usable one exists.
+func (r *Registrar) Register(ctx context.Context, cfg ClientConfig) (Registration, error) {
+	path := cachePath(cfg)
+	if reg, ok := r.cached(path); ok {
+		return reg, nil
+	}
+
+	out, err := r.api.RegisterClient(ctx, &ssooidc.RegisterClientInput{
+		ClientName: aws.String(cfg.ClientName),
+		ClientType: aws.String("public"),
+		GrantTypes: grantTypes,
+		Scopes:     cfg.Scopes,
+	})
+	if err != nil {
+		return Registration{}, fmt.Errorf("failed to register OIDC client %q for sso-session %q: %w", cfg.ClientName, cfg.Session, err)
+	}
+
+	reg := Registration{
+		ClientID:     aws.ToString(out.ClientId),
+		ClientSecret: aws.ToString(out.ClientSecret),
+		ExpiresAt:    time.Unix(out.ClientSecretExpiresAt, 0).UTC(),
+		Scopes:       cfg.Scopes,
+	}
+	if path != "" {
+		if err := writeRegistration(path, reg); err != nil {
+			return Registration{}, err
+		}
+	}
+	return reg, nil
+}
+
+// cached loads the registration at path if it does not expire within the refresh window.
+// Unreadable cache files are ignored and replaced by a fresh registration.
+func (r *Registrar) cached(path string) (Registration, bool) {
+	if path == "" {
+		return Registration{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Registration{}, false
+	}
+	var reg Registration
+	if err := json.Unmarshal(data, &reg); err != nil || reg.ClientID == "" {
+		return Registration{}, false
+	}
+	if !r.now().Add(registrationRefreshWindow).Before(reg.ExpiresAt) {
+		return Registration{}, false
+	}
+	return reg, true
+}
+
+// cachePath keys the cache file on everything that affects the registration, so
+// sessions pointing at different Identity Center instances or asking for different
+// scopes never share a client.
+func cachePath(cfg ClientConfig) string {
+	if cfg.CacheDir == "" {
+		return ""
+	}
+	key := strings.Join([]string{cfg.Session, cfg.StartURL, cfg.Region, cfg.ClientName, strings.Join(cfg.Scopes, ",")}, "\n")
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(cfg.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func writeRegistration(path string, reg Registration) error {
+	data, err := json.MarshalIndent(reg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OIDC client registration: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create registration cache directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write OIDC client registration: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write OIDC client registration: %w", err)
+	}
+	return nil
+}
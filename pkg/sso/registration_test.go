@@ -0,0 +1,163 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+)
+
+type fakeOIDC struct {
+	calls     int
+	lastInput *ssooidc.RegisterClientInput
+	expiresAt time.Time
+	err       error
+}
+
+func (f *fakeOIDC) RegisterClient(ctx context.Context, params *ssooidc.RegisterClientInput, optFns ...func(*ssooidc.Options)) (*ssooidc.RegisterClientOutput, error) {
+	f.calls++
+	f.lastInput = params
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ssooidc.RegisterClientOutput{
+		ClientId:              aws.String("client-id"),
+		ClientSecret:          aws.String("client-secret"),
+		ClientSecretExpiresAt: f.expiresAt.Unix(),
+	}, nil
+}
+
+func TestNewClientConfig(t *testing.T) {
+	t.Setenv("HOME", "/home/tester")
+
+	testCases := []struct {
+		name          string
+		session       awslib.SSOSession
+		want          ClientConfig
+		wantErrSubstr string
+	}{
+		{
+			name:    "defaults",
+			session: awslib.SSOSession{Name: "corp", StartURL: "https://corp.awsapps.com/start", Region: "us-east-1"},
+			want: ClientConfig{
+				Session: "corp", StartURL: "https://corp.awsapps.com/start", Region: "us-east-1",
+				ClientName: DefaultClientName, Scopes: DefaultScopes, CacheDir: "/cache",
+			},
+		},
+		{
+			name: "configured",
+			session: awslib.SSOSession{
+				Name: "corp", StartURL: "https://corp.awsapps.com/start", Region: "eu-west-1",
+				RegistrationScopes: []string{"sso:account:access", "codewhisperer:completions", "sso:account:access"},
+				ClientName:         "corp-console",
+				RegistrationCache:  "~/corp/sso",
+			},
+			want: ClientConfig{
+				Session: "corp", StartURL: "https://corp.awsapps.com/start", Region: "eu-west-1",
+				ClientName: "corp-console", Scopes: []string{"codewhisperer:completions", "sso:account:access"},
+				CacheDir: filepath.Join("/home/tester", "corp", "sso"),
+			},
+		},
+		{
+			name:          "missing start url",
+			session:       awslib.SSOSession{Name: "corp", Region: "us-east-1"},
+			wantErrSubstr: `sso-session "corp" must set sso_start_url and sso_region`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewClientConfig(tc.session, "/cache")
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Session != tc.want.Session || got.StartURL != tc.want.StartURL || got.Region != tc.want.Region ||
+				got.ClientName != tc.want.ClientName || got.CacheDir != tc.want.CacheDir ||
+				strings.Join(got.Scopes, ",") != strings.Join(tc.want.Scopes, ",") {
+				t.Fatalf("unexpected config:\n got %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegistrarCachesRegistration(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	api := &fakeOIDC{expiresAt: now.Add(90 * 24 * time.Hour)}
+	registrar := newRegistrar(api, func() time.Time { return now })
+	cfg := ClientConfig{
+		Session: "corp", StartURL: "https://corp.awsapps.com/start", Region: "us-east-1",
+		ClientName: "corp-console", Scopes: []string{"sso:account:access"}, CacheDir: t.TempDir(),
+	}
+
+	reg, err := registrar.Register(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reg.ClientID != "client-id" || reg.ClientSecret != "client-secret" || !reg.ExpiresAt.Equal(api.expiresAt) {
+		t.Fatalf("unexpected registration: %+v", reg)
+	}
+	if aws.ToString(api.lastInput.ClientName) != "corp-console" || aws.ToString(api.lastInput.ClientType) != "public" ||
+		strings.Join(api.lastInput.Scopes, ",") != "sso:account:access" {
+		t.Fatalf("unexpected RegisterClient input: %+v", api.lastInput)
+	}
+
+	if _, err := registrar.Register(context.Background(), cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.calls != 1 {
+		t.Fatalf("expected cached registration to be reused, got %d calls", api.calls)
+	}
+
+	other := cfg
+	other.Scopes = []string{"codewhisperer:completions", "sso:account:access"}
+	if _, err := registrar.Register(context.Background(), other); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if api.calls != 2 {
+		t.Fatalf("expected different scopes to register a new client, got %d calls", api.calls)
+	}
+}
+
+func TestRegistrarRefreshesExpiringRegistration(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	api := &fakeOIDC{expiresAt: now.Add(10 * time.Minute)}
+	registrar := newRegistrar(api, func() time.Time { return now })
+	cfg := ClientConfig{Session: "corp", ClientName: DefaultClientName, Scopes: DefaultScopes, CacheDir: t.TempDir()}
+
+	for i := 0; i < 2; i++ {
+		if _, err := registrar.Register(context.Background(), cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if api.calls != 2 {
+		t.Fatalf("expected registration expiring within the refresh window to be replaced, got %d calls", api.calls)
+	}
+}
+
+func TestRegistrarRegisterError(t *testing.T) {
+	t.Parallel()
+
+	api := &fakeOIDC{err: errors.New("AccessDeniedException")}
+	registrar := newRegistrar(api, time.Now)
+
+	_, err := registrar.Register(context.Background(), ClientConfig{Session: "corp", ClientName: "corp-console"})
+	if err == nil || !strings.Contains(err.Error(), `failed to register OIDC client "corp-console" for sso-session "corp"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package telemetry exports the steps of a command as OpenTelemetry spans to an OTLP
+// collector, so platform teams can see how long signing in to the console takes across
+// their organization.
+//
+// Spans are sent over OTLP/HTTP with JSON encoding, which every OpenTelemetry
+// Collector accepts, so no SDK or gRPC stack is built into the binary.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TracesPath is the path of the OTLP/HTTP traces endpoint of a collector.
+const TracesPath = "/v1/traces"
+
+// scopeName is the instrumentation scope of every span.
+const scopeName = "github.com/eculver/aws-console"
+
+// Span is a timed step of a command.
+type Span struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+	// Attributes describe the step, such as the command it belongs to.
+	Attributes map[string]string
+	// Error, when set, marks the step as failed with a class of error, such as
+	// "expired". It is never an error message, which may name profiles and accounts.
+	Error string
+}
+
+// Exporter sends spans to the OTLP/HTTP collector at Endpoint.
+type Exporter struct {
+	// Endpoint is the collector's base URL, such as https://otel.example.com:4318, or
+	// its full traces URL ending in TracesPath.
+	Endpoint string
+	// Headers are added to each request, such as an Authorization header.
+	Headers map[string]string
+	// Resource describes what produced the spans, such as service.name and
+	// service.version.
+	Resource map[string]string
+	// HTTP sends the requests; nil uses http.DefaultClient.
+	HTTP *http.Client
+}
+
+// Export sends root and its children as one trace.
+func (e *Exporter) Export(ctx context.Context, root Span, children []Span) error {
+	traceID, err := randomID(16)
+	if err != nil {
+		return err
+	}
+	rootID, err := randomID(8)
+	if err != nil {
+		return err
+	}
+	spans := []span{newSpan(root, traceID, rootID, "")}
+	for _, child := range children {
+		id, err := randomID(8)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, newSpan(child, traceID, id, rootID))
+	}
+	body, err := json.Marshal(traces{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: attributes(e.Resource)},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: scopeName}, Spans: spans}},
+	}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tracesURL(e.Endpoint), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create the telemetry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	client := e.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send spans: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to send spans: the collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// tracesURL returns the traces URL of a collector endpoint.
+func tracesURL(endpoint string) string {
+	if strings.HasSuffix(endpoint, TracesPath) {
+		return endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/") + TracesPath
+}
+
+func randomID(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate a span ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// The OTLP/HTTP JSON encoding of an ExportTraceServiceRequest, with only the fields
+// aws-console sets. IDs are hex encoded and times are nanoseconds since the epoch.
+type traces struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []attribute `json:"attributes,omitempty"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []attribute `json:"attributes,omitempty"`
+	Status            status      `json:"status"`
+}
+
+type attribute struct {
+	Key   string `json:"key"`
+	Value value  `json:"value"`
+}
+
+type value struct {
+	StringValue string `json:"stringValue"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Span kinds and status codes of the OTLP protocol.
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+func newSpan(s Span, traceID, spanID, parentID string) span {
+	out := span{
+		TraceID:           traceID,
+		SpanID:            spanID,
+		ParentSpanID:      parentID,
+		Name:              s.Name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		Attributes:        attributes(s.Attributes),
+		Status:            status{Code: statusOK},
+	}
+	if s.Error != "" {
+		out.Status = status{Code: statusError, Message: s.Error}
+	}
+	return out
+}
+
+// attributes encodes m sorted by key, so requests are reproducible.
+func attributes(m map[string]string) []attribute {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	out := make([]attribute, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, attribute{Key: k, Value: value{StringValue: m[k]}})
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	root := Span{Name: "open-console", Start: start, End: start.Add(2 * time.Second), Error: "expired"}
+	children := []Span{
+		{Name: "config", Start: start, End: start.Add(10 * time.Millisecond)},
+		{Name: "sts", Start: start.Add(10 * time.Millisecond), End: start.Add(time.Second), Attributes: map[string]string{"step": "sts"}},
+	}
+
+	testCases := []struct {
+		name          string
+		endpoint      string
+		status        int
+		wantErrSubstr string
+	}{
+		{name: "base URL", endpoint: "", status: http.StatusOK},
+		{name: "traces URL", endpoint: TracesPath, status: http.StatusOK},
+		{name: "partial success", endpoint: "/", status: http.StatusAccepted},
+		{name: "error status", status: http.StatusUnauthorized, wantErrSubstr: "the collector answered 401 Unauthorized"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got traces
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != TracesPath {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("unexpected headers %v", r.Header)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				w.WriteHeader(tc.status)
+			}))
+			t.Cleanup(server.Close)
+
+			e := &Exporter{
+				Endpoint: server.URL + tc.endpoint,
+				Headers:  map[string]string{"Authorization": "Bearer token"},
+				Resource: map[string]string{"service.name": "aws-console", "os.type": "linux"},
+			}
+			err := e.Export(context.Background(), root, children)
+			if tc.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErrSubstr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 {
+				t.Fatalf("unexpected request %+v", got)
+			}
+			if attrs := got.ResourceSpans[0].Resource.Attributes; len(attrs) != 2 || attrs[0].Key != "os.type" || attrs[1].Value.StringValue != "aws-console" {
+				t.Fatalf("unexpected resource attributes %+v", attrs)
+			}
+			spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+			if len(spans) != 3 {
+				t.Fatalf("expected 3 spans, got %+v", spans)
+			}
+			parent := spans[0]
+			if parent.Name != "open-console" || parent.ParentSpanID != "" || len(parent.TraceID) != 32 || len(parent.SpanID) != 16 {
+				t.Fatalf("unexpected root span %+v", parent)
+			}
+			if parent.Status != (status{Code: statusError, Message: "expired"}) {
+				t.Fatalf("unexpected root status %+v", parent.Status)
+			}
+			sts := spans[2]
+			if sts.Name != "sts" || sts.TraceID != parent.TraceID || sts.ParentSpanID != parent.SpanID || sts.SpanID == parent.SpanID {
+				t.Fatalf("unexpected child span %+v", sts)
+			}
+			if sts.StartTimeUnixNano != "1893456000010000000" || sts.EndTimeUnixNano != "1893456001000000000" || sts.Status.Code != statusOK {
+				t.Fatalf("unexpected child span %+v", sts)
+			}
+			if len(sts.Attributes) != 1 || sts.Attributes[0] != (attribute{Key: "step", Value: value{StringValue: "sts"}}) {
+				t.Fatalf("unexpected child attributes %+v", sts.Attributes)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package term
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Info describes whether the standard streams are attached to a terminal. Commands
+// consult it instead of probing the streams themselves so that piped invocations
+// behave the same everywhere: no ANSI escape codes, spinners, or interactive prompts,
+// and URLs are printed rather than opened.
+type Info struct {
+	StdinTTY  bool
+	StdoutTTY bool
+	StderrTTY bool
+	// Term is the value of the TERM environment variable.
+	Term string
+	// LinkCapable reports whether the terminal emulator is known to render OSC 8 hyperlinks.
+	LinkCapable bool
+	// Headless reports whether there seems to be no display to open a browser on.
+	Headless bool
+	// NoColor reports whether colors are unwanted, as NO_COLOR asks.
+	NoColor bool
+	// Accessible asks for output a screen reader can follow: plain lines only, with no
+	// escape sequences, drawings such as QR codes, or screens that are redrawn.
+	Accessible bool
+}
+
+// Detect inspects the given streams. Streams that are not *os.File are treated as
+// non-terminals.
+func Detect(stdin, stdout, stderr any) Info {
+	return Info{
+		StdinTTY:    IsTerminal(stdin),
+		StdoutTTY:   IsTerminal(stdout),
+		StderrTTY:   IsTerminal(stderr),
+		Term:        os.Getenv("TERM"),
+		LinkCapable: SupportsHyperlinks(os.Getenv),
+		Headless:    Headless(runtime.GOOS, os.Getenv),
+		// Any value disables colors, as https://no-color.org specifies.
+		NoColor: os.Getenv("NO_COLOR") != "",
+	}
+}
+
+// IsTerminal reports whether stream is an *os.File connected to a character device.
+func IsTerminal(stream any) bool {
+	f, ok := stream.(*os.File)
+	if !ok || f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Interactive reports whether prompts can be shown and answered.
+func (i Info) Interactive() bool {
+	return i.StdinTTY && i.StdoutTTY
+}
+
+// ANSI reports whether escape sequences (colors, spinners, hyperlinks) may be written to stdout.
+func (i Info) ANSI() bool {
+	return i.StdoutTTY && i.Term != "dumb" && !i.Accessible
+}
+
+// Color reports whether colors may be written to stdout.
+func (i Info) Color() bool {
+	return i.ANSI() && !i.NoColor
+}
+
+// StderrColor reports whether colors may be written to stderr.
+func (i Info) StderrColor() bool {
+	return i.StderrTTY && i.Term != "dumb" && !i.Accessible && !i.NoColor
+}
+
+// Piped reports whether stdout is redirected to a file or another process.
+func (i Info) Piped() bool {
+	return !i.StdoutTTY
+}
+
+// Hyperlinks reports whether OSC 8 hyperlinks may be written to stdout.
+func (i Info) Hyperlinks() bool {
+	return i.ANSI() && i.LinkCapable
+}
+
+// SupportsHyperlinks guesses from the environment whether the terminal emulator renders
+// OSC 8 hyperlinks. There is no way to query this, so known emulators are matched by the
+// variables they export. FORCE_HYPERLINK=1 or 0 overrides the guess.
+func SupportsHyperlinks(getenv func(string) string) bool {
+	if force := getenv("FORCE_HYPERLINK"); force != "" {
+		enabled, err := strconv.ParseBool(force)
+		return err == nil && enabled
+	}
+
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	for _, name := range []string{"WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "DOMTERM"} {
+		if getenv(name) != "" {
+			return true
+		}
+	}
+	// VTE (GNOME Terminal, Tilix, ...) added support in 0.50.
+	if version, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && version >= 5000 {
+		return true
+	}
+
+	term := getenv("TERM")
+	return strings.HasPrefix(term, "xterm-kitty") || strings.HasPrefix(term, "alacritty") ||
+		strings.HasPrefix(term, "foot") || strings.HasPrefix(term, "xterm-ghostty")
+}
+
+// Headless guesses from the environment whether goos (a runtime.GOOS value) has no
+// display to open a browser on: in CI, in an SSH session to a Mac or Windows host, or
+// on other platforms when neither X11 nor Wayland is running, as in containers and SSH
+// sessions without X forwarding. A BROWSER variable, as set by editors that forward
+// URLs to the local machine, and WSL, which opens the Windows browser, count as a
+// display.
+func Headless(goos string, getenv func(string) string) bool {
+	if ci, err := strconv.ParseBool(getenv("CI")); err == nil && ci {
+		return true
+	}
+	if getenv("BROWSER") != "" {
+		return false
+	}
+	ssh := getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != ""
+	switch goos {
+	case "darwin", "windows":
+		return ssh
+	}
+	if getenv("WSL_DISTRO_NAME") != "" && !ssh {
+		return false
+	}
+	return getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == ""
+}
+
+// Hyperlink wraps label in an OSC 8 escape sequence pointing at target. Callers must
+// check Info.Hyperlinks first; terminals without support print the label only.
+func Hyperlink(target, label string) string {
+	return "\x1b]8;;" + target + "\x1b\\" + label + "\x1b]8;;\x1b\\"
+}
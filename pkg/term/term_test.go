@@ -0,0 +1,193 @@
+package term
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	defer f.Close()
+
+	if IsTerminal(f) {
+		t.Fatal("expected regular file not to be a terminal")
+	}
+	if IsTerminal(&bytes.Buffer{}) {
+		t.Fatal("expected buffer not to be a terminal")
+	}
+	if IsTerminal((*os.File)(nil)) {
+		t.Fatal("expected nil file not to be a terminal")
+	}
+}
+
+func TestInfo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		info            Info
+		wantInteractive bool
+		wantANSI        bool
+		wantColor       bool
+		wantStderrColor bool
+		wantPiped       bool
+	}{
+		{
+			name:            "full terminal",
+			info:            Info{StdinTTY: true, StdoutTTY: true, StderrTTY: true, Term: "xterm-256color"},
+			wantInteractive: true,
+			wantANSI:        true,
+			wantColor:       true,
+			wantStderrColor: true,
+		},
+		{
+			name:            "no color",
+			info:            Info{StdinTTY: true, StdoutTTY: true, Term: "xterm-256color", NoColor: true},
+			wantInteractive: true,
+			wantANSI:        true,
+		},
+		{
+			name:            "accessible",
+			info:            Info{StdinTTY: true, StdoutTTY: true, Term: "xterm-256color", Accessible: true},
+			wantInteractive: true,
+		},
+		{
+			name:            "stdout piped",
+			info:            Info{StdinTTY: true, StderrTTY: true, Term: "xterm-256color"},
+			wantStderrColor: true,
+			wantPiped:       true,
+		},
+		{
+			name:      "stdout piped without color",
+			info:      Info{StdinTTY: true, StderrTTY: true, Term: "xterm-256color", NoColor: true},
+			wantPiped: true,
+		},
+		{
+			name:      "stdin redirected",
+			info:      Info{StdoutTTY: true, Term: "xterm"},
+			wantANSI:  true,
+			wantColor: true,
+		},
+		{
+			name:            "dumb terminal",
+			info:            Info{StdinTTY: true, StdoutTTY: true, Term: "dumb"},
+			wantInteractive: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.info.Interactive(); got != tc.wantInteractive {
+				t.Fatalf("Interactive() = %v, want %v", got, tc.wantInteractive)
+			}
+			if got := tc.info.ANSI(); got != tc.wantANSI {
+				t.Fatalf("ANSI() = %v, want %v", got, tc.wantANSI)
+			}
+			if got := tc.info.Color(); got != tc.wantColor {
+				t.Fatalf("Color() = %v, want %v", got, tc.wantColor)
+			}
+			if got := tc.info.StderrColor(); got != tc.wantStderrColor {
+				t.Fatalf("StderrColor() = %v, want %v", got, tc.wantStderrColor)
+			}
+			if got := tc.info.Piped(); got != tc.wantPiped {
+				t.Fatalf("Piped() = %v, want %v", got, tc.wantPiped)
+			}
+		})
+	}
+}
+
+func TestSupportsHyperlinks(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{name: "unknown terminal", env: map[string]string{"TERM": "xterm-256color"}},
+		{name: "iterm", env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, want: true},
+		{name: "windows terminal", env: map[string]string{"WT_SESSION": "abc"}, want: true},
+		{name: "kitty term", env: map[string]string{"TERM": "xterm-kitty"}, want: true},
+		{name: "new vte", env: map[string]string{"VTE_VERSION": "6003"}, want: true},
+		{name: "old vte", env: map[string]string{"VTE_VERSION": "4803"}},
+		{name: "forced on", env: map[string]string{"FORCE_HYPERLINK": "1"}, want: true},
+		{name: "forced off", env: map[string]string{"FORCE_HYPERLINK": "0", "TERM_PROGRAM": "iTerm.app"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getenv := func(key string) string { return tc.env[key] }
+			if got := SupportsHyperlinks(getenv); got != tc.want {
+				t.Fatalf("SupportsHyperlinks() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHeadless(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want bool
+	}{
+		{name: "mac desktop", goos: "darwin"},
+		{name: "mac over ssh", goos: "darwin", env: map[string]string{"SSH_TTY": "/dev/ttys001"}, want: true},
+		{name: "windows over ssh", goos: "windows", env: map[string]string{"SSH_CONNECTION": "10.0.0.1 52000 10.0.0.2 22"}, want: true},
+		{name: "x11", goos: "linux", env: map[string]string{"DISPLAY": ":0"}},
+		{name: "wayland", goos: "linux", env: map[string]string{"WAYLAND_DISPLAY": "wayland-0"}},
+		{name: "x forwarding", goos: "linux", env: map[string]string{"DISPLAY": "localhost:10.0", "SSH_TTY": "/dev/pts/1"}},
+		{name: "container", goos: "linux", want: true},
+		{name: "ssh without a display", goos: "freebsd", env: map[string]string{"SSH_TTY": "/dev/pts/1"}, want: true},
+		{name: "wsl", goos: "linux", env: map[string]string{"WSL_DISTRO_NAME": "Ubuntu"}},
+		{name: "browser forwarded by an editor", goos: "linux", env: map[string]string{"BROWSER": "/vscode/bin/helpers/browser.sh"}},
+		{name: "ci", goos: "darwin", env: map[string]string{"CI": "true"}, want: true},
+		{name: "ci disabled", goos: "linux", env: map[string]string{"CI": "false", "DISPLAY": ":0"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			getenv := func(key string) string { return tc.env[key] }
+			if got := Headless(tc.goos, getenv); got != tc.want {
+				t.Fatalf("Headless() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHyperlinks(t *testing.T) {
+	t.Parallel()
+
+	if (Info{StdoutTTY: true, Term: "xterm"}).Hyperlinks() {
+		t.Fatal("expected hyperlinks to be disabled for an unknown emulator")
+	}
+	if (Info{Term: "xterm", LinkCapable: true}).Hyperlinks() {
+		t.Fatal("expected hyperlinks to be disabled when stdout is piped")
+	}
+	if !(Info{StdoutTTY: true, Term: "xterm", LinkCapable: true}).Hyperlinks() {
+		t.Fatal("expected hyperlinks to be enabled for a capable terminal")
+	}
+
+	want := "\x1b]8;;https://example.com\x1b\\label\x1b]8;;\x1b\\"
+	if got := Hyperlink("https://example.com", "label"); got != want {
+		t.Fatalf("Hyperlink() = %q, want %q", got, want)
+	}
+}
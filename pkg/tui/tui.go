@@ -0,0 +1,346 @@
+// Package tui implements the dashboard of 'aws-console tui': the configured profiles
+// with the status of their credentials, from which a console can be opened, its
+// sign-in URL copied, or an SSO login refreshed.
+//
+// The dashboard only draws and dispatches; the caller supplies the Actions that check
+// credentials and open consoles, typically with package console.
+package tui
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/prompt"
+)
+
+// Status is the state of a profile's credentials.
+type Status struct {
+	Account string
+	Role    string
+	// Expires is when the credentials expire; zero when they do not or it is unknown.
+	Expires time.Time
+	// Err is why the credentials could not be used, such as an expired SSO login.
+	Err error
+}
+
+// Actions are what the dashboard does for the selected profile. Open, Copy, and Login
+// run with the dashboard suspended, so they may write to the terminal and prompt, for
+// example for an MFA code or to approve an SSO login.
+type Actions struct {
+	// Status checks the credentials of profile without signing in.
+	Status func(ctx context.Context, profile awslib.Profile) Status
+	// Open opens the console for profile.
+	Open func(ctx context.Context, profile string) error
+	// Copy copies a console sign-in URL for profile to the clipboard.
+	Copy func(ctx context.Context, profile string) error
+	// Login refreshes the SSO login of profile.
+	Login func(ctx context.Context, profile string) error
+}
+
+// Model is the Bubble Tea model of the dashboard.
+type Model struct {
+	ctx      context.Context
+	actions  Actions
+	profiles []awslib.Profile
+	statuses map[string]Status
+	now      func() time.Time
+	cursor   int
+	// busy names the profile an action is running for; keys are ignored until it ends.
+	busy    string
+	message string
+	// exec runs an action with the terminal released.
+	exec func(run func() error, done tea.ExecCallback) tea.Cmd
+}
+
+// New returns a dashboard of profiles.
+func New(ctx context.Context, profiles []awslib.Profile, actions Actions) Model {
+	return Model{
+		ctx:      ctx,
+		actions:  actions,
+		profiles: profiles,
+		statuses: make(map[string]Status, len(profiles)),
+		now:      time.Now,
+		exec: func(run func() error, done tea.ExecCallback) tea.Cmd {
+			return tea.Exec(action(run), done)
+		},
+	}
+}
+
+// Run shows the dashboard of profiles on a terminal until the user quits.
+func Run(ctx context.Context, profiles []awslib.Profile, actions Actions, in io.Reader, out io.Writer) error {
+	program := tea.NewProgram(New(ctx, profiles, actions),
+		tea.WithContext(ctx), tea.WithInput(in), tea.WithOutput(out), tea.WithAltScreen())
+	_, err := program.Run()
+	return err
+}
+
+// plainActions are the actions RunPlain offers for a profile, in the order of Actions.
+var plainActions = []string{"Open the console", "Copy a sign-in URL", "Refresh the SSO login"}
+
+// RunPlain shows the dashboard as plain lines, for screen readers and terminals that
+// should not be redrawn: the profiles with the status of their credentials, then a
+// picker for a profile and one for what to do with it, until the profile picker is
+// canceled. Only the profile an action ran for is checked again.
+func RunPlain(ctx context.Context, profiles []awslib.Profile, actions Actions, picker prompt.Picker, out io.Writer) error {
+	m := New(ctx, profiles, actions)
+	if len(profiles) == 0 {
+		fmt.Fprintln(out, "No profiles found in AWS config.")
+		return nil
+	}
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Name
+		if actions.Status != nil {
+			m.statuses[p.Name] = actions.Status(ctx, p)
+		}
+	}
+
+	for {
+		for _, p := range profiles {
+			status, checked := m.statuses[p.Name]
+			fmt.Fprintln(out, m.describeLine(p, status, checked))
+		}
+		i, err := picker.Pick("profile", names)
+		if errors.Is(err, prompt.ErrCanceled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		j, err := picker.Pick("action", plainActions)
+		if errors.Is(err, prompt.ErrCanceled) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		profile := profiles[i]
+		action, success := m.action(j, profile.Name)
+		if action == nil {
+			continue
+		}
+		if err := action(ctx, profile.Name); err != nil {
+			fmt.Fprintf(out, "%s: %s\n", profile.Name, firstLine(err.Error()))
+		} else {
+			fmt.Fprintln(out, success)
+		}
+		if actions.Status != nil {
+			m.statuses[profile.Name] = actions.Status(ctx, profile)
+		}
+	}
+}
+
+// action returns the action of plainActions[i] for profile and what is reported when
+// it succeeds.
+func (m Model) action(i int, profile string) (func(context.Context, string) error, string) {
+	switch i {
+	case 0:
+		return m.actions.Open, "Opened the console for " + profile + "."
+	case 1:
+		return m.actions.Copy, "Copied a sign-in URL for " + profile + " to the clipboard."
+	default:
+		return m.actions.Login, "Refreshed the SSO login for " + profile + "."
+	}
+}
+
+// describeLine describes a profile and its status in a sentence, for RunPlain.
+func (m Model) describeLine(p awslib.Profile, s Status, checked bool) string {
+	parts := []string{}
+	if account := cmp.Or(s.Account, p.AccountID); account != "" {
+		parts = append(parts, "account "+account)
+	}
+	if role := cmp.Or(s.Role, p.RoleName); role != "" {
+		parts = append(parts, "role "+role)
+	}
+	if p.Source != "" {
+		parts = append(parts, "source "+p.Source)
+	}
+	parts = append(parts, m.describe(s, checked))
+	return p.Name + ": " + strings.Join(parts, ", ")
+}
+
+// statusMsg carries the checked credentials of a profile.
+type statusMsg struct {
+	profile string
+	status  Status
+}
+
+// doneMsg reports that an action for a profile ended.
+type doneMsg struct {
+	profile string
+	success string
+	err     error
+}
+
+// Init checks the credentials of every profile.
+func (m Model) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(m.profiles))
+	for _, p := range m.profiles {
+		cmds = append(cmds, m.check(p))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update handles keys and the results of checks and actions.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case statusMsg:
+		m.statuses[msg.profile] = msg.status
+		return m, nil
+	case doneMsg:
+		m.busy = ""
+		if msg.err != nil {
+			m.message = fmt.Sprintf("%s: %v", msg.profile, msg.err)
+		} else {
+			m.message = msg.success
+		}
+		// An action may have signed in, so the profile's status is likely stale.
+		if p, ok := m.profile(msg.profile); ok {
+			delete(m.statuses, p.Name)
+			return m, m.check(p)
+		}
+		return m, nil
+	case tea.KeyMsg:
+		return m.key(msg)
+	}
+	return m, nil
+}
+
+func (m Model) key(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q", "esc":
+		return m, tea.Quit
+	}
+	if m.busy != "" || len(m.profiles) == 0 {
+		return m, nil
+	}
+
+	selected := m.profiles[m.cursor].Name
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.profiles)-1 {
+			m.cursor++
+		}
+	case "enter":
+		return m.run(selected, 0)
+	case "c":
+		return m.run(selected, 1)
+	case "r":
+		return m.run(selected, 2)
+	}
+	return m, nil
+}
+
+// run starts the action of plainActions[i] for profile, reporting success when it ends
+// without an error.
+func (m Model) run(profile string, i int) (tea.Model, tea.Cmd) {
+	action, success := m.action(i, profile)
+	if action == nil {
+		return m, nil
+	}
+	m.busy = profile
+	m.message = ""
+	return m, m.exec(func() error { return action(m.ctx, profile) }, func(err error) tea.Msg {
+		return doneMsg{profile: profile, success: success, err: err}
+	})
+}
+
+// check returns a command that checks the credentials of p.
+func (m Model) check(p awslib.Profile) tea.Cmd {
+	if m.actions.Status == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return statusMsg{profile: p.Name, status: m.actions.Status(m.ctx, p)}
+	}
+}
+
+func (m Model) profile(name string) (awslib.Profile, bool) {
+	for _, p := range m.profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return awslib.Profile{}, false
+}
+
+// View renders the profile table, the key help, and the outcome of the last action.
+func (m Model) View() string {
+	var b strings.Builder
+	if len(m.profiles) == 0 {
+		b.WriteString("No profiles found in AWS config.\n")
+	} else {
+		w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "  PROFILE\tACCOUNT\tROLE\tSOURCE\tSTATUS")
+		for i, p := range m.profiles {
+			cursor := " "
+			if i == m.cursor {
+				cursor = ">"
+			}
+			status, checked := m.statuses[p.Name]
+			account, role := p.AccountID, p.RoleName
+			if status.Account != "" {
+				account = status.Account
+			}
+			if status.Role != "" {
+				role = status.Role
+			}
+			fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\n", cursor, p.Name, account, role, p.Source, m.describe(status, checked))
+		}
+		_ = w.Flush()
+	}
+
+	b.WriteString("\nenter open console · c copy sign-in URL · r refresh SSO login · q quit\n")
+	switch {
+	case m.busy != "":
+		fmt.Fprintf(&b, "Working on %s...\n", m.busy)
+	case m.message != "":
+		b.WriteString(firstLine(m.message) + "\n")
+	}
+	return b.String()
+}
+
+// describe summarizes a profile's status for the STATUS column.
+func (m Model) describe(s Status, checked bool) string {
+	switch {
+	case !checked:
+		return "checking..."
+	case s.Err != nil:
+		return "error: " + firstLine(s.Err.Error())
+	case s.Expires.IsZero():
+		return "ok"
+	}
+	left := s.Expires.Sub(m.now())
+	if left <= 0 {
+		return "expired"
+	}
+	return "ok, expires in " + left.Truncate(time.Minute).String()
+}
+
+// firstLine keeps messages, such as wrapped SDK errors, to a single line.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// action adapts an action to tea.ExecCommand. Actions use the terminal directly, so the
+// streams Bubble Tea offers are not needed.
+type action func() error
+
+func (a action) Run() error          { return a() }
+func (a action) SetStdin(io.Reader)  {}
+func (a action) SetStdout(io.Writer) {}
+func (a action) SetStderr(io.Writer) {}
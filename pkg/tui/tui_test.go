@@ -0,0 +1,296 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	awslib "github.com/eculver/aws-console/pkg/aws"
+	"github.com/eculver/aws-console/pkg/prompt"
+)
+
+var testNow = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func testProfiles() []awslib.Profile {
+	return []awslib.Profile{
+		{Name: "dev", Source: "sso", AccountID: "123456789012", RoleName: "Admin"},
+		{Name: "keys", Source: "static"},
+	}
+}
+
+// newTestModel returns a dashboard whose actions run synchronously and record the
+// profiles they were called with.
+func newTestModel(calls *[]string, actionErr error) Model {
+	record := func(name string) func(context.Context, string) error {
+		return func(_ context.Context, profile string) error {
+			*calls = append(*calls, name+" "+profile)
+			return actionErr
+		}
+	}
+	m := New(context.Background(), testProfiles(), Actions{
+		Status: func(_ context.Context, p awslib.Profile) Status {
+			if p.Name == "keys" {
+				return Status{Err: errors.New("credentials are not valid\nmore detail")}
+			}
+			return Status{Account: "123456789012", Role: "Admin", Expires: testNow.Add(90 * time.Minute)}
+		},
+		Open:  record("open"),
+		Copy:  record("copy"),
+		Login: record("login"),
+	})
+	m.now = func() time.Time { return testNow }
+	m.exec = func(run func() error, done tea.ExecCallback) tea.Cmd {
+		return func() tea.Msg { return done(run()) }
+	}
+	return m
+}
+
+// send delivers msg to m and then every message its commands produce, as the
+// program would, except that batches run in order.
+func send(t *testing.T, m Model, msg tea.Msg) Model {
+	t.Helper()
+	queue := []tea.Msg{msg}
+	for len(queue) > 0 {
+		msg, queue = queue[0], queue[1:]
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			for _, cmd := range batch {
+				if cmd != nil {
+					queue = append(queue, cmd())
+				}
+			}
+			continue
+		}
+		next, cmd := m.Update(msg)
+		m = next.(Model)
+		if cmd != nil {
+			queue = append(queue, cmd())
+		}
+	}
+	return m
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestModelView(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	m := newTestModel(&calls, nil)
+	if view := m.View(); strings.Count(view, "checking...") != 2 {
+		t.Fatalf("View() before the checks = %q, want both profiles checking", view)
+	}
+
+	m = send(t, m, m.Init()())
+	view := m.View()
+	for _, want := range []string{
+		"> dev",
+		"123456789012",
+		"ok, expires in 1h30m0s",
+		"  keys",
+		"error: credentials are not valid",
+		"enter open console · c copy sign-in URL · r refresh SSO login · q quit",
+	} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("View() = %q, want it to contain %q", view, want)
+		}
+	}
+	if strings.Contains(view, "more detail") {
+		t.Fatalf("View() = %q, want errors cut to one line", view)
+	}
+
+	m.now = func() time.Time { return testNow.Add(2 * time.Hour) }
+	if view := m.View(); !strings.Contains(view, "expired") {
+		t.Fatalf("View() after expiry = %q, want it to contain %q", view, "expired")
+	}
+}
+
+func TestModelViewNoProfiles(t *testing.T) {
+	t.Parallel()
+
+	m := New(context.Background(), nil, Actions{})
+	if view := m.View(); !strings.Contains(view, "No profiles found in AWS config.") {
+		t.Fatalf("View() = %q, want the empty message", view)
+	}
+	if _, cmd := m.Update(key("enter")); cmd != nil {
+		t.Fatal("enter with no profiles returned a command")
+	}
+}
+
+func TestModelActions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		keys        []string
+		actionErr   error
+		wantCalls   []string
+		wantMessage string
+	}{
+		{
+			name:        "enter opens the selected profile",
+			keys:        []string{"enter"},
+			wantCalls:   []string{"open dev"},
+			wantMessage: "Opened the console for dev.",
+		},
+		{
+			name:        "c copies a sign-in URL",
+			keys:        []string{"down", "c"},
+			wantCalls:   []string{"copy keys"},
+			wantMessage: "Copied a sign-in URL for keys to the clipboard.",
+		},
+		{
+			name:        "r refreshes the SSO login",
+			keys:        []string{"j", "k", "r"},
+			wantCalls:   []string{"login dev"},
+			wantMessage: "Refreshed the SSO login for dev.",
+		},
+		{
+			name:        "cursor stays in bounds",
+			keys:        []string{"up", "down", "down", "down", "enter"},
+			wantCalls:   []string{"open keys"},
+			wantMessage: "Opened the console for keys.",
+		},
+		{
+			name:        "failure is reported",
+			keys:        []string{"enter"},
+			actionErr:   errors.New("failed to build console URL: boom"),
+			wantCalls:   []string{"open dev"},
+			wantMessage: "dev: failed to build console URL: boom",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []string
+			m := newTestModel(&calls, tc.actionErr)
+			for _, k := range tc.keys {
+				m = send(t, m, key(k))
+			}
+
+			if strings.Join(calls, ",") != strings.Join(tc.wantCalls, ",") {
+				t.Fatalf("actions = %v, want %v", calls, tc.wantCalls)
+			}
+			if !strings.Contains(m.View(), tc.wantMessage) {
+				t.Fatalf("View() = %q, want it to contain %q", m.View(), tc.wantMessage)
+			}
+			if m.busy != "" {
+				t.Fatalf("busy = %q after the action ended", m.busy)
+			}
+		})
+	}
+}
+
+func TestModelIgnoresKeysWhileBusy(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	m := newTestModel(&calls, nil)
+	next, cmd := m.Update(key("enter"))
+	if cmd == nil {
+		t.Fatal("enter returned no command")
+	}
+	m = next.(Model)
+	if !strings.Contains(m.View(), "Working on dev...") {
+		t.Fatalf("View() = %q, want the busy message", m.View())
+	}
+
+	if _, cmd := m.Update(key("c")); cmd != nil {
+		t.Fatal("c while busy returned a command")
+	}
+	if _, cmd := m.Update(key("q")); cmd == nil || cmd() != tea.Quit() {
+		t.Fatal("q while busy did not quit")
+	}
+}
+
+func TestModelRechecksAfterAction(t *testing.T) {
+	t.Parallel()
+
+	checks := 0
+	m := New(context.Background(), testProfiles(), Actions{
+		Status: func(context.Context, awslib.Profile) Status {
+			checks++
+			return Status{}
+		},
+		Login: func(context.Context, string) error { return nil },
+	})
+	m.exec = func(run func() error, done tea.ExecCallback) tea.Cmd {
+		return func() tea.Msg { return done(run()) }
+	}
+
+	m = send(t, m, key("r"))
+	if checks != 1 {
+		t.Fatalf("Status called %d times, want 1 after the login", checks)
+	}
+	if view := m.View(); strings.Count(view, "checking...") != 1 {
+		t.Fatalf("View() = %q, want only the other profile unchecked", view)
+	}
+}
+
+func TestRunPlain(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	checks := 0
+	actions := Actions{
+		Status: func(_ context.Context, p awslib.Profile) Status {
+			checks++
+			if p.Name == "keys" {
+				return Status{Err: errors.New("credentials are not valid\nmore detail")}
+			}
+			return Status{Account: "123456789012", Role: "Admin"}
+		},
+		Open: func(_ context.Context, profile string) error {
+			calls = append(calls, "open "+profile)
+			return nil
+		},
+		Login: func(_ context.Context, profile string) error {
+			calls = append(calls, "login "+profile)
+			return errors.New("not an SSO profile")
+		},
+	}
+
+	out := &strings.Builder{}
+	// Open dev, refresh the login of keys, cancel an action, then quit.
+	input := "dev\n1\nkeys\n3\n1\n\n\n"
+	picker := prompt.NewLinePicker(strings.NewReader(input), out).Accessible()
+	if err := RunPlain(context.Background(), testProfiles(), actions, picker, out); err != nil {
+		t.Fatalf("RunPlain() error = %v", err)
+	}
+
+	if strings.Join(calls, ",") != "open dev,login keys" {
+		t.Fatalf("calls = %v", calls)
+	}
+	if checks != 4 {
+		t.Fatalf("checked %d times, want each profile once and again after each action", checks)
+	}
+	for _, want := range []string{
+		"dev: account 123456789012, role Admin, source sso, ok\n",
+		"keys: source static, error: credentials are not valid\n",
+		"Opened the console for dev.\n",
+		"keys: not an SSO profile\n",
+		"1 of 3: Open the console\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out.String(), "\x1b") {
+		t.Fatalf("output holds escape sequences: %q", out)
+	}
+}
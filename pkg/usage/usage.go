@@ -0,0 +1,107 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/eculver/aws-console/pkg/paths"
+)
+
+// FileName is the name of the usage file within the state directory.
+const FileName = "usage.json"
+
+// Entry tracks how often and how recently a profile was opened.
+type Entry struct {
+	LastUsed time.Time `json:"last_used"`
+	Count    int       `json:"count"`
+}
+
+// Store persists per-profile usage data in a JSON file.
+type Store struct {
+	path string
+	// mu serializes updates, so concurrent records are not lost.
+	mu sync.Mutex
+}
+
+// NewStore creates a store backed by usage.json in the aws-console state directory.
+func NewStore() *Store {
+	dir, err := paths.StateDir()
+	if err != nil {
+		return NewStoreAt("")
+	}
+	return NewStoreAt(filepath.Join(dir, FileName))
+}
+
+// NewStoreAt creates a store backed by the given file. An empty path disables persistence.
+func NewStoreAt(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the backing file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load returns the usage entries keyed by profile name. A missing file yields an empty map.
+func (s *Store) Load() (map[string]Entry, error) {
+	entries := map[string]Entry{}
+	if s.path == "" {
+		return entries, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("failed to read usage data: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse usage data %s: %w", s.path, err)
+	}
+	return entries, nil
+}
+
+// Record marks the profile as used at the given time.
+func (s *Store) Record(profile string, at time.Time) error {
+	if s.path == "" || profile == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	entry := entries[profile]
+	entry.LastUsed = at.UTC()
+	entry.Count++
+	entries[profile] = entry
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage data: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write usage data: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write usage data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreRecordAndLoad(t *testing.T) {
+	t.Parallel()
+
+	store := NewStoreAt(filepath.Join(t.TempDir(), "nested", "usage.json"))
+
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error loading missing file: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries, got %+v", entries)
+	}
+
+	first := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	if err := store.Record("dev", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Record("dev", second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Record("prod", first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err = store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := entries["dev"]; got.Count != 2 || !got.LastUsed.Equal(second) {
+		t.Fatalf("unexpected dev entry: %+v", got)
+	}
+	if got := entries["prod"]; got.Count != 1 || !got.LastUsed.Equal(first) {
+		t.Fatalf("unexpected prod entry: %+v", got)
+	}
+}
+
+func TestStoreRecordIgnoresEmptyProfileAndPath(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "usage.json")
+	if err := NewStoreAt(path).Record("", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no file to be written, got %v", err)
+	}
+	if err := NewStoreAt("").Record("dev", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStoreLoadCorrupt(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "usage.json")
+	if err := os.WriteFile(path, []byte("{not-json"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	_, err := NewStoreAt(path).Load()
+	if err == nil || !strings.Contains(err.Error(), "failed to parse usage data") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}